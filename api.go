@@ -3,9 +3,14 @@ package smr
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path"
 	"runtime"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -15,6 +20,7 @@ import (
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/felixge/fgprof"
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo-contrib/pprof"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -22,6 +28,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"github.com/swaggo/swag"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
@@ -39,6 +46,8 @@ import (
 	"github.com/satisfactorymodding/smr-api/db/postgres"
 	"github.com/satisfactorymodding/smr-api/generated"
 	"github.com/satisfactorymodding/smr-api/gql"
+	"github.com/satisfactorymodding/smr-api/health"
+	"github.com/satisfactorymodding/smr-api/metrics"
 	"github.com/satisfactorymodding/smr-api/migrations"
 	"github.com/satisfactorymodding/smr-api/nodes"
 	"github.com/satisfactorymodding/smr-api/oauth"
@@ -46,6 +55,8 @@ import (
 	"github.com/satisfactorymodding/smr-api/redis/jobs"
 	"github.com/satisfactorymodding/smr-api/storage"
 	"github.com/satisfactorymodding/smr-api/util"
+	"github.com/satisfactorymodding/smr-api/util/geoip"
+	"github.com/satisfactorymodding/smr-api/util/openapi"
 	"github.com/satisfactorymodding/smr-api/validation"
 
 	// Load REST docs
@@ -73,19 +84,32 @@ func Initialize(baseCtx context.Context) context.Context {
 	redis.InitializeRedis(ctx)
 	postgres.InitializePostgres(ctx)
 	storage.InitializeStorage(ctx)
+	storage.InitializeMirrors(ctx)
+	storage.InitializeColdStorage(ctx)
+	storage.InitializeQuarantineStorage(ctx)
 	oauth.InitializeOAuth()
 	util.InitializeSecurity()
 	validation.InitializeValidator()
 	auth.InitializeAuth()
 	jobs.InitializeJobs(ctx)
 	validation.InitializeVirusTotal()
+	geoip.InitializeGeoIP()
 	util.PrintFeatureFlags()
 
 	return ctx
 }
 
 func Migrate(ctx context.Context) {
-	migrations.RunMigrations(ctx)
+	if err := migrations.Up(ctx); err != nil {
+		log.Fatal().Err(err).Msg("failed to migrate database")
+	}
+}
+
+// VerifySchema refuses to continue unless the database is at the schema this binary was built against
+func VerifySchema(ctx context.Context) {
+	if err := migrations.Verify(ctx); err != nil {
+		log.Fatal().Err(err).Msg("database schema does not match this build")
+	}
 }
 
 var e *echo.Echo
@@ -105,6 +129,19 @@ func Setup(ctx context.Context) {
 	}
 
 	db.RunAsyncStatisticLoop(ctx)
+	postgres.RunAsyncReplicaLagLoop(ctx)
+	storage.RunAsyncMultipartCleanupLoop(ctx)
+	storage.RunAsyncMirrorHealthLoop(ctx)
+	jobs.RunAsyncStorageGCLoop(ctx)
+	jobs.RunAsyncColdStorageLoop(ctx)
+	jobs.RunAsyncIntegrityAuditLoop(ctx)
+	jobs.RunAsyncRetentionLoop(ctx)
+	jobs.RunAsyncPurgeLoop(ctx)
+	jobs.RunAsyncTrendingLoop(ctx)
+	jobs.RunAsyncSiteStatsLoop(ctx)
+	jobs.RunAsyncSitemapLoop(ctx)
+	jobs.RunAsyncSyncPruneLoop(ctx)
+	jobs.RunAsyncDownloadRollupLoop(ctx)
 
 	dataValidator := validator.New()
 
@@ -116,24 +153,22 @@ func Setup(ctx context.Context) {
 
 	e.Static("/static", "static")
 
-	v1 := e.Group("/v1")
-
-	v1.Use(func(handlerFunc echo.HandlerFunc) echo.HandlerFunc {
+	restFacade := func(handlerFunc echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
 			newLogger := log.Ctx(ctx.Request().Context()).With().Str("facade", "REST").Logger()
 			newCtx := newLogger.WithContext(context.Background())
 			ctx.SetRequest(ctx.Request().WithContext(newCtx))
 			return handlerFunc(ctx)
 		}
-	})
+	}
 
-	nodes.RegisterOAuthRoutes(v1.Group("/oauth"))
-	nodes.RegisterUserRoutes(v1.Group("/user"))
-	nodes.RegisterUsersRoutes(v1.Group("/users"))
-	nodes.RegisterModRoutes(v1.Group("/mod"))
-	nodes.RegisterModsRoutes(v1.Group("/mods"))
-	nodes.RegisterVersionRoutes(v1.Group("/version"))
-	nodes.RegisterSMLRoutes(v1.Group("/sml"))
+	// Every resource gets its own group, as the GraphQL API is served below /v2 as well
+	for _, version := range nodes.APIVersions {
+		prefix := version.Prefix()
+		nodes.RegisterRoutes(func(resource string) *echo.Group {
+			return e.Group(prefix+resource, restFacade, nodes.RateLimit, nodes.ReadRouting)
+		}, version)
+	}
 
 	v2 := e.Group("/v2")
 
@@ -155,6 +190,7 @@ func Setup(ctx context.Context) {
 	schema := generated.NewExecutableSchema(generated.Config{
 		Resolvers:  &gql.Resolver{},
 		Directives: gql.MakeDirective(),
+		Complexity: gql.MakeComplexity(),
 	})
 
 	v2Query := v2.Group("/query")
@@ -170,12 +206,33 @@ func Setup(ctx context.Context) {
 		}
 	})
 
+	v2Query.Use(nodes.GraphQLRateLimit)
 	v2Query.Use(dataloader.Middleware())
 
 	gqlHandler := handler.New(schema)
 
+	gqlHandler.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			// Same as the CORS policy, any origin is allowed
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+		},
+		InitFunc: func(ctx context.Context, initPayload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+			// Browsers cannot set headers on websocket connections, so the token is sent in the init payload instead
+			if authorization := initPayload.Authorization(); authorization != "" {
+				header := ctx.Value(util.ContextHeader{}).(http.Header).Clone()
+				header.Set("Authorization", authorization)
+				ctx = context.WithValue(ctx, util.ContextHeader{}, header)
+			}
+			return ctx, nil, nil
+		},
+	})
 	gqlHandler.AddTransport(transport.Options{})
 	gqlHandler.AddTransport(transport.GET{})
+	// Checked before POST, so queries with @defer fragments asking for an event stream get the deferred payloads
+	gqlHandler.AddTransport(transport.SSE{})
 	gqlHandler.AddTransport(transport.POST{})
 	gqlHandler.AddTransport(transport.MultipartForm{
 		MaxUploadSize: 100 << 20,
@@ -183,10 +240,21 @@ func Setup(ctx context.Context) {
 	})
 
 	gqlHandler.SetQueryCache(lru.New(5000))
+	gqlHandler.SetErrorPresenter(gql.ErrorPresenter)
+
+	gqlHandler.Use(&gql.SchemaVisibility{})
+	gqlHandler.Use(&gql.QueryLimit{})
+	gqlHandler.Use(gql.FieldUsageTracker{})
+	gqlHandler.Use(gql.ReadRouting{})
 
-	gqlHandler.Use(extension.Introspection{})
+	persistedQueries := redis.PersistedQueryCache{
+		TTL: viper.GetDuration("graphql.persisted_queries.ttl"),
+	}
+	gqlHandler.Use(gql.PersistedQueryAllowlist{
+		Cache: persistedQueries,
+	})
 	gqlHandler.Use(extension.AutomaticPersistedQuery{
-		Cache: lru.New(5000),
+		Cache: persistedQueries,
 	})
 
 	v2Query.Any("", echo.WrapHandler(gqlHandler))
@@ -196,7 +264,33 @@ func Setup(ctx context.Context) {
 		return nil
 	})
 
+	e.GET("/healthz", health.Liveness)
+	e.GET("/readyz", health.Readiness)
+	e.GET("/metrics", metrics.Handler)
+
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
+	e.GET("/openapi.json", openAPIDocument)
+
+	// The local storage driver generates links to files served by the API itself
+	if viper.GetString("storage.type") == "local" {
+		// Quarantined files are never served, even if they share the storage path
+		files := e.Group("/storage", func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				name, err := url.PathUnescape(c.Param("*"))
+				if err != nil {
+					return echo.ErrNotFound
+				}
+
+				name = path.Clean("/" + name)
+				if name == "/quarantine" || storage.IsQuarantined(name) {
+					return echo.ErrNotFound
+				}
+
+				return next(c)
+			}
+		})
+		files.Static("/", viper.GetString("storage.path"))
+	}
 
 	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -216,11 +310,19 @@ func Setup(ctx context.Context) {
 		}
 	})
 
+	if level := viper.GetInt("compression_level"); level != 0 {
+		e.Use(middleware.GzipWithConfig(middleware.GzipConfig{
+			Skipper: skipCompression,
+			Level:   level,
+		}))
+	}
+
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		Skipper:          middleware.DefaultSkipper,
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{echo.GET, echo.HEAD, echo.PUT, echo.PATCH, echo.POST, echo.DELETE},
 		AllowCredentials: true,
+		ExposeHeaders:    []string{"X-Query-Cost", "X-Query-Cost-Limit", "X-Query-Depth", "X-Query-Depth-Limit", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", "X-SMR-Hash"},
 	}))
 
 	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
@@ -280,6 +382,53 @@ func Setup(ctx context.Context) {
 	}()
 }
 
+// skipCompression limits compression to the JSON responses of the REST and GraphQL APIs.
+// Files are already compressed, and proxied downloads have to keep serving byte ranges of the original file.
+func skipCompression(c echo.Context) bool {
+	if c.IsWebSocket() {
+		return true
+	}
+
+	route := c.Path()
+	if !strings.HasPrefix(route, "/v1") && !strings.HasPrefix(route, "/v2") && route != "/openapi.json" {
+		return true
+	}
+
+	for _, binary := range []string{"/download", "/torrent", "/metalink", "/patch/"} {
+		if strings.Contains(route, binary) && !strings.HasSuffix(route, "/download-token") {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  []byte
+	openAPIErr  error
+)
+
+// openAPIDocument serves the REST docs as OpenAPI 3.0, converted once from the generated swagger document
+func openAPIDocument(c echo.Context) error {
+	openAPIOnce.Do(func() {
+		doc, err := swag.ReadDoc()
+		if err != nil {
+			openAPIErr = err
+			return
+		}
+
+		openAPIDoc, openAPIErr = openapi.Convert([]byte(doc))
+	})
+
+	if openAPIErr != nil {
+		log.Err(openAPIErr).Msg("failed to build openapi document")
+		return c.String(500, "failed to build openapi document")
+	}
+
+	return c.Blob(200, echo.MIMEApplicationJSONCharsetUTF8, openAPIDoc)
+}
+
 func Serve() {
 	address := fmt.Sprintf(":%d", viper.GetInt("port"))
 	log.Info().Str("address", address).Msg("starting server")
@@ -321,9 +470,13 @@ func newResource() *resource.Resource {
 	return r
 }
 
-func Start() {
+// Start serves the API, applying the pending migrations first if migrate is set
+func Start(migrate bool) {
 	ctx := Initialize(context.Background())
-	Migrate(ctx)
+	if migrate {
+		Migrate(ctx)
+	}
+	VerifySchema(ctx)
 	Setup(ctx)
 	Serve()
 }
@@ -52,6 +52,14 @@ var (
 		ID:          "10",
 		Description: "Allows user to edit any mod's compatibility info",
 	}
+	RoleViewFieldUsage = &Role{
+		ID:          "11",
+		Description: "Allows user to view the usage of the API schema",
+	}
+	RoleViewAuditLogs = &Role{
+		ID:          "12",
+		Description: "Allows user to view the audit log",
+	}
 )
 
 var (
@@ -69,6 +77,8 @@ var (
 			RoleEditAnnouncements,
 			RoleManageTags,
 			RoleEditAnyModCompatibility,
+			RoleViewFieldUsage,
+			RoleViewAuditLogs,
 		},
 	}
 	GroupModerator = &Group{
@@ -80,6 +90,7 @@ var (
 			RoleEditAnnouncements,
 			RoleManageTags,
 			RoleEditAnyModCompatibility,
+			RoleViewAuditLogs,
 		},
 	}
 	GroupSMLDev = &Group{
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/satisfactorymodding/smr-api"
+	"github.com/satisfactorymodding/smr-api/migrations"
+)
+
+// migrate manages the database schema without serving, with the up, down and status subcommands
+func migrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal().Msg("expected a migrate subcommand: up, down or status")
+	}
+
+	subcommand, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("migrate "+subcommand, flag.ExitOnError)
+	steps := flags.Int("steps", 1, "amount of schema migrations to revert")
+	_ = flags.Parse(args)
+
+	ctx := smr.Initialize(context.Background())
+
+	switch subcommand {
+	case "up":
+		smr.Migrate(ctx)
+	case "down":
+		if err := migrations.Down(ctx, *steps); err != nil {
+			log.Fatal().Err(err).Msg("failed to revert migrations")
+		}
+		log.Info().Int("steps", *steps).Msg("Migrations reverted")
+	case "status":
+		status, err := migrations.GetStatus(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to get migration status")
+		}
+
+		log.Info().
+			Uint("version", status.Version).
+			Uint("latest", status.Latest).
+			Bool("dirty", status.Dirty).
+			Strs("pending", status.Pending).
+			Strs("pending_code", status.PendingCode).
+			Strs("drift", status.Drift).
+			Msg("Migration status")
+
+		if !status.UpToDate() {
+			os.Exit(1)
+		}
+	default:
+		log.Fatal().Str("subcommand", subcommand).Msg("unknown migrate subcommand, expected up, down or status")
+	}
+}
@@ -1,6 +1,13 @@
 package main
 
-import "github.com/satisfactorymodding/smr-api"
+import (
+	"flag"
+	"os"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/satisfactorymodding/smr-api"
+)
 
 // @title Satisfactory Mod Repo API
 // @version 1
@@ -10,7 +17,29 @@ import "github.com/satisfactorymodding/smr-api"
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 
 // @host api.ficsit.app
-// @BasePath /v1
+// @BasePath /
 func main() {
-	smr.Start()
+	command := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		serve(args)
+	case "migrate":
+		migrate(args)
+	default:
+		log.Fatal().Str("command", command).Msg("unknown command, expected serve or migrate")
+	}
+}
+
+// serve starts the API, which refuses to start unless the database schema matches this build
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	runMigrations := flags.Bool("migrate", false, "apply the pending migrations before serving")
+	_ = flags.Parse(args)
+
+	smr.Start(*runMigrations)
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/satisfactorymodding/smr-api"
+	"github.com/satisfactorymodding/smr-api/seed"
+)
+
+// Seeds the configured database with fake content for local development
+func main() {
+	users := flag.Int("users", 25, "amount of users to create, including the admin")
+	mods := flag.Int("mods", 60, "amount of mods to create, besides the mod loader")
+	versions := flag.Int("versions", 4, "amount of versions to create per mod")
+	randomSeed := flag.Int64("seed", 1, "seed of the generated content")
+	withFiles := flag.Bool("files", true, "upload stub archives of the versions to the storage")
+	force := flag.Bool("force", false, "seed even if the database already contains mods")
+	flag.Parse()
+
+	if *users < 1 {
+		log.Fatal().Msg("at least the admin user has to be created")
+	}
+
+	ctx := smr.Initialize(context.Background())
+	smr.Migrate(ctx)
+
+	result, err := seed.Run(ctx, seed.Options{
+		Users:          *users,
+		Mods:           *mods,
+		VersionsPerMod: *versions,
+		RandomSeed:     *randomSeed,
+		WithFiles:      *withFiles,
+		Force:          *force,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to seed database")
+	}
+
+	log.Info().
+		Int("users", result.Users).
+		Int("mods", result.Mods).
+		Int("versions", result.Versions).
+		Msg("Database seeded")
+
+	log.Info().Str("token", result.AdminToken).Msg("Admin session, send it in the Authorization header")
+}
@@ -12,10 +12,19 @@ func main() {
 		return
 	}
 
-	f, _ := os.ReadFile(os.Args[1])
+	f, err := os.Open(os.Args[1])
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		panic(err)
+	}
 
 	validation.InitializeValidator()
-	_, err := validation.ExtractModInfo(context.Background(), f, true, true, "N/A")
+	_, err = validation.ExtractModInfo(context.Background(), f, stat.Size(), true, true, "N/A")
 	if err != nil {
 		panic(err)
 	}
@@ -58,6 +58,10 @@ func initializeDefaults() {
 
 	viper.SetDefault("production", true)
 	viper.SetDefault("profiler", false)
+	viper.SetDefault("metrics.token", "")
+
+	// Gzip level of API responses, between 1 and 9, or -1 for the default level. 0 disables compression
+	viper.SetDefault("compression_level", -1)
 
 	viper.SetDefault("database.redis.host", "localhost")
 	viper.SetDefault("database.redis.port", 6379)
@@ -65,12 +69,29 @@ func initializeDefaults() {
 	viper.SetDefault("database.redis.db", 1)
 	viper.SetDefault("database.redis.job_db", 2)
 
+	// Jobs which must not run twice at once hold a redis lock for at most dedupe_ttl, after which a duplicate may run
+	viper.SetDefault("jobs.dedupe_ttl", time.Hour)
+
 	viper.SetDefault("database.postgres.host", "localhost")
 	viper.SetDefault("database.postgres.port", 5432)
 	viper.SetDefault("database.postgres.user", "postgres")
 	viper.SetDefault("database.postgres.pass", "REPLACE_ME")
 	viper.SetDefault("database.postgres.db", "postgres")
 
+	// Connection pool of the primary and the replica, durations of 0 never close connections.
+	// Pool statistics are published at /metrics, which requires metrics.token as bearer token when it is set.
+	viper.SetDefault("database.postgres.pool.max_open", 50)
+	viper.SetDefault("database.postgres.pool.max_idle", 10)
+	viper.SetDefault("database.postgres.pool.max_lifetime", time.Minute*30)
+	viper.SetDefault("database.postgres.pool.max_idle_time", time.Minute*5)
+
+	// Heavy reads go to the replica at replica.host, which connects with the settings of the primary unless overridden.
+	// Callers read from the primary for replica.sticky after writing, and everyone does while the replica lags more than max_lag.
+	viper.SetDefault("database.postgres.replica.host", "")
+	viper.SetDefault("database.postgres.replica.max_lag", time.Second*5)
+	viper.SetDefault("database.postgres.replica.lag_interval", time.Second*5)
+	viper.SetDefault("database.postgres.replica.sticky", time.Second*10)
+
 	viper.SetDefault("storage.type", "s3")
 	viper.SetDefault("storage.bucket", "smr")
 	viper.SetDefault("storage.key", "REPLACE_ME_KEY")
@@ -79,6 +100,40 @@ func initializeDefaults() {
 	viper.SetDefault("storage.region", "eu-central-1")
 	viper.SetDefault("storage.base_url", "http://localhost:9000")
 	viper.SetDefault("storage.keypath", "%s/file/%s/%s")
+	// Root directory of the local storage driver
+	viper.SetDefault("storage.path", "./storage-data")
+	viper.SetDefault("storage.multipart_upload_ttl", time.Hour)
+	viper.SetDefault("storage.presign_ttl", time.Hour)
+	// Store separated target archives once per content hash
+	viper.SetDefault("storage.dedup", true)
+	// Download link signing, either "" (disabled), "cloudfront" or "hmac"
+	viper.SetDefault("storage.cdn.signing", "")
+	viper.SetDefault("storage.cdn.ttl", time.Hour)
+	viper.SetDefault("storage.cdn.key_pair_id", "")
+	viper.SetDefault("storage.cdn.private_key", "")
+	viper.SetDefault("storage.cdn.secret", "")
+	// Secondary buckets version files are replicated to, see storage.MirrorConfig
+	viper.SetDefault("storage.mirrors", []interface{}{})
+	viper.SetDefault("storage.mirror_region_header", "CF-IPCountry")
+	viper.SetDefault("storage.mirror_health_interval", time.Minute)
+	// CSV database of IP ranges used to locate clients the region header is missing for
+	viper.SetDefault("geoip.database", "")
+
+	// Mod logos are also stored resized to fit each of these sizes
+	viper.SetDefault("images.logo_sizes", []int{64, 128, 256, 512})
+	viper.SetDefault("storage.gc.enabled", false)
+	viper.SetDefault("storage.gc.dry_run", true)
+	viper.SetDefault("storage.gc.interval", time.Hour*24)
+	viper.SetDefault("storage.gc.grace_period", time.Hour*48)
+	viper.SetDefault("storage.audit.enabled", false)
+	viper.SetDefault("storage.audit.interval", time.Hour*24)
+	viper.SetDefault("storage.audit.sample_size", 100)
+	// Cheaper storage stale versions are moved to, configured like a mirror
+	viper.SetDefault("storage.cold.enabled", false)
+	viper.SetDefault("storage.cold.after", time.Hour*24*180)
+	viper.SetDefault("storage.cold.interval", time.Hour*24)
+	viper.SetDefault("storage.cold.batch_size", 500)
+	viper.SetDefault("storage.cold.retry_after", time.Minute)
 
 	viper.SetDefault("oauth.github.client_id", "")
 	viper.SetDefault("oauth.github.client_secret", "")
@@ -93,6 +148,52 @@ func initializeDefaults() {
 	viper.SetDefault("paseto.private_key", "")
 
 	viper.SetDefault("discord.webhook_url", "")
+	viper.SetDefault("discord.alerts_webhook_url", "")
+
+	viper.SetDefault("mail.from", "noreply@ficsit.app")
+	viper.SetDefault("mail.smtp.host", "")
+	viper.SetDefault("mail.smtp.port", 587)
+	viper.SetDefault("mail.smtp.username", "")
+	viper.SetDefault("mail.smtp.password", "")
+
+	// Versions never approved are deleted after unapproved_days, authors are warned notify_days before
+	viper.SetDefault("retention.enabled", false)
+	viper.SetDefault("retention.unapproved_days", 30)
+	viper.SetDefault("retention.notify_days", 7)
+	viper.SetDefault("retention.interval", time.Hour*24)
+	viper.SetDefault("retention.batch_size", 500)
+
+	// Deleted versions can be restored by their authors for restore_days, and by moderators until they are purged.
+	// Deleted content is purged with its files after deleted_days, or never if 0, every interval in batches of batch_size.
+	viper.SetDefault("retention.restore_days", 7)
+	viper.SetDefault("retention.deleted_days", 0)
+
+	// Authors can edit their comments for edit_window after posting them
+	viper.SetDefault("comments.edit_window", time.Minute*15)
+
+	viper.SetDefault("trending.enabled", true)
+	viper.SetDefault("trending.interval", time.Hour)
+
+	// Authors are active if they published an approved version within active_author_window
+	viper.SetDefault("stats.interval", time.Minute*15)
+	viper.SetDefault("stats.active_author_window", time.Hour*24*90)
+
+	// Sitemaps and feeds link to the public pages below site.url
+	viper.SetDefault("site.url", "https://ficsit.app")
+
+	// Sitemaps are served from sitemap.url
+	viper.SetDefault("sitemap.interval", time.Hour)
+	viper.SetDefault("sitemap.page_size", 10000)
+	viper.SetDefault("sitemap.url", "https://api.ficsit.app/v1/sitemap")
+
+	viper.SetDefault("feeds.limit", 50)
+	viper.SetDefault("feeds.cache_ttl", time.Minute*5)
+
+	// Changes are synced once older than settle_delay, and kept for retention
+	viper.SetDefault("sync.limit", 1000)
+	viper.SetDefault("sync.settle_delay", time.Second*30)
+	viper.SetDefault("sync.retention", time.Hour*24*30)
+	viper.SetDefault("sync.prune_interval", time.Hour*6)
 
 	viper.SetDefault("discourse.url", "")
 	viper.SetDefault("discourse.sso_secret", "")
@@ -101,6 +202,110 @@ func initializeDefaults() {
 
 	viper.SetDefault("virustotal.key", "")
 
+	// Checks of /readyz which take longer than the timeout fail. Virus total is checked at most once per interval
+	viper.SetDefault("health.timeout", time.Second*5)
+	viper.SetDefault("health.virustotal_interval", time.Minute*10)
+
+	// Budgets of a GraphQL operation, a user gets the largest budget of their groups (graphql.limits.groups.<group name>).
+	// A limit of 0 means unlimited
+	viper.SetDefault("graphql.limits.anonymous.complexity", 15000)
+	viper.SetDefault("graphql.limits.anonymous.depth", 15)
+	viper.SetDefault("graphql.limits.user.complexity", 25000)
+	viper.SetDefault("graphql.limits.user.depth", 15)
+
+	// Total complexity the operations of a user, or of an address for anonymous requests, can reach within the window.
+	// A budget of 0 disables the limit
+	viper.SetDefault("graphql.limits.window", time.Minute)
+	viper.SetDefault("graphql.limits.anonymous.window_budget", 0)
+	viper.SetDefault("graphql.limits.user.window_budget", 0)
+
+	// Authenticated users can always introspect the schema, restricted to the fields their roles give access to
+	viper.SetDefault("graphql.introspection.anonymous", true)
+
+	// If allowlist_only is set, unauthenticated requests can only run queries which are already persisted
+	viper.SetDefault("graphql.persisted_queries.ttl", time.Hour*24*7)
+	viper.SetDefault("graphql.persisted_queries.allowlist_only", false)
+
+	// Share of the operations of which the used fields are recorded, per client for retention_days
+	viper.SetDefault("graphql.usage.sample_rate", 0.05)
+	viper.SetDefault("graphql.usage.retention_days", 30)
+
+	// Limits are in bytes, 0 means unlimited
+	viper.SetDefault("quota.default_tier", "default")
+	viper.SetDefault("quota.tiers.default.max_file_size", 1000000000)
+	viper.SetDefault("quota.tiers.default.max_mod_storage", 0)
+
+	viper.SetDefault("blueprints.max_file_size", 50000000)
+
+	// Per user, a limit of 0 disables the rate limit
+	viper.SetDefault("ratelimit.create_version.limit", 20)
+	viper.SetDefault("ratelimit.create_version.window", time.Hour)
+	viper.SetDefault("ratelimit.finalize_version.limit", 20)
+	viper.SetDefault("ratelimit.finalize_version.window", time.Hour)
+	viper.SetDefault("ratelimit.create_collection.limit", 10)
+	viper.SetDefault("ratelimit.create_collection.window", time.Hour)
+	viper.SetDefault("ratelimit.create_comment.limit", 20)
+	viper.SetDefault("ratelimit.create_comment.window", time.Minute*10)
+	viper.SetDefault("ratelimit.report_content.limit", 20)
+	viper.SetDefault("ratelimit.report_content.window", time.Hour)
+	viper.SetDefault("ratelimit.register_download.limit", 200)
+	viper.SetDefault("ratelimit.register_download.window", time.Hour)
+
+	// Requests to the REST and GraphQL APIs, per user or per address for anonymous requests
+	viper.SetDefault("ratelimit.requests.limit", 0)
+	viper.SetDefault("ratelimit.requests.window", time.Minute)
+	viper.SetDefault("ratelimit.user_requests.limit", 0)
+	viper.SetDefault("ratelimit.user_requests.window", time.Minute)
+
+	// Webhooks are deactivated after max_failures failed deliveries in a row, 0 never deactivates them
+	viper.SetDefault("webhooks.max_per_user", 10)
+	viper.SetDefault("webhooks.max_mods", 50)
+	viper.SetDefault("webhooks.max_failures", 50)
+	viper.SetDefault("webhooks.timeout", time.Second*10)
+	viper.SetDefault("webhooks.allow_http", false)
+	viper.SetDefault("webhooks.allow_private", false)
+
+	// How long a download registered by an install is remembered, so retries are not counted again
+	viper.SetDefault("downloads.registration_window", time.Hour*24*7)
+
+	// Downloads are recorded as events, which are added to the download counters every rollup_interval once older than settle_delay.
+	// Events are partitioned by month, partitions are created partitions_ahead months in advance
+	// and dropped after retention_months, or never if 0.
+	viper.SetDefault("downloads.rollup_interval", time.Minute)
+	viper.SetDefault("downloads.settle_delay", time.Second*10)
+	viper.SetDefault("downloads.partitions_ahead", 2)
+	viper.SetDefault("downloads.retention_months", 0)
+
+	// Patches larger than max_ratio of the full target file are discarded
+	viper.SetDefault("patches.enabled", true)
+	viper.SetDefault("patches.max_ratio", 0.5)
+
+	// Stream downloads through the API instead of redirecting to storage
+	viper.SetDefault("downloads.proxy", false)
+	// Single use download tokens, if required the direct download endpoints are disabled
+	viper.SetDefault("downloads.tokens.ttl", time.Minute*5)
+	viper.SetDefault("downloads.tokens.required", false)
+	viper.SetDefault("downloads.tokens.bind_ip", true)
+
+	viper.SetDefault("torrents.enabled", false)
+	viper.SetDefault("torrents.min_size", 256*1024*1024)
+	viper.SetDefault("torrents.trackers", []string{})
+
+	viper.SetDefault("validation.max_archive_size", 1000000000)
+	viper.SetDefault("validation.file_blacklist", []string{".exe", ".bat", ".cmd", ".ps1", ".vbs", ".msi", ".scr"})
+	// Either "fail" the upload, or "flag" it for moderators
+	viper.SetDefault("validation.denylist.action", "fail")
+	viper.SetDefault("validation.denylist.extensions", []string{".bat", ".cmd", ".ps1", ".vbs", ".sh"})
+	viper.SetDefault("validation.denylist.binary_extensions", []string{".dll", ".so"})
+	viper.SetDefault("validation.denylist.binary_directories", []string{"Binaries"})
+	viper.SetDefault("validation.denylist.hashes", []string{})
+	viper.SetDefault("validation.rules.archive-size", true)
+	viper.SetDefault("validation.rules.data-json", true)
+	viper.SetDefault("validation.rules.uplugin", true)
+	viper.SetDefault("validation.rules.targets", true)
+	viper.SetDefault("validation.rules.file-blacklist", true)
+	viper.SetDefault("validation.rules.content-denylist", true)
+
 	viper.SetDefault("feature_flags.allow_multi_target_upload", false)
 
 	viper.SetDefault("extractor_host", "localhost:50051")
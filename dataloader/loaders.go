@@ -40,9 +40,7 @@ func Middleware() func(handlerFunc echo.HandlerFunc) echo.HandlerFunc {
 							}
 						}
 
-						var entities []postgres.VersionDependency
-						reqCtx := c.Request().Context()
-						postgres.DBCtx(reqCtx).Where("version_id IN ?", fetchIds).Find(&entities)
+						entities := postgres.GetVersionDependenciesByVersionID(c.Request().Context(), fetchIds)
 
 						for _, entity := range entities {
 							byID[entity.VersionID] = append(byID[entity.VersionID], entity)
@@ -71,9 +69,7 @@ func Middleware() func(handlerFunc echo.HandlerFunc) echo.HandlerFunc {
 							}
 						}
 
-						var entities []postgres.UserMod
-						reqCtx := c.Request().Context()
-						postgres.DBCtx(reqCtx).Where("mod_id IN ?", fetchIds).Find(&entities)
+						entities := postgres.GetModAuthorsByModID(c.Request().Context(), fetchIds)
 
 						for _, entity := range entities {
 							byID[entity.ModID] = append(byID[entity.ModID], entity)
@@ -107,9 +103,7 @@ func Middleware() func(handlerFunc echo.HandlerFunc) echo.HandlerFunc {
 							}
 						}
 
-						var entities []postgres.Version
-						reqCtx := c.Request().Context()
-						postgres.DBCtx(reqCtx).Preload("Targets").Where("approved = ? AND denied = ? AND mod_id IN ?", true, false, fetchIds).Order("created_at desc").Find(&entities)
+						entities := postgres.GetApprovedVersionsByModID(c.Request().Context(), fetchIds)
 
 						for _, entity := range entities {
 							byID[entity.ModID] = append(byID[entity.ModID], entity)
@@ -143,30 +137,7 @@ func Middleware() func(handlerFunc echo.HandlerFunc) echo.HandlerFunc {
 							}
 						}
 
-						var entities []postgres.Version
-						reqCtx := c.Request().Context()
-						postgres.DBCtx(reqCtx).Preload("Targets").Select(
-							"id",
-							"created_at",
-							"updated_at",
-							"deleted_at",
-							"mod_id",
-							"version",
-							"sml_version",
-							"changelog",
-							"downloads",
-							"key",
-							"stability",
-							"approved",
-							"hotness",
-							"denied",
-							"mod_reference",
-							"version_major",
-							"version_minor",
-							"version_patch",
-							"size",
-							"hash",
-						).Where("approved = ? AND denied = ? AND mod_id IN ?", true, false, fetchIds).Order("created_at desc").Find(&entities)
+						entities := postgres.GetApprovedVersionsByModIDNoMeta(c.Request().Context(), fetchIds)
 
 						for _, entity := range entities {
 							byID[entity.ModID] = append(byID[entity.ModID], entity)
@@ -200,9 +171,7 @@ func Middleware() func(handlerFunc echo.HandlerFunc) echo.HandlerFunc {
 							}
 						}
 
-						var entities []postgres.User
-						reqCtx := c.Request().Context()
-						postgres.DBCtx(reqCtx).Where("id IN ?", fetchIds).Find(&entities)
+						entities := postgres.GetExistingUsersByID(c.Request().Context(), fetchIds)
 
 						for _, entity := range entities {
 							tempEntity := entity
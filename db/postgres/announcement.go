@@ -2,16 +2,55 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 
+	"github.com/satisfactorymodding/smr-api/db/postgres/queries/announcements"
 	"github.com/satisfactorymodding/smr-api/util"
 )
 
+// announcementQueries runs the generated announcement queries on the connection of the context,
+// so they take part in its transaction if there is one
+func announcementQueries(ctx context.Context) *announcements.Queries {
+	return announcements.New(DBCtx(ctx).Statement.ConnPool)
+}
+
+func announcementFromRow(row announcements.Announcement) Announcement {
+	return Announcement{
+		SMRModel: SMRModel{
+			ID:       row.ID,
+			SMRDates: rowDates(row.CreatedAt, row.UpdatedAt, row.DeletedAt),
+		},
+		Message:    row.Message,
+		Importance: row.Importance,
+	}
+}
+
+func announcementsFromRows(rows []announcements.Announcement) []Announcement {
+	announcements := make([]Announcement, len(rows))
+	for i, row := range rows {
+		announcements[i] = announcementFromRow(row)
+	}
+	return announcements
+}
+
 func CreateAnnouncement(ctx context.Context, announcement *Announcement) (*Announcement, error) {
-	announcement.ID = util.GenerateUniqueID()
-	DBCtx(ctx).Create(&announcement)
-	return announcement, nil
+	row, err := announcementQueries(ctx).CreateAnnouncement(ctx, announcements.CreateAnnouncementParams{
+		ID:         util.GenerateUniqueID(),
+		Message:    announcement.Message,
+		Importance: announcement.Importance,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create announcement")
+	}
+
+	ClearCache()
+
+	created := announcementFromRow(row)
+	return &created, nil
 }
 
 func GetAnnouncementByID(ctx context.Context, announcementID string) *Announcement {
@@ -21,13 +60,16 @@ func GetAnnouncementByID(ctx context.Context, announcementID string) *Announceme
 		return announcement.(*Announcement)
 	}
 
-	var announcement Announcement
-	DBCtx(ctx).Find(&announcement, "id = ?", announcementID)
-
-	if announcement.ID == "" {
+	row, err := announcementQueries(ctx).GetAnnouncementByID(ctx, announcementID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Err(err).Str("id", announcementID).Msg("failed to get announcement")
+		}
 		return nil
 	}
 
+	announcement := announcementFromRow(row)
+
 	dbCache.Set(cacheKey, &announcement, cache.DefaultExpiration)
 
 	return &announcement
@@ -40,8 +82,13 @@ func GetAnnouncements(ctx context.Context) []Announcement {
 		return announcements.([]Announcement)
 	}
 
-	var announcements []Announcement
-	DBCtx(ctx).Find(&announcements)
+	rows, err := announcementQueries(ctx).ListAnnouncements(ctx)
+	if err != nil {
+		log.Err(err).Msg("failed to list announcements")
+		return nil
+	}
+
+	announcements := announcementsFromRows(rows)
 
 	dbCache.Set(cacheKey, announcements, cache.DefaultExpiration)
 
@@ -55,10 +102,45 @@ func GetAnnouncementsByImportance(ctx context.Context, importance string) []Anno
 		return announcements.([]Announcement)
 	}
 
-	var announcements []Announcement
-	DBCtx(ctx).Find(&announcements, "importance = ?", importance)
+	rows, err := announcementQueries(ctx).ListAnnouncementsByImportance(ctx, importance)
+	if err != nil {
+		log.Err(err).Str("importance", importance).Msg("failed to list announcements")
+		return nil
+	}
+
+	announcements := announcementsFromRows(rows)
 
 	dbCache.Set(cacheKey, announcements, cache.DefaultExpiration)
 
 	return announcements
 }
+
+// UpdateAnnouncement saves the message and importance of the announcement, returning nil if it no longer exists
+func UpdateAnnouncement(ctx context.Context, announcement *Announcement) (*Announcement, error) {
+	row, err := announcementQueries(ctx).UpdateAnnouncement(ctx, announcements.UpdateAnnouncementParams{
+		ID:         announcement.ID,
+		Message:    announcement.Message,
+		Importance: announcement.Importance,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to update announcement")
+	}
+
+	ClearCache()
+
+	updated := announcementFromRow(row)
+	return &updated, nil
+}
+
+func DeleteAnnouncement(ctx context.Context, announcementID string) error {
+	if err := announcementQueries(ctx).DeleteAnnouncement(ctx, announcementID); err != nil {
+		return errors.Wrap(err, "failed to delete announcement")
+	}
+
+	ClearCache()
+
+	return nil
+}
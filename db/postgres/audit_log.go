@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/satisfactorymodding/smr-api/util"
+	"github.com/satisfactorymodding/smr-api/util/diff"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditActionModTransferRequested = "mod_transfer_requested"
+	AuditActionModTransferAccepted  = "mod_transfer_accepted"
+	AuditActionModTransferDeclined  = "mod_transfer_declined"
+	AuditActionModTransferCancelled = "mod_transfer_cancelled"
+	AuditActionModArchived          = "mod_archived"
+	AuditActionModUnarchived        = "mod_unarchived"
+	AuditActionModHidden            = "mod_hidden"
+	AuditActionModDeleted           = "mod_deleted"
+	AuditActionModApproved          = "mod_approved"
+	AuditActionModDenied            = "mod_denied"
+	AuditActionModEdited            = "mod_edited"
+	AuditActionVersionApproved      = "version_approved"
+	AuditActionVersionDenied        = "version_denied"
+	AuditActionVersionDeleted       = "version_deleted"
+	AuditActionVersionEdited        = "version_edited"
+	AuditActionCommentDeleted       = "comment_deleted"
+	AuditActionUserEdited           = "user_edited"
+	AuditActionUserGroupsChanged    = "user_groups_changed"
+	AuditActionGuideEdited          = "guide_edited"
+	AuditActionGuideDeleted         = "guide_deleted"
+	AuditActionBlueprintEdited      = "blueprint_edited"
+	AuditActionBlueprintDeleted     = "blueprint_deleted"
+	AuditActionTagDeleted           = "tag_deleted"
+)
+
+// Fields which change with every edit, and are left out of the recorded changes
+var auditIgnoredFields = []string{"UpdatedAt"}
+
+// NewAuditLog builds an audit log entry for an action performed by a user, or by the system if userID is empty
+func NewAuditLog(userID string, action string, targetType string, targetID string, data map[string]interface{}) *AuditLog {
+	entry := &AuditLog{
+		ID:         util.GenerateUniqueID(),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Data:       data,
+	}
+
+	if userID != "" {
+		entry.UserID = &userID
+	}
+
+	return entry
+}
+
+// WithChanges records the fields which differ between two snapshots of the target, taken with diff.Snapshot
+func (entry *AuditLog) WithChanges(before map[string]interface{}, after map[string]interface{}) *AuditLog {
+	entry.Changes = diff.Changes(before, after, auditIgnoredFields...)
+	return entry
+}
+
+// RecordAuditLog stores an entry, along with the IP of the request it was made in
+func RecordAuditLog(ctx context.Context, entry *AuditLog) {
+	if entry.IP == nil {
+		if ip := util.RequestIP(ctx); ip != "" {
+			entry.IP = &ip
+		}
+	}
+
+	DBCtx(ctx).Create(entry)
+}
+
+// RecordAuditChanges stores an entry for an edit, unless the edit changed nothing
+func RecordAuditChanges(ctx context.Context, entry *AuditLog, before map[string]interface{}, after map[string]interface{}) {
+	if len(entry.WithChanges(before, after).Changes) == 0 {
+		return
+	}
+
+	RecordAuditLog(ctx, entry)
+}
+
+func GetAuditLogsByTarget(ctx context.Context, targetType string, targetID string) []AuditLog {
+	var entries []AuditLog
+	DBCtx(ctx).Order("created_at asc").Find(&entries, "target_type = ? AND target_id = ?", targetType, targetID)
+	return entries
+}
+
+// AuditLogFilter restricts the audit log entries to the ones matching every field set
+type AuditLogFilter struct {
+	UserID     *string
+	Action     *string
+	TargetType *string
+	TargetID   *string
+	Since      *time.Time
+	Until      *time.Time
+}
+
+func auditLogQuery(ctx context.Context, filter AuditLogFilter) *gorm.DB {
+	query := DBCtx(ctx).Model(AuditLog{})
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+
+	if filter.Action != nil {
+		query = query.Where("action = ?", *filter.Action)
+	}
+
+	if filter.TargetType != nil {
+		query = query.Where("target_type = ?", *filter.TargetType)
+	}
+
+	if filter.TargetID != nil {
+		query = query.Where("target_id = ?", *filter.TargetID)
+	}
+
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+
+	return query
+}
+
+// GetAuditLogs returns the entries matching the filter, newest first
+func GetAuditLogs(ctx context.Context, filter AuditLogFilter, limit int, offset int) []AuditLog {
+	var entries []AuditLog
+	auditLogQuery(ctx, filter).
+		Order("created_at desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&entries)
+	return entries
+}
+
+func GetAuditLogCount(ctx context.Context, filter AuditLogFilter) int64 {
+	var count int64
+	auditLogQuery(ctx, filter).Count(&count)
+	return count
+}
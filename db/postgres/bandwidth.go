@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// AddVersionBandwidth accounts for bytes served for a version on the current day
+func AddVersionBandwidth(ctx context.Context, modID string, versionID string, bytes int64) {
+	DBCtx(ctx).Exec(`INSERT INTO version_bandwidth (version_id, day, mod_id, bytes, requests) VALUES (?, current_date, ?, ?, 1)
+		ON CONFLICT (version_id, day) DO UPDATE SET bytes = version_bandwidth.bytes + excluded.bytes, requests = version_bandwidth.requests + 1`,
+		versionID, modID, bytes)
+}
+
+// VersionBandwidthTotal is the bandwidth of a version summed over a period
+type VersionBandwidthTotal struct {
+	VersionID string
+	Bytes     int64
+	Requests  int
+}
+
+// GetModBandwidthByVersion returns the bandwidth served for every version of a mod since the provided day
+func GetModBandwidthByVersion(ctx context.Context, modID string, since time.Time) []VersionBandwidthTotal {
+	var totals []VersionBandwidthTotal
+	DBCtx(ctx).Model(VersionBandwidth{}).
+		Select("version_id, sum(bytes) as bytes, sum(requests) as requests").
+		Where("mod_id = ? AND day >= ?", modID, since).
+		Group("version_id").
+		Order("bytes desc").
+		Scan(&totals)
+	return totals
+}
+
+// DailyBandwidthTotal is the bandwidth of a mod summed over a day
+type DailyBandwidthTotal struct {
+	Day      time.Time
+	Bytes    int64
+	Requests int
+}
+
+// GetModBandwidthByDay returns the bandwidth served for a mod on every day since the provided day
+func GetModBandwidthByDay(ctx context.Context, modID string, since time.Time) []DailyBandwidthTotal {
+	var totals []DailyBandwidthTotal
+	DBCtx(ctx).Model(VersionBandwidth{}).
+		Select("day, sum(bytes) as bytes, sum(requests) as requests").
+		Where("mod_id = ? AND day >= ?", modID, since).
+		Group("day").
+		Order("day asc").
+		Scan(&totals)
+	return totals
+}
+
+// DownloadStatTotal is the amount of downloads of a version target summed over a bucket
+type DownloadStatTotal struct {
+	Bucket     time.Time
+	VersionID  string
+	Version    string
+	TargetName string
+	Downloads  int
+}
+
+// GetModDownloadStats returns the downloads of every version target of a mod between the provided days,
+// bucketed by the start of the day, week or month they happened in
+func GetModDownloadStats(ctx context.Context, modID string, granularity string, from time.Time, to time.Time) []DownloadStatTotal {
+	var totals []DownloadStatTotal
+	ReadDBCtx(ctx).Table("version_download_stats AS stats").
+		Select("date_trunc(?, stats.day) as bucket, stats.version_id, coalesce(versions.version, '') as version, stats.target_name, sum(stats.downloads) as downloads", granularity).
+		Joins("LEFT JOIN versions ON versions.id = stats.version_id").
+		Where("stats.mod_id = ? AND stats.day >= ? AND stats.day <= ?", modID, from, to).
+		Group("bucket, stats.version_id, versions.version, stats.target_name").
+		Order("bucket asc").
+		Scan(&totals)
+	return totals
+}
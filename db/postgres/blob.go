@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// AcquireBlob records a new reference to a blob, creating it if it does not exist yet
+func AcquireBlob(ctx context.Context, hash string, key string, size int64) {
+	DBCtx(ctx).Exec(`INSERT INTO blobs (hash, key, size, ref_count, created_at) VALUES (?, ?, ?, 1, ?)
+		ON CONFLICT (hash) DO UPDATE SET ref_count = blobs.ref_count + 1`, hash, key, size, time.Now())
+}
+
+// ReleaseBlob removes a reference to a blob, and returns whether it was the last one.
+// The blob row is deleted along with its last reference, the caller is responsible for deleting the file.
+func ReleaseBlob(ctx context.Context, hash string) bool {
+	var remaining []int
+	DBCtx(ctx).Raw("UPDATE blobs SET ref_count = ref_count - 1 WHERE hash = ? RETURNING ref_count", hash).Scan(&remaining)
+
+	if len(remaining) == 0 {
+		// Unknown blobs are not referenced by anything else
+		return true
+	}
+
+	if remaining[0] > 0 {
+		return false
+	}
+
+	DBCtx(ctx).Where("hash = ? AND ref_count <= 0", hash).Delete(&Blob{})
+	return true
+}
+
+// IsBlobReferenced returns whether any version holds a reference to the blob
+func IsBlobReferenced(ctx context.Context, hash string) bool {
+	var referenced bool
+	DBCtx(ctx).Raw("SELECT EXISTS (SELECT 1 FROM blobs WHERE hash = ? AND ref_count > 0)", hash).Scan(&referenced)
+	return referenced
+}
+
+// IsBlobQuarantined returns whether the blob is held in quarantine, which makes it unavailable to every version sharing it.
+// A blob is quarantined while a version referencing it is, unless an approved version outside of quarantine references it too,
+// as its content was then already scanned or approved.
+func IsBlobQuarantined(ctx context.Context, key string) bool {
+	var quarantined bool
+	DBCtx(ctx).Raw(`SELECT EXISTS (SELECT 1 FROM version_targets vt JOIN versions v ON v.id = vt.version_id
+		WHERE vt.key = ? AND v.quarantined AND v.deleted_at IS NULL)
+		AND NOT EXISTS (SELECT 1 FROM version_targets vt JOIN versions v ON v.id = vt.version_id
+		WHERE vt.key = ? AND v.approved AND NOT v.quarantined AND v.deleted_at IS NULL)`, key, key).Scan(&quarantined)
+	return quarantined
+}
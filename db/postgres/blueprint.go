@@ -0,0 +1,206 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+
+	"github.com/satisfactorymodding/smr-api/models"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+func CreateBlueprint(ctx context.Context, blueprint *Blueprint) (*Blueprint, error) {
+	// Allow only 8 new blueprints per 24h
+
+	blueprint.ID = util.GenerateUniqueID()
+
+	var blueprints []Blueprint
+	DBCtx(ctx).Order("created_at asc").Find(&blueprints, "user_id = ? AND created_at > ?", blueprint.UserID, time.Now().Add(time.Hour*24*-1))
+
+	currentAvailable := float64(8)
+	lastBlueprintTime := time.Now()
+	for _, blueprint := range blueprints {
+		currentAvailable--
+		if blueprint.CreatedAt.After(lastBlueprintTime) {
+			diff := blueprint.CreatedAt.Sub(lastBlueprintTime)
+			currentAvailable = math.Min(8, currentAvailable+diff.Hours()/3)
+		}
+		lastBlueprintTime = blueprint.CreatedAt
+	}
+
+	if currentAvailable < 1 {
+		timeToWait := time.Until(lastBlueprintTime.Add(time.Hour * 6)).Minutes()
+		return nil, fmt.Errorf("please wait %.0f minutes to post another blueprint", timeToWait)
+	}
+
+	DBCtx(ctx).Create(&blueprint)
+
+	return blueprint, nil
+}
+
+func GetBlueprintByID(ctx context.Context, blueprintID string) *Blueprint {
+	cacheKey := "GetBlueprintById_" + blueprintID
+
+	if blueprint, ok := dbCache.Get(cacheKey); ok {
+		return blueprint.(*Blueprint)
+	}
+
+	return GetBlueprintByIDNoCache(ctx, blueprintID)
+}
+
+func GetBlueprintByIDNoCache(ctx context.Context, blueprintID string) *Blueprint {
+	var blueprint Blueprint
+	DBCtx(ctx).Preload("Tags").Find(&blueprint, "id = ?", blueprintID)
+
+	if blueprint.ID == "" {
+		return nil
+	}
+
+	cacheKey := "GetBlueprintById_" + blueprintID
+	dbCache.Set(cacheKey, &blueprint, cache.DefaultExpiration)
+
+	return &blueprint
+}
+
+func GetBlueprints(ctx context.Context, filter *models.BlueprintFilter) []Blueprint {
+	hash, err := filter.Hash()
+	cacheKey := ""
+	if err == nil {
+		cacheKey = "GetBlueprints_" + hash
+		if blueprints, ok := dbCache.Get(cacheKey); ok {
+			return blueprints.([]Blueprint)
+		}
+	}
+
+	var blueprints []Blueprint
+	query := ReadDBCtx(ctx).Preload("Tags")
+
+	if filter != nil {
+		query = query.Limit(*filter.Limit).
+			Offset(*filter.Offset)
+
+		query = orderAfterCursor(query, "blueprints", string(*filter.OrderBy), *filter.Order, filter.Cursor)
+
+		if filter.Search != nil && *filter.Search != "" {
+			query = query.Where("to_tsvector(name) @@ to_tsquery(?)", strings.ReplaceAll(*filter.Search, " ", " & "))
+		}
+
+		if filter.TagIDs != nil && len(filter.TagIDs) > 0 {
+			query.Joins("INNER JOIN blueprint_tags on blueprint_tags.tag_id in ? AND blueprint_tags.blueprint_id = blueprints.id", filter.TagIDs)
+		}
+	}
+
+	query.Find(&blueprints)
+
+	if cacheKey != "" {
+		dbCache.Set(cacheKey, blueprints, cache.DefaultExpiration)
+	}
+
+	return blueprints
+}
+
+func GetBlueprintsByID(ctx context.Context, blueprintIds []string) []Blueprint {
+	cacheKey := "GetBlueprintsById_" + strings.Join(blueprintIds, ":")
+
+	if blueprints, ok := dbCache.Get(cacheKey); ok {
+		return blueprints.([]Blueprint)
+	}
+
+	var blueprints []Blueprint
+	DBCtx(ctx).Preload("Tags").Find(&blueprints, "id in (?)", blueprintIds)
+
+	if len(blueprintIds) != len(blueprints) {
+		return nil
+	}
+
+	dbCache.Set(cacheKey, blueprints, cache.DefaultExpiration)
+
+	return blueprints
+}
+
+func GetBlueprintCount(ctx context.Context, filter *models.BlueprintFilter) int64 {
+	hash, err := filter.Hash()
+	cacheKey := ""
+	if err == nil {
+		cacheKey = "GetBlueprintCount_" + hash
+		if count, ok := dbCache.Get(cacheKey); ok {
+			return count.(int64)
+		}
+	}
+
+	var blueprintCount int64
+	query := ReadDBCtx(ctx).Model(Blueprint{})
+
+	if filter != nil {
+		if filter.Search != nil && *filter.Search != "" {
+			query = query.Where("to_tsvector(name) @@ to_tsquery(?)", strings.ReplaceAll(*filter.Search, " ", " & "))
+		}
+
+		if filter.TagIDs != nil && len(filter.TagIDs) > 0 {
+			query.Joins("INNER JOIN blueprint_tags on blueprint_tags.tag_id in ? AND blueprint_tags.blueprint_id = blueprints.id", filter.TagIDs)
+		}
+	}
+
+	query.Count(&blueprintCount)
+
+	if cacheKey != "" {
+		dbCache.Set(cacheKey, blueprintCount, cache.DefaultExpiration)
+	}
+
+	return blueprintCount
+}
+
+func IncrementBlueprintViews(ctx context.Context, blueprint *Blueprint) {
+	DBCtx(ctx).Model(blueprint).Update("views", blueprint.Views+1)
+}
+
+func IncrementBlueprintDownloads(ctx context.Context, blueprint *Blueprint) {
+	DBCtx(ctx).Model(blueprint).Update("downloads", blueprint.Downloads+1)
+}
+
+func GetUserBlueprints(ctx context.Context, userID string) []Blueprint {
+	var blueprints []Blueprint
+	DBCtx(ctx).Preload("Tags").Find(&blueprints, "user_id = ?", userID)
+	return blueprints
+}
+
+func ClearBlueprintTags(ctx context.Context, blueprintID string) error {
+	r := DBCtx(ctx).Where("blueprint_id = ?", blueprintID).Delete(&BlueprintTag{})
+	return r.Error
+}
+
+func SetBlueprintTags(ctx context.Context, blueprintID string, tagIDs []string) error {
+	for _, tag := range tagIDs {
+		err := AddBlueprintTag(ctx, blueprintID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ResetBlueprintTags(ctx context.Context, blueprintID string, tagIDs []string) error {
+	err := ClearBlueprintTags(ctx, blueprintID)
+	if err != nil {
+		return err
+	}
+	err = SetBlueprintTags(ctx, blueprintID, tagIDs)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func AddBlueprintTag(ctx context.Context, blueprintID string, tagID string) error {
+	r := DBCtx(ctx).Create(&BlueprintTag{BlueprintID: blueprintID, TagID: tagID})
+	return r.Error
+}
+
+func RemoveBlueprintTag(ctx context.Context, blueprintID string, tagID string) error {
+	r := DBCtx(ctx).Delete(&BlueprintTag{BlueprintID: blueprintID, TagID: tagID})
+	return r.Error
+}
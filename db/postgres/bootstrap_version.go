@@ -33,8 +33,9 @@ func GetBootstrapVersions(ctx context.Context, filter *models.BootstrapVersionFi
 
 	if filter != nil {
 		query = query.Limit(*filter.Limit).
-			Offset(*filter.Offset).
-			Order(string(*filter.OrderBy) + " " + string(*filter.Order))
+			Offset(*filter.Offset)
+
+		query = orderAfterCursor(query, "bootstrap_versions", string(*filter.OrderBy), *filter.Order, filter.Cursor)
 
 		if filter.Search != nil && *filter.Search != "" {
 			query = query.Where("to_tsvector(name) @@ to_tsquery(?)", strings.ReplaceAll(*filter.Search, " ", " & "))
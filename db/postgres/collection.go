@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// Length of the slugs collections are shared with
+const collectionSlugLength = 8
+
+// CreateCollection stores a new collection with its mods, under a newly generated slug
+func CreateCollection(ctx context.Context, collection *Collection) (*Collection, error) {
+	collection.ID = util.GenerateUniqueID()
+
+	for {
+		collection.Slug = util.RandomString(collectionSlugLength)
+		if GetCollectionBySlug(ctx, collection.Slug) == nil {
+			break
+		}
+	}
+
+	mods := collection.Mods
+	collection.Mods = nil
+
+	err := DBCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(collection).Error; err != nil {
+			return err
+		}
+
+		return setCollectionMods(tx, collection.ID, mods)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create collection")
+	}
+
+	collection.Mods = mods
+
+	return collection, nil
+}
+
+// UpdateCollection saves the collection, replacing its mods if mods is not nil
+func UpdateCollection(ctx context.Context, collection *Collection, mods []CollectionMod) error {
+	err := DBCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Mods").Save(collection).Error; err != nil {
+			return err
+		}
+
+		if mods == nil {
+			return nil
+		}
+
+		return setCollectionMods(tx, collection.ID, mods)
+	})
+
+	return errors.Wrap(err, "failed to update collection")
+}
+
+func setCollectionMods(tx *gorm.DB, collectionID string, mods []CollectionMod) error {
+	if err := tx.Where("collection_id = ?", collectionID).Delete(&CollectionMod{}).Error; err != nil {
+		return err
+	}
+
+	for i := range mods {
+		mods[i].CollectionID = collectionID
+		mods[i].Position = i
+	}
+
+	if len(mods) == 0 {
+		return nil
+	}
+
+	return tx.Create(&mods).Error
+}
+
+func GetCollectionByID(ctx context.Context, collectionID string) *Collection {
+	var collection Collection
+	collectionQuery(ctx).Find(&collection, "id = ?", collectionID)
+
+	if collection.ID == "" {
+		return nil
+	}
+
+	return &collection
+}
+
+func GetCollectionBySlug(ctx context.Context, slug string) *Collection {
+	var collection Collection
+	collectionQuery(ctx).Find(&collection, "slug = ?", slug)
+
+	if collection.ID == "" {
+		return nil
+	}
+
+	return &collection
+}
+
+func GetUserCollections(ctx context.Context, userID string) []Collection {
+	var collections []Collection
+	collectionQuery(ctx).Order("created_at desc").Find(&collections, "user_id = ?", userID)
+	return collections
+}
+
+func collectionQuery(ctx context.Context) *gorm.DB {
+	return DBCtx(ctx).Preload("Mods", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position asc")
+	})
+}
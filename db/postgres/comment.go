@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// ReportedComment is a comment with the amount of reports waiting to be reviewed
+type ReportedComment struct {
+	Comment
+	Reports int
+}
+
+func CreateComment(ctx context.Context, comment *Comment) (*Comment, error) {
+	comment.ID = util.GenerateUniqueID()
+
+	if err := DBCtx(ctx).Create(comment).Error; err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+func GetCommentByID(ctx context.Context, commentID string) *Comment {
+	var comment Comment
+	DBCtx(ctx).Find(&comment, "id = ?", commentID)
+
+	if comment.ID == "" {
+		return nil
+	}
+
+	return &comment
+}
+
+// GetModComments returns the top level comments of a mod, newest first
+func GetModComments(ctx context.Context, modID string, limit int, offset int) []Comment {
+	var comments []Comment
+	DBCtx(ctx).
+		Where("mod_id = ? AND parent_id IS NULL", modID).
+		Order("created_at desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&comments)
+	return comments
+}
+
+func GetModCommentCount(ctx context.Context, modID string) int64 {
+	var count int64
+	DBCtx(ctx).Model(&Comment{}).Where("mod_id = ? AND parent_id IS NULL", modID).Count(&count)
+	return count
+}
+
+// GetCommentReplies returns the direct replies to a comment, oldest first
+func GetCommentReplies(ctx context.Context, commentID string) []Comment {
+	var comments []Comment
+	DBCtx(ctx).
+		Where("parent_id = ?", commentID).
+		Order("created_at asc").
+		Find(&comments)
+	return comments
+}
+
+func UpdateCommentBody(ctx context.Context, comment *Comment, body string) {
+	now := time.Now()
+	comment.Body = body
+	comment.EditedAt = &now
+
+	DBCtx(ctx).Model(comment).Updates(map[string]interface{}{
+		"body":      comment.Body,
+		"edited_at": comment.EditedAt,
+	})
+}
+
+// DeleteComment deletes the comment, recording who deleted it, and closes its reports
+func DeleteComment(ctx context.Context, comment *Comment, deletedByID string) error {
+	return DBCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(comment).UpdateColumn("deleted_by_id", deletedByID).Error; err != nil {
+			return err
+		}
+
+		if err := closeTargetReports(tx, ReportTargetComment, comment.ID, ReportStateResolved, deletedByID).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(comment).Error
+	})
+}
+
+// GetReportedComments returns the comments with reports waiting to be reviewed, most reported first
+func GetReportedComments(ctx context.Context, limit int, offset int) []ReportedComment {
+	var comments []ReportedComment
+	DBCtx(ctx).Raw(`SELECT comments.*, reports.count AS reports FROM comments
+		JOIN (
+			SELECT target_id, COUNT(*) AS count FROM reports
+			WHERE target_type = ? AND state IN ?
+			GROUP BY target_id
+		) reports ON reports.target_id = comments.id
+		WHERE comments.deleted_at IS NULL
+		ORDER BY reports.count DESC, comments.created_at ASC
+		LIMIT ? OFFSET ?`, ReportTargetComment, unresolvedReportStates, limit, offset).
+		Scan(&comments)
+	return comments
+}
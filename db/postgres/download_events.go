@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// recordDownloadEvent stores a download, counted by the next RollupDownloadEvents
+func recordDownloadEvent(ctx context.Context, version *Version, target string) {
+	DBCtx(ctx).Exec(`INSERT INTO download_events (version_id, mod_id, target_name) VALUES (?, ?, ?)`,
+		version.ID, version.ModID, target)
+}
+
+// downloadEventsPartition returns the name of the partition holding the download events of a month,
+// as created by create_download_events_partition
+func downloadEventsPartition(month time.Time) string {
+	return fmt.Sprintf("download_events_y%04dm%02d", month.Year(), month.Month())
+}
+
+// EnsureDownloadEventPartitions creates the partitions of the current month and of the months ahead which do not exist yet
+func EnsureDownloadEventPartitions(ctx context.Context, monthsAhead int) error {
+	now := time.Now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	for i := 0; i <= monthsAhead; i++ {
+		day := month.AddDate(0, i, 0)
+		if err := DBCtx(ctx).Exec("SELECT create_download_events_partition(?)", day.Format("2006-01-02")).Error; err != nil {
+			return errors.Wrap(err, "failed to create partition "+downloadEventsPartition(day))
+		}
+	}
+
+	return nil
+}
+
+// DropDownloadEventPartitions drops the partitions of the months before the month of the provided time,
+// and returns their names. Their events have to be rolled up beforehand.
+func DropDownloadEventPartitions(ctx context.Context, before time.Time) ([]string, error) {
+	var partitions []string
+	DBCtx(ctx).Raw(`SELECT child.relname FROM pg_inherits
+		JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+		JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+		WHERE parent.relname = 'download_events' AND child.relname < ?
+		ORDER BY child.relname`, downloadEventsPartition(before)).
+		Scan(&partitions)
+
+	dropped := make([]string, 0, len(partitions))
+	for _, partition := range partitions {
+		if err := DBCtx(ctx).Exec(`DROP TABLE IF EXISTS "` + partition + `"`).Error; err != nil {
+			return dropped, errors.Wrap(err, "failed to drop partition "+partition)
+		}
+
+		dropped = append(dropped, partition)
+	}
+
+	return dropped, nil
+}
+
+// RollupDownloadEvents counts the download events since the last rollup which are older than settleDelay
+// in the downloads of the versions, version_download_stats and mod_daily_stats, and returns the amount counted.
+//
+// The delay leaves time for the events of downloads happening during the rollup to be committed.
+func RollupDownloadEvents(ctx context.Context, settleDelay time.Duration) (int64, error) {
+	var rolledUp int64
+
+	err := Tx(ctx, func(ctx context.Context) error {
+		var since time.Time
+		err := DBCtx(ctx).Raw(`SELECT rolled_up_until FROM download_event_rollups WHERE id = 1 FOR UPDATE`).
+			Row().Scan(&since)
+		if err != nil {
+			return errors.Wrap(err, "failed to lock rollup")
+		}
+
+		until := time.Now().Add(-settleDelay)
+		if !until.After(since) {
+			return nil
+		}
+
+		err = DBCtx(ctx).Raw(`SELECT count(*) FROM download_events WHERE created_at >= ? AND created_at < ?`, since, until).
+			Row().Scan(&rolledUp)
+		if err != nil {
+			return errors.Wrap(err, "failed to count events")
+		}
+
+		if rolledUp > 0 {
+			err = DBCtx(ctx).Exec(`UPDATE versions SET downloads = versions.downloads + events.downloads,
+					last_downloaded_at = greatest(versions.last_downloaded_at, events.last_downloaded_at)
+				FROM (
+					SELECT version_id, count(*) AS downloads, max(created_at) AS last_downloaded_at FROM download_events
+					WHERE created_at >= ? AND created_at < ?
+					GROUP BY version_id
+				) AS events
+				WHERE versions.id = events.version_id`, since, until).Error
+			if err != nil {
+				return errors.Wrap(err, "failed to update versions")
+			}
+
+			err = DBCtx(ctx).Exec(`INSERT INTO version_download_stats (version_id, target_name, day, mod_id, downloads)
+				SELECT version_id, target_name, created_at::date, min(mod_id), count(*) FROM download_events
+				WHERE created_at >= ? AND created_at < ?
+				GROUP BY version_id, target_name, created_at::date
+				ON CONFLICT (version_id, target_name, day) DO UPDATE SET downloads = version_download_stats.downloads + excluded.downloads`,
+				since, until).Error
+			if err != nil {
+				return errors.Wrap(err, "failed to update version download stats")
+			}
+
+			err = DBCtx(ctx).Exec(`INSERT INTO mod_daily_stats (mod_id, day, downloads)
+				SELECT mod_id, created_at::date, count(*) FROM download_events
+				WHERE created_at >= ? AND created_at < ?
+				GROUP BY mod_id, created_at::date
+				ON CONFLICT (mod_id, day) DO UPDATE SET downloads = mod_daily_stats.downloads + excluded.downloads`,
+				since, until).Error
+			if err != nil {
+				return errors.Wrap(err, "failed to update mod daily stats")
+			}
+		}
+
+		return errors.Wrap(DBCtx(ctx).Exec(`UPDATE download_event_rollups SET rolled_up_until = ? WHERE id = 1`, until).Error,
+			"failed to update rollup")
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return rolledUp, nil
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserFavorite is a favorite of a user, with whether the mod has a version the user has not seen yet
+type UserFavorite struct {
+	ModFavorite
+	HasUpdates bool
+}
+
+// FavoriteMod adds the mod to the favorites of the user, marking its latest approved version as seen.
+// Favoriting a mod again only refreshes the seen version.
+func FavoriteMod(ctx context.Context, userID string, mod *Mod) error {
+	return DBCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		favorite := ModFavorite{
+			UserID:            userID,
+			ModID:             mod.ID,
+			LastSeenVersionID: latestApprovedVersionID(tx, mod.ID),
+		}
+
+		var existing int64
+		if err := tx.Model(&ModFavorite{}).Where("user_id = ? AND mod_id = ?", userID, mod.ID).Count(&existing).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "mod_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"last_seen_version_id", "updated_at"}),
+		}).Create(&favorite).Error; err != nil {
+			return err
+		}
+
+		if existing > 0 {
+			return nil
+		}
+
+		return tx.Model(&Mod{}).Where("id = ?", mod.ID).UpdateColumn("favorites", gorm.Expr("favorites + 1")).Error
+	})
+}
+
+// UnfavoriteMod removes the mod from the favorites of the user.
+// Returns whether the mod was a favorite.
+func UnfavoriteMod(ctx context.Context, userID string, mod *Mod) (bool, error) {
+	removed := false
+	err := DBCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND mod_id = ?", userID, mod.ID).Delete(&ModFavorite{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		removed = true
+		return tx.Model(&Mod{}).Where("id = ? AND favorites > 0", mod.ID).UpdateColumn("favorites", gorm.Expr("favorites - 1")).Error
+	})
+	return removed, err
+}
+
+// GetUserFavorites returns the favorites of the user, most recently favorited first.
+// Favorites of deleted mods are left out.
+func GetUserFavorites(ctx context.Context, userID string) []UserFavorite {
+	var favorites []UserFavorite
+	DBCtx(ctx).Raw(`SELECT mod_favorites.*, EXISTS (
+			SELECT 1 FROM versions
+			WHERE versions.mod_id = mod_favorites.mod_id AND versions.approved = true AND versions.denied = false
+			AND versions.deleted_at IS NULL
+			AND versions.created_at > COALESCE((SELECT seen.created_at FROM versions seen WHERE seen.id = mod_favorites.last_seen_version_id), mod_favorites.updated_at)
+		) AS has_updates
+		FROM mod_favorites
+		JOIN mods ON mods.id = mod_favorites.mod_id
+		WHERE mod_favorites.user_id = ? AND mods.deleted_at IS NULL
+		ORDER BY mod_favorites.created_at DESC`, userID).
+		Scan(&favorites)
+	return favorites
+}
+
+func latestApprovedVersionID(tx *gorm.DB, modID string) *string {
+	var versionIDs []string
+	tx.Model(&Version{}).
+		Where("mod_id = ? AND approved = ? AND denied = ?", modID, true, false).
+		Order("created_at desc").
+		Limit(1).
+		Pluck("id", &versionIDs)
+
+	if len(versionIDs) == 0 {
+		return nil
+	}
+
+	return &versionIDs[0]
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"context"
+)
+
+// GetFeedMods returns the newest approved mods which are not hidden
+func GetFeedMods(ctx context.Context, limit int) []Mod {
+	var mods []Mod
+	ReadDBCtx(ctx).Where("approved = ? AND denied = ? AND hidden = ?", true, false, false).
+		Order("created_at desc").Limit(limit).Find(&mods)
+	return mods
+}
+
+// GetFeedVersions returns the newest approved versions of mods which are approved and not hidden,
+// optionally only the ones of a mod
+func GetFeedVersions(ctx context.Context, modID string, limit int) []Version {
+	var versions []Version
+	query := ReadDBCtx(ctx).
+		Joins("JOIN mods ON mods.id = versions.mod_id AND mods.deleted_at IS NULL AND mods.approved = ? AND mods.denied = ? AND mods.hidden = ?", true, false, false).
+		Where("versions.approved = ? AND versions.denied = ? AND versions.draft = ?", true, false, false)
+
+	if modID != "" {
+		query = query.Where("versions.mod_id = ?", modID)
+	}
+
+	query.Order("versions.created_at desc").Limit(limit).Find(&versions)
+
+	return versions
+}
@@ -77,12 +77,13 @@ func GetGuides(ctx context.Context, filter *models.GuideFilter) []Guide {
 	}
 
 	var guides []Guide
-	query := DBCtx(ctx).Preload("Tags")
+	query := ReadDBCtx(ctx).Preload("Tags")
 
 	if filter != nil {
 		query = query.Limit(*filter.Limit).
-			Offset(*filter.Offset).
-			Order(string(*filter.OrderBy) + " " + string(*filter.Order))
+			Offset(*filter.Offset)
+
+		query = orderAfterCursor(query, "guides", string(*filter.OrderBy), *filter.Order, filter.Cursor)
 
 		if filter.Search != nil && *filter.Search != "" {
 			query = query.Where("to_tsvector(name) @@ to_tsquery(?)", strings.ReplaceAll(*filter.Search, " ", " & "))
@@ -132,7 +133,7 @@ func GetGuideCount(ctx context.Context, filter *models.GuideFilter) int64 {
 	}
 
 	var guideCount int64
-	query := DBCtx(ctx).Model(Guide{})
+	query := ReadDBCtx(ctx).Model(Guide{})
 
 	if filter != nil {
 		if filter.Search != nil && *filter.Search != "" {
@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+)
+
+// Entities whose writes run hooks. Writes to the targets and dependencies of a version run the hooks of the version,
+// and writes to the tags and authors of a mod run the hooks of the mod.
+const (
+	EntityMod     = "mod"
+	EntityVersion = "version"
+	EntityUser    = "user"
+)
+
+// Hook runs after an entity was written through its typed queries, with the ID of the written entity
+type Hook func(ctx context.Context, id string)
+
+var hooks = make(map[string][]Hook)
+
+// RegisterHook adds a hook run after every write of the entity, after the ones registered before it
+func RegisterHook(entity string, hook Hook) {
+	hooks[entity] = append(hooks[entity], hook)
+}
+
+func runHooks(ctx context.Context, entity string, id string) {
+	for _, hook := range hooks[entity] {
+		hook(ctx, id)
+	}
+}
+
+func init() {
+	// Cached reads of any entity may embed the written one
+	for _, entity := range []string{EntityMod, EntityVersion, EntityUser} {
+		RegisterHook(entity, func(context.Context, string) {
+			ClearCache()
+		})
+	}
+}
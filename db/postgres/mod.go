@@ -2,19 +2,160 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"math"
 	"strings"
 	"time"
 
 	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 
+	"github.com/satisfactorymodding/smr-api/db/postgres/queries/mods"
 	"github.com/satisfactorymodding/smr-api/generated"
 	"github.com/satisfactorymodding/smr-api/models"
 	"github.com/satisfactorymodding/smr-api/util"
 )
 
+// modQueries runs the generated mod queries on the connection of the context,
+// so they take part in its transaction if there is one
+func modQueries(ctx context.Context) *mods.Queries {
+	return mods.New(DBCtx(ctx).Statement.ConnPool)
+}
+
+func modFromRow(row mods.Mod) Mod {
+	mod := Mod{
+		SMRModel: SMRModel{
+			ID:       row.ID,
+			SMRDates: rowDates(row.CreatedAt, row.UpdatedAt, row.DeletedAt),
+		},
+		LastVersionDate:  timePtr(row.LastVersionDate),
+		ArchiveReason:    stringPtr(row.ArchiveReason),
+		ArchivedAt:       timePtr(row.ArchivedAt),
+		Rating:           float64Ptr(row.Rating),
+		CreatorID:        row.CreatorID.String,
+		Logo:             row.Logo.String,
+		SourceURL:        row.SourceUrl.String,
+		FullDescription:  row.FullDescription.String,
+		ShortDescription: row.ShortDescription.String,
+		Name:             row.Name.String,
+		ModReference:     row.ModReference,
+		Downloads:        uint(row.Downloads.Int32),
+		Popularity:       uint(row.Popularity.Int32),
+		Hotness:          uint(row.Hotness.Int32),
+		Views:            uint(row.Views.Int32),
+		Favorites:        uint(row.Favorites),
+		RatingCount:      uint(row.RatingCount),
+		Hidden:           row.Hidden.Bool,
+		Denied:           row.Denied,
+		Approved:         row.Approved,
+		Archived:         row.Archived,
+	}
+
+	if err := unmarshalJSONColumn(row.Compatibility, &mod.Compatibility); err != nil {
+		log.Err(err).Str("id", row.ID).Msg("failed to decode mod compatibility")
+	}
+
+	if err := unmarshalJSONColumn(row.LogoVariants, &mod.LogoVariants); err != nil {
+		log.Err(err).Str("id", row.ID).Msg("failed to decode mod logo variants")
+	}
+
+	return mod
+}
+
+// modsFromRows converts the rows along with their tags, and their versions and targets if withVersions is set
+func modsFromRows(ctx context.Context, rows []mods.Mod, withVersions bool) ([]Mod, error) {
+	result := make([]Mod, len(rows))
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		result[i] = modFromRow(row)
+		ids[i] = row.ID
+	}
+
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	tagRows, err := modQueries(ctx).ListModTags(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list mod tags")
+	}
+
+	tags := make(map[string][]Tag)
+	for _, row := range tagRows {
+		tags[row.ModID] = append(tags[row.ModID], Tag{
+			SMRModel: SMRModel{
+				ID:       row.ID,
+				SMRDates: rowDates(row.CreatedAt, row.UpdatedAt, row.DeletedAt),
+			},
+			Name:        row.Name,
+			Description: row.Description.String,
+		})
+	}
+
+	modVersions := make(map[string][]Version)
+	if withVersions {
+		versionRows, err := versionQueries(ctx).ListVersionsByModID(ctx, ids)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list mod versions")
+		}
+
+		loaded, err := versionsFromRows(ctx, versionRows)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, version := range loaded {
+			modVersions[version.ModID] = append(modVersions[version.ModID], version)
+		}
+	}
+
+	for i := range result {
+		result[i].Tags = tags[result[i].ID]
+		result[i].Versions = modVersions[result[i].ID]
+	}
+
+	return result, nil
+}
+
+// getMod runs a query returning a single mod and loads it, returning nil if there is none
+func getMod(ctx context.Context, query func(q *mods.Queries) (mods.Mod, error)) *Mod {
+	row, err := query(modQueries(ctx))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Err(err).Msg("failed to get mod")
+		}
+		return nil
+	}
+
+	loaded, err := modsFromRows(ctx, []mods.Mod{row}, true)
+	if err != nil {
+		log.Err(err).Str("id", row.ID).Msg("failed to load mod")
+		return nil
+	}
+
+	return &loaded[0]
+}
+
+// listMods runs a query returning mods and loads them, returning nil if it fails
+func listMods(ctx context.Context, withVersions bool, query func(q *mods.Queries) ([]mods.Mod, error)) []Mod {
+	rows, err := query(modQueries(ctx))
+	if err != nil {
+		log.Err(err).Msg("failed to list mods")
+		return nil
+	}
+
+	loaded, err := modsFromRows(ctx, rows, withVersions)
+	if err != nil {
+		log.Err(err).Msg("failed to load mods")
+		return nil
+	}
+
+	return loaded
+}
+
 func GetModByID(ctx context.Context, modID string) *Mod {
 	cacheKey := "GetModById_" + modID
 	if mod, ok := dbCache.Get(cacheKey); ok {
@@ -25,16 +166,17 @@ func GetModByID(ctx context.Context, modID string) *Mod {
 }
 
 func GetModByIDNoCache(ctx context.Context, modID string) *Mod {
-	var mod Mod
-	DBCtx(ctx).Preload("Tags").Preload("Versions.Targets").Find(&mod, "id = ?", modID)
+	mod := getMod(ctx, func(q *mods.Queries) (mods.Mod, error) {
+		return q.GetModByID(ctx, modID)
+	})
 
-	if mod.ID == "" {
+	if mod == nil {
 		return nil
 	}
 
-	dbCache.Set("GetModById_"+modID, &mod, cache.DefaultExpiration)
+	dbCache.Set("GetModById_"+modID, mod, cache.DefaultExpiration)
 
-	return &mod
+	return mod
 }
 
 func GetModByReference(ctx context.Context, modReference string) *Mod {
@@ -43,16 +185,36 @@ func GetModByReference(ctx context.Context, modReference string) *Mod {
 		return mod.(*Mod)
 	}
 
-	var mod Mod
-	DBCtx(ctx).Preload("Tags").Preload("Versions.Targets").Find(&mod, "mod_reference = ?", modReference)
+	mod := getMod(ctx, func(q *mods.Queries) (mods.Mod, error) {
+		return q.GetModByReference(ctx, modReference)
+	})
 
-	if mod.ID == "" {
+	if mod == nil {
 		return nil
 	}
 
-	dbCache.Set(cacheKey, &mod, cache.DefaultExpiration)
+	dbCache.Set(cacheKey, mod, cache.DefaultExpiration)
 
-	return &mod
+	return mod
+}
+
+// MaxModReferences is the amount of mods that can be requested at once by reference
+const MaxModReferences = 100
+
+// GetModsByReferences returns the mods matching the provided references, references that do not match any mod are skipped
+func GetModsByReferences(ctx context.Context, modReferences []string) []Mod {
+	cacheKey := "GetModsByReferences_" + strings.Join(modReferences, ":")
+	if mods, ok := dbCache.Get(cacheKey); ok {
+		return mods.([]Mod)
+	}
+
+	result := listMods(ctx, true, func(q *mods.Queries) ([]mods.Mod, error) {
+		return q.ListModsByReference(ctx, modReferences)
+	})
+
+	dbCache.Set(cacheKey, result, cache.DefaultExpiration)
+
+	return result
 }
 
 func GetModsByID(ctx context.Context, modIds []string) []Mod {
@@ -61,22 +223,107 @@ func GetModsByID(ctx context.Context, modIds []string) []Mod {
 		return mods.([]Mod)
 	}
 
-	var mods []Mod
-	DBCtx(ctx).Preload("Tags").Find(&mods, "id in (?)", modIds)
+	result := listMods(ctx, false, func(q *mods.Queries) ([]mods.Mod, error) {
+		return q.ListModsByID(ctx, modIds)
+	})
 
-	if len(modIds) != len(mods) {
+	if len(modIds) != len(result) {
 		return nil
 	}
 
-	dbCache.Set(cacheKey, mods, cache.DefaultExpiration)
+	dbCache.Set(cacheKey, result, cache.DefaultExpiration)
 
-	return mods
+	return result
+}
+
+// SaveMod saves the editable fields of the mod. Its counters are left to the queries which update them.
+func SaveMod(ctx context.Context, mod *Mod) error {
+	return saveMod(ctx, mod, sql.NullTime{})
+}
+
+// SaveModIfUnchanged saves the mod like SaveMod, unless it was updated by someone else since it was loaded with the
+// provided updatedAt, in which case an EditConflictError is returned
+func SaveModIfUnchanged(ctx context.Context, mod *Mod, updatedAt time.Time) error {
+	return saveMod(ctx, mod, sql.NullTime{Time: updatedAt, Valid: true})
 }
 
-func DeleteMod(ctx context.Context, modID string) {
-	DBCtx(ctx).Delete(Mod{}, "id = ?", modID)
-	DBCtx(ctx).Delete(Version{}, "mod_id = ?", modID)
-	DBCtx(ctx).Delete(UserMod{}, "mod_id = ?", modID)
+func saveMod(ctx context.Context, mod *Mod, expectedUpdatedAt sql.NullTime) error {
+	compatibility, err := marshalJSONColumn(mod.Compatibility)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode mod compatibility")
+	}
+
+	logoVariants, err := marshalJSONColumn(mod.LogoVariants)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode mod logo variants")
+	}
+
+	updatedAt, err := modQueries(ctx).UpdateMod(ctx, mods.UpdateModParams{
+		ID:                mod.ID,
+		Name:              nullString(mod.Name),
+		ShortDescription:  nullString(mod.ShortDescription),
+		FullDescription:   nullString(mod.FullDescription),
+		Logo:              nullString(mod.Logo),
+		LogoVariants:      logoVariants,
+		SourceUrl:         nullString(mod.SourceURL),
+		CreatorID:         nullString(mod.CreatorID),
+		ModReference:      mod.ModReference,
+		Approved:          mod.Approved,
+		Denied:            mod.Denied,
+		Hidden:            sql.NullBool{Bool: mod.Hidden, Valid: true},
+		Compatibility:     compatibility,
+		LastVersionDate:   nullTimePtr(mod.LastVersionDate),
+		Archived:          mod.Archived,
+		ArchiveReason:     nullStringPtr(mod.ArchiveReason),
+		ArchivedAt:        nullTimePtr(mod.ArchivedAt),
+		ExpectedUpdatedAt: expectedUpdatedAt,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) && expectedUpdatedAt.Valid {
+			conflict := &EditConflictError{TargetType: "mod"}
+			if current, err := modQueries(ctx).GetModByID(ctx, mod.ID); err == nil {
+				conflict.UpdatedAt = current.UpdatedAt.Time
+			}
+			return conflict
+		}
+		return errors.Wrap(err, "failed to save mod")
+	}
+
+	mod.UpdatedAt = updatedAt.Time
+
+	runHooks(ctx, EntityMod, mod.ID)
+
+	return nil
+}
+
+// DeleteModCascade deletes a mod along with its versions and their dependencies in a single transaction,
+// cancels its pending transfers and drops the rows listing it as tagged, favorited or trending.
+//
+// The rows which are only soft deleted, and the stored files, are left to be purged.
+func DeleteModCascade(ctx context.Context, modID string) error {
+	return Tx(ctx, func(ctx context.Context) error {
+		if err := DBCtx(ctx).Where("mod_id = ?", modID).Delete(&Version{}).Error; err != nil {
+			return errors.Wrap(err, "failed to delete versions")
+		}
+
+		err := DBCtx(ctx).Where("version_id IN (SELECT id FROM versions WHERE mod_id = ?)", modID).
+			Delete(&VersionDependency{}).Error
+		if err != nil {
+			return errors.Wrap(err, "failed to delete version dependencies")
+		}
+
+		for _, table := range []string{"mod_tags", "user_mods", "mod_favorites", "mod_trending"} {
+			if err := DBCtx(ctx).Exec("DELETE FROM "+table+" WHERE mod_id = ?", modID).Error; err != nil {
+				return errors.Wrap(err, "failed to delete "+table)
+			}
+		}
+
+		if err := CancelModTransfers(ctx, modID); err != nil {
+			return err
+		}
+
+		return errors.Wrap(DBCtx(ctx).Where("id = ?", modID).Delete(&Mod{}).Error, "failed to delete mod")
+	})
 }
 
 func GetModCount(ctx context.Context, search string, unapproved bool) int64 {
@@ -89,7 +336,7 @@ func GetModCount(ctx context.Context, search string, unapproved bool) int64 {
 	query := DBCtx(ctx).Model(Mod{}).Where("approved = ? AND denied = ?", !unapproved, false)
 
 	if search != "" {
-		query = query.Where("to_tsvector(name) @@ to_tsquery(?)", strings.ReplaceAll(search, " ", " & "))
+		query = query.Where("search_vector @@ to_tsquery(?, ?)", searchConfig, searchQuery(search))
 	}
 
 	query.Count(&modCount)
@@ -119,9 +366,48 @@ func GetModCountNew(ctx context.Context, filter *models.ModFilter, unapproved bo
 	return modCount
 }
 
+// TagFacet is a tag with the amount of mods matching a filter which have it
+type TagFacet struct {
+	Tag
+	Count int
+}
+
+// GetModTagFacets counts the mods matching the filter by tag, most used tags first
+func GetModTagFacets(ctx context.Context, filter *models.ModFilter, unapproved bool) []TagFacet {
+	hash, err := filter.Hash()
+	cacheKey := ""
+	if err == nil {
+		cacheKey = "GetModTagFacets_" + hash + "_" + fmt.Sprint(unapproved)
+		if facets, ok := dbCache.Get(cacheKey); ok {
+			return facets.([]TagFacet)
+		}
+	}
+
+	fields := filter.Fields
+	filter.Fields = nil
+	matching := NewModQuery(ctx, filter, unapproved, true).Select("mods.id")
+	filter.Fields = fields
+
+	var facets []TagFacet
+	ReadDBCtx(ctx).Table("mod_tags").
+		Select("tags.*, count(*) as count").
+		Joins("INNER JOIN tags ON tags.id = mod_tags.tag_id").
+		Where("tags.deleted_at IS NULL AND mod_tags.mod_id IN (?)", matching).
+		Group("tags.id").
+		Order("count desc, tags.name asc").
+		Scan(&facets)
+
+	if cacheKey != "" {
+		dbCache.Set(cacheKey, facets, cache.DefaultExpiration)
+	}
+
+	return facets
+}
+
 func IncrementModViews(ctx context.Context, mod *Mod) {
 	// TODO unignore
 	// DBCtx(ctx).Model(mod).Update("views", mod.Views+1)
+	addModDailyStat(ctx, mod.ID, "views")
 }
 
 func GetMods(ctx context.Context, limit int, offset int, orderBy string, order string, search string, unapproved bool) []Mod {
@@ -142,7 +428,7 @@ func GetMods(ctx context.Context, limit int, offset int, orderBy string, order s
 	query = query.Where("approved = ? AND denied = ?", !unapproved, false)
 
 	if search != "" {
-		query = query.Where("to_tsvector(name) @@ to_tsquery(?)", strings.ReplaceAll(search, " ", " & "))
+		query = query.Where("search_vector @@ to_tsquery(?, ?)", searchConfig, searchQuery(search))
 	}
 
 	query.Find(&mods)
@@ -207,7 +493,7 @@ func CreateMod(ctx context.Context, mod *Mod) (*Mod, error) {
 }
 
 func NewModQuery(ctx context.Context, filter *models.ModFilter, unapproved bool, count bool) *gorm.DB {
-	query := DBCtx(ctx)
+	query := ReadDBCtx(ctx)
 
 	if count {
 		query = query.Model(Mod{})
@@ -216,16 +502,15 @@ func NewModQuery(ctx context.Context, filter *models.ModFilter, unapproved bool,
 	query = query.Where("approved = ? AND denied = ?", !unapproved, false)
 	query = query.Preload("Tags").Preload("Versions.Targets")
 	if filter != nil {
-		if filter.Search != nil && *filter.Search != "" {
-			cleanSearch := strings.ReplaceAll(strings.TrimSpace(*filter.Search), " ", " & ")
-			sub := DBCtx(ctx).Table("mods")
-			sub = sub.Select("id, (similarity(name, ?) * 2 + similarity(short_description, ?) + similarity(full_description, ?) * 0.5) as s", cleanSearch, cleanSearch, cleanSearch)
-
-			query = query.Joins("INNER JOIN (?) AS t1 on t1.id = mods.id", sub)
-			query = query.Where("t1.s > 0.2")
-
-			if !count && *filter.OrderBy == generated.ModFieldsSearch {
-				query = query.Order("t1.s DESC")
+		ranked := false
+		if filter.Search != nil {
+			if tsQuery := searchQuery(*filter.Search); tsQuery != "" {
+				sub := DBCtx(ctx).Table("mods").
+					Select("id, ts_rank(search_vector, to_tsquery(?, ?)) as s", searchConfig, tsQuery).
+					Where("search_vector @@ to_tsquery(?, ?)", searchConfig, tsQuery)
+
+				query = query.Joins("INNER JOIN (?) AS t1 on t1.id = mods.id", sub)
+				ranked = true
 			}
 		}
 
@@ -234,11 +519,9 @@ func NewModQuery(ctx context.Context, filter *models.ModFilter, unapproved bool,
 				Offset(*filter.Offset)
 
 			if *filter.OrderBy != generated.ModFieldsSearch {
-				if string(*filter.OrderBy) == "last_version_date" {
-					query = query.Order("case when last_version_date is null then 1 else 0 end, last_version_date " + string(*filter.Order))
-				} else {
-					query = query.Order("mods." + string(*filter.OrderBy) + " " + string(*filter.Order))
-				}
+				query = orderAfterCursor(query, "mods", string(*filter.OrderBy), *filter.Order, filter.Cursor)
+			} else if ranked {
+				query = query.Order("t1.s DESC").Order("mods.popularity DESC")
 			}
 		}
 
@@ -246,6 +529,10 @@ func NewModQuery(ctx context.Context, filter *models.ModFilter, unapproved bool,
 			query = query.Where("hidden = false")
 		}
 
+		if filter.IncludeArchived == nil || !(*filter.IncludeArchived) {
+			query = query.Where("archived = false")
+		}
+
 		if filter.Ids != nil && len(filter.Ids) > 0 {
 			query = query.Where("mods.id in (?)", filter.Ids)
 		} else if filter.References != nil && len(filter.References) > 0 {
@@ -257,7 +544,25 @@ func NewModQuery(ctx context.Context, filter *models.ModFilter, unapproved bool,
 		}
 
 		if filter.TagIDs != nil && len(filter.TagIDs) > 0 {
-			query.Joins("INNER JOIN mod_tags on mod_tags.tag_id in ? AND mod_tags.mod_id = mods.id", filter.TagIDs)
+			query = query.Where("mods.id in (select mod_id from mod_tags where tag_id in ?)", filter.TagIDs)
+		}
+
+		if filter.Targets != nil && len(filter.Targets) > 0 {
+			query = query.Where("mods.id in (select v.mod_id from versions v inner join version_targets vt on vt.version_id = v.id "+
+				"where v.approved = true and v.denied = false and v.deleted_at is null and vt.target_name in ?)", filter.Targets)
+		}
+
+		if filter.SMLVersion != nil || filter.GameVersion != nil {
+			query = query.Where("mods.id in (select mod_id from versions where approved = true and denied = false and deleted_at is null and sml_version in ?)",
+				GetMatchingSMLRequirements(ctx, filter.SMLVersion, filter.GameVersion))
+		}
+
+		if filter.LastVersionAfter != nil {
+			query = query.Where("mods.last_version_date >= ?", *filter.LastVersionAfter)
+		}
+
+		if filter.LastVersionBefore != nil {
+			query = query.Where("mods.last_version_date < ?", *filter.LastVersionBefore)
 		}
 	}
 
@@ -270,21 +575,27 @@ func GetModByIDOrReference(ctx context.Context, modIDOrReference string) *Mod {
 		return mod.(*Mod)
 	}
 
-	var mod Mod
-	DBCtx(ctx).Preload("Tags").Preload("Versions.Targets").Find(&mod, "mod_reference = ? OR id = ?", modIDOrReference, modIDOrReference)
+	mod := getMod(ctx, func(q *mods.Queries) (mods.Mod, error) {
+		return q.GetModByIDOrReference(ctx, modIDOrReference)
+	})
 
-	if mod.ID == "" {
+	if mod == nil {
 		return nil
 	}
 
-	dbCache.Set(cacheKey, &mod, cache.DefaultExpiration)
+	dbCache.Set(cacheKey, mod, cache.DefaultExpiration)
 
-	return &mod
+	return mod
 }
 
 func ClearModTags(ctx context.Context, modID string) error {
-	r := DBCtx(ctx).Where("mod_id = ?", modID).Delete(&ModTag{})
-	return r.Error
+	if err := modQueries(ctx).ClearModTags(ctx, modID); err != nil {
+		return errors.Wrap(err, "failed to clear mod tags")
+	}
+
+	runHooks(ctx, EntityMod, modID)
+
+	return nil
 }
 
 func SetModTags(ctx context.Context, modID string, tagIDs []string) error {
@@ -310,13 +621,23 @@ func ResetModTags(ctx context.Context, modID string, tagIDs []string) error {
 }
 
 func AddModTag(ctx context.Context, modID string, tagID string) error {
-	r := DBCtx(ctx).Create(&ModTag{ModID: modID, TagID: tagID})
-	return r.Error
+	if err := modQueries(ctx).AddModTag(ctx, mods.AddModTagParams{ModID: modID, TagID: tagID}); err != nil {
+		return errors.Wrap(err, "failed to add mod tag")
+	}
+
+	runHooks(ctx, EntityMod, modID)
+
+	return nil
 }
 
 func RemoveModTag(ctx context.Context, modID string, tagID string) error {
-	r := DBCtx(ctx).Delete(&ModTag{ModID: modID, TagID: tagID})
-	return r.Error
+	if err := modQueries(ctx).RemoveModTag(ctx, mods.RemoveModTagParams{ModID: modID, TagID: tagID}); err != nil {
+		return errors.Wrap(err, "failed to remove mod tag")
+	}
+
+	runHooks(ctx, EntityMod, modID)
+
+	return nil
 }
 
 func GetModsByIDOrReference(ctx context.Context, modIDOrReferences []string) []Mod {
@@ -325,10 +646,11 @@ func GetModsByIDOrReference(ctx context.Context, modIDOrReferences []string) []M
 		return mod.([]Mod)
 	}
 
-	var mods []Mod
-	DBCtx(ctx).Preload("Tags").Find(&mods, "mod_reference IN ? OR id IN ?", modIDOrReferences, modIDOrReferences)
+	result := listMods(ctx, false, func(q *mods.Queries) ([]mods.Mod, error) {
+		return q.ListModsByIDOrReference(ctx, modIDOrReferences)
+	})
 
-	dbCache.Set(cacheKey, mods, cache.DefaultExpiration)
+	dbCache.Set(cacheKey, result, cache.DefaultExpiration)
 
-	return mods
+	return result
 }
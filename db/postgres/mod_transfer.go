@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// CreateModTransfer records a pending transfer of the mod from its current owner to another user,
+// replacing any transfer of the mod which is still pending
+func CreateModTransfer(ctx context.Context, mod *Mod, toUserID string, requestedByID string) (*ModTransfer, error) {
+	transfer := &ModTransfer{
+		SMRModel: SMRModel{
+			ID: util.GenerateUniqueID(),
+		},
+		ModID:         mod.ID,
+		FromUserID:    mod.CreatorID,
+		ToUserID:      toUserID,
+		RequestedByID: requestedByID,
+		Status:        ModTransferStatePending,
+	}
+
+	err := Tx(ctx, func(ctx context.Context) error {
+		tx := DBCtx(ctx)
+
+		if err := tx.Model(ModTransfer{}).
+			Where("mod_id = ? AND status = ?", mod.ID, ModTransferStatePending).
+			Update("status", ModTransferStateCancelled).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(NewAuditLog(requestedByID, AuditActionModTransferRequested, "mod", mod.ID, map[string]interface{}{
+			"transfer_id":  transfer.ID,
+			"from_user_id": transfer.FromUserID,
+			"to_user_id":   transfer.ToUserID,
+		})).Error
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create mod transfer")
+	}
+
+	return transfer, nil
+}
+
+func GetModTransferByID(ctx context.Context, transferID string) *ModTransfer {
+	var transfer ModTransfer
+	DBCtx(ctx).Find(&transfer, "id = ?", transferID)
+
+	if transfer.ID == "" {
+		return nil
+	}
+
+	return &transfer
+}
+
+// GetPendingModTransfers returns the pending transfers sent by or to a user
+func GetPendingModTransfers(ctx context.Context, userID string) []ModTransfer {
+	var transfers []ModTransfer
+	DBCtx(ctx).Order("created_at desc").
+		Find(&transfers, "status = ? AND (from_user_id = ? OR to_user_id = ?)", ModTransferStatePending, userID, userID)
+	return transfers
+}
+
+// AcceptModTransfer makes the recipient of the transfer the owner of the mod.
+//
+// The previous owner stays on the mod as an editor, and every other author keeps their role.
+func AcceptModTransfer(ctx context.Context, transfer *ModTransfer) error {
+	err := Tx(ctx, func(ctx context.Context) error {
+		tx := DBCtx(ctx)
+
+		var mod Mod
+		if err := tx.First(&mod, "id = ?", transfer.ModID).Error; err != nil {
+			return err
+		}
+
+		if mod.CreatorID != transfer.FromUserID {
+			return errors.New("the owner of the mod changed since the transfer was requested")
+		}
+
+		if err := tx.Model(UserMod{}).
+			Where("mod_id = ? AND user_id = ?", mod.ID, transfer.FromUserID).
+			Update("role", "editor").Error; err != nil {
+			return err
+		}
+
+		if err := tx.Save(&UserMod{
+			UserID: transfer.ToUserID,
+			ModID:  mod.ID,
+			Role:   "creator",
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&mod).Update("creator_id", transfer.ToUserID).Error; err != nil {
+			return err
+		}
+
+		return finishModTransfer(tx, transfer, ModTransferStateAccepted, transfer.ToUserID, AuditActionModTransferAccepted)
+	})
+
+	return errors.Wrap(err, "failed to accept mod transfer")
+}
+
+// CloseModTransfer declines or cancels a pending transfer on behalf of a user
+func CloseModTransfer(ctx context.Context, transfer *ModTransfer, status string, userID string) error {
+	action := AuditActionModTransferCancelled
+	if status == ModTransferStateDeclined {
+		action = AuditActionModTransferDeclined
+	}
+
+	err := Tx(ctx, func(ctx context.Context) error {
+		return finishModTransfer(DBCtx(ctx), transfer, status, userID, action)
+	})
+
+	return errors.Wrap(err, "failed to close mod transfer")
+}
+
+// CancelModTransfers cancels every pending transfer of the mod
+func CancelModTransfers(ctx context.Context, modID string) error {
+	err := DBCtx(ctx).Model(ModTransfer{}).
+		Where("mod_id = ? AND status = ?", modID, ModTransferStatePending).
+		Update("status", ModTransferStateCancelled).Error
+	return errors.Wrap(err, "failed to cancel mod transfers")
+}
+
+func finishModTransfer(tx *gorm.DB, transfer *ModTransfer, status string, userID string, action string) error {
+	result := tx.Model(transfer).
+		Where("status = ?", ModTransferStatePending).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.New("transfer is no longer pending")
+	}
+
+	return tx.Create(NewAuditLog(userID, action, "mod", transfer.ModID, map[string]interface{}{
+		"transfer_id":  transfer.ID,
+		"from_user_id": transfer.FromUserID,
+		"to_user_id":   transfer.ToUserID,
+	})).Error
+}
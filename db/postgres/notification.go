@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// CreateNotifications stores a notification for each of the users, skipping duplicate users
+func CreateNotifications(ctx context.Context, userIDs []string, notificationType string, message string, modID *string, versionID *string) []Notification {
+	seen := make(map[string]bool, len(userIDs))
+	notifications := make([]Notification, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		notifications = append(notifications, Notification{
+			ID:        util.GenerateUniqueID(),
+			UserID:    userID,
+			Type:      notificationType,
+			Message:   message,
+			ModID:     modID,
+			VersionID: versionID,
+		})
+	}
+
+	if len(notifications) > 0 {
+		DBCtx(ctx).Create(&notifications)
+	}
+
+	return notifications
+}
+
+// GetNotifications returns the notifications of a user, newest first
+func GetNotifications(ctx context.Context, userID string, unreadOnly bool, limit int, offset int) []Notification {
+	var notifications []Notification
+	notificationQuery(ctx, userID, unreadOnly).
+		Order("created_at desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&notifications)
+	return notifications
+}
+
+func GetNotificationCount(ctx context.Context, userID string, unreadOnly bool) int64 {
+	var count int64
+	notificationQuery(ctx, userID, unreadOnly).Count(&count)
+	return count
+}
+
+// MarkNotificationsRead marks the notifications of a user as read, or all of them if notificationIDs is nil.
+// Returns the amount of notifications which were unread.
+func MarkNotificationsRead(ctx context.Context, userID string, notificationIDs []string) int64 {
+	query := notificationQuery(ctx, userID, true)
+
+	if notificationIDs != nil {
+		query = query.Where("id in ?", notificationIDs)
+	}
+
+	return query.Update("read_at", time.Now()).RowsAffected
+}
+
+// GetDependentModAuthors returns the authors of other mods with an approved version depending on the mod
+func GetDependentModAuthors(ctx context.Context, mod *Mod) []string {
+	var userIDs []string
+	DBCtx(ctx).Raw(`SELECT DISTINCT user_mods.user_id FROM version_dependencies
+		JOIN versions ON versions.id = version_dependencies.version_id
+		JOIN mods ON mods.id = versions.mod_id
+		JOIN user_mods ON user_mods.mod_id = mods.id
+		WHERE version_dependencies.mod_id = ? AND versions.approved = true AND versions.deleted_at IS NULL
+		AND mods.id != ? AND mods.deleted_at IS NULL`, mod.ModReference, mod.ID).
+		Scan(&userIDs)
+	return userIDs
+}
+
+func notificationQuery(ctx context.Context, userID string, unreadOnly bool) *gorm.DB {
+	query := DBCtx(ctx).Model(Notification{}).Where("user_id = ?", userID)
+
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	return query
+}
@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/models"
+)
+
+// orderAfterCursor orders the query by the column of the table, with the id as a tiebreaker
+// so that every row has a stable position. Nulls are always ordered last.
+//
+// If a cursor is provided, only the rows positioned after it are returned.
+func orderAfterCursor(query *gorm.DB, table string, column string, order generated.Order, cursor *models.Cursor) *gorm.DB {
+	direction, comparison := "desc", "<"
+	if order == generated.OrderAsc {
+		direction, comparison = "asc", ">"
+	}
+
+	column = table + "." + column
+	id := table + ".id"
+
+	query = query.Order(column + " " + direction + " nulls last").Order(id + " " + direction)
+
+	if cursor == nil {
+		return query
+	}
+
+	value := cursor.Value()
+	if value == nil {
+		return query.Where(column+" is null and "+id+" "+comparison+" ?", cursor.ID)
+	}
+
+	return query.Where("("+column+" "+comparison+" ? or ("+column+" = ? and "+id+" "+comparison+" ?) or "+column+" is null)", value, value, cursor.ID)
+}
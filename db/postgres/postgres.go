@@ -2,10 +2,12 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
@@ -79,13 +81,39 @@ func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (stri
 var debugEnabled = false
 
 func InitializePostgres(ctx context.Context) {
+	dbInit, err := openDB(func(key string) string {
+		return viper.GetString("database.postgres." + key)
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	db = dbInit
+
+	if debugEnabled {
+		db = db.Debug()
+	}
+
+	if viper.GetString("database.postgres.replica.host") != "" {
+		initializeReadReplica()
+	}
+
+	dbCache = cache.New(time.Second*5, time.Second*10)
+
+	// TODO Create search indexes
+
+	log.Info().Msg("Postgres initialized")
+}
+
+// openDB connects to the database configured by the settings returned by setting
+func openDB(setting func(key string) string) (*gorm.DB, error) {
 	connection := postgres.Open(fmt.Sprintf(
-		"sslmode=disable host=%s port=%d user=%s dbname=%s password=%s",
-		viper.GetString("database.postgres.host"),
-		viper.GetInt("database.postgres.port"),
-		viper.GetString("database.postgres.user"),
-		viper.GetString("database.postgres.db"),
-		viper.GetString("database.postgres.pass"),
+		"sslmode=disable host=%s port=%s user=%s dbname=%s password=%s",
+		setting("host"),
+		setting("port"),
+		setting("user"),
+		setting("db"),
+		setting("pass"),
 	))
 
 	dbInit, err := gorm.Open(connection, &gorm.Config{
@@ -94,31 +122,88 @@ func InitializePostgres(ctx context.Context) {
 		},
 	})
 	if err != nil {
-		panic(err)
+		return nil, errors.Wrap(err, "failed to connect to database")
 	}
 
-	err = dbInit.Use(otel.NewPlugin())
+	if err := dbInit.Use(otel.NewPlugin()); err != nil {
+		return nil, errors.Wrap(err, "failed to install tracing")
+	}
+
+	sqlDB, err := dbInit.DB()
 	if err != nil {
-		panic(err)
+		return nil, errors.Wrap(err, "failed to get database connection")
 	}
 
-	db = dbInit
+	configurePool(sqlDB)
 
-	if debugEnabled {
-		db = db.Debug()
+	return dbInit, nil
+}
+
+// configurePool applies the limits of database.postgres.pool to a connection pool
+func configurePool(sqlDB *sql.DB) {
+	sqlDB.SetMaxOpenConns(viper.GetInt("database.postgres.pool.max_open"))
+	sqlDB.SetMaxIdleConns(viper.GetInt("database.postgres.pool.max_idle"))
+	sqlDB.SetConnMaxLifetime(viper.GetDuration("database.postgres.pool.max_lifetime"))
+	sqlDB.SetConnMaxIdleTime(viper.GetDuration("database.postgres.pool.max_idle_time"))
+}
+
+// PoolStats returns the statistics of the connection pools, by pool name
+func PoolStats() map[string]sql.DBStats {
+	pools := map[string]*gorm.DB{"primary": db}
+	if readReplica != nil {
+		pools["replica"] = readReplica
 	}
 
-	dbCache = cache.New(time.Second*5, time.Second*10)
+	stats := make(map[string]sql.DBStats, len(pools))
+	for name, pool := range pools {
+		if pool == nil {
+			continue
+		}
 
-	// TODO Create search indexes
+		if sqlDB, err := pool.DB(); err == nil {
+			stats[name] = sqlDB.Stats()
+		}
+	}
 
-	log.Info().Msg("Postgres initialized")
+	return stats
+}
+
+// Ping checks that the database accepts connections
+func Ping(ctx context.Context) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Wrap(err, "failed to get database connection")
+	}
+
+	return errors.Wrap(sqlDB.PingContext(ctx), "failed to ping database")
 }
 
 func Save(ctx context.Context, object interface{}) {
 	DBCtx(ctx).Save(object)
 }
 
+// EditConflictError is returned when an object was modified since the client which edits it loaded it
+type EditConflictError struct {
+	TargetType string
+	UpdatedAt  time.Time
+}
+
+func (e *EditConflictError) Error() string {
+	return "this " + e.TargetType + " was modified since it was loaded, reload it and apply the changes again"
+}
+
+func (e *EditConflictError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{
+		"code": "EDIT_CONFLICT",
+	}
+
+	if !e.UpdatedAt.IsZero() {
+		extensions["updated_at"] = e.UpdatedAt.Format(time.RFC3339Nano)
+	}
+
+	return extensions
+}
+
 func Delete(ctx context.Context, object interface{}) {
 	DBCtx(ctx).Delete(object)
 	ClearCache()
@@ -129,6 +214,19 @@ func DeleteForced(ctx context.Context, object interface{}) {
 	ClearCache()
 }
 
+// Tx runs fn in a transaction, which is committed if fn returns nil and rolled back otherwise.
+//
+// Every query made with the context passed to fn takes part in the transaction, including Save, Delete, DeleteForced
+// and the generated queries. Calls nested in another transaction use a savepoint. The cache is cleared once the
+// transaction ends, as it may hold entries read within it which were rolled back.
+func Tx(ctx context.Context, fn func(ctx context.Context) error) error {
+	defer ClearCache()
+
+	return DBCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(ContextWithDB(ctx, tx))
+	})
+}
+
 func DBCtx(ctx context.Context) *gorm.DB {
 	if ctx != nil {
 		dbCtx := DBFromContext(ctx)
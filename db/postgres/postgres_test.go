@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// poolTestDriver opens connections which cannot run anything, enough to exercise the pool limits
+type poolTestDriver struct{}
+
+func (poolTestDriver) Open(string) (driver.Conn, error) {
+	return poolTestConn{}, nil
+}
+
+type poolTestConn struct{}
+
+func (poolTestConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (poolTestConn) Close() error {
+	return nil
+}
+
+func (poolTestConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func init() {
+	sql.Register("pool-test", poolTestDriver{})
+}
+
+func TestConfigurePoolAppliesLimits(t *testing.T) {
+	for key, value := range map[string]interface{}{
+		"database.postgres.pool.max_open":      3,
+		"database.postgres.pool.max_idle":      1,
+		"database.postgres.pool.max_lifetime":  time.Minute,
+		"database.postgres.pool.max_idle_time": time.Minute,
+	} {
+		previous := viper.Get(key)
+		viper.Set(key, value)
+		key := key
+		t.Cleanup(func() {
+			viper.Set(key, previous)
+		})
+	}
+
+	sqlDB, err := sql.Open("pool-test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	configurePool(sqlDB)
+
+	if open := sqlDB.Stats().MaxOpenConnections; open != 3 {
+		t.Errorf("expected at most 3 open connections, got %d", open)
+	}
+
+	conns := make([]*sql.Conn, 0, 3)
+	for i := 0; i < 3; i++ {
+		conn, err := sqlDB.Conn(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, conn)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if _, err := sqlDB.Conn(ctx); err == nil {
+		t.Error("expected the pool to refuse a fourth connection")
+	}
+
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+
+	if idle := sqlDB.Stats().Idle; idle != 1 {
+		t.Errorf("expected 1 idle connection to be kept, got %d", idle)
+	}
+}
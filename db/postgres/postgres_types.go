@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/satisfactorymodding/smr-api/util/diff"
 )
 
 type Tabler interface {
@@ -32,6 +34,7 @@ type User struct {
 	JoinedFrom string
 	Mods       []Mod `gorm:"many2many:user_mods;"`
 	Banned     bool  `gorm:"default:false;not null"`
+	QuotaTier  *string
 }
 
 type UserSession struct {
@@ -45,6 +48,10 @@ type UserSession struct {
 type Mod struct {
 	LastVersionDate *time.Time
 	Compatibility   *CompatibilityInfo `gorm:"serializer:json"`
+	LogoVariants    map[string]string  `gorm:"serializer:json"` // Keys of the resized logos, by "<size>.<format>"
+	ArchiveReason   *string
+	ArchivedAt      *time.Time
+	Rating          *float64 // Average of the ratings, nil if the mod was never rated
 	SMRModel
 	CreatorID        string
 	Logo             string
@@ -60,9 +67,12 @@ type Mod struct {
 	Popularity       uint
 	Hotness          uint
 	Views            uint
+	Favorites        uint
+	RatingCount      uint
 	Hidden           bool
 	Denied           bool `gorm:"default:false;not null"`
 	Approved         bool `gorm:"default:false;not null"`
+	Archived         bool `gorm:"default:false;not null"`
 }
 
 type UserMod struct {
@@ -73,25 +83,39 @@ type UserMod struct {
 
 // If updated, update dataloader
 type Version struct {
-	Metadata     *string
-	Hash         *string
-	Size         *int64
-	VersionPatch *int
-	VersionMinor *int
-	VersionMajor *int
-	ModReference *string
-	SMRModel
-	Changelog  string
-	Stability  string `gorm:"default:'alpha'" sql:"type:version_stability"`
-	Key        string
-	SMLVersion string `gorm:"type:varchar(16)"`
-	Version    string `gorm:"type:varchar(16)"`
-	ModID      string
-	Targets    []VersionTarget `gorm:"foreignKey:VersionID"`
-	Hotness    uint
-	Downloads  uint
-	Denied     bool `gorm:"default:false;not null"`
-	Approved   bool `gorm:"default:false;not null"`
+	Metadata            *string
+	ContentFlags        *string
+	LastDownloadedAt    *time.Time
+	TorrentKey          *string
+	MetalinkKey         *string
+	InfoHash            *string
+	IntegrityCheckedAt  *time.Time
+	RetentionNotifiedAt *time.Time
+	Hash                *string
+	Size                *int64
+	VersionPatch        *int
+	VersionMinor        *int
+	VersionMajor        *int
+	ModReference        *string
+	SMRModel
+	Changelog       string
+	ChangelogSource string `gorm:"type:varchar(16);default:'user';not null"`
+	ContainerType   string `gorm:"type:varchar(8);default:'smod';not null"`
+	StorageTier     string `gorm:"type:varchar(16);default:'hot';not null"`
+	Stability       string `gorm:"default:'alpha'" sql:"type:version_stability"`
+	Key             string
+	SMLVersion      string `gorm:"type:varchar(16)"`
+	Version         string `gorm:"type:varchar(16)"`
+	ModID           string
+	Targets         []VersionTarget `gorm:"foreignKey:VersionID"`
+	Hotness         uint
+	Downloads       uint
+	Denied          bool `gorm:"default:false;not null"`
+	Approved        bool `gorm:"default:false;not null"`
+	Draft           bool `gorm:"default:false;not null"`
+	LegacyTarget    bool `gorm:"default:false;not null"`
+	Quarantined     bool `gorm:"default:false;not null"`
+	Corrupted       bool `gorm:"default:false;not null"`
 }
 
 type TinyVersion struct {
@@ -119,6 +143,21 @@ type Guide struct {
 	Views            uint
 }
 
+type Blueprint struct {
+	SMRModel
+	Name             string `gorm:"type:varchar(50)"`
+	ShortDescription string `gorm:"type:varchar(128)"`
+	FullDescription  string
+	UserID           string
+	Key              string
+	Hash             string
+	Size             int64
+	Tags             []Tag `gorm:"many2many:blueprint_tags"`
+	User             User
+	Views            uint
+	Downloads        uint
+}
+
 type UserGroup struct {
 	SMRDates
 
@@ -185,6 +224,11 @@ type GuideTag struct {
 	GuideID string `gorm:"primary_key;type:varchar(16)"`
 }
 
+type BlueprintTag struct {
+	TagID       string `gorm:"primary_key;type:varchar(24)"`
+	BlueprintID string `gorm:"primary_key;type:varchar(16)"`
+}
+
 type CompatibilityInfo struct {
 	EA  Compatibility `gorm:"type:compatibility"`
 	EXP Compatibility `gorm:"type:compatibility"`
@@ -203,8 +247,268 @@ type VersionTarget struct {
 	Size       int64
 }
 
+// VersionTargetPatch is a binary patch turning a target of FromVersionID into the same target of VersionID
+type VersionTargetPatch struct {
+	VersionID     string `gorm:"primary_key;type:varchar(14)"`
+	FromVersionID string `gorm:"primary_key;type:varchar(14)"`
+	TargetName    string `gorm:"primary_key;type:varchar(16)"`
+	Key           string
+	Hash          string
+	Size          int64
+	CreatedAt     time.Time
+}
+
+// Storage tiers of a Version
+const (
+	StorageTierHot         = "hot"
+	StorageTierCold        = "cold"
+	StorageTierRehydrating = "rehydrating"
+)
+
+// Replication states of a FileReplica
+const (
+	ReplicaStatePending    = "pending"
+	ReplicaStateReplicated = "replicated"
+	ReplicaStateFailed     = "failed"
+)
+
+// FileReplica tracks the copy of a version file on a storage mirror
+type FileReplica struct {
+	Key       string `gorm:"primary_key"`
+	Mirror    string `gorm:"primary_key;type:varchar(32)"`
+	VersionID string `gorm:"type:varchar(14)"`
+	State     string `gorm:"type:varchar(16)"`
+	Error     *string
+	UpdatedAt time.Time
+}
+
+// Blob is a content addressed file, shared by every version target with the same content
+type Blob struct {
+	Hash      string `gorm:"primary_key;type:char(64)"`
+	Key       string
+	Size      int64
+	RefCount  int
+	CreatedAt time.Time
+}
+
+// VersionBandwidth is the amount of data served for a version on a day
+type VersionBandwidth struct {
+	VersionID string    `gorm:"primary_key;type:varchar(14)"`
+	Day       time.Time `gorm:"primary_key;type:date"`
+	ModID     string
+	Bytes     int64
+	Requests  int
+}
+
+func (VersionBandwidth) TableName() string {
+	return "version_bandwidth"
+}
+
 type SMLVersionTarget struct {
 	VersionID  string `gorm:"primary_key;type:varchar(14)"`
 	TargetName string `gorm:"primary_key;type:varchar(16)"`
 	Link       string
 }
+
+type QuotaOverride struct {
+	UserID        *string
+	ModID         *string
+	MaxFileSize   *int64
+	MaxModStorage *int64
+	SMRModel
+}
+
+// States of a ModTransfer
+const (
+	ModTransferStatePending   = "pending"
+	ModTransferStateAccepted  = "accepted"
+	ModTransferStateDeclined  = "declined"
+	ModTransferStateCancelled = "cancelled"
+)
+
+// ModTransfer is a request to hand the ownership of a mod to another user, which has to be accepted by them
+type ModTransfer struct {
+	SMRModel
+	ModID         string `gorm:"type:varchar(14)"`
+	FromUserID    string `gorm:"type:varchar(14)"`
+	ToUserID      string `gorm:"type:varchar(14)"`
+	RequestedByID string `gorm:"type:varchar(14)"`
+	Status        string `gorm:"type:varchar(16)"`
+}
+
+// AuditLog records a sensitive action performed by a user on an object
+type AuditLog struct {
+	ID         string `gorm:"primary_key;type:varchar(14)"`
+	UserID     *string
+	Action     string                 `gorm:"type:varchar(64)"`
+	TargetType string                 `gorm:"type:varchar(32)"`
+	TargetID   string                 `gorm:"type:varchar(14)"`
+	Data       map[string]interface{} `gorm:"serializer:json"`
+	Changes    map[string]diff.Change `gorm:"serializer:json"` // Fields of the target changed by the action
+	IP         *string                `gorm:"type:varchar(45)"`
+	CreatedAt  time.Time
+}
+
+// ModFavorite is a mod tracked by a user
+type ModFavorite struct {
+	UserID            string `gorm:"primary_key;type:varchar(14)"`
+	ModID             string `gorm:"primary_key;type:varchar(14)"`
+	LastSeenVersionID *string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// ModRating is the rating of a mod by a user, from 1 to 5 stars
+type ModRating struct {
+	UserID    string `gorm:"primary_key;type:varchar(14)"`
+	ModID     string `gorm:"primary_key;type:varchar(14)"`
+	Rating    int
+	Review    *string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// UserModDownload records that a user downloaded a version of a mod
+type UserModDownload struct {
+	UserID    string `gorm:"primary_key;type:varchar(14)"`
+	ModID     string `gorm:"primary_key;type:varchar(14)"`
+	CreatedAt time.Time
+}
+
+// Comment is a message posted on a mod, in reply to another comment of the mod if ParentID is set
+type Comment struct {
+	ParentID    *string
+	EditedAt    *time.Time
+	DeletedByID *string
+	SMRModel
+	ModID  string
+	UserID string
+	Body   string
+}
+
+// Types of content a Report can target
+const (
+	ReportTargetMod     = "mod"
+	ReportTargetVersion = "version"
+	ReportTargetComment = "comment"
+	ReportTargetUser    = "user"
+)
+
+// States of a Report, open and triaged reports are waiting for moderators to act
+const (
+	ReportStateOpen      = "open"
+	ReportStateTriaged   = "triaged"
+	ReportStateResolved  = "resolved"
+	ReportStateDismissed = "dismissed"
+)
+
+// Report is a report of abusive content by a user
+type Report struct {
+	Details      *string
+	ResolvedByID *string
+	ResolvedAt   *time.Time
+	Note         *string
+	ID           string `gorm:"primary_key;type:varchar(14)"`
+	ReporterID   string `gorm:"type:varchar(14)"`
+	TargetType   string `gorm:"type:varchar(16)"`
+	TargetID     string `gorm:"type:varchar(14)"`
+	Reason       string `gorm:"type:varchar(32)"`
+	State        string `gorm:"type:varchar(16);default:'open';not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Types of a Notification
+const (
+	NotificationModApproved       = "mod_approved"
+	NotificationModDenied         = "mod_denied"
+	NotificationVersionApproved   = "version_approved"
+	NotificationVersionDenied     = "version_denied"
+	NotificationDependencyUpdated = "dependency_updated"
+)
+
+// Notification is a message shown to a user in the app
+type Notification struct {
+	ID        string `gorm:"primary_key;type:varchar(14)"`
+	UserID    string `gorm:"type:varchar(14)"`
+	Type      string `gorm:"type:varchar(32)"`
+	Message   string
+	ModID     *string
+	VersionID *string
+	ReadAt    *time.Time
+	CreatedAt time.Time
+}
+
+// ModDailyStat counts the unique views and downloads of a mod on a day
+type ModDailyStat struct {
+	ModID     string    `gorm:"primary_key;type:varchar(14)"`
+	Day       time.Time `gorm:"primary_key;type:date"`
+	Views     int
+	Downloads int
+}
+
+// ModTrending is the trending score of a mod over a period, precomputed from its daily stats
+type ModTrending struct {
+	ModID      string `gorm:"primary_key;type:varchar(14)"`
+	Period     string `gorm:"primary_key;type:varchar(16)"`
+	Score      float64
+	Views      int
+	Downloads  int
+	ComputedAt time.Time
+}
+
+func (ModTrending) TableName() string {
+	return "mod_trending"
+}
+
+// VersionDownloadStat counts the unique downloads of a version target on a day.
+// TargetName is empty for downloads of the whole version.
+type VersionDownloadStat struct {
+	VersionID  string    `gorm:"primary_key;type:varchar(14)"`
+	TargetName string    `gorm:"primary_key;type:varchar(16)"`
+	Day        time.Time `gorm:"primary_key;type:date"`
+	ModID      string
+	Downloads  int
+}
+
+// Collection is an ordered list of mods shared by a user, such as a modpack
+type Collection struct {
+	SMRModel
+	UserID      string `gorm:"type:varchar(14)"`
+	Name        string `gorm:"type:varchar(64)"`
+	Description string
+	Slug        string          `gorm:"type:varchar(16)"`
+	Mods        []CollectionMod `gorm:"foreignKey:CollectionID"`
+}
+
+// CollectionMod is a mod of a Collection, optionally pinned to the versions matching a constraint
+type CollectionMod struct {
+	CollectionID      string `gorm:"primary_key;type:varchar(14)"`
+	ModReference      string `gorm:"primary_key;type:varchar(32)"`
+	Position          int
+	VersionConstraint *string `gorm:"type:varchar(64)"`
+}
+
+// Events a Webhook can subscribe to
+const (
+	WebhookEventVersionCreated  = "version_created"
+	WebhookEventVersionApproved = "version_approved"
+	WebhookEventCommentCreated  = "comment_created"
+	WebhookEventReportCreated   = "report_created"
+)
+
+// Webhook delivers the events of its owner's choice to an URL, signed with its secret.
+// Webhooks without mods receive the events of every mod.
+type Webhook struct {
+	LastStatus     *int
+	LastError      *string
+	LastDeliveryAt *time.Time
+	SMRModel
+	UserID       string   `gorm:"type:varchar(14)"`
+	URL          string   `gorm:"column:url"`
+	Secret       string   `gorm:"type:varchar(64)"`
+	Events       []string `gorm:"serializer:json"`
+	ModIDs       []string `gorm:"column:mod_ids;serializer:json"`
+	Active       bool
+	FailureCount int
+}
@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// softDeletedTable is a table of soft deleted rows which own nothing besides the rows of their dependents
+type softDeletedTable struct {
+	name string
+	// dependents are the "table.column" referencing the rows, which are purged along with them
+	dependents []string
+}
+
+// softDeletedTables are purged by PurgeDeletedRows.
+//
+// Mods, versions, blueprints and comments have their own purge, as they own files or are referenced by rows
+// which have to go first. Users are never purged, their content and moderation history outlive them.
+var softDeletedTables = []softDeletedTable{
+	{name: "announcements"},
+	{name: "bootstrap_versions"},
+	{name: "collections", dependents: []string{"collection_mods.collection_id"}},
+	{name: "guides", dependents: []string{"guide_tags.guide_id"}},
+	{name: "mod_transfers"},
+	{name: "quota_overrides"},
+	{name: "sml_versions", dependents: []string{"sml_version_targets.version_id"}},
+	{name: "tags", dependents: []string{"mod_tags.tag_id", "guide_tags.tag_id", "blueprint_tags.tag_id"}},
+	{name: "user_sessions"},
+	{name: "webhooks"},
+}
+
+// modDependents are the tables referencing a mod by their mod_id column, which are purged along with it
+var modDependents = []string{
+	"comments",
+	"mod_tags",
+	"user_mods",
+	"mod_favorites",
+	"mod_ratings",
+	"user_mod_downloads",
+	"mod_transfers",
+	"quota_overrides",
+	"notifications",
+	"mod_daily_stats",
+	"mod_trending",
+	"version_bandwidth",
+	"version_download_stats",
+	"download_events",
+}
+
+// PurgeDeletedRows permanently deletes up to limit rows of every table in softDeletedTables deleted before the
+// provided time, and returns the amount of rows purged by table
+func PurgeDeletedRows(ctx context.Context, deletedBefore time.Time, limit int) (map[string]int, error) {
+	purged := make(map[string]int, len(softDeletedTables))
+
+	for _, table := range softDeletedTables {
+		table := table
+
+		var ids []string
+		DBCtx(ctx).Table(table.name).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+			Order("deleted_at asc").
+			Limit(limit).
+			Pluck("id", &ids)
+
+		if len(ids) == 0 {
+			continue
+		}
+
+		err := Tx(ctx, func(ctx context.Context) error {
+			for _, dependent := range table.dependents {
+				dependentTable, column, _ := strings.Cut(dependent, ".")
+				if err := DBCtx(ctx).Exec("DELETE FROM "+dependentTable+" WHERE "+column+" IN ?", ids).Error; err != nil {
+					return err
+				}
+			}
+
+			return DBCtx(ctx).Exec("DELETE FROM "+table.name+" WHERE id IN ?", ids).Error
+		})
+		if err != nil {
+			return purged, errors.Wrap(err, "failed to purge "+table.name)
+		}
+
+		purged[table.name] = len(ids)
+	}
+
+	return purged, nil
+}
+
+// modNotDependedOn excludes the mods still depended on by versions of other mods, whose files have to stay available
+const modNotDependedOn = `NOT EXISTS (
+	SELECT 1 FROM version_dependencies
+	JOIN versions ON versions.id = version_dependencies.version_id
+	WHERE version_dependencies.mod_id = mods.mod_reference AND versions.mod_id <> mods.id
+)`
+
+// GetDeletedModPurgeCandidates returns mods deleted before the provided time,
+// except those still depended on by versions of other mods
+func GetDeletedModPurgeCandidates(ctx context.Context, deletedBefore time.Time, limit int) []Mod {
+	var mods []Mod
+	DBCtx(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+		Where(modNotDependedOn).
+		Order("deleted_at asc").
+		Limit(limit).
+		Find(&mods)
+	return mods
+}
+
+// GetDeletedModForPurge returns a deleted mod, unless it is still depended on by versions of other mods
+func GetDeletedModForPurge(ctx context.Context, modID string) *Mod {
+	var mod Mod
+	DBCtx(ctx).Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", modID).
+		Where(modNotDependedOn).
+		First(&mod)
+
+	if mod.ID == "" {
+		return nil
+	}
+
+	return &mod
+}
+
+// GetModVersionsForPurge returns every version of a mod, including the deleted ones
+func GetModVersionsForPurge(ctx context.Context, modID string) []Version {
+	var versions []Version
+	DBCtx(ctx).Unscoped().Preload("Targets").
+		Where("mod_id = ?", modID).
+		Find(&versions)
+	return versions
+}
+
+// PurgeMod permanently deletes a mod and every row referencing it. Its versions have to be purged beforehand.
+func PurgeMod(ctx context.Context, modID string) error {
+	err := Tx(ctx, func(ctx context.Context) error {
+		for _, table := range modDependents {
+			if err := DBCtx(ctx).Exec("DELETE FROM "+table+" WHERE mod_id = ?", modID).Error; err != nil {
+				return err
+			}
+		}
+
+		return DBCtx(ctx).Unscoped().Where("id = ?", modID).Delete(&Mod{}).Error
+	})
+
+	return errors.Wrap(err, "failed to purge mod")
+}
+
+// GetDeletedBlueprintPurgeCandidates returns blueprints deleted before the provided time
+func GetDeletedBlueprintPurgeCandidates(ctx context.Context, deletedBefore time.Time, limit int) []Blueprint {
+	var blueprints []Blueprint
+	DBCtx(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+		Order("deleted_at asc").
+		Limit(limit).
+		Find(&blueprints)
+	return blueprints
+}
+
+// PurgeBlueprint permanently deletes a blueprint and its tags
+func PurgeBlueprint(ctx context.Context, blueprintID string) error {
+	err := Tx(ctx, func(ctx context.Context) error {
+		if err := DBCtx(ctx).Where("blueprint_id = ?", blueprintID).Delete(&BlueprintTag{}).Error; err != nil {
+			return err
+		}
+
+		return DBCtx(ctx).Unscoped().Where("id = ?", blueprintID).Delete(&Blueprint{}).Error
+	})
+
+	return errors.Wrap(err, "failed to purge blueprint")
+}
+
+// PurgeDeletedComments permanently deletes up to limit comments deleted before the provided time.
+//
+// Comments keep their place in the thread while replies to them remain,
+// so threads are purged from their leaves over successive runs.
+func PurgeDeletedComments(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	result := DBCtx(ctx).Exec(`DELETE FROM comments WHERE id IN (
+		SELECT comments.id FROM comments
+		WHERE comments.deleted_at IS NOT NULL AND comments.deleted_at < ?
+		AND NOT EXISTS (SELECT 1 FROM comments replies WHERE replies.parent_id = comments.id)
+		ORDER BY comments.deleted_at asc
+		LIMIT ?
+	)`, deletedBefore, limit)
+
+	ClearCache()
+
+	return int(result.RowsAffected), errors.Wrap(result.Error, "failed to purge comments")
+}
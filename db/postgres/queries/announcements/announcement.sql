@@ -0,0 +1,36 @@
+-- name: CreateAnnouncement :one
+insert into announcements (id, message, importance, created_at, updated_at)
+values ($1, $2, $3, now(), now())
+returning *;
+
+-- name: GetAnnouncementByID :one
+select *
+from announcements
+where id = $1
+  and deleted_at is null;
+
+-- name: ListAnnouncements :many
+select *
+from announcements
+where deleted_at is null;
+
+-- name: ListAnnouncementsByImportance :many
+select *
+from announcements
+where importance = $1
+  and deleted_at is null;
+
+-- name: UpdateAnnouncement :one
+update announcements
+set message    = $2,
+    importance = $3,
+    updated_at = now()
+where id = $1
+  and deleted_at is null
+returning *;
+
+-- name: DeleteAnnouncement :exec
+update announcements
+set deleted_at = now()
+where id = $1
+  and deleted_at is null;
@@ -0,0 +1,172 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+// source: announcement.sql
+
+package announcements
+
+import (
+	"context"
+)
+
+const createAnnouncement = `-- name: CreateAnnouncement :one
+insert into announcements (id, message, importance, created_at, updated_at)
+values ($1, $2, $3, now(), now())
+returning id, message, importance, created_at, updated_at, deleted_at
+`
+
+type CreateAnnouncementParams struct {
+	ID         string
+	Message    string
+	Importance string
+}
+
+func (q *Queries) CreateAnnouncement(ctx context.Context, arg CreateAnnouncementParams) (Announcement, error) {
+	row := q.db.QueryRowContext(ctx, createAnnouncement, arg.ID, arg.Message, arg.Importance)
+	var i Announcement
+	err := row.Scan(
+		&i.ID,
+		&i.Message,
+		&i.Importance,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const deleteAnnouncement = `-- name: DeleteAnnouncement :exec
+update announcements
+set deleted_at = now()
+where id = $1
+  and deleted_at is null
+`
+
+func (q *Queries) DeleteAnnouncement(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteAnnouncement, id)
+	return err
+}
+
+const getAnnouncementByID = `-- name: GetAnnouncementByID :one
+select id, message, importance, created_at, updated_at, deleted_at
+from announcements
+where id = $1
+  and deleted_at is null
+`
+
+func (q *Queries) GetAnnouncementByID(ctx context.Context, id string) (Announcement, error) {
+	row := q.db.QueryRowContext(ctx, getAnnouncementByID, id)
+	var i Announcement
+	err := row.Scan(
+		&i.ID,
+		&i.Message,
+		&i.Importance,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const listAnnouncements = `-- name: ListAnnouncements :many
+select id, message, importance, created_at, updated_at, deleted_at
+from announcements
+where deleted_at is null
+`
+
+func (q *Queries) ListAnnouncements(ctx context.Context) ([]Announcement, error) {
+	rows, err := q.db.QueryContext(ctx, listAnnouncements)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Announcement
+	for rows.Next() {
+		var i Announcement
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Importance,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAnnouncementsByImportance = `-- name: ListAnnouncementsByImportance :many
+select id, message, importance, created_at, updated_at, deleted_at
+from announcements
+where importance = $1
+  and deleted_at is null
+`
+
+func (q *Queries) ListAnnouncementsByImportance(ctx context.Context, importance string) ([]Announcement, error) {
+	rows, err := q.db.QueryContext(ctx, listAnnouncementsByImportance, importance)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Announcement
+	for rows.Next() {
+		var i Announcement
+		if err := rows.Scan(
+			&i.ID,
+			&i.Message,
+			&i.Importance,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateAnnouncement = `-- name: UpdateAnnouncement :one
+update announcements
+set message    = $2,
+    importance = $3,
+    updated_at = now()
+where id = $1
+  and deleted_at is null
+returning id, message, importance, created_at, updated_at, deleted_at
+`
+
+type UpdateAnnouncementParams struct {
+	ID         string
+	Message    string
+	Importance string
+}
+
+func (q *Queries) UpdateAnnouncement(ctx context.Context, arg UpdateAnnouncementParams) (Announcement, error) {
+	row := q.db.QueryRowContext(ctx, updateAnnouncement, arg.ID, arg.Message, arg.Importance)
+	var i Announcement
+	err := row.Scan(
+		&i.ID,
+		&i.Message,
+		&i.Importance,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,19 @@
+-- name: ListVersionDependencies :many
+select *
+from version_dependencies
+where version_id = any (@version_ids::varchar[])
+  and deleted_at is null;
+
+-- name: UpsertVersionDependencies :exec
+insert into version_dependencies (version_id, mod_id, condition, optional, created_at, updated_at)
+select @version_id::varchar,
+       unnest(@mod_ids::varchar[]),
+       unnest(@conditions::varchar[]),
+       unnest(@optionals::boolean[]),
+       now(),
+       now()
+on conflict (version_id, mod_id) do update
+    set condition  = excluded.condition,
+        optional   = excluded.optional,
+        updated_at = excluded.updated_at,
+        deleted_at = null;
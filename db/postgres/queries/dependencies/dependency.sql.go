@@ -0,0 +1,82 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+// source: dependency.sql
+
+package dependencies
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const listVersionDependencies = `-- name: ListVersionDependencies :many
+select created_at, updated_at, deleted_at, version_id, mod_id, condition, optional
+from version_dependencies
+where version_id = any ($1::varchar[])
+  and deleted_at is null
+`
+
+func (q *Queries) ListVersionDependencies(ctx context.Context, versionIds []string) ([]VersionDependency, error) {
+	rows, err := q.db.QueryContext(ctx, listVersionDependencies, pq.Array(versionIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VersionDependency
+	for rows.Next() {
+		var i VersionDependency
+		if err := rows.Scan(
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.VersionID,
+			&i.ModID,
+			&i.Condition,
+			&i.Optional,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertVersionDependencies = `-- name: UpsertVersionDependencies :exec
+insert into version_dependencies (version_id, mod_id, condition, optional, created_at, updated_at)
+select $1::varchar,
+       unnest($2::varchar[]),
+       unnest($3::varchar[]),
+       unnest($4::boolean[]),
+       now(),
+       now()
+on conflict (version_id, mod_id) do update
+    set condition  = excluded.condition,
+        optional   = excluded.optional,
+        updated_at = excluded.updated_at,
+        deleted_at = null
+`
+
+type UpsertVersionDependenciesParams struct {
+	VersionID  string
+	ModIds     []string
+	Conditions []string
+	Optionals  []bool
+}
+
+func (q *Queries) UpsertVersionDependencies(ctx context.Context, arg UpsertVersionDependenciesParams) error {
+	_, err := q.db.ExecContext(ctx, upsertVersionDependencies,
+		arg.VersionID,
+		pq.Array(arg.ModIds),
+		pq.Array(arg.Conditions),
+		pq.Array(arg.Optionals),
+	)
+	return err
+}
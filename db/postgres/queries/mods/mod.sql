@@ -0,0 +1,82 @@
+-- name: GetModByID :one
+select *
+from mods
+where id = $1
+  and deleted_at is null;
+
+-- name: GetModByReference :one
+select *
+from mods
+where mod_reference = $1
+  and deleted_at is null;
+
+-- name: GetModByIDOrReference :one
+select *
+from mods
+where (mod_reference = $1 or id = $1)
+  and deleted_at is null
+limit 1;
+
+-- name: ListModsByID :many
+select *
+from mods
+where id = any (@ids::varchar[])
+  and deleted_at is null;
+
+-- name: ListModsByReference :many
+select *
+from mods
+where mod_reference = any (@mod_references::text[])
+  and deleted_at is null;
+
+-- name: ListModsByIDOrReference :many
+select *
+from mods
+where (mod_reference = any (@ids::text[]) or id = any (@ids::varchar[]))
+  and deleted_at is null;
+
+-- name: UpdateMod :one
+update mods
+set name              = $2,
+    short_description = $3,
+    full_description  = $4,
+    logo              = $5,
+    logo_variants     = $6,
+    source_url        = $7,
+    creator_id        = $8,
+    mod_reference     = $9,
+    approved          = $10,
+    denied            = $11,
+    hidden            = $12,
+    compatibility     = $13,
+    last_version_date = $14,
+    archived          = $15,
+    archive_reason    = $16,
+    archived_at       = $17,
+    updated_at        = now()
+where id = $1
+  and deleted_at is null
+  and (sqlc.narg('expected_updated_at')::timestamptz is null or updated_at = sqlc.narg('expected_updated_at'))
+returning updated_at;
+
+-- name: ListModTags :many
+select mod_tags.mod_id, tags.*
+from tags
+         join mod_tags on mod_tags.tag_id = tags.id
+where mod_tags.mod_id = any (@mod_ids::varchar[])
+  and tags.deleted_at is null;
+
+-- name: ClearModTags :exec
+delete
+from mod_tags
+where mod_id = $1;
+
+-- name: AddModTag :exec
+insert into mod_tags (mod_id, tag_id)
+values ($1, $2);
+
+-- name: RemoveModTag :exec
+delete
+from mod_tags
+where mod_id = $1
+  and tag_id = $2;
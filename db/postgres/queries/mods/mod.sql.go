@@ -0,0 +1,485 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+// source: mod.sql
+
+package mods
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+)
+
+const addModTag = `-- name: AddModTag :exec
+insert into mod_tags (mod_id, tag_id)
+values ($1, $2)
+`
+
+type AddModTagParams struct {
+	ModID string
+	TagID string
+}
+
+func (q *Queries) AddModTag(ctx context.Context, arg AddModTagParams) error {
+	_, err := q.db.ExecContext(ctx, addModTag, arg.ModID, arg.TagID)
+	return err
+}
+
+const clearModTags = `-- name: ClearModTags :exec
+delete
+from mod_tags
+where mod_id = $1
+`
+
+func (q *Queries) ClearModTags(ctx context.Context, modID string) error {
+	_, err := q.db.ExecContext(ctx, clearModTags, modID)
+	return err
+}
+
+const getModByID = `-- name: GetModByID :one
+select id, created_at, updated_at, deleted_at, name, short_description, full_description, logo, source_url, creator_id, approved, views, hotness, popularity, downloads, denied, last_version_date, mod_reference, hidden, compatibility, logo_variants, archived, archive_reason, archived_at, favorites, rating, rating_count, search_vector
+from mods
+where id = $1
+  and deleted_at is null
+`
+
+func (q *Queries) GetModByID(ctx context.Context, id string) (Mod, error) {
+	row := q.db.QueryRowContext(ctx, getModByID, id)
+	var i Mod
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Name,
+		&i.ShortDescription,
+		&i.FullDescription,
+		&i.Logo,
+		&i.SourceUrl,
+		&i.CreatorID,
+		&i.Approved,
+		&i.Views,
+		&i.Hotness,
+		&i.Popularity,
+		&i.Downloads,
+		&i.Denied,
+		&i.LastVersionDate,
+		&i.ModReference,
+		&i.Hidden,
+		&i.Compatibility,
+		&i.LogoVariants,
+		&i.Archived,
+		&i.ArchiveReason,
+		&i.ArchivedAt,
+		&i.Favorites,
+		&i.Rating,
+		&i.RatingCount,
+		&i.SearchVector,
+	)
+	return i, err
+}
+
+const getModByIDOrReference = `-- name: GetModByIDOrReference :one
+select id, created_at, updated_at, deleted_at, name, short_description, full_description, logo, source_url, creator_id, approved, views, hotness, popularity, downloads, denied, last_version_date, mod_reference, hidden, compatibility, logo_variants, archived, archive_reason, archived_at, favorites, rating, rating_count, search_vector
+from mods
+where (mod_reference = $1 or id = $1)
+  and deleted_at is null
+limit 1
+`
+
+func (q *Queries) GetModByIDOrReference(ctx context.Context, modReference string) (Mod, error) {
+	row := q.db.QueryRowContext(ctx, getModByIDOrReference, modReference)
+	var i Mod
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Name,
+		&i.ShortDescription,
+		&i.FullDescription,
+		&i.Logo,
+		&i.SourceUrl,
+		&i.CreatorID,
+		&i.Approved,
+		&i.Views,
+		&i.Hotness,
+		&i.Popularity,
+		&i.Downloads,
+		&i.Denied,
+		&i.LastVersionDate,
+		&i.ModReference,
+		&i.Hidden,
+		&i.Compatibility,
+		&i.LogoVariants,
+		&i.Archived,
+		&i.ArchiveReason,
+		&i.ArchivedAt,
+		&i.Favorites,
+		&i.Rating,
+		&i.RatingCount,
+		&i.SearchVector,
+	)
+	return i, err
+}
+
+const getModByReference = `-- name: GetModByReference :one
+select id, created_at, updated_at, deleted_at, name, short_description, full_description, logo, source_url, creator_id, approved, views, hotness, popularity, downloads, denied, last_version_date, mod_reference, hidden, compatibility, logo_variants, archived, archive_reason, archived_at, favorites, rating, rating_count, search_vector
+from mods
+where mod_reference = $1
+  and deleted_at is null
+`
+
+func (q *Queries) GetModByReference(ctx context.Context, modReference string) (Mod, error) {
+	row := q.db.QueryRowContext(ctx, getModByReference, modReference)
+	var i Mod
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Name,
+		&i.ShortDescription,
+		&i.FullDescription,
+		&i.Logo,
+		&i.SourceUrl,
+		&i.CreatorID,
+		&i.Approved,
+		&i.Views,
+		&i.Hotness,
+		&i.Popularity,
+		&i.Downloads,
+		&i.Denied,
+		&i.LastVersionDate,
+		&i.ModReference,
+		&i.Hidden,
+		&i.Compatibility,
+		&i.LogoVariants,
+		&i.Archived,
+		&i.ArchiveReason,
+		&i.ArchivedAt,
+		&i.Favorites,
+		&i.Rating,
+		&i.RatingCount,
+		&i.SearchVector,
+	)
+	return i, err
+}
+
+const listModTags = `-- name: ListModTags :many
+select mod_tags.mod_id, tags.id, tags.name, tags.created_at, tags.updated_at, tags.deleted_at, tags.description
+from tags
+         join mod_tags on mod_tags.tag_id = tags.id
+where mod_tags.mod_id = any ($1::varchar[])
+  and tags.deleted_at is null
+`
+
+type ListModTagsRow struct {
+	ModID       string
+	ID          string
+	Name        string
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	DeletedAt   sql.NullTime
+	Description sql.NullString
+}
+
+func (q *Queries) ListModTags(ctx context.Context, modIds []string) ([]ListModTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listModTags, pq.Array(modIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListModTagsRow
+	for rows.Next() {
+		var i ListModTagsRow
+		if err := rows.Scan(
+			&i.ModID,
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Description,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listModsByID = `-- name: ListModsByID :many
+select id, created_at, updated_at, deleted_at, name, short_description, full_description, logo, source_url, creator_id, approved, views, hotness, popularity, downloads, denied, last_version_date, mod_reference, hidden, compatibility, logo_variants, archived, archive_reason, archived_at, favorites, rating, rating_count, search_vector
+from mods
+where id = any ($1::varchar[])
+  and deleted_at is null
+`
+
+func (q *Queries) ListModsByID(ctx context.Context, ids []string) ([]Mod, error) {
+	rows, err := q.db.QueryContext(ctx, listModsByID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Mod
+	for rows.Next() {
+		var i Mod
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Name,
+			&i.ShortDescription,
+			&i.FullDescription,
+			&i.Logo,
+			&i.SourceUrl,
+			&i.CreatorID,
+			&i.Approved,
+			&i.Views,
+			&i.Hotness,
+			&i.Popularity,
+			&i.Downloads,
+			&i.Denied,
+			&i.LastVersionDate,
+			&i.ModReference,
+			&i.Hidden,
+			&i.Compatibility,
+			&i.LogoVariants,
+			&i.Archived,
+			&i.ArchiveReason,
+			&i.ArchivedAt,
+			&i.Favorites,
+			&i.Rating,
+			&i.RatingCount,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listModsByIDOrReference = `-- name: ListModsByIDOrReference :many
+select id, created_at, updated_at, deleted_at, name, short_description, full_description, logo, source_url, creator_id, approved, views, hotness, popularity, downloads, denied, last_version_date, mod_reference, hidden, compatibility, logo_variants, archived, archive_reason, archived_at, favorites, rating, rating_count, search_vector
+from mods
+where (mod_reference = any ($1::text[]) or id = any ($1::varchar[]))
+  and deleted_at is null
+`
+
+func (q *Queries) ListModsByIDOrReference(ctx context.Context, ids []string) ([]Mod, error) {
+	rows, err := q.db.QueryContext(ctx, listModsByIDOrReference, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Mod
+	for rows.Next() {
+		var i Mod
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Name,
+			&i.ShortDescription,
+			&i.FullDescription,
+			&i.Logo,
+			&i.SourceUrl,
+			&i.CreatorID,
+			&i.Approved,
+			&i.Views,
+			&i.Hotness,
+			&i.Popularity,
+			&i.Downloads,
+			&i.Denied,
+			&i.LastVersionDate,
+			&i.ModReference,
+			&i.Hidden,
+			&i.Compatibility,
+			&i.LogoVariants,
+			&i.Archived,
+			&i.ArchiveReason,
+			&i.ArchivedAt,
+			&i.Favorites,
+			&i.Rating,
+			&i.RatingCount,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listModsByReference = `-- name: ListModsByReference :many
+select id, created_at, updated_at, deleted_at, name, short_description, full_description, logo, source_url, creator_id, approved, views, hotness, popularity, downloads, denied, last_version_date, mod_reference, hidden, compatibility, logo_variants, archived, archive_reason, archived_at, favorites, rating, rating_count, search_vector
+from mods
+where mod_reference = any ($1::text[])
+  and deleted_at is null
+`
+
+func (q *Queries) ListModsByReference(ctx context.Context, modReferences []string) ([]Mod, error) {
+	rows, err := q.db.QueryContext(ctx, listModsByReference, pq.Array(modReferences))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Mod
+	for rows.Next() {
+		var i Mod
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Name,
+			&i.ShortDescription,
+			&i.FullDescription,
+			&i.Logo,
+			&i.SourceUrl,
+			&i.CreatorID,
+			&i.Approved,
+			&i.Views,
+			&i.Hotness,
+			&i.Popularity,
+			&i.Downloads,
+			&i.Denied,
+			&i.LastVersionDate,
+			&i.ModReference,
+			&i.Hidden,
+			&i.Compatibility,
+			&i.LogoVariants,
+			&i.Archived,
+			&i.ArchiveReason,
+			&i.ArchivedAt,
+			&i.Favorites,
+			&i.Rating,
+			&i.RatingCount,
+			&i.SearchVector,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeModTag = `-- name: RemoveModTag :exec
+delete
+from mod_tags
+where mod_id = $1
+  and tag_id = $2
+`
+
+type RemoveModTagParams struct {
+	ModID string
+	TagID string
+}
+
+func (q *Queries) RemoveModTag(ctx context.Context, arg RemoveModTagParams) error {
+	_, err := q.db.ExecContext(ctx, removeModTag, arg.ModID, arg.TagID)
+	return err
+}
+
+const updateMod = `-- name: UpdateMod :one
+update mods
+set name              = $2,
+    short_description = $3,
+    full_description  = $4,
+    logo              = $5,
+    logo_variants     = $6,
+    source_url        = $7,
+    creator_id        = $8,
+    mod_reference     = $9,
+    approved          = $10,
+    denied            = $11,
+    hidden            = $12,
+    compatibility     = $13,
+    last_version_date = $14,
+    archived          = $15,
+    archive_reason    = $16,
+    archived_at       = $17,
+    updated_at        = now()
+where id = $1
+  and deleted_at is null
+  and ($18::timestamptz is null or updated_at = $18)
+returning updated_at
+`
+
+type UpdateModParams struct {
+	ID                string
+	Name              sql.NullString
+	ShortDescription  sql.NullString
+	FullDescription   sql.NullString
+	Logo              sql.NullString
+	LogoVariants      json.RawMessage
+	SourceUrl         sql.NullString
+	CreatorID         sql.NullString
+	ModReference      string
+	Approved          bool
+	Denied            bool
+	Hidden            sql.NullBool
+	Compatibility     json.RawMessage
+	LastVersionDate   sql.NullTime
+	Archived          bool
+	ArchiveReason     sql.NullString
+	ArchivedAt        sql.NullTime
+	ExpectedUpdatedAt sql.NullTime
+}
+
+func (q *Queries) UpdateMod(ctx context.Context, arg UpdateModParams) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, updateMod,
+		arg.ID,
+		arg.Name,
+		arg.ShortDescription,
+		arg.FullDescription,
+		arg.Logo,
+		arg.LogoVariants,
+		arg.SourceUrl,
+		arg.CreatorID,
+		arg.ModReference,
+		arg.Approved,
+		arg.Denied,
+		arg.Hidden,
+		arg.Compatibility,
+		arg.LastVersionDate,
+		arg.Archived,
+		arg.ArchiveReason,
+		arg.ArchivedAt,
+		arg.ExpectedUpdatedAt,
+	)
+	var updated_at sql.NullTime
+	err := row.Scan(&updated_at)
+	return updated_at, err
+}
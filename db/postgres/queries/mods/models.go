@@ -0,0 +1,41 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+
+package mods
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+type Mod struct {
+	ID               string
+	CreatedAt        sql.NullTime
+	UpdatedAt        sql.NullTime
+	DeletedAt        sql.NullTime
+	Name             sql.NullString
+	ShortDescription sql.NullString
+	FullDescription  sql.NullString
+	Logo             sql.NullString
+	SourceUrl        sql.NullString
+	CreatorID        sql.NullString
+	Approved         bool
+	Views            sql.NullInt32
+	Hotness          sql.NullInt32
+	Popularity       sql.NullInt32
+	Downloads        sql.NullInt32
+	Denied           bool
+	LastVersionDate  sql.NullTime
+	ModReference     string
+	Hidden           sql.NullBool
+	Compatibility    json.RawMessage
+	LogoVariants     json.RawMessage
+	Archived         bool
+	ArchiveReason    sql.NullString
+	ArchivedAt       sql.NullTime
+	Favorites        int32
+	Rating           sql.NullFloat64
+	RatingCount      int32
+	SearchVector     interface{}
+}
@@ -0,0 +1,17 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+
+package targets
+
+import (
+	"database/sql"
+)
+
+type VersionTarget struct {
+	VersionID  sql.NullString
+	TargetName sql.NullString
+	Key        sql.NullString
+	Hash       sql.NullString
+	Size       sql.NullInt64
+}
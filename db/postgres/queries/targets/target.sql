@@ -0,0 +1,22 @@
+-- name: GetVersionTarget :one
+select *
+from version_targets
+where version_id = $1
+  and target_name = $2;
+
+-- name: ListVersionTargets :many
+select *
+from version_targets
+where version_id = any (@version_ids::varchar[]);
+
+-- name: UpsertVersionTargets :exec
+insert into version_targets (version_id, target_name, key, hash, size)
+select unnest(@version_ids::varchar[]),
+       unnest(@target_names::varchar[]),
+       unnest(@keys::text[]),
+       unnest(@hashes::text[]),
+       unnest(@sizes::bigint[])
+on conflict (version_id, target_name) do update
+    set key  = excluded.key,
+        hash = excluded.hash,
+        size = excluded.size;
@@ -0,0 +1,105 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+// source: target.sql
+
+package targets
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const getVersionTarget = `-- name: GetVersionTarget :one
+select version_id, target_name, key, hash, size
+from version_targets
+where version_id = $1
+  and target_name = $2
+`
+
+type GetVersionTargetParams struct {
+	VersionID  sql.NullString
+	TargetName sql.NullString
+}
+
+func (q *Queries) GetVersionTarget(ctx context.Context, arg GetVersionTargetParams) (VersionTarget, error) {
+	row := q.db.QueryRowContext(ctx, getVersionTarget, arg.VersionID, arg.TargetName)
+	var i VersionTarget
+	err := row.Scan(
+		&i.VersionID,
+		&i.TargetName,
+		&i.Key,
+		&i.Hash,
+		&i.Size,
+	)
+	return i, err
+}
+
+const listVersionTargets = `-- name: ListVersionTargets :many
+select version_id, target_name, key, hash, size
+from version_targets
+where version_id = any ($1::varchar[])
+`
+
+func (q *Queries) ListVersionTargets(ctx context.Context, versionIds []string) ([]VersionTarget, error) {
+	rows, err := q.db.QueryContext(ctx, listVersionTargets, pq.Array(versionIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []VersionTarget
+	for rows.Next() {
+		var i VersionTarget
+		if err := rows.Scan(
+			&i.VersionID,
+			&i.TargetName,
+			&i.Key,
+			&i.Hash,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertVersionTargets = `-- name: UpsertVersionTargets :exec
+insert into version_targets (version_id, target_name, key, hash, size)
+select unnest($1::varchar[]),
+       unnest($2::varchar[]),
+       unnest($3::text[]),
+       unnest($4::text[]),
+       unnest($5::bigint[])
+on conflict (version_id, target_name) do update
+    set key  = excluded.key,
+        hash = excluded.hash,
+        size = excluded.size
+`
+
+type UpsertVersionTargetsParams struct {
+	VersionIds  []string
+	TargetNames []string
+	Keys        []string
+	Hashes      []string
+	Sizes       []int64
+}
+
+func (q *Queries) UpsertVersionTargets(ctx context.Context, arg UpsertVersionTargetsParams) error {
+	_, err := q.db.ExecContext(ctx, upsertVersionTargets,
+		pq.Array(arg.VersionIds),
+		pq.Array(arg.TargetNames),
+		pq.Array(arg.Keys),
+		pq.Array(arg.Hashes),
+		pq.Array(arg.Sizes),
+	)
+	return err
+}
@@ -0,0 +1,32 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+
+package users
+
+import (
+	"database/sql"
+)
+
+type User struct {
+	ID         string
+	CreatedAt  sql.NullTime
+	UpdatedAt  sql.NullTime
+	DeletedAt  sql.NullTime
+	Email      sql.NullString
+	Username   sql.NullString
+	Avatar     sql.NullString
+	JoinedFrom sql.NullString
+	Banned     bool
+	Rank       int32
+	GithubID   sql.NullString
+	GoogleID   sql.NullString
+	FacebookID sql.NullString
+	QuotaTier  sql.NullString
+}
+
+type UserMod struct {
+	UserID string
+	ModID  string
+	Role   sql.NullString
+}
@@ -0,0 +1,56 @@
+-- name: GetUserByID :one
+select *
+from users
+where id = $1
+  and deleted_at is null;
+
+-- name: ListUsersByID :many
+select *
+from users
+where id = any (@ids::varchar[])
+  and deleted_at is null;
+
+-- name: UpdateUser :exec
+update users
+set email       = $2,
+    username    = $3,
+    avatar      = $4,
+    joined_from = $5,
+    banned      = $6,
+    github_id   = $7,
+    google_id   = $8,
+    facebook_id = $9,
+    quota_tier  = $10,
+    updated_at  = now()
+where id = $1
+  and deleted_at is null;
+
+-- name: ListUserMods :many
+select user_mods.*
+from user_mods
+         join mods on mods.id = user_mods.mod_id
+where user_mods.user_id = $1
+  and mods.deleted_at is null;
+
+-- name: ListModAuthors :many
+select *
+from user_mods
+where mod_id = any (@mod_ids::varchar[]);
+
+-- name: GetModAuthor :one
+select *
+from user_mods
+where user_id = $1
+  and mod_id = $2;
+
+-- name: SetModAuthor :exec
+insert into user_mods (user_id, mod_id, role)
+values ($1, $2, $3)
+on conflict (user_id, mod_id) do update
+    set role = excluded.role;
+
+-- name: RemoveModAuthor :exec
+delete
+from user_mods
+where user_id = $1
+  and mod_id = $2;
@@ -0,0 +1,246 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+// source: user.sql
+
+package users
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const getModAuthor = `-- name: GetModAuthor :one
+select user_id, mod_id, role
+from user_mods
+where user_id = $1
+  and mod_id = $2
+`
+
+type GetModAuthorParams struct {
+	UserID string
+	ModID  string
+}
+
+func (q *Queries) GetModAuthor(ctx context.Context, arg GetModAuthorParams) (UserMod, error) {
+	row := q.db.QueryRowContext(ctx, getModAuthor, arg.UserID, arg.ModID)
+	var i UserMod
+	err := row.Scan(&i.UserID, &i.ModID, &i.Role)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+select id, created_at, updated_at, deleted_at, email, username, avatar, joined_from, banned, rank, github_id, google_id, facebook_id, quota_tier
+from users
+where id = $1
+  and deleted_at is null
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Email,
+		&i.Username,
+		&i.Avatar,
+		&i.JoinedFrom,
+		&i.Banned,
+		&i.Rank,
+		&i.GithubID,
+		&i.GoogleID,
+		&i.FacebookID,
+		&i.QuotaTier,
+	)
+	return i, err
+}
+
+const listModAuthors = `-- name: ListModAuthors :many
+select user_id, mod_id, role
+from user_mods
+where mod_id = any ($1::varchar[])
+`
+
+func (q *Queries) ListModAuthors(ctx context.Context, modIds []string) ([]UserMod, error) {
+	rows, err := q.db.QueryContext(ctx, listModAuthors, pq.Array(modIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserMod
+	for rows.Next() {
+		var i UserMod
+		if err := rows.Scan(&i.UserID, &i.ModID, &i.Role); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserMods = `-- name: ListUserMods :many
+select user_mods.user_id, user_mods.mod_id, user_mods.role
+from user_mods
+         join mods on mods.id = user_mods.mod_id
+where user_mods.user_id = $1
+  and mods.deleted_at is null
+`
+
+func (q *Queries) ListUserMods(ctx context.Context, userID string) ([]UserMod, error) {
+	rows, err := q.db.QueryContext(ctx, listUserMods, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserMod
+	for rows.Next() {
+		var i UserMod
+		if err := rows.Scan(&i.UserID, &i.ModID, &i.Role); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByID = `-- name: ListUsersByID :many
+select id, created_at, updated_at, deleted_at, email, username, avatar, joined_from, banned, rank, github_id, google_id, facebook_id, quota_tier
+from users
+where id = any ($1::varchar[])
+  and deleted_at is null
+`
+
+func (q *Queries) ListUsersByID(ctx context.Context, ids []string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Email,
+			&i.Username,
+			&i.Avatar,
+			&i.JoinedFrom,
+			&i.Banned,
+			&i.Rank,
+			&i.GithubID,
+			&i.GoogleID,
+			&i.FacebookID,
+			&i.QuotaTier,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeModAuthor = `-- name: RemoveModAuthor :exec
+delete
+from user_mods
+where user_id = $1
+  and mod_id = $2
+`
+
+type RemoveModAuthorParams struct {
+	UserID string
+	ModID  string
+}
+
+func (q *Queries) RemoveModAuthor(ctx context.Context, arg RemoveModAuthorParams) error {
+	_, err := q.db.ExecContext(ctx, removeModAuthor, arg.UserID, arg.ModID)
+	return err
+}
+
+const setModAuthor = `-- name: SetModAuthor :exec
+insert into user_mods (user_id, mod_id, role)
+values ($1, $2, $3)
+on conflict (user_id, mod_id) do update
+    set role = excluded.role
+`
+
+type SetModAuthorParams struct {
+	UserID string
+	ModID  string
+	Role   sql.NullString
+}
+
+func (q *Queries) SetModAuthor(ctx context.Context, arg SetModAuthorParams) error {
+	_, err := q.db.ExecContext(ctx, setModAuthor, arg.UserID, arg.ModID, arg.Role)
+	return err
+}
+
+const updateUser = `-- name: UpdateUser :exec
+update users
+set email       = $2,
+    username    = $3,
+    avatar      = $4,
+    joined_from = $5,
+    banned      = $6,
+    github_id   = $7,
+    google_id   = $8,
+    facebook_id = $9,
+    quota_tier  = $10,
+    updated_at  = now()
+where id = $1
+  and deleted_at is null
+`
+
+type UpdateUserParams struct {
+	ID         string
+	Email      sql.NullString
+	Username   sql.NullString
+	Avatar     sql.NullString
+	JoinedFrom sql.NullString
+	Banned     bool
+	GithubID   sql.NullString
+	GoogleID   sql.NullString
+	FacebookID sql.NullString
+	QuotaTier  sql.NullString
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
+	_, err := q.db.ExecContext(ctx, updateUser,
+		arg.ID,
+		arg.Email,
+		arg.Username,
+		arg.Avatar,
+		arg.JoinedFrom,
+		arg.Banned,
+		arg.GithubID,
+		arg.GoogleID,
+		arg.FacebookID,
+		arg.QuotaTier,
+	)
+	return err
+}
@@ -0,0 +1,47 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+
+package versions
+
+import (
+	"database/sql"
+)
+
+type Version struct {
+	ID                  string
+	CreatedAt           sql.NullTime
+	UpdatedAt           sql.NullTime
+	DeletedAt           sql.NullTime
+	ModID               sql.NullString
+	Version             sql.NullString
+	SmlVersion          sql.NullString
+	Changelog           sql.NullString
+	Downloads           sql.NullInt32
+	Key                 sql.NullString
+	Stability           sql.NullString
+	Approved            bool
+	Hotness             sql.NullInt32
+	Denied              bool
+	Metadata            sql.NullString
+	ModReference        sql.NullString
+	VersionMajor        sql.NullInt32
+	VersionMinor        sql.NullInt32
+	VersionPatch        sql.NullInt32
+	Size                sql.NullInt64
+	Hash                sql.NullString
+	Draft               bool
+	ChangelogSource     string
+	LegacyTarget        bool
+	Quarantined         bool
+	ContentFlags        sql.NullString
+	ContainerType       string
+	StorageTier         string
+	LastDownloadedAt    sql.NullTime
+	TorrentKey          sql.NullString
+	MetalinkKey         sql.NullString
+	InfoHash            sql.NullString
+	Corrupted           bool
+	IntegrityCheckedAt  sql.NullTime
+	RetentionNotifiedAt sql.NullTime
+}
@@ -0,0 +1,117 @@
+-- name: GetVersionByID :one
+select *
+from versions
+where id = $1
+  and deleted_at is null;
+
+-- name: GetDeletedVersion :one
+select *
+from versions
+where id = $1
+  and deleted_at is not null;
+
+-- name: GetModVersion :one
+select *
+from versions
+where mod_id = $1
+  and id = $2
+  and deleted_at is null;
+
+-- name: GetModVersionByName :one
+select *
+from versions
+where mod_id = $1
+  and version = $2
+  and deleted_at is null
+order by id
+limit 1;
+
+-- name: ListVersionsByID :many
+select *
+from versions
+where id = any (@ids::varchar[])
+  and deleted_at is null;
+
+-- name: ListVersionsByModID :many
+select *
+from versions
+where mod_id = any (@mod_ids::varchar[])
+  and deleted_at is null;
+
+-- name: ListApprovedModVersions :many
+select *
+from versions
+where mod_id = any (@mod_ids::varchar[])
+  and approved = true
+  and denied = false
+  and deleted_at is null
+order by created_at desc;
+
+-- name: ListApprovedModVersionsNoMeta :many
+select id,
+       created_at,
+       updated_at,
+       deleted_at,
+       mod_id,
+       version,
+       sml_version,
+       changelog,
+       downloads,
+       key,
+       stability,
+       approved,
+       hotness,
+       denied,
+       mod_reference,
+       version_major,
+       version_minor,
+       version_patch,
+       size,
+       hash
+from versions
+where mod_id = any (@mod_ids::varchar[])
+  and approved = true
+  and denied = false
+  and deleted_at is null
+order by created_at desc;
+
+-- name: UpdateVersion :one
+update versions
+set version               = $2,
+    sml_version           = $3,
+    changelog             = $4,
+    changelog_source      = $5,
+    stability             = sqlc.narg('stability')::text::version_stability,
+    key                   = $6,
+    metadata              = $7,
+    mod_reference         = $8,
+    version_major         = $9,
+    version_minor         = $10,
+    version_patch         = $11,
+    size                  = $12,
+    hash                  = $13,
+    approved              = $14,
+    denied                = $15,
+    draft                 = $16,
+    legacy_target         = $17,
+    quarantined           = $18,
+    corrupted             = $19,
+    content_flags         = $20,
+    container_type        = $21,
+    storage_tier          = $22,
+    torrent_key           = $23,
+    metalink_key          = $24,
+    info_hash             = $25,
+    integrity_checked_at  = $26,
+    retention_notified_at = $27,
+    updated_at            = now()
+where id = $1
+  and deleted_at is null
+  and (sqlc.narg('expected_updated_at')::timestamptz is null or updated_at = sqlc.narg('expected_updated_at'))
+returning updated_at;
+
+-- name: DeleteVersion :exec
+update versions
+set deleted_at = now()
+where id = $1
+  and deleted_at is null;
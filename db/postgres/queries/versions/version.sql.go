@@ -0,0 +1,641 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.22.0
+// source: version.sql
+
+package versions
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const deleteVersion = `-- name: DeleteVersion :exec
+update versions
+set deleted_at = now()
+where id = $1
+  and deleted_at is null
+`
+
+func (q *Queries) DeleteVersion(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteVersion, id)
+	return err
+}
+
+const getDeletedVersion = `-- name: GetDeletedVersion :one
+select id, created_at, updated_at, deleted_at, mod_id, version, sml_version, changelog, downloads, key, stability, approved, hotness, denied, metadata, mod_reference, version_major, version_minor, version_patch, size, hash, draft, changelog_source, legacy_target, quarantined, content_flags, container_type, storage_tier, last_downloaded_at, torrent_key, metalink_key, info_hash, corrupted, integrity_checked_at, retention_notified_at
+from versions
+where id = $1
+  and deleted_at is not null
+`
+
+func (q *Queries) GetDeletedVersion(ctx context.Context, id string) (Version, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedVersion, id)
+	var i Version
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ModID,
+		&i.Version,
+		&i.SmlVersion,
+		&i.Changelog,
+		&i.Downloads,
+		&i.Key,
+		&i.Stability,
+		&i.Approved,
+		&i.Hotness,
+		&i.Denied,
+		&i.Metadata,
+		&i.ModReference,
+		&i.VersionMajor,
+		&i.VersionMinor,
+		&i.VersionPatch,
+		&i.Size,
+		&i.Hash,
+		&i.Draft,
+		&i.ChangelogSource,
+		&i.LegacyTarget,
+		&i.Quarantined,
+		&i.ContentFlags,
+		&i.ContainerType,
+		&i.StorageTier,
+		&i.LastDownloadedAt,
+		&i.TorrentKey,
+		&i.MetalinkKey,
+		&i.InfoHash,
+		&i.Corrupted,
+		&i.IntegrityCheckedAt,
+		&i.RetentionNotifiedAt,
+	)
+	return i, err
+}
+
+const getModVersion = `-- name: GetModVersion :one
+select id, created_at, updated_at, deleted_at, mod_id, version, sml_version, changelog, downloads, key, stability, approved, hotness, denied, metadata, mod_reference, version_major, version_minor, version_patch, size, hash, draft, changelog_source, legacy_target, quarantined, content_flags, container_type, storage_tier, last_downloaded_at, torrent_key, metalink_key, info_hash, corrupted, integrity_checked_at, retention_notified_at
+from versions
+where mod_id = $1
+  and id = $2
+  and deleted_at is null
+`
+
+type GetModVersionParams struct {
+	ModID sql.NullString
+	ID    string
+}
+
+func (q *Queries) GetModVersion(ctx context.Context, arg GetModVersionParams) (Version, error) {
+	row := q.db.QueryRowContext(ctx, getModVersion, arg.ModID, arg.ID)
+	var i Version
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ModID,
+		&i.Version,
+		&i.SmlVersion,
+		&i.Changelog,
+		&i.Downloads,
+		&i.Key,
+		&i.Stability,
+		&i.Approved,
+		&i.Hotness,
+		&i.Denied,
+		&i.Metadata,
+		&i.ModReference,
+		&i.VersionMajor,
+		&i.VersionMinor,
+		&i.VersionPatch,
+		&i.Size,
+		&i.Hash,
+		&i.Draft,
+		&i.ChangelogSource,
+		&i.LegacyTarget,
+		&i.Quarantined,
+		&i.ContentFlags,
+		&i.ContainerType,
+		&i.StorageTier,
+		&i.LastDownloadedAt,
+		&i.TorrentKey,
+		&i.MetalinkKey,
+		&i.InfoHash,
+		&i.Corrupted,
+		&i.IntegrityCheckedAt,
+		&i.RetentionNotifiedAt,
+	)
+	return i, err
+}
+
+const getModVersionByName = `-- name: GetModVersionByName :one
+select id, created_at, updated_at, deleted_at, mod_id, version, sml_version, changelog, downloads, key, stability, approved, hotness, denied, metadata, mod_reference, version_major, version_minor, version_patch, size, hash, draft, changelog_source, legacy_target, quarantined, content_flags, container_type, storage_tier, last_downloaded_at, torrent_key, metalink_key, info_hash, corrupted, integrity_checked_at, retention_notified_at
+from versions
+where mod_id = $1
+  and version = $2
+  and deleted_at is null
+order by id
+limit 1
+`
+
+type GetModVersionByNameParams struct {
+	ModID   sql.NullString
+	Version sql.NullString
+}
+
+func (q *Queries) GetModVersionByName(ctx context.Context, arg GetModVersionByNameParams) (Version, error) {
+	row := q.db.QueryRowContext(ctx, getModVersionByName, arg.ModID, arg.Version)
+	var i Version
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ModID,
+		&i.Version,
+		&i.SmlVersion,
+		&i.Changelog,
+		&i.Downloads,
+		&i.Key,
+		&i.Stability,
+		&i.Approved,
+		&i.Hotness,
+		&i.Denied,
+		&i.Metadata,
+		&i.ModReference,
+		&i.VersionMajor,
+		&i.VersionMinor,
+		&i.VersionPatch,
+		&i.Size,
+		&i.Hash,
+		&i.Draft,
+		&i.ChangelogSource,
+		&i.LegacyTarget,
+		&i.Quarantined,
+		&i.ContentFlags,
+		&i.ContainerType,
+		&i.StorageTier,
+		&i.LastDownloadedAt,
+		&i.TorrentKey,
+		&i.MetalinkKey,
+		&i.InfoHash,
+		&i.Corrupted,
+		&i.IntegrityCheckedAt,
+		&i.RetentionNotifiedAt,
+	)
+	return i, err
+}
+
+const getVersionByID = `-- name: GetVersionByID :one
+select id, created_at, updated_at, deleted_at, mod_id, version, sml_version, changelog, downloads, key, stability, approved, hotness, denied, metadata, mod_reference, version_major, version_minor, version_patch, size, hash, draft, changelog_source, legacy_target, quarantined, content_flags, container_type, storage_tier, last_downloaded_at, torrent_key, metalink_key, info_hash, corrupted, integrity_checked_at, retention_notified_at
+from versions
+where id = $1
+  and deleted_at is null
+`
+
+func (q *Queries) GetVersionByID(ctx context.Context, id string) (Version, error) {
+	row := q.db.QueryRowContext(ctx, getVersionByID, id)
+	var i Version
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ModID,
+		&i.Version,
+		&i.SmlVersion,
+		&i.Changelog,
+		&i.Downloads,
+		&i.Key,
+		&i.Stability,
+		&i.Approved,
+		&i.Hotness,
+		&i.Denied,
+		&i.Metadata,
+		&i.ModReference,
+		&i.VersionMajor,
+		&i.VersionMinor,
+		&i.VersionPatch,
+		&i.Size,
+		&i.Hash,
+		&i.Draft,
+		&i.ChangelogSource,
+		&i.LegacyTarget,
+		&i.Quarantined,
+		&i.ContentFlags,
+		&i.ContainerType,
+		&i.StorageTier,
+		&i.LastDownloadedAt,
+		&i.TorrentKey,
+		&i.MetalinkKey,
+		&i.InfoHash,
+		&i.Corrupted,
+		&i.IntegrityCheckedAt,
+		&i.RetentionNotifiedAt,
+	)
+	return i, err
+}
+
+const listApprovedModVersions = `-- name: ListApprovedModVersions :many
+select id, created_at, updated_at, deleted_at, mod_id, version, sml_version, changelog, downloads, key, stability, approved, hotness, denied, metadata, mod_reference, version_major, version_minor, version_patch, size, hash, draft, changelog_source, legacy_target, quarantined, content_flags, container_type, storage_tier, last_downloaded_at, torrent_key, metalink_key, info_hash, corrupted, integrity_checked_at, retention_notified_at
+from versions
+where mod_id = any ($1::varchar[])
+  and approved = true
+  and denied = false
+  and deleted_at is null
+order by created_at desc
+`
+
+func (q *Queries) ListApprovedModVersions(ctx context.Context, modIds []string) ([]Version, error) {
+	rows, err := q.db.QueryContext(ctx, listApprovedModVersions, pq.Array(modIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Version
+	for rows.Next() {
+		var i Version
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ModID,
+			&i.Version,
+			&i.SmlVersion,
+			&i.Changelog,
+			&i.Downloads,
+			&i.Key,
+			&i.Stability,
+			&i.Approved,
+			&i.Hotness,
+			&i.Denied,
+			&i.Metadata,
+			&i.ModReference,
+			&i.VersionMajor,
+			&i.VersionMinor,
+			&i.VersionPatch,
+			&i.Size,
+			&i.Hash,
+			&i.Draft,
+			&i.ChangelogSource,
+			&i.LegacyTarget,
+			&i.Quarantined,
+			&i.ContentFlags,
+			&i.ContainerType,
+			&i.StorageTier,
+			&i.LastDownloadedAt,
+			&i.TorrentKey,
+			&i.MetalinkKey,
+			&i.InfoHash,
+			&i.Corrupted,
+			&i.IntegrityCheckedAt,
+			&i.RetentionNotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listApprovedModVersionsNoMeta = `-- name: ListApprovedModVersionsNoMeta :many
+select id,
+       created_at,
+       updated_at,
+       deleted_at,
+       mod_id,
+       version,
+       sml_version,
+       changelog,
+       downloads,
+       key,
+       stability,
+       approved,
+       hotness,
+       denied,
+       mod_reference,
+       version_major,
+       version_minor,
+       version_patch,
+       size,
+       hash
+from versions
+where mod_id = any ($1::varchar[])
+  and approved = true
+  and denied = false
+  and deleted_at is null
+order by created_at desc
+`
+
+type ListApprovedModVersionsNoMetaRow struct {
+	ID           string
+	CreatedAt    sql.NullTime
+	UpdatedAt    sql.NullTime
+	DeletedAt    sql.NullTime
+	ModID        sql.NullString
+	Version      sql.NullString
+	SmlVersion   sql.NullString
+	Changelog    sql.NullString
+	Downloads    sql.NullInt32
+	Key          sql.NullString
+	Stability    sql.NullString
+	Approved     bool
+	Hotness      sql.NullInt32
+	Denied       bool
+	ModReference sql.NullString
+	VersionMajor sql.NullInt32
+	VersionMinor sql.NullInt32
+	VersionPatch sql.NullInt32
+	Size         sql.NullInt64
+	Hash         sql.NullString
+}
+
+func (q *Queries) ListApprovedModVersionsNoMeta(ctx context.Context, modIds []string) ([]ListApprovedModVersionsNoMetaRow, error) {
+	rows, err := q.db.QueryContext(ctx, listApprovedModVersionsNoMeta, pq.Array(modIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListApprovedModVersionsNoMetaRow
+	for rows.Next() {
+		var i ListApprovedModVersionsNoMetaRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ModID,
+			&i.Version,
+			&i.SmlVersion,
+			&i.Changelog,
+			&i.Downloads,
+			&i.Key,
+			&i.Stability,
+			&i.Approved,
+			&i.Hotness,
+			&i.Denied,
+			&i.ModReference,
+			&i.VersionMajor,
+			&i.VersionMinor,
+			&i.VersionPatch,
+			&i.Size,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVersionsByID = `-- name: ListVersionsByID :many
+select id, created_at, updated_at, deleted_at, mod_id, version, sml_version, changelog, downloads, key, stability, approved, hotness, denied, metadata, mod_reference, version_major, version_minor, version_patch, size, hash, draft, changelog_source, legacy_target, quarantined, content_flags, container_type, storage_tier, last_downloaded_at, torrent_key, metalink_key, info_hash, corrupted, integrity_checked_at, retention_notified_at
+from versions
+where id = any ($1::varchar[])
+  and deleted_at is null
+`
+
+func (q *Queries) ListVersionsByID(ctx context.Context, ids []string) ([]Version, error) {
+	rows, err := q.db.QueryContext(ctx, listVersionsByID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Version
+	for rows.Next() {
+		var i Version
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ModID,
+			&i.Version,
+			&i.SmlVersion,
+			&i.Changelog,
+			&i.Downloads,
+			&i.Key,
+			&i.Stability,
+			&i.Approved,
+			&i.Hotness,
+			&i.Denied,
+			&i.Metadata,
+			&i.ModReference,
+			&i.VersionMajor,
+			&i.VersionMinor,
+			&i.VersionPatch,
+			&i.Size,
+			&i.Hash,
+			&i.Draft,
+			&i.ChangelogSource,
+			&i.LegacyTarget,
+			&i.Quarantined,
+			&i.ContentFlags,
+			&i.ContainerType,
+			&i.StorageTier,
+			&i.LastDownloadedAt,
+			&i.TorrentKey,
+			&i.MetalinkKey,
+			&i.InfoHash,
+			&i.Corrupted,
+			&i.IntegrityCheckedAt,
+			&i.RetentionNotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVersionsByModID = `-- name: ListVersionsByModID :many
+select id, created_at, updated_at, deleted_at, mod_id, version, sml_version, changelog, downloads, key, stability, approved, hotness, denied, metadata, mod_reference, version_major, version_minor, version_patch, size, hash, draft, changelog_source, legacy_target, quarantined, content_flags, container_type, storage_tier, last_downloaded_at, torrent_key, metalink_key, info_hash, corrupted, integrity_checked_at, retention_notified_at
+from versions
+where mod_id = any ($1::varchar[])
+  and deleted_at is null
+`
+
+func (q *Queries) ListVersionsByModID(ctx context.Context, modIds []string) ([]Version, error) {
+	rows, err := q.db.QueryContext(ctx, listVersionsByModID, pq.Array(modIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Version
+	for rows.Next() {
+		var i Version
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ModID,
+			&i.Version,
+			&i.SmlVersion,
+			&i.Changelog,
+			&i.Downloads,
+			&i.Key,
+			&i.Stability,
+			&i.Approved,
+			&i.Hotness,
+			&i.Denied,
+			&i.Metadata,
+			&i.ModReference,
+			&i.VersionMajor,
+			&i.VersionMinor,
+			&i.VersionPatch,
+			&i.Size,
+			&i.Hash,
+			&i.Draft,
+			&i.ChangelogSource,
+			&i.LegacyTarget,
+			&i.Quarantined,
+			&i.ContentFlags,
+			&i.ContainerType,
+			&i.StorageTier,
+			&i.LastDownloadedAt,
+			&i.TorrentKey,
+			&i.MetalinkKey,
+			&i.InfoHash,
+			&i.Corrupted,
+			&i.IntegrityCheckedAt,
+			&i.RetentionNotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateVersion = `-- name: UpdateVersion :one
+update versions
+set version               = $2,
+    sml_version           = $3,
+    changelog             = $4,
+    changelog_source      = $5,
+    stability             = $28::text::version_stability,
+    key                   = $6,
+    metadata              = $7,
+    mod_reference         = $8,
+    version_major         = $9,
+    version_minor         = $10,
+    version_patch         = $11,
+    size                  = $12,
+    hash                  = $13,
+    approved              = $14,
+    denied                = $15,
+    draft                 = $16,
+    legacy_target         = $17,
+    quarantined           = $18,
+    corrupted             = $19,
+    content_flags         = $20,
+    container_type        = $21,
+    storage_tier          = $22,
+    torrent_key           = $23,
+    metalink_key          = $24,
+    info_hash             = $25,
+    integrity_checked_at  = $26,
+    retention_notified_at = $27,
+    updated_at            = now()
+where id = $1
+  and deleted_at is null
+  and ($29::timestamptz is null or updated_at = $29)
+returning updated_at
+`
+
+type UpdateVersionParams struct {
+	ID                  string
+	Version             sql.NullString
+	SmlVersion          sql.NullString
+	Changelog           sql.NullString
+	ChangelogSource     string
+	Key                 sql.NullString
+	Metadata            sql.NullString
+	ModReference        sql.NullString
+	VersionMajor        sql.NullInt32
+	VersionMinor        sql.NullInt32
+	VersionPatch        sql.NullInt32
+	Size                sql.NullInt64
+	Hash                sql.NullString
+	Approved            bool
+	Denied              bool
+	Draft               bool
+	LegacyTarget        bool
+	Quarantined         bool
+	Corrupted           bool
+	ContentFlags        sql.NullString
+	ContainerType       string
+	StorageTier         string
+	TorrentKey          sql.NullString
+	MetalinkKey         sql.NullString
+	InfoHash            sql.NullString
+	IntegrityCheckedAt  sql.NullTime
+	RetentionNotifiedAt sql.NullTime
+	Stability           sql.NullString
+	ExpectedUpdatedAt   sql.NullTime
+}
+
+func (q *Queries) UpdateVersion(ctx context.Context, arg UpdateVersionParams) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, updateVersion,
+		arg.ID,
+		arg.Version,
+		arg.SmlVersion,
+		arg.Changelog,
+		arg.ChangelogSource,
+		arg.Key,
+		arg.Metadata,
+		arg.ModReference,
+		arg.VersionMajor,
+		arg.VersionMinor,
+		arg.VersionPatch,
+		arg.Size,
+		arg.Hash,
+		arg.Approved,
+		arg.Denied,
+		arg.Draft,
+		arg.LegacyTarget,
+		arg.Quarantined,
+		arg.Corrupted,
+		arg.ContentFlags,
+		arg.ContainerType,
+		arg.StorageTier,
+		arg.TorrentKey,
+		arg.MetalinkKey,
+		arg.InfoHash,
+		arg.IntegrityCheckedAt,
+		arg.RetentionNotifiedAt,
+		arg.Stability,
+		arg.ExpectedUpdatedAt,
+	)
+	var updated_at sql.NullTime
+	err := row.Scan(&updated_at)
+	return updated_at, err
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/patrickmn/go-cache"
+)
+
+func GetUserQuotaOverride(ctx context.Context, userID string) *QuotaOverride {
+	cacheKey := "GetUserQuotaOverride_" + userID
+	if override, ok := dbCache.Get(cacheKey); ok {
+		return override.(*QuotaOverride)
+	}
+
+	var override QuotaOverride
+	DBCtx(ctx).Order("created_at desc").Find(&override, "user_id = ?", userID)
+
+	if override.ID == "" {
+		return nil
+	}
+
+	dbCache.Set(cacheKey, &override, cache.DefaultExpiration)
+
+	return &override
+}
+
+func GetModQuotaOverride(ctx context.Context, modID string) *QuotaOverride {
+	cacheKey := "GetModQuotaOverride_" + modID
+	if override, ok := dbCache.Get(cacheKey); ok {
+		return override.(*QuotaOverride)
+	}
+
+	var override QuotaOverride
+	DBCtx(ctx).Order("created_at desc").Find(&override, "mod_id = ?", modID)
+
+	if override.ID == "" {
+		return nil
+	}
+
+	dbCache.Set(cacheKey, &override, cache.DefaultExpiration)
+
+	return &override
+}
+
+// GetModStorageUsage returns the total size of all stored version and target files of a mod
+func GetModStorageUsage(ctx context.Context, modID string) int64 {
+	var versionSize int64
+	DBCtx(ctx).Model(Version{}).
+		Select("coalesce(sum(size), 0)").
+		Where("mod_id = ?", modID).
+		Scan(&versionSize)
+
+	var targetSize int64
+	DBCtx(ctx).Model(VersionTarget{}).
+		Select("coalesce(sum(version_targets.size), 0)").
+		Joins("JOIN versions ON versions.id = version_targets.version_id").
+		Where("versions.mod_id = ? AND versions.deleted_at IS NULL", modID).
+		Where("version_targets.key <> versions.key").
+		Scan(&targetSize)
+
+	return versionSize + targetSize
+}
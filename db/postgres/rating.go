@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordUserDownload remembers that the user downloaded the mod
+func RecordUserDownload(ctx context.Context, userID string, modID string) {
+	DBCtx(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&UserModDownload{
+		UserID: userID,
+		ModID:  modID,
+	})
+}
+
+func HasUserDownloadedMod(ctx context.Context, userID string, modID string) bool {
+	var count int64
+	DBCtx(ctx).Model(&UserModDownload{}).Where("user_id = ? AND mod_id = ?", userID, modID).Count(&count)
+	return count > 0
+}
+
+func GetModRating(ctx context.Context, userID string, modID string) *ModRating {
+	var rating ModRating
+	DBCtx(ctx).Find(&rating, "user_id = ? AND mod_id = ?", userID, modID)
+
+	if rating.UserID == "" {
+		return nil
+	}
+
+	return &rating
+}
+
+// GetModRatings returns the ratings of a mod, most recently updated first
+func GetModRatings(ctx context.Context, modID string, withReview bool, limit int, offset int) []ModRating {
+	query := DBCtx(ctx).Where("mod_id = ?", modID)
+
+	if withReview {
+		query = query.Where("review IS NOT NULL AND review != ''")
+	}
+
+	var ratings []ModRating
+	query.Order("updated_at desc").Limit(limit).Offset(offset).Find(&ratings)
+	return ratings
+}
+
+// GetModRatingHistogram returns the amount of ratings of the mod for each amount of stars, from 1 to 5
+func GetModRatingHistogram(ctx context.Context, modID string) [5]int {
+	var rows []struct {
+		Rating int
+		Count  int
+	}
+
+	DBCtx(ctx).Model(&ModRating{}).
+		Select("rating, count(*) as count").
+		Where("mod_id = ?", modID).
+		Group("rating").
+		Scan(&rows)
+
+	var histogram [5]int
+	for _, row := range rows {
+		if row.Rating >= 1 && row.Rating <= 5 {
+			histogram[row.Rating-1] = row.Count
+		}
+	}
+
+	return histogram
+}
+
+// RateMod stores the rating of the user, replacing their previous one, and updates the aggregates of the mod
+func RateMod(ctx context.Context, rating *ModRating) error {
+	err := DBCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "mod_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rating", "review", "updated_at"}),
+		}).Create(rating).Error; err != nil {
+			return err
+		}
+
+		return updateModRatingAggregates(tx, rating.ModID)
+	})
+
+	ClearCache()
+
+	return err
+}
+
+// DeleteModRating removes the rating of the user and updates the aggregates of the mod.
+// Returns whether the user had rated the mod.
+func DeleteModRating(ctx context.Context, userID string, modID string) (bool, error) {
+	deleted := false
+	err := DBCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND mod_id = ?", userID, modID).Delete(&ModRating{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		deleted = true
+		return updateModRatingAggregates(tx, modID)
+	})
+
+	ClearCache()
+
+	return deleted, err
+}
+
+func updateModRatingAggregates(tx *gorm.DB, modID string) error {
+	return tx.Exec(`UPDATE mods SET
+		rating = (SELECT AVG(rating) FROM mod_ratings WHERE mod_id = ?),
+		rating_count = (SELECT COUNT(*) FROM mod_ratings WHERE mod_id = ?)
+		WHERE id = ?`, modID, modID, modID).Error
+}
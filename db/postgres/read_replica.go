@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+var (
+	readReplica *gorm.DB
+	// replicaBehind is set while the replica lags more than database.postgres.replica.max_lag, or cannot be reached
+	replicaBehind atomic.Bool
+)
+
+type ContextPrimary struct{}
+
+// ContextWithPrimary sends every read of the context to the primary, for requests which have to see their own writes
+func ContextWithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ContextPrimary{}, true)
+}
+
+// HasReadReplica returns whether a read replica is configured
+func HasReadReplica() bool {
+	return readReplica != nil
+}
+
+// ReadDBCtx returns the connection for heavy reads which tolerate some lag, such as searches, lists and stats.
+//
+// They go to the read replica if one is configured, unless the context is in a transaction or requires the primary,
+// or the replica lags behind. Lookups by ID always use DBCtx, so they see writes as soon as they are committed.
+func ReadDBCtx(ctx context.Context) *gorm.DB {
+	if readReplica == nil || ctx == nil || replicaBehind.Load() {
+		return DBCtx(ctx)
+	}
+
+	if DBFromContext(ctx) != nil {
+		return DBCtx(ctx)
+	}
+
+	if primary, _ := ctx.Value(ContextPrimary{}).(bool); primary {
+		return DBCtx(ctx)
+	}
+
+	return readReplica.WithContext(ctx)
+}
+
+func initializeReadReplica() {
+	replica, err := openDB(func(key string) string {
+		if viper.IsSet("database.postgres.replica." + key) {
+			return viper.GetString("database.postgres.replica." + key)
+		}
+		return viper.GetString("database.postgres." + key)
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	readReplica = replica
+
+	if debugEnabled {
+		readReplica = readReplica.Debug()
+	}
+
+	log.Info().Msg("Postgres read replica initialized")
+}
+
+// ReplicaLag returns how far the read replica is behind the primary. A replica which replayed everything it received
+// has no lag, even if the primary did not write anything for a while.
+func ReplicaLag(ctx context.Context) (time.Duration, error) {
+	if readReplica == nil {
+		return 0, nil
+	}
+
+	var seconds float64
+	err := readReplica.WithContext(ctx).Raw(`SELECT CASE
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE coalesce(extract(epoch FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`).Row().Scan(&seconds)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get replica lag")
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// RunAsyncReplicaLagLoop periodically checks the lag of the read replica, and sends every read to the primary
+// while it lags more than database.postgres.replica.max_lag
+func RunAsyncReplicaLagLoop(ctx context.Context) {
+	if readReplica == nil {
+		return
+	}
+
+	go func() {
+		for {
+			lag, err := ReplicaLag(ctx)
+			maxLag := viper.GetDuration("database.postgres.replica.max_lag")
+
+			behind := err != nil || lag > maxLag
+			if behind != replicaBehind.Swap(behind) {
+				if err != nil {
+					log.Err(err).Msg("read replica unavailable, reading from the primary")
+				} else if behind {
+					log.Warn().Dur("lag", lag).Msg("read replica lagging, reading from the primary")
+				} else {
+					log.Info().Dur("lag", lag).Msg("read replica caught up")
+				}
+			}
+
+			time.Sleep(viper.GetDuration("database.postgres.replica.lag_interval"))
+		}
+	}()
+}
+
+// CheckReplica checks that the read replica can be reached and does not lag behind
+func CheckReplica(ctx context.Context) error {
+	lag, err := ReplicaLag(ctx)
+	if err != nil {
+		return err
+	}
+
+	if maxLag := viper.GetDuration("database.postgres.replica.max_lag"); lag > maxLag {
+		return errors.Errorf("replica lags %s behind, more than %s", lag, maxLag)
+	}
+
+	return nil
+}
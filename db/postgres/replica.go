@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// GetFileReplica returns the replication state of a file on a mirror
+func GetFileReplica(ctx context.Context, key string, mirror string) *FileReplica {
+	var replica FileReplica
+	DBCtx(ctx).First(&replica, "key = ? AND mirror = ?", key, mirror)
+
+	if replica.Key == "" {
+		return nil
+	}
+
+	return &replica
+}
+
+// GetReplicatedMirrors returns the mirrors a file has been fully replicated to
+func GetReplicatedMirrors(ctx context.Context, key string) []string {
+	cacheKey := "GetReplicatedMirrors_" + key
+	if mirrors, ok := dbCache.Get(cacheKey); ok {
+		return mirrors.([]string)
+	}
+
+	var mirrors []string
+	DBCtx(ctx).Model(FileReplica{}).
+		Where("key = ? AND state = ?", key, ReplicaStateReplicated).
+		Pluck("mirror", &mirrors)
+
+	dbCache.Set(cacheKey, mirrors, cache.DefaultExpiration)
+
+	return mirrors
+}
+
+// GetVersionFileReplicas returns the replication state of every file of a version
+func GetVersionFileReplicas(ctx context.Context, versionID string) []FileReplica {
+	var replicas []FileReplica
+	DBCtx(ctx).Where("version_id = ?", versionID).Find(&replicas)
+	return replicas
+}
+
+// SetFileReplicaState records the replication state of a file on a mirror
+func SetFileReplicaState(ctx context.Context, versionID string, key string, mirror string, state string, replicationError error) {
+	replica := FileReplica{
+		Key:       key,
+		Mirror:    mirror,
+		VersionID: versionID,
+		State:     state,
+	}
+
+	if replicationError != nil {
+		message := replicationError.Error()
+		replica.Error = &message
+	}
+
+	Save(ctx, &replica)
+	dbCache.Delete("GetReplicatedMirrors_" + key)
+}
+
+// DeleteVersionFileReplicas forgets every mirror copy of the files of a version
+func DeleteVersionFileReplicas(ctx context.Context, versionID string) {
+	DBCtx(ctx).Where("version_id = ?", versionID).Delete(&FileReplica{})
+	ClearCache()
+}
+
+// DeleteFileReplicas forgets every mirror copy of a file, whichever version it was replicated for
+func DeleteFileReplicas(ctx context.Context, key string) {
+	DBCtx(ctx).Where("key = ?", key).Delete(&FileReplica{})
+	dbCache.Delete("GetReplicatedMirrors_" + key)
+}
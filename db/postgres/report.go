@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// Report states for which moderators still have to act
+var unresolvedReportStates = []string{ReportStateOpen, ReportStateTriaged}
+
+// CreateReport stores a new open report.
+// If the reporter already has an unresolved report of the same content, that report is returned instead.
+func CreateReport(ctx context.Context, report *Report) (*Report, error) {
+	var existing Report
+	DBCtx(ctx).
+		Where("reporter_id = ? AND target_type = ? AND target_id = ? AND state IN ?", report.ReporterID, report.TargetType, report.TargetID, unresolvedReportStates).
+		Find(&existing)
+
+	if existing.ID != "" {
+		return &existing, nil
+	}
+
+	report.ID = util.GenerateUniqueID()
+	report.State = ReportStateOpen
+
+	if err := DBCtx(ctx).Create(report).Error; err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func GetReportByID(ctx context.Context, reportID string) *Report {
+	var report Report
+	DBCtx(ctx).Find(&report, "id = ?", reportID)
+
+	if report.ID == "" {
+		return nil
+	}
+
+	return &report
+}
+
+// GetReports returns the reports in any of the states, oldest first so that they are handled in order
+func GetReports(ctx context.Context, states []string, targetType *string, limit int, offset int) []Report {
+	var reports []Report
+	reportQuery(ctx, states, targetType).
+		Order("created_at asc").
+		Limit(limit).
+		Offset(offset).
+		Find(&reports)
+	return reports
+}
+
+func GetReportCount(ctx context.Context, states []string, targetType *string) int64 {
+	var count int64
+	reportQuery(ctx, states, targetType).Count(&count)
+	return count
+}
+
+// GetUnresolvedTargetReports returns the reports of the content moderators still have to act on
+func GetUnresolvedTargetReports(ctx context.Context, targetType string, targetID string) []Report {
+	var reports []Report
+	DBCtx(ctx).
+		Where("target_type = ? AND target_id = ? AND state IN ?", targetType, targetID, unresolvedReportStates).
+		Order("created_at asc").
+		Find(&reports)
+	return reports
+}
+
+// SetReportState moves the report to a new state, recording the moderator if the report is closed
+func SetReportState(ctx context.Context, report *Report, state string, moderatorID string, note *string) {
+	updates := map[string]interface{}{
+		"state": state,
+	}
+
+	if note != nil {
+		updates["note"] = *note
+	}
+
+	if state == ReportStateResolved || state == ReportStateDismissed {
+		updates["resolved_by_id"] = moderatorID
+		updates["resolved_at"] = time.Now()
+	} else {
+		updates["resolved_by_id"] = nil
+		updates["resolved_at"] = nil
+	}
+
+	DBCtx(ctx).Model(report).Updates(updates)
+}
+
+// CloseTargetReports closes every unresolved report of the content with the state.
+// Returns the amount of reports closed.
+func CloseTargetReports(ctx context.Context, targetType string, targetID string, state string, moderatorID string) int64 {
+	return closeTargetReports(DBCtx(ctx), targetType, targetID, state, moderatorID).RowsAffected
+}
+
+func closeTargetReports(tx *gorm.DB, targetType string, targetID string, state string, moderatorID string) *gorm.DB {
+	return tx.Model(&Report{}).
+		Where("target_type = ? AND target_id = ? AND state IN ?", targetType, targetID, unresolvedReportStates).
+		Updates(map[string]interface{}{
+			"state":          state,
+			"resolved_by_id": moderatorID,
+			"resolved_at":    time.Now(),
+		})
+}
+
+func reportQuery(ctx context.Context, states []string, targetType *string) *gorm.DB {
+	query := DBCtx(ctx).Model(&Report{})
+
+	if len(states) > 0 {
+		query = query.Where("state IN ?", states)
+	}
+
+	if targetType != nil {
+		query = query.Where("target_type = ?", *targetType)
+	}
+
+	return query
+}
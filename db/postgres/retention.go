@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// GetRetentionNotifyCandidates returns published versions which were never approved,
+// created before the provided time and whose authors have not been warned yet
+func GetRetentionNotifyCandidates(ctx context.Context, createdBefore time.Time, limit int) []Version {
+	var versions []Version
+	DBCtx(ctx).
+		Where("approved = ? AND draft = ? AND retention_notified_at IS NULL", false, false).
+		Where("created_at < ?", createdBefore).
+		Order("created_at asc").
+		Limit(limit).
+		Find(&versions)
+	return versions
+}
+
+// GetRetentionDeleteCandidates returns published versions which were never approved,
+// created before the provided time and whose authors were warned before notifiedBefore
+func GetRetentionDeleteCandidates(ctx context.Context, createdBefore time.Time, notifiedBefore time.Time, limit int) []Version {
+	var versions []Version
+	DBCtx(ctx).Preload("Targets").
+		Where("approved = ? AND draft = ?", false, false).
+		Where("created_at < ? AND retention_notified_at < ?", createdBefore, notifiedBefore).
+		Order("created_at asc").
+		Limit(limit).
+		Find(&versions)
+	return versions
+}
+
+// GetDeletedVersionPurgeCandidates returns versions deleted before the provided time
+func GetDeletedVersionPurgeCandidates(ctx context.Context, deletedBefore time.Time, limit int) []Version {
+	var versions []Version
+	DBCtx(ctx).Unscoped().Preload("Targets").
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+		Order("deleted_at asc").
+		Limit(limit).
+		Find(&versions)
+	return versions
+}
+
+// SetVersionRetentionNotified records that the authors were warned of the upcoming deletion of a version
+func SetVersionRetentionNotified(ctx context.Context, version *Version) {
+	DBCtx(ctx).Model(version).Update("retention_notified_at", time.Now())
+	ClearCache()
+}
+
+// GetDeletedVersionForPurge returns a deleted version which was never approved, along with its targets
+func GetDeletedVersionForPurge(ctx context.Context, versionID string) *Version {
+	var version Version
+	DBCtx(ctx).Unscoped().Preload("Targets").
+		Where("id = ? AND deleted_at IS NOT NULL AND approved = false", versionID).
+		First(&version)
+
+	if version.ID == "" {
+		return nil
+	}
+
+	return &version
+}
+
+// GetKeysReferencedByOtherVersions returns which of the keys are stored by versions or targets of versions other than versionID.
+// A version replacing one with the same version number is stored under the same keys.
+func GetKeysReferencedByOtherVersions(ctx context.Context, versionID string, keys []string) map[string]bool {
+	referenced := make(map[string]bool)
+	if len(keys) == 0 {
+		return referenced
+	}
+
+	var found []string
+	DBCtx(ctx).Raw(`SELECT key FROM versions WHERE key IN ? AND id != ?
+		UNION SELECT key FROM version_targets WHERE key IN ? AND version_id != ?`, keys, versionID, keys, versionID).
+		Scan(&found)
+
+	for _, key := range found {
+		referenced[key] = true
+	}
+
+	return referenced
+}
+
+// GetVersionPatchKeys returns the keys of every patch from or to a version
+func GetVersionPatchKeys(ctx context.Context, versionID string) []string {
+	var keys []string
+	DBCtx(ctx).Model(VersionTargetPatch{}).
+		Where("version_id = ? OR from_version_id = ?", versionID, versionID).
+		Pluck("key", &keys)
+	return keys
+}
+
+// PurgeVersion permanently deletes a version and every row referencing it
+func PurgeVersion(ctx context.Context, versionID string) error {
+	err := DBCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("version_id = ?", versionID).Delete(&FileReplica{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("version_id = ? OR from_version_id = ?", versionID, versionID).Delete(&VersionTargetPatch{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("version_id = ?", versionID).Delete(&VersionTarget{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("version_id = ?", versionID).Delete(&VersionDependency{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Where("id = ?", versionID).Delete(&Version{}).Error
+	})
+
+	ClearCache()
+
+	return errors.Wrap(err, "failed to purge version")
+}
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Conversions between the nullable columns of the generated queries and the fields of the models
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: true}
+}
+
+func nullStringPtr(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return nullString(*value)
+}
+
+func nullInt32Ptr(value *int) sql.NullInt32 {
+	if value == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(*value), Valid: true}
+}
+
+func nullInt64Ptr(value *int64) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *value, Valid: true}
+}
+
+func nullTimePtr(value *time.Time) sql.NullTime {
+	if value == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *value, Valid: true}
+}
+
+func float64Ptr(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
+
+func stringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func intPtr(value sql.NullInt32) *int {
+	if !value.Valid {
+		return nil
+	}
+	i := int(value.Int32)
+	return &i
+}
+
+func int64Ptr(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
+
+func timePtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+func rowDates(createdAt sql.NullTime, updatedAt sql.NullTime, deletedAt sql.NullTime) SMRDates {
+	return SMRDates{
+		CreatedAt: createdAt.Time,
+		UpdatedAt: updatedAt.Time,
+		DeletedAt: gorm.DeletedAt(deletedAt),
+	}
+}
+
+// marshalJSONColumn encodes the value of a json column, storing nil pointers and maps as NULL
+func marshalJSONColumn(value interface{}) (json.RawMessage, error) {
+	reflected := reflect.ValueOf(value)
+	if !reflected.IsValid() || ((reflected.Kind() == reflect.Ptr || reflected.Kind() == reflect.Map) && reflected.IsNil()) {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value)
+	return data, errors.Wrap(err, "failed to marshal json column")
+}
+
+// unmarshalJSONColumn decodes the value of a json column into target, leaving it untouched if the column is NULL
+func unmarshalJSONColumn(data json.RawMessage, target interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return errors.Wrap(json.Unmarshal(data, target), "failed to unmarshal json column")
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres/queries/mods"
+)
+
+func TestModRowDecodesJSONColumns(t *testing.T) {
+	compatibility, err := marshalJSONColumn(&CompatibilityInfo{EA: Compatibility{State: "Works"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mod := modFromRow(mods.Mod{
+		ID:            "mod",
+		Compatibility: compatibility,
+		LogoVariants:  []byte(`{"256.webp":"logos/mod/256.webp"}`),
+	})
+
+	if mod.Compatibility == nil || mod.Compatibility.EA.State != "Works" {
+		t.Errorf("expected the compatibility to be decoded, got %+v", mod.Compatibility)
+	}
+
+	if mod.LogoVariants["256.webp"] != "logos/mod/256.webp" {
+		t.Errorf("expected the logo variants to be decoded, got %v", mod.LogoVariants)
+	}
+
+	// Rows saved through GORM store nil values as a json null
+	mod = modFromRow(mods.Mod{ID: "mod", Compatibility: []byte("null")})
+	if mod.Compatibility != nil || mod.LogoVariants != nil {
+		t.Errorf("expected nil json columns, got %+v and %v", mod.Compatibility, mod.LogoVariants)
+	}
+}
+
+func TestMarshalJSONColumnStoresNilAsNull(t *testing.T) {
+	for name, value := range map[string]interface{}{
+		"pointer": (*CompatibilityInfo)(nil),
+		"map":     map[string]string(nil),
+	} {
+		data, err := marshalJSONColumn(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if data != nil {
+			t.Errorf("expected a nil %s to be stored as NULL, got %s", name, data)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"strings"
+	"unicode"
+)
+
+// searchConfig is the text search configuration of the search vectors, see migration 000056
+const searchConfig = "english"
+
+// searchQuery converts a search typed by a user to a tsquery matching the documents containing every word
+// or a word starting with it, so that results show up while the last word is still being typed.
+// Returns an empty string if the search contains no word.
+func searchQuery(search string) string {
+	words := strings.FieldsFunc(search, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SiteStats are aggregate statistics of the public mods
+type SiteStats struct {
+	ComputedAt time.Time `json:"computed_at"`
+	Mods       int64     `json:"mods"`
+	Versions   int64     `json:"versions"`
+	Downloads  int64     `json:"downloads"`
+	// ActiveAuthors published an approved version within the active window
+	ActiveAuthors int64 `json:"active_authors"`
+	// StorageSize is the total size of every stored version and target file in bytes
+	StorageSize int64 `json:"storage_size"`
+}
+
+// ComputeSiteStats aggregates the stats views as of their last refresh, and the storage usage
+func ComputeSiteStats(ctx context.Context, activeWindow time.Duration) (*SiteStats, error) {
+	stats := &SiteStats{
+		ComputedAt: time.Now(),
+	}
+
+	err := ReadDBCtx(ctx).Raw(`SELECT count(*), coalesce(sum(versions), 0), coalesce(sum(downloads), 0)
+		FROM stats_mod_downloads`).
+		Row().Scan(&stats.Mods, &stats.Versions, &stats.Downloads)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count mods")
+	}
+
+	err = ReadDBCtx(ctx).Raw(`SELECT count(*) FROM stats_top_authors WHERE last_version_at > ?`, time.Now().Add(-activeWindow)).
+		Row().Scan(&stats.ActiveAuthors)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to count active authors")
+	}
+
+	// Targets sharing the file of their version are only counted once, as in GetModStorageUsage
+	err = ReadDBCtx(ctx).Raw(`SELECT
+		(SELECT coalesce(sum(size), 0) FROM versions WHERE deleted_at IS NULL) +
+		(SELECT coalesce(sum(version_targets.size), 0) FROM version_targets
+			JOIN versions ON versions.id = version_targets.version_id
+			WHERE versions.deleted_at IS NULL AND version_targets.key <> versions.key)`).
+		Row().Scan(&stats.StorageSize)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sum storage size")
+	}
+
+	return stats, nil
+}
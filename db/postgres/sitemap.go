@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SitemapEntry is a public page listed by the sitemaps, identified by the ID used in its URL
+type SitemapEntry struct {
+	ID        string
+	UpdatedAt time.Time
+}
+
+// GetSitemapMods returns the approved mods which are not hidden, by their reference
+func GetSitemapMods(ctx context.Context) ([]SitemapEntry, error) {
+	var entries []SitemapEntry
+	err := ReadDBCtx(ctx).Raw(`SELECT mod_reference AS id, greatest(updated_at, coalesce(last_version_date, updated_at)) AS updated_at
+		FROM mods
+		WHERE approved = true AND denied = false AND hidden = false AND deleted_at IS NULL
+		ORDER BY created_at`).Scan(&entries).Error
+	return entries, errors.Wrap(err, "failed to list sitemap mods")
+}
+
+// GetSitemapGuides returns every guide
+func GetSitemapGuides(ctx context.Context) ([]SitemapEntry, error) {
+	var entries []SitemapEntry
+	err := ReadDBCtx(ctx).Raw(`SELECT id, updated_at FROM guides WHERE deleted_at IS NULL ORDER BY created_at`).
+		Scan(&entries).Error
+	return entries, errors.Wrap(err, "failed to list sitemap guides")
+}
+
+// GetSitemapUsers returns the users which are not banned and author a public mod or a guide,
+// as the profiles of the others have nothing worth indexing
+func GetSitemapUsers(ctx context.Context) ([]SitemapEntry, error) {
+	var entries []SitemapEntry
+	err := ReadDBCtx(ctx).Raw(`SELECT users.id, users.updated_at FROM users
+		WHERE users.banned = false AND users.deleted_at IS NULL AND (
+			EXISTS (SELECT 1 FROM user_mods JOIN mods ON mods.id = user_mods.mod_id
+				WHERE user_mods.user_id = users.id
+				AND mods.approved = true AND mods.denied = false AND mods.hidden = false AND mods.deleted_at IS NULL)
+			OR EXISTS (SELECT 1 FROM guides WHERE guides.user_id = users.id AND guides.deleted_at IS NULL)
+		)
+		ORDER BY users.created_at`).Scan(&entries).Error
+	return entries, errors.Wrap(err, "failed to list sitemap users")
+}
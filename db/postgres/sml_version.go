@@ -2,8 +2,12 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
+	"github.com/patrickmn/go-cache"
+
 	"github.com/satisfactorymodding/smr-api/models"
 	"github.com/satisfactorymodding/smr-api/util"
 )
@@ -33,8 +37,9 @@ func GetSMLVersions(ctx context.Context, filter *models.SMLVersionFilter) []SMLV
 
 	if filter != nil {
 		query = query.Limit(*filter.Limit).
-			Offset(*filter.Offset).
-			Order(string(*filter.OrderBy) + " " + string(*filter.Order))
+			Offset(*filter.Offset)
+
+		query = orderAfterCursor(query, "sml_versions", string(*filter.OrderBy), *filter.Order, filter.Cursor)
 
 		if filter.Search != nil && *filter.Search != "" {
 			query = query.Where("to_tsvector(name) @@ to_tsquery(?)", strings.ReplaceAll(*filter.Search, " ", " & "))
@@ -88,3 +93,80 @@ func GetSMLVersionTargets(ctx context.Context, smlVersionID string) []SMLVersion
 
 	return smlVersionTargets
 }
+
+// GetMatchingSMLRequirements returns the SML requirements of approved versions which are satisfied by an SML release.
+//
+// Only the releases matching the SML version constraint, and made for the game build are considered.
+// The releases made for a game build are the ones targeting the newest build which is not above it.
+func GetMatchingSMLRequirements(ctx context.Context, smlVersion *string, gameVersion *int) []string {
+	cacheKey := "GetMatchingSMLRequirements"
+	if smlVersion != nil {
+		cacheKey += "_sml:" + *smlVersion
+	}
+	if gameVersion != nil {
+		cacheKey += "_game:" + fmt.Sprint(*gameVersion)
+	}
+
+	if requirements, ok := dbCache.Get(cacheKey); ok {
+		return requirements.([]string)
+	}
+
+	var releases []SMLVersion
+	DBCtx(ctx).Find(&releases)
+
+	if gameVersion != nil {
+		newest := -1
+		for _, release := range releases {
+			if release.SatisfactoryVersion <= *gameVersion && release.SatisfactoryVersion > newest {
+				newest = release.SatisfactoryVersion
+			}
+		}
+
+		targeting := make([]SMLVersion, 0)
+		for _, release := range releases {
+			if release.SatisfactoryVersion == newest {
+				targeting = append(targeting, release)
+			}
+		}
+		releases = targeting
+	}
+
+	var constraint *semver.Constraints
+	if smlVersion != nil {
+		constraint, _ = semver.NewConstraint(*smlVersion)
+	}
+
+	candidates := make([]*semver.Version, 0, len(releases))
+	for _, release := range releases {
+		version, err := semver.NewVersion(release.Version)
+		if err != nil {
+			continue
+		}
+
+		if constraint == nil || constraint.Check(version) {
+			candidates = append(candidates, version)
+		}
+	}
+
+	var requirements []string
+	DBCtx(ctx).Model(Version{}).Where("approved = ? AND denied = ?", true, false).Distinct().Pluck("sml_version", &requirements)
+
+	matching := make([]string, 0)
+	for _, requirement := range requirements {
+		requirementConstraint, err := semver.NewConstraint(requirement)
+		if err != nil {
+			continue
+		}
+
+		for _, candidate := range candidates {
+			if requirementConstraint.Check(candidate) {
+				matching = append(matching, requirement)
+				break
+			}
+		}
+	}
+
+	dbCache.Set(cacheKey, matching, cache.DefaultExpiration)
+
+	return matching
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// statsViews are the materialized views of migration 000057, in refresh order,
+// as stats_top_authors aggregates stats_mod_downloads
+var statsViews = []string{
+	"stats_mod_downloads",
+	"stats_weekly_versions",
+	"stats_top_authors",
+}
+
+// ModDownloadStats are the aggregated approved versions of a public mod
+type ModDownloadStats struct {
+	LastVersionAt *time.Time
+	ModID         string
+	Name          string
+	ModReference  string
+	Versions      int64
+	Downloads     int64
+}
+
+// WeeklyVersionStats are the approved versions of public mods created in the week starting at Week
+type WeeklyVersionStats struct {
+	Week     time.Time
+	Versions int64
+	Mods     int64
+}
+
+// AuthorStats are the aggregated public mods of an author
+type AuthorStats struct {
+	LastVersionAt *time.Time
+	UserID        string
+	Username      string
+	Mods          int64
+	Downloads     int64
+}
+
+// RefreshStatsViews recomputes the materialized stats views.
+// They are refreshed concurrently, so they can still be queried while it runs.
+func RefreshStatsViews(ctx context.Context) error {
+	for _, view := range statsViews {
+		if err := DBCtx(ctx).Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY " + view).Error; err != nil {
+			return errors.Wrap(err, "failed to refresh "+view)
+		}
+	}
+
+	return nil
+}
+
+// GetTopModsByDownloads returns the public mods with the most downloads as of the last refresh
+func GetTopModsByDownloads(ctx context.Context, limit int) []ModDownloadStats {
+	var stats []ModDownloadStats
+	ReadDBCtx(ctx).Table("stats_mod_downloads").
+		Order("downloads desc").
+		Limit(limit).
+		Find(&stats)
+	return stats
+}
+
+// GetWeeklyVersionStats returns the versions created in the last weeks as of the last refresh, oldest first.
+// Weeks without any version are left out.
+func GetWeeklyVersionStats(ctx context.Context, weeks int) []WeeklyVersionStats {
+	var stats []WeeklyVersionStats
+	ReadDBCtx(ctx).Table("stats_weekly_versions").
+		Where("week >= date_trunc('week', now()) - make_interval(weeks => ?)", weeks-1).
+		Order("week asc").
+		Find(&stats)
+	return stats
+}
+
+// GetTopAuthors returns the authors of the public mods with the most downloads as of the last refresh
+func GetTopAuthors(ctx context.Context, limit int) []AuthorStats {
+	var stats []AuthorStats
+	ReadDBCtx(ctx).Table("stats_top_authors").
+		Order("downloads desc").
+		Limit(limit).
+		Find(&stats)
+	return stats
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"time"
+)
+
+// SyncChange records that a mod or version was created, updated or deleted. Changes are recorded by triggers,
+// so they cover every write, but not the counters which change all the time, such as downloads.
+type SyncChange struct {
+	CreatedAt  time.Time
+	EntityType string
+	EntityID   string
+	ID         int64
+}
+
+const (
+	SyncEntityMod     = "mod"
+	SyncEntityVersion = "version"
+)
+
+// GetSyncChanges returns the changes following since, which were recorded before until, oldest first
+func GetSyncChanges(ctx context.Context, since int64, until time.Time, limit int) []SyncChange {
+	var changes []SyncChange
+	DBCtx(ctx).Where("id > ? AND created_at < ?", since, until).Order("id").Limit(limit).Find(&changes)
+	return changes
+}
+
+// GetSyncHead returns the ID of the last change recorded before until, or 0 if there is none
+func GetSyncHead(ctx context.Context, until time.Time) int64 {
+	var head int64
+	DBCtx(ctx).Raw("SELECT coalesce(max(id), 0) FROM sync_changes WHERE created_at < ?", until).Scan(&head)
+	return head
+}
+
+// GetSyncTail returns the ID of the oldest recorded change, or 0 if there is none
+func GetSyncTail(ctx context.Context) int64 {
+	var tail int64
+	DBCtx(ctx).Raw("SELECT coalesce(min(id), 0) FROM sync_changes").Scan(&tail)
+	return tail
+}
+
+// PruneSyncChanges deletes the changes recorded before, and returns how many were deleted
+func PruneSyncChanges(ctx context.Context, before time.Time) int64 {
+	return DBCtx(ctx).Where("created_at < ?", before).Delete(&SyncChange{}).RowsAffected
+}
+
+// GetSyncMods returns the mods which are approved and not deleted
+func GetSyncMods(ctx context.Context, modIDs []string) []Mod {
+	var mods []Mod
+	DBCtx(ctx).Preload("Tags").
+		Where("id IN ? AND approved = ? AND denied = ?", modIDs, true, false).
+		Find(&mods)
+	return mods
+}
+
+// GetSyncVersions returns the approved versions which are not deleted, of mods which are approved and not deleted
+func GetSyncVersions(ctx context.Context, versionIDs []string) []Version {
+	var versions []Version
+	DBCtx(ctx).Preload("Targets").
+		Joins("JOIN mods ON mods.id = versions.mod_id AND mods.deleted_at IS NULL AND mods.approved = ? AND mods.denied = ?", true, false).
+		Where("versions.id IN ? AND versions.approved = ? AND versions.denied = ? AND versions.draft = ?", versionIDs, true, false, false).
+		Find(&versions)
+	return versions
+}
+
+// GetSyncVersionDependencies returns the dependencies of the versions, by version ID
+func GetSyncVersionDependencies(ctx context.Context, versionIDs []string) map[string][]VersionDependency {
+	var dependencies []VersionDependency
+	DBCtx(ctx).Where("version_id IN ?", versionIDs).Find(&dependencies)
+
+	result := make(map[string][]VersionDependency)
+	for _, dependency := range dependencies {
+		result[dependency.VersionID] = append(result[dependency.VersionID], dependency)
+	}
+
+	return result
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/finnbear/moderation"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
+	"gorm.io/gorm"
 
 	"github.com/satisfactorymodding/smr-api/generated"
 	"github.com/satisfactorymodding/smr-api/util"
@@ -150,3 +151,39 @@ func GetTags(ctx context.Context, filter *generated.TagFilter) []Tag {
 
 	return tags
 }
+
+func GetTagsByIDs(ctx context.Context, tagIDs []string) []Tag {
+	var tags []Tag
+	DBCtx(ctx).Find(&tags, "id in (?)", tagIDs)
+	return tags
+}
+
+// MergeTags moves every mod, guide and blueprint of the tags to the target tag, then deletes the merged tags
+func MergeTags(ctx context.Context, tagIDs []string, targetID string) error {
+	err := DBCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, table := range []struct {
+			name   string
+			column string
+		}{
+			{"mod_tags", "mod_id"},
+			{"guide_tags", "guide_id"},
+			{"blueprint_tags", "blueprint_id"},
+		} {
+			if err := tx.Exec("INSERT INTO "+table.name+" (tag_id, "+table.column+") "+
+				"SELECT DISTINCT ?, "+table.column+" FROM "+table.name+" WHERE tag_id IN ? "+
+				"ON CONFLICT DO NOTHING", targetID, tagIDs).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec("DELETE FROM "+table.name+" WHERE tag_id IN ?", tagIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&Tag{}, "id IN ?", tagIDs).Error
+	})
+
+	ClearCache()
+
+	return err
+}
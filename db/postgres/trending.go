@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Periods over which trending mods are computed, by their length in days
+var TrendingPeriods = map[string]int{
+	"day":   1,
+	"week":  7,
+	"month": 30,
+}
+
+// A view counts less towards the trending score than a download
+const trendingViewWeight = 0.25
+
+// Caps how much a mod can be boosted for growing compared to the previous period
+const trendingMaxGrowth = 10
+
+// addModDailyStat increments a counter of the daily stats of a mod for the current day
+func addModDailyStat(ctx context.Context, modID string, column string) {
+	DBCtx(ctx).Exec(`INSERT INTO mod_daily_stats (mod_id, day, `+column+`) VALUES (?, current_date, 1)
+		ON CONFLICT (mod_id, day) DO UPDATE SET `+column+` = mod_daily_stats.`+column+` + 1`, modID)
+}
+
+// ComputeTrendingMods replaces the trending scores of a period.
+//
+// The activity of a mod is its downloads and weighted views over the last days of the period.
+// The score is the activity, boosted by how much it grew compared to the period before.
+func ComputeTrendingMods(ctx context.Context, period string) error {
+	days, ok := TrendingPeriods[period]
+	if !ok {
+		return errors.New("unknown trending period " + period)
+	}
+
+	err := DBCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`DELETE FROM mod_trending WHERE period = ?`, period).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(`INSERT INTO mod_trending (mod_id, period, score, views, downloads, computed_at)
+			SELECT mod_id, ?, least(current * (current + 1) / (previous + 1), current * ?), views, downloads, now()
+			FROM (
+				SELECT mod_id,
+					coalesce(sum(views) FILTER (WHERE day > current_date - ?), 0) AS views,
+					coalesce(sum(downloads) FILTER (WHERE day > current_date - ?), 0) AS downloads,
+					coalesce(sum(downloads + views * ?) FILTER (WHERE day > current_date - ?), 0)::double precision AS current,
+					coalesce(sum(downloads + views * ?) FILTER (WHERE day <= current_date - ?), 0)::double precision AS previous
+				FROM mod_daily_stats
+				WHERE day > current_date - ?
+				GROUP BY mod_id
+			) AS activity
+			WHERE current > 0`,
+			period, trendingMaxGrowth,
+			days, days,
+			trendingViewWeight, days,
+			trendingViewWeight, days,
+			days*2).Error
+	})
+
+	return errors.Wrap(err, "failed to compute trending mods")
+}
+
+// PruneModDailyStats deletes the daily stats which are too old to be part of any trending period
+func PruneModDailyStats(ctx context.Context) {
+	longest := 0
+	for _, days := range TrendingPeriods {
+		if days > longest {
+			longest = days
+		}
+	}
+
+	DBCtx(ctx).Where("day < ?", time.Now().AddDate(0, 0, -longest*2-1)).Delete(&ModDailyStat{})
+}
+
+// GetTrendingMods returns the approved and listed mods with the highest trending score of the period
+func GetTrendingMods(ctx context.Context, period string, limit int) []Mod {
+	cacheKey := "GetTrendingMods_" + period + "_" + fmt.Sprint(limit)
+	if mods, ok := dbCache.Get(cacheKey); ok {
+		return mods.([]Mod)
+	}
+
+	var mods []Mod
+	DBCtx(ctx).Preload("Tags").
+		Joins("JOIN mod_trending ON mod_trending.mod_id = mods.id AND mod_trending.period = ?", period).
+		Where("mods.approved = ? AND mods.denied = ? AND mods.hidden = ? AND mods.archived = ?", true, false, false, false).
+		Order("mod_trending.score desc").
+		Limit(limit).
+		Find(&mods)
+
+	dbCache.Set(cacheKey, mods, cache.DefaultExpiration)
+
+	return mods
+}
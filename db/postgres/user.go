@@ -2,7 +2,12 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres/queries/users"
 	"github.com/satisfactorymodding/smr-api/oauth"
 	"github.com/satisfactorymodding/smr-api/util"
 )
@@ -59,7 +64,9 @@ func GetUserSession(ctx context.Context, oauthUser *oauth.UserData, userAgent st
 		}
 
 		if newID {
-			Save(ctx, &user)
+			if err := SaveUser(ctx, &user); err != nil {
+				log.Err(err).Str("id", user.ID).Msg("failed to link sign in to user")
+			}
 		}
 	}
 
@@ -104,38 +111,159 @@ func GetUserByToken(ctx context.Context, token string) *User {
 	return &user
 }
 
-func GetUserByID(ctx context.Context, userID string) *User {
-	var user User
-	DBCtx(ctx).Find(&user, "id = ?", userID)
+// userQueries runs the generated user queries on the connection of the context,
+// so they take part in its transaction if there is one
+func userQueries(ctx context.Context) *users.Queries {
+	return users.New(DBCtx(ctx).Statement.ConnPool)
+}
 
-	if user.ID == "" {
+func userFromRow(row users.User) User {
+	return User{
+		SMRModel: SMRModel{
+			ID:       row.ID,
+			SMRDates: rowDates(row.CreatedAt, row.UpdatedAt, row.DeletedAt),
+		},
+		GithubID:   stringPtr(row.GithubID),
+		GoogleID:   stringPtr(row.GoogleID),
+		FacebookID: stringPtr(row.FacebookID),
+		Email:      row.Email.String,
+		Username:   row.Username.String,
+		Avatar:     row.Avatar.String,
+		JoinedFrom: row.JoinedFrom.String,
+		Banned:     row.Banned,
+		QuotaTier:  stringPtr(row.QuotaTier),
+	}
+}
+
+func userModsFromRows(rows []users.UserMod) []UserMod {
+	userMods := make([]UserMod, len(rows))
+	for i, row := range rows {
+		userMods[i] = UserMod{
+			UserID: row.UserID,
+			ModID:  row.ModID,
+			Role:   row.Role.String,
+		}
+	}
+	return userMods
+}
+
+func GetUserByID(ctx context.Context, userID string) *User {
+	row, err := userQueries(ctx).GetUserByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Err(err).Str("id", userID).Msg("failed to get user")
+		}
 		return nil
 	}
 
+	user := userFromRow(row)
+
 	return &user
 }
 
 func GetUsersByID(ctx context.Context, userIds []string) *[]User {
-	var users []User
-	DBCtx(ctx).Find(&users, "id in (?)", userIds)
+	result := GetExistingUsersByID(ctx, userIds)
+
+	if len(userIds) != len(result) {
+		return nil
+	}
 
-	if len(userIds) != len(users) {
+	return &result
+}
+
+// GetExistingUsersByID returns the users with the provided IDs, skipping the IDs which do not match any user
+func GetExistingUsersByID(ctx context.Context, userIds []string) []User {
+	rows, err := userQueries(ctx).ListUsersByID(ctx, userIds)
+	if err != nil {
+		log.Err(err).Strs("ids", userIds).Msg("failed to list users")
 		return nil
 	}
 
-	return &users
+	result := make([]User, len(rows))
+	for i, row := range rows {
+		result[i] = userFromRow(row)
+	}
+
+	return result
+}
+
+// SaveUser saves the profile, sign in IDs, ban and quota tier of the user
+func SaveUser(ctx context.Context, user *User) error {
+	err := userQueries(ctx).UpdateUser(ctx, users.UpdateUserParams{
+		ID:         user.ID,
+		Email:      nullString(user.Email),
+		Username:   nullString(user.Username),
+		Avatar:     nullString(user.Avatar),
+		JoinedFrom: nullString(user.JoinedFrom),
+		Banned:     user.Banned,
+		GithubID:   nullStringPtr(user.GithubID),
+		GoogleID:   nullStringPtr(user.GoogleID),
+		FacebookID: nullStringPtr(user.FacebookID),
+		QuotaTier:  nullStringPtr(user.QuotaTier),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to save user")
+	}
+
+	runHooks(ctx, EntityUser, user.ID)
+
+	return nil
 }
 
 func GetUserMods(ctx context.Context, userID string) []UserMod {
-	var mods []UserMod
-	DBCtx(ctx).Raw("SELECT * from \"user_mods\" as tdm WHERE user_id = ? AND mod_id = (SELECT id FROM mods WHERE id = tdm.mod_id AND deleted_at is NULL LIMIT 1)", userID).Find(&mods)
-	return mods
+	rows, err := userQueries(ctx).ListUserMods(ctx, userID)
+	if err != nil {
+		log.Err(err).Str("user_id", userID).Msg("failed to list user mods")
+		return nil
+	}
+
+	return userModsFromRows(rows)
 }
 
 func GetModAuthors(ctx context.Context, modID string) []UserMod {
-	var authors []UserMod
-	DBCtx(ctx).Find(&authors, "mod_id = ?", modID)
-	return authors
+	return GetModAuthorsByModID(ctx, []string{modID})
+}
+
+// GetModAuthorsByModID returns the authors of every provided mod
+func GetModAuthorsByModID(ctx context.Context, modIDs []string) []UserMod {
+	rows, err := userQueries(ctx).ListModAuthors(ctx, modIDs)
+	if err != nil {
+		log.Err(err).Strs("mod_ids", modIDs).Msg("failed to list mod authors")
+		return nil
+	}
+
+	return userModsFromRows(rows)
+}
+
+// SetModAuthor adds the user to the authors of the mod, or changes their role if they already are one
+func SetModAuthor(ctx context.Context, modID string, userID string, role string) error {
+	err := userQueries(ctx).SetModAuthor(ctx, users.SetModAuthorParams{
+		UserID: userID,
+		ModID:  modID,
+		Role:   nullString(role),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to set mod author")
+	}
+
+	runHooks(ctx, EntityMod, modID)
+
+	return nil
+}
+
+// RemoveModAuthor removes the user from the authors of the mod
+func RemoveModAuthor(ctx context.Context, modID string, userID string) error {
+	err := userQueries(ctx).RemoveModAuthor(ctx, users.RemoveModAuthorParams{
+		UserID: userID,
+		ModID:  modID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to remove mod author")
+	}
+
+	runHooks(ctx, EntityMod, modID)
+
+	return nil
 }
 
 func UserCanUploadModVersions(ctx context.Context, user *User, modID string) bool {
@@ -143,14 +271,15 @@ func UserCanUploadModVersions(ctx context.Context, user *User, modID string) boo
 		return false
 	}
 
-	var userMod UserMod
-	DBCtx(ctx).First(&userMod, "user_id = ? AND mod_id = ?", user.ID, modID)
-
-	if userMod.UserID == "" {
+	userMod, err := userQueries(ctx).GetModAuthor(ctx, users.GetModAuthorParams{UserID: user.ID, ModID: modID})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Err(err).Str("user_id", user.ID).Str("mod_id", modID).Msg("failed to get mod author")
+		}
 		return false
 	}
 
-	if userMod.Role != "creator" && userMod.Role != "editor" {
+	if userMod.Role.String != "creator" && userMod.Role.String != "editor" {
 		return false
 	}
 
@@ -2,37 +2,234 @@ package postgres
 
 import (
 	"context"
-	"errors"
+	"database/sql"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 
+	"github.com/satisfactorymodding/smr-api/db/postgres/queries/dependencies"
+	"github.com/satisfactorymodding/smr-api/db/postgres/queries/targets"
+	"github.com/satisfactorymodding/smr-api/db/postgres/queries/versions"
 	"github.com/satisfactorymodding/smr-api/models"
 	"github.com/satisfactorymodding/smr-api/util"
 )
 
 var semverCheck = regexp.MustCompile(`^(<=|<|>|>=|\^)?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
 
+// versionQueries runs the generated version queries on the connection of the context,
+// so they take part in its transaction if there is one
+func versionQueries(ctx context.Context) *versions.Queries {
+	return versions.New(DBCtx(ctx).Statement.ConnPool)
+}
+
+func targetQueries(ctx context.Context) *targets.Queries {
+	return targets.New(DBCtx(ctx).Statement.ConnPool)
+}
+
+func dependencyQueries(ctx context.Context) *dependencies.Queries {
+	return dependencies.New(DBCtx(ctx).Statement.ConnPool)
+}
+
+func versionFromRow(row versions.Version) Version {
+	return Version{
+		SMRModel: SMRModel{
+			ID:       row.ID,
+			SMRDates: rowDates(row.CreatedAt, row.UpdatedAt, row.DeletedAt),
+		},
+		Metadata:            stringPtr(row.Metadata),
+		ContentFlags:        stringPtr(row.ContentFlags),
+		LastDownloadedAt:    timePtr(row.LastDownloadedAt),
+		TorrentKey:          stringPtr(row.TorrentKey),
+		MetalinkKey:         stringPtr(row.MetalinkKey),
+		InfoHash:            stringPtr(row.InfoHash),
+		IntegrityCheckedAt:  timePtr(row.IntegrityCheckedAt),
+		RetentionNotifiedAt: timePtr(row.RetentionNotifiedAt),
+		Hash:                stringPtr(row.Hash),
+		Size:                int64Ptr(row.Size),
+		VersionPatch:        intPtr(row.VersionPatch),
+		VersionMinor:        intPtr(row.VersionMinor),
+		VersionMajor:        intPtr(row.VersionMajor),
+		ModReference:        stringPtr(row.ModReference),
+		Changelog:           row.Changelog.String,
+		ChangelogSource:     row.ChangelogSource,
+		ContainerType:       row.ContainerType,
+		StorageTier:         row.StorageTier,
+		Stability:           row.Stability.String,
+		Key:                 row.Key.String,
+		SMLVersion:          row.SmlVersion.String,
+		Version:             row.Version.String,
+		ModID:               row.ModID.String,
+		Hotness:             uint(row.Hotness.Int32),
+		Downloads:           uint(row.Downloads.Int32),
+		Denied:              row.Denied,
+		Approved:            row.Approved,
+		Draft:               row.Draft,
+		LegacyTarget:        row.LegacyTarget,
+		Quarantined:         row.Quarantined,
+		Corrupted:           row.Corrupted,
+	}
+}
+
+func versionTargetFromRow(row targets.VersionTarget) VersionTarget {
+	return VersionTarget{
+		VersionID:  row.VersionID.String,
+		TargetName: row.TargetName.String,
+		Key:        row.Key.String,
+		Hash:       row.Hash.String,
+		Size:       row.Size.Int64,
+	}
+}
+
+// versionsFromRows converts the rows along with their targets
+func versionsFromRows(ctx context.Context, rows []versions.Version) ([]Version, error) {
+	result := make([]Version, len(rows))
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		result[i] = versionFromRow(row)
+		ids[i] = row.ID
+	}
+
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	targetRows, err := targetQueries(ctx).ListVersionTargets(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list version targets")
+	}
+
+	versionTargets := make(map[string][]VersionTarget)
+	for _, row := range targetRows {
+		versionTargets[row.VersionID.String] = append(versionTargets[row.VersionID.String], versionTargetFromRow(row))
+	}
+
+	for i := range result {
+		result[i].Targets = versionTargets[result[i].ID]
+	}
+
+	return result, nil
+}
+
+// getVersion runs a query returning a single version and loads it, returning nil if there is none
+func getVersion(ctx context.Context, query func(q *versions.Queries) (versions.Version, error)) *Version {
+	row, err := query(versionQueries(ctx))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Err(err).Msg("failed to get version")
+		}
+		return nil
+	}
+
+	loaded, err := versionsFromRows(ctx, []versions.Version{row})
+	if err != nil {
+		log.Err(err).Str("id", row.ID).Msg("failed to load version")
+		return nil
+	}
+
+	return &loaded[0]
+}
+
+// listVersions runs a query returning versions and loads them, returning nil if it fails
+func listVersions(ctx context.Context, query func(q *versions.Queries) ([]versions.Version, error)) []Version {
+	rows, err := query(versionQueries(ctx))
+	if err != nil {
+		log.Err(err).Msg("failed to list versions")
+		return nil
+	}
+
+	loaded, err := versionsFromRows(ctx, rows)
+	if err != nil {
+		log.Err(err).Msg("failed to load versions")
+		return nil
+	}
+
+	return loaded
+}
+
 func GetVersionsByID(ctx context.Context, versionIds []string) []Version {
 	cacheKey := "GetVersionsById_" + strings.Join(versionIds, ":")
 	if versions, ok := dbCache.Get(cacheKey); ok {
 		return versions.([]Version)
 	}
 
-	var versions []Version
-	DBCtx(ctx).Preload("Targets").Find(&versions, "id in (?)", versionIds)
+	result := listVersions(ctx, func(q *versions.Queries) ([]versions.Version, error) {
+		return q.ListVersionsByID(ctx, versionIds)
+	})
 
-	if len(versionIds) != len(versions) {
+	if len(versionIds) != len(result) {
 		return nil
 	}
 
-	dbCache.Set(cacheKey, versions, cache.DefaultExpiration)
+	dbCache.Set(cacheKey, result, cache.DefaultExpiration)
 
-	return versions
+	return result
+}
+
+// GetApprovedModVersions returns every approved version of the mod, with their dependencies
+func GetApprovedModVersions(ctx context.Context, modID string) []Version {
+	cacheKey := "GetApprovedModVersions_" + modID
+	if versions, ok := dbCache.Get(cacheKey); ok {
+		return versions.([]Version)
+	}
+
+	result := GetApprovedVersionsByModID(ctx, []string{modID})
+
+	dbCache.Set(cacheKey, result, cache.DefaultExpiration)
+
+	return result
+}
+
+// GetApprovedVersionsByModID returns every approved version of the mods, latest first, with their targets
+func GetApprovedVersionsByModID(ctx context.Context, modIDs []string) []Version {
+	return listVersions(ctx, func(q *versions.Queries) ([]versions.Version, error) {
+		return q.ListApprovedModVersions(ctx, modIDs)
+	})
+}
+
+// GetApprovedVersionsByModIDNoMeta returns the approved versions of the mods like GetApprovedVersionsByModID,
+// without their metadata and the fields only used when they are published or stored
+func GetApprovedVersionsByModIDNoMeta(ctx context.Context, modIDs []string) []Version {
+	return listVersions(ctx, func(q *versions.Queries) ([]versions.Version, error) {
+		rows, err := q.ListApprovedModVersionsNoMeta(ctx, modIDs)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list versions")
+		}
+
+		result := make([]versions.Version, len(rows))
+		for i, row := range rows {
+			result[i] = versions.Version{
+				ID:           row.ID,
+				CreatedAt:    row.CreatedAt,
+				UpdatedAt:    row.UpdatedAt,
+				DeletedAt:    row.DeletedAt,
+				ModID:        row.ModID,
+				Version:      row.Version,
+				SmlVersion:   row.SmlVersion,
+				Changelog:    row.Changelog,
+				Downloads:    row.Downloads,
+				Key:          row.Key,
+				Stability:    row.Stability,
+				Approved:     row.Approved,
+				Hotness:      row.Hotness,
+				Denied:       row.Denied,
+				ModReference: row.ModReference,
+				VersionMajor: row.VersionMajor,
+				VersionMinor: row.VersionMinor,
+				VersionPatch: row.VersionPatch,
+				Size:         row.Size,
+				Hash:         row.Hash,
+			}
+		}
+
+		return result, nil
+	})
 }
 
 func GetModLatestVersions(ctx context.Context, modID string, unapproved bool) *[]Version {
@@ -73,6 +270,52 @@ func GetModsLatestVersions(ctx context.Context, modIds []string, unapproved bool
 	return &versions
 }
 
+// GetLatestCompatibleVersions returns the latest approved version of every listed mod which supports the target
+// and works with an SML release made for the game build
+func GetLatestCompatibleVersions(ctx context.Context, gameVersion int, target string) []Version {
+	cacheKey := "GetLatestCompatibleVersions_" + fmt.Sprint(gameVersion) + "_" + target
+	if versions, ok := dbCache.Get(cacheKey); ok {
+		return versions.([]Version)
+	}
+
+	var versions []Version
+
+	DBCtx(ctx).Preload("Targets").Select("distinct on (versions.mod_id) versions.*").
+		Joins("INNER JOIN mods on mods.id = versions.mod_id").
+		Where("versions.approved = ? AND versions.denied = ?", true, false).
+		Where("mods.approved = ? AND mods.denied = ? AND mods.hidden = ? AND mods.deleted_at is null", true, false, false).
+		Where("versions.sml_version in ?", GetMatchingSMLRequirements(ctx, nil, &gameVersion)).
+		Where("versions.id in (select version_id from version_targets where target_name = ?)", target).
+		Order("versions.mod_id, versions.version_major desc nulls last, versions.version_minor desc nulls last, versions.version_patch desc nulls last, versions.created_at desc").
+		Find(&versions)
+
+	dbCache.Set(cacheKey, versions, cache.DefaultExpiration)
+
+	return versions
+}
+
+// GetLatestTargetVersion returns the newest approved version of a mod having the target, optionally only among the
+// versions requiring an SML release matching smlVersion, or one supporting gameVersion
+func GetLatestTargetVersion(ctx context.Context, modID string, target string, smlVersion *string, gameVersion *int) *Version {
+	query := DBCtx(ctx).Preload("Targets").
+		Where("mod_id = ? AND approved = ? AND denied = ? AND draft = ?", modID, true, false, false).
+		Where("id in (select version_id from version_targets where target_name = ?)", target)
+
+	if smlVersion != nil || gameVersion != nil {
+		query = query.Where("sml_version in ?", GetMatchingSMLRequirements(ctx, smlVersion, gameVersion))
+	}
+
+	var version Version
+	query.Order("version_major desc nulls last, version_minor desc nulls last, version_patch desc nulls last, created_at desc").
+		Limit(1).Find(&version)
+
+	if version.ID == "" {
+		return nil
+	}
+
+	return &version
+}
+
 func GetModVersions(ctx context.Context, modID string, limit int, offset int, orderBy string, order string, unapproved bool) []Version {
 	cacheKey := "GetModVersions_" + modID + "_" + fmt.Sprint(limit) + "_" + fmt.Sprint(offset) + "_" + orderBy + "_" + order + "_" + fmt.Sprint(unapproved)
 	if versions, ok := dbCache.Get(cacheKey); ok {
@@ -120,8 +363,9 @@ func GetModVersionsNew(ctx context.Context, modID string, filter *models.Version
 
 	if filter != nil {
 		query = query.Limit(*filter.Limit).
-			Offset(*filter.Offset).
-			Order(string(*filter.OrderBy) + " " + string(*filter.Order))
+			Offset(*filter.Offset)
+
+		query = orderAfterCursor(query, "versions", string(*filter.OrderBy), *filter.Order, filter.Cursor)
 	}
 
 	query.Preload("Targets").Where("approved = ? AND denied = ?", !unapproved, false).Find(&versions, "mod_id = ?", modID)
@@ -139,16 +383,17 @@ func GetModVersion(ctx context.Context, modID string, versionID string) *Version
 		return version.(*Version)
 	}
 
-	var version Version
-	DBCtx(ctx).Preload("Targets").First(&version, "mod_id = ? AND id = ?", modID, versionID)
+	version := getVersion(ctx, func(q *versions.Queries) (versions.Version, error) {
+		return q.GetModVersion(ctx, versions.GetModVersionParams{ModID: nullString(modID), ID: versionID})
+	})
 
-	if version.ID == "" {
+	if version == nil {
 		return nil
 	}
 
-	dbCache.Set(cacheKey, &version, cache.DefaultExpiration)
+	dbCache.Set(cacheKey, version, cache.DefaultExpiration)
 
-	return &version
+	return version
 }
 
 func GetModVersionByName(ctx context.Context, modID string, versionName string) *Version {
@@ -157,24 +402,47 @@ func GetModVersionByName(ctx context.Context, modID string, versionName string)
 		return version.(*Version)
 	}
 
-	var version Version
-	DBCtx(ctx).Preload("Targets").First(&version, "mod_id = ? AND version = ?", modID, versionName)
+	version := GetConflictingVersion(ctx, modID, versionName)
 
-	if version.ID == "" {
+	if version == nil {
 		return nil
 	}
 
-	dbCache.Set(cacheKey, &version, cache.DefaultExpiration)
+	dbCache.Set(cacheKey, version, cache.DefaultExpiration)
 
-	return &version
+	return version
 }
 
-func CreateVersion(ctx context.Context, version *Version) error {
-	var versionCount int64
-	DBCtx(ctx).Model(Version{}).Where("mod_id = ? AND version = ?", version.ModID, version.Version).Count(&versionCount)
+// VersionAlreadyExistsError is returned when a mod already has a version with the same name
+type VersionAlreadyExistsError struct {
+	VersionID string
+	Version   string
+}
+
+func (e *VersionAlreadyExistsError) Error() string {
+	return "this mod already has a version with this name (" + e.Version + ")"
+}
 
-	if versionCount > 0 {
-		return errors.New("this mod already has a version with this name")
+func (e *VersionAlreadyExistsError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":       "VERSION_ALREADY_EXISTS",
+		"version_id": e.VersionID,
+	}
+}
+
+// GetConflictingVersion returns the existing version of the mod with the provided name, bypassing the cache
+func GetConflictingVersion(ctx context.Context, modID string, versionName string) *Version {
+	return getVersion(ctx, func(q *versions.Queries) (versions.Version, error) {
+		return q.GetModVersionByName(ctx, versions.GetModVersionByNameParams{ModID: nullString(modID), Version: nullString(versionName)})
+	})
+}
+
+func CreateVersion(ctx context.Context, version *Version) error {
+	if existing := GetConflictingVersion(ctx, version.ModID, version.Version); existing != nil {
+		return &VersionAlreadyExistsError{
+			VersionID: existing.ID,
+			Version:   existing.Version,
+		}
 	}
 
 	// Allow only new 5 versions per 24h
@@ -193,8 +461,28 @@ func CreateVersion(ctx context.Context, version *Version) error {
 	return nil
 }
 
-func IncrementVersionDownloads(ctx context.Context, version *Version) {
-	DBCtx(ctx).Model(version).Update("downloads", version.Downloads+1)
+// IncrementVersionDownloads counts a download of the version, or of one of its targets if target is not empty.
+// The download is recorded as an event, and added to the counters by the next rollup.
+func IncrementVersionDownloads(ctx context.Context, version *Version, target string) {
+	recordDownloadEvent(ctx, version, target)
+}
+
+// GetColdStorageCandidates returns public versions in hot storage which have not been downloaded since the provided time
+func GetColdStorageCandidates(ctx context.Context, before time.Time, limit int) []Version {
+	var versions []Version
+	DBCtx(ctx).Preload("Targets").
+		Where("storage_tier = ? AND quarantined = ? AND draft = ?", StorageTierHot, false, false).
+		Where("coalesce(last_downloaded_at, created_at) < ?", before).
+		Order("coalesce(last_downloaded_at, created_at) asc").
+		Limit(limit).
+		Find(&versions)
+	return versions
+}
+
+// SetVersionStorageTier updates the storage tier of a version
+func SetVersionStorageTier(ctx context.Context, version *Version, tier string) {
+	DBCtx(ctx).Model(version).Update("storage_tier", tier)
+	ClearCache()
 }
 
 func GetVersion(ctx context.Context, versionID string) *Version {
@@ -203,16 +491,101 @@ func GetVersion(ctx context.Context, versionID string) *Version {
 		return version.(*Version)
 	}
 
-	var version Version
-	DBCtx(ctx).Preload("Targets").First(&version, "id = ?", versionID)
+	version := getVersion(ctx, func(q *versions.Queries) (versions.Version, error) {
+		return q.GetVersionByID(ctx, versionID)
+	})
 
-	if version.ID == "" {
+	if version == nil {
 		return nil
 	}
 
-	dbCache.Set(cacheKey, &version, cache.DefaultExpiration)
+	dbCache.Set(cacheKey, version, cache.DefaultExpiration)
 
-	return &version
+	return version
+}
+
+// GetDeletedVersion returns a version which was deleted but not purged yet
+func GetDeletedVersion(ctx context.Context, versionID string) *Version {
+	return getVersion(ctx, func(q *versions.Queries) (versions.Version, error) {
+		return q.GetDeletedVersion(ctx, versionID)
+	})
+}
+
+// SaveVersion saves the fields of the version which are not counters or kept up to date by their own queries
+func SaveVersion(ctx context.Context, version *Version) error {
+	return saveVersion(ctx, version, sql.NullTime{})
+}
+
+// SaveVersionIfUnchanged saves the version like SaveVersion, unless it was updated by someone else since it was
+// loaded with the provided updatedAt, in which case an EditConflictError is returned
+func SaveVersionIfUnchanged(ctx context.Context, version *Version, updatedAt time.Time) error {
+	return saveVersion(ctx, version, sql.NullTime{Time: updatedAt, Valid: true})
+}
+
+func saveVersion(ctx context.Context, version *Version, expectedUpdatedAt sql.NullTime) error {
+	updatedAt, err := versionQueries(ctx).UpdateVersion(ctx, versions.UpdateVersionParams{
+		ID:                  version.ID,
+		Version:             nullString(version.Version),
+		SmlVersion:          nullString(version.SMLVersion),
+		Changelog:           nullString(version.Changelog),
+		ChangelogSource:     version.ChangelogSource,
+		Stability:           nullString(version.Stability),
+		Key:                 nullString(version.Key),
+		Metadata:            nullStringPtr(version.Metadata),
+		ModReference:        nullStringPtr(version.ModReference),
+		VersionMajor:        nullInt32Ptr(version.VersionMajor),
+		VersionMinor:        nullInt32Ptr(version.VersionMinor),
+		VersionPatch:        nullInt32Ptr(version.VersionPatch),
+		Size:                nullInt64Ptr(version.Size),
+		Hash:                nullStringPtr(version.Hash),
+		Approved:            version.Approved,
+		Denied:              version.Denied,
+		Draft:               version.Draft,
+		LegacyTarget:        version.LegacyTarget,
+		Quarantined:         version.Quarantined,
+		Corrupted:           version.Corrupted,
+		ContentFlags:        nullStringPtr(version.ContentFlags),
+		ContainerType:       version.ContainerType,
+		StorageTier:         version.StorageTier,
+		TorrentKey:          nullStringPtr(version.TorrentKey),
+		MetalinkKey:         nullStringPtr(version.MetalinkKey),
+		InfoHash:            nullStringPtr(version.InfoHash),
+		IntegrityCheckedAt:  nullTimePtr(version.IntegrityCheckedAt),
+		RetentionNotifiedAt: nullTimePtr(version.RetentionNotifiedAt),
+		ExpectedUpdatedAt:   expectedUpdatedAt,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) && expectedUpdatedAt.Valid {
+			return &EditConflictError{TargetType: "version"}
+		}
+		return errors.Wrap(err, "failed to save version")
+	}
+
+	version.UpdatedAt = updatedAt.Time
+
+	runHooks(ctx, EntityVersion, version.ID)
+
+	return nil
+}
+
+// DeleteVersion soft deletes the version, leaving its files and rows to be purged
+func DeleteVersion(ctx context.Context, versionID string) error {
+	if err := versionQueries(ctx).DeleteVersion(ctx, versionID); err != nil {
+		return errors.Wrap(err, "failed to delete version")
+	}
+
+	runHooks(ctx, EntityVersion, versionID)
+
+	return nil
+}
+
+// RestoreVersion undoes the deletion of a version, clearing its denied state
+func RestoreVersion(ctx context.Context, version *Version) {
+	DBCtx(ctx).Unscoped().Model(version).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"denied":     false,
+	})
+	ClearCache()
 }
 
 func GetVersionsNew(ctx context.Context, filter *models.VersionFilter, unapproved bool) []Version {
@@ -226,12 +599,13 @@ func GetVersionsNew(ctx context.Context, filter *models.VersionFilter, unapprove
 	}
 
 	var versions []Version
-	query := DBCtx(ctx).Preload("Targets").Where("approved = ? AND denied = ?", !unapproved, false)
+	query := ReadDBCtx(ctx).Preload("Targets").Where("approved = ? AND denied = ? AND draft = ?", !unapproved, false, false)
 
 	if filter != nil {
 		query = query.Limit(*filter.Limit).
-			Offset(*filter.Offset).
-			Order(string(*filter.OrderBy) + " " + string(*filter.Order))
+			Offset(*filter.Offset)
+
+		query = orderAfterCursor(query, "versions", string(*filter.OrderBy), *filter.Order, filter.Cursor)
 
 		if filter.Search != nil && *filter.Search != "" {
 			query = query.Where("to_tsvector(version) @@ to_tsquery(?)", strings.ReplaceAll(*filter.Search, " ", " & "))
@@ -251,6 +625,28 @@ func GetVersionsNew(ctx context.Context, filter *models.VersionFilter, unapprove
 	return versions
 }
 
+// GetChangelogFeed returns the newest approved versions having a changelog, optionally only of the provided mods
+// and created after since. Versions of hidden or deleted mods are excluded.
+func GetChangelogFeed(ctx context.Context, modIDs []string, since *time.Time, limit int) []Version {
+	var versions []Version
+	query := DBCtx(ctx).Preload("Targets").
+		Joins("JOIN mods ON mods.id = versions.mod_id AND mods.deleted_at IS NULL AND mods.hidden = ?", false).
+		Where("versions.approved = ? AND versions.denied = ? AND versions.draft = ?", true, false, false).
+		Where("versions.changelog <> ''")
+
+	if modIDs != nil {
+		query = query.Where("versions.mod_id IN ?", modIDs)
+	}
+
+	if since != nil {
+		query = query.Where("versions.created_at > ?", *since)
+	}
+
+	query.Order("versions.created_at desc").Limit(limit).Find(&versions)
+
+	return versions
+}
+
 func GetVersionCountNew(ctx context.Context, filter *models.VersionFilter, unapproved bool) int64 {
 	hash, err := filter.Hash()
 	cacheKey := ""
@@ -262,7 +658,7 @@ func GetVersionCountNew(ctx context.Context, filter *models.VersionFilter, unapp
 	}
 
 	var versionCount int64
-	query := DBCtx(ctx).Model(Version{}).Where("approved = ? AND denied = ?", !unapproved, false)
+	query := ReadDBCtx(ctx).Model(Version{}).Where("approved = ? AND denied = ? AND draft = ?", !unapproved, false, false)
 
 	if filter != nil {
 		if filter.Search != nil && *filter.Search != "" {
@@ -285,24 +681,192 @@ func GetVersionTarget(ctx context.Context, versionID string, target string) *Ver
 		return versionTarget.(*VersionTarget)
 	}
 
-	var versionTarget VersionTarget
-	DBCtx(ctx).First(&versionTarget, "version_id = ? AND target_name = ?", versionID, target)
-
-	if versionTarget.VersionID == "" {
+	row, err := targetQueries(ctx).GetVersionTarget(ctx, targets.GetVersionTargetParams{
+		VersionID:  nullString(versionID),
+		TargetName: nullString(target),
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Err(err).Str("version_id", versionID).Str("target", target).Msg("failed to get version target")
+		}
 		return nil
 	}
 
+	versionTarget := versionTargetFromRow(row)
+
 	dbCache.Set(cacheKey, &versionTarget, cache.DefaultExpiration)
 
 	return &versionTarget
 }
 
+func GetVersionTargets(ctx context.Context, versionID string) []VersionTarget {
+	rows, err := targetQueries(ctx).ListVersionTargets(ctx, []string{versionID})
+	if err != nil {
+		log.Err(err).Str("version_id", versionID).Msg("failed to list version targets")
+		return nil
+	}
+
+	versionTargets := make([]VersionTarget, len(rows))
+	for i, row := range rows {
+		versionTargets[i] = versionTargetFromRow(row)
+	}
+
+	return versionTargets
+}
+
+// GetPreviousModVersion returns the latest public version of the mod created before the provided one
+func GetPreviousModVersion(ctx context.Context, modID string, versionID string) *Version {
+	var version Version
+	DBCtx(ctx).Preload("Targets").
+		Where("mod_id = ? AND id != ? AND approved = ? AND denied = ? AND draft = ? AND quarantined = ?", modID, versionID, true, false, false, false).
+		Where("created_at < (?)", DBCtx(ctx).Model(Version{}).Select("created_at").Where("id = ?", versionID)).
+		Order("created_at desc").
+		First(&version)
+
+	if version.ID == "" {
+		return nil
+	}
+
+	return &version
+}
+
+func GetVersionTargetPatch(ctx context.Context, versionID string, fromVersionID string, target string) *VersionTargetPatch {
+	cacheKey := "GetVersionTargetPatch_" + versionID + "_" + fromVersionID + "_" + target
+	if patch, ok := dbCache.Get(cacheKey); ok {
+		return patch.(*VersionTargetPatch)
+	}
+
+	var patch VersionTargetPatch
+	DBCtx(ctx).First(&patch, "version_id = ? AND from_version_id = ? AND target_name = ?", versionID, fromVersionID, target)
+
+	if patch.VersionID == "" {
+		return nil
+	}
+
+	dbCache.Set(cacheKey, &patch, cache.DefaultExpiration)
+
+	return &patch
+}
+
+func GetVersionTargetPatches(ctx context.Context, versionID string, fromVersionID string) []VersionTargetPatch {
+	var patches []VersionTargetPatch
+	DBCtx(ctx).Where("version_id = ? AND from_version_id = ?", versionID, fromVersionID).Find(&patches)
+	return patches
+}
+
 func GetVersionDependencies(ctx context.Context, versionID string) []VersionDependency {
-	var versionDependencies []VersionDependency
-	DBCtx(ctx).Where("version_id = ?", versionID).Find(&versionDependencies)
+	return GetVersionDependenciesByVersionID(ctx, []string{versionID})
+}
+
+// GetVersionDependenciesByVersionID returns the dependencies of every provided version
+func GetVersionDependenciesByVersionID(ctx context.Context, versionIDs []string) []VersionDependency {
+	rows, err := dependencyQueries(ctx).ListVersionDependencies(ctx, versionIDs)
+	if err != nil {
+		log.Err(err).Strs("version_ids", versionIDs).Msg("failed to list version dependencies")
+		return nil
+	}
+
+	versionDependencies := make([]VersionDependency, len(rows))
+	for i, row := range rows {
+		versionDependencies[i] = VersionDependency{
+			SMRDates:  rowDates(row.CreatedAt, row.UpdatedAt, row.DeletedAt),
+			VersionID: row.VersionID,
+			ModID:     row.ModID,
+			Condition: row.Condition.String,
+			Optional:  row.Optional.Bool,
+		}
+	}
+
 	return versionDependencies
 }
 
+// SaveVersionDependencies stores the required and optional dependencies of a version, by mod reference,
+// in a single statement. Existing dependencies on the same mods are updated, and restored if they were deleted.
+// A mod listed in both is an optional dependency.
+func SaveVersionDependencies(ctx context.Context, versionID string, required map[string]string, optional map[string]string) error {
+	byMod := make(map[string]VersionDependency, len(required)+len(optional))
+	for modID, condition := range required {
+		byMod[modID] = VersionDependency{VersionID: versionID, ModID: modID, Condition: condition}
+	}
+	for modID, condition := range optional {
+		byMod[modID] = VersionDependency{VersionID: versionID, ModID: modID, Condition: condition, Optional: true}
+	}
+
+	if len(byMod) == 0 {
+		return nil
+	}
+
+	modIDs := make([]string, 0, len(byMod))
+	for modID := range byMod {
+		modIDs = append(modIDs, modID)
+	}
+
+	// A stable order keeps concurrent saves of the same version from deadlocking
+	sort.Strings(modIDs)
+
+	params := dependencies.UpsertVersionDependenciesParams{
+		VersionID:  versionID,
+		ModIds:     modIDs,
+		Conditions: make([]string, len(modIDs)),
+		Optionals:  make([]bool, len(modIDs)),
+	}
+	for i, modID := range modIDs {
+		params.Conditions[i] = byMod[modID].Condition
+		params.Optionals[i] = byMod[modID].Optional
+	}
+
+	if err := dependencyQueries(ctx).UpsertVersionDependencies(ctx, params); err != nil {
+		return errors.Wrap(err, "failed to save version dependencies")
+	}
+
+	runHooks(ctx, EntityVersion, versionID)
+
+	return nil
+}
+
+// SaveVersionTargets stores targets in a single statement, updating the existing ones
+func SaveVersionTargets(ctx context.Context, versionTargets []*VersionTarget) error {
+	if len(versionTargets) == 0 {
+		return nil
+	}
+
+	sorted := make([]*VersionTarget, len(versionTargets))
+	copy(sorted, versionTargets)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].VersionID != sorted[j].VersionID {
+			return sorted[i].VersionID < sorted[j].VersionID
+		}
+		return sorted[i].TargetName < sorted[j].TargetName
+	})
+
+	params := targets.UpsertVersionTargetsParams{
+		VersionIds:  make([]string, len(sorted)),
+		TargetNames: make([]string, len(sorted)),
+		Keys:        make([]string, len(sorted)),
+		Hashes:      make([]string, len(sorted)),
+		Sizes:       make([]int64, len(sorted)),
+	}
+	for i, target := range sorted {
+		params.VersionIds[i] = target.VersionID
+		params.TargetNames[i] = target.TargetName
+		params.Keys[i] = target.Key
+		params.Hashes[i] = target.Hash
+		params.Sizes[i] = target.Size
+	}
+
+	if err := targetQueries(ctx).UpsertVersionTargets(ctx, params); err != nil {
+		return errors.Wrap(err, "failed to save version targets")
+	}
+
+	for i, versionID := range params.VersionIds {
+		if i == 0 || params.VersionIds[i-1] != versionID {
+			runHooks(ctx, EntityVersion, versionID)
+		}
+	}
+
+	return nil
+}
+
 func GetModVersionsConstraint(ctx context.Context, modID string, constraint string) []Version {
 	matches := semverCheck.FindAllStringSubmatch(constraint, -1)
 	if len(matches) == 0 {
@@ -396,3 +960,47 @@ func GetModVersionsConstraint(ctx context.Context, modID string, constraint stri
 	query.Preload("Targets").Find(&versions)
 	return versions
 }
+
+// GetVersionFileKeys returns the keys of every file referenced by a version, target, patch or torrent, including deleted versions
+func GetVersionFileKeys(ctx context.Context) []string {
+	var keys []string
+	DBCtx(ctx).Raw(`SELECT key FROM versions WHERE key IS NOT NULL AND key != ''
+		UNION SELECT key FROM version_targets WHERE key IS NOT NULL AND key != ''
+		UNION SELECT key FROM version_target_patches WHERE key IS NOT NULL AND key != ''
+		UNION SELECT torrent_key FROM versions WHERE torrent_key IS NOT NULL
+		UNION SELECT metalink_key FROM versions WHERE metalink_key IS NOT NULL`).
+		Scan(&keys)
+	return keys
+}
+
+// SetVersionTorrent records the torrent and metalink generated for a version
+func SetVersionTorrent(ctx context.Context, version *Version, torrentKey string, metalinkKey string, infoHash string) {
+	DBCtx(ctx).Model(version).Updates(map[string]interface{}{
+		"torrent_key":  torrentKey,
+		"metalink_key": metalinkKey,
+		"info_hash":    infoHash,
+	})
+	ClearCache()
+}
+
+// GetIntegrityAuditVersions returns versions in hot storage not audited since the provided time, least recently audited first.
+// Quarantined versions are skipped, as their files are not in the primary storage.
+func GetIntegrityAuditVersions(ctx context.Context, checkedBefore time.Time, limit int) []Version {
+	var versions []Version
+	DBCtx(ctx).Preload("Targets").
+		Where("storage_tier = ? AND quarantined = ?", StorageTierHot, false).
+		Where("integrity_checked_at IS NULL OR integrity_checked_at < ?", checkedBefore).
+		Order("integrity_checked_at asc nulls first").
+		Limit(limit).
+		Find(&versions)
+	return versions
+}
+
+// SetVersionIntegrity records the result of an integrity audit of a version
+func SetVersionIntegrity(ctx context.Context, version *Version, corrupted bool) {
+	DBCtx(ctx).Model(version).Updates(map[string]interface{}{
+		"corrupted":            corrupted,
+		"integrity_checked_at": time.Now(),
+	})
+	ClearCache()
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// CreateWebhook stores a new active webhook with a newly generated secret
+func CreateWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.ID = util.GenerateUniqueID()
+	webhook.Secret = secret
+	webhook.Active = true
+
+	if err := DBCtx(ctx).Create(webhook).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to create webhook")
+	}
+
+	return webhook, nil
+}
+
+func GetWebhookByID(ctx context.Context, webhookID string) *Webhook {
+	var webhook Webhook
+	DBCtx(ctx).Find(&webhook, "id = ?", webhookID)
+
+	if webhook.ID == "" {
+		return nil
+	}
+
+	return &webhook
+}
+
+// GetUserWebhooks returns the webhooks of a user, oldest first
+func GetUserWebhooks(ctx context.Context, userID string) []Webhook {
+	var webhooks []Webhook
+	DBCtx(ctx).Where("user_id = ?", userID).Order("created_at asc").Find(&webhooks)
+	return webhooks
+}
+
+func GetUserWebhookCount(ctx context.Context, userID string) int64 {
+	var count int64
+	DBCtx(ctx).Model(Webhook{}).Where("user_id = ?", userID).Count(&count)
+	return count
+}
+
+// GetEventWebhooks returns the active webhooks subscribed to the event, of the mod if it concerns one
+func GetEventWebhooks(ctx context.Context, event string, modID *string) []Webhook {
+	events, _ := json.Marshal([]string{event})
+
+	query := DBCtx(ctx).Where("active = true AND events @> ?::jsonb", string(events))
+
+	if modID != nil {
+		mods, _ := json.Marshal([]string{*modID})
+		query = query.Where("(mod_ids = '[]'::jsonb OR mod_ids @> ?::jsonb)", string(mods))
+	} else {
+		query = query.Where("mod_ids = '[]'::jsonb")
+	}
+
+	var webhooks []Webhook
+	query.Find(&webhooks)
+	return webhooks
+}
+
+// ResetWebhookSecret replaces the secret deliveries of the webhook are signed with
+func ResetWebhookSecret(ctx context.Context, webhook *Webhook) error {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return err
+	}
+
+	if err := DBCtx(ctx).Model(webhook).Update("secret", secret).Error; err != nil {
+		return errors.Wrap(err, "failed to reset webhook secret")
+	}
+
+	return nil
+}
+
+// RecordWebhookDelivery stores the outcome of a delivery attempt. A successful delivery clears the failures,
+// and the webhook is deactivated once maxFailures attempts in a row failed. A maxFailures of 0 never deactivates it.
+func RecordWebhookDelivery(ctx context.Context, webhook *Webhook, status *int, deliveryErr error, maxFailures int) {
+	updates := map[string]interface{}{
+		"last_status":      status,
+		"last_delivery_at": time.Now(),
+		"last_error":       nil,
+		"failure_count":    0,
+	}
+
+	if deliveryErr != nil {
+		updates["last_error"] = deliveryErr.Error()
+		updates["failure_count"] = gorm.Expr("failure_count + 1")
+
+		if maxFailures > 0 && webhook.FailureCount+1 >= maxFailures {
+			updates["active"] = false
+		}
+	}
+
+	DBCtx(ctx).Model(webhook).UpdateColumns(updates)
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "failed to generate webhook secret")
+	}
+
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis"
+)
+
+// GetSiteStats returns the site statistics cached by the last computation, computing them if there is none
+func GetSiteStats(ctx context.Context) (*postgres.SiteStats, error) {
+	var stats postgres.SiteStats
+	if redis.GetSiteStats(&stats) {
+		return &stats, nil
+	}
+
+	return RefreshSiteStats(ctx)
+}
+
+// RefreshSiteStats computes the site statistics, and caches them for a few stats.interval,
+// so they are still served if a computation fails
+func RefreshSiteStats(ctx context.Context) (*postgres.SiteStats, error) {
+	stats, err := postgres.ComputeSiteStats(ctx, viper.GetDuration("stats.active_author_window"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := redis.StoreSiteStats(stats, viper.GetDuration("stats.interval")*3); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/util/sitemap"
+)
+
+// SitemapIndex is the name of the sitemap index listing every other sitemap
+const SitemapIndex = "index"
+
+// sitemapSources list the public pages of the site, by the path they are found at below site.url
+var sitemapSources = []struct {
+	name  string
+	path  string
+	query func(ctx context.Context) ([]postgres.SitemapEntry, error)
+}{
+	{name: "mods", path: "/mod/", query: postgres.GetSitemapMods},
+	{name: "guides", path: "/guide/", query: postgres.GetSitemapGuides},
+	{name: "users", path: "/user/", query: postgres.GetSitemapUsers},
+}
+
+// GetSitemap returns a sitemap rendered by the last generation, generating them if there is none.
+// Returns nil if the sitemap does not exist.
+func GetSitemap(ctx context.Context, name string) ([]byte, error) {
+	if result, ok := redis.GetSitemap(name); ok {
+		return result, nil
+	}
+
+	if _, ok := redis.GetSitemap(SitemapIndex); ok {
+		return nil, nil
+	}
+
+	sitemaps, err := GenerateSitemaps(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return sitemaps[name], nil
+}
+
+// GenerateSitemaps renders the sitemaps of the public mods, guides and users, paginated by sitemap.page_size,
+// and the index listing them. They are stored for a few sitemap.interval, so they are still served if a generation fails.
+func GenerateSitemaps(ctx context.Context) (map[string][]byte, error) {
+	siteURL := strings.TrimSuffix(viper.GetString("site.url"), "/")
+	sitemapURL := strings.TrimSuffix(viper.GetString("sitemap.url"), "/")
+
+	sitemaps := make(map[string][]byte)
+	index := make([]sitemap.URL, 0)
+
+	for _, source := range sitemapSources {
+		entries, err := source.query(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		urls := make([]sitemap.URL, len(entries))
+		for i, entry := range entries {
+			urls[i] = sitemap.NewURL(siteURL+source.path+url.PathEscape(entry.ID), entry.UpdatedAt)
+		}
+
+		for i, page := range sitemap.Paginate(urls, viper.GetInt("sitemap.page_size")) {
+			rendered, err := sitemap.Render(page)
+			if err != nil {
+				return nil, err
+			}
+
+			name := source.name + "-" + strconv.Itoa(i+1)
+			sitemaps[name] = rendered
+
+			// Modification dates are all formatted in UTC, so they can be compared as strings
+			lastModified := page[0]
+			for _, entry := range page {
+				if entry.LastMod > lastModified.LastMod {
+					lastModified = entry
+				}
+			}
+
+			index = append(index, sitemap.URL{Loc: sitemapURL + "/" + name + ".xml", LastMod: lastModified.LastMod})
+		}
+	}
+
+	rendered, err := sitemap.RenderIndex(index)
+	if err != nil {
+		return nil, err
+	}
+
+	sitemaps[SitemapIndex] = rendered
+
+	if err := redis.StoreSitemaps(sitemaps, viper.GetDuration("sitemap.interval")*3); err != nil {
+		return nil, err
+	}
+
+	return sitemaps, nil
+}
@@ -14,14 +14,31 @@ type NewMod struct {
 }
 
 type UpdateMod struct {
-	Name             *string                 `json:"name" validate:"omitempty,min=3,max=32"`
-	ShortDescription *string                 `json:"short_description" validate:"omitempty,min=16,max=128"`
-	FullDescription  *string                 `json:"full_description"`
-	Logo             *graphql.Upload         `json:"logo"`
-	SourceURL        *string                 `json:"source_url"`
-	ModReference     *string                 `json:"mod_reference"`
-	Hidden           *bool                   `json:"hidden"`
-	Compatibility    *CompatibilityInfoInput `json:"compatibility"`
-	Authors          []UpdateUserMod         `json:"authors"`
-	TagIDs           []string                `json:"tagIDs" validate:"dive,min=3,max=24"`
+	Name              *string                 `json:"name" validate:"omitempty,min=3,max=32"`
+	ShortDescription  *string                 `json:"short_description" validate:"omitempty,min=16,max=128"`
+	FullDescription   *string                 `json:"full_description"`
+	Logo              *graphql.Upload         `json:"logo"`
+	SourceURL         *string                 `json:"source_url"`
+	ModReference      *string                 `json:"mod_reference"`
+	Hidden            *bool                   `json:"hidden"`
+	Compatibility     *CompatibilityInfoInput `json:"compatibility"`
+	Authors           []UpdateUserMod         `json:"authors"`
+	TagIDs            []string                `json:"tagIDs" validate:"dive,min=3,max=24"`
+	ExpectedUpdatedAt *string                 `json:"expected_updated_at"`
+}
+
+type NewBlueprint struct {
+	Name             string          `json:"name" validate:"required,min=3,max=50"`
+	ShortDescription string          `json:"short_description" validate:"required,min=16,max=128"`
+	FullDescription  *string         `json:"full_description"`
+	File             *graphql.Upload `json:"file" validate:"required"`
+	TagIDs           []string        `json:"tagIDs" validate:"dive,min=3,max=24"`
+}
+
+type UpdateBlueprint struct {
+	Name             *string         `json:"name" validate:"omitempty,min=3,max=50"`
+	ShortDescription *string         `json:"short_description" validate:"omitempty,min=16,max=128"`
+	FullDescription  *string         `json:"full_description"`
+	File             *graphql.Upload `json:"file"`
+	TagIDs           []string        `json:"tagIDs" validate:"dive,min=3,max=24"`
 }
@@ -0,0 +1,410 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// Estimated size of lists which cannot be limited by the client
+const unboundedListSize = 10
+
+// MakeComplexity estimates the cost of list fields as the cost of a single item times the amount of items they can return
+func MakeComplexity() generated.ComplexityRoot {
+	var root generated.ComplexityRoot
+
+	root.Query.GetMods = filteredListComplexity
+	root.Query.GetUnapprovedMods = filteredListComplexity
+	root.Query.GetMyMods = filteredListComplexity
+	root.Query.GetMyUnapprovedMods = filteredListComplexity
+	root.Query.GetVersions = filteredListComplexity
+	root.Query.GetUnapprovedVersions = filteredListComplexity
+	root.Query.GetMyVersions = filteredListComplexity
+	root.Query.GetMyUnapprovedVersions = filteredListComplexity
+	root.Query.GetGuides = filteredListComplexity
+	root.Query.GetBlueprints = filteredListComplexity
+	root.Query.GetSMLVersions = filteredListComplexity
+	root.Query.GetBootstrapVersions = filteredListComplexity
+	root.Mod.Versions = filteredListComplexity
+	root.GetMods.TagFacets = unboundedListComplexity
+
+	root.Query.GetModsByTag = func(childComplexity int, tagName string, filter map[string]interface{}) int {
+		return filteredListComplexity(childComplexity, filter)
+	}
+
+	root.Mod.Ratings = func(childComplexity int, withReview *bool, limit *int, offset *int) int {
+		if limit != nil && *limit > 0 {
+			return childComplexity * *limit
+		}
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetModsByReferences = func(childComplexity int, references []string) int {
+		return childComplexity * len(references)
+	}
+
+	root.Query.GetTrendingMods = func(childComplexity int, period generated.TrendingPeriod, limit *int) int {
+		if limit != nil && *limit > 0 {
+			return childComplexity * *limit
+		}
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetChangelogFeed = func(childComplexity int, modIds []string, since *string, limit *int) int {
+		if limit != nil && *limit > 0 {
+			return childComplexity * *limit
+		}
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.DownloadStats = func(childComplexity int, modID string, granularity generated.StatsGranularity, from string, to *string) int {
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetModsCompatibleWith = func(childComplexity int, gameVersion int, target generated.TargetName) int {
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetNotifications = func(childComplexity int, unreadOnly *bool, limit *int, offset *int) int {
+		if limit != nil && *limit > 0 {
+			return childComplexity * *limit
+		}
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetComments = func(childComplexity int, modID string, limit *int, offset *int) int {
+		if limit != nil && *limit > 0 {
+			return childComplexity * *limit
+		}
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetReportedComments = func(childComplexity int, limit *int, offset *int) int {
+		if limit != nil && *limit > 0 {
+			return childComplexity * *limit
+		}
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetReports = func(childComplexity int, state []generated.ReportState, typeArg *generated.ReportTargetType, limit *int, offset *int) int {
+		if limit != nil && *limit > 0 {
+			return childComplexity * *limit
+		}
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetTopModsByDownloads = func(childComplexity int, limit *int) int {
+		if limit != nil && *limit > 0 {
+			return childComplexity * *limit
+		}
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetVersionsPerWeek = func(childComplexity int, weeks *int) int {
+		if weeks != nil && *weeks > 0 {
+			return childComplexity * *weeks
+		}
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetTopAuthors = func(childComplexity int, limit *int) int {
+		if limit != nil && *limit > 0 {
+			return childComplexity * *limit
+		}
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetAuditLogs = func(childComplexity int, filter *generated.AuditLogFilter) int {
+		if filter != nil && filter.Limit != nil && *filter.Limit > 0 {
+			return childComplexity * *filter.Limit
+		}
+		return childComplexity * unboundedListSize
+	}
+
+	root.Query.GetMyCollections = unboundedListComplexity
+	root.Query.MyFavorites = unboundedListComplexity
+
+	root.Comment.Replies = unboundedListComplexity
+	root.Version.Dependencies = unboundedListComplexity
+	root.User.Mods = unboundedListComplexity
+
+	root.Query.GetUserMods = func(childComplexity int, userID string, role *generated.ModAuthorRole) int {
+		return childComplexity * unboundedListSize
+	}
+	root.User.Guides = unboundedListComplexity
+	root.User.Blueprints = unboundedListComplexity
+
+	return root
+}
+
+func filteredListComplexity(childComplexity int, filter map[string]interface{}) int {
+	return childComplexity * filterLimit(filter)
+}
+
+func unboundedListComplexity(childComplexity int) int {
+	return childComplexity * unboundedListSize
+}
+
+// filterLimit returns the page size requested by a filter, defaulting to the one of the models filters
+func filterLimit(filter map[string]interface{}) int {
+	limit := 10
+
+	for _, key := range []string{"limit", "first"} {
+		switch value := filter[key].(type) {
+		case int:
+			limit = value
+		case int64:
+			limit = int(value)
+		case float64:
+			limit = int(value)
+		case json.Number:
+			if parsed, err := value.Int64(); err == nil {
+				limit = int(parsed)
+			}
+		}
+	}
+
+	if limit < 1 {
+		return 1
+	}
+
+	return limit
+}
+
+// QueryLimit rejects operations which are nested too deeply or are too complex for the budget of the user,
+// before any of their resolvers run.
+//
+// The cost of every operation and the remaining budget are reported in the response headers and extensions,
+// so that clients can throttle themselves before getting rejected.
+type QueryLimit struct {
+	schema graphql.ExecutableSchema
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.ResponseInterceptor
+	graphql.HandlerExtension
+} = &QueryLimit{}
+
+// QueryCost is the cost of an operation, reported in the "cost" response extension.
+// Limits of 0 are unlimited.
+type QueryCost struct {
+	Requested  int   `json:"requested"`
+	Limit      int   `json:"limit"`
+	Depth      int   `json:"depth"`
+	DepthLimit int   `json:"depth_limit"`
+	Budget     int64 `json:"budget,omitempty"`
+	Remaining  int64 `json:"remaining,omitempty"`
+	Reset      int64 `json:"reset,omitempty"`
+}
+
+func (QueryLimit) ExtensionName() string {
+	return "QueryLimit"
+}
+
+func (q *QueryLimit) Validate(schema graphql.ExecutableSchema) error {
+	q.schema = schema
+	return nil
+}
+
+func (q *QueryLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	operation := rc.Doc.Operations.ForName(rc.OperationName)
+	if operation == nil {
+		return nil
+	}
+
+	budget := getQueryBudget(ctx)
+
+	cost := &QueryCost{
+		Requested:  complexity.Calculate(q.schema, operation, rc.Variables),
+		Limit:      budget.Complexity,
+		Depth:      selectionDepth(operation.SelectionSet),
+		DepthLimit: budget.Depth,
+	}
+
+	rc.Stats.SetExtension(q.ExtensionName(), cost)
+	defer writeQueryCostHeaders(ctx, cost)
+
+	if budget.Depth > 0 && cost.Depth > budget.Depth {
+		return queryTooComplex("depth", budget.Depth, cost.Depth)
+	}
+
+	if budget.Complexity > 0 && cost.Requested > budget.Complexity {
+		return queryTooComplex("complexity", budget.Complexity, cost.Requested)
+	}
+
+	if budget.Window <= 0 {
+		return nil
+	}
+
+	remaining, reset, ok, err := redis.ConsumeQueryBudget(budget.Identity, int64(cost.Requested), budget.Window, viper.GetDuration("graphql.limits.window"))
+	if err != nil {
+		log.Err(err).Msg("failed to consume query budget")
+		return nil
+	}
+
+	cost.Budget = budget.Window
+	cost.Remaining = remaining
+	cost.Reset = int64(math.Ceil(reset.Seconds()))
+
+	if !ok {
+		gqlErr := gqlerror.Errorf("query budget exhausted: %d of the remaining %d requested, resets in %ds", cost.Requested, remaining, cost.Reset)
+		errcode.Set(gqlErr, "QUERY_BUDGET_EXHAUSTED")
+		gqlErr.Extensions["remaining"] = remaining
+		gqlErr.Extensions["reset"] = cost.Reset
+		return gqlErr
+	}
+
+	return nil
+}
+
+func (q *QueryLimit) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil || !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+
+	cost, ok := graphql.GetOperationContext(ctx).Stats.GetExtension(q.ExtensionName()).(*QueryCost)
+	if !ok {
+		return resp
+	}
+
+	if resp.Extensions == nil {
+		resp.Extensions = make(map[string]interface{})
+	}
+
+	resp.Extensions["cost"] = cost
+
+	return resp
+}
+
+func writeQueryCostHeaders(ctx context.Context, cost *QueryCost) {
+	writer, ok := ctx.Value(util.ContextResponse{}).(http.ResponseWriter)
+	if !ok {
+		return
+	}
+
+	header := writer.Header()
+	header.Set("X-Query-Cost", strconv.Itoa(cost.Requested))
+	header.Set("X-Query-Cost-Limit", strconv.Itoa(cost.Limit))
+	header.Set("X-Query-Depth", strconv.Itoa(cost.Depth))
+	header.Set("X-Query-Depth-Limit", strconv.Itoa(cost.DepthLimit))
+
+	if cost.Budget > 0 {
+		// The query budget is more specific than the request rate limit, so it takes over its headers
+		util.SetRateLimitHeaders(header, cost.Budget, cost.Remaining, time.Duration(cost.Reset)*time.Second)
+	}
+}
+
+func queryTooComplex(limit string, maximum int, actual int) *gqlerror.Error {
+	err := gqlerror.Errorf("query too complex: %s of %d exceeds the limit of %d", limit, actual, maximum)
+	errcode.Set(err, "QUERY_TOO_COMPLEX")
+	err.Extensions["limit"] = limit
+	err.Extensions["maximum"] = maximum
+	err.Extensions["actual"] = actual
+	return err
+}
+
+type queryBudget struct {
+	Complexity int
+	Depth      int
+	// Window is the total complexity which can be spent within graphql.limits.window
+	Window int64
+	// Identity is who the window budget is spent by
+	Identity string
+}
+
+func getQueryBudget(ctx context.Context) queryBudget {
+	budget := queryBudgetFromConfig("anonymous")
+	budget.Identity = "ip:" + RealIP(ctx)
+
+	user := requestUser(ctx)
+	if user == nil {
+		return budget
+	}
+
+	budget = queryBudgetFromConfig("user")
+	budget.Identity = "user:" + user.ID
+
+	if len(viper.GetStringMap("graphql.limits.groups")) == 0 {
+		return budget
+	}
+
+	for _, group := range user.GetGroups(ctx) {
+		if group == nil {
+			continue
+		}
+
+		key := "groups." + strings.ReplaceAll(strings.ToLower(group.Name), " ", "_")
+		if viper.IsSet("graphql.limits." + key) {
+			groupBudget := queryBudgetFromConfig(key)
+			budget.Complexity = largestLimit(budget.Complexity, groupBudget.Complexity)
+			budget.Depth = largestLimit(budget.Depth, groupBudget.Depth)
+			budget.Window = int64(largestLimit(int(budget.Window), int(groupBudget.Window)))
+		}
+	}
+
+	return budget
+}
+
+func queryBudgetFromConfig(key string) queryBudget {
+	return queryBudget{
+		Complexity: viper.GetInt("graphql.limits." + key + ".complexity"),
+		Depth:      viper.GetInt("graphql.limits." + key + ".depth"),
+		Window:     viper.GetInt64("graphql.limits." + key + ".window_budget"),
+	}
+}
+
+// largestLimit returns the least restrictive limit, where 0 is unlimited
+func largestLimit(a int, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func selectionDepth(selections ast.SelectionSet) int {
+	depth := 0
+
+	for _, selection := range selections {
+		current := 0
+
+		switch s := selection.(type) {
+		case *ast.Field:
+			current = 1 + selectionDepth(s.SelectionSet)
+		case *ast.InlineFragment:
+			current = selectionDepth(s.SelectionSet)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				current = selectionDepth(s.Definition.SelectionSet)
+			}
+		}
+
+		if current > depth {
+			depth = current
+		}
+	}
+
+	return depth
+}
@@ -0,0 +1,116 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/util/solver"
+)
+
+// dependencySource provides the approved versions of mods to the dependency solver
+type dependencySource struct {
+	ctx context.Context
+}
+
+func (d dependencySource) Versions(modReference string) ([]solver.Version, error) {
+	mod := postgres.GetModByReference(d.ctx, modReference)
+
+	if mod == nil {
+		if modReference != "SML" {
+			return nil, nil
+		}
+
+		// SML releases predating the SML mod page are only tracked as SML versions
+		smlVersions := postgres.GetSMLVersions(d.ctx, nil)
+		versions := make([]solver.Version, len(smlVersions))
+		for i, smlVersion := range smlVersions {
+			versions[i] = solver.Version{Version: smlVersion.Version}
+		}
+		return versions, nil
+	}
+
+	modVersions := postgres.GetAllModVersionsWithDependencies(d.ctx, mod.ID)
+	versions := make([]solver.Version, len(modVersions))
+	for i, modVersion := range modVersions {
+		version := solver.Version{
+			ID:                   modVersion.ID,
+			Version:              modVersion.Version,
+			Dependencies:         make(map[string]string),
+			OptionalDependencies: make(map[string]string),
+			Targets:              make([]string, len(modVersion.Targets)),
+		}
+
+		for _, dependency := range modVersion.Dependencies {
+			if dependency.Optional {
+				version.OptionalDependencies[dependency.ModID] = dependency.Condition
+			} else {
+				version.Dependencies[dependency.ModID] = dependency.Condition
+			}
+		}
+
+		for j, target := range modVersion.Targets {
+			version.Targets[j] = target.TargetName
+		}
+
+		versions[i] = version
+	}
+
+	return versions, nil
+}
+
+// resolveConstraints pins a version of every mod required by the constraints, keyed by mod reference,
+// reporting why they cannot be satisfied if they conflict
+func resolveConstraints(ctx context.Context, constraints map[string]string, target *generated.TargetName) (*generated.DependencyResolution, error) {
+	targetName := ""
+	if target != nil {
+		targetName = string(*target)
+	}
+
+	resolved, err := solver.Solve(dependencySource{ctx: ctx}, constraints, targetName)
+	if err != nil {
+		var conflict *solver.Conflict
+		if !errors.As(err, &conflict) {
+			return nil, err
+		}
+
+		requirements := make([]*generated.DependencyRequirement, len(conflict.Requirements))
+		for i, requirement := range conflict.Requirements {
+			requirements[i] = &generated.DependencyRequirement{
+				Constraint: requirement.Constraint,
+				RequiredBy: optionalString(requirement.RequiredBy),
+			}
+		}
+
+		return &generated.DependencyResolution{
+			Versions: []*generated.ResolvedVersion{},
+			Conflict: &generated.DependencyConflict{
+				ModReference: conflict.ModReference,
+				Message:      conflict.Message,
+				Requirements: requirements,
+			},
+		}, nil
+	}
+
+	versions := make([]*generated.ResolvedVersion, len(resolved))
+	for i, version := range resolved {
+		versions[i] = &generated.ResolvedVersion{
+			ModReference: version.ModReference,
+			Version:      version.Version.Version,
+			VersionID:    optionalString(version.Version.ID),
+		}
+	}
+
+	return &generated.DependencyResolution{
+		Versions: versions,
+	}, nil
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
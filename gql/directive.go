@@ -17,6 +17,9 @@ import (
 func MakeDirective() generated.DirectiveRoot {
 	return generated.DirectiveRoot{
 		CanEditGuide:             canEditGuide,
+		CanEditBlueprint:         canEditBlueprint,
+		CanEditCollection:        canEditCollection,
+		CanEditWebhook:           canEditWebhook,
 		CanEditMod:               canEditMod,
 		CanEditVersion:           canEditVersion,
 		IsLoggedIn:               isLoggedIn,
@@ -29,6 +32,8 @@ func MakeDirective() generated.DirectiveRoot {
 		CanEditBootstrapVersions: canEditBootstrapVersions,
 		CanEditAnnouncements:     canEditAnnouncements,
 		CanManageTags:            canManageTags,
+		CanViewFieldUsage:        canViewFieldUsage,
+		CanViewAuditLogs:         canViewAuditLogs,
 		CanEditModCompatibility:  canEditModCompatibility,
 	}
 }
@@ -148,6 +153,59 @@ func canEditGuide(ctx context.Context, obj interface{}, next graphql.Resolver, f
 	return nil, errors.New("user not authorized to perform this action")
 }
 
+func canEditCollection(ctx context.Context, obj interface{}, next graphql.Resolver, field string) (interface{}, error) {
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	dbCollection := postgres.GetCollectionByID(ctx, getArgument(ctx, field).(string))
+
+	if dbCollection == nil {
+		return nil, errors.New("collection not found")
+	}
+
+	if dbCollection.UserID == user.ID {
+		return next(ctx)
+	}
+
+	if user.Has(ctx, auth.RoleEditAnyContent) {
+		return next(ctx)
+	}
+
+	return nil, errors.New("user not authorized to perform this action")
+}
+
+// canEditWebhook only lets the owners of webhooks manage them, as they hold the secret deliveries are signed with
+func canEditWebhook(ctx context.Context, obj interface{}, next graphql.Resolver, field string) (interface{}, error) {
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	dbWebhook := postgres.GetWebhookByID(ctx, getArgument(ctx, field).(string))
+
+	if dbWebhook == nil || dbWebhook.UserID != user.ID {
+		return nil, errors.New("webhook not found")
+	}
+
+	return next(ctx)
+}
+
+func canEditBlueprint(ctx context.Context, obj interface{}, next graphql.Resolver, field string) (interface{}, error) {
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	dbBlueprint := postgres.GetBlueprintByID(ctx, getArgument(ctx, field).(string))
+
+	if dbBlueprint == nil {
+		return nil, errors.New("blueprint not found")
+	}
+
+	if dbBlueprint.UserID == user.ID {
+		return next(ctx)
+	}
+
+	if user.Has(ctx, auth.RoleEditAnyContent) {
+		return next(ctx)
+	}
+
+	return nil, errors.New("user not authorized to perform this action")
+}
+
 func isLoggedIn(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
 	header := ctx.Value(util.ContextHeader{}).(http.Header)
 	authorization := header.Get("Authorization")
@@ -259,3 +317,23 @@ func canManageTags(ctx context.Context, obj interface{}, next graphql.Resolver)
 
 	return nil, errors.New("user not authorized to perform this action")
 }
+
+func canViewFieldUsage(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	if user.Has(ctx, auth.RoleViewFieldUsage) {
+		return next(ctx)
+	}
+
+	return nil, errors.New("user not authorized to perform this action")
+}
+
+func canViewAuditLogs(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	if user.Has(ctx, auth.RoleViewAuditLogs) {
+		return next(ctx)
+	}
+
+	return nil, errors.New("user not authorized to perform this action")
+}
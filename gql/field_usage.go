@@ -0,0 +1,191 @@
+package gql
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/spf13/viper"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// Maximum length of a client name, longer names are truncated
+const maxClientNameLength = 64
+
+// FieldUsageTracker records the schema coordinates used by a sample of the operations, per client,
+// so that deprecated fields can be removed once no client uses them anymore
+type FieldUsageTracker struct{}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = FieldUsageTracker{}
+
+func (FieldUsageTracker) ExtensionName() string {
+	return "FieldUsageTracker"
+}
+
+func (FieldUsageTracker) Validate(_ graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (FieldUsageTracker) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rand.Float64() >= viper.GetFloat64("graphql.usage.sample_rate") { //nolint:gosec
+		return nil
+	}
+
+	operation := rc.Doc.Operations.ForName(rc.OperationName)
+	if operation == nil {
+		return nil
+	}
+
+	usage := make(map[string]struct{})
+	collectFieldUsage(operation.SelectionSet, rc.Variables, usage)
+
+	coordinates := make([]string, 0, len(usage))
+	for coordinate := range usage {
+		coordinates = append(coordinates, coordinate)
+	}
+
+	go redis.RecordFieldUsage(clientName(ctx), coordinates)
+
+	return nil
+}
+
+// clientName identifies the client of the request, from the X-Client-Name header or the product of its user agent
+func clientName(ctx context.Context) string {
+	header, _ := ctx.Value(util.ContextHeader{}).(http.Header)
+	if header == nil {
+		return "unknown"
+	}
+
+	name := strings.TrimSpace(header.Get("X-Client-Name"))
+	if name == "" {
+		name, _, _ = strings.Cut(header.Get("User-Agent"), " ")
+	}
+
+	if name == "" {
+		return "unknown"
+	}
+
+	if len(name) > maxClientNameLength {
+		name = name[:maxClientNameLength]
+	}
+
+	return name
+}
+
+func collectFieldUsage(selections ast.SelectionSet, variables map[string]interface{}, usage map[string]struct{}) {
+	for _, selection := range selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if s.ObjectDefinition == nil || s.Definition == nil || strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+
+			coordinate := s.ObjectDefinition.Name + "." + s.Name
+			usage[coordinate] = struct{}{}
+
+			for _, argument := range s.Arguments {
+				usage[coordinate+"("+argument.Name+":)"] = struct{}{}
+
+				definition := s.Definition.Arguments.ForName(argument.Name)
+				if definition == nil {
+					continue
+				}
+
+				if value, err := argument.Value.Value(variables); err == nil {
+					collectInputUsage(definition.Type, value, usage)
+				}
+			}
+
+			collectFieldUsage(s.SelectionSet, variables, usage)
+		case *ast.InlineFragment:
+			collectFieldUsage(s.SelectionSet, variables, usage)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				collectFieldUsage(s.Definition.SelectionSet, variables, usage)
+			}
+		}
+	}
+}
+
+// collectInputUsage records the fields of input objects provided as the value of an argument
+func collectInputUsage(valueType *ast.Type, value interface{}, usage map[string]struct{}) {
+	if valueType.Elem != nil {
+		if values, ok := value.([]interface{}); ok {
+			for _, v := range values {
+				collectInputUsage(valueType.Elem, v, usage)
+			}
+		}
+		return
+	}
+
+	definition := schemaDefinition().Types[valueType.NamedType]
+	if definition == nil || definition.Kind != ast.InputObject {
+		return
+	}
+
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	for name, fieldValue := range fields {
+		usage[definition.Name+"."+name] = struct{}{}
+
+		if field := definition.Fields.ForName(name); field != nil {
+			collectInputUsage(field.Type, fieldValue, usage)
+		}
+	}
+}
+
+func schemaDefinition() *ast.Schema {
+	return generated.NewExecutableSchema(generated.Config{}).Schema()
+}
+
+// deprecatedCoordinates returns the deprecation reason of every deprecated field, input field and argument of the schema
+func deprecatedCoordinates() map[string]string {
+	deprecated := make(map[string]string)
+
+	reason := func(directives ast.DirectiveList) (string, bool) {
+		directive := directives.ForName("deprecated")
+		if directive == nil {
+			return "", false
+		}
+
+		if argument := directive.Arguments.ForName("reason"); argument != nil && argument.Value != nil {
+			return argument.Value.Raw, true
+		}
+
+		return "No longer supported", true
+	}
+
+	for _, definition := range schemaDefinition().Types {
+		if strings.HasPrefix(definition.Name, "__") {
+			continue
+		}
+
+		for _, field := range definition.Fields {
+			coordinate := definition.Name + "." + field.Name
+			if r, ok := reason(field.Directives); ok {
+				deprecated[coordinate] = r
+			}
+
+			for _, argument := range field.Arguments {
+				if r, ok := reason(argument.Directives); ok {
+					deprecated[coordinate+"("+argument.Name+":)"] = r
+				}
+			}
+		}
+	}
+
+	return deprecated
+}
@@ -1,10 +1,17 @@
 package gql
 
 import (
+	"encoding/json"
+	"sort"
 	"time"
 
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/russross/blackfriday"
+
 	"github.com/satisfactorymodding/smr-api/db/postgres"
 	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/nodes"
+	"github.com/satisfactorymodding/smr-api/validation"
 )
 
 func DBUserToGenerated(user *postgres.User) *generated.User {
@@ -29,6 +36,31 @@ func DBUserToGenerated(user *postgres.User) *generated.User {
 	return result
 }
 
+func DBUserModToGenerated(userMod *postgres.UserMod) *generated.UserMod {
+	if userMod == nil {
+		return nil
+	}
+
+	return &generated.UserMod{
+		UserID:     userMod.UserID,
+		ModID:      userMod.ModID,
+		Role:       userMod.Role,
+		AuthorRole: modAuthorRole(userMod.Role),
+	}
+}
+
+// modAuthorRole maps the role stored for an author to the one exposed to clients.
+// Creators own the mod, editors can upload versions, and any other role only credits the user.
+func modAuthorRole(role string) generated.ModAuthorRole {
+	switch role {
+	case "creator":
+		return generated.ModAuthorRoleOwner
+	case "editor":
+		return generated.ModAuthorRoleEditor
+	}
+	return generated.ModAuthorRoleContributor
+}
+
 func DBModToGenerated(mod *postgres.Mod) *generated.Mod {
 	if mod == nil {
 		return nil
@@ -43,6 +75,12 @@ func DBModToGenerated(mod *postgres.Mod) *generated.Mod {
 		LastVersionDate = mod.LastVersionDate.Format(time.RFC3339Nano)
 	}
 
+	var ArchivedAt *string
+	if mod.ArchivedAt != nil {
+		archivedAt := mod.ArchivedAt.Format(time.RFC3339Nano)
+		ArchivedAt = &archivedAt
+	}
+
 	return &generated.Mod{
 		ID:               mod.ID,
 		Name:             mod.Name,
@@ -55,12 +93,18 @@ func DBModToGenerated(mod *postgres.Mod) *generated.Mod {
 		Downloads:        int(mod.Downloads),
 		Hotness:          int(mod.Hotness),
 		Popularity:       int(mod.Popularity),
+		Favorites:        int(mod.Favorites),
+		Rating:           mod.Rating,
+		RatingCount:      int(mod.RatingCount),
 		UpdatedAt:        mod.UpdatedAt.Format(time.RFC3339Nano),
 		CreatedAt:        mod.CreatedAt.Format(time.RFC3339Nano),
 		FullDescription:  &FullDescription,
 		LastVersionDate:  &LastVersionDate,
 		ModReference:     mod.ModReference,
 		Hidden:           mod.Hidden,
+		Archived:         mod.Archived,
+		ArchiveReason:    mod.ArchiveReason,
+		ArchivedAt:       ArchivedAt,
 		Versions:         DBVersionsToGeneratedSlice(mod.Versions),
 		Tags:             DBTagsToGeneratedSlice(mod.Tags),
 		Compatibility:    DBCompInfoToGenCompInfo(mod.Compatibility),
@@ -79,20 +123,80 @@ func DBVersionToGenerated(version *postgres.Version) *generated.Version {
 	}
 
 	return &generated.Version{
-		ID:         version.ID,
-		Version:    version.Version,
-		SmlVersion: version.SMLVersion,
-		Changelog:  version.Changelog,
-		Downloads:  int(version.Downloads),
-		Stability:  generated.VersionStabilities(version.Stability),
-		Targets:    DBVersionTargetsToGeneratedSlice(version.Targets),
-		Approved:   version.Approved,
-		UpdatedAt:  version.UpdatedAt.Format(time.RFC3339Nano),
-		CreatedAt:  version.CreatedAt.Format(time.RFC3339Nano),
-		ModID:      version.ModID,
-		Metadata:   version.Metadata,
-		Hash:       version.Hash,
-		Size:       &size,
+		ID:              version.ID,
+		Version:         version.Version,
+		SmlVersion:      version.SMLVersion,
+		Changelog:       version.Changelog,
+		ChangelogSource: generated.ChangelogSource(version.ChangelogSource),
+		ContainerType:   generated.ContainerType(version.ContainerType),
+		Downloads:       int(version.Downloads),
+		Stability:       generated.VersionStabilities(version.Stability),
+		Targets:         DBVersionTargetsToGeneratedSlice(version.Targets),
+		Approved:        version.Approved,
+		Draft:           version.Draft,
+		LegacyTarget:    version.LegacyTarget,
+		Corrupted:       version.Corrupted,
+		Status:          DBVersionStatus(version),
+		UpdatedAt:       version.UpdatedAt.Format(time.RFC3339Nano),
+		CreatedAt:       version.CreatedAt.Format(time.RFC3339Nano),
+		ModID:           version.ModID,
+		Metadata:        version.Metadata,
+		Hash:            version.Hash,
+		Size:            &size,
+		ContentFlags:    DBContentFlagsToGenerated(version.ContentFlags),
+		Torrent:         versionDistributionLink(version.ID, version.TorrentKey, "torrent"),
+		Metalink:        versionDistributionLink(version.ID, version.MetalinkKey, "metalink"),
+		InfoHash:        version.InfoHash,
+	}
+}
+
+func versionDistributionLink(versionID string, key *string, kind string) *string {
+	if key == nil || *key == "" {
+		return nil
+	}
+
+	link := nodes.APIV1.Prefix() + "/version/" + versionID + "/" + kind
+	return &link
+}
+
+func DBContentFlagsToGenerated(contentFlags *string) []*generated.ContentFlag {
+	if contentFlags == nil {
+		return nil
+	}
+
+	var flags []validation.ContentFlag
+	if err := json.Unmarshal([]byte(*contentFlags), &flags); err != nil {
+		return nil
+	}
+
+	converted := make([]*generated.ContentFlag, len(flags))
+	for i, flag := range flags {
+		flag := flag
+		converted[i] = &generated.ContentFlag{
+			Path:   flag.Path,
+			Reason: flag.Reason,
+		}
+
+		if flag.Hash != "" {
+			converted[i].Hash = &flag.Hash
+		}
+	}
+
+	return converted
+}
+
+func DBVersionStatus(version *postgres.Version) generated.VersionStatus {
+	switch {
+	case version.Draft:
+		return generated.VersionStatusDraft
+	case version.Quarantined:
+		return generated.VersionStatusQuarantined
+	case version.Denied:
+		return generated.VersionStatusDenied
+	case version.Approved:
+		return generated.VersionStatusApproved
+	default:
+		return generated.VersionStatusPending
 	}
 }
 
@@ -122,6 +226,28 @@ func DBGuideToGenerated(guide *postgres.Guide) *generated.Guide {
 	}
 }
 
+func DBBlueprintToGenerated(blueprint *postgres.Blueprint) *generated.Blueprint {
+	if blueprint == nil {
+		return nil
+	}
+
+	return &generated.Blueprint{
+		ID:               blueprint.ID,
+		Name:             blueprint.Name,
+		ShortDescription: blueprint.ShortDescription,
+		FullDescription:  &blueprint.FullDescription,
+		UserID:           blueprint.UserID,
+		Link:             nodes.APIV1.Prefix() + "/blueprint/" + blueprint.ID + "/download",
+		Hash:             blueprint.Hash,
+		Size:             int(blueprint.Size),
+		Views:            int(blueprint.Views),
+		Downloads:        int(blueprint.Downloads),
+		UpdatedAt:        blueprint.UpdatedAt.Format(time.RFC3339Nano),
+		CreatedAt:        blueprint.CreatedAt.Format(time.RFC3339Nano),
+		Tags:             DBTagsToGeneratedSlice(blueprint.Tags),
+	}
+}
+
 func DBSMLVersionToGenerated(smlVersion *postgres.SMLVersion) *generated.SMLVersion {
 	if smlVersion == nil {
 		return nil
@@ -213,18 +339,36 @@ func DBTagsToGeneratedSlice(tags []postgres.Tag) []*generated.Tag {
 	return converted
 }
 
+func DBVersionTargetPatchToGenerated(patch *postgres.VersionTargetPatch) *generated.VersionTargetPatch {
+	if patch == nil {
+		return nil
+	}
+
+	return &generated.VersionTargetPatch{
+		VersionID:     patch.VersionID,
+		FromVersionID: patch.FromVersionID,
+		TargetName:    generated.TargetName(patch.TargetName),
+		Size:          int(patch.Size),
+		Hash:          patch.Hash,
+	}
+}
+
 func DBVersionTargetToGenerated(versionTarget *postgres.VersionTarget) *generated.VersionTarget {
 	if versionTarget == nil {
 		return nil
 	}
 
-	hash := versionTarget.Hash
 	size := int(versionTarget.Size)
 
+	var hash *string
+	if versionTarget.Hash != "" {
+		hash = &versionTarget.Hash
+	}
+
 	return &generated.VersionTarget{
 		VersionID:  versionTarget.VersionID,
 		TargetName: generated.TargetName(versionTarget.TargetName),
-		Hash:       &hash,
+		Hash:       hash,
 		Size:       &size,
 	}
 }
@@ -291,3 +435,307 @@ func DBCompToGenComp(db postgres.Compatibility) *generated.Compatibility {
 		Note:  &db.Note,
 	}
 }
+
+func DBModTransferToGenerated(transfer *postgres.ModTransfer) *generated.ModTransfer {
+	if transfer == nil {
+		return nil
+	}
+
+	return &generated.ModTransfer{
+		ID:         transfer.ID,
+		ModID:      transfer.ModID,
+		FromUserID: transfer.FromUserID,
+		ToUserID:   transfer.ToUserID,
+		Status:     generated.ModTransferStatus(transfer.Status),
+		CreatedAt:  transfer.CreatedAt.Format(time.RFC3339Nano),
+		UpdatedAt:  transfer.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func DBNotificationToGenerated(notification *postgres.Notification) *generated.Notification {
+	if notification == nil {
+		return nil
+	}
+
+	return &generated.Notification{
+		ID:        notification.ID,
+		UserID:    notification.UserID,
+		Type:      generated.NotificationType(notification.Type),
+		Message:   notification.Message,
+		ModID:     notification.ModID,
+		VersionID: notification.VersionID,
+		Read:      notification.ReadAt != nil,
+		CreatedAt: notification.CreatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func DBCollectionToGenerated(collection *postgres.Collection) *generated.Collection {
+	if collection == nil {
+		return nil
+	}
+
+	mods := make([]*generated.CollectionMod, len(collection.Mods))
+	for i, mod := range collection.Mods {
+		mods[i] = &generated.CollectionMod{
+			ModReference: mod.ModReference,
+			Version:      mod.VersionConstraint,
+		}
+	}
+
+	return &generated.Collection{
+		ID:          collection.ID,
+		Name:        collection.Name,
+		Description: collection.Description,
+		Slug:        collection.Slug,
+		UserID:      collection.UserID,
+		UpdatedAt:   collection.UpdatedAt.Format(time.RFC3339Nano),
+		CreatedAt:   collection.CreatedAt.Format(time.RFC3339Nano),
+		Mods:        mods,
+	}
+}
+
+func DBUserFavoriteToGenerated(favorite *postgres.UserFavorite) *generated.Favorite {
+	if favorite == nil {
+		return nil
+	}
+
+	return &generated.Favorite{
+		ModID:             favorite.ModID,
+		LastSeenVersionID: favorite.LastSeenVersionID,
+		HasUpdates:        favorite.HasUpdates,
+		CreatedAt:         favorite.CreatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+// Comments can contain any markdown, raw HTML included, so their rendering is restricted to user content safe markup
+var commentPolicy = bluemonday.UGCPolicy()
+
+func DBCommentToGenerated(comment *postgres.Comment) *generated.Comment {
+	if comment == nil {
+		return nil
+	}
+
+	var EditedAt *string
+	if comment.EditedAt != nil {
+		editedAt := comment.EditedAt.Format(time.RFC3339Nano)
+		EditedAt = &editedAt
+	}
+
+	return &generated.Comment{
+		ID:        comment.ID,
+		ModID:     comment.ModID,
+		UserID:    comment.UserID,
+		ParentID:  comment.ParentID,
+		Body:      comment.Body,
+		BodyHTML:  string(commentPolicy.SanitizeBytes(blackfriday.MarkdownCommon([]byte(comment.Body)))),
+		EditedAt:  EditedAt,
+		UpdatedAt: comment.UpdatedAt.Format(time.RFC3339Nano),
+		CreatedAt: comment.CreatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func DBCommentsToGeneratedSlice(comments []postgres.Comment) []*generated.Comment {
+	converted := make([]*generated.Comment, len(comments))
+	for i, comment := range comments {
+		comment := comment
+		converted[i] = DBCommentToGenerated(&comment)
+	}
+	return converted
+}
+
+func DBModRatingToGenerated(rating *postgres.ModRating) *generated.ModRating {
+	if rating == nil {
+		return nil
+	}
+
+	return &generated.ModRating{
+		UserID:    rating.UserID,
+		ModID:     rating.ModID,
+		Rating:    rating.Rating,
+		Review:    rating.Review,
+		CreatedAt: rating.CreatedAt.Format(time.RFC3339Nano),
+		UpdatedAt: rating.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func DBReportToGenerated(report *postgres.Report) *generated.Report {
+	if report == nil {
+		return nil
+	}
+
+	var ResolvedAt *string
+	if report.ResolvedAt != nil {
+		resolvedAt := report.ResolvedAt.Format(time.RFC3339Nano)
+		ResolvedAt = &resolvedAt
+	}
+
+	return &generated.Report{
+		ID:           report.ID,
+		ReporterID:   report.ReporterID,
+		TargetType:   generated.ReportTargetType(report.TargetType),
+		TargetID:     report.TargetID,
+		Reason:       generated.ReportReason(report.Reason),
+		Details:      report.Details,
+		State:        generated.ReportState(report.State),
+		Note:         report.Note,
+		ResolvedByID: report.ResolvedByID,
+		ResolvedAt:   ResolvedAt,
+		CreatedAt:    report.CreatedAt.Format(time.RFC3339Nano),
+		UpdatedAt:    report.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func DBAuditLogToGenerated(entry *postgres.AuditLog) *generated.AuditLog {
+	if entry == nil {
+		return nil
+	}
+
+	var data *string
+	if entry.Data != nil {
+		data = jsonString(entry.Data)
+	}
+
+	changes := make([]*generated.AuditChange, 0, len(entry.Changes))
+	for field, change := range entry.Changes {
+		converted := &generated.AuditChange{Field: field}
+		if change.Before != nil {
+			converted.Before = jsonString(change.Before)
+		}
+		if change.After != nil {
+			converted.After = jsonString(change.After)
+		}
+		changes = append(changes, converted)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return &generated.AuditLog{
+		ID:         entry.ID,
+		UserID:     entry.UserID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Data:       data,
+		Changes:    changes,
+		IP:         entry.IP,
+		CreatedAt:  entry.CreatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+// jsonString encodes a value as JSON, or returns nil if it cannot be encoded
+func jsonString(value interface{}) *string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	result := string(encoded)
+	return &result
+}
+
+func DBWebhookToGenerated(webhook *postgres.Webhook) *generated.Webhook {
+	if webhook == nil {
+		return nil
+	}
+
+	var LastDeliveryAt *string
+	if webhook.LastDeliveryAt != nil {
+		lastDeliveryAt := webhook.LastDeliveryAt.Format(time.RFC3339Nano)
+		LastDeliveryAt = &lastDeliveryAt
+	}
+
+	events := make([]generated.WebhookEvent, len(webhook.Events))
+	for i, event := range webhook.Events {
+		events[i] = generated.WebhookEvent(event)
+	}
+
+	modIDs := webhook.ModIDs
+	if modIDs == nil {
+		modIDs = []string{}
+	}
+
+	return &generated.Webhook{
+		ID:             webhook.ID,
+		URL:            webhook.URL,
+		Secret:         webhook.Secret,
+		Events:         events,
+		ModIds:         modIDs,
+		Active:         webhook.Active,
+		FailureCount:   webhook.FailureCount,
+		LastStatus:     webhook.LastStatus,
+		LastError:      webhook.LastError,
+		LastDeliveryAt: LastDeliveryAt,
+		CreatedAt:      webhook.CreatedAt.Format(time.RFC3339Nano),
+		UpdatedAt:      webhook.UpdatedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func DBSiteStatsToGenerated(stats *postgres.SiteStats) *generated.SiteStats {
+	if stats == nil {
+		return nil
+	}
+
+	return &generated.SiteStats{
+		Mods:          int(stats.Mods),
+		Versions:      int(stats.Versions),
+		Downloads:     int(stats.Downloads),
+		ActiveAuthors: int(stats.ActiveAuthors),
+		StorageSize:   int(stats.StorageSize),
+		ComputedAt:    stats.ComputedAt.Format(time.RFC3339Nano),
+	}
+}
+
+func DBModDownloadStatsToGenerated(stats *postgres.ModDownloadStats) *generated.ModDownloadStats {
+	if stats == nil {
+		return nil
+	}
+
+	var lastVersionAt *string
+	if stats.LastVersionAt != nil {
+		formatted := stats.LastVersionAt.Format(time.RFC3339Nano)
+		lastVersionAt = &formatted
+	}
+
+	return &generated.ModDownloadStats{
+		ModID:         stats.ModID,
+		Name:          stats.Name,
+		ModReference:  stats.ModReference,
+		Versions:      int(stats.Versions),
+		Downloads:     int(stats.Downloads),
+		LastVersionAt: lastVersionAt,
+	}
+}
+
+func DBWeeklyVersionStatsToGenerated(stats *postgres.WeeklyVersionStats) *generated.WeeklyVersionStats {
+	if stats == nil {
+		return nil
+	}
+
+	return &generated.WeeklyVersionStats{
+		Week:     stats.Week.Format(time.RFC3339Nano),
+		Versions: int(stats.Versions),
+		Mods:     int(stats.Mods),
+	}
+}
+
+func DBAuthorStatsToGenerated(stats *postgres.AuthorStats) *generated.AuthorStats {
+	if stats == nil {
+		return nil
+	}
+
+	var lastVersionAt *string
+	if stats.LastVersionAt != nil {
+		formatted := stats.LastVersionAt.Format(time.RFC3339Nano)
+		lastVersionAt = &formatted
+	}
+
+	return &generated.AuthorStats{
+		UserID:        stats.UserID,
+		Username:      stats.Username,
+		Mods:          int(stats.Mods),
+		Downloads:     int(stats.Downloads),
+		LastVersionAt: lastVersionAt,
+	}
+}
@@ -3,11 +3,11 @@ package gql
 import (
 	"context"
 	"fmt"
-	"net"
-	"net/http"
-	"strings"
 	"time"
 
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -17,6 +17,9 @@ import (
 	"github.com/satisfactorymodding/smr-api/util"
 )
 
+// Maximum amount of objects which can be moderated by a single bulk mutation
+const maxBulkModeration = 100
+
 type TraceWrapper struct {
 	Span trace.Span
 }
@@ -48,6 +51,26 @@ func (wrapper TraceWrapper) end() {
 	}
 }
 
+// ErrorPresenter exposes the extensions of errors implementing Extensions() (e.g. error codes) to clients
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+
+	var extendedErr interface {
+		Extensions() map[string]interface{}
+	}
+	if errors.As(err, &extendedErr) {
+		if presented.Extensions == nil {
+			presented.Extensions = make(map[string]interface{})
+		}
+
+		for k, v := range extendedErr.Extensions() {
+			presented.Extensions[k] = v
+		}
+	}
+
+	return presented
+}
+
 // SetStringINNOE sets target if value not nil or empty
 func SetStringINNOE(value *string, target *string) {
 	if value == nil || *value == "" {
@@ -89,18 +112,42 @@ func SetCompatibilityINN(value *generated.CompatibilityInfoInput, target **postg
 }
 
 func RealIP(ctx context.Context) string {
-	header := ctx.Value(util.ContextHeader{}).(http.Header)
+	return util.RequestIP(ctx)
+}
+
+// parseExpectedUpdatedAt parses the updated_at an edit expects the edited object to still have
+func parseExpectedUpdatedAt(value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
 
-	if ip := header.Get("X-Forwarded-For"); ip != "" {
-		return strings.Split(ip, ", ")[0]
+	expected, err := time.Parse(time.RFC3339Nano, *value)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid expected_updated_at")
 	}
 
-	if ip := header.Get("X-Real-IP"); ip != "" {
-		return ip
+	return &expected, nil
+}
+
+// pageBounds validates the limit and offset arguments of a list, defaulting to the first 10 items
+func pageBounds(limit *int, offset *int) (int, int, error) {
+	pageLimit := 10
+	if limit != nil {
+		pageLimit = *limit
 	}
 
-	request := ctx.Value(util.ContextRequest{}).(*http.Request)
-	ra, _, _ := net.SplitHostPort(request.RemoteAddr)
+	if pageLimit < 1 || pageLimit > 100 {
+		return 0, 0, errors.New("limit must be between 1 and 100")
+	}
+
+	pageOffset := 0
+	if offset != nil {
+		pageOffset = *offset
+	}
+
+	if pageOffset < 0 {
+		return 0, 0, errors.New("offset must not be negative")
+	}
 
-	return ra
+	return pageLimit, pageOffset, nil
 }
@@ -0,0 +1,56 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis"
+)
+
+// notifyVersionApproved notifies the authors of the mod, and the authors of every other mod depending on it
+func notifyVersionApproved(ctx context.Context, version *postgres.Version, mod *postgres.Mod) {
+	if mod == nil {
+		return
+	}
+
+	notifyModAuthors(ctx, mod, postgres.NotificationVersionApproved, mod.Name+" v"+version.Version+" was approved", &version.ID)
+
+	notify(ctx, postgres.GetDependentModAuthors(ctx, mod), postgres.NotificationDependencyUpdated,
+		mod.Name+", a dependency of your mods, was updated to v"+version.Version, &mod.ID, &version.ID)
+}
+
+func notifyVersionDenied(ctx context.Context, version *postgres.Version, mod *postgres.Mod, reason *string) {
+	if mod == nil {
+		return
+	}
+
+	message := mod.Name + " v" + version.Version + " was denied"
+	if reason != nil && *reason != "" {
+		message += ": " + *reason
+	}
+
+	notifyModAuthors(ctx, mod, postgres.NotificationVersionDenied, message, &version.ID)
+}
+
+func notifyModAuthors(ctx context.Context, mod *postgres.Mod, notificationType string, message string, versionID *string) {
+	authors := postgres.GetModAuthors(ctx, mod.ID)
+
+	userIDs := make([]string, len(authors))
+	for i, author := range authors {
+		userIDs[i] = author.UserID
+	}
+
+	notify(ctx, userIDs, notificationType, message, &mod.ID, versionID)
+}
+
+// notify stores a notification for every user, and delivers it to their live subscriptions
+func notify(ctx context.Context, userIDs []string, notificationType string, message string, modID *string, versionID *string) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	for _, notification := range postgres.CreateNotifications(ctx, userIDs, notificationType, message, modID, versionID) {
+		notification := notification
+		redis.PublishNotification(DBNotificationToGenerated(&notification))
+	}
+}
@@ -0,0 +1,125 @@
+package gql
+
+import (
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/models"
+)
+
+// pageInfo builds the page info from the rows of a page fetched with one extra row.
+//
+// If cursor returns nil, the list cannot be paginated with cursors and no end cursor is provided.
+func pageInfo[T any](rows []T, limit int, cursor func(T) *models.Cursor) *generated.PageInfo {
+	info := &generated.PageInfo{
+		HasNextPage: len(rows) > limit,
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	if len(rows) > 0 {
+		if endCursor := cursor(rows[len(rows)-1]); endCursor != nil {
+			encoded := endCursor.Encode()
+			info.EndCursor = &encoded
+		}
+	}
+
+	return info
+}
+
+func modCursor(orderBy generated.ModFields) func(postgres.Mod) *models.Cursor {
+	return func(mod postgres.Mod) *models.Cursor {
+		switch orderBy {
+		case generated.ModFieldsUpdatedAt:
+			return models.NewCursor(mod.ID, mod.UpdatedAt)
+		case generated.ModFieldsName:
+			return models.NewCursor(mod.ID, mod.Name)
+		case generated.ModFieldsViews:
+			return models.NewCursor(mod.ID, mod.Views)
+		case generated.ModFieldsDownloads:
+			return models.NewCursor(mod.ID, mod.Downloads)
+		case generated.ModFieldsHotness:
+			return models.NewCursor(mod.ID, mod.Hotness)
+		case generated.ModFieldsPopularity:
+			return models.NewCursor(mod.ID, mod.Popularity)
+		case generated.ModFieldsLastVersionDate:
+			return models.NewCursor(mod.ID, mod.LastVersionDate)
+		case generated.ModFieldsRating:
+			return models.NewCursor(mod.ID, mod.Rating)
+		case generated.ModFieldsSearch:
+			return nil
+		}
+		return models.NewCursor(mod.ID, mod.CreatedAt)
+	}
+}
+
+func versionCursor(orderBy generated.VersionFields) func(postgres.Version) *models.Cursor {
+	return func(version postgres.Version) *models.Cursor {
+		switch orderBy {
+		case generated.VersionFieldsUpdatedAt:
+			return models.NewCursor(version.ID, version.UpdatedAt)
+		case generated.VersionFieldsDownloads:
+			return models.NewCursor(version.ID, version.Downloads)
+		}
+		return models.NewCursor(version.ID, version.CreatedAt)
+	}
+}
+
+func guideCursor(orderBy generated.GuideFields) func(postgres.Guide) *models.Cursor {
+	return func(guide postgres.Guide) *models.Cursor {
+		switch orderBy {
+		case generated.GuideFieldsUpdatedAt:
+			return models.NewCursor(guide.ID, guide.UpdatedAt)
+		case generated.GuideFieldsName:
+			return models.NewCursor(guide.ID, guide.Name)
+		case generated.GuideFieldsViews:
+			return models.NewCursor(guide.ID, guide.Views)
+		}
+		return models.NewCursor(guide.ID, guide.CreatedAt)
+	}
+}
+
+func blueprintCursor(orderBy generated.BlueprintFields) func(postgres.Blueprint) *models.Cursor {
+	return func(blueprint postgres.Blueprint) *models.Cursor {
+		switch orderBy {
+		case generated.BlueprintFieldsUpdatedAt:
+			return models.NewCursor(blueprint.ID, blueprint.UpdatedAt)
+		case generated.BlueprintFieldsName:
+			return models.NewCursor(blueprint.ID, blueprint.Name)
+		case generated.BlueprintFieldsViews:
+			return models.NewCursor(blueprint.ID, blueprint.Views)
+		case generated.BlueprintFieldsDownloads:
+			return models.NewCursor(blueprint.ID, blueprint.Downloads)
+		}
+		return models.NewCursor(blueprint.ID, blueprint.CreatedAt)
+	}
+}
+
+func smlVersionCursor(orderBy generated.SMLVersionFields) func(postgres.SMLVersion) *models.Cursor {
+	return func(smlVersion postgres.SMLVersion) *models.Cursor {
+		switch orderBy {
+		case generated.SMLVersionFieldsUpdatedAt:
+			return models.NewCursor(smlVersion.ID, smlVersion.UpdatedAt)
+		case generated.SMLVersionFieldsSatisfactoryVersion:
+			return models.NewCursor(smlVersion.ID, smlVersion.SatisfactoryVersion)
+		case generated.SMLVersionFieldsDate:
+			return models.NewCursor(smlVersion.ID, smlVersion.Date)
+		}
+		return models.NewCursor(smlVersion.ID, smlVersion.CreatedAt)
+	}
+}
+
+func bootstrapVersionCursor(orderBy generated.BootstrapVersionFields) func(postgres.BootstrapVersion) *models.Cursor {
+	return func(bootstrapVersion postgres.BootstrapVersion) *models.Cursor {
+		switch orderBy {
+		case generated.BootstrapVersionFieldsUpdatedAt:
+			return models.NewCursor(bootstrapVersion.ID, bootstrapVersion.UpdatedAt)
+		case generated.BootstrapVersionFieldsSatisfactoryVersion:
+			return models.NewCursor(bootstrapVersion.ID, bootstrapVersion.SatisfactoryVersion)
+		case generated.BootstrapVersionFieldsDate:
+			return models.NewCursor(bootstrapVersion.ID, bootstrapVersion.Date)
+		}
+		return models.NewCursor(bootstrapVersion.ID, bootstrapVersion.CreatedAt)
+	}
+}
@@ -0,0 +1,74 @@
+package gql
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/spf13/viper"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// PersistedQueryAllowlist restricts unauthenticated requests to queries which are already persisted,
+// if graphql.persisted_queries.allowlist_only is set.
+//
+// Such requests can neither send a query document without its hash, nor persist a new query.
+// It must be used before the AutomaticPersistedQuery extension sharing the cache.
+type PersistedQueryAllowlist struct {
+	Cache graphql.Cache
+}
+
+var _ interface {
+	graphql.OperationParameterMutator
+	graphql.HandlerExtension
+} = PersistedQueryAllowlist{}
+
+func (PersistedQueryAllowlist) ExtensionName() string {
+	return "PersistedQueryAllowlist"
+}
+
+func (PersistedQueryAllowlist) Validate(_ graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (p PersistedQueryAllowlist) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	if !viper.GetBool("graphql.persisted_queries.allowlist_only") || isAuthenticated(ctx) {
+		return nil
+	}
+
+	extension, _ := rawParams.Extensions["persistedQuery"].(map[string]interface{})
+	hash, _ := extension["sha256Hash"].(string)
+
+	if hash == "" {
+		return persistedQueryRequired("only persisted queries are allowed without authentication")
+	}
+
+	if rawParams.Query != "" {
+		if _, ok := p.Cache.Get(ctx, hash); !ok {
+			return persistedQueryRequired("queries can only be persisted with authentication")
+		}
+	}
+
+	return nil
+}
+
+func persistedQueryRequired(message string) *gqlerror.Error {
+	err := gqlerror.Errorf("%s", message)
+	errcode.Set(err, "PERSISTED_QUERY_REQUIRED")
+	return err
+}
+
+func isAuthenticated(ctx context.Context) bool {
+	header, _ := ctx.Value(util.ContextHeader{}).(http.Header)
+	if header == nil || header.Get("Authorization") == "" {
+		return false
+	}
+
+	user := postgres.GetUserByToken(ctx, header.Get("Authorization"))
+
+	return user != nil && !user.Banned
+}
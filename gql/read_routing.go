@@ -0,0 +1,58 @@
+package gql
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/spf13/viper"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// ReadRouting sends the reads of mutations to the primary database, and the ones of their caller for
+// database.postgres.replica.sticky afterwards, so that they see their own writes despite the replica lag.
+// Callers are identified the same way as by the REST API, so writes through either are seen by both.
+type ReadRouting struct{}
+
+var _ interface {
+	graphql.OperationInterceptor
+	graphql.HandlerExtension
+} = ReadRouting{}
+
+func (ReadRouting) ExtensionName() string {
+	return "ReadRouting"
+}
+
+func (ReadRouting) Validate(_ graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (ReadRouting) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	if !postgres.HasReadReplica() {
+		return next(ctx)
+	}
+
+	header, _ := ctx.Value(util.ContextHeader{}).(http.Header)
+	if header == nil {
+		return next(ctx)
+	}
+
+	identity := util.CallerIdentity(header.Get("Authorization"), RealIP(ctx))
+
+	rc := graphql.GetOperationContext(ctx)
+	mutation := rc.Operation != nil && rc.Operation.Operation == ast.Mutation
+
+	if mutation || redis.IsStuckToPrimary(identity) {
+		ctx = postgres.ContextWithPrimary(ctx)
+	}
+
+	if mutation {
+		redis.StickToPrimary(identity, viper.GetDuration("database.postgres.replica.sticky"))
+	}
+
+	return next(ctx)
+}
@@ -6,18 +6,66 @@ import (
 
 type Resolver struct{}
 
+func (r *Resolver) AuditLog() generated.AuditLogResolver {
+	return &auditLogResolver{r}
+}
+
+func (r *Resolver) Collection() generated.CollectionResolver {
+	return &collectionResolver{r}
+}
+
+func (r *Resolver) CollectionMod() generated.CollectionModResolver {
+	return &collectionModResolver{r}
+}
+
+func (r *Resolver) Comment() generated.CommentResolver {
+	return &commentResolver{r}
+}
+
+func (r *Resolver) Entity() generated.EntityResolver {
+	return &entityResolver{r}
+}
+
+func (r *Resolver) Favorite() generated.FavoriteResolver {
+	return &favoriteResolver{r}
+}
+
 func (r *Resolver) Mod() generated.ModResolver {
 	return &modResolver{r}
 }
 
+func (r *Resolver) ModRating() generated.ModRatingResolver {
+	return &modRatingResolver{r}
+}
+
+func (r *Resolver) ModTransfer() generated.ModTransferResolver {
+	return &modTransferResolver{r}
+}
+
 func (r *Resolver) Mutation() generated.MutationResolver {
 	return &mutationResolver{r}
 }
 
+func (r *Resolver) Notification() generated.NotificationResolver {
+	return &notificationResolver{r}
+}
+
 func (r *Resolver) Query() generated.QueryResolver {
 	return &queryResolver{r}
 }
 
+func (r *Resolver) Report() generated.ReportResolver {
+	return &reportResolver{r}
+}
+
+func (r *Resolver) ReportedComment() generated.ReportedCommentResolver {
+	return &reportedCommentResolver{r}
+}
+
+func (r *Resolver) Subscription() generated.SubscriptionResolver {
+	return &subscriptionResolver{r}
+}
+
 func (r *Resolver) User() generated.UserResolver {
 	return &userResolver{r}
 }
@@ -30,6 +78,10 @@ func (r *Resolver) VersionTarget() generated.VersionTargetResolver {
 	return &versionTargetResolver{r}
 }
 
+func (r *Resolver) VersionTargetPatch() generated.VersionTargetPatchResolver {
+	return &versionTargetPatchResolver{r}
+}
+
 func (r *Resolver) Version() generated.VersionResolver {
 	return &versionResolver{r}
 }
@@ -58,6 +110,14 @@ func (r *Resolver) GetGuides() generated.GetGuidesResolver {
 	return &getGuidesResolver{r}
 }
 
+func (r *Resolver) Blueprint() generated.BlueprintResolver {
+	return &blueprintResolver{r}
+}
+
+func (r *Resolver) GetBlueprints() generated.GetBlueprintsResolver {
+	return &getBlueprintsResolver{r}
+}
+
 func (r *Resolver) GetSMLVersions() generated.GetSMLVersionsResolver {
 	return &getSMLVersionsResolver{r}
 }
@@ -69,3 +129,5 @@ func (r *Resolver) GetBootstrapVersions() generated.GetBootstrapVersionsResolver
 type mutationResolver struct{ *Resolver }
 
 type queryResolver struct{ *Resolver }
+
+type subscriptionResolver struct{ *Resolver }
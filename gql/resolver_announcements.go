@@ -42,7 +42,9 @@ func (r *mutationResolver) DeleteAnnouncement(ctx context.Context, announcementI
 		return false, errors.New("announcement not found")
 	}
 
-	postgres.Delete(newCtx, &dbAnnouncement)
+	if err := postgres.DeleteAnnouncement(newCtx, dbAnnouncement.ID); err != nil {
+		return false, err
+	}
 
 	return true, nil
 }
@@ -65,9 +67,16 @@ func (r *mutationResolver) UpdateAnnouncement(ctx context.Context, announcementI
 	SetStringINNOE(announcement.Message, &dbAnnouncement.Message)
 	SetStringINNOE((*string)(announcement.Importance), &dbAnnouncement.Importance)
 
-	postgres.Save(newCtx, &dbAnnouncement)
+	resultAnnouncement, err := postgres.UpdateAnnouncement(newCtx, dbAnnouncement)
+	if err != nil {
+		return nil, err
+	}
 
-	return DBAnnouncementToGenerated(dbAnnouncement), nil
+	if resultAnnouncement == nil {
+		return nil, errors.New("announcement not found")
+	}
+
+	return DBAnnouncementToGenerated(resultAnnouncement), nil
 }
 
 func (r *queryResolver) GetAnnouncement(ctx context.Context, announcementID string) (*generated.Announcement, error) {
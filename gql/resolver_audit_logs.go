@@ -0,0 +1,80 @@
+package gql
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/dataloader"
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+)
+
+func (r *queryResolver) GetAuditLogs(ctx context.Context, filter *generated.AuditLogFilter) (*generated.GetAuditLogs, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getAuditLogs")
+	defer wrapper.end()
+
+	if filter == nil {
+		filter = &generated.AuditLogFilter{}
+	}
+
+	pageLimit, pageOffset, err := pageBounds(filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	dbFilter := postgres.AuditLogFilter{
+		UserID:     filter.UserID,
+		Action:     filter.Action,
+		TargetType: filter.TargetType,
+		TargetID:   filter.TargetID,
+	}
+
+	for _, bound := range []struct {
+		value  *string
+		target **time.Time
+	}{{filter.Since, &dbFilter.Since}, {filter.Until, &dbFilter.Until}} {
+		if bound.value == nil {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339Nano, *bound.value)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid date")
+		}
+
+		*bound.target = &parsed
+	}
+
+	entries := postgres.GetAuditLogs(newCtx, dbFilter, pageLimit, pageOffset)
+
+	converted := make([]*generated.AuditLog, len(entries))
+	for i, entry := range entries {
+		entry := entry
+		converted[i] = DBAuditLogToGenerated(&entry)
+	}
+
+	return &generated.GetAuditLogs{
+		AuditLogs: converted,
+		Count:     int(postgres.GetAuditLogCount(newCtx, dbFilter)),
+	}, nil
+}
+
+type auditLogResolver struct{ *Resolver }
+
+func (r *auditLogResolver) User(ctx context.Context, obj *generated.AuditLog) (*generated.User, error) {
+	wrapper, _ := WrapQueryTrace(ctx, "AuditLog.user")
+	defer wrapper.end()
+
+	if obj.UserID == nil {
+		return nil, nil
+	}
+
+	user, err := dataloader.For(ctx).UserByID.Load(*obj.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return DBUserToGenerated(user), nil
+}
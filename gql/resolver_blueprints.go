@@ -0,0 +1,280 @@
+package gql
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"gopkg.in/go-playground/validator.v9"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/models"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/storage"
+	"github.com/satisfactorymodding/smr-api/util"
+	"github.com/satisfactorymodding/smr-api/util/diff"
+	"github.com/satisfactorymodding/smr-api/validation"
+)
+
+func (r *mutationResolver) CreateBlueprint(ctx context.Context, blueprint generated.NewBlueprint) (*generated.Blueprint, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "createBlueprint")
+	defer wrapper.end()
+
+	val := ctx.Value(util.ContextValidator{}).(*validator.Validate)
+	if err := val.Struct(&blueprint); err != nil {
+		return nil, errors.Wrap(err, "validation failed")
+	}
+
+	dbBlueprint := &postgres.Blueprint{
+		Name:             blueprint.Name,
+		ShortDescription: blueprint.ShortDescription,
+	}
+
+	SetStringINNOE(blueprint.FullDescription, &dbBlueprint.FullDescription)
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	dbBlueprint.UserID = user.ID
+
+	resultBlueprint, err := postgres.CreateBlueprint(newCtx, dbBlueprint)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uploadBlueprintFile(newCtx, resultBlueprint, blueprint.File); err != nil {
+		// The blueprint was never visible, there is nothing to restore
+		postgres.DeleteForced(newCtx, resultBlueprint)
+		return nil, err
+	}
+
+	err = postgres.SetBlueprintTags(newCtx, resultBlueprint.ID, blueprint.TagIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Need to get the blueprint again to populate tags
+	return DBBlueprintToGenerated(postgres.GetBlueprintByIDNoCache(newCtx, resultBlueprint.ID)), nil
+}
+
+func (r *mutationResolver) UpdateBlueprint(ctx context.Context, blueprintID string, blueprint generated.UpdateBlueprint) (*generated.Blueprint, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "updateBlueprint")
+	defer wrapper.end()
+
+	val := ctx.Value(util.ContextValidator{}).(*validator.Validate)
+	if err := val.Struct(&blueprint); err != nil {
+		return nil, errors.Wrap(err, "validation failed")
+	}
+
+	dbBlueprint := postgres.GetBlueprintByIDNoCache(newCtx, blueprintID)
+
+	if dbBlueprint == nil {
+		return nil, errors.New("blueprint not found")
+	}
+
+	if blueprint.TagIDs != nil {
+		if err := postgres.ResetBlueprintTags(newCtx, blueprintID, blueprint.TagIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	before := diff.Snapshot(dbBlueprint)
+
+	SetStringINNOE(blueprint.Name, &dbBlueprint.Name)
+	SetStringINNOE(blueprint.ShortDescription, &dbBlueprint.ShortDescription)
+	SetStringINNOE(blueprint.FullDescription, &dbBlueprint.FullDescription)
+
+	if blueprint.File != nil {
+		if err := uploadBlueprintFile(newCtx, dbBlueprint, blueprint.File); err != nil {
+			return nil, err
+		}
+	} else {
+		postgres.Save(newCtx, &dbBlueprint)
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditChanges(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionBlueprintEdited, "blueprint", dbBlueprint.ID, nil), before, diff.Snapshot(dbBlueprint))
+
+	return DBBlueprintToGenerated(postgres.GetBlueprintByIDNoCache(newCtx, blueprintID)), nil
+}
+
+func (r *mutationResolver) DeleteBlueprint(ctx context.Context, blueprintID string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "deleteBlueprint")
+	defer wrapper.end()
+
+	dbBlueprint := postgres.GetBlueprintByID(newCtx, blueprintID)
+
+	if dbBlueprint == nil {
+		return false, errors.New("blueprint not found")
+	}
+
+	// The file is kept until the blueprint is purged
+	postgres.Delete(newCtx, &dbBlueprint)
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditLog(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionBlueprintDeleted, "blueprint", dbBlueprint.ID, nil))
+
+	return true, nil
+}
+
+// uploadBlueprintFile validates the uploaded package and stores it as the current file of the blueprint.
+//
+// Unlike mod versions, blueprints are published without going through quarantine and the virus scan. The scan only
+// looks at the .dll and .so files of an archive, and a blueprint package is rejected unless it only holds .sbp and
+// .sbpcfg files, so there would be nothing to scan.
+func uploadBlueprintFile(ctx context.Context, dbBlueprint *postgres.Blueprint, upload *graphql.Upload) error {
+	maxSize := viper.GetInt64("blueprints.max_file_size")
+
+	// The declared size is only checked to fail early, the package is limited while it is buffered
+	if maxSize > 0 && upload.Size > maxSize {
+		return blueprintTooLargeError(maxSize)
+	}
+
+	file, size, cleanup, err := util.BufferToTempFileLimited(upload.File, maxSize)
+	if errors.Is(err, util.ErrFileTooLarge) {
+		return blueprintTooLargeError(maxSize)
+	}
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	info, err := validation.ExtractBlueprintInfo(file, size)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "failed to rewind blueprint package")
+	}
+
+	success, key := storage.UploadBlueprint(ctx, dbBlueprint.ID, dbBlueprint.Name, file)
+	if !success {
+		return errors.New("failed to upload blueprint")
+	}
+
+	if dbBlueprint.Key != "" && dbBlueprint.Key != key {
+		storage.DeleteBlueprint(ctx, dbBlueprint.Key)
+	}
+
+	dbBlueprint.Key = key
+	dbBlueprint.Hash = info.Hash
+	dbBlueprint.Size = info.Size
+
+	postgres.Save(ctx, &dbBlueprint)
+
+	return nil
+}
+
+func blueprintTooLargeError(maxSize int64) error {
+	return errors.Errorf("blueprint package exceeds the maximum size of %d bytes", maxSize)
+}
+
+func (r *queryResolver) GetBlueprint(ctx context.Context, blueprintID string) (*generated.Blueprint, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getBlueprint")
+	defer wrapper.end()
+
+	blueprint := postgres.GetBlueprintByID(newCtx, blueprintID)
+
+	if blueprint != nil {
+		if redis.CanIncrement(RealIP(ctx), "view", "blueprint:"+blueprintID, time.Hour*4) {
+			postgres.IncrementBlueprintViews(newCtx, blueprint)
+		}
+	}
+
+	return DBBlueprintToGenerated(blueprint), nil
+}
+
+func (r *queryResolver) GetBlueprints(ctx context.Context, filter map[string]interface{}) (*generated.GetBlueprints, error) {
+	wrapper, _ := WrapQueryTrace(ctx, "getBlueprints")
+	defer wrapper.end()
+	return &generated.GetBlueprints{}, nil
+}
+
+type getBlueprintsResolver struct{ *Resolver }
+
+func (r *getBlueprintsResolver) Blueprints(ctx context.Context, obj *generated.GetBlueprints) ([]*generated.Blueprint, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "GetBlueprints.blueprints")
+	defer wrapper.end()
+
+	resolverContext := graphql.GetFieldContext(ctx)
+	blueprintFilter, err := models.ProcessBlueprintFilter(resolverContext.Parent.Args["filter"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+
+	var blueprints []postgres.Blueprint
+
+	if blueprintFilter.Ids == nil || len(blueprintFilter.Ids) == 0 {
+		blueprints = postgres.GetBlueprints(newCtx, blueprintFilter)
+	} else {
+		blueprints = postgres.GetBlueprintsByID(newCtx, blueprintFilter.Ids)
+	}
+
+	if blueprints == nil {
+		return nil, errors.New("blueprints not found")
+	}
+
+	converted := make([]*generated.Blueprint, len(blueprints))
+	for k, v := range blueprints {
+		converted[k] = DBBlueprintToGenerated(&v)
+	}
+
+	return converted, nil
+}
+
+func (r *getBlueprintsResolver) Count(ctx context.Context, obj *generated.GetBlueprints) (int, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "GetBlueprints.count")
+	defer wrapper.end()
+
+	resolverContext := graphql.GetFieldContext(ctx)
+	blueprintFilter, err := models.ProcessBlueprintFilter(resolverContext.Parent.Args["filter"].(map[string]interface{}))
+	if err != nil {
+		return 0, err
+	}
+
+	if blueprintFilter.Ids != nil && len(blueprintFilter.Ids) != 0 {
+		return len(blueprintFilter.Ids), nil
+	}
+
+	return int(postgres.GetBlueprintCount(newCtx, blueprintFilter)), nil
+}
+
+func (r *getBlueprintsResolver) PageInfo(ctx context.Context, obj *generated.GetBlueprints) (*generated.PageInfo, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "GetBlueprints.page_info")
+	defer wrapper.end()
+
+	resolverContext := graphql.GetFieldContext(ctx)
+	blueprintFilter, err := models.ProcessBlueprintFilter(resolverContext.Parent.Args["filter"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+
+	if blueprintFilter.Ids != nil && len(blueprintFilter.Ids) != 0 {
+		return &generated.PageInfo{}, nil
+	}
+
+	limit := *blueprintFilter.Limit
+	peek := limit + 1
+	blueprintFilter.Limit = &peek
+
+	return pageInfo(postgres.GetBlueprints(newCtx, blueprintFilter), limit, blueprintCursor(*blueprintFilter.OrderBy)), nil
+}
+
+type blueprintResolver struct{ *Resolver }
+
+func (r *blueprintResolver) User(ctx context.Context, obj *generated.Blueprint) (*generated.User, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Blueprint.user")
+	defer wrapper.end()
+
+	user := postgres.GetUserByID(newCtx, obj.UserID)
+
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	return DBUserToGenerated(user), nil
+}
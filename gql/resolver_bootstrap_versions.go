@@ -148,3 +148,24 @@ func (r *getBootstrapVersionsResolver) Count(ctx context.Context, obj *generated
 
 	return int(postgres.GetBootstrapVersionCount(newCtx, bootstrapVersionFilter)), nil
 }
+
+func (r *getBootstrapVersionsResolver) PageInfo(ctx context.Context, obj *generated.GetBootstrapVersions) (*generated.PageInfo, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "GetBootstrapVersions.page_info")
+	defer wrapper.end()
+
+	resolverContext := graphql.GetFieldContext(ctx)
+	bootstrapVersionFilter, err := models.ProcessBootstrapVersionFilter(resolverContext.Parent.Args["filter"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+
+	if bootstrapVersionFilter.Ids != nil && len(bootstrapVersionFilter.Ids) != 0 {
+		return &generated.PageInfo{}, nil
+	}
+
+	limit := *bootstrapVersionFilter.Limit
+	peek := limit + 1
+	bootstrapVersionFilter.Limit = &peek
+
+	return pageInfo(postgres.GetBootstrapVersions(newCtx, bootstrapVersionFilter), limit, bootstrapVersionCursor(*bootstrapVersionFilter.OrderBy)), nil
+}
@@ -0,0 +1,212 @@
+package gql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/dataloader"
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/redis"
+)
+
+func (r *mutationResolver) CreateCollection(ctx context.Context, collection generated.NewCollection) (*generated.Collection, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "createCollection")
+	defer wrapper.end()
+
+	if err := validateCollectionName(collection.Name); err != nil {
+		return nil, err
+	}
+
+	mods, err := collectionModsFromInput(collection.Mods)
+	if err != nil {
+		return nil, err
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	if err := redis.CheckUserRateLimit(user.ID, "create_collection"); err != nil {
+		return nil, err
+	}
+
+	dbCollection := &postgres.Collection{
+		UserID: user.ID,
+		Name:   strings.TrimSpace(collection.Name),
+		Mods:   mods,
+	}
+
+	SetStringINNOE(collection.Description, &dbCollection.Description)
+
+	resultCollection, err := postgres.CreateCollection(newCtx, dbCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	return DBCollectionToGenerated(resultCollection), nil
+}
+
+func (r *mutationResolver) UpdateCollection(ctx context.Context, collectionID string, collection generated.UpdateCollection) (*generated.Collection, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "updateCollection")
+	defer wrapper.end()
+
+	dbCollection := postgres.GetCollectionByID(newCtx, collectionID)
+
+	if dbCollection == nil {
+		return nil, errors.New("collection not found")
+	}
+
+	if collection.Name != nil {
+		if err := validateCollectionName(*collection.Name); err != nil {
+			return nil, err
+		}
+
+		dbCollection.Name = strings.TrimSpace(*collection.Name)
+	}
+
+	if collection.Description != nil {
+		dbCollection.Description = *collection.Description
+	}
+
+	var mods []postgres.CollectionMod
+	if collection.Mods != nil {
+		var err error
+		if mods, err = collectionModsFromInput(collection.Mods); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := postgres.UpdateCollection(newCtx, dbCollection, mods); err != nil {
+		return nil, err
+	}
+
+	return DBCollectionToGenerated(postgres.GetCollectionByID(newCtx, collectionID)), nil
+}
+
+func (r *mutationResolver) DeleteCollection(ctx context.Context, collectionID string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "deleteCollection")
+	defer wrapper.end()
+
+	dbCollection := postgres.GetCollectionByID(newCtx, collectionID)
+
+	if dbCollection == nil {
+		return false, errors.New("collection not found")
+	}
+
+	postgres.Delete(newCtx, &dbCollection)
+
+	return true, nil
+}
+
+func (r *queryResolver) GetCollection(ctx context.Context, collectionID string) (*generated.Collection, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getCollection")
+	defer wrapper.end()
+
+	return DBCollectionToGenerated(postgres.GetCollectionByID(newCtx, collectionID)), nil
+}
+
+func (r *queryResolver) GetCollectionBySlug(ctx context.Context, slug string) (*generated.Collection, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getCollectionBySlug")
+	defer wrapper.end()
+
+	return DBCollectionToGenerated(postgres.GetCollectionBySlug(newCtx, slug)), nil
+}
+
+func (r *queryResolver) GetMyCollections(ctx context.Context) ([]*generated.Collection, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getMyCollections")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	collections := postgres.GetUserCollections(newCtx, user.ID)
+
+	converted := make([]*generated.Collection, len(collections))
+	for i, collection := range collections {
+		collection := collection
+		converted[i] = DBCollectionToGenerated(&collection)
+	}
+
+	return converted, nil
+}
+
+type collectionResolver struct{ *Resolver }
+
+func (r *collectionResolver) User(ctx context.Context, obj *generated.Collection) (*generated.User, error) {
+	wrapper, _ := WrapQueryTrace(ctx, "Collection.user")
+	defer wrapper.end()
+
+	user, err := dataloader.For(ctx).UserByID.Load(obj.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	return DBUserToGenerated(user), nil
+}
+
+func (r *collectionResolver) InstallSet(ctx context.Context, obj *generated.Collection, target *generated.TargetName) (*generated.DependencyResolution, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Collection.installSet")
+	defer wrapper.end()
+
+	constraints := make(map[string]string, len(obj.Mods))
+	for _, mod := range obj.Mods {
+		constraint := "*"
+		if mod.Version != nil {
+			constraint = *mod.Version
+		}
+
+		constraints[mod.ModReference] = constraint
+	}
+
+	return resolveConstraints(newCtx, constraints, target)
+}
+
+type collectionModResolver struct{ *Resolver }
+
+func (r *collectionModResolver) Mod(ctx context.Context, obj *generated.CollectionMod) (*generated.Mod, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "CollectionMod.mod")
+	defer wrapper.end()
+
+	return DBModToGenerated(postgres.GetModByReference(newCtx, obj.ModReference)), nil
+}
+
+func validateCollectionName(name string) error {
+	name = strings.TrimSpace(name)
+	if len(name) < 3 || len(name) > 64 {
+		return errors.New("collection name must be between 3 and 64 characters")
+	}
+	return nil
+}
+
+// collectionModsFromInput validates the mods of a collection, keeping their order
+func collectionModsFromInput(input []*generated.CollectionModInput) ([]postgres.CollectionMod, error) {
+	if len(input) > postgres.MaxModReferences {
+		return nil, errors.Errorf("collections can contain at most %d mods", postgres.MaxModReferences)
+	}
+
+	seen := make(map[string]bool, len(input))
+	mods := make([]postgres.CollectionMod, 0, len(input))
+	for _, mod := range input {
+		if seen[mod.ModReference] {
+			return nil, errors.New("mod " + mod.ModReference + " is in the collection more than once")
+		}
+		seen[mod.ModReference] = true
+
+		if mod.Version != nil {
+			if _, err := semver.NewConstraint(*mod.Version); err != nil {
+				return nil, errors.Wrap(err, "invalid version constraint for "+mod.ModReference)
+			}
+		}
+
+		mods = append(mods, postgres.CollectionMod{
+			ModReference:      mod.ModReference,
+			VersionConstraint: mod.Version,
+		})
+	}
+
+	return mods, nil
+}
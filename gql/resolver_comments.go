@@ -0,0 +1,283 @@
+package gql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/auth"
+	"github.com/satisfactorymodding/smr-api/dataloader"
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/integrations"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// Maximum length of the markdown source of a comment
+const maxCommentLength = 4000
+
+func (r *mutationResolver) CreateComment(ctx context.Context, modID string, body string, parentID *string) (*generated.Comment, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "createComment")
+	defer wrapper.end()
+
+	body, err := validateCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	mod := postgres.GetModByID(newCtx, modID)
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	if parentID != nil {
+		parent := postgres.GetCommentByID(newCtx, *parentID)
+		if parent == nil || parent.ModID != mod.ID {
+			return nil, errors.New("parent comment not found")
+		}
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	if err := redis.CheckUserRateLimit(user.ID, "create_comment"); err != nil {
+		return nil, err
+	}
+
+	comment, err := postgres.CreateComment(newCtx, &postgres.Comment{
+		ModID:    mod.ID,
+		UserID:   user.ID,
+		ParentID: parentID,
+		Body:     body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	converted := DBCommentToGenerated(comment)
+
+	redis.PublishComment(converted)
+
+	go integrations.DispatchWebhookEvent(util.ReWrapCtx(ctx), postgres.WebhookEventCommentCreated, &mod.ID, converted)
+
+	return converted, nil
+}
+
+func (r *mutationResolver) UpdateComment(ctx context.Context, commentID string, body string) (*generated.Comment, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "updateComment")
+	defer wrapper.end()
+
+	body, err := validateCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := postgres.GetCommentByID(newCtx, commentID)
+	if comment == nil {
+		return nil, errors.New("comment not found")
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	if comment.UserID != user.ID {
+		return nil, errors.New("only the author of a comment can edit it")
+	}
+
+	if time.Since(comment.CreatedAt) > viper.GetDuration("comments.edit_window") {
+		return nil, errors.New("comments can no longer be edited this long after being posted")
+	}
+
+	postgres.UpdateCommentBody(newCtx, comment, body)
+
+	return DBCommentToGenerated(comment), nil
+}
+
+func (r *mutationResolver) DeleteComment(ctx context.Context, commentID string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "deleteComment")
+	defer wrapper.end()
+
+	comment := postgres.GetCommentByID(newCtx, commentID)
+	if comment == nil {
+		return false, errors.New("comment not found")
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	moderated := comment.UserID != user.ID
+	if moderated && !user.Has(newCtx, auth.RoleDeleteAnyContent) {
+		return false, errors.New("user not authorized to perform this action")
+	}
+
+	if err := postgres.DeleteComment(newCtx, comment, user.ID); err != nil {
+		return false, err
+	}
+
+	if moderated {
+		postgres.RecordAuditLog(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionCommentDeleted, "comment", comment.ID, map[string]interface{}{
+			"mod_id":  comment.ModID,
+			"user_id": comment.UserID,
+			"body":    comment.Body,
+		}))
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) ReportComment(ctx context.Context, commentID string, reason string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "reportComment")
+	defer wrapper.end()
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return false, errors.New("a reason is required to report a comment")
+	}
+
+	comment := postgres.GetCommentByID(newCtx, commentID)
+	if comment == nil {
+		return false, errors.New("comment not found")
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	if err := redis.CheckUserRateLimit(user.ID, "report_content"); err != nil {
+		return false, err
+	}
+
+	report := &postgres.Report{
+		ReporterID: user.ID,
+		TargetType: postgres.ReportTargetComment,
+		TargetID:   comment.ID,
+		Reason:     string(generated.ReportReasonOther),
+		Details:    &reason,
+	}
+
+	created, err := postgres.CreateReport(newCtx, report)
+	if err != nil {
+		return false, err
+	}
+
+	if created == report {
+		dispatchReportWebhooks(newCtx, created)
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) DismissCommentReports(ctx context.Context, commentID string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "dismissCommentReports")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	return postgres.CloseTargetReports(newCtx, postgres.ReportTargetComment, commentID, postgres.ReportStateDismissed, user.ID) > 0, nil
+}
+
+func (r *queryResolver) GetComments(ctx context.Context, modID string, limit *int, offset *int) (*generated.GetComments, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getComments")
+	defer wrapper.end()
+
+	pageLimit, pageOffset, err := pageBounds(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := postgres.GetModComments(newCtx, modID, pageLimit, pageOffset)
+
+	return &generated.GetComments{
+		Comments: DBCommentsToGeneratedSlice(comments),
+		Count:    int(postgres.GetModCommentCount(newCtx, modID)),
+	}, nil
+}
+
+func (r *queryResolver) GetComment(ctx context.Context, commentID string) (*generated.Comment, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getComment")
+	defer wrapper.end()
+
+	return DBCommentToGenerated(postgres.GetCommentByID(newCtx, commentID)), nil
+}
+
+func (r *queryResolver) GetReportedComments(ctx context.Context, limit *int, offset *int) ([]*generated.ReportedComment, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getReportedComments")
+	defer wrapper.end()
+
+	pageLimit, pageOffset, err := pageBounds(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := postgres.GetReportedComments(newCtx, pageLimit, pageOffset)
+
+	converted := make([]*generated.ReportedComment, len(comments))
+	for i, comment := range comments {
+		comment := comment
+		converted[i] = &generated.ReportedComment{
+			Comment: DBCommentToGenerated(&comment.Comment),
+		}
+	}
+
+	return converted, nil
+}
+
+func (r *subscriptionResolver) CommentCreated(ctx context.Context, modID string) (<-chan *generated.Comment, error) {
+	return redis.SubscribeComments(ctx, modID), nil
+}
+
+type commentResolver struct{ *Resolver }
+
+func (r *commentResolver) User(ctx context.Context, obj *generated.Comment) (*generated.User, error) {
+	wrapper, _ := WrapQueryTrace(ctx, "Comment.user")
+	defer wrapper.end()
+
+	user, err := dataloader.For(ctx).UserByID.Load(obj.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return DBUserToGenerated(user), nil
+}
+
+func (r *commentResolver) Replies(ctx context.Context, obj *generated.Comment) ([]*generated.Comment, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Comment.replies")
+	defer wrapper.end()
+
+	return DBCommentsToGeneratedSlice(postgres.GetCommentReplies(newCtx, obj.ID)), nil
+}
+
+type reportedCommentResolver struct{ *Resolver }
+
+func (r *reportedCommentResolver) Reports(ctx context.Context, obj *generated.ReportedComment) ([]*generated.CommentReport, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "ReportedComment.reports")
+	defer wrapper.end()
+
+	reports := postgres.GetUnresolvedTargetReports(newCtx, postgres.ReportTargetComment, obj.Comment.ID)
+
+	converted := make([]*generated.CommentReport, len(reports))
+	for i, report := range reports {
+		reason := report.Reason
+		if report.Details != nil {
+			reason = *report.Details
+		}
+
+		converted[i] = &generated.CommentReport{
+			UserID:    report.ReporterID,
+			Reason:    reason,
+			CreatedAt: report.CreatedAt.Format(time.RFC3339Nano),
+		}
+	}
+
+	return converted, nil
+}
+
+func validateCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+
+	if body == "" {
+		return "", errors.New("comments cannot be empty")
+	}
+
+	if len(body) > maxCommentLength {
+		return "", errors.Errorf("comments cannot be longer than %d characters", maxCommentLength)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,73 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+)
+
+func (r *mutationResolver) FavoriteMod(ctx context.Context, modID string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "favoriteMod")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	mod := postgres.GetModByID(newCtx, modID)
+	if mod == nil {
+		return false, errors.New("mod not found")
+	}
+
+	if err := postgres.FavoriteMod(newCtx, user.ID, mod); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) UnfavoriteMod(ctx context.Context, modID string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "unfavoriteMod")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	mod := postgres.GetModByID(newCtx, modID)
+	if mod == nil {
+		return false, errors.New("mod not found")
+	}
+
+	return postgres.UnfavoriteMod(newCtx, user.ID, mod)
+}
+
+func (r *queryResolver) MyFavorites(ctx context.Context) ([]*generated.Favorite, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "myFavorites")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	favorites := postgres.GetUserFavorites(newCtx, user.ID)
+
+	converted := make([]*generated.Favorite, len(favorites))
+	for i, favorite := range favorites {
+		favorite := favorite
+		converted[i] = DBUserFavoriteToGenerated(&favorite)
+	}
+
+	return converted, nil
+}
+
+type favoriteResolver struct{ *Resolver }
+
+func (r *favoriteResolver) Mod(ctx context.Context, obj *generated.Favorite) (*generated.Mod, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Favorite.mod")
+	defer wrapper.end()
+
+	mod := postgres.GetModByID(newCtx, obj.ModID)
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	return DBModToGenerated(mod), nil
+}
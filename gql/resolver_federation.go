@@ -0,0 +1,29 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+)
+
+// entityResolver resolves the entities referenced by other subgraphs of the federated graph
+type entityResolver struct{ *Resolver }
+
+func (r *entityResolver) FindModByID(ctx context.Context, id string) (*generated.Mod, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Entity.findModByID")
+	defer wrapper.end()
+	return DBModToGenerated(postgres.GetModByID(newCtx, id)), nil
+}
+
+func (r *entityResolver) FindUserByID(ctx context.Context, id string) (*generated.User, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Entity.findUserByID")
+	defer wrapper.end()
+	return DBUserToGenerated(postgres.GetUserByID(newCtx, id)), nil
+}
+
+func (r *entityResolver) FindVersionByID(ctx context.Context, id string) (*generated.Version, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Entity.findVersionByID")
+	defer wrapper.end()
+	return DBVersionToGenerated(postgres.GetVersion(newCtx, id)), nil
+}
@@ -0,0 +1,89 @@
+package gql
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/redis"
+)
+
+func (r *queryResolver) GetFieldUsage(ctx context.Context, days *int, deprecatedOnly *bool) ([]*generated.FieldUsage, error) {
+	wrapper, _ := WrapQueryTrace(ctx, "getFieldUsage")
+	defer wrapper.end()
+
+	usageDays := 30
+	if days != nil {
+		usageDays = *days
+	}
+
+	if usageDays < 1 || usageDays > 90 {
+		return nil, errors.New("days must be between 1 and 90")
+	}
+
+	entries, err := redis.GetFieldUsage(usageDays)
+	if err != nil {
+		return nil, err
+	}
+
+	deprecated := deprecatedCoordinates()
+	onlyDeprecated := deprecatedOnly != nil && *deprecatedOnly
+
+	byCoordinate := make(map[string]*generated.FieldUsage)
+	usageOf := func(coordinate string) *generated.FieldUsage {
+		usage, ok := byCoordinate[coordinate]
+		if !ok {
+			usage = &generated.FieldUsage{
+				Coordinate: coordinate,
+				Clients:    []*generated.FieldUsageClient{},
+			}
+
+			if reason, ok := deprecated[coordinate]; ok {
+				usage.Deprecated = true
+				usage.DeprecationReason = &reason
+			}
+
+			byCoordinate[coordinate] = usage
+		}
+		return usage
+	}
+
+	for coordinate := range deprecated {
+		usageOf(coordinate)
+	}
+
+	for _, entry := range entries {
+		if _, ok := deprecated[entry.Coordinate]; onlyDeprecated && !ok {
+			continue
+		}
+
+		usage := usageOf(entry.Coordinate)
+		usage.Requests += int(entry.Requests)
+		usage.Clients = append(usage.Clients, &generated.FieldUsageClient{
+			Client:   entry.Client,
+			Requests: int(entry.Requests),
+			LastSeen: entry.LastSeen.Format(time.RFC3339Nano),
+		})
+	}
+
+	converted := make([]*generated.FieldUsage, 0, len(byCoordinate))
+	for _, usage := range byCoordinate {
+		sort.Slice(usage.Clients, func(i, j int) bool {
+			return usage.Clients[i].Requests > usage.Clients[j].Requests
+		})
+
+		converted = append(converted, usage)
+	}
+
+	sort.Slice(converted, func(i, j int) bool {
+		if converted[i].Requests != converted[j].Requests {
+			return converted[i].Requests > converted[j].Requests
+		}
+		return converted[i].Coordinate < converted[j].Coordinate
+	})
+
+	return converted, nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/satisfactorymodding/smr-api/models"
 	"github.com/satisfactorymodding/smr-api/redis"
 	"github.com/satisfactorymodding/smr-api/util"
+	"github.com/satisfactorymodding/smr-api/util/diff"
 )
 
 func (r *mutationResolver) CreateGuide(ctx context.Context, guide generated.NewGuide) (*generated.Guide, error) {
@@ -69,12 +70,17 @@ func (r *mutationResolver) UpdateGuide(ctx context.Context, guideID string, guid
 		return nil, errors.New("guide not found")
 	}
 
+	before := diff.Snapshot(dbGuide)
+
 	SetStringINNOE(guide.Name, &dbGuide.Name)
 	SetStringINNOE(guide.ShortDescription, &dbGuide.ShortDescription)
 	SetStringINNOE(guide.Guide, &dbGuide.Guide)
 
 	postgres.Save(newCtx, &dbGuide)
 
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditChanges(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionGuideEdited, "guide", dbGuide.ID, nil), before, diff.Snapshot(dbGuide))
+
 	return DBGuideToGenerated(dbGuide), nil
 }
 
@@ -90,6 +96,9 @@ func (r *mutationResolver) DeleteGuide(ctx context.Context, guideID string) (boo
 
 	postgres.Delete(newCtx, &dbGuide)
 
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditLog(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionGuideDeleted, "guide", dbGuide.ID, nil))
+
 	return true, nil
 }
 
@@ -163,6 +172,27 @@ func (r *getGuidesResolver) Count(ctx context.Context, obj *generated.GetGuides)
 	return int(postgres.GetGuideCount(newCtx, guideFilter)), nil
 }
 
+func (r *getGuidesResolver) PageInfo(ctx context.Context, obj *generated.GetGuides) (*generated.PageInfo, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "GetGuides.page_info")
+	defer wrapper.end()
+
+	resolverContext := graphql.GetFieldContext(ctx)
+	guideFilter, err := models.ProcessGuideFilter(resolverContext.Parent.Args["filter"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+
+	if guideFilter.Ids != nil && len(guideFilter.Ids) != 0 {
+		return &generated.PageInfo{}, nil
+	}
+
+	limit := *guideFilter.Limit
+	peek := limit + 1
+	guideFilter.Limit = &peek
+
+	return pageInfo(postgres.GetGuides(newCtx, guideFilter), limit, guideCursor(*guideFilter.OrderBy)), nil
+}
+
 type guideResolver struct{ *Resolver }
 
 func (r *guideResolver) User(ctx context.Context, obj *generated.Guide) (*generated.User, error) {
@@ -0,0 +1,167 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/auth"
+	"github.com/satisfactorymodding/smr-api/dataloader"
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/integrations"
+)
+
+func (r *mutationResolver) TransferMod(ctx context.Context, modID string, newOwnerUserID string) (*generated.ModTransfer, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "transferMod")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	mod := postgres.GetModByID(newCtx, modID)
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	if mod.CreatorID != user.ID && !user.Has(newCtx, auth.RoleEditAnyContent) {
+		return nil, errors.New("only the owner of the mod can transfer it")
+	}
+
+	newOwner := postgres.GetUserByID(newCtx, newOwnerUserID)
+	if newOwner == nil {
+		return nil, errors.New("user not found")
+	}
+
+	if newOwner.ID == mod.CreatorID {
+		return nil, errors.New("user already owns the mod")
+	}
+
+	if newOwner.Banned {
+		return nil, errors.New("mods cannot be transferred to banned users")
+	}
+
+	transfer, err := postgres.CreateModTransfer(newCtx, mod, newOwner.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	integrations.ModTransferRequested(newCtx, mod, transfer)
+
+	return DBModTransferToGenerated(transfer), nil
+}
+
+func (r *mutationResolver) AcceptModTransfer(ctx context.Context, transferID string) (*generated.Mod, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "acceptModTransfer")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	transfer := postgres.GetModTransferByID(newCtx, transferID)
+	if transfer == nil || transfer.ToUserID != user.ID {
+		return nil, errors.New("transfer not found")
+	}
+
+	if transfer.Status != postgres.ModTransferStatePending {
+		return nil, errors.New("transfer is no longer pending")
+	}
+
+	if err := postgres.AcceptModTransfer(newCtx, transfer); err != nil {
+		return nil, err
+	}
+
+	mod := postgres.GetModByID(newCtx, transfer.ModID)
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	integrations.ModTransferAccepted(newCtx, mod, transfer)
+
+	publishModUpdated(mod)
+
+	return DBModToGenerated(mod), nil
+}
+
+func (r *mutationResolver) CancelModTransfer(ctx context.Context, transferID string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "cancelModTransfer")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	transfer := postgres.GetModTransferByID(newCtx, transferID)
+	if transfer == nil {
+		return false, errors.New("transfer not found")
+	}
+
+	status := postgres.ModTransferStateCancelled
+	switch {
+	case transfer.ToUserID == user.ID:
+		status = postgres.ModTransferStateDeclined
+	case transfer.FromUserID == user.ID, transfer.RequestedByID == user.ID, user.Has(newCtx, auth.RoleEditAnyContent):
+	default:
+		return false, errors.New("transfer not found")
+	}
+
+	if err := postgres.CloseModTransfer(newCtx, transfer, status, user.ID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *queryResolver) GetMyModTransfers(ctx context.Context) ([]*generated.ModTransfer, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getMyModTransfers")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	transfers := postgres.GetPendingModTransfers(newCtx, user.ID)
+
+	converted := make([]*generated.ModTransfer, len(transfers))
+	for i, transfer := range transfers {
+		transfer := transfer
+		converted[i] = DBModTransferToGenerated(&transfer)
+	}
+
+	return converted, nil
+}
+
+type modTransferResolver struct{ *Resolver }
+
+func (r *modTransferResolver) Mod(ctx context.Context, obj *generated.ModTransfer) (*generated.Mod, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "ModTransfer.mod")
+	defer wrapper.end()
+
+	mod := postgres.GetModByID(newCtx, obj.ModID)
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	return DBModToGenerated(mod), nil
+}
+
+func (r *modTransferResolver) From(ctx context.Context, obj *generated.ModTransfer) (*generated.User, error) {
+	wrapper, _ := WrapQueryTrace(ctx, "ModTransfer.from")
+	defer wrapper.end()
+
+	return transferUser(ctx, obj.FromUserID)
+}
+
+func (r *modTransferResolver) To(ctx context.Context, obj *generated.ModTransfer) (*generated.User, error) {
+	wrapper, _ := WrapQueryTrace(ctx, "ModTransfer.to")
+	defer wrapper.end()
+
+	return transferUser(ctx, obj.ToUserID)
+}
+
+func transferUser(ctx context.Context, userID string) (*generated.User, error) {
+	user, err := dataloader.For(ctx).UserByID.Load(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+
+	return DBUserToGenerated(user), nil
+}
@@ -3,24 +3,32 @@ package gql
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/dgraph-io/ristretto"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
 	"gopkg.in/go-playground/validator.v9"
 
+	"github.com/satisfactorymodding/smr-api/auth"
 	"github.com/satisfactorymodding/smr-api/dataloader"
 	"github.com/satisfactorymodding/smr-api/db/postgres"
 	"github.com/satisfactorymodding/smr-api/generated"
 	"github.com/satisfactorymodding/smr-api/integrations"
 	"github.com/satisfactorymodding/smr-api/models"
 	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/redis/jobs"
 	"github.com/satisfactorymodding/smr-api/storage"
 	"github.com/satisfactorymodding/smr-api/util"
 	"github.com/satisfactorymodding/smr-api/util/converter"
+	"github.com/satisfactorymodding/smr-api/util/diff"
 )
 
 var DisallowedModReferences = map[string]bool{
@@ -65,6 +73,7 @@ func (r *mutationResolver) CreateMod(ctx context.Context, mod generated.NewMod)
 	dbMod.CreatorID = user.ID
 
 	var logoData []byte
+	var logoSource []byte
 
 	if mod.Logo != nil {
 		file, err := io.ReadAll(mod.Logo.File)
@@ -72,6 +81,7 @@ func (r *mutationResolver) CreateMod(ctx context.Context, mod generated.NewMod)
 			return nil, errors.Wrap(err, "failed to read logo file")
 		}
 
+		logoSource = file
 		logoData, err = converter.ConvertAnyImageToWebp(ctx, file)
 
 		if err != nil {
@@ -90,7 +100,10 @@ func (r *mutationResolver) CreateMod(ctx context.Context, mod generated.NewMod)
 		success, logoKey := storage.UploadModLogo(ctx, resultMod.ID, bytes.NewReader(logoData))
 		if success {
 			resultMod.Logo = storage.GenerateDownloadLink(logoKey)
-			postgres.Save(newCtx, &resultMod)
+			setModLogoVariants(ctx, resultMod, logoSource)
+			if err := postgres.SaveMod(newCtx, resultMod); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -113,92 +126,116 @@ func (r *mutationResolver) UpdateMod(ctx context.Context, modID string, mod gene
 		return nil, errors.Wrap(err, "validation failed")
 	}
 
-	if mod.TagIDs != nil {
-		err := postgres.ResetModTags(newCtx, modID, mod.TagIDs)
-		if err != nil {
-			return nil, err
-		}
+	expectedUpdatedAt, err := parseExpectedUpdatedAt(mod.ExpectedUpdatedAt)
+	if err != nil {
+		return nil, err
 	}
 
-	dbMod := postgres.GetModByIDNoCache(newCtx, modID)
-
-	if dbMod == nil {
-		return nil, errors.New("mod not found")
-	}
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
 
-	if mod.ModReference != nil && *mod.ModReference != dbMod.ModReference && dbMod.ID != dbMod.ModReference {
-		return nil, errors.New("this mod already has set a mod reference")
-	}
+	// The tags and authors are only changed along with the mod, an edit conflict rolls them back
+	var dbMod *postgres.Mod
+	err = postgres.Tx(newCtx, func(txCtx context.Context) error {
+		if mod.TagIDs != nil {
+			if err := postgres.ResetModTags(txCtx, modID, mod.TagIDs); err != nil {
+				return err
+			}
+		}
 
-	SetStringINNOE(mod.Name, &dbMod.Name)
-	SetStringINNOE(mod.ShortDescription, &dbMod.ShortDescription)
-	SetINN(mod.SourceURL, &dbMod.SourceURL)
-	SetINN(mod.FullDescription, &dbMod.FullDescription)
-	SetINN(mod.ModReference, &dbMod.ModReference)
-	SetINN(mod.Hidden, &dbMod.Hidden)
-	SetCompatibilityINN(mod.Compatibility, &dbMod.Compatibility)
+		dbMod = postgres.GetModByIDNoCache(txCtx, modID)
 
-	if mod.Logo != nil {
-		file, err := io.ReadAll(mod.Logo.File)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to read logo file")
+		if dbMod == nil {
+			return errors.New("mod not found")
 		}
 
-		logoData, err := converter.ConvertAnyImageToWebp(ctx, file)
-		if err != nil {
-			return nil, err
+		if mod.ModReference != nil && *mod.ModReference != dbMod.ModReference && dbMod.ID != dbMod.ModReference {
+			return errors.New("this mod already has set a mod reference")
 		}
 
-		success, logoKey := storage.UploadModLogo(ctx, dbMod.ID, bytes.NewReader(logoData))
-		if success {
-			dbMod.Logo = storage.GenerateDownloadLink(logoKey)
-		} else {
-			dbMod.Logo = ""
-		}
-	}
+		before := diff.Snapshot(dbMod)
 
-	postgres.Save(newCtx, &dbMod)
+		SetStringINNOE(mod.Name, &dbMod.Name)
+		SetStringINNOE(mod.ShortDescription, &dbMod.ShortDescription)
+		SetINN(mod.SourceURL, &dbMod.SourceURL)
+		SetINN(mod.FullDescription, &dbMod.FullDescription)
+		SetINN(mod.ModReference, &dbMod.ModReference)
+		SetINN(mod.Hidden, &dbMod.Hidden)
+		SetCompatibilityINN(mod.Compatibility, &dbMod.Compatibility)
 
-	if mod.Authors != nil {
-		authors, err := dataloader.For(ctx).UserModsByModID.Load(modID)
-		if err != nil {
-			return nil, err
+		if mod.Logo != nil {
+			file, err := io.ReadAll(mod.Logo.File)
+			if err != nil {
+				return errors.Wrap(err, "failed to read logo file")
+			}
+
+			logoData, err := converter.ConvertAnyImageToWebp(ctx, file)
+			if err != nil {
+				return err
+			}
+
+			success, logoKey := storage.UploadModLogo(ctx, dbMod.ID, bytes.NewReader(logoData))
+			if success {
+				dbMod.Logo = storage.GenerateDownloadLink(logoKey)
+				setModLogoVariants(ctx, dbMod, file)
+			} else {
+				dbMod.Logo = ""
+				dbMod.LogoVariants = nil
+			}
 		}
 
-		for _, author := range authors {
-			// Creators cannot be deleted
-			if author.Role == "creator" {
-				continue
+		if mod.Authors != nil {
+			for _, author := range postgres.GetModAuthors(txCtx, modID) {
+				// Creators cannot be deleted
+				if author.Role == "creator" {
+					continue
+				}
+
+				found := false
+				for _, userMod := range mod.Authors {
+					if userMod.UserID == author.UserID {
+						found = true
+						break
+					}
+				}
+
+				if !found {
+					if err := postgres.RemoveModAuthor(txCtx, modID, author.UserID); err != nil {
+						return err
+					}
+				}
 			}
 
-			found := false
 			for _, userMod := range mod.Authors {
-				if userMod.UserID == author.UserID {
-					found = true
-					break
+				role := "creator"
+
+				if userMod.Role == "editor" {
+					role = "editor"
 				}
-			}
 
-			if !found {
-				postgres.Delete(newCtx, author)
+				if err := postgres.SetModAuthor(txCtx, modID, userMod.UserID, role); err != nil {
+					return err
+				}
 			}
 		}
 
-		for _, userMod := range mod.Authors {
-			role := "creator"
-
-			if userMod.Role == "editor" {
-				role = "editor"
+		if expectedUpdatedAt != nil {
+			if err := postgres.SaveModIfUnchanged(txCtx, dbMod, *expectedUpdatedAt); err != nil {
+				return err
 			}
-
-			postgres.Save(newCtx, &postgres.UserMod{
-				UserID: userMod.UserID,
-				ModID:  modID,
-				Role:   role,
-			})
+		} else if err := postgres.SaveMod(txCtx, dbMod); err != nil {
+			return err
 		}
+
+		postgres.RecordAuditChanges(txCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionModEdited, "mod", dbMod.ID, nil), before, diff.Snapshot(dbMod))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	publishModUpdated(dbMod)
+
 	return DBModToGenerated(dbMod), nil
 }
 
@@ -233,7 +270,22 @@ func (r *mutationResolver) DeleteMod(ctx context.Context, modID string) (bool, e
 		return false, errors.New("mod not found")
 	}
 
-	postgres.Delete(newCtx, &dbMod)
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	err := postgres.Tx(newCtx, func(ctx context.Context) error {
+		if err := postgres.DeleteModCascade(ctx, dbMod.ID); err != nil {
+			return err
+		}
+
+		postgres.RecordAuditLog(ctx, postgres.NewAuditLog(user.ID, postgres.AuditActionModDeleted, "mod", dbMod.ID, nil))
+
+		return nil
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to delete mod")
+	}
+
+	jobs.SubmitJobPurgeModTask(util.ReWrapCtx(ctx), dbMod.ID)
 
 	return true, nil
 }
@@ -250,7 +302,16 @@ func (r *mutationResolver) ApproveMod(ctx context.Context, modID string) (bool,
 
 	dbMod.Approved = true
 
-	postgres.Save(newCtx, &dbMod)
+	if err := postgres.SaveMod(newCtx, dbMod); err != nil {
+		return false, err
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditLog(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionModApproved, "mod", dbMod.ID, nil))
+
+	publishModUpdated(dbMod)
+
+	notifyModAuthors(newCtx, dbMod, postgres.NotificationModApproved, dbMod.Name+" was approved", nil)
 
 	go integrations.NewMod(util.ReWrapCtx(ctx), dbMod)
 
@@ -269,8 +330,129 @@ func (r *mutationResolver) DenyMod(ctx context.Context, modID string) (bool, err
 
 	dbMod.Denied = true
 
-	postgres.Save(newCtx, &dbMod)
-	postgres.Delete(newCtx, &dbMod)
+	// The authors are notified before the mod is deleted, which drops them from the mod
+	notifyModAuthors(newCtx, dbMod, postgres.NotificationModDenied, dbMod.Name+" was denied", nil)
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	err := postgres.Tx(newCtx, func(ctx context.Context) error {
+		if err := postgres.SaveMod(ctx, dbMod); err != nil {
+			return err
+		}
+
+		if err := postgres.DeleteModCascade(ctx, dbMod.ID); err != nil {
+			return err
+		}
+
+		postgres.RecordAuditLog(ctx, postgres.NewAuditLog(user.ID, postgres.AuditActionModDenied, "mod", dbMod.ID, nil))
+
+		return nil
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to deny mod")
+	}
+
+	// Unlike deleted mods, denied mods are only purged after the retention period, along with the denial notification
+	return true, nil
+}
+
+func (r *mutationResolver) HideMods(ctx context.Context, modIds []string, reason *string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "hideMods")
+	defer wrapper.end()
+
+	if len(modIds) > maxBulkModeration {
+		return false, fmt.Errorf("at most %d mods can be moderated at once", maxBulkModeration)
+	}
+
+	dbMods := make(map[string]*postgres.Mod, len(modIds))
+	for _, modID := range modIds {
+		dbMod := postgres.GetModByID(newCtx, modID)
+		if dbMod == nil {
+			return false, errors.New("mod " + modID + " not found")
+		}
+
+		dbMods[dbMod.ID] = dbMod
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	tx := postgres.DBCtx(newCtx).Begin()
+	txCtx := postgres.ContextWithDB(newCtx, tx)
+	defer tx.Rollback()
+
+	for _, dbMod := range dbMods {
+		dbMod.Hidden = true
+		if err := postgres.SaveMod(txCtx, dbMod); err != nil {
+			return false, err
+		}
+
+		postgres.RecordAuditLog(txCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionModHidden, "mod", dbMod.ID, map[string]interface{}{
+			"reason": reason,
+		}))
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return false, errors.Wrap(err, "failed to hide mods")
+	}
+
+	for _, dbMod := range dbMods {
+		publishModUpdated(dbMod)
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) ArchiveMod(ctx context.Context, modID string, reason *string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "archiveMod")
+	defer wrapper.end()
+
+	dbMod := postgres.GetModByID(newCtx, modID)
+
+	if dbMod == nil {
+		return false, errors.New("mod not found")
+	}
+
+	now := time.Now()
+	dbMod.Archived = true
+	dbMod.ArchiveReason = reason
+	dbMod.ArchivedAt = &now
+
+	if err := postgres.SaveMod(newCtx, dbMod); err != nil {
+		return false, err
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditLog(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionModArchived, "mod", dbMod.ID, map[string]interface{}{
+		"reason": reason,
+	}))
+
+	publishModUpdated(dbMod)
+
+	return true, nil
+}
+
+func (r *mutationResolver) UnarchiveMod(ctx context.Context, modID string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "unarchiveMod")
+	defer wrapper.end()
+
+	dbMod := postgres.GetModByID(newCtx, modID)
+
+	if dbMod == nil {
+		return false, errors.New("mod not found")
+	}
+
+	dbMod.Archived = false
+	dbMod.ArchiveReason = nil
+	dbMod.ArchivedAt = nil
+
+	if err := postgres.SaveMod(newCtx, dbMod); err != nil {
+		return false, err
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditLog(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionModUnarchived, "mod", dbMod.ID, nil))
+
+	publishModUpdated(dbMod)
 
 	return true, nil
 }
@@ -305,6 +487,58 @@ func (r *queryResolver) GetModByReference(ctx context.Context, modReference stri
 	return DBModToGenerated(mod), nil
 }
 
+func (r *queryResolver) GetModsByReferences(ctx context.Context, references []string) ([]*generated.Mod, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getModsByReferences")
+	defer wrapper.end()
+
+	if len(references) > postgres.MaxModReferences {
+		return nil, errors.Errorf("at most %d mod references can be requested at once", postgres.MaxModReferences)
+	}
+
+	mods := postgres.GetModsByReferences(newCtx, references)
+
+	converted := make([]*generated.Mod, len(mods))
+	for k, v := range mods {
+		converted[k] = DBModToGenerated(&v)
+	}
+
+	return converted, nil
+}
+
+func (r *queryResolver) GetModsCompatibleWith(ctx context.Context, gameVersion int, target generated.TargetName) ([]*generated.CompatibleMod, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getModsCompatibleWith")
+	defer wrapper.end()
+
+	versions := postgres.GetLatestCompatibleVersions(newCtx, gameVersion, string(target))
+	if len(versions) == 0 {
+		return []*generated.CompatibleMod{}, nil
+	}
+
+	modIds := make([]string, len(versions))
+	for i, version := range versions {
+		modIds[i] = version.ModID
+	}
+
+	mods := make(map[string]*postgres.Mod, len(modIds))
+	for _, mod := range postgres.GetModsByIDOrReference(newCtx, modIds) {
+		mod := mod
+		mods[mod.ID] = &mod
+	}
+
+	compatible := make([]*generated.CompatibleMod, 0, len(versions))
+	for _, version := range versions {
+		version := version
+		if mod, ok := mods[version.ModID]; ok {
+			compatible = append(compatible, &generated.CompatibleMod{
+				Mod:     DBModToGenerated(mod),
+				Version: DBVersionToGenerated(&version),
+			})
+		}
+	}
+
+	return compatible, nil
+}
+
 func (r *queryResolver) GetMods(ctx context.Context, filter map[string]interface{}) (*generated.GetMods, error) {
 	wrapper, _ := WrapQueryTrace(ctx, "getMods")
 	defer wrapper.end()
@@ -335,10 +569,7 @@ func (r *getModsResolver) Mods(ctx context.Context, obj *generated.GetMods) ([]*
 	wrapper, newCtx := WrapQueryTrace(ctx, "GetMods.mods")
 	defer wrapper.end()
 
-	resolverContext := graphql.GetFieldContext(ctx)
-	unapproved := resolverContext.Parent.Field.Field.Name == "getUnapprovedMods"
-
-	modFilter, err := models.ProcessModFilter(resolverContext.Parent.Args["filter"].(map[string]interface{}))
+	modFilter, unapproved, err := getModsFilter(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -365,10 +596,7 @@ func (r *getModsResolver) Count(ctx context.Context, obj *generated.GetMods) (in
 	wrapper, newCtx := WrapQueryTrace(ctx, "GetMods.count")
 	defer wrapper.end()
 
-	resolverContext := graphql.GetFieldContext(ctx)
-	unapproved := resolverContext.Parent.Field.Field.Name == "getUnapprovedMods"
-
-	modFilter, err := models.ProcessModFilter(resolverContext.Parent.Args["filter"].(map[string]interface{}))
+	modFilter, unapproved, err := getModsFilter(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -380,6 +608,70 @@ func (r *getModsResolver) Count(ctx context.Context, obj *generated.GetMods) (in
 	return int(postgres.GetModCountNew(newCtx, modFilter, unapproved)), nil
 }
 
+func (r *getModsResolver) TagFacets(ctx context.Context, obj *generated.GetMods) ([]*generated.TagFacet, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "GetMods.tag_facets")
+	defer wrapper.end()
+
+	modFilter, unapproved, err := getModsFilter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	facets := postgres.GetModTagFacets(newCtx, modFilter, unapproved)
+
+	converted := make([]*generated.TagFacet, len(facets))
+	for i, facet := range facets {
+		facet := facet
+		converted[i] = &generated.TagFacet{
+			Tag:   DBTagToGenerated(&facet.Tag),
+			Count: facet.Count,
+		}
+	}
+
+	return converted, nil
+}
+
+// getModsFilter returns the filter of the query a GetMods is resolved for, and whether it lists unapproved mods
+func getModsFilter(ctx context.Context) (*models.ModFilter, bool, error) {
+	parent := graphql.GetFieldContext(ctx).Parent
+	unapproved := parent.Field.Field.Name == "getUnapprovedMods"
+
+	modFilter, err := models.ProcessModFilter(parent.Args["filter"].(map[string]interface{}))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if parent.Field.Field.Name == "getModsByTag" {
+		tag := postgres.GetTagByName(ctx, parent.Args["tagName"].(string))
+		if tag == nil {
+			return nil, false, errors.New("tag not found")
+		}
+
+		modFilter.TagIDs = []string{tag.ID}
+	}
+
+	return modFilter, unapproved, nil
+}
+
+func (r *getModsResolver) PageInfo(ctx context.Context, obj *generated.GetMods) (*generated.PageInfo, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "GetMods.page_info")
+	defer wrapper.end()
+
+	modFilter, unapproved, err := getModsFilter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := *modFilter.Limit
+	peek := limit + 1
+	modFilter.Limit = &peek
+	modFilter.Fields = nil
+	modFilter.AddField("id")
+	modFilter.AddField(string(*modFilter.OrderBy))
+
+	return pageInfo(postgres.GetModsNew(newCtx, modFilter, unapproved), limit, modCursor(*modFilter.OrderBy)), nil
+}
+
 type getMyModsResolver struct{ *Resolver }
 
 func (r *getMyModsResolver) Mods(ctx context.Context, obj *generated.GetMyMods) ([]*generated.Mod, error) {
@@ -437,6 +729,32 @@ func (r *getMyModsResolver) Count(ctx context.Context, obj *generated.GetMyMods)
 	return int(postgres.GetModCountNew(newCtx, modFilter, unapproved)), nil
 }
 
+func (r *getMyModsResolver) PageInfo(ctx context.Context, obj *generated.GetMyMods) (*generated.PageInfo, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "GetMyMods.page_info")
+	defer wrapper.end()
+
+	resolverContext := graphql.GetFieldContext(ctx)
+	unapproved := resolverContext.Parent.Field.Field.Name == "getMyUnapprovedMods"
+
+	modFilter, err := models.ProcessModFilter(resolverContext.Parent.Args["filter"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+
+	if modFilter.Ids != nil && len(modFilter.Ids) != 0 {
+		return &generated.PageInfo{}, nil
+	}
+
+	limit := *modFilter.Limit
+	peek := limit + 1
+	modFilter.Limit = &peek
+	modFilter.Fields = nil
+	modFilter.AddField("id")
+	modFilter.AddField(string(*modFilter.OrderBy))
+
+	return pageInfo(postgres.GetModsNew(newCtx, modFilter, unapproved), limit, modCursor(*modFilter.OrderBy)), nil
+}
+
 type modResolver struct{ *Resolver }
 
 func (r *modResolver) Authors(ctx context.Context, obj *generated.Mod) ([]*generated.UserMod, error) {
@@ -454,11 +772,7 @@ func (r *modResolver) Authors(ctx context.Context, obj *generated.Mod) ([]*gener
 
 	converted := make([]*generated.UserMod, len(authors))
 	for k, v := range authors {
-		converted[k] = &generated.UserMod{
-			UserID: v.UserID,
-			ModID:  v.ModID,
-			Role:   v.Role,
-		}
+		converted[k] = DBUserModToGenerated(&v)
 	}
 
 	return converted, nil
@@ -564,6 +878,285 @@ func (r *modResolver) LatestVersions(ctx context.Context, obj *generated.Mod) (*
 	return &converted, nil
 }
 
+// setModLogoVariants stores the logo resized to each of images.logo_sizes.
+// Variants that fail are skipped, clients then get the closest available one.
+func setModLogoVariants(ctx context.Context, mod *postgres.Mod, logo []byte) {
+	sizes := viper.GetIntSlice("images.logo_sizes")
+
+	variants, err := converter.GenerateImageVariants(ctx, logo, sizes)
+	if err != nil {
+		log.Err(err).Str("mod_id", mod.ID).Msg("failed to generate logo variants")
+		mod.LogoVariants = nil
+		return
+	}
+
+	mod.LogoVariants = make(map[string]string, len(variants))
+	for _, variant := range variants {
+		success, key := storage.UploadModLogoVariant(ctx, mod.ID, variant.Size, variant.Format, bytes.NewReader(variant.Data))
+		if success {
+			mod.LogoVariants[logoVariantName(variant.Size, variant.Format)] = key
+		}
+	}
+}
+
+func logoVariantName(size int, format string) string {
+	return strconv.Itoa(size) + "." + format
+}
+
+// Logo returns the logo of the mod, or the smallest variant at least as large as the requested size.
+// Variants missing in the requested format fall back to WebP, and to the original logo if the mod has none.
+func (r *modResolver) Logo(ctx context.Context, obj *generated.Mod, size *int, format *generated.ImageFormat) (*string, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Mod.logo")
+	defer wrapper.end()
+
+	if size == nil && format == nil {
+		return obj.Logo, nil
+	}
+
+	dbMod := postgres.GetModByID(newCtx, obj.ID)
+	if dbMod == nil || len(dbMod.LogoVariants) == 0 {
+		return obj.Logo, nil
+	}
+
+	formats := []string{string(generated.ImageFormatWebp)}
+	if format != nil && *format != generated.ImageFormatWebp {
+		formats = append([]string{string(*format)}, formats...)
+	}
+
+	for _, variantFormat := range formats {
+		sizes := make([]int, 0, len(dbMod.LogoVariants))
+		for name := range dbMod.LogoVariants {
+			variantSize, variantFormatName, _ := strings.Cut(name, ".")
+			if parsed, err := strconv.Atoi(variantSize); err == nil && variantFormatName == variantFormat {
+				sizes = append(sizes, parsed)
+			}
+		}
+
+		if len(sizes) == 0 {
+			continue
+		}
+
+		sort.Ints(sizes)
+
+		// The largest variant is used if none is large enough
+		best := sizes[len(sizes)-1]
+		if size != nil {
+			for _, variantSize := range sizes {
+				if variantSize >= *size {
+					best = variantSize
+					break
+				}
+			}
+		}
+
+		link := storage.GenerateDownloadLink(dbMod.LogoVariants[logoVariantName(best, variantFormat)])
+		return &link, nil
+	}
+
+	return obj.Logo, nil
+}
+
+var bandwidthPeriods = map[generated.BandwidthPeriod]int{
+	generated.BandwidthPeriodDay:   1,
+	generated.BandwidthPeriodWeek:  7,
+	generated.BandwidthPeriodMonth: 30,
+	generated.BandwidthPeriodYear:  365,
+}
+
+func (r *modResolver) BandwidthUsage(ctx context.Context, obj *generated.Mod, period generated.BandwidthPeriod) (*generated.BandwidthUsage, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Mod.bandwidthUsage")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	if !postgres.UserCanUploadModVersions(newCtx, user, obj.ID) && !user.Has(newCtx, auth.RoleEditAnyContent) {
+		return nil, errors.New("user not authorized to perform this action")
+	}
+
+	days, ok := bandwidthPeriods[period]
+	if !ok {
+		return nil, errors.New("invalid period")
+	}
+
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+
+	usage := &generated.BandwidthUsage{
+		Period:   period,
+		Versions: make([]*generated.VersionBandwidthUsage, 0),
+		Days:     make([]*generated.DailyBandwidthUsage, 0),
+	}
+
+	for _, total := range postgres.GetModBandwidthByVersion(newCtx, obj.ID, since) {
+		usage.Bytes += total.Bytes
+		usage.Requests += total.Requests
+		usage.Versions = append(usage.Versions, &generated.VersionBandwidthUsage{
+			VersionID: total.VersionID,
+			Bytes:     total.Bytes,
+			Requests:  total.Requests,
+		})
+	}
+
+	for _, total := range postgres.GetModBandwidthByDay(newCtx, obj.ID, since) {
+		usage.Days = append(usage.Days, &generated.DailyBandwidthUsage{
+			Day:      total.Day.Format("2006-01-02"),
+			Bytes:    total.Bytes,
+			Requests: total.Requests,
+		})
+	}
+
+	return usage, nil
+}
+
+// Longest amount of buckets returned by downloadStats
+const maxDownloadStatsBuckets = 366
+
+// Lengths of a bucket of each granularity, in days. Months are approximated.
+var statsGranularityDays = map[generated.StatsGranularity]int{
+	generated.StatsGranularityDay:   1,
+	generated.StatsGranularityWeek:  7,
+	generated.StatsGranularityMonth: 31,
+}
+
+func (r *queryResolver) DownloadStats(ctx context.Context, modID string, granularity generated.StatsGranularity, from string, to *string) ([]*generated.DownloadStatsBucket, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "downloadStats")
+	defer wrapper.end()
+
+	bucketDays, ok := statsGranularityDays[granularity]
+	if !ok {
+		return nil, errors.New("invalid granularity")
+	}
+
+	start, err := parseDay(from)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid from date")
+	}
+
+	end := time.Now().UTC()
+	if to != nil {
+		if end, err = parseDay(*to); err != nil {
+			return nil, errors.Wrap(err, "invalid to date")
+		}
+	}
+
+	if end.Before(start) {
+		return nil, errors.New("to must not be before from")
+	}
+
+	if end.Sub(start) > time.Duration(maxDownloadStatsBuckets*bucketDays)*time.Hour*24 {
+		return nil, fmt.Errorf("at most %d buckets can be requested at once", maxDownloadStatsBuckets)
+	}
+
+	totals := postgres.GetModDownloadStats(newCtx, modID, string(granularity), start, end)
+
+	buckets := make([]*generated.DownloadStatsBucket, 0)
+	var bucket *generated.DownloadStatsBucket
+	var versions map[string]*generated.VersionDownloadCount
+	var targets map[string]*generated.TargetDownloadCount
+
+	for _, total := range totals {
+		bucketStart := total.Bucket.Format("2006-01-02")
+		if bucket == nil || bucket.Start != bucketStart {
+			bucket = &generated.DownloadStatsBucket{
+				Start:    bucketStart,
+				Versions: make([]*generated.VersionDownloadCount, 0),
+				Targets:  make([]*generated.TargetDownloadCount, 0),
+			}
+			versions = make(map[string]*generated.VersionDownloadCount)
+			targets = make(map[string]*generated.TargetDownloadCount)
+			buckets = append(buckets, bucket)
+		}
+
+		bucket.Downloads += total.Downloads
+
+		if versions[total.VersionID] == nil {
+			versions[total.VersionID] = &generated.VersionDownloadCount{
+				VersionID: total.VersionID,
+				Version:   total.Version,
+			}
+			bucket.Versions = append(bucket.Versions, versions[total.VersionID])
+		}
+		versions[total.VersionID].Downloads += total.Downloads
+
+		if targets[total.TargetName] == nil {
+			targets[total.TargetName] = &generated.TargetDownloadCount{}
+			if total.TargetName != "" {
+				targetName := generated.TargetName(total.TargetName)
+				targets[total.TargetName].TargetName = &targetName
+			}
+			bucket.Targets = append(bucket.Targets, targets[total.TargetName])
+		}
+		targets[total.TargetName].Downloads += total.Downloads
+	}
+
+	return buckets, nil
+}
+
+// parseDay parses a date, either as a day or as a RFC3339 timestamp
+func parseDay(value string) (time.Time, error) {
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		return day, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "expected a day or a RFC3339 timestamp")
+	}
+
+	return parsed, nil
+}
+
+func (r *subscriptionResolver) ModUpdated(ctx context.Context, modID *string) (<-chan *generated.Mod, error) {
+	events := redis.SubscribeModEvents(ctx, redis.EventModUpdated)
+	out := make(chan *generated.Mod)
+
+	go func() {
+		defer close(out)
+
+		for mod := range events {
+			// Hidden and unapproved mods are not listed publicly either
+			if mod.Hidden || !mod.Approved {
+				continue
+			}
+
+			if modID != nil && mod.ID != *modID {
+				continue
+			}
+
+			select {
+			case out <- mod:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (r *queryResolver) GetTrendingMods(ctx context.Context, period generated.TrendingPeriod, limit *int) ([]*generated.Mod, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getTrendingMods")
+	defer wrapper.end()
+
+	count := 10
+	if limit != nil {
+		count = *limit
+	}
+
+	if count < 1 || count > 100 {
+		return nil, errors.New("limit must be between 1 and 100")
+	}
+
+	mods := postgres.GetTrendingMods(newCtx, string(period), count)
+
+	converted := make([]*generated.Mod, len(mods))
+	for i, mod := range mods {
+		mod := mod
+		converted[i] = DBModToGenerated(&mod)
+	}
+
+	return converted, nil
+}
+
 func (r *queryResolver) GetModByIDOrReference(ctx context.Context, modIDOrReference string) (*generated.Mod, error) {
 	wrapper, newCtx := WrapQueryTrace(ctx, "getModByIdOrReference")
 	defer wrapper.end()
@@ -620,6 +1213,22 @@ func (r *queryResolver) ResolveModVersions(ctx context.Context, filter []*genera
 	return modVersions, nil
 }
 
+func (r *queryResolver) ResolveDependencies(ctx context.Context, constraints []*generated.ModConstraint, target *generated.TargetName) (*generated.DependencyResolution, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "resolveDependencies")
+	defer wrapper.end()
+
+	if len(constraints) > postgres.MaxModReferences {
+		return nil, errors.Errorf("at most %d constraints can be resolved at once", postgres.MaxModReferences)
+	}
+
+	constraintMapping := make(map[string]string, len(constraints))
+	for _, constraint := range constraints {
+		constraintMapping[constraint.ModReference] = constraint.Constraint
+	}
+
+	return resolveConstraints(newCtx, constraintMapping, target)
+}
+
 func (r *queryResolver) GetModAssetList(ctx context.Context, modReference string) ([]string, error) {
 	wrapper, _ := WrapQueryTrace(ctx, "getModAssetList")
 	defer wrapper.end()
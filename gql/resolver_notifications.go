@@ -0,0 +1,80 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/redis"
+)
+
+func (r *queryResolver) GetNotifications(ctx context.Context, unreadOnly *bool, limit *int, offset *int) (*generated.GetNotifications, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getNotifications")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	pageLimit := 10
+	if limit != nil {
+		pageLimit = *limit
+	}
+
+	if pageLimit < 1 || pageLimit > 100 {
+		return nil, errors.New("limit must be between 1 and 100")
+	}
+
+	pageOffset := 0
+	if offset != nil {
+		pageOffset = *offset
+	}
+
+	if pageOffset < 0 {
+		return nil, errors.New("offset must not be negative")
+	}
+
+	onlyUnread := unreadOnly != nil && *unreadOnly
+
+	notifications := postgres.GetNotifications(newCtx, user.ID, onlyUnread, pageLimit, pageOffset)
+
+	converted := make([]*generated.Notification, len(notifications))
+	for i, notification := range notifications {
+		notification := notification
+		converted[i] = DBNotificationToGenerated(&notification)
+	}
+
+	return &generated.GetNotifications{
+		Notifications: converted,
+		Count:         int(postgres.GetNotificationCount(newCtx, user.ID, onlyUnread)),
+		Unread:        int(postgres.GetNotificationCount(newCtx, user.ID, true)),
+	}, nil
+}
+
+func (r *mutationResolver) MarkNotificationsRead(ctx context.Context, notificationIds []string) (int, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "markNotificationsRead")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	return int(postgres.MarkNotificationsRead(newCtx, user.ID, notificationIds)), nil
+}
+
+func (r *subscriptionResolver) NotificationReceived(ctx context.Context) (<-chan *generated.Notification, error) {
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	return redis.SubscribeNotifications(ctx, user.ID), nil
+}
+
+type notificationResolver struct{ *Resolver }
+
+func (r *notificationResolver) Mod(ctx context.Context, obj *generated.Notification) (*generated.Mod, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Notification.mod")
+	defer wrapper.end()
+
+	if obj.ModID == nil {
+		return nil, nil
+	}
+
+	return DBModToGenerated(postgres.GetModByID(newCtx, *obj.ModID)), nil
+}
@@ -126,7 +126,9 @@ func completeOAuthFlow(ctx context.Context, user *oauth.UserData, userAgent stri
 		success, avatarKey := storage.UploadUserAvatar(ctx, session.UserID, bytes.NewReader(avatarData))
 		if success {
 			dbUser.Avatar = storage.GenerateDownloadLink(avatarKey)
-			postgres.Save(ctx, &dbUser)
+			if err := postgres.SaveUser(ctx, dbUser); err != nil {
+				return nil, err
+			}
 		}
 	}
 
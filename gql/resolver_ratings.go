@@ -0,0 +1,126 @@
+package gql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/auth"
+	"github.com/satisfactorymodding/smr-api/dataloader"
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+)
+
+// Maximum length of the review accompanying a rating
+const maxReviewLength = 500
+
+func (r *mutationResolver) RateMod(ctx context.Context, modID string, rating int, review *string) (*generated.ModRating, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "rateMod")
+	defer wrapper.end()
+
+	if rating < 1 || rating > 5 {
+		return nil, errors.New("rating must be between 1 and 5")
+	}
+
+	if review != nil {
+		trimmed := strings.TrimSpace(*review)
+		if len(trimmed) > maxReviewLength {
+			return nil, errors.Errorf("reviews cannot be longer than %d characters", maxReviewLength)
+		}
+
+		review = &trimmed
+		if trimmed == "" {
+			review = nil
+		}
+	}
+
+	mod := postgres.GetModByID(newCtx, modID)
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	if postgres.UserCanUploadModVersions(newCtx, user, mod.ID) {
+		return nil, errors.New("authors cannot rate their own mods")
+	}
+
+	if !postgres.HasUserDownloadedMod(newCtx, user.ID, mod.ID) {
+		return nil, errors.New("only users who downloaded the mod can rate it")
+	}
+
+	dbRating := &postgres.ModRating{
+		UserID: user.ID,
+		ModID:  mod.ID,
+		Rating: rating,
+		Review: review,
+	}
+
+	if err := postgres.RateMod(newCtx, dbRating); err != nil {
+		return nil, err
+	}
+
+	return DBModRatingToGenerated(postgres.GetModRating(newCtx, user.ID, mod.ID)), nil
+}
+
+func (r *mutationResolver) DeleteModRating(ctx context.Context, modID string, userID *string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "deleteModRating")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	ratingUserID := user.ID
+	if userID != nil && *userID != user.ID {
+		if !user.Has(newCtx, auth.RoleDeleteAnyContent) {
+			return false, errors.New("user not authorized to perform this action")
+		}
+
+		ratingUserID = *userID
+	}
+
+	return postgres.DeleteModRating(newCtx, ratingUserID, modID)
+}
+
+func (r *modResolver) RatingHistogram(ctx context.Context, obj *generated.Mod) ([]int, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Mod.rating_histogram")
+	defer wrapper.end()
+
+	histogram := postgres.GetModRatingHistogram(newCtx, obj.ID)
+
+	return histogram[:], nil
+}
+
+func (r *modResolver) Ratings(ctx context.Context, obj *generated.Mod, withReview *bool, limit *int, offset *int) ([]*generated.ModRating, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "Mod.ratings")
+	defer wrapper.end()
+
+	pageLimit, pageOffset, err := pageBounds(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := postgres.GetModRatings(newCtx, obj.ID, withReview != nil && *withReview, pageLimit, pageOffset)
+
+	converted := make([]*generated.ModRating, len(ratings))
+	for i, rating := range ratings {
+		rating := rating
+		converted[i] = DBModRatingToGenerated(&rating)
+	}
+
+	return converted, nil
+}
+
+type modRatingResolver struct{ *Resolver }
+
+func (r *modRatingResolver) User(ctx context.Context, obj *generated.ModRating) (*generated.User, error) {
+	wrapper, _ := WrapQueryTrace(ctx, "ModRating.user")
+	defer wrapper.end()
+
+	user, err := dataloader.For(ctx).UserByID.Load(obj.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return DBUserToGenerated(user), nil
+}
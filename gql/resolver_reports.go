@@ -0,0 +1,178 @@
+package gql
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/dataloader"
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/integrations"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// Maximum length of the details provided with a report
+const maxReportDetailsLength = 2000
+
+func (r *mutationResolver) ReportContent(ctx context.Context, typeArg generated.ReportTargetType, id string, reason generated.ReportReason, details *string) (*generated.Report, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "reportContent")
+	defer wrapper.end()
+
+	if details != nil {
+		trimmed := strings.TrimSpace(*details)
+		if len(trimmed) > maxReportDetailsLength {
+			return nil, errors.Errorf("report details cannot be longer than %d characters", maxReportDetailsLength)
+		}
+
+		details = &trimmed
+		if trimmed == "" {
+			details = nil
+		}
+	}
+
+	if reason == generated.ReportReasonOther && details == nil {
+		return nil, errors.New("details are required when reporting for another reason")
+	}
+
+	if !reportTargetExists(newCtx, typeArg, id) {
+		return nil, errors.New(string(typeArg) + " not found")
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	if err := redis.CheckUserRateLimit(user.ID, "report_content"); err != nil {
+		return nil, err
+	}
+
+	newReport := &postgres.Report{
+		ReporterID: user.ID,
+		TargetType: string(typeArg),
+		TargetID:   id,
+		Reason:     string(reason),
+		Details:    details,
+	}
+
+	report, err := postgres.CreateReport(newCtx, newReport)
+	if err != nil {
+		return nil, err
+	}
+
+	// The unresolved report of the same content is returned if the reporter already reported it
+	if report == newReport {
+		dispatchReportWebhooks(newCtx, report)
+	}
+
+	return DBReportToGenerated(report), nil
+}
+
+func (r *mutationResolver) UpdateReport(ctx context.Context, reportID string, state generated.ReportState, note *string) (*generated.Report, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "updateReport")
+	defer wrapper.end()
+
+	report := postgres.GetReportByID(newCtx, reportID)
+	if report == nil {
+		return nil, errors.New("report not found")
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	postgres.SetReportState(newCtx, report, string(state), user.ID, note)
+
+	return DBReportToGenerated(postgres.GetReportByID(newCtx, reportID)), nil
+}
+
+func (r *queryResolver) GetReports(ctx context.Context, state []generated.ReportState, typeArg *generated.ReportTargetType, limit *int, offset *int) (*generated.GetReports, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getReports")
+	defer wrapper.end()
+
+	pageLimit, pageOffset, err := pageBounds(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	states := []string{postgres.ReportStateOpen, postgres.ReportStateTriaged}
+	if state != nil {
+		states = make([]string, len(state))
+		for i, s := range state {
+			states[i] = string(s)
+		}
+	}
+
+	var targetType *string
+	if typeArg != nil {
+		t := string(*typeArg)
+		targetType = &t
+	}
+
+	reports := postgres.GetReports(newCtx, states, targetType, pageLimit, pageOffset)
+
+	converted := make([]*generated.Report, len(reports))
+	for i, report := range reports {
+		report := report
+		converted[i] = DBReportToGenerated(&report)
+	}
+
+	return &generated.GetReports{
+		Reports: converted,
+		Count:   int(postgres.GetReportCount(newCtx, states, targetType)),
+	}, nil
+}
+
+type reportResolver struct{ *Resolver }
+
+func (r *reportResolver) Reporter(ctx context.Context, obj *generated.Report) (*generated.User, error) {
+	wrapper, _ := WrapQueryTrace(ctx, "Report.reporter")
+	defer wrapper.end()
+
+	user, err := dataloader.For(ctx).UserByID.Load(obj.ReporterID)
+	if err != nil {
+		return nil, err
+	}
+
+	return DBUserToGenerated(user), nil
+}
+
+func reportTargetExists(ctx context.Context, targetType generated.ReportTargetType, id string) bool {
+	switch targetType {
+	case generated.ReportTargetTypeMod:
+		return postgres.GetModByID(ctx, id) != nil
+	case generated.ReportTargetTypeVersion:
+		return postgres.GetVersion(ctx, id) != nil
+	case generated.ReportTargetTypeComment:
+		return postgres.GetCommentByID(ctx, id) != nil
+	case generated.ReportTargetTypeUser:
+		return postgres.GetUserByID(ctx, id) != nil
+	}
+	return false
+}
+
+// dispatchReportWebhooks delivers a new report to the webhooks subscribed to the reports of its mod.
+// The reporter and the details of the report are left to the moderators.
+func dispatchReportWebhooks(ctx context.Context, report *postgres.Report) {
+	var modID *string
+	switch report.TargetType {
+	case postgres.ReportTargetMod:
+		modID = &report.TargetID
+	case postgres.ReportTargetVersion:
+		if version := postgres.GetVersion(ctx, report.TargetID); version != nil {
+			modID = &version.ModID
+		}
+	case postgres.ReportTargetComment:
+		if comment := postgres.GetCommentByID(ctx, report.TargetID); comment != nil {
+			modID = &comment.ModID
+		}
+	}
+
+	data := map[string]interface{}{
+		"id":          report.ID,
+		"target_type": report.TargetType,
+		"target_id":   report.TargetID,
+		"reason":      report.Reason,
+		"created_at":  report.CreatedAt.Format(time.RFC3339Nano),
+	}
+
+	go integrations.DispatchWebhookEvent(util.ReWrapCtx(ctx), postgres.WebhookEventReportCreated, modID, data)
+}
@@ -191,3 +191,24 @@ func (r *getSMLVersionsResolver) Count(ctx context.Context, obj *generated.GetSM
 
 	return int(postgres.GetSMLVersionCount(newCtx, smlVersionFilter)), nil
 }
+
+func (r *getSMLVersionsResolver) PageInfo(ctx context.Context, obj *generated.GetSMLVersions) (*generated.PageInfo, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "GetSMLVersions.page_info")
+	defer wrapper.end()
+
+	resolverContext := graphql.GetFieldContext(ctx)
+	smlVersionFilter, err := models.ProcessSMLVersionFilter(resolverContext.Parent.Args["filter"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+
+	if smlVersionFilter.Ids != nil && len(smlVersionFilter.Ids) != 0 {
+		return &generated.PageInfo{}, nil
+	}
+
+	limit := *smlVersionFilter.Limit
+	peek := limit + 1
+	smlVersionFilter.Limit = &peek
+
+	return pageInfo(postgres.GetSMLVersions(newCtx, smlVersionFilter), limit, smlVersionCursor(*smlVersionFilter.OrderBy)), nil
+}
@@ -0,0 +1,96 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/db"
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+)
+
+func (r *queryResolver) GetSiteStats(ctx context.Context) (*generated.SiteStats, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getSiteStats")
+	defer wrapper.end()
+
+	stats, err := db.GetSiteStats(newCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	return DBSiteStatsToGenerated(stats), nil
+}
+
+func (r *queryResolver) GetTopModsByDownloads(ctx context.Context, limit *int) ([]*generated.ModDownloadStats, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getTopModsByDownloads")
+	defer wrapper.end()
+
+	count, err := statsLimit(limit, 10, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := postgres.GetTopModsByDownloads(newCtx, count)
+
+	converted := make([]*generated.ModDownloadStats, len(stats))
+	for i, stat := range stats {
+		stat := stat
+		converted[i] = DBModDownloadStatsToGenerated(&stat)
+	}
+
+	return converted, nil
+}
+
+func (r *queryResolver) GetVersionsPerWeek(ctx context.Context, weeks *int) ([]*generated.WeeklyVersionStats, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getVersionsPerWeek")
+	defer wrapper.end()
+
+	count, err := statsLimit(weeks, 12, 520)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := postgres.GetWeeklyVersionStats(newCtx, count)
+
+	converted := make([]*generated.WeeklyVersionStats, len(stats))
+	for i, stat := range stats {
+		stat := stat
+		converted[i] = DBWeeklyVersionStatsToGenerated(&stat)
+	}
+
+	return converted, nil
+}
+
+func (r *queryResolver) GetTopAuthors(ctx context.Context, limit *int) ([]*generated.AuthorStats, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getTopAuthors")
+	defer wrapper.end()
+
+	count, err := statsLimit(limit, 10, 100)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := postgres.GetTopAuthors(newCtx, count)
+
+	converted := make([]*generated.AuthorStats, len(stats))
+	for i, stat := range stats {
+		stat := stat
+		converted[i] = DBAuthorStatsToGenerated(&stat)
+	}
+
+	return converted, nil
+}
+
+func statsLimit(limit *int, def int, max int) (int, error) {
+	count := def
+	if limit != nil {
+		count = *limit
+	}
+
+	if count < 1 || count > max {
+		return 0, errors.Errorf("limit must be between 1 and %d", max)
+	}
+
+	return count, nil
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/satisfactorymodding/smr-api/generated"
 )
 
+// Maximum amount of tags authors can put on a mod
+const maxModTags = 10
+
 func (r *mutationResolver) CreateTag(ctx context.Context, tagName string, description string) (*generated.Tag, error) {
 	wrapper, newCtx := WrapMutationTrace(ctx, "createTag")
 	defer wrapper.end()
@@ -59,6 +62,11 @@ func (r *mutationResolver) DeleteTag(ctx context.Context, id string) (bool, erro
 
 	postgres.Delete(newCtx, &dbTag)
 
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditLog(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionTagDeleted, "tag", dbTag.ID, map[string]interface{}{
+		"name": dbTag.Name,
+	}))
+
 	return true, nil
 }
 
@@ -72,19 +80,131 @@ func (r *mutationResolver) UpdateTag(ctx context.Context, id string, newName str
 		return nil, errors.New("Tag not found")
 	}
 
-	err := postgres.ValidateTagName(dbTag.Name)
-	if err != nil {
-		return nil, err
+	if newName != dbTag.Name {
+		if err := postgres.ValidateTagName(newName); err != nil {
+			return nil, err
+		}
+
+		if postgres.GetTagByName(newCtx, newName) != nil {
+			return nil, errors.New("Tag " + newName + " already exists")
+		}
 	}
 
 	SetStringINNOE(&newName, &dbTag.Name)
 	SetStringINNOE(&description, &dbTag.Description)
 
 	postgres.Save(newCtx, &dbTag)
+	postgres.ClearCache()
 
 	return DBTagToGenerated(dbTag), nil
 }
 
+func (r *mutationResolver) MergeTags(ctx context.Context, tagIDs []string, targetTagID string) (*generated.Tag, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "mergeTags")
+	defer wrapper.end()
+
+	targetTag := postgres.GetTagByID(newCtx, targetTagID)
+	if targetTag == nil {
+		return nil, errors.New("Tag not found")
+	}
+
+	for _, tagID := range tagIDs {
+		if tagID == targetTagID {
+			return nil, errors.New("a tag cannot be merged into itself")
+		}
+	}
+
+	if len(postgres.GetTagsByIDs(newCtx, tagIDs)) != len(tagIDs) {
+		return nil, errors.New("Tag not found")
+	}
+
+	if err := postgres.MergeTags(newCtx, tagIDs, targetTagID); err != nil {
+		return nil, errors.Wrap(err, "failed to merge tags")
+	}
+
+	return DBTagToGenerated(targetTag), nil
+}
+
+func (r *mutationResolver) SetModTags(ctx context.Context, modID string, tagIDs []string) (*generated.Mod, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "setModTags")
+	defer wrapper.end()
+
+	if len(tagIDs) > maxModTags {
+		return nil, errors.Errorf("mods can have at most %d tags", maxModTags)
+	}
+
+	if len(postgres.GetTagsByIDs(newCtx, tagIDs)) != len(tagIDs) {
+		return nil, errors.New("Tag not found")
+	}
+
+	if err := postgres.ResetModTags(newCtx, modID, tagIDs); err != nil {
+		return nil, err
+	}
+
+	return modWithTags(newCtx, modID)
+}
+
+func (r *mutationResolver) AddModTag(ctx context.Context, modID string, tagID string) (*generated.Mod, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "addModTag")
+	defer wrapper.end()
+
+	mod := postgres.GetModByIDNoCache(newCtx, modID)
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	for _, tag := range mod.Tags {
+		if tag.ID == tagID {
+			return DBModToGenerated(mod), nil
+		}
+	}
+
+	if len(mod.Tags) >= maxModTags {
+		return nil, errors.Errorf("mods can have at most %d tags", maxModTags)
+	}
+
+	if postgres.GetTagByID(newCtx, tagID) == nil {
+		return nil, errors.New("Tag not found")
+	}
+
+	if err := postgres.AddModTag(newCtx, modID, tagID); err != nil {
+		return nil, err
+	}
+
+	return modWithTags(newCtx, modID)
+}
+
+func (r *mutationResolver) RemoveModTag(ctx context.Context, modID string, tagID string) (*generated.Mod, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "removeModTag")
+	defer wrapper.end()
+
+	if err := postgres.RemoveModTag(newCtx, modID, tagID); err != nil {
+		return nil, err
+	}
+
+	return modWithTags(newCtx, modID)
+}
+
+func (r *queryResolver) GetModsByTag(ctx context.Context, tagName string, filter map[string]interface{}) (*generated.GetMods, error) {
+	wrapper, _ := WrapQueryTrace(ctx, "getModsByTag")
+	defer wrapper.end()
+	return &generated.GetMods{}, nil
+}
+
+// modWithTags returns the mod after its tags were changed
+func modWithTags(ctx context.Context, modID string) (*generated.Mod, error) {
+	postgres.ClearCache()
+
+	mod := postgres.GetModByIDNoCache(ctx, modID)
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	publishModUpdated(mod)
+
+	return DBModToGenerated(mod), nil
+}
+
 func (r *queryResolver) GetTag(ctx context.Context, id string) (*generated.Tag, error) {
 	wrapper, newCtx := WrapQueryTrace(ctx, "getTag_"+id)
 	defer wrapper.end()
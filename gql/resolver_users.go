@@ -10,6 +10,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
@@ -21,6 +22,7 @@ import (
 	"github.com/satisfactorymodding/smr-api/storage"
 	"github.com/satisfactorymodding/smr-api/util"
 	"github.com/satisfactorymodding/smr-api/util/converter"
+	"github.com/satisfactorymodding/smr-api/util/diff"
 )
 
 func (r *mutationResolver) UpdateUser(ctx context.Context, userID string, input generated.UpdateUser) (*generated.User, error) {
@@ -33,6 +35,9 @@ func (r *mutationResolver) UpdateUser(ctx context.Context, userID string, input
 		return nil, errors.New("user not found")
 	}
 
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	before := diff.Snapshot(dbUser)
+
 	if input.Avatar != nil {
 		file, err := io.ReadAll(input.Avatar.File)
 		if err != nil {
@@ -51,7 +56,13 @@ func (r *mutationResolver) UpdateUser(ctx context.Context, userID string, input
 	}
 
 	if input.Groups != nil {
+		previousGroups := groupIDs(dbUser.GetGroups(newCtx))
 		dbUser.SetGroups(newCtx, input.Groups)
+
+		entry := postgres.NewAuditLog(user.ID, postgres.AuditActionUserGroupsChanged, "user", dbUser.ID, nil)
+		postgres.RecordAuditChanges(newCtx, entry,
+			map[string]interface{}{"Groups": previousGroups},
+			map[string]interface{}{"Groups": groupIDs(dbUser.GetGroups(newCtx))})
 	}
 
 	if input.Username != nil {
@@ -66,11 +77,29 @@ func (r *mutationResolver) UpdateUser(ctx context.Context, userID string, input
 		}
 	}
 
-	postgres.Save(newCtx, &dbUser)
+	if err := postgres.SaveUser(newCtx, dbUser); err != nil {
+		return nil, err
+	}
+
+	postgres.RecordAuditChanges(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionUserEdited, "user", dbUser.ID, nil), before, diff.Snapshot(dbUser))
 
 	return DBUserToGenerated(dbUser), nil
 }
 
+// groupIDs returns the sorted IDs of the groups, for comparing them in the audit log
+func groupIDs(groups []*auth.Group) []string {
+	ids := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if group != nil {
+			ids = append(ids, group.ID)
+		}
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
 func (r *mutationResolver) Logout(ctx context.Context) (bool, error) {
 	wrapper, newCtx := WrapMutationTrace(ctx, "logout")
 	defer wrapper.end()
@@ -111,6 +140,23 @@ func (r *queryResolver) GetUsers(ctx context.Context, userIds []string) ([]*gene
 	return converted, nil
 }
 
+func (r *queryResolver) GetUserMods(ctx context.Context, userID string, role *generated.ModAuthorRole) ([]*generated.UserMod, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getUserMods")
+	defer wrapper.end()
+
+	mods := postgres.GetUserMods(newCtx, userID)
+
+	converted := make([]*generated.UserMod, 0, len(mods))
+	for _, v := range mods {
+		userMod := DBUserModToGenerated(&v)
+		if role == nil || userMod.AuthorRole == *role {
+			converted = append(converted, userMod)
+		}
+	}
+
+	return converted, nil
+}
+
 type userResolver struct{ *Resolver }
 
 func (r *userResolver) Mods(ctx context.Context, obj *generated.User) ([]*generated.UserMod, error) {
@@ -125,11 +171,7 @@ func (r *userResolver) Mods(ctx context.Context, obj *generated.User) ([]*genera
 
 	converted := make([]*generated.UserMod, len(mods))
 	for k, v := range mods {
-		converted[k] = &generated.UserMod{
-			UserID: v.UserID,
-			ModID:  v.ModID,
-			Role:   v.Role,
-		}
+		converted[k] = DBUserModToGenerated(&v)
 	}
 
 	return converted, nil
@@ -153,6 +195,24 @@ func (r *userResolver) Guides(ctx context.Context, obj *generated.User) ([]*gene
 	return converted, nil
 }
 
+func (r *userResolver) Blueprints(ctx context.Context, obj *generated.User) ([]*generated.Blueprint, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "User.blueprints")
+	defer wrapper.end()
+
+	blueprints := postgres.GetUserBlueprints(newCtx, obj.ID)
+
+	if blueprints == nil {
+		return nil, errors.New("blueprints not found")
+	}
+
+	converted := make([]*generated.Blueprint, len(blueprints))
+	for k, v := range blueprints {
+		converted[k] = DBBlueprintToGenerated(&v)
+	}
+
+	return converted, nil
+}
+
 func (r *userResolver) Groups(ctx context.Context, obj *generated.User) ([]*generated.Group, error) {
 	wrapper, newCtx := WrapQueryTrace(ctx, "User.guides")
 	defer wrapper.end()
@@ -214,6 +274,10 @@ func (r *userResolver) Roles(ctx context.Context, obj *generated.User) (*generat
 		userRoles.EditAnyModCompatibility = true
 	}
 
+	if hasRole, ok := roles[auth.RoleViewFieldUsage]; ok && hasRole {
+		userRoles.ViewFieldUsage = true
+	}
+
 	return userRoles, nil
 }
 
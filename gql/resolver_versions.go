@@ -3,26 +3,37 @@ package gql
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
-	"runtime/debug"
+	"net/http"
+	"sort"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/Masterminds/semver/v3"
 	"github.com/dgraph-io/ristretto"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 
+	"github.com/satisfactorymodding/smr-api/auth"
 	"github.com/satisfactorymodding/smr-api/dataloader"
 	"github.com/satisfactorymodding/smr-api/db/postgres"
 	"github.com/satisfactorymodding/smr-api/generated"
 	"github.com/satisfactorymodding/smr-api/integrations"
+	"github.com/satisfactorymodding/smr-api/migrations/utils"
 	"github.com/satisfactorymodding/smr-api/models"
+	"github.com/satisfactorymodding/smr-api/nodes"
 	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/redis/jobs"
 	"github.com/satisfactorymodding/smr-api/storage"
 	"github.com/satisfactorymodding/smr-api/util"
+	"github.com/satisfactorymodding/smr-api/util/diff"
+	"github.com/satisfactorymodding/smr-api/validation"
 )
 
-func (r *mutationResolver) CreateVersion(ctx context.Context, modID string) (string, error) {
+func (r *mutationResolver) CreateVersion(ctx context.Context, modID string, size *int64) (string, error) {
 	wrapper, newCtx := WrapMutationTrace(ctx, "createVersion")
 	defer wrapper.end()
 
@@ -36,10 +47,32 @@ func (r *mutationResolver) CreateVersion(ctx context.Context, modID string) (str
 		return "", errors.New("mod is not validated")
 	}
 
+	if mod.Archived {
+		return "", errors.New("archived mods cannot receive new versions")
+	}
+
 	if mod.ID == mod.ModReference {
 		return "", errors.New("you must update your mod reference on the site to match your mod_reference in your data.json")
 	}
 
+	var declaredSize int64
+	if size != nil {
+		declaredSize = *size
+
+		if err := validation.CheckArchiveSize(declaredSize); err != nil {
+			return "", err
+		}
+	}
+
+	if err := validation.CheckModQuota(newCtx, mod, declaredSize); err != nil {
+		return "", err
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	if err := redis.CheckUserRateLimit(user.ID, "create_version"); err != nil {
+		return "", err
+	}
+
 	versionID := util.GenerateUniqueID()
 
 	storage.StartUploadMultipartMod(ctx, mod.ID, mod.Name, versionID)
@@ -47,12 +80,12 @@ func (r *mutationResolver) CreateVersion(ctx context.Context, modID string) (str
 	return versionID, nil
 }
 
-func (r *mutationResolver) UploadVersionPart(ctx context.Context, modID string, versionID string, part int, file graphql.Upload) (bool, error) {
+func (r *mutationResolver) UploadVersionPart(ctx context.Context, modID string, versionID string, part int, file graphql.Upload, checksum *generated.PartChecksum) (bool, error) {
 	wrapper, newCtx := WrapMutationTrace(ctx, "createVersion")
 	defer wrapper.end()
 
-	if part > 100 {
-		return false, errors.New("files can consist of max 41 chunks")
+	if err := validation.CheckUploadPart(part); err != nil {
+		return false, err
 	}
 
 	mod := postgres.GetModByID(newCtx, modID)
@@ -65,6 +98,10 @@ func (r *mutationResolver) UploadVersionPart(ctx context.Context, modID string,
 		return false, errors.New("mod is not validated")
 	}
 
+	if mod.Archived {
+		return false, errors.New("archived mods cannot receive new versions")
+	}
+
 	if mod.ID == mod.ModReference {
 		return false, errors.New("you must update your mod reference on the site to match your mod_reference in your data.json")
 	}
@@ -75,11 +112,184 @@ func (r *mutationResolver) UploadVersionPart(ctx context.Context, modID string,
 		return false, errors.Wrap(err, "failed to read file")
 	}
 
-	success, _ := storage.UploadMultipartMod(ctx, mod.ID, mod.Name, versionID, int64(part), bytes.NewReader(fileData))
+	uploadedSize := storage.GetMultipartModUploadedSize(mod.ID, mod.Name, versionID, int64(part)) + int64(len(fileData))
+	if err := validation.CheckArchiveSize(uploadedSize); err != nil {
+		return false, err
+	}
+
+	if err := validation.CheckModQuota(newCtx, mod, uploadedSize); err != nil {
+		return false, err
+	}
+
+	var partChecksum *storage.PartChecksum
+	if checksum != nil {
+		partChecksum = &storage.PartChecksum{
+			Algorithm: storage.ChecksumAlgorithm(checksum.Algorithm),
+			Value:     checksum.Value,
+		}
+	}
+
+	success, _, err := storage.UploadMultipartMod(ctx, mod.ID, mod.Name, versionID, int64(part), bytes.NewReader(fileData), partChecksum)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to upload part")
+	}
+
+	if parts, err := storage.ListUploadedMultipartModParts(newCtx, mod.ID, mod.Name, versionID); err == nil {
+		received := len(parts)
+		redis.PublishVersionUploadProgress(versionID, &generated.VersionUploadProgress{
+			State:         generated.VersionUploadStateUploading,
+			PartsReceived: &received,
+		})
+	}
 
 	return success, nil
 }
 
+func (r *subscriptionResolver) VersionCreated(ctx context.Context, modID *string) (<-chan *generated.Version, error) {
+	return filterVersionEvents(ctx, redis.SubscribeVersionEvents(ctx, redis.EventVersionCreated), modID), nil
+}
+
+func (r *subscriptionResolver) VersionApproved(ctx context.Context, modID *string) (<-chan *generated.Version, error) {
+	return filterVersionEvents(ctx, redis.SubscribeVersionEvents(ctx, redis.EventVersionApproved), modID), nil
+}
+
+// filterVersionEvents only relays the versions of the provided mod, if any
+func filterVersionEvents(ctx context.Context, events <-chan *generated.Version, modID *string) <-chan *generated.Version {
+	if modID == nil {
+		return events
+	}
+
+	out := make(chan *generated.Version)
+
+	go func() {
+		defer close(out)
+
+		for version := range events {
+			if version.ModID != *modID {
+				continue
+			}
+
+			select {
+			case out <- version:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+func (r *subscriptionResolver) VersionUploadProgress(ctx context.Context, modID string, versionID string) (<-chan *generated.VersionUploadProgress, error) {
+	mod := postgres.GetModByID(ctx, modID)
+
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	stored, err := getModVersionUploadStatus(mod.ID, versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Uploads only have a stored state once they are finalized, until then they are known by their parts
+	if stored == nil {
+		if _, err := storage.ListUploadedMultipartModParts(ctx, mod.ID, mod.Name, versionID); err != nil {
+			return nil, errors.New("version upload not found")
+		}
+	}
+
+	// Subscribe before reading the current state again, so no update can be missed in between
+	progress := redis.SubscribeVersionUploadProgress(ctx, versionID)
+
+	stored, err = getModVersionUploadStatus(mod.ID, versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored == nil {
+		return progress, nil
+	}
+
+	current := &generated.VersionUploadProgress{
+		State: stored.State,
+	}
+
+	if stored.Err != "" {
+		current.Reason = &stored.Err
+	}
+
+	out := make(chan *generated.VersionUploadProgress, 1)
+	out <- current
+
+	go func() {
+		defer close(out)
+
+		for update := range progress {
+			select {
+			case out <- update:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (r *mutationResolver) SignVersionUploadParts(ctx context.Context, modID string, versionID string, size int64, parts int) (*generated.PresignedVersionUpload, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "signVersionUploadParts")
+	defer wrapper.end()
+
+	if err := validation.CheckUploadPartCount(parts); err != nil {
+		return nil, err
+	}
+
+	mod := postgres.GetModByID(newCtx, modID)
+
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	if !mod.Approved {
+		return nil, errors.New("mod is not validated")
+	}
+
+	if mod.Archived {
+		return nil, errors.New("archived mods cannot receive new versions")
+	}
+
+	if mod.ID == mod.ModReference {
+		return nil, errors.New("you must update your mod reference on the site to match your mod_reference in your data.json")
+	}
+
+	if size < int64(parts) {
+		return nil, errors.New("every part must hold at least one byte")
+	}
+
+	if err := validation.CheckUploadPartSize(size, parts); err != nil {
+		return nil, err
+	}
+
+	if err := validation.CheckArchiveSize(size); err != nil {
+		return nil, err
+	}
+
+	if err := validation.CheckModQuota(newCtx, mod, size); err != nil {
+		return nil, err
+	}
+
+	urls, expiresAt, err := storage.SignUploadMultipartModParts(ctx, mod.ID, mod.Name, versionID, size, parts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.PresignedVersionUpload{
+		PartUrls:  urls,
+		ExpiresAt: expiresAt.Format(time.RFC3339Nano),
+	}, nil
+}
+
 func (r *mutationResolver) FinalizeCreateVersion(ctx context.Context, modID string, versionID string, version generated.NewVersion) (bool, error) {
 	wrapper, newCtx := WrapMutationTrace(ctx, "finalizeCreateVersion")
 	defer wrapper.end()
@@ -94,57 +304,175 @@ func (r *mutationResolver) FinalizeCreateVersion(ctx context.Context, modID stri
 		return false, errors.New("mod is not validated")
 	}
 
+	if mod.Archived {
+		return false, errors.New("archived mods cannot receive new versions")
+	}
+
 	if mod.ID == mod.ModReference {
 		return false, errors.New("you must update your mod reference on the site to match your mod_reference in your data.json")
 	}
 
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	if err := redis.CheckUserRateLimit(user.ID, "finalize_version"); err != nil {
+		return false, err
+	}
+
 	log.Info().Str("mod_id", mod.ID).Str("version_id", versionID).Msg("finalization gql call")
 
-	go func(ctx context.Context, mod *postgres.Mod, versionID string, version generated.NewVersion) {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Error().Interface("recover", r).Str("stack", string(debug.Stack())).Msgf("recovered from version finalization")
+	if err := redis.StoreVersionUploadState(mod.ID, versionID, generated.VersionUploadStateQueued, nil, nil); err != nil {
+		return false, errors.Wrap(err, "failed to store version upload state")
+	}
 
-				if err := redis.StoreVersionUploadState(versionID, nil, errors.New("internal error, please try again, if it fails again, please report on discord")); err != nil {
-					log.Error().Err(err).Msg("failed to store version upload state")
-				}
-			}
-		}()
+	draft := version.Draft != nil && *version.Draft
+	replaceUnapproved := version.ReplaceUnapproved != nil && *version.ReplaceUnapproved
 
-		log.Info().Str("mod_id", mod.ID).Str("version_id", versionID).Msg("calling FinalizeVersionUploadAsync")
+	if err := jobs.SubmitJobFinalizeVersionUploadTask(util.ReWrapCtx(ctx), mod.ID, versionID, version.Changelog, string(version.Stability), draft, replaceUnapproved); err != nil {
+		return false, errors.Wrap(err, "failed to queue version finalization")
+	}
 
-		data, err := FinalizeVersionUploadAsync(ctx, mod, versionID, version)
-		if err2 := redis.StoreVersionUploadState(versionID, data, err); err2 != nil {
-			log.Err(err2).Msg("error storing redis state")
-			return
-		}
+	return true, nil
+}
 
-		log.Info().Str("mod_id", mod.ID).Str("version_id", versionID).Msg("finished FinalizeVersionUploadAsync")
+func (r *mutationResolver) ValidateVersionUpload(ctx context.Context, modID string, file graphql.Upload) (*generated.VersionValidationResult, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "validateVersionUpload")
+	defer wrapper.end()
 
-		if err != nil {
-			log.Err(err).Msgf("error completing version upload [%s]", versionID)
-		} else {
-			log.Info().Msgf("completed version upload: %s", versionID)
+	mod := postgres.GetModByID(newCtx, modID)
+
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	fileData, fileSize, cleanup, err := validation.BufferArchive(file.File)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading mod file")
+	}
+	defer cleanup()
+
+	result := &generated.VersionValidationResult{
+		Targets:          []string{},
+		Dependencies:     []*generated.ValidatedDependency{},
+		Failures:         []*generated.ValidationFailure{},
+		DependencyErrors: []*generated.DependencyError{},
+	}
+
+	addFailure := func(err error) {
+		var validationError *validation.ValidationError
+		var dependencyError *validation.DependencyValidationError
+		switch {
+		case errors.As(err, &validationError):
+			for _, failure := range validationError.Failures {
+				rule := failure.Rule
+				result.Failures = append(result.Failures, &generated.ValidationFailure{
+					Rule:    &rule,
+					Message: failure.Message,
+				})
+			}
+		case errors.As(err, &dependencyError):
+			result.DependencyErrors = dependencyError.Errors
+			result.Failures = append(result.Failures, &generated.ValidationFailure{Message: err.Error()})
+		default:
+			result.Failures = append(result.Failures, &generated.ValidationFailure{Message: err.Error()})
 		}
-	}(util.ReWrapCtx(ctx), mod, versionID, version)
+	}
 
-	return true, nil
+	if err := validation.CheckModQuota(newCtx, mod, fileSize); err != nil {
+		addFailure(err)
+	}
+
+	normalized, err := validation.NormalizeArchive(fileData, fileSize, mod.ModReference)
+	if err != nil {
+		addFailure(err)
+		return result, nil
+	}
+	defer normalized.Close()
+
+	// Metadata extraction stores the extracted assets, so it is not part of the dry run
+	modInfo, err := validation.ExtractModInfo(newCtx, normalized.File, normalized.Size, false, true, mod.ModReference)
+	if err != nil {
+		addFailure(err)
+		return result, nil
+	}
+
+	result.ModReference = &modInfo.ModReference
+	result.Version = &modInfo.Version
+	result.SmlVersion = &modInfo.SMLVersion
+
+	if modInfo.Type == validation.MultiTargetUEPlugin {
+		result.Targets = modInfo.Targets
+	} else {
+		result.Targets = modInfo.LegacyTargets
+	}
+
+	for modReference, condition := range modInfo.Dependencies {
+		result.Dependencies = append(result.Dependencies, &generated.ValidatedDependency{
+			ModReference: modReference,
+			Condition:    condition,
+		})
+	}
+
+	for modReference, condition := range modInfo.OptionalDependencies {
+		result.Dependencies = append(result.Dependencies, &generated.ValidatedDependency{
+			ModReference: modReference,
+			Condition:    condition,
+			Optional:     true,
+		})
+	}
+
+	sort.Slice(result.Dependencies, func(a, b int) bool {
+		return result.Dependencies[a].ModReference < result.Dependencies[b].ModReference
+	})
+
+	if err := checkModInfo(newCtx, mod, modInfo); err != nil {
+		addFailure(err)
+	}
+
+	if existing := postgres.GetConflictingVersion(newCtx, mod.ID, modInfo.Version); existing != nil {
+		addFailure(&postgres.VersionAlreadyExistsError{
+			VersionID: existing.ID,
+			Version:   existing.Version,
+		})
+	}
+
+	result.Valid = len(result.Failures) == 0
+
+	return result, nil
 }
 
 func (r *mutationResolver) UpdateVersion(ctx context.Context, versionID string, version generated.UpdateVersion) (*generated.Version, error) {
 	wrapper, newCtx := WrapMutationTrace(ctx, "updateVersion")
 	defer wrapper.end()
 
+	expectedUpdatedAt, err := parseExpectedUpdatedAt(version.ExpectedUpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
 	dbVersion := postgres.GetVersion(newCtx, versionID)
 
 	if dbVersion == nil {
 		return nil, errors.New("version not found")
 	}
 
-	SetStringINNOE(version.Changelog, &dbVersion.Changelog)
+	before := diff.Snapshot(dbVersion)
+
+	if version.Changelog != nil && *version.Changelog != "" {
+		dbVersion.Changelog = *version.Changelog
+		dbVersion.ChangelogSource = string(generated.ChangelogSourceUser)
+	}
 	SetStabilityINN(version.Stability, &dbVersion.Stability)
 
-	postgres.Save(newCtx, &dbVersion)
+	// The version may be cached, only the database knows whether it changed since the client loaded it
+	if expectedUpdatedAt != nil {
+		if err := postgres.SaveVersionIfUnchanged(newCtx, dbVersion, *expectedUpdatedAt); err != nil {
+			return nil, err
+		}
+	} else if err := postgres.SaveVersion(newCtx, dbVersion); err != nil {
+		return nil, err
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditChanges(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionVersionEdited, "version", dbVersion.ID, nil), before, diff.Snapshot(dbVersion))
 
 	return DBVersionToGenerated(dbVersion), nil
 }
@@ -159,7 +487,191 @@ func (r *mutationResolver) DeleteVersion(ctx context.Context, versionID string)
 		return false, errors.New("version not found")
 	}
 
-	postgres.Delete(newCtx, &dbVersion)
+	if err := postgres.DeleteVersion(newCtx, dbVersion.ID); err != nil {
+		return false, err
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditLog(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionVersionDeleted, "version", dbVersion.ID, nil))
+
+	return true, nil
+}
+
+func (r *mutationResolver) RestoreVersion(ctx context.Context, versionID string) (*generated.Version, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "restoreVersion")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	dbVersion := postgres.GetDeletedVersion(newCtx, versionID)
+
+	if dbVersion == nil {
+		return nil, errors.New("version not found")
+	}
+
+	if !user.Has(newCtx, auth.RoleEditAnyContent) && !user.Has(newCtx, auth.RoleApproveVersions) {
+		if !postgres.UserCanUploadModVersions(newCtx, user, dbVersion.ModID) {
+			return nil, errors.New("user not authorized to perform this action")
+		}
+
+		if dbVersion.Denied {
+			return nil, errors.New("denied versions can only be restored by moderators")
+		}
+
+		grace := time.Duration(viper.GetInt("retention.restore_days")) * time.Hour * 24
+		if dbVersion.DeletedAt.Time.Add(grace).Before(time.Now()) {
+			return nil, errors.New("the version was deleted too long ago to be restored, please contact the moderators")
+		}
+	}
+
+	if postgres.GetModByID(newCtx, dbVersion.ModID) == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	if existing := postgres.GetConflictingVersion(newCtx, dbVersion.ModID, dbVersion.Version); existing != nil {
+		return nil, &postgres.VersionAlreadyExistsError{
+			VersionID: existing.ID,
+			Version:   existing.Version,
+		}
+	}
+
+	postgres.RestoreVersion(newCtx, dbVersion)
+
+	return DBVersionToGenerated(postgres.GetVersion(newCtx, dbVersion.ID)), nil
+}
+
+func (r *mutationResolver) PublishVersion(ctx context.Context, versionID string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "publishVersion")
+	defer wrapper.end()
+
+	dbVersion := postgres.GetVersion(newCtx, versionID)
+
+	if dbVersion == nil {
+		return false, errors.New("version not found")
+	}
+
+	if !dbVersion.Draft {
+		return false, errors.New("version is already published")
+	}
+
+	dbVersion.Draft = false
+
+	if err := postgres.SaveVersion(newCtx, dbVersion); err != nil {
+		return false, err
+	}
+
+	QuarantineVersion(newCtx, dbVersion)
+
+	// Drafts are never approved, so they always go through the regular scan before becoming public
+	jobs.SubmitJobScanModOnVirusTotalTask(newCtx, dbVersion.ModID, dbVersion.ID, "", dbVersion.ContentFlags == nil)
+
+	return true, nil
+}
+
+func (r *mutationResolver) CreateDownloadToken(ctx context.Context, versionID string, target *generated.TargetName) (*generated.DownloadToken, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "createDownloadToken")
+	defer wrapper.end()
+
+	dbVersion := postgres.GetVersion(newCtx, versionID)
+
+	if dbVersion == nil {
+		return nil, errors.New("version not found")
+	}
+
+	if dbVersion.Quarantined {
+		return nil, errors.New("version is quarantined pending virus scan")
+	}
+
+	download := redis.DownloadToken{
+		VersionID: versionID,
+		IP:        RealIP(ctx),
+	}
+
+	if target != nil {
+		if postgres.GetVersionTarget(newCtx, versionID, string(*target)) == nil {
+			return nil, errors.New("target not found")
+		}
+
+		download.Target = string(*target)
+	}
+
+	// Logged in users are optional, their downloads are attributed to them
+	header := ctx.Value(util.ContextHeader{}).(http.Header)
+	if authorization := header.Get("Authorization"); authorization != "" {
+		if user := postgres.GetUserByToken(newCtx, authorization); user != nil {
+			download.UserID = user.ID
+		}
+	}
+
+	token, expiresAt, err := redis.CreateDownloadToken(download)
+	if err != nil {
+		return nil, err
+	}
+
+	return &generated.DownloadToken{
+		Token:     token,
+		Path:      nodes.APIV1.Prefix() + "/download/" + token,
+		ExpiresAt: expiresAt.Format(time.RFC3339Nano),
+	}, nil
+}
+
+func (r *mutationResolver) RegisterVersionDownload(ctx context.Context, versionID string, target *generated.TargetName, installID string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "registerVersionDownload")
+	defer wrapper.end()
+
+	if installID == "" || len(installID) > 128 {
+		return false, errors.New("install id must be between 1 and 128 characters")
+	}
+
+	// Install ids are chosen by the client, so addresses are limited in how many they can register
+	if err := redis.CheckUserRateLimit("ip:"+RealIP(ctx), "register_download"); err != nil {
+		return false, err
+	}
+
+	dbVersion := postgres.GetVersion(newCtx, versionID)
+
+	if dbVersion == nil {
+		return false, errors.New("version not found")
+	}
+
+	targetName := ""
+	if target != nil {
+		if postgres.GetVersionTarget(newCtx, versionID, string(*target)) == nil {
+			return false, errors.New("target not found")
+		}
+
+		targetName = string(*target)
+	}
+
+	if !redis.CanIncrement(installID, "register_download", "version:"+versionID, viper.GetDuration("downloads.registration_window")) {
+		return true, nil
+	}
+
+	postgres.IncrementVersionDownloads(newCtx, dbVersion, targetName)
+
+	if user := requestUser(ctx); user != nil {
+		postgres.RecordUserDownload(newCtx, user.ID, dbVersion.ModID)
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) RevalidateVersions(ctx context.Context, modID *string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "revalidateVersions")
+	defer wrapper.end()
+
+	var modFilter func(postgres.Mod) bool
+	if modID != nil {
+		if postgres.GetModByID(newCtx, *modID) == nil {
+			return false, errors.New("mod not found")
+		}
+
+		modFilter = func(mod postgres.Mod) bool {
+			return mod.ID == *modID
+		}
+	}
+
+	go utils.ReindexAllModFiles(util.ReWrapCtx(ctx), true, modFilter, nil)
 
 	return true, nil
 }
@@ -174,14 +686,30 @@ func (r *mutationResolver) ApproveVersion(ctx context.Context, versionID string)
 		return false, errors.New("version not found")
 	}
 
+	if dbVersion.Draft {
+		return false, errors.New("version is a draft and has not been published yet")
+	}
+
+	// Manual approval overrides a pending or failed virus scan
+	ReleaseVersionFromQuarantine(newCtx, dbVersion)
+
 	dbVersion.Approved = true
 
-	postgres.Save(newCtx, &dbVersion)
+	if err := postgres.SaveVersion(newCtx, dbVersion); err != nil {
+		return false, err
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditLog(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionVersionApproved, "version", dbVersion.ID, nil))
 
 	mod := postgres.GetModByID(newCtx, dbVersion.ModID)
 	now := time.Now()
 	mod.LastVersionDate = &now
-	postgres.Save(newCtx, &mod)
+	if err := postgres.SaveMod(newCtx, mod); err != nil {
+		return false, err
+	}
+
+	PublishVersionApproved(newCtx, dbVersion, mod)
 
 	go integrations.NewVersion(util.ReWrapCtx(ctx), dbVersion)
 
@@ -200,21 +728,197 @@ func (r *mutationResolver) DenyVersion(ctx context.Context, versionID string) (b
 
 	dbVersion.Denied = true
 
-	postgres.Save(newCtx, &dbVersion)
-	postgres.Delete(newCtx, &dbVersion)
+	if err := postgres.SaveVersion(newCtx, dbVersion); err != nil {
+		return false, err
+	}
+
+	if err := postgres.DeleteVersion(newCtx, dbVersion.ID); err != nil {
+		return false, err
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	postgres.RecordAuditLog(newCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionVersionDenied, "version", dbVersion.ID, nil))
 
 	mod := postgres.GetModByID(newCtx, dbVersion.ModID)
-	postgres.Save(newCtx, &mod)
+	if mod != nil {
+		if err := postgres.SaveMod(newCtx, mod); err != nil {
+			return false, err
+		}
+	}
+
+	notifyVersionDenied(newCtx, dbVersion, mod, nil)
+
+	return true, nil
+}
+
+func (r *mutationResolver) ApproveVersions(ctx context.Context, versionIds []string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "approveVersions")
+	defer wrapper.end()
+
+	dbVersions, err := getBulkModerationVersions(newCtx, versionIds)
+	if err != nil {
+		return false, err
+	}
+
+	for _, dbVersion := range dbVersions {
+		if dbVersion.Draft {
+			return false, errors.New("version " + dbVersion.ID + " is a draft and has not been published yet")
+		}
+	}
+
+	// Manual approval overrides a pending or failed virus scan
+	for _, dbVersion := range dbVersions {
+		ReleaseVersionFromQuarantine(newCtx, dbVersion)
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	tx := postgres.DBCtx(newCtx).Begin()
+	txCtx := postgres.ContextWithDB(newCtx, tx)
+	defer tx.Rollback()
+
+	now := time.Now()
+	mods := make(map[string]*postgres.Mod)
+	for _, dbVersion := range dbVersions {
+		dbVersion.Approved = true
+		if err := postgres.SaveVersion(txCtx, dbVersion); err != nil {
+			return false, err
+		}
+
+		if _, ok := mods[dbVersion.ModID]; !ok {
+			mod := postgres.GetModByID(txCtx, dbVersion.ModID)
+			if mod == nil {
+				return false, errors.New("mod of version " + dbVersion.ID + " not found")
+			}
+
+			mod.LastVersionDate = &now
+			if err := postgres.SaveMod(txCtx, mod); err != nil {
+				return false, err
+			}
+			mods[dbVersion.ModID] = mod
+		}
+
+		postgres.RecordAuditLog(txCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionVersionApproved, "version", dbVersion.ID, nil))
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return false, errors.Wrap(err, "failed to approve versions")
+	}
+
+	for _, dbVersion := range dbVersions {
+		PublishVersionApproved(newCtx, dbVersion, mods[dbVersion.ModID])
+
+		go integrations.NewVersion(util.ReWrapCtx(ctx), dbVersion)
+	}
 
 	return true, nil
 }
 
+func (r *mutationResolver) DenyVersions(ctx context.Context, versionIds []string, reason *string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "denyVersions")
+	defer wrapper.end()
+
+	dbVersions, err := getBulkModerationVersions(newCtx, versionIds)
+	if err != nil {
+		return false, err
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	tx := postgres.DBCtx(newCtx).Begin()
+	txCtx := postgres.ContextWithDB(newCtx, tx)
+	defer tx.Rollback()
+
+	for _, dbVersion := range dbVersions {
+		dbVersion.Denied = true
+
+		if err := postgres.SaveVersion(txCtx, dbVersion); err != nil {
+			return false, err
+		}
+
+		if err := postgres.DeleteVersion(txCtx, dbVersion.ID); err != nil {
+			return false, err
+		}
+
+		postgres.RecordAuditLog(txCtx, postgres.NewAuditLog(user.ID, postgres.AuditActionVersionDenied, "version", dbVersion.ID, map[string]interface{}{
+			"reason": reason,
+		}))
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return false, errors.Wrap(err, "failed to deny versions")
+	}
+
+	for _, dbVersion := range dbVersions {
+		notifyVersionDenied(newCtx, dbVersion, postgres.GetModByID(newCtx, dbVersion.ModID), reason)
+	}
+
+	return true, nil
+}
+
+// getBulkModerationVersions returns the versions to moderate at once, failing if any of them does not exist
+func getBulkModerationVersions(ctx context.Context, versionIDs []string) ([]*postgres.Version, error) {
+	if len(versionIDs) > maxBulkModeration {
+		return nil, fmt.Errorf("at most %d versions can be moderated at once", maxBulkModeration)
+	}
+
+	dbVersions := make([]*postgres.Version, 0, len(versionIDs))
+	seen := make(map[string]bool)
+	for _, versionID := range versionIDs {
+		if seen[versionID] {
+			continue
+		}
+		seen[versionID] = true
+
+		dbVersion := postgres.GetVersion(ctx, versionID)
+		if dbVersion == nil {
+			return nil, errors.New("version " + versionID + " not found")
+		}
+
+		dbVersions = append(dbVersions, dbVersion)
+	}
+
+	return dbVersions, nil
+}
+
 func (r *queryResolver) GetVersion(ctx context.Context, versionID string) (*generated.Version, error) {
 	wrapper, newCtx := WrapQueryTrace(ctx, "getVersion")
 	defer wrapper.end()
 	return DBVersionToGenerated(postgres.GetVersion(newCtx, versionID)), nil
 }
 
+func (r *queryResolver) GetChangelogFeed(ctx context.Context, modIds []string, since *string, limit *int) ([]*generated.Version, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getChangelogFeed")
+	defer wrapper.end()
+
+	count, _, err := pageBounds(limit, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(modIds) > 100 {
+		return nil, errors.New("at most 100 mods can be requested")
+	}
+
+	var sinceTime *time.Time
+	if since != nil {
+		parsed, err := parseDay(*since)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid since")
+		}
+		sinceTime = &parsed
+	}
+
+	versions := postgres.GetChangelogFeed(newCtx, modIds, sinceTime, count)
+
+	converted := make([]*generated.Version, len(versions))
+	for k, v := range versions {
+		converted[k] = DBVersionToGenerated(&v)
+	}
+
+	return converted, nil
+}
+
 func (r *queryResolver) GetVersions(ctx context.Context, _ map[string]interface{}) (*generated.GetVersions, error) {
 	wrapper, _ := WrapQueryTrace(ctx, "getVersions")
 	defer wrapper.end()
@@ -257,7 +961,263 @@ func (r *queryResolver) CheckVersionUploadState(ctx context.Context, modID strin
 		return nil, errors.New("you must update your mod reference on the site to match your mod_reference in your data.json")
 	}
 
-	return redis.GetVersionUploadState(versionID)
+	status, err := getModVersionUploadStatus(mod.ID, versionID)
+	if err != nil || status == nil {
+		return nil, err
+	}
+
+	if status.Err != "" {
+		return status.Data, &gqlerror.Error{
+			Message:    status.Err,
+			Path:       graphql.GetPath(ctx),
+			Extensions: status.ErrExtensions,
+		}
+	}
+
+	return status.Data, nil
+}
+
+func (r *queryResolver) GetVersionUploadStatus(ctx context.Context, modID string, versionID string) (*generated.VersionUploadStatus, error) {
+	wrapper, _ := WrapQueryTrace(ctx, "getVersionUploadStatus")
+	defer wrapper.end()
+
+	status, err := getModVersionUploadStatus(modID, versionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get version upload status")
+	}
+
+	if status == nil {
+		return nil, nil
+	}
+
+	result := &generated.VersionUploadStatus{
+		State:            status.State,
+		Result:           status.Data,
+		DependencyErrors: status.DependencyErrors,
+	}
+
+	if status.Err != "" {
+		result.Reason = &status.Err
+	}
+
+	if code, ok := status.ErrExtensions["code"].(string); ok {
+		result.ReasonCode = &code
+	}
+
+	return result, nil
+}
+
+func (r *queryResolver) GetModUploadQuota(ctx context.Context, modID string) (*generated.UploadQuota, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getModUploadQuota")
+	defer wrapper.end()
+
+	mod := postgres.GetModByID(newCtx, modID)
+
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	quota := validation.GetModQuota(newCtx, mod)
+
+	result := &generated.UploadQuota{
+		Tier:           quota.Tier,
+		ModStorageUsed: int(postgres.GetModStorageUsage(newCtx, mod.ID)),
+	}
+
+	if quota.MaxFileSize > 0 {
+		maxFileSize := int(quota.MaxFileSize)
+		result.MaxFileSize = &maxFileSize
+	}
+
+	if quota.MaxModStorage > 0 {
+		maxModStorage := int(quota.MaxModStorage)
+		result.MaxModStorage = &maxModStorage
+	}
+
+	return result, nil
+}
+
+func (r *queryResolver) GetVersionUploadedParts(ctx context.Context, modID string, versionID string) ([]int, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getVersionUploadedParts")
+	defer wrapper.end()
+
+	mod := postgres.GetModByID(newCtx, modID)
+
+	if mod == nil {
+		return nil, errors.New("mod not found")
+	}
+
+	parts, err := storage.ListUploadedMultipartModParts(newCtx, mod.ID, mod.Name, versionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list uploaded parts")
+	}
+
+	return parts, nil
+}
+
+func (r *queryResolver) CompareVersions(ctx context.Context, modID string, fromVersion string, toVersion string) (*generated.VersionComparison, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "compareVersions")
+	defer wrapper.end()
+
+	from := postgres.GetModVersionByName(newCtx, modID, fromVersion)
+	if from == nil || !from.Approved {
+		return nil, errors.New("version " + fromVersion + " not found")
+	}
+
+	to := postgres.GetModVersionByName(newCtx, modID, toVersion)
+	if to == nil || !to.Approved {
+		return nil, errors.New("version " + toVersion + " not found")
+	}
+
+	fromSemver, err := semver.NewVersion(from.Version)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid version "+from.Version)
+	}
+
+	toSemver, err := semver.NewVersion(to.Version)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid version "+to.Version)
+	}
+
+	lowest, highest := fromSemver, toSemver
+	if lowest.GreaterThan(highest) {
+		lowest, highest = highest, lowest
+	}
+
+	type between struct {
+		version *postgres.Version
+		semver  *semver.Version
+	}
+
+	var versions []between
+	for _, version := range postgres.GetApprovedModVersions(newCtx, modID) {
+		version := version
+		parsed, err := semver.NewVersion(version.Version)
+		if err != nil {
+			continue
+		}
+
+		if parsed.GreaterThan(lowest) && !parsed.GreaterThan(highest) {
+			versions = append(versions, between{version: &version, semver: parsed})
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].semver.LessThan(versions[j].semver)
+	})
+
+	converted := make([]*generated.Version, len(versions))
+	for i, version := range versions {
+		converted[i] = DBVersionToGenerated(version.version)
+	}
+
+	comparison := &generated.VersionComparison{
+		From:              DBVersionToGenerated(from),
+		To:                DBVersionToGenerated(to),
+		Versions:          converted,
+		DependencyChanges: compareDependencies(postgres.GetVersionDependencies(newCtx, from.ID), postgres.GetVersionDependencies(newCtx, to.ID)),
+		TargetChanges:     compareTargets(from.Targets, to.Targets),
+	}
+
+	if from.Size != nil && to.Size != nil {
+		sizeDelta := int(*to.Size - *from.Size)
+		comparison.SizeDelta = &sizeDelta
+	}
+
+	return comparison, nil
+}
+
+func compareDependencies(from []postgres.VersionDependency, to []postgres.VersionDependency) []*generated.DependencyChange {
+	previous := make(map[string]postgres.VersionDependency, len(from))
+	for _, dependency := range from {
+		previous[dependency.ModID] = dependency
+	}
+
+	changes := make([]*generated.DependencyChange, 0)
+
+	for _, dependency := range to {
+		dependency := dependency
+		old, ok := previous[dependency.ModID]
+		delete(previous, dependency.ModID)
+
+		if !ok {
+			changes = append(changes, &generated.DependencyChange{
+				ModID:       dependency.ModID,
+				Change:      generated.ChangeTypeAdded,
+				ToCondition: &dependency.Condition,
+				Optional:    dependency.Optional,
+			})
+		} else if old.Condition != dependency.Condition || old.Optional != dependency.Optional {
+			changes = append(changes, &generated.DependencyChange{
+				ModID:         dependency.ModID,
+				Change:        generated.ChangeTypeChanged,
+				FromCondition: &old.Condition,
+				ToCondition:   &dependency.Condition,
+				Optional:      dependency.Optional,
+			})
+		}
+	}
+
+	for _, dependency := range previous {
+		dependency := dependency
+		changes = append(changes, &generated.DependencyChange{
+			ModID:         dependency.ModID,
+			Change:        generated.ChangeTypeRemoved,
+			FromCondition: &dependency.Condition,
+			Optional:      dependency.Optional,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ModID < changes[j].ModID
+	})
+
+	return changes
+}
+
+func compareTargets(from []postgres.VersionTarget, to []postgres.VersionTarget) []*generated.TargetChange {
+	previous := make(map[string]postgres.VersionTarget, len(from))
+	for _, target := range from {
+		previous[target.TargetName] = target
+	}
+
+	changes := make([]*generated.TargetChange, 0)
+
+	for _, target := range to {
+		old, ok := previous[target.TargetName]
+		delete(previous, target.TargetName)
+
+		sizeDelta := int(target.Size - old.Size)
+
+		if !ok {
+			changes = append(changes, &generated.TargetChange{
+				TargetName: generated.TargetName(target.TargetName),
+				Change:     generated.ChangeTypeAdded,
+				SizeDelta:  &sizeDelta,
+			})
+		} else if old.Hash != target.Hash {
+			changes = append(changes, &generated.TargetChange{
+				TargetName: generated.TargetName(target.TargetName),
+				Change:     generated.ChangeTypeChanged,
+				SizeDelta:  &sizeDelta,
+			})
+		}
+	}
+
+	for _, target := range previous {
+		sizeDelta := int(-target.Size)
+		changes = append(changes, &generated.TargetChange{
+			TargetName: generated.TargetName(target.TargetName),
+			Change:     generated.ChangeTypeRemoved,
+			SizeDelta:  &sizeDelta,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].TargetName < changes[j].TargetName
+	})
+
+	return changes
 }
 
 type getVersionsResolver struct{ *Resolver }
@@ -317,6 +1277,32 @@ func (r *getVersionsResolver) Count(ctx context.Context, _ *generated.GetVersion
 	return int(postgres.GetVersionCountNew(newCtx, versionFilter, unapproved)), nil
 }
 
+func (r *getVersionsResolver) PageInfo(ctx context.Context, _ *generated.GetVersions) (*generated.PageInfo, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "GetVersions.page_info")
+	defer wrapper.end()
+
+	resolverContext := graphql.GetFieldContext(ctx)
+	unapproved := resolverContext.Parent.Field.Field.Name == "getUnapprovedVersions"
+
+	versionFilter, err := models.ProcessVersionFilter(resolverContext.Parent.Args["filter"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+
+	if versionFilter.Ids != nil && len(versionFilter.Ids) != 0 {
+		return &generated.PageInfo{}, nil
+	}
+
+	limit := *versionFilter.Limit
+	peek := limit + 1
+	versionFilter.Limit = &peek
+	versionFilter.Fields = nil
+	versionFilter.AddField("id")
+	versionFilter.AddField(string(*versionFilter.OrderBy))
+
+	return pageInfo(postgres.GetVersionsNew(newCtx, versionFilter, unapproved), limit, versionCursor(*versionFilter.OrderBy)), nil
+}
+
 type versionResolver struct{ *Resolver }
 
 func findWindowsTarget(obj *generated.Version) *generated.VersionTarget {
@@ -340,7 +1326,7 @@ func (r *versionResolver) Link(ctx context.Context, obj *generated.Version) (str
 		return link, nil
 	}
 
-	return "/v1/version/" + obj.ID + "/download", nil
+	return nodes.APIV1.Prefix() + "/version/" + obj.ID + "/download", nil
 }
 
 func (r *versionResolver) Mod(ctx context.Context, obj *generated.Version) (*generated.Mod, error) {
@@ -434,7 +1420,20 @@ func (r *versionResolver) Dependencies(ctx context.Context, obj *generated.Versi
 type versionTargetResolver struct{ *Resolver }
 
 func (r *versionTargetResolver) Link(_ context.Context, obj *generated.VersionTarget) (string, error) {
-	return "/v1/version/" + obj.VersionID + "/" + string(obj.TargetName) + "/download", nil
+	return nodes.APIV1.Prefix() + "/version/" + obj.VersionID + "/" + string(obj.TargetName) + "/download", nil
+}
+
+func (r *versionTargetResolver) PatchFrom(ctx context.Context, obj *generated.VersionTarget, versionID string) (*generated.VersionTargetPatch, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "VersionTarget.patchFrom")
+	defer wrapper.end()
+
+	return DBVersionTargetPatchToGenerated(postgres.GetVersionTargetPatch(newCtx, obj.VersionID, versionID, string(obj.TargetName))), nil
+}
+
+type versionTargetPatchResolver struct{ *Resolver }
+
+func (r *versionTargetPatchResolver) Link(_ context.Context, obj *generated.VersionTargetPatch) (string, error) {
+	return nodes.APIV1.Prefix() + "/version/" + obj.VersionID + "/" + string(obj.TargetName) + "/patch/" + obj.FromVersionID, nil
 }
 
 type getMyVersionsResolver struct{ *Resolver }
@@ -493,3 +1492,29 @@ func (r *getMyVersionsResolver) Count(ctx context.Context, _ *generated.GetMyVer
 
 	return int(postgres.GetVersionCountNew(newCtx, versionFilter, unapproved)), nil
 }
+
+func (r *getMyVersionsResolver) PageInfo(ctx context.Context, _ *generated.GetMyVersions) (*generated.PageInfo, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "GetMyVersions.page_info")
+	defer wrapper.end()
+
+	resolverContext := graphql.GetFieldContext(ctx)
+	unapproved := resolverContext.Parent.Field.Field.Name == "getMyUnapprovedVersions"
+
+	versionFilter, err := models.ProcessVersionFilter(resolverContext.Parent.Args["filter"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+
+	if versionFilter.Ids != nil && len(versionFilter.Ids) != 0 {
+		return &generated.PageInfo{}, nil
+	}
+
+	limit := *versionFilter.Limit
+	peek := limit + 1
+	versionFilter.Limit = &peek
+	versionFilter.Fields = nil
+	versionFilter.AddField("id")
+	versionFilter.AddField(string(*versionFilter.OrderBy))
+
+	return pageInfo(postgres.GetVersionsNew(newCtx, versionFilter, unapproved), limit, versionCursor(*versionFilter.OrderBy)), nil
+}
@@ -0,0 +1,136 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/integrations"
+)
+
+func (r *mutationResolver) CreateWebhook(ctx context.Context, webhook generated.NewWebhook) (*generated.Webhook, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "createWebhook")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	if postgres.GetUserWebhookCount(newCtx, user.ID) >= viper.GetInt64("webhooks.max_per_user") {
+		return nil, errors.Errorf("users can have at most %d webhooks", viper.GetInt64("webhooks.max_per_user"))
+	}
+
+	dbWebhook := &postgres.Webhook{
+		UserID: user.ID,
+		URL:    webhook.URL,
+		Events: webhookEventsFromInput(webhook.Events),
+		ModIDs: webhook.ModIds,
+	}
+
+	if err := integrations.PrepareWebhook(newCtx, user, dbWebhook); err != nil {
+		return nil, err
+	}
+
+	resultWebhook, err := postgres.CreateWebhook(newCtx, dbWebhook)
+	if err != nil {
+		return nil, err
+	}
+
+	return DBWebhookToGenerated(resultWebhook), nil
+}
+
+func (r *mutationResolver) UpdateWebhook(ctx context.Context, webhookID string, webhook generated.UpdateWebhook) (*generated.Webhook, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "updateWebhook")
+	defer wrapper.end()
+
+	dbWebhook := postgres.GetWebhookByID(newCtx, webhookID)
+
+	if dbWebhook == nil {
+		return nil, errors.New("webhook not found")
+	}
+
+	SetStringINNOE(webhook.URL, &dbWebhook.URL)
+
+	if webhook.Events != nil {
+		dbWebhook.Events = webhookEventsFromInput(webhook.Events)
+	}
+
+	if webhook.ModIds != nil {
+		dbWebhook.ModIDs = webhook.ModIds
+	}
+
+	if webhook.Active != nil {
+		if *webhook.Active && !dbWebhook.Active {
+			dbWebhook.FailureCount = 0
+		}
+
+		dbWebhook.Active = *webhook.Active
+	}
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+	if err := integrations.PrepareWebhook(newCtx, user, dbWebhook); err != nil {
+		return nil, err
+	}
+
+	postgres.Save(newCtx, &dbWebhook)
+
+	return DBWebhookToGenerated(postgres.GetWebhookByID(newCtx, webhookID)), nil
+}
+
+func (r *mutationResolver) DeleteWebhook(ctx context.Context, webhookID string) (bool, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "deleteWebhook")
+	defer wrapper.end()
+
+	dbWebhook := postgres.GetWebhookByID(newCtx, webhookID)
+
+	if dbWebhook == nil {
+		return false, errors.New("webhook not found")
+	}
+
+	postgres.Delete(newCtx, &dbWebhook)
+
+	return true, nil
+}
+
+func (r *mutationResolver) ResetWebhookSecret(ctx context.Context, webhookID string) (*generated.Webhook, error) {
+	wrapper, newCtx := WrapMutationTrace(ctx, "resetWebhookSecret")
+	defer wrapper.end()
+
+	dbWebhook := postgres.GetWebhookByID(newCtx, webhookID)
+
+	if dbWebhook == nil {
+		return nil, errors.New("webhook not found")
+	}
+
+	if err := postgres.ResetWebhookSecret(newCtx, dbWebhook); err != nil {
+		return nil, err
+	}
+
+	return DBWebhookToGenerated(postgres.GetWebhookByID(newCtx, webhookID)), nil
+}
+
+func (r *queryResolver) GetMyWebhooks(ctx context.Context) ([]*generated.Webhook, error) {
+	wrapper, newCtx := WrapQueryTrace(ctx, "getMyWebhooks")
+	defer wrapper.end()
+
+	user := ctx.Value(postgres.UserKey{}).(*postgres.User)
+
+	webhooks := postgres.GetUserWebhooks(newCtx, user.ID)
+
+	converted := make([]*generated.Webhook, len(webhooks))
+	for i, webhook := range webhooks {
+		webhook := webhook
+		converted[i] = DBWebhookToGenerated(&webhook)
+	}
+
+	return converted, nil
+}
+
+func webhookEventsFromInput(events []generated.WebhookEvent) []string {
+	converted := make([]string, len(events))
+	for i, event := range events {
+		converted[i] = string(event)
+	}
+	return converted
+}
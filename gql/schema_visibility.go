@@ -0,0 +1,146 @@
+package gql
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/introspection"
+	"github.com/spf13/viper"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/satisfactorymodding/smr-api/auth"
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// Directives which restrict a field to the users having a role
+var roleDirectives = map[string]*auth.Role{
+	"canApproveMods":           auth.RoleApproveMods,
+	"canApproveVersions":       auth.RoleApproveVersions,
+	"canEditUsers":             auth.RoleEditUsers,
+	"canEditSMLVersions":       auth.RoleEditSMLVersions,
+	"canEditBootstrapVersions": auth.RoleEditBootstrapVersions,
+	"canEditAnnouncements":     auth.RoleEditAnnouncements,
+	"canManageTags":            auth.RoleManageTags,
+	"canViewFieldUsage":        auth.RoleViewFieldUsage,
+	"canViewAuditLogs":         auth.RoleViewAuditLogs,
+}
+
+// SchemaVisibility enables introspection, unless it is disabled for anonymous users by graphql.introspection.anonymous.
+//
+// Fields restricted to a role by their directives are hidden from the introspection of users not having the role.
+type SchemaVisibility struct {
+	restricted map[string][]*auth.Role
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.FieldInterceptor
+	graphql.HandlerExtension
+} = &SchemaVisibility{}
+
+func (SchemaVisibility) ExtensionName() string {
+	return "SchemaVisibility"
+}
+
+func (s *SchemaVisibility) Validate(schema graphql.ExecutableSchema) error {
+	s.restricted = restrictedFields(schema.Schema())
+	return nil
+}
+
+func (s *SchemaVisibility) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	rc.DisableIntrospection = !viper.GetBool("graphql.introspection.anonymous") && requestUser(ctx) == nil
+	return nil
+}
+
+func (s *SchemaVisibility) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	res, err := next(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Object != "__Type" || fc.Field.Name != "fields" || fc.Parent == nil {
+		return res, err
+	}
+
+	fields, ok := res.([]introspection.Field)
+	if !ok {
+		return res, err
+	}
+
+	parent, ok := fc.Parent.Result.(*introspection.Type)
+	if !ok || parent.Name() == nil {
+		return res, err
+	}
+
+	var user *postgres.User
+	userLoaded := false
+
+	visible := make([]introspection.Field, 0, len(fields))
+	for _, field := range fields {
+		roles, restricted := s.restricted[*parent.Name()+"."+field.Name]
+		if restricted {
+			if !userLoaded {
+				user = requestUser(ctx)
+				userLoaded = true
+			}
+
+			if !hasRoles(ctx, user, roles) {
+				continue
+			}
+		}
+
+		visible = append(visible, field)
+	}
+
+	return visible, nil
+}
+
+// restrictedFields returns the roles required by every field restricted by a role directive
+func restrictedFields(schema *ast.Schema) map[string][]*auth.Role {
+	restricted := make(map[string][]*auth.Role)
+
+	for _, definition := range schema.Types {
+		for _, field := range definition.Fields {
+			for _, directive := range field.Directives {
+				if role, ok := roleDirectives[directive.Name]; ok {
+					coordinate := definition.Name + "." + field.Name
+					restricted[coordinate] = append(restricted[coordinate], role)
+				}
+			}
+		}
+	}
+
+	return restricted
+}
+
+// requestUser returns the user authenticated by the request, whether or not the field requires it
+func requestUser(ctx context.Context) *postgres.User {
+	if user, ok := ctx.Value(postgres.UserKey{}).(*postgres.User); ok && user != nil {
+		return user
+	}
+
+	header, _ := ctx.Value(util.ContextHeader{}).(http.Header)
+	if header == nil || header.Get("Authorization") == "" {
+		return nil
+	}
+
+	return postgres.GetUserByToken(ctx, header.Get("Authorization"))
+}
+
+func hasRoles(ctx context.Context, user *postgres.User, roles []*auth.Role) bool {
+	if user == nil {
+		return false
+	}
+
+	for _, role := range roles {
+		if !user.Has(ctx, role) {
+			return false
+		}
+	}
+
+	return true
+}
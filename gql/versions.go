@@ -1,20 +1,24 @@
 package gql
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"io"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
 
 	"github.com/satisfactorymodding/smr-api/db/postgres"
 	"github.com/satisfactorymodding/smr-api/generated"
 	"github.com/satisfactorymodding/smr-api/integrations"
+	"github.com/satisfactorymodding/smr-api/redis"
 	"github.com/satisfactorymodding/smr-api/redis/jobs"
 	"github.com/satisfactorymodding/smr-api/storage"
 	"github.com/satisfactorymodding/smr-api/util"
+	"github.com/satisfactorymodding/smr-api/util/converter"
 	"github.com/satisfactorymodding/smr-api/validation"
 )
 
@@ -35,219 +39,546 @@ func FinalizeVersionUploadAsync(ctx context.Context, mod *postgres.Mod, versionI
 		return nil, err
 	}
 
-	// TODO Optimize
-	fileData, err := io.ReadAll(modFile)
+	fileData, fileSize, cleanup, err := validation.BufferArchive(modFile)
+	modFile.Close()
 	if err != nil {
 		storage.DeleteMod(ctx, mod.ID, mod.Name, versionID)
 		return nil, errors.Wrap(err, "failed reading mod file")
 	}
+	defer cleanup()
 
-	modInfo, err := validation.ExtractModInfo(ctx, fileData, true, true, mod.ModReference)
-	if err != nil {
+	// Pre-signed parts are capped at the size declared when signing, this catches quota used up by other uploads since
+	if err := validation.CheckModQuota(ctx, mod, fileSize); err != nil {
 		storage.DeleteMod(ctx, mod.ID, mod.Name, versionID)
-		return nil, errors.Wrap(err, "failed extracting mod info")
+		return nil, err
 	}
 
-	if modInfo.ModReference != mod.ModReference {
-		storage.DeleteMod(ctx, mod.ID, mod.Name, versionID)
-		return nil, errors.New("data.json mod_reference does not match mod reference")
-	}
+	setUploadStage(versionID, generated.VersionUploadStateValidating)
 
-	if modInfo.Type == validation.DataJSON {
+	normalized, err := validation.NormalizeArchive(fileData, fileSize, mod.ModReference)
+	if err != nil {
 		storage.DeleteMod(ctx, mod.ID, mod.Name, versionID)
-		return nil, errors.New("data.json mods are obsolete and not allowed")
+		return nil, err
 	}
+	defer normalized.Close()
 
-	if modInfo.Type == validation.MultiTargetUEPlugin && !util.FlagEnabled(util.FeatureFlagAllowMultiTargetUpload) {
-		storage.DeleteMod(ctx, mod.ID, mod.Name, versionID)
-		return nil, errors.New("multi-target mods are not allowed")
-	}
-
-	versionMajor := int(modInfo.Semver.Major())
-	versionMinor := int(modInfo.Semver.Minor())
-	versionPatch := int(modInfo.Semver.Patch())
-
-	dbVersion := &postgres.Version{
-		Version:      modInfo.Version,
-		SMLVersion:   modInfo.SMLVersion,
-		Changelog:    version.Changelog,
-		ModID:        mod.ID,
-		Stability:    string(version.Stability),
-		ModReference: &modInfo.ModReference,
-		Size:         &modInfo.Size,
-		Hash:         &modInfo.Hash,
-		VersionMajor: &versionMajor,
-		VersionMinor: &versionMinor,
-		VersionPatch: &versionPatch,
-	}
-
-	autoApproved := true
-	for _, obj := range modInfo.Objects {
-		if obj.Type != "pak" {
-			autoApproved = false
-			break
+	if normalized.Changed {
+		l.Info().Str("container", string(normalized.Container)).Msg("Storing normalized archive")
+
+		if !storage.ReplaceMod(ctx, mod.ID, mod.Name, versionID, normalized.Reader()) {
+			storage.DeleteMod(ctx, mod.ID, mod.Name, versionID)
+			return nil, errors.New("failed storing normalized archive")
 		}
 	}
 
-	dbVersion.Approved = autoApproved
-
-	err = postgres.CreateVersion(ctx, dbVersion)
-
+	modInfo, err := validation.ExtractModInfo(ctx, normalized.File, normalized.Size, true, true, mod.ModReference)
 	if err != nil {
 		storage.DeleteMod(ctx, mod.ID, mod.Name, versionID)
-		return nil, err
+		return nil, errors.Wrap(err, "failed extracting mod info")
 	}
 
-	for modID, condition := range modInfo.Dependencies {
-		dependency := postgres.VersionDependency{
-			VersionID: dbVersion.ID,
-			ModID:     modID,
-			Condition: condition,
-			Optional:  false,
-		}
+	if err := checkModInfo(ctx, mod, modInfo); err != nil {
+		storage.DeleteMod(ctx, mod.ID, mod.Name, versionID)
+		return nil, err
+	}
 
-		postgres.Save(ctx, &dependency)
+	replaceable := func(existing *postgres.Version) bool {
+		return version.ReplaceUnapproved != nil && *version.ReplaceUnapproved && !existing.Approved
 	}
 
-	for modID, condition := range modInfo.OptionalDependencies {
-		dependency := postgres.VersionDependency{
-			VersionID: dbVersion.ID,
-			ModID:     modID,
-			Condition: condition,
-			Optional:  true,
+	if existing := postgres.GetConflictingVersion(ctx, mod.ID, modInfo.Version); existing != nil && !replaceable(existing) {
+		storage.DeleteMod(ctx, mod.ID, mod.Name, versionID)
+		return nil, &postgres.VersionAlreadyExistsError{
+			VersionID: existing.ID,
+			Version:   existing.Version,
 		}
-
-		postgres.Save(ctx, &dependency)
 	}
 
-	jsonData, err := json.Marshal(modInfo.Metadata)
-	if err != nil {
-		log.Err(err).Msgf("[%s] failed serializing", dbVersion.ID)
-	} else {
-		metadata := string(jsonData)
-		dbVersion.Metadata = &metadata
-		postgres.Save(ctx, &dbVersion)
-	}
+	// Files are stored before the transaction is opened, so it is not held open during storage I/O.
+	// They are removed by hand if storing the version fails.
+	targets := make([]*postgres.VersionTarget, 0)
 
 	if modInfo.Type == validation.MultiTargetUEPlugin {
-		targets := make([]*postgres.VersionTarget, 0)
-
 		for _, target := range modInfo.Targets {
-			dbVersionTarget := &postgres.VersionTarget{
-				VersionID:  dbVersion.ID,
+			targets = append(targets, &postgres.VersionTarget{
 				TargetName: target,
-			}
+			})
+		}
 
-			postgres.Save(ctx, dbVersionTarget)
+		setUploadStage(versionID, generated.VersionUploadStateSeparating)
 
-			targets = append(targets, dbVersionTarget)
-		}
+		for i, target := range targets {
+			log.Info().Str("target", target.TargetName).Str("mod", mod.Name).Str("version", modInfo.Version).Msg("separating mod")
 
-		separateSuccess := true
-		for _, target := range targets {
-			log.Info().Str("target", target.TargetName).Str("mod", mod.Name).Str("version", dbVersion.Version).Msg("separating mod")
-			success, key, hash, size := storage.SeparateModTarget(ctx, fileData, mod.ID, mod.Name, dbVersion.Version, target.TargetName)
+			targetName := generated.TargetName(target.TargetName)
+			targetIndex := i + 1
+			targetCount := len(targets)
+			redis.PublishVersionUploadProgress(versionID, &generated.VersionUploadProgress{
+				State:       generated.VersionUploadStateSeparating,
+				Target:      &targetName,
+				TargetIndex: &targetIndex,
+				TargetCount: &targetCount,
+			})
+			success, key, hash, size := storage.SeparateModTarget(ctx, normalized.File, normalized.Size, mod.ID, mod.Name, modInfo.Version, target.TargetName)
 
 			if !success {
-				separateSuccess = false
-				break
+				removeModFiles(ctx, mod, versionID, modInfo.Version, targets)
+
+				return nil, errors.New("failed to separate mod")
 			}
 
 			target.Key = key
 			target.Hash = hash
 			target.Size = size
-
-			postgres.Save(ctx, target)
-		}
-
-		if !separateSuccess {
-			removeMod(ctx, modInfo, mod, dbVersion)
-
-			return nil, errors.New("failed to separate mod")
 		}
 	}
 
 	success, key := storage.RenameVersion(ctx, mod.ID, mod.Name, versionID, modInfo.Version)
 
 	if !success {
-		removeMod(ctx, modInfo, mod, dbVersion)
+		removeModFiles(ctx, mod, versionID, modInfo.Version, targets)
 
 		return nil, errors.New("failed to upload mod")
 	}
 
+	// Legacy layouts cannot be separated, so every inferred target points to the whole archive
+	legacyTargets := make([]*postgres.VersionTarget, 0)
 	if modInfo.Type == validation.UEPlugin {
-		dbVersionTarget := &postgres.VersionTarget{
-			VersionID:  dbVersion.ID,
-			TargetName: "Windows",
-			Key:        key,
-			Hash:       *dbVersion.Hash,
-			Size:       *dbVersion.Size,
+		for _, target := range modInfo.LegacyTargets {
+			legacyTargets = append(legacyTargets, &postgres.VersionTarget{
+				TargetName: target,
+				Key:        key,
+				Hash:       modInfo.Hash,
+				Size:       modInfo.Size,
+			})
+		}
+	}
+
+	// Everything stored in the database for the version is rolled back if any step fails
+	var dbVersion *postgres.Version
+	var autoApproved, draft bool
+	var replacedVersionID string
+
+	err = postgres.Tx(ctx, func(txCtx context.Context) error {
+		if existing := postgres.GetConflictingVersion(txCtx, mod.ID, modInfo.Version); existing != nil {
+			if !replaceable(existing) {
+				return &postgres.VersionAlreadyExistsError{
+					VersionID: existing.ID,
+					Version:   existing.Version,
+				}
+			}
+
+			l.Info().Str("replaced_version_id", existing.ID).Msg("Replacing unapproved version")
+			if err := postgres.DeleteVersion(txCtx, existing.ID); err != nil {
+				return err
+			}
+			replacedVersionID = existing.ID
+		}
+
+		versionMajor := int(modInfo.Semver.Major())
+		versionMinor := int(modInfo.Semver.Minor())
+		versionPatch := int(modInfo.Semver.Patch())
+
+		changelog := version.Changelog
+		changelogSource := generated.ChangelogSourceUser
+		if strings.TrimSpace(changelog) == "" && modInfo.Changelog != "" {
+			changelog = modInfo.Changelog
+			changelogSource = modInfo.ChangelogSource
 		}
 
-		postgres.Save(ctx, dbVersionTarget)
+		dbVersion = &postgres.Version{
+			Version:         modInfo.Version,
+			SMLVersion:      modInfo.SMLVersion,
+			Changelog:       changelog,
+			ChangelogSource: string(changelogSource),
+			ModID:           mod.ID,
+			Stability:       string(version.Stability),
+			ModReference:    &modInfo.ModReference,
+			Size:            &modInfo.Size,
+			Hash:            &modInfo.Hash,
+			ContainerType:   string(normalized.Container),
+			VersionMajor:    &versionMajor,
+			VersionMinor:    &versionMinor,
+			VersionPatch:    &versionPatch,
+			LegacyTarget:    modInfo.Type == validation.UEPlugin,
+		}
+
+		autoApproved = true
+		for _, obj := range modInfo.Objects {
+			if obj.Type != "pak" {
+				autoApproved = false
+				break
+			}
+		}
+
+		// Flagged content has to be reviewed by a moderator
+		if len(modInfo.ContentFlags) > 0 {
+			contentFlags, err := json.Marshal(modInfo.ContentFlags)
+			if err != nil {
+				l.Err(err).Msg("failed serializing content flags")
+			} else {
+				flags := string(contentFlags)
+				dbVersion.ContentFlags = &flags
+			}
+
+			autoApproved = false
+		}
+
+		draft = version.Draft != nil && *version.Draft
+
+		dbVersion.Approved = autoApproved && !draft
+		dbVersion.Draft = draft
+
+		if err := postgres.CreateVersion(txCtx, dbVersion); err != nil {
+			return err
+		}
+
+		if err := postgres.SaveVersionDependencies(txCtx, dbVersion.ID, modInfo.Dependencies, modInfo.OptionalDependencies); err != nil {
+			return err
+		}
+
+		jsonData, err := json.Marshal(modInfo.Metadata)
+		if err != nil {
+			log.Err(err).Msgf("[%s] failed serializing", dbVersion.ID)
+		} else {
+			metadata := string(jsonData)
+			dbVersion.Metadata = &metadata
+		}
+
+		for _, target := range targets {
+			target.VersionID = dbVersion.ID
+
+			if storage.IsBlob(target.Key) {
+				postgres.AcquireBlob(txCtx, target.Hash, target.Key, target.Size)
+			}
+		}
+
+		if err := postgres.SaveVersionTargets(txCtx, targets); err != nil {
+			return err
+		}
+
+		for _, target := range legacyTargets {
+			target.VersionID = dbVersion.ID
+		}
+
+		if err := postgres.SaveVersionTargets(txCtx, legacyTargets); err != nil {
+			return err
+		}
+
+		dbVersion.Key = key
+		if err := postgres.SaveVersion(txCtx, dbVersion); err != nil {
+			return err
+		}
+
+		return postgres.SaveMod(txCtx, mod)
+	})
+	if err != nil {
+		storage.DeleteVersionFile(ctx, key)
+		removeModFiles(ctx, mod, versionID, modInfo.Version, targets)
+
+		return nil, err
 	}
 
-	dbVersion.Key = key
-	postgres.Save(ctx, &dbVersion)
-	postgres.Save(ctx, &mod)
+	// The replaced version is purged once the new one is committed, as unapproved versions are never restored
+	if replacedVersionID != "" {
+		jobs.SubmitJobPurgeVersionTask(ctx, replacedVersionID)
+	}
+
+	// The logo is only stored once the version is committed, so a failed upload leaves no files behind
+	if mod.Logo == "" && modInfo.Icon != nil {
+		setModLogoFromIcon(ctx, mod, modInfo.Icon)
+		if err := postgres.SaveMod(ctx, mod); err != nil {
+			l.Err(err).Msg("failed to save mod logo")
+		}
+	}
+
+	if draft {
+		l.Info().Msg("Version kept as draft")
+	} else if autoApproved {
+		// A blob shared with a version pending its scan is published by this one
+		releaseVersionBlobs(ctx, dbVersion)
 
-	if autoApproved {
 		mod := postgres.GetModByID(ctx, dbVersion.ModID)
 		now := time.Now()
 		mod.LastVersionDate = &now
-		postgres.Save(ctx, &mod)
+		if err := postgres.SaveMod(ctx, mod); err != nil {
+			l.Err(err).Msg("failed to save last version date")
+		}
+
+		PublishVersionApproved(ctx, dbVersion, mod)
 
 		go integrations.NewVersion(util.ReWrapCtx(ctx), dbVersion)
 	} else {
 		l.Info().Msg("Submitting version job for virus scan")
-		jobs.SubmitJobScanModOnVirusTotalTask(ctx, mod.ID, dbVersion.ID, true)
+		setUploadStage(versionID, generated.VersionUploadStateScanning)
+		QuarantineVersion(ctx, dbVersion)
+		jobs.SubmitJobScanModOnVirusTotalTask(ctx, mod.ID, dbVersion.ID, versionID, dbVersion.ContentFlags == nil)
+	}
+
+	if !dbVersion.Quarantined && viper.GetBool("patches.enabled") {
+		jobs.SubmitJobGenerateVersionPatchesTask(ctx, mod.ID, dbVersion.ID)
+	}
+
+	if !dbVersion.Quarantined && len(storage.Mirrors()) > 0 {
+		jobs.SubmitJobReplicateVersionFilesTask(ctx, dbVersion.ID)
+	}
+
+	if !dbVersion.Quarantined && viper.GetBool("torrents.enabled") {
+		jobs.SubmitJobGenerateVersionTorrentTask(ctx, dbVersion.ID)
 	}
 
 	return &generated.CreateVersionResponse{
-		AutoApproved: autoApproved,
+		AutoApproved: dbVersion.Approved,
 		Version:      DBVersionToGenerated(dbVersion),
 	}, nil
 }
 
-func removeMod(ctx context.Context, modInfo *validation.ModInfo, mod *postgres.Mod, dbVersion *postgres.Version) {
-	for modID, condition := range modInfo.Dependencies {
-		dependency := postgres.VersionDependency{
-			VersionID: dbVersion.ID,
-			ModID:     modID,
-			Condition: condition,
-			Optional:  false,
+// QuarantineVersion moves the files of a version pending a virus scan to the quarantine prefix,
+// so they cannot be downloaded until the scan completes.
+func QuarantineVersion(ctx context.Context, dbVersion *postgres.Version) {
+	moveVersionFiles(ctx, dbVersion, storage.MoveToQuarantine)
+	dbVersion.Quarantined = true
+	if err := postgres.SaveVersion(ctx, dbVersion); err != nil {
+		log.Err(err).Str("version_id", dbVersion.ID).Msg("failed to save quarantined version")
+	}
+
+	// Blobs keep their key, downloads of them are refused while they are quarantined.
+	// Blobs already published by another version stay available, as their content was already scanned.
+	for _, key := range versionBlobKeys(ctx, dbVersion) {
+		if !postgres.IsBlobQuarantined(ctx, key) {
+			continue
+		}
+
+		if err := storage.QuarantineBlob(ctx, key); err != nil {
+			log.Err(err).Str("version_id", dbVersion.ID).Str("key", key).Msg("failed to quarantine blob")
+		}
+
+		for _, mirror := range postgres.GetReplicatedMirrors(ctx, key) {
+			if err := storage.DeleteFromMirror(mirror, key); err != nil {
+				log.Err(err).Str("version_id", dbVersion.ID).Str("key", key).Str("mirror", mirror).Msg("failed to delete quarantined blob from mirror")
+			}
 		}
 
-		postgres.DeleteForced(ctx, &dependency)
+		postgres.DeleteFileReplicas(ctx, key)
+	}
+}
+
+// ReleaseVersionFromQuarantine moves the files of a version back to their public location
+func ReleaseVersionFromQuarantine(ctx context.Context, dbVersion *postgres.Version) {
+	if !dbVersion.Quarantined {
+		return
+	}
+
+	moveVersionFiles(ctx, dbVersion, storage.ReleaseFromQuarantine)
+	dbVersion.Quarantined = false
+	if err := postgres.SaveVersion(ctx, dbVersion); err != nil {
+		log.Err(err).Str("version_id", dbVersion.ID).Msg("failed to save released version")
+	}
+
+	releaseVersionBlobs(ctx, dbVersion)
+
+	// Versions approved while quarantined only become public now
+	if mod := postgres.GetModByID(ctx, dbVersion.ModID); isVersionPublic(dbVersion, mod) {
+		publishVersionCreated(ctx, dbVersion)
+	}
+
+	if viper.GetBool("patches.enabled") {
+		jobs.SubmitJobGenerateVersionPatchesTask(ctx, dbVersion.ModID, dbVersion.ID)
 	}
 
-	for modID, condition := range modInfo.OptionalDependencies {
-		dependency := postgres.VersionDependency{
-			VersionID: dbVersion.ID,
-			ModID:     modID,
-			Condition: condition,
-			Optional:  true,
+	if len(storage.Mirrors()) > 0 {
+		jobs.SubmitJobReplicateVersionFilesTask(ctx, dbVersion.ID)
+	}
+
+	if viper.GetBool("torrents.enabled") {
+		jobs.SubmitJobGenerateVersionTorrentTask(ctx, dbVersion.ID)
+	}
+}
+
+// releaseVersionBlobs moves the blobs of a version out of quarantine, unless another version still holds them there
+func releaseVersionBlobs(ctx context.Context, dbVersion *postgres.Version) {
+	for _, key := range versionBlobKeys(ctx, dbVersion) {
+		if postgres.IsBlobQuarantined(ctx, key) {
+			continue
 		}
 
-		postgres.DeleteForced(ctx, &dependency)
+		if err := storage.ReleaseBlobFromQuarantine(ctx, key); err != nil {
+			log.Err(err).Str("version_id", dbVersion.ID).Str("key", key).Msg("failed to release blob from quarantine")
+		}
 	}
+}
+
+// versionBlobKeys returns the keys of the content addressed targets of a version
+func versionBlobKeys(ctx context.Context, dbVersion *postgres.Version) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, target := range postgres.GetVersionTargets(ctx, dbVersion.ID) {
+		if storage.IsBlob(target.Key) && !seen[target.Key] {
+			seen[target.Key] = true
+			keys = append(keys, target.Key)
+		}
+	}
+
+	return keys
+}
 
-	for _, target := range modInfo.Targets {
-		dbVersionTarget := postgres.VersionTarget{
-			VersionID:  dbVersion.ID,
-			TargetName: target,
+func moveVersionFiles(ctx context.Context, dbVersion *postgres.Version, move func(context.Context, string) (string, error)) {
+	moved := make(map[string]string)
+	moveKey := func(key string) string {
+		if key == "" {
+			return key
 		}
 
-		postgres.DeleteForced(ctx, &dbVersionTarget)
+		if newKey, ok := moved[key]; ok {
+			return newKey
+		}
+
+		newKey, err := move(ctx, key)
+		if err != nil {
+			log.Err(err).Str("version_id", dbVersion.ID).Str("key", key).Msg("failed to move version file")
+		}
+
+		moved[key] = newKey
+		return newKey
 	}
 
-	// For UEPlugin mods, a Windows target is created.
-	// However, that happens after the last possible call to this function, therefore we can ignore it
+	dbVersion.Key = moveKey(dbVersion.Key)
 
-	postgres.DeleteForced(ctx, &dbVersion)
+	// Legacy targets share the key of the version
+	for _, target := range postgres.GetVersionTargets(ctx, dbVersion.ID) {
+		target := target
+		target.Key = moveKey(target.Key)
+		if err := postgres.SaveVersionTargets(ctx, []*postgres.VersionTarget{&target}); err != nil {
+			log.Err(err).Str("version_id", dbVersion.ID).Str("target", target.TargetName).Msg("failed to save moved target")
+		}
+	}
+}
+
+// checkModInfo verifies that the extracted mod info can be published as a version of the mod
+func checkModInfo(ctx context.Context, mod *postgres.Mod, modInfo *validation.ModInfo) error {
+	if modInfo.ModReference != mod.ModReference {
+		return errors.New("data.json mod_reference does not match mod reference")
+	}
 
-	storage.DeleteMod(ctx, mod.ID, mod.Name, dbVersion.ID)
-	for _, target := range modInfo.Targets {
-		storage.DeleteModTarget(ctx, mod.ID, mod.Name, dbVersion.ID, target)
+	if modInfo.Type == validation.DataJSON {
+		return errors.New("data.json mods are obsolete and not allowed")
 	}
+
+	if modInfo.Type == validation.MultiTargetUEPlugin && !util.FlagEnabled(util.FeatureFlagAllowMultiTargetUpload) {
+		return errors.New("multi-target mods are not allowed")
+	}
+
+	return validation.ValidateDependencies(ctx, modInfo)
+}
+
+func setUploadStage(versionID string, state generated.VersionUploadState) {
+	if err := redis.UpdateVersionUploadStage(versionID, state, nil); err != nil {
+		log.Err(err).Str("version_id", versionID).Msg("failed to update version upload stage")
+	}
+}
+
+// getModVersionUploadStatus returns the stored state of an upload, or nil if it is unknown or an upload of another mod
+func getModVersionUploadStatus(modID string, versionID string) (*redis.StoredVersionUploadState, error) {
+	status, err := redis.GetVersionUploadStatus(versionID)
+	if err != nil || status == nil {
+		return nil, err
+	}
+
+	if status.ModID != modID {
+		return nil, nil
+	}
+
+	return status, nil
+}
+
+// setModLogoFromIcon uses the icon bundled in the mod archive as the logo of a mod that has none
+func setModLogoFromIcon(ctx context.Context, mod *postgres.Mod, icon []byte) {
+	logoData, err := converter.ConvertAnyImageToWebp(ctx, icon)
+	if err != nil {
+		log.Err(err).Str("mod_id", mod.ID).Msg("failed to convert bundled icon")
+		return
+	}
+
+	success, logoKey := storage.UploadModLogo(ctx, mod.ID, bytes.NewReader(logoData))
+	if !success {
+		return
+	}
+
+	mod.Logo = storage.GenerateDownloadLink(logoKey)
+	setModLogoVariants(ctx, mod, icon)
+}
+
+// PublishVersionApproved notifies lifecycle subscribers of an approved version, and of its mod's updated latest versions
+func PublishVersionApproved(ctx context.Context, version *postgres.Version, mod *postgres.Mod) {
+	publishModUpdated(mod)
+	notifyVersionApproved(ctx, version, mod)
+
+	if !isVersionPublic(version, mod) {
+		return
+	}
+
+	publishVersionCreated(ctx, version)
+
+	converted := DBVersionToGenerated(version)
+	redis.PublishVersionEvent(redis.EventVersionApproved, converted)
+
+	go integrations.DispatchWebhookEvent(util.ReWrapCtx(ctx), postgres.WebhookEventVersionApproved, &version.ModID, converted)
+}
+
+// isVersionPublic returns whether the version is listed publicly, only those are relayed to subscriptions and webhooks
+func isVersionPublic(version *postgres.Version, mod *postgres.Mod) bool {
+	return version.Approved && !version.Denied && !version.Quarantined && !version.Draft && mod != nil && mod.Approved && !mod.Hidden
+}
+
+// publishVersionCreated relays a version which became public to the subscriptions and webhooks
+func publishVersionCreated(ctx context.Context, version *postgres.Version) {
+	converted := DBVersionToGenerated(version)
+	redis.PublishVersionEvent(redis.EventVersionCreated, converted)
+
+	go integrations.DispatchWebhookEvent(util.ReWrapCtx(ctx), postgres.WebhookEventVersionCreated, &version.ModID, converted)
+}
+
+func publishModUpdated(mod *postgres.Mod) {
+	converted := DBModToGenerated(mod)
+	if converted == nil {
+		return
+	}
+
+	// Versions are resolved separately, there is no need to relay them
+	converted.Versions = nil
+	redis.PublishModEvent(redis.EventModUpdated, converted)
+}
+
+// removeModFiles deletes the files stored for a version whose creation failed
+func removeModFiles(ctx context.Context, mod *postgres.Mod, versionID string, version string, targets []*postgres.VersionTarget) {
+	storage.DeleteMod(ctx, mod.ID, mod.Name, versionID)
+
+	for _, target := range targets {
+		if storage.IsBlob(target.Key) {
+			// The reference of the version was never committed, so the blob is only deleted if no other version references it
+			if !postgres.IsBlobReferenced(ctx, target.Hash) {
+				storage.DeleteVersionFile(ctx, target.Key)
+			}
+			continue
+		}
+
+		storage.DeleteModTarget(ctx, mod.ID, mod.Name, version, target.TargetName)
+	}
+}
+
+// ReleaseTargetFile drops the reference of a version target to its file, and returns whether the file was deleted.
+// Blobs are only deleted once the last target referencing them is released.
+// A blob kept for other versions is moved out of quarantine if the released target was the last one holding it there.
+func ReleaseTargetFile(ctx context.Context, key string, hash string) bool {
+	if storage.IsBlob(key) && !postgres.ReleaseBlob(ctx, hash) {
+		if !postgres.IsBlobQuarantined(ctx, key) {
+			if err := storage.ReleaseBlobFromQuarantine(ctx, key); err != nil {
+				log.Err(err).Str("key", key).Msg("failed to release blob from quarantine")
+			}
+		}
+
+		return false
+	}
+
+	return storage.DeleteVersionFile(ctx, key)
 }
@@ -0,0 +1,192 @@
+// Package health reports whether the API and the services it depends on are functional,
+// for load balancers and monitoring.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/storage"
+	"github.com/satisfactorymodding/smr-api/validation"
+)
+
+// Statuses of a check, and of the whole API
+const (
+	StatusOK = "ok"
+	// StatusDegraded means a non-critical dependency failed, the API still serves requests
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+	StatusDisabled    = "disabled"
+)
+
+type Check struct {
+	Status  string  `json:"status"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks"`
+}
+
+type dependency struct {
+	name string
+	// The API cannot serve requests without a critical dependency
+	critical bool
+	check    func(ctx context.Context) error
+}
+
+var dependencies = []dependency{
+	{name: "postgres", critical: true, check: postgres.Ping},
+	{name: "redis", critical: true, check: func(context.Context) error { return redis.Ping() }},
+	{name: "storage", critical: true, check: func(context.Context) error { return storage.Ping() }},
+}
+
+var (
+	virusTotalCheck   Check
+	virusTotalChecked time.Time
+	virusTotalLock    sync.Mutex
+)
+
+// Liveness answers as long as the process serves requests, without checking any dependency
+func Liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, Report{Status: StatusOK, Checks: map[string]Check{}})
+}
+
+// Readiness checks every dependency, answering 503 if a critical one failed
+func Readiness(c echo.Context) error {
+	report := Run(c.Request().Context())
+
+	status := http.StatusOK
+	if report.Status == StatusUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+
+	return c.JSON(status, report)
+}
+
+// Run checks every dependency concurrently, each within health.timeout
+func Run(ctx context.Context) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]Check, len(dependencies)+3),
+	}
+
+	checked := dependencies
+	if postgres.HasReadReplica() {
+		// Reads go to the primary while the replica is down
+		checked = append(checked[:len(checked):len(checked)], dependency{name: "postgres_replica", check: postgres.CheckReplica})
+	}
+
+	var lock sync.Mutex
+	var wait sync.WaitGroup
+
+	for _, dep := range checked {
+		dep := dep
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			result := run(ctx, dep.check)
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			report.Checks[dep.name] = result
+			if result.Status != StatusOK {
+				if dep.critical {
+					report.Status = StatusUnavailable
+				} else if report.Status == StatusOK {
+					report.Status = StatusDegraded
+				}
+			}
+		}()
+	}
+
+	wait.Add(1)
+	go func() {
+		defer wait.Done()
+		result := checkVirusTotal(ctx)
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		report.Checks["virustotal"] = result
+		if result.Status == StatusUnavailable && report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}()
+
+	wait.Wait()
+
+	// Mirrors are checked by their own loop, downloads fall back to the primary storage while they are down
+	for name, healthy := range storage.MirrorHealth() {
+		result := Check{Status: StatusOK}
+		if !healthy {
+			result = Check{Status: StatusUnavailable, Error: "mirror failed its last health check"}
+			if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}
+		report.Checks["mirror:"+name] = result
+	}
+
+	return report
+}
+
+// checkVirusTotal reuses its result for health.virustotal_interval, as every check is counted against the API quota
+func checkVirusTotal(ctx context.Context) Check {
+	if viper.GetString("virustotal.key") == "" {
+		return Check{Status: StatusDisabled}
+	}
+
+	virusTotalLock.Lock()
+	defer virusTotalLock.Unlock()
+
+	if time.Since(virusTotalChecked) < viper.GetDuration("health.virustotal_interval") {
+		return virusTotalCheck
+	}
+
+	virusTotalCheck = run(ctx, func(context.Context) error { return validation.PingVirusTotal() })
+	virusTotalChecked = time.Now()
+
+	return virusTotalCheck
+}
+
+// run times a check, failing it if it does not complete within health.timeout
+func run(ctx context.Context, check func(ctx context.Context) error) Check {
+	ctx, cancel := context.WithTimeout(ctx, viper.GetDuration("health.timeout"))
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Check{
+		Status:  StatusOK,
+		Latency: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -160,3 +160,55 @@ func NewVersion(ctx context.Context, version *postgres.Version) {
 
 	_, _ = io.ReadAll(res.Body)
 }
+
+// CorruptedVersion alerts moderators, through discord.alerts_webhook_url, that stored files of a version failed the integrity audit
+func CorruptedVersion(ctx context.Context, version *postgres.Version, corruptedKeys []string) {
+	if version == nil {
+		return
+	}
+
+	if viper.GetString("discord.alerts_webhook_url") == "" {
+		return
+	}
+
+	mod := postgres.GetModByID(ctx, version.ModID)
+
+	if mod == nil {
+		return
+	}
+
+	payload := map[string]interface{}{
+		"username": "SMR Storage Audit",
+		"embeds": []interface{}{
+			map[string]interface{}{
+				"title":       "**" + mod.Name + " v" + version.Version + "** is corrupted",
+				"url":         "https://ficsit.app/mod/" + mod.ModReference + "/version/" + version.ID,
+				"color":       16711680,
+				"description": "Stored files do not match their recorded hash:\n" + strings.Join(corruptedKeys, "\n"),
+			},
+		},
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		log.Err(err).Msg("error marshaling discord webhook")
+		return
+	}
+
+	req, _ := http.NewRequest("POST", viper.GetString("discord.alerts_webhook_url"), bytes.NewReader(payloadJSON))
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("cache-control", "no-cache")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Err(err).Msg("error sending discord webhook")
+		return
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(res.Body)
+
+	_, _ = io.ReadAll(res.Body)
+}
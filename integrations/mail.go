@@ -0,0 +1,109 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+)
+
+// SendMail sends a plain text email through mail.smtp, doing nothing if no SMTP host is configured
+func SendMail(to []string, subject string, body string) error {
+	host := viper.GetString("mail.smtp.host")
+	if host == "" || len(to) == 0 {
+		return nil
+	}
+
+	from := viper.GetString("mail.from")
+
+	var auth smtp.Auth
+	if viper.GetString("mail.smtp.username") != "" {
+		auth = smtp.PlainAuth("", viper.GetString("mail.smtp.username"), viper.GetString("mail.smtp.password"), host)
+	}
+
+	message := "From: " + from + "\r\n" +
+		"To: " + strings.Join(to, ", ") + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+
+	address := fmt.Sprintf("%s:%d", host, viper.GetInt("mail.smtp.port"))
+
+	return errors.Wrap(smtp.SendMail(address, auth, from, to, []byte(message)), "failed to send mail")
+}
+
+// modAuthorEmails returns the email addresses of every author of a mod
+func modAuthorEmails(ctx context.Context, modID string) []string {
+	var emails []string
+	for _, author := range postgres.GetModAuthors(ctx, modID) {
+		user := postgres.GetUserByID(ctx, author.UserID)
+		if user != nil && user.Email != "" {
+			emails = append(emails, user.Email)
+		}
+	}
+	return emails
+}
+
+// UnapprovedVersionExpiring warns the authors of a mod that a version which was never approved will be deleted
+func UnapprovedVersionExpiring(ctx context.Context, version *postgres.Version, deleteAt time.Time) error {
+	mod := postgres.GetModByID(ctx, version.ModID)
+	if mod == nil {
+		return nil
+	}
+
+	subject := mod.Name + " v" + version.Version + " will be deleted"
+	body := "Version " + version.Version + " of " + mod.Name + " was never approved, " +
+		"and will be deleted on " + deleteAt.Format("January 2, 2006") + ".\r\n\r\n" +
+		"If you believe this is a mistake, please contact the moderators before then.\r\n\r\n" +
+		"https://ficsit.app/mod/" + mod.ModReference + "/version/" + version.ID + "\r\n"
+
+	if err := SendMail(modAuthorEmails(ctx, mod.ID), subject, body); err != nil {
+		log.Err(err).Str("version", version.ID).Msg("failed to notify authors of version deletion")
+		return err
+	}
+
+	return nil
+}
+
+// userEmails returns the email addresses of the users
+func userEmails(ctx context.Context, userIDs ...string) []string {
+	var emails []string
+	for _, userID := range userIDs {
+		user := postgres.GetUserByID(ctx, userID)
+		if user != nil && user.Email != "" {
+			emails = append(emails, user.Email)
+		}
+	}
+	return emails
+}
+
+// ModTransferRequested notifies the current and the new owner of a mod that its ownership transfer was requested
+func ModTransferRequested(ctx context.Context, mod *postgres.Mod, transfer *postgres.ModTransfer) {
+	subject := "Ownership transfer of " + mod.Name + " requested"
+	body := "The ownership of " + mod.Name + " is being transferred to a new owner.\r\n\r\n" +
+		"The transfer will only take effect once the new owner accepted it, until then it can be cancelled by either of you.\r\n\r\n" +
+		"https://ficsit.app/mod/" + mod.ModReference + "\r\n"
+
+	if err := SendMail(userEmails(ctx, transfer.FromUserID, transfer.ToUserID), subject, body); err != nil {
+		log.Err(err).Str("transfer", transfer.ID).Msg("failed to notify users of mod transfer request")
+	}
+}
+
+// ModTransferAccepted notifies the previous and the new owner of a mod that its ownership was transferred
+func ModTransferAccepted(ctx context.Context, mod *postgres.Mod, transfer *postgres.ModTransfer) {
+	subject := "Ownership of " + mod.Name + " transferred"
+	body := "The ownership transfer of " + mod.Name + " was accepted.\r\n\r\n" +
+		"The previous owner remains an editor of the mod, and every other author kept their role.\r\n\r\n" +
+		"https://ficsit.app/mod/" + mod.ModReference + "\r\n"
+
+	if err := SendMail(userEmails(ctx, transfer.FromUserID, transfer.ToUserID), subject, body); err != nil {
+		log.Err(err).Str("transfer", transfer.ID).Msg("failed to notify users of mod transfer")
+	}
+}
@@ -0,0 +1,254 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/auth"
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// WebhookEvents are the events webhooks can subscribe to
+var WebhookEvents = []string{
+	postgres.WebhookEventVersionCreated,
+	postgres.WebhookEventVersionApproved,
+	postgres.WebhookEventCommentCreated,
+	postgres.WebhookEventReportCreated,
+}
+
+// Maximum length of a webhook URL
+const maxWebhookURLLength = 2048
+
+// WebhookDelivery is the body POSTed to webhooks
+type WebhookDelivery struct {
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	ModID     *string     `json:"mod_id,omitempty"`
+}
+
+// PrepareWebhook validates the URL, events and mods of a webhook of the user, removing duplicates.
+//
+// Reports are only delivered to moderators, or to the authors of every mod of the webhook.
+func PrepareWebhook(ctx context.Context, user *postgres.User, webhook *postgres.Webhook) error {
+	if err := validateWebhookURL(webhook.URL); err != nil {
+		return err
+	}
+
+	events := make([]string, 0, len(webhook.Events))
+	for _, event := range webhook.Events {
+		if !isWebhookEvent(event) {
+			return errors.New("unknown webhook event: " + event)
+		}
+
+		if !containsString(events, event) {
+			events = append(events, event)
+		}
+	}
+
+	if len(events) == 0 {
+		return errors.New("webhooks have to subscribe to at least one event")
+	}
+
+	if len(webhook.ModIDs) > viper.GetInt("webhooks.max_mods") {
+		return errors.Errorf("webhooks can be limited to at most %d mods", viper.GetInt("webhooks.max_mods"))
+	}
+
+	modIDs := make([]string, 0, len(webhook.ModIDs))
+	for _, modID := range webhook.ModIDs {
+		mod := postgres.GetModByID(ctx, modID)
+		if mod == nil {
+			return errors.New("mod not found: " + modID)
+		}
+
+		if !containsString(modIDs, mod.ID) {
+			modIDs = append(modIDs, mod.ID)
+		}
+	}
+
+	if containsString(events, postgres.WebhookEventReportCreated) && !user.Has(ctx, auth.RoleApproveMods) {
+		if len(modIDs) == 0 {
+			return errors.New("only moderators can receive the reports of every mod")
+		}
+
+		for _, modID := range modIDs {
+			if !isModAuthor(ctx, user.ID, modID) {
+				return errors.New("reports can only be received for your own mods: " + modID)
+			}
+		}
+	}
+
+	webhook.Events = events
+	webhook.ModIDs = modIDs
+
+	return nil
+}
+
+// DispatchWebhookEvent queues the delivery of an event to every webhook subscribed to it
+func DispatchWebhookEvent(ctx context.Context, event string, modID *string, data interface{}) {
+	webhooks := postgres.GetEventWebhooks(ctx, event, modID)
+
+	for _, webhook := range webhooks {
+		delivery := WebhookDelivery{
+			ID:        util.GenerateUniqueID(),
+			Event:     event,
+			ModID:     modID,
+			Data:      data,
+			CreatedAt: time.Now(),
+		}
+
+		payload, err := json.Marshal(delivery)
+		if err != nil {
+			log.Err(err).Str("event", event).Msg("failed to marshal webhook delivery")
+			return
+		}
+
+		jobs.SubmitJobDeliverWebhookTask(ctx, webhook.ID, delivery.ID, event, payload)
+	}
+}
+
+// DeliverWebhook POSTs the payload to the webhook, signed with its secret in the X-SMR-Signature header.
+// Any response other than 2xx fails the delivery, so it can be retried.
+func DeliverWebhook(ctx context.Context, webhook *postgres.Webhook, deliveryID string, event string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to create webhook request")
+	}
+
+	signature := hmac.New(sha256.New, []byte(webhook.Secret))
+	signature.Write(payload)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SMR-Webhooks")
+	req.Header.Set("X-SMR-Event", event)
+	req.Header.Set("X-SMR-Delivery", deliveryID)
+	req.Header.Set("X-SMR-Signature", "sha256="+hex.EncodeToString(signature.Sum(nil)))
+
+	res, err := webhookClient().Do(req)
+	if err != nil {
+		err = errors.Wrap(err, "failed to deliver webhook")
+		postgres.RecordWebhookDelivery(ctx, webhook, nil, err, viper.GetInt("webhooks.max_failures"))
+		return err
+	}
+
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	status := res.StatusCode
+	if status < 200 || status >= 300 {
+		err = fmt.Errorf("webhook responded with status %d", status)
+	}
+
+	postgres.RecordWebhookDelivery(ctx, webhook, &status, err, viper.GetInt("webhooks.max_failures"))
+
+	return err
+}
+
+func validateWebhookURL(raw string) error {
+	if len(raw) > maxWebhookURLLength {
+		return errors.Errorf("webhook urls cannot be longer than %d characters", maxWebhookURLLength)
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return errors.New("invalid webhook url")
+	}
+
+	if parsed.Scheme != "https" && (parsed.Scheme != "http" || !viper.GetBool("webhooks.allow_http")) {
+		return errors.New("webhook urls have to use https")
+	}
+
+	return nil
+}
+
+var (
+	webhookHTTPClient *http.Client
+	webhookClientOnce sync.Once
+)
+
+// webhookClient refuses to connect to private addresses, unless webhooks.allow_private is set,
+// so webhooks cannot be used to reach the internal network of the API
+func webhookClient() *http.Client {
+	webhookClientOnce.Do(func() {
+		webhookHTTPClient = newWebhookClient()
+	})
+	return webhookHTTPClient
+}
+
+func newWebhookClient() *http.Client {
+	allowPrivate := viper.GetBool("webhooks.allow_private")
+
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			if allowPrivate {
+				return nil
+			}
+
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+				return errors.New("webhooks cannot be delivered to private addresses")
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: viper.GetDuration("webhooks.timeout"),
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		// Redirects could lead anywhere, deliveries have to be accepted by the configured URL
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isWebhookEvent(event string) bool {
+	return containsString(WebhookEvents, event)
+}
+
+func isModAuthor(ctx context.Context, userID string, modID string) bool {
+	for _, author := range postgres.GetModAuthors(ctx, modID) {
+		if author.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
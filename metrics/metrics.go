@@ -0,0 +1,70 @@
+// Package metrics publishes runtime statistics of the API in the Prometheus text format, for monitoring.
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+)
+
+// Handler serves the metrics, to the holders of metrics.token when it is set
+func Handler(c echo.Context) error {
+	if token := viper.GetString("metrics.token"); token != "" {
+		provided := strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			return c.String(http.StatusUnauthorized, "unauthorized")
+		}
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+
+	writePoolStats(c.Response())
+
+	return nil
+}
+
+type poolMetric struct {
+	name  string
+	kind  string
+	help  string
+	value func(stats sql.DBStats) float64
+}
+
+var poolMetrics = []poolMetric{
+	{"smr_db_pool_max_open_connections", "gauge", "Maximum number of open connections, 0 is unlimited", func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+	{"smr_db_pool_open_connections", "gauge", "Number of open connections", func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+	{"smr_db_pool_in_use_connections", "gauge", "Number of connections in use", func(s sql.DBStats) float64 { return float64(s.InUse) }},
+	{"smr_db_pool_idle_connections", "gauge", "Number of idle connections", func(s sql.DBStats) float64 { return float64(s.Idle) }},
+	{"smr_db_pool_wait_count_total", "counter", "Number of times a connection was waited for", func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+	{"smr_db_pool_wait_duration_seconds_total", "counter", "Time spent waiting for a connection", func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+	{"smr_db_pool_max_idle_closed_total", "counter", "Number of connections closed because of max_idle", func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+	{"smr_db_pool_max_idle_time_closed_total", "counter", "Number of connections closed because of max_idle_time", func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }},
+	{"smr_db_pool_max_lifetime_closed_total", "counter", "Number of connections closed because of max_lifetime", func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+}
+
+func writePoolStats(w io.Writer) {
+	stats := postgres.PoolStats()
+
+	pools := make([]string, 0, len(stats))
+	for pool := range stats {
+		pools = append(pools, pool)
+	}
+	sort.Strings(pools)
+
+	for _, metric := range poolMetrics {
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for _, pool := range pools {
+			_, _ = fmt.Fprintf(w, "%s{pool=%q} %g\n", metric.name, pool, metric.value(stats[pool]))
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm/clause"
+
+	postgres2 "github.com/satisfactorymodding/smr-api/db/postgres"
+)
+
+// migrationChecksum is the checksum of a schema migration as it was applied
+type migrationChecksum struct {
+	Version  uint `gorm:"primary_key;autoIncrement:false"`
+	Name     string
+	Checksum string
+}
+
+func (migrationChecksum) TableName() string {
+	return "schema_migration_checksums"
+}
+
+// sqlMigration is a schema migration embedded in the binary
+type sqlMigration struct {
+	Version  uint
+	Name     string
+	Checksum string
+}
+
+// embeddedMigrations lists the embedded schema migrations by version, with a checksum covering both of their directions
+func embeddedMigrations() ([]sqlMigration, error) {
+	entries, err := fs.ReadDir(sqlFiles, "sql")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list schema migrations")
+	}
+
+	files := make(map[uint]map[source.Direction][]byte)
+	names := make(map[uint]string)
+	for _, entry := range entries {
+		parsed, err := source.DefaultParse(entry.Name())
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid schema migration name "+entry.Name())
+		}
+
+		data, err := fs.ReadFile(sqlFiles, "sql/"+entry.Name())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read schema migration "+entry.Name())
+		}
+
+		if files[parsed.Version] == nil {
+			files[parsed.Version] = make(map[source.Direction][]byte)
+		}
+
+		files[parsed.Version][parsed.Direction] = data
+		names[parsed.Version] = parsed.Identifier
+	}
+
+	migrations := make([]sqlMigration, 0, len(files))
+	for version, directions := range files {
+		hash := sha256.New()
+		hash.Write(directions[source.Up])
+		hash.Write([]byte{0})
+		hash.Write(directions[source.Down])
+
+		migrations = append(migrations, sqlMigration{
+			Version:  version,
+			Name:     names[version],
+			Checksum: hex.EncodeToString(hash.Sum(nil)),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func ensureChecksumTable(ctx context.Context) error {
+	return errors.Wrap(postgres2.DBCtx(ctx).Exec(`CREATE TABLE IF NOT EXISTS schema_migration_checksums
+(
+    version  bigint      not null primary key,
+    name     text        not null,
+    checksum varchar(64) not null
+)`).Error, "failed to create checksum table")
+}
+
+// recordChecksums stores the checksums of the embedded migrations up to the applied version.
+// Migrations applied before checksums were tracked are trusted as they are.
+func recordChecksums(ctx context.Context, version uint) error {
+	if err := ensureChecksumTable(ctx); err != nil {
+		return err
+	}
+
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return err
+	}
+
+	checksums := make([]migrationChecksum, 0, len(migrations))
+	for _, migration := range migrations {
+		if migration.Version > version {
+			break
+		}
+
+		checksums = append(checksums, migrationChecksum{
+			Version:  migration.Version,
+			Name:     migration.Name,
+			Checksum: migration.Checksum,
+		})
+	}
+
+	if len(checksums) == 0 {
+		return nil
+	}
+
+	result := postgres2.DBCtx(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&checksums)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to record migration checksums")
+	}
+
+	if result.RowsAffected > 0 {
+		log.Ctx(ctx).Info().Int64("count", result.RowsAffected).Msg("recorded migration checksums")
+	}
+
+	return nil
+}
+
+// removeChecksums forgets the checksums of the migrations after the applied version, once they got reverted
+func removeChecksums(ctx context.Context, version uint) error {
+	if err := ensureChecksumTable(ctx); err != nil {
+		return err
+	}
+
+	return errors.Wrap(postgres2.DBCtx(ctx).Where("version > ?", version).Delete(&migrationChecksum{}).Error, "failed to remove migration checksums")
+}
+
+// verifyChecksums describes how the migrations applied up to the version differ from the embedded ones
+func verifyChecksums(ctx context.Context, version uint) ([]string, error) {
+	if err := ensureChecksumTable(ctx); err != nil {
+		return nil, err
+	}
+
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	embedded := make(map[uint]sqlMigration, len(migrations))
+	for _, migration := range migrations {
+		embedded[migration.Version] = migration
+	}
+
+	var applied []migrationChecksum
+	if err := postgres2.DBCtx(ctx).Order("version").Find(&applied).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to get migration checksums")
+	}
+
+	var drift []string
+
+	var latest uint
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+
+	if version > latest {
+		drift = append(drift, fmt.Sprintf("schema version %d is newer than the latest known migration %d", version, latest))
+	}
+
+	for _, checksum := range applied {
+		migration, ok := embedded[checksum.Version]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("applied migration %d_%s is unknown", checksum.Version, checksum.Name))
+			continue
+		}
+
+		if migration.Checksum != checksum.Checksum {
+			drift = append(drift, fmt.Sprintf("applied migration %d_%s has been modified", checksum.Version, checksum.Name))
+		}
+	}
+
+	return drift, nil
+}
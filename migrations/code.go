@@ -2,10 +2,13 @@ package migrations
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"os"
 	"strings"
 
 	"github.com/lab259/go-migration"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
@@ -27,14 +30,58 @@ func (c codeMigrationLogger) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
-func codeMigrations(ctx context.Context) {
-	source := migration.DefaultCodeSource()
+func codeManager(ctx context.Context) (migration.Manager, *sql.DB, error) {
+	db, err := postgres2.DBCtx(ctx).DB()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get database")
+	}
+
+	return migration.NewDefaultManager(migration.NewPostgreSQLTarget(db), migration.DefaultCodeSource()), db, nil
+}
+
+func codeMigrations(ctx context.Context) error {
+	manager, db, err := codeManager(ctx)
+	if err != nil {
+		return err
+	}
 
 	// TODO Custom reporter, this one's very ugly
 	reporter := migration.NewDefaultReporterWithParams(codeMigrationLogger{log: &log.Logger}, os.Exit)
 
-	db, _ := postgres2.DBCtx(ctx).DB()
-	manager := migration.NewDefaultManager(migration.NewPostgreSQLTarget(db), source)
-	runner := migration.NewArgsRunnerCustom(reporter, manager, os.Exit, "migrate")
-	runner.Run(db)
+	summaries, err := manager.Migrate(reporter, db)
+	if err != nil {
+		return errors.Wrap(err, "failed to apply code migrations")
+	}
+
+	for _, summary := range summaries {
+		if summary.Failed() {
+			return errors.Wrap(summary.Failure(), "code migration "+summary.Migration.GetDescription()+" failed")
+		}
+
+		if summary.Panicked() {
+			return fmt.Errorf("code migration %s panicked: %v", summary.Migration.GetDescription(), summary.PanicData())
+		}
+	}
+
+	return nil
+}
+
+// pendingCodeMigrations lists the descriptions of the code migrations which are not applied yet
+func pendingCodeMigrations(ctx context.Context) ([]string, error) {
+	manager, _, err := codeManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := manager.MigrationsPending()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get pending code migrations")
+	}
+
+	descriptions := make([]string, 0, len(pending))
+	for _, m := range pending {
+		descriptions = append(descriptions, m.GetDescription())
+	}
+
+	return descriptions, nil
 }
@@ -2,52 +2,156 @@ package migrations
 
 import (
 	"context"
-	"errors"
+	"embed"
 	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 
 	postgres2 "github.com/satisfactorymodding/smr-api/db/postgres"
-
-	// Import migrations
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
-func RunMigrations(ctx context.Context) {
-	databaseMigrations(ctx)
-	codeMigrations(ctx)
+// The schema migrations are embedded, so the binary always migrates to the schema it was built against
+//
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+// Up applies every pending schema migration and then every pending code migration.
+// It refuses to run if an applied migration differs from the one embedded in the binary.
+func Up(ctx context.Context) error {
+	if err := withMigrate(ctx, func(m *migrate.Migrate) error {
+		version, _, err := schemaVersion(m)
+		if err != nil {
+			return err
+		}
+
+		drift, err := verifyChecksums(ctx, version)
+		if err != nil {
+			return err
+		}
+
+		if len(drift) > 0 {
+			return errors.New("refusing to migrate a drifted schema: " + strings.Join(drift, "; "))
+		}
+
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return errors.Wrap(err, "failed to apply schema migrations")
+		}
+
+		version, _, err = schemaVersion(m)
+		if err != nil {
+			return err
+		}
+
+		return recordChecksums(ctx, version)
+	}); err != nil {
+		return err
+	}
+
+	if err := codeMigrations(ctx); err != nil {
+		return err
+	}
+
 	log.Info().Msg("Migrations Complete")
+
+	return nil
 }
 
-var migrationDir = "./migrations"
+// Down reverts the given amount of schema migrations, newest first.
+// Code migrations only move data forward, and are never reverted.
+func Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return errors.New("at least one migration has to be reverted")
+	}
+
+	return withMigrate(ctx, func(m *migrate.Migrate) error {
+		version, _, err := schemaVersion(m)
+		if err != nil {
+			return err
+		}
+
+		drift, err := verifyChecksums(ctx, version)
+		if err != nil {
+			return err
+		}
+
+		if len(drift) > 0 {
+			return errors.New("refusing to revert a drifted schema: " + strings.Join(drift, "; "))
+		}
+
+		if err := m.Steps(-steps); err != nil {
+			return errors.Wrap(err, "failed to revert schema migrations")
+		}
 
-func SetMigrationDir(newMigrationDir string) {
-	migrationDir = newMigrationDir
+		version, _, err = schemaVersion(m)
+		if err != nil {
+			return err
+		}
+
+		return removeChecksums(ctx, version)
+	})
 }
 
-func databaseMigrations(ctx context.Context) {
-	db, _ := postgres2.DBCtx(ctx).DB()
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+// withMigrate runs fn with a migrator of the embedded schema migrations, on a connection of its own
+func withMigrate(ctx context.Context, fn func(m *migrate.Migrate) error) error {
+	db, err := postgres2.DBCtx(ctx).DB()
+	if err != nil {
+		return errors.Wrap(err, "failed to get database")
+	}
+
+	// The migrator closes its connection when done, which would close the whole pool if it was given one
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get database connection")
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		_ = conn.Close()
+		return errors.Wrap(err, "failed to create migration driver")
+	}
+
+	source, err := iofs.New(sqlFiles, "sql")
 	if err != nil {
-		panic(err)
+		_ = driver.Close()
+		return errors.Wrap(err, "failed to read schema migrations")
 	}
 
-	m, err := migrate.NewWithDatabaseInstance("file://"+migrationDir+"/sql", "postgres", driver)
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
 	if err != nil {
-		panic(err)
+		_ = source.Close()
+		_ = driver.Close()
+		return errors.Wrap(err, "failed to create migrator")
 	}
 
+	defer func() {
+		if sourceErr, dbErr := m.Close(); sourceErr != nil || dbErr != nil {
+			log.Ctx(ctx).Warn().AnErr("source", sourceErr).AnErr("database", dbErr).Msg("failed to close migrator")
+		}
+	}()
+
 	m.Log = &SimpleLogger{
 		ctx: ctx,
 	}
 
-	err = m.Up()
+	return fn(m)
+}
+
+// schemaVersion returns the applied schema version, which is 0 if no migration was ever applied
+func schemaVersion(m *migrate.Migrate) (uint, bool, error) {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
 
-	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		panic(err)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to get schema version")
 	}
+
+	return version, dirty, nil
 }
 
 type SimpleLogger struct {
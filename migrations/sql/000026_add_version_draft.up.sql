@@ -0,0 +1,2 @@
+ALTER TABLE versions
+    ADD COLUMN IF NOT EXISTS draft boolean NOT NULL DEFAULT false;
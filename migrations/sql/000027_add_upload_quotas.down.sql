@@ -0,0 +1,4 @@
+drop table if exists quota_overrides;
+
+ALTER TABLE users
+    DROP COLUMN quota_tier;
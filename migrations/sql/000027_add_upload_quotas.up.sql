@@ -0,0 +1,19 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS quota_tier varchar(32);
+
+create table if not exists quota_overrides
+(
+    id varchar(14) not null constraint quota_overrides_pkey primary key,
+    user_id varchar(14) references users(id),
+    mod_id varchar(14) references mods(id),
+    max_file_size bigint,
+    max_mod_storage bigint,
+
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone
+);
+
+create index if not exists idx_quota_overrides_deleted_at on quota_overrides (deleted_at);
+create index if not exists idx_quota_overrides_user_id on quota_overrides (user_id);
+create index if not exists idx_quota_overrides_mod_id on quota_overrides (mod_id);
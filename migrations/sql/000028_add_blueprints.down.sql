@@ -0,0 +1,2 @@
+drop table if exists blueprint_tags;
+drop table if exists blueprints;
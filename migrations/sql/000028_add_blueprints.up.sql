@@ -0,0 +1,27 @@
+create table if not exists blueprints
+(
+    id varchar(14) not null constraint blueprints_pkey primary key,
+    name varchar(50),
+    short_description varchar(128),
+    full_description text,
+    user_id varchar(14) references users(id),
+    key text,
+    hash char(64),
+    size bigint,
+    views integer default 0,
+    downloads integer default 0,
+
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone
+);
+
+create index if not exists idx_blueprints_deleted_at on blueprints (deleted_at);
+create index if not exists idx_blueprints_user_id on blueprints (user_id);
+
+create table if not exists blueprint_tags
+(
+    tag_id varchar(24) not null references tags(id),
+    blueprint_id varchar(14) not null references blueprints(id),
+    primary key (blueprint_id, tag_id)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE versions
+    DROP COLUMN changelog_source;
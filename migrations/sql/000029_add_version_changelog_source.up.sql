@@ -0,0 +1,2 @@
+ALTER TABLE versions
+    ADD COLUMN IF NOT EXISTS changelog_source varchar(16) NOT NULL DEFAULT 'user';
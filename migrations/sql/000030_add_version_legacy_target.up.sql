@@ -0,0 +1,7 @@
+ALTER TABLE versions
+    ADD COLUMN IF NOT EXISTS legacy_target boolean NOT NULL DEFAULT false;
+
+-- Versions uploaded before the multi-target format have a single target pointing to the whole archive
+UPDATE versions
+SET legacy_target = true
+WHERE EXISTS(SELECT 1 FROM version_targets WHERE version_targets.version_id = versions.id AND version_targets.key = versions.key);
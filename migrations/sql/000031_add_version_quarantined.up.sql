@@ -0,0 +1,2 @@
+ALTER TABLE versions
+    ADD COLUMN IF NOT EXISTS quarantined boolean NOT NULL DEFAULT false;
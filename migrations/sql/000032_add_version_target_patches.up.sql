@@ -0,0 +1,15 @@
+create table if not exists version_target_patches
+(
+    version_id varchar(14) not null references versions(id),
+    from_version_id varchar(14) not null references versions(id),
+    target_name varchar(16) not null,
+    key text,
+    hash char(64),
+    size bigint,
+
+    created_at timestamp with time zone,
+
+    primary key (version_id, from_version_id, target_name)
+);
+
+create index if not exists idx_version_target_patches_from_version_id on version_target_patches (from_version_id);
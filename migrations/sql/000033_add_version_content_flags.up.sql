@@ -0,0 +1,2 @@
+ALTER TABLE versions
+    ADD COLUMN IF NOT EXISTS content_flags text;
@@ -0,0 +1,2 @@
+ALTER TABLE versions
+    ADD COLUMN IF NOT EXISTS container_type varchar(8) NOT NULL DEFAULT 'smod';
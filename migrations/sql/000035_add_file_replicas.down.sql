@@ -0,0 +1 @@
+drop table if exists file_replicas;
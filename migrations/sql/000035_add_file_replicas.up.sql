@@ -0,0 +1,14 @@
+create table if not exists file_replicas
+(
+    key text not null,
+    mirror varchar(32) not null,
+    version_id varchar(14) not null references versions(id),
+    state varchar(16) not null,
+    error text,
+
+    updated_at timestamp with time zone,
+
+    primary key (key, mirror)
+);
+
+create index if not exists idx_file_replicas_version_id on file_replicas (version_id);
@@ -0,0 +1,5 @@
+drop index if exists idx_versions_storage_tier;
+
+alter table versions
+    drop column if exists storage_tier,
+    drop column if exists last_downloaded_at;
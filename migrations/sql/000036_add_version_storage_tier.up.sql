@@ -0,0 +1,5 @@
+alter table versions
+    add column if not exists storage_tier varchar(16) default 'hot' not null,
+    add column if not exists last_downloaded_at timestamp with time zone;
+
+create index if not exists idx_versions_storage_tier on versions (storage_tier);
@@ -0,0 +1,4 @@
+alter table versions
+    drop column if exists torrent_key,
+    drop column if exists metalink_key,
+    drop column if exists info_hash;
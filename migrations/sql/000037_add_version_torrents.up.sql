@@ -0,0 +1,4 @@
+alter table versions
+    add column if not exists torrent_key text,
+    add column if not exists metalink_key text,
+    add column if not exists info_hash char(40);
@@ -0,0 +1,5 @@
+drop index if exists idx_versions_integrity_checked_at;
+
+alter table versions
+    drop column if exists corrupted,
+    drop column if exists integrity_checked_at;
@@ -0,0 +1,5 @@
+alter table versions
+    add column if not exists corrupted boolean default false not null,
+    add column if not exists integrity_checked_at timestamp with time zone;
+
+create index if not exists idx_versions_integrity_checked_at on versions (integrity_checked_at nulls first);
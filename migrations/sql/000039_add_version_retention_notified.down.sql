@@ -0,0 +1,2 @@
+alter table versions
+    drop column if exists retention_notified_at;
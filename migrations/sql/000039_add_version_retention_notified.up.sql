@@ -0,0 +1,2 @@
+alter table versions
+    add column if not exists retention_notified_at timestamp with time zone;
@@ -0,0 +1,9 @@
+create table if not exists blobs
+(
+    hash char(64) primary key,
+    key text not null,
+    size bigint,
+    ref_count integer default 0 not null,
+
+    created_at timestamp with time zone
+);
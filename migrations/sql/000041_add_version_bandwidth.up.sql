@@ -0,0 +1,12 @@
+create table if not exists version_bandwidth
+(
+    version_id varchar(14) not null,
+    day date not null,
+    mod_id varchar(14) not null,
+    bytes bigint default 0 not null,
+    requests integer default 0 not null,
+
+    primary key (version_id, day)
+);
+
+create index if not exists idx_version_bandwidth_mod_id_day on version_bandwidth (mod_id, day);
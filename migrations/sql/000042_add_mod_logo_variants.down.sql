@@ -0,0 +1,2 @@
+alter table mods
+    drop column if exists logo_variants;
@@ -0,0 +1,2 @@
+alter table mods
+    add column if not exists logo_variants jsonb;
@@ -0,0 +1,32 @@
+create table if not exists mod_transfers
+(
+    id varchar(14) not null constraint mod_transfers_pkey primary key,
+    mod_id varchar(14) not null references mods(id),
+    from_user_id varchar(14) not null references users(id),
+    to_user_id varchar(14) not null references users(id),
+    requested_by_id varchar(14) not null references users(id),
+    status varchar(16) not null,
+
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone
+);
+
+create index if not exists idx_mod_transfers_deleted_at on mod_transfers (deleted_at);
+create index if not exists idx_mod_transfers_mod_id on mod_transfers (mod_id);
+create index if not exists idx_mod_transfers_to_user_id on mod_transfers (to_user_id);
+
+create table if not exists audit_logs
+(
+    id varchar(14) not null constraint audit_logs_pkey primary key,
+    user_id varchar(14) references users(id),
+    action varchar(64) not null,
+    target_type varchar(32) not null,
+    target_id varchar(14) not null,
+    data jsonb,
+
+    created_at timestamp with time zone
+);
+
+create index if not exists idx_audit_logs_target on audit_logs (target_type, target_id);
+create index if not exists idx_audit_logs_user_id on audit_logs (user_id);
@@ -0,0 +1,8 @@
+alter table mods
+    drop column if exists archived_at;
+
+alter table mods
+    drop column if exists archive_reason;
+
+alter table mods
+    drop column if exists archived;
@@ -0,0 +1,8 @@
+alter table mods
+    add column if not exists archived boolean default false not null;
+
+alter table mods
+    add column if not exists archive_reason text;
+
+alter table mods
+    add column if not exists archived_at timestamp with time zone;
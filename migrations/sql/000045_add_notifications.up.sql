@@ -0,0 +1,14 @@
+create table if not exists notifications
+(
+    id varchar(14) not null constraint notifications_pkey primary key,
+    user_id varchar(14) not null references users(id),
+    type varchar(32) not null,
+    message text not null,
+    mod_id varchar(14),
+    version_id varchar(14),
+    read_at timestamp with time zone,
+
+    created_at timestamp with time zone
+);
+
+create index if not exists idx_notifications_user_id on notifications (user_id, created_at);
@@ -0,0 +1,3 @@
+drop table if exists mod_trending;
+
+drop table if exists mod_daily_stats;
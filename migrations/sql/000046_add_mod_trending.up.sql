@@ -0,0 +1,25 @@
+create table if not exists mod_daily_stats
+(
+    mod_id varchar(14) not null,
+    day date not null,
+    views integer default 0 not null,
+    downloads integer default 0 not null,
+
+    primary key (mod_id, day)
+);
+
+create index if not exists idx_mod_daily_stats_day on mod_daily_stats (day);
+
+create table if not exists mod_trending
+(
+    mod_id varchar(14) not null,
+    period varchar(16) not null,
+    score double precision not null,
+    views integer default 0 not null,
+    downloads integer default 0 not null,
+    computed_at timestamp with time zone,
+
+    primary key (mod_id, period)
+);
+
+create index if not exists idx_mod_trending_period_score on mod_trending (period, score desc);
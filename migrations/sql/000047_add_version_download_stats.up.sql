@@ -0,0 +1,12 @@
+create table if not exists version_download_stats
+(
+    version_id varchar(14) not null,
+    target_name varchar(16) default '' not null,
+    day date not null,
+    mod_id varchar(14) not null,
+    downloads integer default 0 not null,
+
+    primary key (version_id, target_name, day)
+);
+
+create index if not exists idx_version_download_stats_mod_id_day on version_download_stats (mod_id, day);
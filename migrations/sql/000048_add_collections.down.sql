@@ -0,0 +1,3 @@
+drop table if exists collection_mods;
+
+drop table if exists collections;
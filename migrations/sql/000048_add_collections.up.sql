@@ -0,0 +1,26 @@
+create table if not exists collections
+(
+    id varchar(14) not null constraint collections_pkey primary key,
+    user_id varchar(14) not null references users(id),
+    name varchar(64) not null,
+    description text,
+    slug varchar(16) not null,
+
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone
+);
+
+create unique index if not exists idx_collections_slug on collections (slug);
+create index if not exists idx_collections_deleted_at on collections (deleted_at);
+create index if not exists idx_collections_user_id on collections (user_id);
+
+create table if not exists collection_mods
+(
+    collection_id varchar(14) not null references collections(id),
+    mod_reference varchar(32) not null,
+    position integer not null,
+    version_constraint varchar(64),
+
+    primary key (collection_id, mod_reference)
+);
@@ -0,0 +1,4 @@
+alter table mods
+    drop column if exists favorites;
+
+drop table if exists mod_favorites;
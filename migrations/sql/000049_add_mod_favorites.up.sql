@@ -0,0 +1,16 @@
+create table if not exists mod_favorites
+(
+    user_id varchar(14) not null references users(id),
+    mod_id varchar(14) not null references mods(id),
+    last_seen_version_id varchar(14),
+
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+
+    constraint mod_favorites_pkey primary key (user_id, mod_id)
+);
+
+create index if not exists idx_mod_favorites_mod_id on mod_favorites (mod_id);
+
+alter table mods
+    add column if not exists favorites integer default 0 not null;
@@ -0,0 +1,3 @@
+drop table if exists comment_reports;
+
+drop table if exists comments;
@@ -0,0 +1,29 @@
+create table if not exists comments
+(
+    id varchar(14) not null constraint comments_pkey primary key,
+    mod_id varchar(14) not null references mods(id),
+    user_id varchar(14) not null references users(id),
+    parent_id varchar(14) references comments(id),
+    body text not null,
+    edited_at timestamp with time zone,
+    deleted_by_id varchar(14),
+
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone
+);
+
+create index if not exists idx_comments_mod_id on comments (mod_id, created_at);
+create index if not exists idx_comments_parent_id on comments (parent_id);
+create index if not exists idx_comments_deleted_at on comments (deleted_at);
+
+create table if not exists comment_reports
+(
+    comment_id varchar(14) not null references comments(id),
+    user_id varchar(14) not null references users(id),
+    reason text not null,
+
+    created_at timestamp with time zone,
+
+    constraint comment_reports_pkey primary key (comment_id, user_id)
+);
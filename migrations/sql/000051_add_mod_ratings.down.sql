@@ -0,0 +1,9 @@
+alter table mods
+    drop column if exists rating_count;
+
+alter table mods
+    drop column if exists rating;
+
+drop table if exists user_mod_downloads;
+
+drop table if exists mod_ratings;
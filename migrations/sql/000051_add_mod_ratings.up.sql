@@ -0,0 +1,30 @@
+create table if not exists mod_ratings
+(
+    user_id varchar(14) not null references users(id),
+    mod_id varchar(14) not null references mods(id),
+    rating smallint not null constraint mod_ratings_rating_check check (rating between 1 and 5),
+    review varchar(500),
+
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+
+    constraint mod_ratings_pkey primary key (user_id, mod_id)
+);
+
+create index if not exists idx_mod_ratings_mod_id on mod_ratings (mod_id, updated_at);
+
+create table if not exists user_mod_downloads
+(
+    user_id varchar(14) not null references users(id),
+    mod_id varchar(14) not null references mods(id),
+
+    created_at timestamp with time zone,
+
+    constraint user_mod_downloads_pkey primary key (user_id, mod_id)
+);
+
+alter table mods
+    add column if not exists rating double precision;
+
+alter table mods
+    add column if not exists rating_count integer default 0 not null;
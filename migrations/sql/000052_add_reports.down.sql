@@ -0,0 +1,18 @@
+create table if not exists comment_reports
+(
+    comment_id varchar(14) not null references comments(id),
+    user_id varchar(14) not null references users(id),
+    reason text not null,
+
+    created_at timestamp with time zone,
+
+    constraint comment_reports_pkey primary key (comment_id, user_id)
+);
+
+insert into comment_reports (comment_id, user_id, reason, created_at)
+select target_id, reporter_id, coalesce(details, reason), created_at
+from reports
+where target_type = 'comment' and state in ('open', 'triaged')
+on conflict do nothing;
+
+drop table if exists reports;
@@ -0,0 +1,30 @@
+create table if not exists reports
+(
+    id varchar(14) not null constraint reports_pkey primary key,
+    reporter_id varchar(14) not null references users(id),
+    target_type varchar(16) not null,
+    target_id varchar(14) not null,
+    reason varchar(32) not null,
+    details text,
+    state varchar(16) default 'open' not null,
+    resolved_by_id varchar(14) references users(id),
+    resolved_at timestamp with time zone,
+    note text,
+
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone
+);
+
+create index if not exists idx_reports_state on reports (state, created_at);
+create index if not exists idx_reports_target on reports (target_type, target_id);
+
+-- A reporter can only have a single unresolved report of the same content
+create unique index if not exists idx_reports_reporter_target on reports (reporter_id, target_type, target_id)
+    where state in ('open', 'triaged');
+
+insert into reports (id, reporter_id, target_type, target_id, reason, details, state, created_at, updated_at)
+select substr(md5(comment_id || user_id), 1, 14), user_id, 'comment', comment_id, 'other', reason, 'open', created_at, created_at
+from comment_reports
+on conflict do nothing;
+
+drop table if exists comment_reports;
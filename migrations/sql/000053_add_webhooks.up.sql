@@ -0,0 +1,22 @@
+create table if not exists webhooks
+(
+    id varchar(14) not null constraint webhooks_pkey primary key,
+    user_id varchar(14) not null references users(id),
+    url text not null,
+    secret varchar(64) not null,
+    events jsonb default '[]' not null,
+    mod_ids jsonb default '[]' not null,
+    active boolean default true not null,
+    failure_count integer default 0 not null,
+    last_status integer,
+    last_error text,
+    last_delivery_at timestamp with time zone,
+
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone
+);
+
+create index if not exists idx_webhooks_deleted_at on webhooks (deleted_at);
+create index if not exists idx_webhooks_user_id on webhooks (user_id);
+create index if not exists idx_webhooks_events on webhooks using gin (events) where active = true and deleted_at is null;
@@ -0,0 +1,9 @@
+drop trigger if exists version_targets_sync_changes on version_targets;
+drop trigger if exists versions_sync_changes on versions;
+drop trigger if exists user_mods_sync_changes on user_mods;
+drop trigger if exists mod_tags_sync_changes on mod_tags;
+drop trigger if exists mods_sync_changes on mods;
+
+drop function if exists record_sync_change();
+
+drop table if exists sync_changes;
@@ -0,0 +1,53 @@
+create table if not exists sync_changes
+(
+    id bigserial constraint sync_changes_pkey primary key,
+    entity_type varchar(16) not null,
+    entity_id varchar(14) not null,
+    created_at timestamp with time zone default now() not null
+);
+
+create index if not exists idx_sync_changes_created_at on sync_changes (created_at);
+
+-- Arguments: entity type, column of the entity id, columns of which changes are not recorded
+create or replace function record_sync_change() returns trigger as
+$$
+declare
+    ignored text[] := tg_argv[2:];
+    changed jsonb;
+begin
+    if tg_op = 'DELETE' then
+        changed := to_jsonb(old);
+    else
+        changed := to_jsonb(new);
+    end if;
+
+    if tg_op = 'UPDATE' and changed - ignored = to_jsonb(old) - ignored then
+        return null;
+    end if;
+
+    insert into sync_changes (entity_type, entity_id) values (tg_argv[0], changed ->> tg_argv[1]);
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger mods_sync_changes
+    after insert or update or delete on mods
+    for each row execute procedure record_sync_change('mod', 'id',
+        'updated_at', 'downloads', 'popularity', 'hotness', 'views', 'favorites', 'rating', 'rating_count');
+
+create trigger mod_tags_sync_changes
+    after insert or update or delete on mod_tags
+    for each row execute procedure record_sync_change('mod', 'mod_id');
+
+create trigger user_mods_sync_changes
+    after insert or update or delete on user_mods
+    for each row execute procedure record_sync_change('mod', 'mod_id');
+
+create trigger versions_sync_changes
+    after insert or update or delete on versions
+    for each row execute procedure record_sync_change('version', 'id',
+        'updated_at', 'downloads', 'hotness', 'last_downloaded_at', 'integrity_checked_at', 'retention_notified_at', 'storage_tier');
+
+create trigger version_targets_sync_changes
+    after insert or update or delete on version_targets
+    for each row execute procedure record_sync_change('version', 'version_id');
@@ -0,0 +1,6 @@
+drop index if exists idx_audit_logs_created_at;
+drop index if exists idx_audit_logs_action;
+
+alter table audit_logs
+    drop column if exists ip,
+    drop column if exists changes;
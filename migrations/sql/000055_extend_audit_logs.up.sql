@@ -0,0 +1,6 @@
+alter table audit_logs
+    add column if not exists changes jsonb,
+    add column if not exists ip varchar(45);
+
+create index if not exists idx_audit_logs_action on audit_logs (action, created_at);
+create index if not exists idx_audit_logs_created_at on audit_logs (created_at);
@@ -0,0 +1,19 @@
+drop trigger if exists mods_sync_changes on mods;
+
+create trigger mods_sync_changes
+    after insert or update or delete on mods
+    for each row execute procedure record_sync_change('mod', 'id',
+        'updated_at', 'downloads', 'popularity', 'hotness', 'views', 'favorites', 'rating', 'rating_count');
+
+drop trigger if exists tags_search_vector on tags;
+drop trigger if exists mod_tags_search_vector on mod_tags;
+drop trigger if exists mods_search_vector on mods;
+
+drop function if exists refresh_mod_search_vectors();
+drop function if exists set_mod_search_vector();
+drop function if exists mod_search_vector(varchar, text, text, text);
+
+drop index if exists idx_mods_search_vector;
+
+alter table mods
+    drop column if exists search_vector;
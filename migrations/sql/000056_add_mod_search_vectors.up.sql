@@ -0,0 +1,77 @@
+alter table mods
+    add column if not exists search_vector tsvector;
+
+-- Names weigh the most, then tags and short descriptions, then full descriptions
+create or replace function mod_search_vector(mod_id varchar, name text, short_description text, full_description text) returns tsvector as
+$$
+select setweight(to_tsvector('english', coalesce($2, '')), 'A') ||
+       setweight(to_tsvector('english', coalesce((
+           select string_agg(tags.name, ' ')
+           from mod_tags
+                    join tags on tags.id = mod_tags.tag_id
+           where mod_tags.mod_id = $1
+             and tags.deleted_at is null
+       ), '')), 'B') ||
+       setweight(to_tsvector('english', coalesce($3, '')), 'B') ||
+       setweight(to_tsvector('english', coalesce($4, '')), 'C');
+$$ language sql stable;
+
+create or replace function set_mod_search_vector() returns trigger as
+$$
+begin
+    new.search_vector := mod_search_vector(new.id, new.name, new.short_description, new.full_description);
+    return new;
+end;
+$$ language plpgsql;
+
+create trigger mods_search_vector
+    before insert or update of name, short_description, full_description on mods
+    for each row execute procedure set_mod_search_vector();
+
+-- Refreshes the vectors of the mods whose tags changed
+create or replace function refresh_mod_search_vectors() returns trigger as
+$$
+begin
+    if tg_table_name = 'tags' then
+        update mods
+        set search_vector = mod_search_vector(id, name, short_description, full_description)
+        where id in (select mod_id from mod_tags where tag_id = new.id);
+        return null;
+    end if;
+
+    if tg_op <> 'INSERT' then
+        update mods
+        set search_vector = mod_search_vector(id, name, short_description, full_description)
+        where id = old.mod_id;
+    end if;
+
+    if tg_op <> 'DELETE' then
+        update mods
+        set search_vector = mod_search_vector(id, name, short_description, full_description)
+        where id = new.mod_id;
+    end if;
+
+    return null;
+end;
+$$ language plpgsql;
+
+create trigger mod_tags_search_vector
+    after insert or update or delete on mod_tags
+    for each row execute procedure refresh_mod_search_vectors();
+
+create trigger tags_search_vector
+    after update of name, deleted_at on tags
+    for each row execute procedure refresh_mod_search_vectors();
+
+-- The vectors are derived from the other columns, their changes are not synced on their own
+drop trigger if exists mods_sync_changes on mods;
+
+create trigger mods_sync_changes
+    after insert or update or delete on mods
+    for each row execute procedure record_sync_change('mod', 'id',
+        'updated_at', 'downloads', 'popularity', 'hotness', 'views', 'favorites', 'rating', 'rating_count', 'search_vector');
+
+update mods
+set search_vector = mod_search_vector(id, name, short_description, full_description);
+
+create index if not exists idx_mods_search_vector on mods using gin (search_vector);
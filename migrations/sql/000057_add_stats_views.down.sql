@@ -0,0 +1,3 @@
+drop materialized view if exists stats_top_authors;
+drop materialized view if exists stats_weekly_versions;
+drop materialized view if exists stats_mod_downloads;
@@ -0,0 +1,53 @@
+-- Aggregates of the public mods for the stats queries, refreshed by the site stats job.
+-- Every view has a unique index, so that it can be refreshed concurrently without blocking reads.
+
+create materialized view if not exists stats_mod_downloads as
+select mods.id                                      as mod_id,
+       mods.name                                    as name,
+       mods.mod_reference                           as mod_reference,
+       mods.downloads::bigint                       as downloads,
+       count(versions.id)                           as versions,
+       max(versions.created_at)                     as last_version_at
+from mods
+         left join versions on versions.mod_id = mods.id
+    and versions.approved = true and versions.denied = false and versions.draft = false and versions.deleted_at is null
+where mods.approved = true
+  and mods.denied = false
+  and mods.hidden = false
+  and mods.deleted_at is null
+group by mods.id;
+
+create unique index if not exists idx_stats_mod_downloads_mod_id on stats_mod_downloads (mod_id);
+create index if not exists idx_stats_mod_downloads_downloads on stats_mod_downloads (downloads desc);
+
+create materialized view if not exists stats_weekly_versions as
+select date_trunc('week', versions.created_at) as week,
+       count(*)                                as versions,
+       count(distinct versions.mod_id)         as mods
+from versions
+         join mods on mods.id = versions.mod_id
+where versions.approved = true
+  and versions.denied = false
+  and versions.draft = false
+  and versions.deleted_at is null
+  and mods.approved = true
+  and mods.denied = false
+  and mods.hidden = false
+  and mods.deleted_at is null
+group by date_trunc('week', versions.created_at);
+
+create unique index if not exists idx_stats_weekly_versions_week on stats_weekly_versions (week);
+
+create materialized view if not exists stats_top_authors as
+select users.id                                   as user_id,
+       users.username                             as username,
+       count(stats_mod_downloads.mod_id)          as mods,
+       sum(stats_mod_downloads.downloads)::bigint as downloads,
+       max(stats_mod_downloads.last_version_at)   as last_version_at
+from user_mods
+         join users on users.id = user_mods.user_id and users.deleted_at is null
+         join stats_mod_downloads on stats_mod_downloads.mod_id = user_mods.mod_id
+group by users.id;
+
+create unique index if not exists idx_stats_top_authors_user_id on stats_top_authors (user_id);
+create index if not exists idx_stats_top_authors_downloads on stats_top_authors (downloads desc);
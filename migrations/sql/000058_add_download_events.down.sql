@@ -0,0 +1,3 @@
+drop table if exists download_event_rollups;
+drop function if exists create_download_events_partition(date);
+drop table if exists download_events;
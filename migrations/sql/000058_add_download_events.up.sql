@@ -0,0 +1,37 @@
+-- Every download, partitioned by month. The counters of versions, version_download_stats and mod_daily_stats
+-- are rolled up from the events periodically instead of being incremented by every download.
+create table if not exists download_events
+(
+    created_at  timestamp with time zone default now() not null,
+    version_id  varchar(14)                            not null,
+    mod_id      varchar(14)                            not null,
+    target_name varchar(16) default ''                 not null
+) partition by range (created_at);
+
+create index if not exists idx_download_events_created_at on download_events (created_at);
+create index if not exists idx_download_events_mod_id_created_at on download_events (mod_id, created_at);
+
+-- Creates the partition holding the events of the month of the provided day, named download_events_yYYYYmMM
+create or replace function create_download_events_partition(day date) returns void as
+$$
+declare
+    month date := date_trunc('month', day)::date;
+begin
+    execute format('create table if not exists %I partition of download_events for values from (%L) to (%L)',
+                   'download_events_' || to_char(month, '"y"YYYY"m"MM'), month, (month + interval '1 month')::date);
+end;
+$$ language plpgsql;
+
+select create_download_events_partition(current_date);
+select create_download_events_partition((current_date + interval '1 month')::date);
+
+-- Events before rolled_up_until are counted in the aggregate tables
+create table if not exists download_event_rollups
+(
+    id              integer primary key,
+    rolled_up_until timestamp with time zone not null
+);
+
+insert into download_event_rollups (id, rolled_up_until)
+values (1, now())
+on conflict do nothing;
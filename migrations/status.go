@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/pkg/errors"
+)
+
+// Status compares the schema of the database with the migrations embedded in the binary
+type Status struct {
+	// Version is the applied schema version, or 0 if none was applied yet
+	Version uint
+	// Dirty is set when a migration failed halfway, and the schema has to be fixed by hand
+	Dirty bool
+	// Latest is the version of the newest embedded schema migration
+	Latest uint
+	// Pending lists the embedded schema migrations which are not applied yet
+	Pending []string
+	// PendingCode lists the code migrations which are not applied yet
+	PendingCode []string
+	// Drift describes every way the applied schema differs from the embedded migrations
+	Drift []string
+}
+
+// UpToDate reports whether the binary can safely run against the database
+func (s *Status) UpToDate() bool {
+	return !s.Dirty && len(s.Pending) == 0 && len(s.PendingCode) == 0 && len(s.Drift) == 0
+}
+
+// GetStatus compares the database with the embedded migrations, without changing the schema
+func GetStatus(ctx context.Context) (*Status, error) {
+	status := &Status{}
+
+	if err := withMigrate(ctx, func(m *migrate.Migrate) error {
+		version, dirty, err := schemaVersion(m)
+		if err != nil {
+			return err
+		}
+
+		status.Version = version
+		status.Dirty = dirty
+
+		migrations, err := embeddedMigrations()
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			status.Latest = migration.Version
+
+			if migration.Version > version {
+				status.Pending = append(status.Pending, fmt.Sprintf("%d_%s", migration.Version, migration.Name))
+			}
+		}
+
+		// Databases migrated before checksums were tracked get them recorded on their first check
+		if err := recordChecksums(ctx, version); err != nil {
+			return err
+		}
+
+		status.Drift, err = verifyChecksums(ctx, version)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	pendingCode, err := pendingCodeMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status.PendingCode = pendingCode
+
+	return status, nil
+}
+
+// Verify returns an error if the database is not exactly at the schema the binary was built against
+func Verify(ctx context.Context) error {
+	status, err := GetStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if status.Dirty {
+		return fmt.Errorf("schema is dirty at version %d and has to be fixed by hand", status.Version)
+	}
+
+	if len(status.Drift) > 0 {
+		return errors.New("schema has drifted: " + strings.Join(status.Drift, "; "))
+	}
+
+	if len(status.Pending) > 0 || len(status.PendingCode) > 0 {
+		return fmt.Errorf("%d schema and %d code migrations are pending, run them with migrate up or serve --migrate", len(status.Pending), len(status.PendingCode))
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Cursor is the position of a row in an ordered list.
+//
+// It holds the value of the ordered column of the row, and the id of the row
+// as a tiebreaker. A cursor without a value points at a row where the column is null.
+type Cursor struct {
+	ID     string     `json:"i"`
+	Time   *time.Time `json:"t,omitempty"`
+	Number *int64     `json:"n,omitempty"`
+	Float  *float64   `json:"f,omitempty"`
+	String *string    `json:"s,omitempty"`
+}
+
+// NewCursor creates the cursor of a row from its id and the value of its ordered column
+func NewCursor(id string, value interface{}) *Cursor {
+	cursor := &Cursor{ID: id}
+
+	switch v := value.(type) {
+	case time.Time:
+		cursor.Time = &v
+	case *time.Time:
+		cursor.Time = v
+	case int:
+		n := int64(v)
+		cursor.Number = &n
+	case uint:
+		n := int64(v)
+		cursor.Number = &n
+	case float64:
+		cursor.Float = &v
+	case *float64:
+		cursor.Float = v
+	case string:
+		cursor.String = &v
+	}
+
+	return cursor
+}
+
+// ParseCursor decodes a cursor created by Encode
+func ParseCursor(encoded string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &cursor, nil
+}
+
+// Encode returns the opaque representation of the cursor
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Value returns the value of the ordered column, or nil if it was null
+func (c *Cursor) Value() interface{} {
+	switch {
+	case c.Time != nil:
+		return *c.Time
+	case c.Number != nil:
+		return *c.Number
+	case c.Float != nil:
+		return *c.Float
+	case c.String != nil:
+		return *c.String
+	}
+	return nil
+}
+
+// applyPagination applies the first and after arguments of a filter.
+//
+// first takes precedence over limit, and the offset is ignored when paginating after a cursor.
+func applyPagination(first *int, after *string, limit **int, offset **int) (*Cursor, error) {
+	if first != nil {
+		*limit = first
+	}
+
+	if after == nil || *after == "" {
+		return nil, nil
+	}
+
+	cursor, err := ParseCursor(*after)
+	if err != nil {
+		return nil, err
+	}
+
+	zero := 0
+	*offset = &zero
+
+	return cursor, nil
+}
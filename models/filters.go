@@ -3,8 +3,10 @@ package models
 import (
 	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/Masterminds/semver/v3"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -18,6 +20,9 @@ var dataValidator = validator.New()
 type VersionFilter struct {
 	Limit   *int                     `json:"limit" validate:"omitempty,min=1,max=100"`
 	Offset  *int                     `json:"offset" validate:"omitempty,min=0"`
+	First   *int                     `json:"first" validate:"omitempty,min=1,max=100"`
+	After   *string                  `json:"after"`
+	Cursor  *Cursor                  `json:"-"`
 	OrderBy *generated.VersionFields `json:"order_by"`
 	Order   *generated.Order         `json:"order"`
 	Search  *string                  `json:"search" validate:"omitempty,min=3"`
@@ -29,6 +34,7 @@ func (f *VersionFilter) IsDefault(ignoreLimits bool) bool {
 	return ((f.Limit != nil && *f.Limit == 10) || ignoreLimits) &&
 		f.Offset != nil && *f.Offset == 0 &&
 		f.Ids == nil &&
+		f.Cursor == nil &&
 		f.Order != nil && *f.Order == generated.OrderDesc &&
 		f.OrderBy != nil && *f.OrderBy == generated.VersionFieldsCreatedAt
 }
@@ -88,6 +94,12 @@ func ProcessVersionFilter(filter map[string]interface{}) (*VersionFilter, error)
 		return nil, err
 	}
 
+	cursor, err := applyPagination(base.First, base.After, &base.Limit, &base.Offset)
+	if err != nil {
+		return nil, err
+	}
+	base.Cursor = cursor
+
 	if err := dataValidator.Struct(base); err != nil {
 		return nil, errors.Wrap(err, "failed to validate VersionFilter")
 	}
@@ -98,6 +110,9 @@ func ProcessVersionFilter(filter map[string]interface{}) (*VersionFilter, error)
 type ModFilter struct {
 	Limit      *int                 `json:"limit" validate:"omitempty,min=1,max=100"`
 	Offset     *int                 `json:"offset" validate:"omitempty,min=0"`
+	First      *int                 `json:"first" validate:"omitempty,min=1,max=100"`
+	After      *string              `json:"after"`
+	Cursor     *Cursor              `json:"-"`
 	OrderBy    *generated.ModFields `json:"order_by"`
 	Order      *generated.Order     `json:"order"`
 	Search     *string              `json:"search" validate:"omitempty,min=3"`
@@ -106,6 +121,13 @@ type ModFilter struct {
 	Hidden     *bool                `json:"hidden"`
 	Fields     []string             `json:"-"`
 	TagIDs     []string             `json:"tagIDs" validate:"omitempty,max=100"`
+
+	Targets           []generated.TargetName `json:"targets" validate:"omitempty,max=10"`
+	SMLVersion        *string                `json:"sml_version"`
+	GameVersion       *int                   `json:"game_version" validate:"omitempty,min=0"`
+	LastVersionAfter  *time.Time             `json:"last_version_after"`
+	LastVersionBefore *time.Time             `json:"last_version_before"`
+	IncludeArchived   *bool                  `json:"include_archived"`
 }
 
 func DefaultModFilter() *ModFilter {
@@ -137,11 +159,17 @@ func (f *ModFilter) AddField(name string) {
 		"downloads",
 		"hotness",
 		"popularity",
+		"favorites",
+		"rating",
+		"rating_count",
 		"updated_at",
 		"created_at",
 		"last_version_date",
 		"mod_reference",
 		"hidden",
+		"archived",
+		"archive_reason",
+		"archived_at",
 		"compatibility":
 		f.Fields = append(f.Fields, "mods."+name)
 	}
@@ -166,16 +194,35 @@ func ProcessModFilter(filter map[string]interface{}) (*ModFilter, error) {
 		return nil, err
 	}
 
+	cursor, err := applyPagination(base.First, base.After, &base.Limit, &base.Offset)
+	if err != nil {
+		return nil, err
+	}
+	base.Cursor = cursor
+
 	if err := dataValidator.Struct(base); err != nil {
 		return nil, errors.Wrap(err, "failed to validate ModFilter")
 	}
 
+	if base.SMLVersion != nil {
+		if _, err := semver.NewConstraint(*base.SMLVersion); err != nil {
+			return nil, errors.Wrap(err, "invalid sml_version constraint")
+		}
+	}
+
+	if base.Cursor != nil && *base.OrderBy == generated.ModFieldsSearch {
+		return nil, errors.New("cursors are not supported when ordering by search relevance")
+	}
+
 	return base, nil
 }
 
 type GuideFilter struct {
 	Limit   *int                   `json:"limit" validate:"omitempty,min=1,max=100"`
 	Offset  *int                   `json:"offset" validate:"omitempty,min=0"`
+	First   *int                   `json:"first" validate:"omitempty,min=1,max=100"`
+	After   *string                `json:"after"`
+	Cursor  *Cursor                `json:"-"`
 	OrderBy *generated.GuideFields `json:"order_by"`
 	Order   *generated.Order       `json:"order"`
 	Search  *string                `json:"search" validate:"omitempty,min=3"`
@@ -216,6 +263,12 @@ func ProcessGuideFilter(filter map[string]interface{}) (*GuideFilter, error) {
 		return nil, err
 	}
 
+	cursor, err := applyPagination(base.First, base.After, &base.Limit, &base.Offset)
+	if err != nil {
+		return nil, err
+	}
+	base.Cursor = cursor
+
 	if err := dataValidator.Struct(base); err != nil {
 		return nil, errors.Wrap(err, "failed to validate GuideFilter")
 	}
@@ -223,9 +276,71 @@ func ProcessGuideFilter(filter map[string]interface{}) (*GuideFilter, error) {
 	return base, nil
 }
 
+type BlueprintFilter struct {
+	Limit   *int                       `json:"limit" validate:"omitempty,min=1,max=100"`
+	Offset  *int                       `json:"offset" validate:"omitempty,min=0"`
+	First   *int                       `json:"first" validate:"omitempty,min=1,max=100"`
+	After   *string                    `json:"after"`
+	Cursor  *Cursor                    `json:"-"`
+	OrderBy *generated.BlueprintFields `json:"order_by"`
+	Order   *generated.Order           `json:"order"`
+	Search  *string                    `json:"search" validate:"omitempty,min=3"`
+	Ids     []string                   `json:"ids" validate:"omitempty,max=100"`
+	TagIDs  []string                   `json:"tagIDs" validate:"omitempty,max=100"`
+}
+
+func (f BlueprintFilter) Hash() (string, error) {
+	hash, err := hashstructure.Hash(f, hashstructure.FormatV2, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash BlueprintFilter")
+	}
+	return strconv.FormatUint(hash, 10), nil
+}
+
+func DefaultBlueprintFilter() *BlueprintFilter {
+	limit := 10
+	offset := 0
+	order := generated.OrderDesc
+	orderBy := generated.BlueprintFieldsCreatedAt
+	return &BlueprintFilter{
+		Limit:   &limit,
+		Offset:  &offset,
+		Ids:     nil,
+		Order:   &order,
+		OrderBy: &orderBy,
+	}
+}
+
+func ProcessBlueprintFilter(filter map[string]interface{}) (*BlueprintFilter, error) {
+	base := DefaultBlueprintFilter()
+
+	if filter == nil {
+		return base, nil
+	}
+
+	if err := ApplyChanges(filter, base); err != nil {
+		return nil, err
+	}
+
+	cursor, err := applyPagination(base.First, base.After, &base.Limit, &base.Offset)
+	if err != nil {
+		return nil, err
+	}
+	base.Cursor = cursor
+
+	if err := dataValidator.Struct(base); err != nil {
+		return nil, errors.Wrap(err, "failed to validate BlueprintFilter")
+	}
+
+	return base, nil
+}
+
 type SMLVersionFilter struct {
 	Limit   *int                        `json:"limit" validate:"omitempty,min=1,max=100"`
 	Offset  *int                        `json:"offset" validate:"omitempty,min=0"`
+	First   *int                        `json:"first" validate:"omitempty,min=1,max=100"`
+	After   *string                     `json:"after"`
+	Cursor  *Cursor                     `json:"-"`
 	OrderBy *generated.SMLVersionFields `json:"order_by"`
 	Order   *generated.Order            `json:"order"`
 	Search  *string                     `json:"search" validate:"omitempty,min=3"`
@@ -257,6 +372,12 @@ func ProcessSMLVersionFilter(filter map[string]interface{}) (*SMLVersionFilter,
 		return nil, err
 	}
 
+	cursor, err := applyPagination(base.First, base.After, &base.Limit, &base.Offset)
+	if err != nil {
+		return nil, err
+	}
+	base.Cursor = cursor
+
 	if err := dataValidator.Struct(base); err != nil {
 		return nil, errors.Wrap(err, "failed to validate SMLVersionFilter")
 	}
@@ -271,6 +392,12 @@ func ApplyChanges(changes interface{}, to interface{}) error {
 		Result:      to,
 		ZeroFields:  true,
 		DecodeHook: func(a reflect.Type, b reflect.Type, v interface{}) (interface{}, error) {
+			if b == reflect.TypeOf(time.Time{}) {
+				if date, ok := v.(string); ok {
+					return time.Parse(time.RFC3339Nano, date)
+				}
+			}
+
 			if reflect.PtrTo(b).Implements(reflect.TypeOf((*graphql.Unmarshaler)(nil)).Elem()) {
 				resultType := reflect.New(b)
 				result := resultType.MethodByName("UnmarshalGQL").Call([]reflect.Value{reflect.ValueOf(v)})
@@ -291,6 +418,9 @@ func ApplyChanges(changes interface{}, to interface{}) error {
 type BootstrapVersionFilter struct {
 	Limit   *int                              `json:"limit" validate:"omitempty,min=1,max=100"`
 	Offset  *int                              `json:"offset" validate:"omitempty,min=0"`
+	First   *int                              `json:"first" validate:"omitempty,min=1,max=100"`
+	After   *string                           `json:"after"`
+	Cursor  *Cursor                           `json:"-"`
 	OrderBy *generated.BootstrapVersionFields `json:"order_by"`
 	Order   *generated.Order                  `json:"order"`
 	Search  *string                           `json:"search" validate:"omitempty,min=3"`
@@ -322,6 +452,12 @@ func ProcessBootstrapVersionFilter(filter map[string]interface{}) (*BootstrapVer
 		return nil, err
 	}
 
+	cursor, err := applyPagination(base.First, base.After, &base.Limit, &base.Offset)
+	if err != nil {
+		return nil, err
+	}
+	base.Cursor = cursor
+
 	if err := dataValidator.Struct(base); err != nil {
 		return nil, errors.Wrap(err, "failed to validate BootstrapVersionFilter")
 	}
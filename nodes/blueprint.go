@@ -0,0 +1,123 @@
+package nodes
+
+import (
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/models"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/storage"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// @Summary Retrieve a list of Blueprints
+// @Tags Blueprints
+// @Description Retrieve a list of blueprints
+// @Accept  json
+// @Produce  json
+// @Param limit query int false "How many blueprints to return"
+// @Param offset query int false "Offset for list of blueprints to return"
+// @Param order_by query string false "Order by field" Enums(created_at, updated_at, name, views, downloads)
+// @Param order query string false "Order of results" Enums(asc, desc)
+// @Param search query string false "Search string"
+// @Success 200 {object} GenericResponse{data=[]Blueprint}
+// @Router /v1/blueprints [get]
+func getBlueprints(c echo.Context) (interface{}, *ErrorResponse) {
+	filter := blueprintFilterFromQuery(c)
+
+	blueprints := postgres.GetBlueprints(c.Request().Context(), filter)
+
+	converted := make([]*Blueprint, len(blueprints))
+	for k, v := range blueprints {
+		converted[k] = BlueprintToBlueprint(&v, true)
+	}
+
+	return converted, nil
+}
+
+// @Summary Retrieve a count of Blueprints
+// @Tags Blueprints
+// @Description Retrieve a count of Blueprints
+// @Accept  json
+// @Produce  json
+// @Param search query string false "Search string"
+// @Success 200 {object} GenericResponse{data=int}
+// @Router /v1/blueprints/count [get]
+func getBlueprintCount(c echo.Context) (interface{}, *ErrorResponse) {
+	return postgres.GetBlueprintCount(c.Request().Context(), blueprintFilterFromQuery(c)), nil
+}
+
+// @Summary Retrieve a Blueprint
+// @Tags Blueprint
+// @Description Retrieve a blueprint by blueprint ID
+// @Accept  json
+// @Produce  json
+// @Param blueprintId path string true "Blueprint ID"
+// @Success 200 {object} GenericResponse{data=Blueprint}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Blueprint not found"
+// @Router /v1/blueprint/{blueprintId} [get]
+func getBlueprint(c echo.Context) (interface{}, *ErrorResponse) {
+	blueprintID := c.Param("blueprintId")
+
+	blueprint := postgres.GetBlueprintByID(c.Request().Context(), blueprintID)
+
+	if blueprint == nil {
+		return nil, &ErrorBlueprintNotFound
+	}
+
+	if _, ok := c.QueryParams()["view"]; ok {
+		if redis.CanIncrement(c.RealIP(), "view", "blueprint:"+blueprintID, time.Hour*4) {
+			postgres.IncrementBlueprintViews(c.Request().Context(), blueprint)
+		}
+	}
+
+	return BlueprintToBlueprint(blueprint, false), nil
+}
+
+// @Summary Download a Blueprint
+// @Tags Blueprint
+// @Description Download a blueprint package by blueprint ID
+// @Accept  json
+// @Produce  json
+// @Param blueprintId path string true "Blueprint ID"
+// @Success 302 "Redirect to the file"
+// @Failure 404 {string} string "Not found"
+// @Router /v1/blueprint/{blueprintId}/download [get]
+func downloadBlueprint(c echo.Context) error {
+	blueprintID := c.Param("blueprintId")
+
+	blueprint := postgres.GetBlueprintByID(c.Request().Context(), blueprintID)
+
+	if blueprint == nil || blueprint.Key == "" {
+		return c.String(404, "blueprint not found")
+	}
+
+	if redis.CanIncrement(c.RealIP(), "download", "blueprint:"+blueprintID, time.Hour*4) {
+		postgres.IncrementBlueprintDownloads(c.Request().Context(), blueprint)
+	}
+
+	return c.Redirect(302, storage.GenerateSignedDownloadLink(blueprint.Key))
+}
+
+func blueprintFilterFromQuery(c echo.Context) *models.BlueprintFilter {
+	filter := models.DefaultBlueprintFilter()
+
+	limit := util.GetIntRange(c, "limit", 1, 100, 25)
+	offset := util.GetIntRange(c, "offset", 0, 9999999, 0)
+	orderBy := generated.BlueprintFields(util.OneOf(c, "order_by", []string{"created_at", "updated_at", "name", "views", "downloads"}, "created_at"))
+	order := generated.Order(util.OneOf(c, "order", []string{"asc", "desc"}, "desc"))
+
+	filter.Limit = &limit
+	filter.Offset = &offset
+	filter.OrderBy = &orderBy
+	filter.Order = &order
+
+	if search := c.QueryParam("search"); search != "" {
+		filter.Search = &search
+	}
+
+	return filter
+}
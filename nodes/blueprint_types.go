@@ -0,0 +1,48 @@
+package nodes
+
+import (
+	"time"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+)
+
+type Blueprint struct {
+	UpdatedAt        time.Time `json:"updated_at"`
+	CreatedAt        time.Time `json:"created_at"`
+	UserID           string    `json:"user_id"`
+	FullDescription  string    `json:"full_description,omitempty"`
+	ID               string    `json:"id"`
+	ShortDescription string    `json:"short_description"`
+	Name             string    `json:"name"`
+	Hash             string    `json:"hash"`
+	Tags             []string  `json:"tags"`
+	Size             int64     `json:"size"`
+	Views            uint      `json:"views"`
+	Downloads        uint      `json:"downloads"`
+}
+
+func BlueprintToBlueprint(blueprint *postgres.Blueprint, short bool) *Blueprint {
+	result := Blueprint{
+		ID:               blueprint.ID,
+		Name:             blueprint.Name,
+		ShortDescription: blueprint.ShortDescription,
+		UserID:           blueprint.UserID,
+		Hash:             blueprint.Hash,
+		Size:             blueprint.Size,
+		Views:            blueprint.Views,
+		Downloads:        blueprint.Downloads,
+		UpdatedAt:        blueprint.UpdatedAt,
+		CreatedAt:        blueprint.CreatedAt,
+		Tags:             make([]string, len(blueprint.Tags)),
+	}
+
+	for i, tag := range blueprint.Tags {
+		result.Tags[i] = tag.Name
+	}
+
+	if !short {
+		result.FullDescription = blueprint.FullDescription
+	}
+
+	return &result
+}
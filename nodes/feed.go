@@ -0,0 +1,157 @@
+package nodes
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/rs/zerolog/log"
+	"github.com/russross/blackfriday"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/util/feed"
+)
+
+const mimeRSS = "application/rss+xml; charset=UTF-8"
+
+var changelogPolicy = bluemonday.UGCPolicy()
+
+// @Summary Retrieve the New Mods Feed
+// @Tags Feeds
+// @Description Retrieve an RSS feed of the newest approved mods
+// @Produce  xml
+// @Success 200 {string} string "RSS feed"
+// @Router /v1/feeds/mods.rss [get]
+func getModsFeed(c echo.Context) error {
+	return serveFeed(c, "mods", func(ctx context.Context) feed.Feed {
+		siteURL := feedSiteURL()
+
+		result := feed.Feed{
+			Title:       "New Satisfactory Mods",
+			Link:        siteURL,
+			Description: "The newest mods published on the Satisfactory Mod Repository",
+		}
+
+		for _, mod := range postgres.GetFeedMods(ctx, viper.GetInt("feeds.limit")) {
+			result.Items = append(result.Items, feed.Item{
+				Title:       mod.Name,
+				Link:        siteURL + "/mod/" + mod.ModReference,
+				Description: mod.ShortDescription,
+				Published:   mod.CreatedAt,
+			})
+		}
+
+		return result
+	})
+}
+
+// @Summary Retrieve the New Versions Feed
+// @Tags Feeds
+// @Description Retrieve an RSS feed of the newest approved versions of all mods
+// @Produce  xml
+// @Success 200 {string} string "RSS feed"
+// @Router /v1/feeds/versions.rss [get]
+func getVersionsFeed(c echo.Context) error {
+	return serveFeed(c, "versions", func(ctx context.Context) feed.Feed {
+		result := feed.Feed{
+			Title:       "New Satisfactory Mod Versions",
+			Link:        feedSiteURL(),
+			Description: "The newest versions published on the Satisfactory Mod Repository",
+		}
+
+		versions := postgres.GetFeedVersions(ctx, "", viper.GetInt("feeds.limit"))
+
+		modIDs := make([]string, 0)
+		seen := make(map[string]bool)
+		for _, version := range versions {
+			if !seen[version.ModID] {
+				seen[version.ModID] = true
+				modIDs = append(modIDs, version.ModID)
+			}
+		}
+
+		mods := make(map[string]*postgres.Mod)
+		if len(modIDs) > 0 {
+			for _, mod := range postgres.GetModsByID(ctx, modIDs) {
+				mod := mod
+				mods[mod.ID] = &mod
+			}
+		}
+
+		for _, version := range versions {
+			if mod, ok := mods[version.ModID]; ok {
+				result.Items = append(result.Items, versionFeedItem(mod, version))
+			}
+		}
+
+		return result
+	})
+}
+
+// @Summary Retrieve the Versions Feed of a Mod
+// @Tags Feeds
+// @Description Retrieve an RSS feed of the newest approved versions of a mod
+// @Produce  xml
+// @Param modId path string true "Mod ID or reference"
+// @Success 200 {string} string "RSS feed"
+// @Failure 404 {string} string "Mod not found"
+// @Router /v1/mod/{modId}/versions.rss [get]
+func getModVersionsFeed(c echo.Context) error {
+	mod := postgres.GetModByIDOrReference(c.Request().Context(), c.Param("modId"))
+	if mod == nil || !mod.Approved || mod.Denied || mod.Hidden {
+		return c.String(404, "mod not found")
+	}
+
+	return serveFeed(c, "mod:"+mod.ID, func(ctx context.Context) feed.Feed {
+		result := feed.Feed{
+			Title:       mod.Name + " Versions",
+			Link:        feedSiteURL() + "/mod/" + mod.ModReference,
+			Description: "The newest versions of " + mod.Name,
+		}
+
+		for _, version := range postgres.GetFeedVersions(ctx, mod.ID, viper.GetInt("feeds.limit")) {
+			result.Items = append(result.Items, versionFeedItem(mod, version))
+		}
+
+		return result
+	})
+}
+
+func versionFeedItem(mod *postgres.Mod, version postgres.Version) feed.Item {
+	return feed.Item{
+		Title:       mod.Name + " " + version.Version,
+		Link:        feedSiteURL() + "/mod/" + mod.ModReference + "/version/" + version.ID,
+		Description: string(changelogPolicy.SanitizeBytes(blackfriday.MarkdownCommon([]byte(version.Changelog)))),
+		Published:   version.CreatedAt,
+	}
+}
+
+func feedSiteURL() string {
+	return strings.TrimSuffix(viper.GetString("site.url"), "/")
+}
+
+// serveFeed sends the feed cached under name, building and caching it for feeds.cache_ttl if it is not cached
+func serveFeed(c echo.Context, name string, build func(ctx context.Context) feed.Feed) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+
+	if cached, ok := redis.GetFeed(name); ok {
+		return sendConditionalBlob(c, mimeRSS, cached, time.Time{})
+	}
+
+	result := build(c.Request().Context())
+	result.Self = c.Scheme() + "://" + c.Request().Host + c.Request().URL.Path
+
+	rendered, err := result.RenderRSS()
+	if err != nil {
+		log.Err(err).Str("feed", name).Msg("failed to render feed")
+		return c.String(500, "failed to render feed")
+	}
+
+	redis.StoreFeed(name, rendered, viper.GetDuration("feeds.cache_ttl"))
+
+	return sendConditionalBlob(c, mimeRSS, rendered, time.Time{})
+}
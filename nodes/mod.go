@@ -1,14 +1,20 @@
 package nodes
 
 import (
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
 
 	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/models"
 	"github.com/satisfactorymodding/smr-api/redis"
-	"github.com/satisfactorymodding/smr-api/storage"
 	"github.com/satisfactorymodding/smr-api/util"
 )
 
@@ -22,8 +28,8 @@ import (
 // @Param order_by query string false "Order by field" Enums(created_at, updated_at, name, views, downloads, hotness, popularity, last_version_date)
 // @Param order query string false "Order of results" Enums(asc, desc)
 // @Param search query string false "Search string"
-// @Success 200
-// @Router /mods [get]
+// @Success 200 {object} GenericResponse{data=[]Mod}
+// @Router /v1/mods [get]
 func getMods(c echo.Context) (interface{}, *ErrorResponse) {
 	limit := util.GetIntRange(c, "limit", 1, 100, 25)
 	offset := util.GetIntRange(c, "offset", 0, 9999999, 0)
@@ -47,8 +53,8 @@ func getMods(c echo.Context) (interface{}, *ErrorResponse) {
 // @Accept  json
 // @Produce  json
 // @Param search query string false "Search string"
-// @Success 200
-// @Router /mods/count [get]
+// @Success 200 {object} GenericResponse{data=int}
+// @Router /v1/mods/count [get]
 func getModCount(c echo.Context) (interface{}, *ErrorResponse) {
 	search := c.QueryParam("search")
 	return postgres.GetModCount(c.Request().Context(), search, false), nil
@@ -60,8 +66,9 @@ func getModCount(c echo.Context) (interface{}, *ErrorResponse) {
 // @Accept  json
 // @Produce  json
 // @Param modId path string true "Mod ID"
-// @Success 200
-// @Router /mod/{modId} [get]
+// @Success 200 {object} GenericResponse{data=Mod}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Mod not found"
+// @Router /v1/mod/{modId} [get]
 func getMod(c echo.Context) (interface{}, *ErrorResponse) {
 	modID := c.Param("modId")
 
@@ -86,8 +93,9 @@ func getMod(c echo.Context) (interface{}, *ErrorResponse) {
 // @Accept  json
 // @Produce  json
 // @Param modIds path string true "Mod IDs"
-// @Success 200
-// @Router /mods/{modIds} [get]
+// @Success 200 {object} GenericResponse{data=[]Mod}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Mod not found"
+// @Router /v1/mods/{modIds} [get]
 func getModsByIds(c echo.Context) (interface{}, *ErrorResponse) {
 	modID := c.Param("modIds")
 	modIDSplit := strings.Split(modID, ",")
@@ -108,24 +116,221 @@ func getModsByIds(c echo.Context) (interface{}, *ErrorResponse) {
 	return converted, nil
 }
 
+// @Summary Retrieve a list of Mods by mod reference
+// @Tags Mods
+// @Description Retrieve a list of mods by mod references, references without a matching mod are skipped
+// @Accept  json
+// @Produce  json
+// @Param modReferences path string true "Mod references, comma separated"
+// @Success 200 {object} GenericResponse{data=[]Mod}
+// @Failure 400 {object} GenericResponse{error=ErrorResponse} "Too many mod references"
+// @Router /v1/mods/references/{modReferences} [get]
+func getModsByReferences(c echo.Context) (interface{}, *ErrorResponse) {
+	modReferences := strings.Split(c.Param("modReferences"), ",")
+
+	if len(modReferences) > postgres.MaxModReferences {
+		return nil, &ErrorTooManyModReferences
+	}
+
+	mods := postgres.GetModsByReferences(c.Request().Context(), modReferences)
+
+	converted := make([]*Mod, len(mods))
+	for k, v := range mods {
+		converted[k] = ModToMod(&v, true)
+	}
+
+	return converted, nil
+}
+
+// Stabilities ordered by how stable they are
+var stabilities = []string{"release", "beta", "alpha"}
+
+// @Summary Check installed Mods for updates
+// @Tags Mods
+// @Description Retrieve the latest version of every installed mod, whether it is an update, and where to download it from.
+// @Description Meant for launchers, replacing a request per mod.
+// @Accept  json
+// @Produce  json
+// @Param request body ModUpdatesRequest true "Installed mods"
+// @Success 200 {object} GenericResponse{data=ModUpdates}
+// @Failure 400 {object} GenericResponse{error=ErrorResponse} "Invalid request"
+// @Router /v1/mods/updates [post]
+func getModUpdates(c echo.Context) (interface{}, *ErrorResponse) {
+	var request ModUpdatesRequest
+	if err := c.Bind(&request); err != nil {
+		return nil, GenericUserError(err)
+	}
+
+	if len(request.Mods) > postgres.MaxModReferences {
+		return nil, &ErrorTooManyModReferences
+	}
+
+	if request.GameVersion != nil && request.Target == "" {
+		return nil, GenericUserError(errors.New("game_version requires a target"))
+	}
+
+	allowed := stabilities[:1]
+	if request.Stability != "" {
+		index := -1
+		for i, stability := range stabilities {
+			if stability == request.Stability {
+				index = i
+			}
+		}
+
+		if index < 0 {
+			return nil, GenericUserError(errors.New("unknown stability " + request.Stability))
+		}
+
+		allowed = stabilities[:index+1]
+	}
+
+	ctx := c.Request().Context()
+
+	references := make([]string, len(request.Mods))
+	for i, installed := range request.Mods {
+		references[i] = installed.ModReference
+	}
+
+	mods := make(map[string]postgres.Mod)
+	modIDs := make([]string, 0, len(request.Mods))
+	for _, mod := range postgres.GetModsByReferences(ctx, references) {
+		mods[mod.ModReference] = mod
+		modIDs = append(modIDs, mod.ID)
+	}
+
+	latest := make(map[string]*postgres.Version)
+	if request.GameVersion != nil {
+		for _, version := range postgres.GetLatestCompatibleVersions(ctx, *request.GameVersion, request.Target) {
+			version := version
+			latest[version.ModID] = &version
+		}
+	} else if len(modIDs) > 0 {
+		for _, version := range *postgres.GetModsLatestVersions(ctx, modIDs, false) {
+			version := version
+			if !contains(allowed, version.Stability) || (request.Target != "" && !hasTarget(&version, request.Target)) {
+				continue
+			}
+
+			if current, ok := latest[version.ModID]; !ok || newerVersion(&version, current) {
+				latest[version.ModID] = &version
+			}
+		}
+	}
+
+	result := ModUpdates{
+		Mods:    make([]ModUpdate, 0, len(request.Mods)),
+		Unknown: make([]string, 0),
+	}
+
+	for _, installed := range request.Mods {
+		mod, ok := mods[installed.ModReference]
+		if !ok {
+			result.Unknown = append(result.Unknown, installed.ModReference)
+			continue
+		}
+
+		update := ModUpdate{
+			ModReference: installed.ModReference,
+			ModID:        mod.ID,
+			Installed:    installed.Version,
+		}
+
+		if version, ok := latest[mod.ID]; ok {
+			update.Latest = VersionToVersion(version)
+			update.UpdateAvailable = isUpdate(installed.Version, version.Version)
+			update.DownloadURL, update.TokenRequired = downloadURL(routePrefix(c), version, request.Target)
+		}
+
+		result.Mods = append(result.Mods, update)
+	}
+
+	return result, nil
+}
+
+// isUpdate returns whether the latest version is newer than the installed one.
+// Versions which are not semver are an update whenever they differ.
+func isUpdate(installed string, latest string) bool {
+	installedVersion, err := semver.NewVersion(installed)
+	if err != nil {
+		return installed != latest
+	}
+
+	latestVersion, err := semver.NewVersion(latest)
+	if err != nil {
+		return installed != latest
+	}
+
+	return latestVersion.GreaterThan(installedVersion)
+}
+
+func newerVersion(version *postgres.Version, than *postgres.Version) bool {
+	a, errA := semver.NewVersion(version.Version)
+	b, errB := semver.NewVersion(than.Version)
+	if errA != nil || errB != nil {
+		return version.CreatedAt.After(than.CreatedAt)
+	}
+	return a.GreaterThan(b)
+}
+
+func hasTarget(version *postgres.Version, target string) bool {
+	for _, versionTarget := range version.Targets {
+		if versionTarget.TargetName == target {
+			return true
+		}
+	}
+	return false
+}
+
+// downloadURL returns the path below the API prefix to download the version from,
+// or to create a download token for it if tokens are required
+func downloadURL(prefix string, version *postgres.Version, target string) (string, bool) {
+	if viper.GetBool("downloads.tokens.required") {
+		if target != "" {
+			return prefix + "/version/" + version.ID + "/download-token?target=" + url.QueryEscape(target), true
+		}
+		return prefix + "/version/" + version.ID + "/download-token", true
+	}
+
+	if target != "" {
+		return prefix + "/version/" + version.ID + "/" + url.PathEscape(target) + "/download", false
+	}
+
+	return prefix + "/version/" + version.ID + "/download", false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // @Summary Retrieve a list of latest versions for a mod
 // @Tags Mod
-// @Description Retrieve a list of latest versions for a mod based on mod id
+// @Description Retrieve the latest version of every stability of a mod, based on mod id or mod reference
 // @Accept  json
 // @Produce  json
-// @Param modId path string true "Mod ID"
-// @Success 200
-// @Router /mod/{modId}/latest-versions [get]
+// @Param modId path string true "Mod ID or mod reference"
+// @Success 200 {object} GenericResponse{data=map[string]Version}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Mod or version not found"
+// @Router /v1/mod/{modId}/latest-versions [get]
 func getModLatestVersions(c echo.Context) (interface{}, *ErrorResponse) {
-	modID := c.Param("modId")
+	mod := postgres.GetModByIDOrReference(c.Request().Context(), c.Param("modId"))
+
+	if mod == nil {
+		return nil, &ErrorModNotFound
+	}
 
-	versions := postgres.GetModsLatestVersions(c.Request().Context(), []string{modID}, false)
+	versions := postgres.GetModsLatestVersions(c.Request().Context(), []string{mod.ID}, false)
 
 	if versions == nil {
 		return nil, &ErrorVersionNotFound
 	}
 
-	result := make(map[string]*Version)
+	result := make(LatestVersions)
 
 	for _, v := range *versions {
 		result[v.Stability] = VersionToVersion(&v)
@@ -134,14 +339,78 @@ func getModLatestVersions(c echo.Context) (interface{}, *ErrorResponse) {
 	return result, nil
 }
 
+// @Summary Retrieve the latest version of a mod for a target
+// @Tags Mod
+// @Description Retrieve the newest approved version of a mod having a file for the target, and where to download it from.
+// @Description Meant for install scripts of dedicated servers.
+// @Accept  json
+// @Produce  json
+// @Param modId path string true "Mod ID or mod reference"
+// @Param target path string true "Target name, such as WindowsServer or LinuxServer"
+// @Param game_version query int false "Only versions supporting this game build"
+// @Param sml_version query string false "Only versions supporting an SML release matching this version or constraint"
+// @Success 200 {object} GenericResponse{data=TargetVersion}
+// @Failure 400 {object} GenericResponse{error=ErrorResponse} "Invalid request"
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Mod or version not found"
+// @Router /v1/mod/{modId}/latest/{target} [get]
+func getModLatestTargetVersion(c echo.Context) (interface{}, *ErrorResponse) {
+	ctx := c.Request().Context()
+	target := c.Param("target")
+
+	var gameVersion *int
+	if value := c.QueryParam("game_version"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, GenericUserError(errors.New("game_version must be a game build number"))
+		}
+		gameVersion = &parsed
+	}
+
+	var smlVersion *string
+	if value := c.QueryParam("sml_version"); value != "" {
+		if _, err := semver.NewConstraint(value); err != nil {
+			return nil, GenericUserError(errors.Wrap(err, "invalid sml_version"))
+		}
+		smlVersion = &value
+	}
+
+	mod := postgres.GetModByIDOrReference(ctx, c.Param("modId"))
+
+	if mod == nil {
+		return nil, &ErrorModNotFound
+	}
+
+	version := postgres.GetLatestTargetVersion(ctx, mod.ID, target, smlVersion, gameVersion)
+
+	if version == nil {
+		return nil, &ErrorVersionNotFound
+	}
+
+	result := &TargetVersion{
+		Version: VersionToVersion(version),
+	}
+
+	for _, versionTarget := range version.Targets {
+		if versionTarget.TargetName == target {
+			converted := VersionTargetToVersionTarget(versionTarget)
+			result.Target = &converted
+		}
+	}
+
+	result.DownloadURL, result.TokenRequired = downloadURL(routePrefix(c), version, target)
+
+	return result, nil
+}
+
 // @Summary Retrieve a list of latest versions for mods
 // @Tags Mods
 // @Description Retrieve a list of latest versions for mods based on mod id
 // @Accept  json
 // @Produce  json
 // @Param modIds path string true "Mod IDs"
-// @Success 200
-// @Router /mods/{modIds}/latest-versions [get]
+// @Success 200 {object} GenericResponse{data=map[string]map[string]Version}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Version not found"
+// @Router /v1/mods/{modIds}/latest-versions [get]
 func getModsLatestVersions(c echo.Context) (interface{}, *ErrorResponse) {
 	modID := c.Param("modIds")
 	modIDSplit := strings.Split(modID, ",")
@@ -168,31 +437,36 @@ func getModsLatestVersions(c echo.Context) (interface{}, *ErrorResponse) {
 
 // @Summary Retrieve a Mod Versions
 // @Tags Mod
-// @Description Retrieve a mod versions by mod ID
+// @Description Retrieve the approved versions of a mod by mod ID or mod reference, filtered the same way as the versions of a mod in GraphQL
 // @Accept  json
 // @Produce  json
 // @Param limit query int false "How many versions to return"
 // @Param offset query int false "Offset for list of versions to return"
-// @Param order_by query string false "Order by field" Enums(created_at, updated_at)
+// @Param order_by query string false "Order by field" Enums(created_at, updated_at, downloads)
 // @Param order query string false "Order of results" Enums(asc, desc)
-// @Param modId path string true "Mod ID"
-// @Success 200
-// @Router /mod/{modId}/versions [get]
+// @Param modId path string true "Mod ID or mod reference"
+// @Success 200 {object} GenericResponse{data=[]Version}
+// @Failure 400 {object} GenericResponse{error=ErrorResponse} "Invalid filter"
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Mod not found"
+// @Router /v1/mod/{modId}/versions [get]
 func getModVersions(c echo.Context) (interface{}, *ErrorResponse) {
-	limit := util.GetIntRange(c, "limit", 1, 100, 25)
-	offset := util.GetIntRange(c, "offset", 0, 9999999, 0)
-	orderBy := util.OneOf(c, "order_by", []string{"created_at", "updated_at"}, "created_at")
-	order := util.OneOf(c, "order", []string{"asc", "desc"}, "desc")
-
-	modID := c.Param("modId")
+	filter, err := models.ProcessVersionFilter(map[string]interface{}{
+		"limit":    util.GetIntRange(c, "limit", 1, 100, 25),
+		"offset":   util.GetIntRange(c, "offset", 0, 9999999, 0),
+		"order_by": util.OneOf(c, "order_by", []string{"created_at", "updated_at", "downloads"}, "created_at"),
+		"order":    util.OneOf(c, "order", []string{"asc", "desc"}, "desc"),
+	})
+	if err != nil {
+		return nil, GenericUserError(err)
+	}
 
-	mod := postgres.GetModByID(c.Request().Context(), modID)
+	mod := postgres.GetModByIDOrReference(c.Request().Context(), c.Param("modId"))
 
 	if mod == nil {
 		return nil, &ErrorModNotFound
 	}
 
-	versions := postgres.GetModVersions(c.Request().Context(), mod.ID, limit, offset, orderBy, order, false)
+	versions := postgres.GetModVersionsNew(c.Request().Context(), mod.ID, filter, false)
 
 	converted := make([]*Version, len(versions))
 	for k, v := range versions {
@@ -208,8 +482,9 @@ func getModVersions(c echo.Context) (interface{}, *ErrorResponse) {
 // @Accept  json
 // @Produce  json
 // @Param modId path string true "Mod ID"
-// @Success 200
-// @Router /mod/{modId}/authors [get]
+// @Success 200 {object} GenericResponse{data=[]ModUser}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Mod not found"
+// @Router /v1/mod/{modId}/authors [get]
 func getModAuthors(c echo.Context) (interface{}, *ErrorResponse) {
 	modID := c.Param("modId")
 
@@ -231,18 +506,18 @@ func getModAuthors(c echo.Context) (interface{}, *ErrorResponse) {
 
 // @Summary Retrieve a Mod Version
 // @Tags Mod
-// @Description Retrieve a mod version by mod ID and version ID
+// @Description Retrieve a mod version by mod ID or mod reference, and version ID or version
 // @Accept  json
 // @Produce  json
-// @Param modId path string true "Mod ID"
-// @Param versionId path string true "Version ID"
-// @Success 200
-// @Router /mod/{modId}/versions/{versionId} [get]
+// @Param modId path string true "Mod ID or mod reference"
+// @Param versionId path string true "Version ID or version, e.g. 1.2.3"
+// @Success 200 {object} GenericResponse{data=Version}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Mod or version not found"
+// @Router /v1/mod/{modId}/versions/{versionId} [get]
 func getModVersion(c echo.Context) (interface{}, *ErrorResponse) {
-	modID := c.Param("modId")
 	versionID := c.Param("versionId")
 
-	mod := postgres.GetModByID(c.Request().Context(), modID)
+	mod := postgres.GetModByIDOrReference(c.Request().Context(), c.Param("modId"))
 
 	if mod == nil {
 		return nil, &ErrorModNotFound
@@ -250,6 +525,10 @@ func getModVersion(c echo.Context) (interface{}, *ErrorResponse) {
 
 	version := postgres.GetModVersion(c.Request().Context(), mod.ID, versionID)
 
+	if version == nil {
+		version = postgres.GetModVersionByName(c.Request().Context(), mod.ID, versionID)
+	}
+
 	if version == nil {
 		return nil, &ErrorVersionNotFound
 	}
@@ -264,8 +543,16 @@ func getModVersion(c echo.Context) (interface{}, *ErrorResponse) {
 // @Produce  json
 // @Param modId path string true "Mod ID"
 // @Param versionId path string true "Version ID"
-// @Success 200
-// @Router /mod/{modId}/versions/{versionId}/download [get]
+// @Param mirror query string false "Name of the mirror to download from, primary for the primary storage"
+// @Success 200 "Headers of the file, in response to HEAD requests"
+// @Header 200 {integer} Content-Length "Size of the file in bytes"
+// @Header 200 {string} X-SMR-Hash "Hash of the file"
+// @Success 302 "Redirect to the file"
+// @Success 202 {object} GenericResponse{data=DownloadPreparing} "The file is being restored from cold storage"
+// @Failure 404 {string} string "Not found"
+// @Failure 403 {string} string "Version is quarantined"
+// @Router /v1/mod/{modId}/versions/{versionId}/download [get]
+// @Router /v1/mod/{modId}/versions/{versionId}/download [head]
 func downloadModVersion(c echo.Context) error {
 	modID := c.Param("modId")
 	versionID := c.Param("versionId")
@@ -282,11 +569,25 @@ func downloadModVersion(c echo.Context) error {
 		return c.String(404, "version not found, modID:"+modID+" versionID:"+versionID)
 	}
 
+	if version.Quarantined {
+		return c.String(403, "version is quarantined pending virus scan")
+	}
+
+	if c.Request().Method == http.MethodHead {
+		return headDownload(c, version.Key, version.Hash, version.Size)
+	}
+
+	if preparing, err := preparingDownload(c, version); preparing {
+		return err
+	}
+
 	if redis.CanIncrement(c.RealIP(), "download", "version:"+versionID, time.Hour*4) {
-		postgres.IncrementVersionDownloads(c.Request().Context(), version)
+		postgres.IncrementVersionDownloads(c.Request().Context(), version, "")
 	}
 
-	return c.Redirect(302, storage.GenerateDownloadLink(version.Key))
+	recordUserDownload(c, version)
+
+	return sendDownload(c, version, version.Key, version.Hash, version.Size)
 }
 
 // @Summary Download a Mod Version by TargetName
@@ -297,8 +598,16 @@ func downloadModVersion(c echo.Context) error {
 // @Param modId path string true "Mod ID"
 // @Param versionId path string true "Version ID"
 // @Param target path string true "TargetName"
-// @Success 200
-// @Router /mod/{modId}/versions/{versionId}/{target}/download [get]
+// @Param mirror query string false "Name of the mirror to download from, primary for the primary storage"
+// @Success 200 "Headers of the file, in response to HEAD requests"
+// @Header 200 {integer} Content-Length "Size of the file in bytes"
+// @Header 200 {string} X-SMR-Hash "Hash of the file"
+// @Success 302 "Redirect to the file"
+// @Success 202 {object} GenericResponse{data=DownloadPreparing} "The file is being restored from cold storage"
+// @Failure 404 {string} string "Not found"
+// @Failure 403 {string} string "Version is quarantined"
+// @Router /v1/mod/{modId}/versions/{versionId}/{target}/download [get]
+// @Router /v1/mod/{modId}/versions/{versionId}/{target}/download [head]
 func downloadModVersionTarget(c echo.Context) error {
 	modID := c.Param("modId")
 	versionID := c.Param("versionId")
@@ -316,17 +625,31 @@ func downloadModVersionTarget(c echo.Context) error {
 		return c.String(404, "version not found, modID:"+modID+" versionID:"+versionID)
 	}
 
+	if version.Quarantined {
+		return c.String(403, "version is quarantined pending virus scan")
+	}
+
 	versionTarget := postgres.GetVersionTarget(c.Request().Context(), versionID, target)
 
 	if versionTarget == nil {
 		return c.String(404, "target not found, modID:"+modID+" versionID:"+versionID+" target:"+target)
 	}
 
+	if c.Request().Method == http.MethodHead {
+		return headDownload(c, versionTarget.Key, &versionTarget.Hash, &versionTarget.Size)
+	}
+
+	if preparing, err := preparingDownload(c, version); preparing {
+		return err
+	}
+
 	if redis.CanIncrement(c.RealIP(), "download", "version:"+versionID, time.Hour*4) {
-		postgres.IncrementVersionDownloads(c.Request().Context(), version)
+		postgres.IncrementVersionDownloads(c.Request().Context(), version, target)
 	}
 
-	return c.Redirect(302, storage.GenerateDownloadLink(versionTarget.Key))
+	recordUserDownload(c, version)
+
+	return sendDownload(c, version, versionTarget.Key, &versionTarget.Hash, &versionTarget.Size)
 }
 
 // @Summary Retrieve all Mod Versions
@@ -335,8 +658,9 @@ func downloadModVersionTarget(c echo.Context) error {
 // @Accept  json
 // @Produce  json
 // @Param modId path string true "Mod ID"
-// @Success 200
-// @Router /mod/{modId}/versions/all [get]
+// @Success 200 {object} GenericResponse{data=[]Version}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Mod not found"
+// @Router /v1/mod/{modId}/versions/all [get]
 func getAllModVersions(c echo.Context) (interface{}, *ErrorResponse) {
 	modID := c.Param("modId")
 
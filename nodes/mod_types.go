@@ -48,18 +48,82 @@ func ModToMod(mod *postgres.Mod, short bool) *Mod {
 }
 
 type Version struct {
-	UpdatedAt    time.Time           `json:"updated_at,omitempty"`
-	CreatedAt    time.Time           `json:"created_at,omitempty"`
-	ID           string              `json:"id,omitempty"`
-	Version      string              `json:"version,omitempty"`
-	SMLVersion   string              `json:"sml_version,omitempty"`
-	Changelog    string              `json:"changelog,omitempty"`
-	Stability    string              `json:"stability,omitempty"`
-	ModID        string              `json:"mod_id,omitempty"`
-	Dependencies []VersionDependency `json:"dependencies,omitempty"`
-	Targets      []VersionTarget     `json:"targets,omitempty"`
-	Downloads    uint                `json:"downloads,omitempty"`
-	Approved     bool                `json:"approved,omitempty"`
+	UpdatedAt       time.Time           `json:"updated_at,omitempty"`
+	CreatedAt       time.Time           `json:"created_at,omitempty"`
+	ID              string              `json:"id,omitempty"`
+	Version         string              `json:"version,omitempty"`
+	SMLVersion      string              `json:"sml_version,omitempty"`
+	Changelog       string              `json:"changelog,omitempty"`
+	ChangelogSource string              `json:"changelog_source,omitempty"`
+	Stability       string              `json:"stability,omitempty"`
+	ModID           string              `json:"mod_id,omitempty"`
+	Dependencies    []VersionDependency `json:"dependencies,omitempty"`
+	Targets         []VersionTarget     `json:"targets,omitempty"`
+	Downloads       uint                `json:"downloads,omitempty"`
+	Approved        bool                `json:"approved,omitempty"`
+}
+
+// Counters like downloads and views do not change the modification time
+func (m *Mod) lastModified() time.Time {
+	return m.UpdatedAt
+}
+
+func (v *Version) lastModified() time.Time {
+	return v.UpdatedAt
+}
+
+// LatestVersions are the latest versions of a mod, keyed by stability
+type LatestVersions map[string]*Version
+
+func (l LatestVersions) lastModified() time.Time {
+	var latest time.Time
+	for _, version := range l {
+		if version.lastModified().After(latest) {
+			latest = version.lastModified()
+		}
+	}
+	return latest
+}
+
+type InstalledMod struct {
+	ModReference string `json:"mod_reference"`
+	Version      string `json:"version"`
+}
+
+type ModUpdatesRequest struct {
+	Mods []InstalledMod `json:"mods"`
+	// Only versions supporting the target are considered
+	Target string `json:"target,omitempty"`
+	// Only versions compatible with the game build are considered, requires a target
+	GameVersion *int `json:"game_version,omitempty"`
+	// Least stable versions to consider if no game version is provided, defaults to release
+	Stability string `json:"stability,omitempty" enums:"release,beta,alpha"`
+}
+
+type ModUpdate struct {
+	ModReference    string   `json:"mod_reference"`
+	ModID           string   `json:"mod_id"`
+	Installed       string   `json:"installed"`
+	Latest          *Version `json:"latest,omitempty"`
+	UpdateAvailable bool     `json:"update_available"`
+	// Path to download the latest version from, or to create a download token for it if TokenRequired is set
+	DownloadURL   string `json:"download_url,omitempty"`
+	TokenRequired bool   `json:"token_required,omitempty"`
+}
+
+type ModUpdates struct {
+	Mods []ModUpdate `json:"mods"`
+	// References of mods which do not exist
+	Unknown []string `json:"unknown"`
+}
+
+// TargetVersion is the latest version of a mod having a target
+type TargetVersion struct {
+	Version *Version       `json:"version"`
+	Target  *VersionTarget `json:"target"`
+	// Path to download the target from, or to create a download token for it if TokenRequired is set
+	DownloadURL   string `json:"download_url"`
+	TokenRequired bool   `json:"token_required,omitempty"`
 }
 
 type VersionDependency struct {
@@ -76,6 +140,22 @@ type VersionTarget struct {
 	Size       int64  `json:"size"`
 }
 
+type DownloadToken struct {
+	Token     string `json:"token"`
+	Path      string `json:"path"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type DownloadPreparing struct {
+	Status     string `json:"status"`
+	RetryAfter int    `json:"retry_after"`
+}
+
+type TargetVerification struct {
+	Hash    string `json:"hash"`
+	Matches bool   `json:"matches"`
+}
+
 func TinyVersionToVersion(version *postgres.TinyVersion) *Version {
 	var dependencies []VersionDependency
 	if version.Dependencies != nil {
@@ -106,16 +186,17 @@ func TinyVersionToVersion(version *postgres.TinyVersion) *Version {
 
 func VersionToVersion(version *postgres.Version) *Version {
 	return &Version{
-		ID:         version.ID,
-		Version:    version.Version,
-		SMLVersion: version.SMLVersion,
-		Changelog:  version.Changelog,
-		Downloads:  version.Downloads,
-		Stability:  version.Stability,
-		Approved:   version.Approved,
-		UpdatedAt:  version.UpdatedAt,
-		CreatedAt:  version.CreatedAt,
-		ModID:      version.ModID,
+		ID:              version.ID,
+		Version:         version.Version,
+		SMLVersion:      version.SMLVersion,
+		Changelog:       version.Changelog,
+		ChangelogSource: version.ChangelogSource,
+		Downloads:       version.Downloads,
+		Stability:       version.Stability,
+		Approved:        version.Approved,
+		UpdatedAt:       version.UpdatedAt,
+		CreatedAt:       version.CreatedAt,
+		ModID:           version.ModID,
 	}
 }
 
@@ -162,6 +243,63 @@ type SMLVersion struct {
 	SatisfactoryVersion int       `json:"satisfactory_version"`
 }
 
+func (v *SMLVersion) lastModified() time.Time {
+	return v.UpdatedAt
+}
+
+// LatestSMLVersions are the latest versions of SML, keyed by stability
+type LatestSMLVersions map[string]*SMLVersion
+
+func (l LatestSMLVersions) lastModified() time.Time {
+	var latest time.Time
+	for _, version := range l {
+		if version.lastModified().After(latest) {
+			latest = version.lastModified()
+		}
+	}
+	return latest
+}
+
+// SMLVersionV2 is the shape of SML versions since APIV2, without the bootstrap version
+type SMLVersionV2 struct {
+	Date                time.Time `json:"date"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	CreatedAt           time.Time `json:"created_at"`
+	ID                  string    `json:"id"`
+	Version             string    `json:"version"`
+	Stability           string    `json:"stability"`
+	Link                string    `json:"link"`
+	Changelog           string    `json:"changelog"`
+	SatisfactoryVersion int       `json:"satisfactory_version"`
+}
+
+// LatestSMLVersionsV2 are the latest versions of SML, keyed by stability
+type LatestSMLVersionsV2 map[string]*SMLVersionV2
+
+func (l LatestSMLVersionsV2) lastModified() time.Time {
+	var latest time.Time
+	for _, version := range l {
+		if version.UpdatedAt.After(latest) {
+			latest = version.UpdatedAt
+		}
+	}
+	return latest
+}
+
+func SMLVersionToSMLVersionV2(version *postgres.SMLVersion) *SMLVersionV2 {
+	return &SMLVersionV2{
+		ID:                  version.ID,
+		Version:             version.Version,
+		SatisfactoryVersion: version.SatisfactoryVersion,
+		Stability:           version.Stability,
+		Date:                version.Date,
+		Link:                version.Link,
+		Changelog:           version.Changelog,
+		UpdatedAt:           version.UpdatedAt,
+		CreatedAt:           version.CreatedAt,
+	}
+}
+
 func SMLVersionToSMLVersion(version *postgres.SMLVersion) *SMLVersion {
 	return &SMLVersion{
 		ID:                  version.ID,
@@ -13,16 +13,33 @@ type ErrorResponse struct {
 }
 
 var (
+	ErrorRateLimited      = ErrorResponse{Code: 2, Message: "rate limit exceeded", Status: 429}
+	ErrorStatsUnavailable = ErrorResponse{Code: 3, Message: "statistics are unavailable", Status: 500}
+	ErrorSyncTokenInvalid = ErrorResponse{Code: 4, Message: "invalid sync token", Status: 400}
+	ErrorSyncTokenExpired = ErrorResponse{Code: 5, Message: "sync token expired, a full sync is required", Status: 410}
+
 	ErrorInvalidAuthorizationToken = ErrorResponse{Code: 100, Message: "invalid authorization token", Status: 403}
 	ErrorUserNotAuthorized         = ErrorResponse{Code: 101, Message: "you are not authorized to perform this action", Status: 403}
 	ErrorInvalidOAuthCode          = ErrorResponse{Code: 102, Message: "invalid oauth code", Status: 400}
 	ErrorUserNotFound              = ErrorResponse{Code: 103, Message: "user not found", Status: 404}
 	ErrorUserBanned                = ErrorResponse{Code: 104, Message: "user banned", Status: 403}
 
-	ErrorModNotFound     = ErrorResponse{Code: 200, Message: "mod not found", Status: 404}
-	ErrorFailedModUpload = ErrorResponse{Code: 201, Message: "failed to upload mod", Status: 500}
+	ErrorModNotFound          = ErrorResponse{Code: 200, Message: "mod not found", Status: 404}
+	ErrorFailedModUpload      = ErrorResponse{Code: 201, Message: "failed to upload mod", Status: 500}
+	ErrorTooManyModReferences = ErrorResponse{Code: 202, Message: "at most 100 mod references can be requested at once", Status: 400}
+
+	ErrorVersionNotFound           = ErrorResponse{Code: 300, Message: "version not found", Status: 404}
+	ErrorVersionTargetNotFound     = ErrorResponse{Code: 301, Message: "target not found", Status: 404}
+	ErrorVersionTargetHashNotFound = ErrorResponse{Code: 302, Message: "target has no recorded hash", Status: 404}
+	ErrorVersionTargetHashMissing  = ErrorResponse{Code: 303, Message: "hash query parameter is required", Status: 400}
+	ErrorVersionQuarantined        = ErrorResponse{Code: 304, Message: "version is quarantined pending virus scan", Status: 403}
+	ErrorDownloadTokenFailed       = ErrorResponse{Code: 305, Message: "failed to create download token", Status: 500}
+
+	ErrorBlueprintNotFound = ErrorResponse{Code: 400, Message: "blueprint not found", Status: 404}
 
-	ErrorVersionNotFound = ErrorResponse{Code: 300, Message: "version not found", Status: 404}
+	ErrorWebhookNotFound       = ErrorResponse{Code: 500, Message: "webhook not found", Status: 404}
+	ErrorTooManyWebhooks       = ErrorResponse{Code: 501, Message: "too many webhooks", Status: 400}
+	ErrorFailedWebhookCreation = ErrorResponse{Code: 502, Message: "failed to create webhook", Status: 500}
 )
 
 func GenericUserError(err error) *ErrorResponse {
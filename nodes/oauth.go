@@ -16,8 +16,8 @@ import (
 // @Tags OAuth
 // @Accept  json
 // @Produce  json
-// @Success 200
-// @Router /oauth [get]
+// @Success 200 {object} GenericResponse{data=map[string]string}
+// @Router /v1/oauth [get]
 func getOAuth(c echo.Context) (interface{}, *ErrorResponse) {
 	callbackURL := c.Param("url")
 	unescapedURL, err := url.PathUnescape(callbackURL)
@@ -34,8 +34,9 @@ func getOAuth(c echo.Context) (interface{}, *ErrorResponse) {
 // @Produce  json
 // @Param code query string true "OAuth Code"
 // @Param state query string true "OAuth Code"
-// @Success 200
-// @Router /oauth/github [get]
+// @Success 200 {object} GenericResponse{data=UserSession}
+// @Failure 400 {object} GenericResponse{error=ErrorResponse} "Invalid OAuth code"
+// @Router /v1/oauth/github [get]
 func getGithub(c echo.Context) (interface{}, *ErrorResponse) {
 	code := c.QueryParam("code")
 
@@ -70,7 +71,9 @@ func getGithub(c echo.Context) (interface{}, *ErrorResponse) {
 		success, avatarKey := storage.UploadUserAvatar(c.Request().Context(), session.UserID, bytes.NewReader(avatarData))
 		if success {
 			dbUser.Avatar = storage.GenerateDownloadLink(avatarKey)
-			postgres.Save(c.Request().Context(), &dbUser)
+			if err := postgres.SaveUser(c.Request().Context(), dbUser); err != nil {
+				return nil, GenericUserError(err)
+			}
 		}
 	}
 
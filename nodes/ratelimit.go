@@ -0,0 +1,78 @@
+package nodes
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// RateLimit counts REST requests against the request rate limit shared with the GraphQL API
+func RateLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	return rateLimit(next, func(c echo.Context, err *redis.RateLimitExceededError) error {
+		return c.JSON(http.StatusTooManyRequests, GenericResponse{
+			Success: false,
+			Error: &ErrorResponse{
+				Code:    ErrorRateLimited.Code,
+				Status:  ErrorRateLimited.Status,
+				Message: err.Error(),
+			},
+		})
+	})
+}
+
+// GraphQLRateLimit counts GraphQL requests against the request rate limit shared with the REST API
+func GraphQLRateLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	return rateLimit(next, func(c echo.Context, err *redis.RateLimitExceededError) error {
+		return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+			"errors": []interface{}{
+				map[string]interface{}{
+					"message":    err.Error(),
+					"extensions": err.Extensions(),
+				},
+			},
+		})
+	})
+}
+
+// rateLimit counts the request of the user, or of the address for anonymous requests, against
+// ratelimit.user_requests or ratelimit.requests, and reports the state of the limit in the X-RateLimit headers.
+// Requests exceeding the limit are answered with 429 Too Many Requests and a Retry-After header.
+func rateLimit(next echo.HandlerFunc, limited func(echo.Context, *redis.RateLimitExceededError) error) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		action := "requests"
+		identity := "ip:" + c.RealIP()
+		if user := userFromContext(c); user != nil {
+			action = "user_requests"
+			identity = "user:" + user.ID
+		}
+
+		limit, remaining, reset, ok, err := redis.CountRequest(identity, action)
+		if err != nil {
+			log.Err(err).Msg("failed to count request")
+			return next(c)
+		}
+
+		if limit <= 0 {
+			return next(c)
+		}
+
+		header := c.Response().Header()
+		util.SetRateLimitHeaders(header, limit, remaining, reset)
+
+		if !ok {
+			header.Set("Retry-After", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+			return limited(c, &redis.RateLimitExceededError{
+				Action:     action,
+				RetryAfter: reset,
+			})
+		}
+
+		return next(c)
+	}
+}
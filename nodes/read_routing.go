@@ -0,0 +1,37 @@
+package nodes
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// ReadRouting sends the reads of requests writing something to the primary database, and the ones of their caller
+// for database.postgres.replica.sticky afterwards, so that they see their own writes despite the replica lag
+func ReadRouting(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !postgres.HasReadReplica() {
+			return next(c)
+		}
+
+		identity := util.CallerIdentity(c.Request().Header.Get("Authorization"), c.RealIP())
+
+		method := c.Request().Method
+		write := method != http.MethodGet && method != http.MethodHead
+
+		if write || redis.IsStuckToPrimary(identity) {
+			c.SetRequest(c.Request().WithContext(postgres.ContextWithPrimary(c.Request().Context())))
+		}
+
+		if write {
+			redis.StickToPrimary(identity, viper.GetDuration("database.postgres.replica.sticky"))
+		}
+
+		return next(c)
+	}
+}
@@ -1,58 +1,152 @@
 package nodes
 
 import (
+	"strconv"
+
 	"github.com/labstack/echo/v4"
 )
 
-func RegisterModRoutes(router *echo.Group) {
+// APIVersion is a version of the REST API, served below /v<version>.
+//
+// The response shapes of a version are frozen once it is released. Breaking changes are made in a new version,
+// by registering a different handler for it.
+type APIVersion int
+
+const (
+	APIV1 APIVersion = 1
+	// APIV2 drops the bootstrap version of SML versions
+	APIV2 APIVersion = 2
+)
+
+// APIVersions are the versions of the REST API which are served
+var APIVersions = []APIVersion{APIV1, APIV2}
+
+func (v APIVersion) Prefix() string {
+	return "/v" + strconv.Itoa(int(v))
+}
+
+// RegisterRoutes registers every route of the API version, in the groups created by group for each resource
+func RegisterRoutes(group func(prefix string) *echo.Group, version APIVersion) {
+	RegisterOAuthRoutes(group("/oauth"), version)
+	RegisterUserRoutes(group("/user"), version)
+	RegisterUsersRoutes(group("/users"), version)
+	RegisterModRoutes(group("/mod"), version)
+	RegisterModsRoutes(group("/mods"), version)
+	RegisterVersionRoutes(group("/version"), version)
+	RegisterDownloadRoutes(group("/download"), version)
+	RegisterSMLRoutes(group("/sml"), version)
+	RegisterBlueprintRoutes(group("/blueprint"), version)
+	RegisterBlueprintsRoutes(group("/blueprints"), version)
+	RegisterStatsRoutes(group("/stats"), version)
+	RegisterSitemapRoutes(group("/sitemap"), version)
+	RegisterFeedRoutes(group("/feeds"), version)
+	RegisterSyncRoutes(group("/sync"), version)
+}
+
+func RegisterModRoutes(router *echo.Group, version APIVersion) {
 	router.GET("/count", dataWrapper(getModCount))
 
 	router.GET("/:modId", dataWrapper(getMod))
 
 	router.GET("/:modId/latest-versions", dataWrapper(getModLatestVersions))
+	router.GET("/:modId/latest/:target", dataWrapper(getModLatestTargetVersion))
 	router.GET("/:modId/versions", dataWrapper(getModVersions))
 	router.GET("/:modId/authors", dataWrapper(getModAuthors))
 
 	router.GET("/:modId/versions/all", dataWrapper(getAllModVersions))
+	router.GET("/:modId/versions.rss", getModVersionsFeed)
 
 	router.GET("/:modId/versions/:versionId", dataWrapper(getModVersion))
-	router.GET("/:modId/versions/:versionId/download", downloadModVersion)
-	router.GET("/:modId/versions/:versionId/:target/download", downloadModVersionTarget)
+	router.GET("/:modId/versions/:versionId/download", downloadModVersion, downloadTokenRequired)
+	router.HEAD("/:modId/versions/:versionId/download", downloadModVersion, downloadTokenRequired)
+	router.GET("/:modId/versions/:versionId/:target/download", downloadModVersionTarget, downloadTokenRequired)
+	router.HEAD("/:modId/versions/:versionId/:target/download", downloadModVersionTarget, downloadTokenRequired)
 }
 
-func RegisterModsRoutes(router *echo.Group) {
+func RegisterModsRoutes(router *echo.Group, version APIVersion) {
 	router.GET("", dataWrapper(getMods))
 
 	router.GET("/count", dataWrapper(getModCount))
 
+	router.GET("/references/:modReferences", dataWrapper(getModsByReferences))
+
+	router.POST("/updates", dataWrapper(getModUpdates))
+
 	router.GET("/:modIds", dataWrapper(getModsByIds))
 	router.GET("/:modIds/latest-versions", dataWrapper(getModsLatestVersions))
 }
 
-func RegisterOAuthRoutes(router *echo.Group) {
+func RegisterOAuthRoutes(router *echo.Group, version APIVersion) {
 	router.GET("/:url", dataWrapper(getOAuth))
 	router.GET("/github", dataWrapper(getGithub))
 }
 
-func RegisterUserRoutes(router *echo.Group) {
+func RegisterUserRoutes(router *echo.Group, version APIVersion) {
 	router.GET("/me", dataWrapper(authorized(getMe)))
 	router.GET("/me/logout", dataWrapper(authorized(getLogout)))
 	router.GET("/me/mods", dataWrapper(authorized(getMyMods)))
+	router.GET("/me/webhooks", dataWrapper(authorized(getMyWebhooks)))
+	router.POST("/me/webhooks", dataWrapper(authorized(createWebhook)))
+	router.DELETE("/me/webhooks/:webhookId", dataWrapper(authorized(deleteWebhook)))
 
 	router.GET("/:userId", dataWrapper(getUser))
 	router.GET("/:userId/mods", dataWrapper(getUserMods))
 }
 
-func RegisterUsersRoutes(router *echo.Group) {
+func RegisterUsersRoutes(router *echo.Group, version APIVersion) {
 	router.GET("/:userIds", dataWrapper(getUsers))
 }
 
-func RegisterVersionRoutes(router *echo.Group) {
+func RegisterVersionRoutes(router *echo.Group, version APIVersion) {
 	router.GET("/:versionId", dataWrapper(getVersion))
-	router.GET("/:versionId/download", downloadVersion)
-	router.GET("/:versionId/:target/download", downloadModTarget)
+	router.GET("/:versionId/download", downloadVersion, downloadTokenRequired)
+	router.HEAD("/:versionId/download", downloadVersion, downloadTokenRequired)
+	router.GET("/:versionId/download-token", dataWrapper(createDownloadToken))
+	router.GET("/:versionId/torrent", downloadVersionTorrent)
+	router.GET("/:versionId/metalink", downloadVersionMetalink)
+	router.GET("/:versionId/:target/download", downloadModTarget, downloadTokenRequired)
+	router.HEAD("/:versionId/:target/download", downloadModTarget, downloadTokenRequired)
+	router.GET("/:versionId/:target/verify", dataWrapper(verifyModTarget))
+	router.GET("/:versionId/:target/patch/:fromVersionId", downloadModTargetPatch, downloadTokenRequired)
+}
+
+func RegisterDownloadRoutes(router *echo.Group, version APIVersion) {
+	router.GET("/:token", downloadWithToken)
+}
+
+func RegisterBlueprintRoutes(router *echo.Group, version APIVersion) {
+	router.GET("/:blueprintId", dataWrapper(getBlueprint))
+	router.GET("/:blueprintId/download", downloadBlueprint)
+}
+
+func RegisterBlueprintsRoutes(router *echo.Group, version APIVersion) {
+	router.GET("", dataWrapper(getBlueprints))
+
+	router.GET("/count", dataWrapper(getBlueprintCount))
+}
+
+func RegisterStatsRoutes(router *echo.Group, version APIVersion) {
+	router.GET("", dataWrapper(getSiteStats))
+}
+
+func RegisterSMLRoutes(router *echo.Group, version APIVersion) {
+	if version >= APIV2 {
+		router.GET("/latest-versions", dataWrapper(getSMLLatestVersionsV2))
+	} else {
+		router.GET("/latest-versions", dataWrapper(getSMLLatestVersions))
+	}
+}
+
+func RegisterSitemapRoutes(router *echo.Group, version APIVersion) {
+	router.GET("", getSitemapIndex)
+	router.GET("/:sitemap", getSitemap)
+}
+
+func RegisterFeedRoutes(router *echo.Group, version APIVersion) {
+	router.GET("/mods.rss", getModsFeed)
+	router.GET("/versions.rss", getVersionsFeed)
 }
 
-func RegisterSMLRoutes(router *echo.Group) {
-	router.GET("/latest-versions", dataWrapper(getSMLLatestVersions))
+func RegisterSyncRoutes(router *echo.Group, version APIVersion) {
+	router.GET("", dataWrapper(getSync))
 }
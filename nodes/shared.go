@@ -1,9 +1,23 @@
 package nodes
 
 import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash"
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
 
 	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs"
+	"github.com/satisfactorymodding/smr-api/storage"
+	"github.com/satisfactorymodding/smr-api/util/geoip"
 )
 
 type DataFunction func(c echo.Context) (data interface{}, err *ErrorResponse)
@@ -18,11 +32,82 @@ func dataWrapper(nested DataFunction) func(c echo.Context) error {
 			})
 		}
 
-		return c.JSON(200, GenericResponse{
+		response := GenericResponse{
 			Success: true,
 			Data:    data,
-		})
+		}
+
+		method := c.Request().Method
+		if method != http.MethodGet && method != http.MethodHead {
+			return c.JSON(200, response)
+		}
+
+		return sendConditional(c, response, data)
+	}
+}
+
+// lastModifier is implemented by responses which know when their content last changed
+type lastModifier interface {
+	lastModified() time.Time
+}
+
+// sendConditional sends a response tagged with the hash of its content, and the last modification time if
+// the data provides one. Clients sending a matching If-None-Match or a recent enough If-Modified-Since get a 304.
+func sendConditional(c echo.Context, response GenericResponse, data interface{}) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize response")
+	}
+
+	var modified time.Time
+	if modifier, ok := data.(lastModifier); ok {
+		modified = modifier.lastModified()
+	}
+
+	return sendConditionalBlob(c, echo.MIMEApplicationJSONCharsetUTF8, body, modified)
+}
+
+// sendConditionalBlob sends a body tagged with its hash, and the last modification time unless it is zero
+func sendConditionalBlob(c echo.Context, contentType string, body []byte, modified time.Time) error {
+	etag := "\"" + strconv.FormatUint(xxhash.Sum64(body), 16) + "\""
+
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+
+	modified = modified.UTC().Truncate(time.Second)
+	if !modified.IsZero() {
+		header.Set(echo.HeaderLastModified, modified.Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request(), etag, modified) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.Blob(200, contentType, body)
+}
+
+// notModified evaluates the conditional request headers. If-None-Match takes precedence over If-Modified-Since.
+func notModified(request *http.Request, etag string, modified time.Time) bool {
+	if inm := request.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if modified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(request.Header.Get(echo.HeaderIfModifiedSince))
+	if err != nil {
+		return false
 	}
+
+	return !modified.After(since)
 }
 
 type AuthorizedDataFunction func(user *postgres.User, c echo.Context) (data interface{}, err *ErrorResponse)
@@ -42,3 +127,166 @@ func authorized(nested AuthorizedDataFunction) DataFunction {
 		return nested(user, c)
 	}
 }
+
+// mirroredDownloadLink returns the download link of a version file from the mirror closest to the client,
+// based on the country reported in storage.mirror_region_header, or looked up from the client IP.
+//
+// The mirror query parameter selects a mirror by name instead, "primary" forces the primary storage.
+func mirroredDownloadLink(c echo.Context, key string) string {
+	if len(storage.Mirrors()) == 0 {
+		return storage.GenerateSignedDownloadLink(key)
+	}
+
+	override := c.QueryParam("mirror")
+	if override == "primary" {
+		return storage.GenerateSignedDownloadLink(key)
+	}
+
+	replicated := postgres.GetReplicatedMirrors(c.Request().Context(), key)
+
+	if override != "" {
+		return storage.GenerateDownloadLinkFromMirror(key, override, replicated)
+	}
+
+	region := c.Request().Header.Get(viper.GetString("storage.mirror_region_header"))
+
+	// Cloudflare reports XX for clients it could not locate
+	if region == "" || region == "XX" {
+		region = geoip.Country(c.RealIP())
+	}
+
+	return storage.GenerateMirroredDownloadLink(key, region, replicated)
+}
+
+// routePrefix returns the prefix of the API version serving the request, so generated links stay on the same version
+func routePrefix(c echo.Context) string {
+	for _, version := range APIVersions {
+		if prefix := version.Prefix(); strings.HasPrefix(c.Path(), prefix+"/") {
+			return prefix
+		}
+	}
+
+	return APIV1.Prefix()
+}
+
+// downloadTokenRequired rejects direct downloads if downloads.tokens.required is set,
+// so files can only be downloaded with a download token.
+func downloadTokenRequired(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if viper.GetBool("downloads.tokens.required") {
+			return c.String(403, "downloads require a download token, see "+routePrefix(c)+"/version/{versionId}/download-token")
+		}
+
+		return next(c)
+	}
+}
+
+// preparingDownload responds to downloads of versions in cold storage with 202 Accepted,
+// queuing their rehydration. Clients should retry after the Retry-After delay.
+func preparingDownload(c echo.Context, version *postgres.Version) (bool, error) {
+	if version.StorageTier == "" || version.StorageTier == postgres.StorageTierHot {
+		return false, nil
+	}
+
+	if version.StorageTier == postgres.StorageTierCold {
+		postgres.SetVersionStorageTier(c.Request().Context(), version, postgres.StorageTierRehydrating)
+		jobs.SubmitJobRehydrateVersionTask(c.Request().Context(), version.ID)
+	}
+
+	retryAfter := int(viper.GetDuration("storage.cold.retry_after").Seconds())
+	c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+
+	return true, c.JSON(202, GenericResponse{
+		Success: true,
+		Data: DownloadPreparing{
+			Status:     "preparing",
+			RetryAfter: retryAfter,
+		},
+	})
+}
+
+// sendDownload redirects to the download link of a version file, or streams it from storage if downloads.proxy is set.
+// Proxied downloads honor Range and If-Range, with the file hash as ETag, so interrupted downloads can be resumed.
+// Served bytes are accounted to the version for bandwidth usage statistics.
+func sendDownload(c echo.Context, version *postgres.Version, key string, hash *string, size *int64) error {
+	if blobQuarantined(c, key) {
+		return c.String(403, "file is quarantined pending virus scan")
+	}
+
+	if !viper.GetBool("downloads.proxy") {
+		// Redirected downloads are served by the storage, so the whole file is accounted for
+		if size != nil {
+			recordBandwidth(c, version, *size)
+		}
+
+		return c.Redirect(302, mirroredDownloadLink(c, key))
+	}
+
+	file, err := storage.OpenFile(key)
+	if err != nil {
+		log.Err(err).Str("key", key).Msg("failed to open download")
+		return c.String(404, "file not found")
+	}
+	defer file.Close()
+
+	name := setDownloadHeaders(c, key, hash)
+
+	http.ServeContent(c.Response(), c.Request(), name, time.Time{}, file)
+	recordBandwidth(c, version, c.Response().Size)
+	return nil
+}
+
+// headDownload answers HEAD requests to downloads with the size and hash of the file,
+// so clients can check for disk space and cached copies without downloading it
+func headDownload(c echo.Context, key string, hash *string, size *int64) error {
+	if blobQuarantined(c, key) {
+		return c.String(403, "file is quarantined pending virus scan")
+	}
+
+	setDownloadHeaders(c, key, hash)
+
+	header := c.Response().Header()
+	header.Set("Accept-Ranges", "bytes")
+	if size != nil {
+		header.Set(echo.HeaderContentLength, strconv.FormatInt(*size, 10))
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// setDownloadHeaders sets the headers describing a downloaded file, and returns its name
+func setDownloadHeaders(c echo.Context, key string, hash *string) string {
+	name := path.Base(storage.DecodeName(key))
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, "application/zip")
+	header.Set(echo.HeaderContentDisposition, "attachment; filename=\""+name+"\"")
+	if hash != nil && *hash != "" {
+		header.Set("ETag", "\""+*hash+"\"")
+		header.Set("X-SMR-Hash", *hash)
+	}
+
+	return name
+}
+
+// recordBandwidth accounts for the bytes of a version served to a client
+func recordBandwidth(c echo.Context, version *postgres.Version, bytes int64) {
+	if c.Request().Method == http.MethodHead || bytes <= 0 {
+		return
+	}
+
+	postgres.AddVersionBandwidth(c.Request().Context(), version.ModID, version.ID, bytes)
+}
+
+// blobQuarantined returns whether the key is a blob shared with a quarantined version,
+// which is refused to every version sharing it until the scan completes
+func blobQuarantined(c echo.Context, key string) bool {
+	return storage.IsBlob(key) && postgres.IsBlobQuarantined(c.Request().Context(), key)
+}
+
+// recordUserDownload remembers the mods downloaded by authenticated users, as only they can rate them
+func recordUserDownload(c echo.Context, version *postgres.Version) {
+	if user := userFromContext(c); user != nil {
+		postgres.RecordUserDownload(c.Request().Context(), user.ID, version.ModID)
+	}
+}
@@ -0,0 +1,55 @@
+package nodes
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"github.com/satisfactorymodding/smr-api/db"
+)
+
+// @Summary Retrieve the Sitemap Index
+// @Tags Sitemap
+// @Description Retrieve the sitemap index listing the sitemaps of the public mods, guides and users, regenerated periodically
+// @Produce  xml
+// @Success 200 {string} string "Sitemap index"
+// @Failure 500 {string} string "Sitemaps unavailable"
+// @Router /v1/sitemap [get]
+func getSitemapIndex(c echo.Context) error {
+	return serveSitemap(c, db.SitemapIndex)
+}
+
+// @Summary Retrieve a Sitemap
+// @Tags Sitemap
+// @Description Retrieve a page of the sitemap of the public mods, guides or users, as listed by the sitemap index
+// @Produce  xml
+// @Param sitemap path string true "Sitemap name, such as mods-1.xml"
+// @Success 200 {string} string "Sitemap"
+// @Failure 404 {string} string "Sitemap not found"
+// @Failure 500 {string} string "Sitemaps unavailable"
+// @Router /v1/sitemap/{sitemap} [get]
+func getSitemap(c echo.Context) error {
+	name := strings.TrimSuffix(c.Param("sitemap"), ".xml")
+	if name == db.SitemapIndex {
+		return c.String(404, "sitemap not found")
+	}
+
+	return serveSitemap(c, name)
+}
+
+func serveSitemap(c echo.Context, name string) error {
+	sitemap, err := db.GetSitemap(c.Request().Context(), name)
+	if err != nil {
+		log.Err(err).Msg("failed to get sitemap")
+		return c.String(500, "sitemaps are unavailable")
+	}
+
+	if sitemap == nil {
+		return c.String(404, "sitemap not found")
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=600")
+
+	return c.Blob(200, echo.MIMEApplicationXMLCharsetUTF8, sitemap)
+}
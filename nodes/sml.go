@@ -11,8 +11,9 @@ import (
 // @Description Retrieve a list of latest versions for sml
 // @Accept  json
 // @Produce  json
-// @Success 200
-// @Router /sml/latest-versions [get]
+// @Success 200 {object} GenericResponse{data=map[string]SMLVersion}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Version not found"
+// @Router /v1/sml/latest-versions [get]
 func getSMLLatestVersions(c echo.Context) (interface{}, *ErrorResponse) {
 	smlVersions := postgres.GetSMLLatestVersions(c.Request().Context())
 
@@ -20,7 +21,7 @@ func getSMLLatestVersions(c echo.Context) (interface{}, *ErrorResponse) {
 		return nil, &ErrorVersionNotFound
 	}
 
-	result := make(map[string]*SMLVersion)
+	result := make(LatestSMLVersions)
 
 	for _, v := range *smlVersions {
 		result[v.Stability] = SMLVersionToSMLVersion(&v)
@@ -28,3 +29,27 @@ func getSMLLatestVersions(c echo.Context) (interface{}, *ErrorResponse) {
 
 	return result, nil
 }
+
+// @Summary Retrieve a list of latest versions for sml
+// @Tags SML
+// @Description Retrieve a list of latest versions for sml
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} GenericResponse{data=map[string]SMLVersionV2}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Version not found"
+// @Router /v2/sml/latest-versions [get]
+func getSMLLatestVersionsV2(c echo.Context) (interface{}, *ErrorResponse) {
+	smlVersions := postgres.GetSMLLatestVersions(c.Request().Context())
+
+	if smlVersions == nil {
+		return nil, &ErrorVersionNotFound
+	}
+
+	result := make(LatestSMLVersionsV2)
+
+	for _, v := range *smlVersions {
+		result[v.Stability] = SMLVersionToSMLVersionV2(&v)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,28 @@
+package nodes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+
+	"github.com/satisfactorymodding/smr-api/db"
+)
+
+// @Summary Retrieve the Site Statistics
+// @Tags Stats
+// @Description Retrieve aggregate statistics of the approved mods which are not hidden, refreshed periodically
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} GenericResponse{data=SiteStats}
+// @Failure 500 {object} GenericResponse{error=ErrorResponse} "Statistics unavailable"
+// @Router /v1/stats [get]
+func getSiteStats(c echo.Context) (interface{}, *ErrorResponse) {
+	stats, err := db.GetSiteStats(c.Request().Context())
+	if err != nil {
+		log.Err(err).Msg("failed to get site stats")
+		return nil, &ErrorStatsUnavailable
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=60")
+
+	return SiteStatsToSiteStats(stats), nil
+}
@@ -0,0 +1,31 @@
+package nodes
+
+import (
+	"time"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+)
+
+type SiteStats struct {
+	ComputedAt    time.Time `json:"computed_at"`
+	Mods          int64     `json:"mods"`
+	Versions      int64     `json:"versions"`
+	Downloads     int64     `json:"downloads"`
+	ActiveAuthors int64     `json:"active_authors"`
+	StorageSize   int64     `json:"storage_size"`
+}
+
+func SiteStatsToSiteStats(stats *postgres.SiteStats) *SiteStats {
+	return &SiteStats{
+		ComputedAt:    stats.ComputedAt,
+		Mods:          stats.Mods,
+		Versions:      stats.Versions,
+		Downloads:     stats.Downloads,
+		ActiveAuthors: stats.ActiveAuthors,
+		StorageSize:   stats.StorageSize,
+	}
+}
+
+func (s *SiteStats) lastModified() time.Time {
+	return s.ComputedAt
+}
@@ -0,0 +1,144 @@
+package nodes
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+)
+
+// @Summary Retrieve Changes since a Sync Token
+// @Tags Sync
+// @Description Retrieve the mods and versions created, updated or deleted since the token returned by a previous sync,
+// @Description oldest first. Counters like downloads are not synced.
+// @Description
+// @Description Without a token, no changes are returned along with the current token. Fetch it before a full sync,
+// @Description then sync from it to catch up with the changes made since.
+// @Description
+// @Description A mod which is deleted is reported once, the deletion of its versions is implied.
+// @Accept  json
+// @Produce  json
+// @Param since query string false "Token returned by the previous sync"
+// @Success 200 {object} GenericResponse{data=Sync}
+// @Failure 400 {object} GenericResponse{error=ErrorResponse} "Invalid token"
+// @Failure 410 {object} GenericResponse{error=ErrorResponse} "Token expired, a full sync is required"
+// @Router /v1/sync [get]
+func getSync(c echo.Context) (interface{}, *ErrorResponse) {
+	ctx := c.Request().Context()
+
+	// Changes are only returned once they settled, so that transactions committing out of order are not skipped
+	until := time.Now().Add(-viper.GetDuration("sync.settle_delay"))
+
+	since := c.QueryParam("since")
+	if since == "" {
+		return &Sync{
+			Token:   strconv.FormatInt(postgres.GetSyncHead(ctx, until), 10),
+			Changes: make([]SyncChange, 0),
+		}, nil
+	}
+
+	sinceID, err := strconv.ParseInt(since, 10, 64)
+	if err != nil || sinceID < 0 {
+		return nil, &ErrorSyncTokenInvalid
+	}
+
+	if tail := postgres.GetSyncTail(ctx); tail > 0 && sinceID < tail-1 {
+		return nil, &ErrorSyncTokenExpired
+	}
+
+	limit := viper.GetInt("sync.limit")
+	changes := postgres.GetSyncChanges(ctx, sinceID, until, limit+1)
+
+	result := &Sync{
+		Token:   since,
+		HasMore: len(changes) > limit,
+		Changes: make([]SyncChange, 0),
+	}
+
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+
+	if len(changes) == 0 {
+		return result, nil
+	}
+
+	result.Token = strconv.FormatInt(changes[len(changes)-1].ID, 10)
+
+	// Every entity is reported once, at its last change
+	last := make(map[string]int)
+	for i, change := range changes {
+		last[change.EntityType+":"+change.EntityID] = i
+	}
+
+	modIDs := make([]string, 0)
+	versionIDs := make([]string, 0)
+	for i, change := range changes {
+		if last[change.EntityType+":"+change.EntityID] != i {
+			continue
+		}
+
+		switch change.EntityType {
+		case postgres.SyncEntityMod:
+			modIDs = append(modIDs, change.EntityID)
+		case postgres.SyncEntityVersion:
+			versionIDs = append(versionIDs, change.EntityID)
+		}
+	}
+
+	mods := make(map[string]*Mod)
+	if len(modIDs) > 0 {
+		for _, mod := range postgres.GetSyncMods(ctx, modIDs) {
+			mod := mod
+			mods[mod.ID] = ModToMod(&mod, false)
+		}
+	}
+
+	versions := make(map[string]*Version)
+	if len(versionIDs) > 0 {
+		dependencies := postgres.GetSyncVersionDependencies(ctx, versionIDs)
+		for _, version := range postgres.GetSyncVersions(ctx, versionIDs) {
+			version := version
+			converted := VersionToVersion(&version)
+
+			for _, target := range version.Targets {
+				converted.Targets = append(converted.Targets, VersionTargetToVersionTarget(target))
+			}
+
+			for _, dependency := range dependencies[version.ID] {
+				converted.Dependencies = append(converted.Dependencies, VersionDependencyToVersionDependency(dependency))
+			}
+
+			versions[version.ID] = converted
+		}
+	}
+
+	for i, change := range changes {
+		if last[change.EntityType+":"+change.EntityID] != i {
+			continue
+		}
+
+		synced := SyncChange{
+			Type: change.EntityType,
+			ID:   change.EntityID,
+		}
+
+		switch change.EntityType {
+		case postgres.SyncEntityMod:
+			synced.Mod = mods[change.EntityID]
+			synced.Deleted = synced.Mod == nil
+		case postgres.SyncEntityVersion:
+			synced.Version = versions[change.EntityID]
+			synced.Deleted = synced.Version == nil
+		default:
+			continue
+		}
+
+		result.Changes = append(result.Changes, synced)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,18 @@
+package nodes
+
+type Sync struct {
+	// Token to pass as since to fetch the following changes
+	Token   string       `json:"token"`
+	Changes []SyncChange `json:"changes"`
+	HasMore bool         `json:"has_more"`
+}
+
+// SyncChange is the current state of a changed mod or version. Deleted is set if it is not available anymore,
+// in which case neither the mod nor the version is provided.
+type SyncChange struct {
+	Mod     *Mod     `json:"mod,omitempty"`
+	Version *Version `json:"version,omitempty"`
+	Type    string   `json:"type"`
+	ID      string   `json:"id"`
+	Deleted bool     `json:"deleted"`
+}
@@ -29,8 +29,9 @@ func userFromContext(c echo.Context) *postgres.User {
 // @Description Retrieve the user associated with the token
 // @Accept  json
 // @Produce  json
-// @Success 200
-// @Router /user/me [get]
+// @Success 200 {object} GenericResponse{data=User}
+// @Failure 403 {object} GenericResponse{error=ErrorResponse} "Invalid authorization token"
+// @Router /v1/user/me [get]
 func getMe(user *postgres.User, c echo.Context) (interface{}, *ErrorResponse) {
 	return UserToPrivateUser(user), nil
 }
@@ -40,8 +41,9 @@ func getMe(user *postgres.User, c echo.Context) (interface{}, *ErrorResponse) {
 // @Description Log out the user associated with the token
 // @Accept  json
 // @Produce  json
-// @Success 200
-// @Router /user/me/logout [get]
+// @Success 200 {object} GenericResponse
+// @Failure 403 {object} GenericResponse{error=ErrorResponse} "Invalid authorization token"
+// @Router /v1/user/me/logout [get]
 func getLogout(user *postgres.User, c echo.Context) (interface{}, *ErrorResponse) {
 	postgres.LogoutSession(c.Request().Context(), c.Request().Header.Get("Authorization"))
 	return nil, nil
@@ -52,8 +54,9 @@ func getLogout(user *postgres.User, c echo.Context) (interface{}, *ErrorResponse
 // @Description Retrieve the users mods associated with the token
 // @Accept  json
 // @Produce  json
-// @Success 200
-// @Router /user/me/mods [get]
+// @Success 200 {object} GenericResponse{data=[]UserMod}
+// @Failure 403 {object} GenericResponse{error=ErrorResponse} "Invalid authorization token"
+// @Router /v1/user/me/mods [get]
 func getMyMods(user *postgres.User, c echo.Context) (interface{}, *ErrorResponse) {
 	mods := postgres.GetUserMods(c.Request().Context(), user.ID)
 
@@ -70,10 +73,12 @@ func getMyMods(user *postgres.User, c echo.Context) (interface{}, *ErrorResponse
 // @Description Retrieve a list of users by user ID
 // @Accept  json
 // @Produce  json
-// @Success 200
+// @Success 200 {object} GenericResponse{data=[]PublicUser}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "User not found"
 // @Param userIds path string true "User IDs comma-separated"
-// @Success 200
-// @Router /users/{userIds} [get]
+// @Success 200 {object} GenericResponse{data=[]PublicUser}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "User not found"
+// @Router /v1/users/{userIds} [get]
 func getUsers(c echo.Context) (interface{}, *ErrorResponse) {
 	userID := c.Param("userIds")
 	userIDSplit := strings.Split(userID, ",")
@@ -100,8 +105,9 @@ func getUsers(c echo.Context) (interface{}, *ErrorResponse) {
 // @Accept  json
 // @Produce  json
 // @Param userId path string true "User ID"
-// @Success 200
-// @Router /user/{userId}/mods [get]
+// @Success 200 {object} GenericResponse{data=[]UserMod}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "User not found"
+// @Router /v1/user/{userId}/mods [get]
 func getUserMods(c echo.Context) (interface{}, *ErrorResponse) {
 	userID := c.Param("userId")
 
@@ -127,8 +133,9 @@ func getUserMods(c echo.Context) (interface{}, *ErrorResponse) {
 // @Accept  json
 // @Produce  json
 // @Param userId path string true "User ID"
-// @Success 200
-// @Router /user/{userId} [get]
+// @Success 200 {object} GenericResponse{data=PublicUser}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "User not found"
+// @Router /v1/user/{userId} [get]
 func getUser(c echo.Context) (interface{}, *ErrorResponse) {
 	userID := c.Param("userId")
 
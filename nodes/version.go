@@ -1,9 +1,13 @@
 package nodes
 
 import (
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
 
 	"github.com/satisfactorymodding/smr-api/db/postgres"
 	"github.com/satisfactorymodding/smr-api/redis"
@@ -16,8 +20,9 @@ import (
 // @Accept  json
 // @Produce  json
 // @Param versionId path string true "Version ID"
-// @Success 200
-// @Router /version/{versionId} [get]
+// @Success 200 {object} GenericResponse{data=Version}
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Version not found"
+// @Router /v1/version/{versionId} [get]
 func getVersion(c echo.Context) (interface{}, *ErrorResponse) {
 	versionID := c.Param("versionId")
 
@@ -36,8 +41,16 @@ func getVersion(c echo.Context) (interface{}, *ErrorResponse) {
 // @Accept  json
 // @Produce  json
 // @Param versionId path string true "Version ID"
-// @Success 200
-// @Router /versions/{versionId}/download [get]
+// @Param mirror query string false "Name of the mirror to download from, primary for the primary storage"
+// @Success 200 "Headers of the file, in response to HEAD requests"
+// @Header 200 {integer} Content-Length "Size of the file in bytes"
+// @Header 200 {string} X-SMR-Hash "Hash of the file"
+// @Success 302 "Redirect to the file"
+// @Success 202 {object} GenericResponse{data=DownloadPreparing} "The file is being restored from cold storage"
+// @Failure 404 {string} string "Not found"
+// @Failure 403 {string} string "Version is quarantined"
+// @Router /v1/version/{versionId}/download [get]
+// @Router /v1/version/{versionId}/download [head]
 func downloadVersion(c echo.Context) error {
 	versionID := c.Param("versionId")
 
@@ -47,11 +60,78 @@ func downloadVersion(c echo.Context) error {
 		return c.String(404, "version not found")
 	}
 
+	if version.Quarantined {
+		return c.String(403, "version is quarantined pending virus scan")
+	}
+
+	if c.Request().Method == http.MethodHead {
+		return headDownload(c, version.Key, version.Hash, version.Size)
+	}
+
+	if preparing, err := preparingDownload(c, version); preparing {
+		return err
+	}
+
 	if redis.CanIncrement(c.RealIP(), "download", "version:"+versionID, time.Hour*4) {
-		postgres.IncrementVersionDownloads(c.Request().Context(), version)
+		postgres.IncrementVersionDownloads(c.Request().Context(), version, "")
+	}
+
+	recordUserDownload(c, version)
+
+	return sendDownload(c, version, version.Key, version.Hash, version.Size)
+}
+
+// @Summary Download a Version torrent
+// @Tags Version
+// @Description Download the torrent of a version file, only available for large versions
+// @Accept  json
+// @Produce  json
+// @Param versionId path string true "Version ID"
+// @Success 302 "Redirect to the file"
+// @Failure 403 {string} string "Version is quarantined"
+// @Failure 404 {string} string "Not found"
+// @Router /v1/version/{versionId}/torrent [get]
+func downloadVersionTorrent(c echo.Context) error {
+	return downloadVersionDistribution(c, func(version *postgres.Version) *string {
+		return version.TorrentKey
+	})
+}
+
+// @Summary Download a Version metalink
+// @Tags Version
+// @Description Download the metalink document of a version file, only available for large versions
+// @Accept  json
+// @Produce  json
+// @Param versionId path string true "Version ID"
+// @Success 302 "Redirect to the file"
+// @Failure 403 {string} string "Version is quarantined"
+// @Failure 404 {string} string "Not found"
+// @Router /v1/version/{versionId}/metalink [get]
+func downloadVersionMetalink(c echo.Context) error {
+	return downloadVersionDistribution(c, func(version *postgres.Version) *string {
+		return version.MetalinkKey
+	})
+}
+
+func downloadVersionDistribution(c echo.Context, getKey func(version *postgres.Version) *string) error {
+	versionID := c.Param("versionId")
+
+	version := postgres.GetVersion(c.Request().Context(), versionID)
+
+	if version == nil {
+		return c.String(404, "version not found")
 	}
 
-	return c.Redirect(302, storage.GenerateDownloadLink(version.Key))
+	if version.Quarantined {
+		return c.String(403, "version is quarantined pending virus scan")
+	}
+
+	key := getKey(version)
+	if key == nil || *key == "" {
+		return c.String(404, "version has no torrent, versionID:"+versionID)
+	}
+
+	return c.Redirect(302, storage.GenerateSignedDownloadLink(*key))
 }
 
 // @Summary Download a TargetName
@@ -62,8 +142,16 @@ func downloadVersion(c echo.Context) error {
 // @Produce  json
 // @Param versionId path string true "Version ID"
 // @Param target path string true "TargetName"
-// @Success 200
-// @Router /versions/{versionId}/{target}/download [get]
+// @Param mirror query string false "Name of the mirror to download from, primary for the primary storage"
+// @Success 200 "Headers of the file, in response to HEAD requests"
+// @Header 200 {integer} Content-Length "Size of the file in bytes"
+// @Header 200 {string} X-SMR-Hash "Hash of the file"
+// @Success 302 "Redirect to the file"
+// @Success 202 {object} GenericResponse{data=DownloadPreparing} "The file is being restored from cold storage"
+// @Failure 404 {string} string "Not found"
+// @Failure 403 {string} string "Version is quarantined"
+// @Router /v1/version/{versionId}/{target}/download [get]
+// @Router /v1/version/{versionId}/{target}/download [head]
 func downloadModTarget(c echo.Context) error {
 	versionID := c.Param("versionId")
 	target := c.Param("target")
@@ -74,15 +162,247 @@ func downloadModTarget(c echo.Context) error {
 		return c.String(404, "version not found, versionID:"+versionID)
 	}
 
+	if version.Quarantined {
+		return c.String(403, "version is quarantined pending virus scan")
+	}
+
 	versionTarget := postgres.GetVersionTarget(c.Request().Context(), versionID, target)
 
 	if versionTarget == nil {
 		return c.String(404, "target not found, versionID:"+versionID+" target:"+target)
 	}
 
+	if c.Request().Method == http.MethodHead {
+		return headDownload(c, versionTarget.Key, &versionTarget.Hash, &versionTarget.Size)
+	}
+
+	if preparing, err := preparingDownload(c, version); preparing {
+		return err
+	}
+
 	if redis.CanIncrement(c.RealIP(), "download", "version:"+versionID, time.Hour*4) {
-		postgres.IncrementVersionDownloads(c.Request().Context(), version)
+		postgres.IncrementVersionDownloads(c.Request().Context(), version, target)
+	}
+
+	recordUserDownload(c, version)
+
+	return sendDownload(c, version, versionTarget.Key, &versionTarget.Hash, &versionTarget.Size)
+}
+
+// @Summary Download a TargetName patch
+// @Tags Version
+// @Tags TargetName
+// @Description Download the patch turning the TargetName of a previous version into the TargetName of this version
+// @Accept  json
+// @Produce  json
+// @Param versionId path string true "Version ID"
+// @Param target path string true "TargetName"
+// @Param fromVersionId path string true "Version ID the patch applies to"
+// @Success 302 "Redirect to the file"
+// @Success 202 {object} GenericResponse{data=DownloadPreparing} "The file is being restored from cold storage"
+// @Failure 404 {string} string "Not found"
+// @Router /v1/version/{versionId}/{target}/patch/{fromVersionId} [get]
+func downloadModTargetPatch(c echo.Context) error {
+	versionID := c.Param("versionId")
+	target := c.Param("target")
+	fromVersionID := c.Param("fromVersionId")
+
+	version := postgres.GetVersion(c.Request().Context(), versionID)
+
+	if version == nil {
+		return c.String(404, "version not found, versionID:"+versionID)
+	}
+
+	if version.Quarantined {
+		return c.String(403, "version is quarantined pending virus scan")
+	}
+
+	patch := postgres.GetVersionTargetPatch(c.Request().Context(), versionID, fromVersionID, target)
+
+	if patch == nil {
+		return c.String(404, "patch not found, versionID:"+versionID+" target:"+target+" fromVersionID:"+fromVersionID)
+	}
+
+	if redis.CanIncrement(c.RealIP(), "download", "version:"+versionID, time.Hour*4) {
+		postgres.IncrementVersionDownloads(c.Request().Context(), version, target)
+	}
+
+	recordUserDownload(c, version)
+
+	recordBandwidth(c, version, patch.Size)
+
+	return c.Redirect(302, storage.GenerateSignedDownloadLink(patch.Key))
+}
+
+// @Summary Create a download token
+// @Tags Version
+// @Description Create a short-lived token allowing a single download of a version, or of one of its targets
+// @Accept  json
+// @Produce  json
+// @Param versionId path string true "Version ID"
+// @Param target query string false "TargetName"
+// @Success 200 {object} GenericResponse{data=DownloadToken}
+// @Failure 403 {object} GenericResponse{error=ErrorResponse} "Version is quarantined"
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Version or target not found"
+// @Failure 500 {object} GenericResponse{error=ErrorResponse} "Failed to create the download token"
+// @Router /v1/version/{versionId}/download-token [get]
+func createDownloadToken(c echo.Context) (interface{}, *ErrorResponse) {
+	versionID := c.Param("versionId")
+	target := c.QueryParam("target")
+
+	version := postgres.GetVersion(c.Request().Context(), versionID)
+
+	if version == nil {
+		return nil, &ErrorVersionNotFound
+	}
+
+	if version.Quarantined {
+		return nil, &ErrorVersionQuarantined
+	}
+
+	if target != "" && postgres.GetVersionTarget(c.Request().Context(), versionID, target) == nil {
+		return nil, &ErrorVersionTargetNotFound
+	}
+
+	download := redis.DownloadToken{
+		VersionID: versionID,
+		Target:    target,
+		IP:        c.RealIP(),
+	}
+
+	if user := userFromContext(c); user != nil {
+		download.UserID = user.ID
+	}
+
+	token, expiresAt, err := redis.CreateDownloadToken(download)
+	if err != nil {
+		log.Err(err).Str("version_id", versionID).Msg("failed to create download token")
+		return nil, &ErrorDownloadTokenFailed
+	}
+
+	return &DownloadToken{
+		Token:     token,
+		Path:      routePrefix(c) + "/download/" + token,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+// @Summary Download with a download token
+// @Tags Version
+// @Description Download the version or target a download token was created for. Tokens can only be used once.
+// @Accept  json
+// @Produce  json
+// @Param token path string true "Download token"
+// @Param mirror query string false "Name of the mirror to download from, primary for the primary storage"
+// @Success 302 "Redirect to the file"
+// @Success 202 {object} GenericResponse{data=DownloadPreparing} "The file is being restored from cold storage"
+// @Failure 404 {string} string "Not found"
+// @Failure 403 {string} string "Invalid or expired download token"
+// @Router /v1/download/{token} [get]
+func downloadWithToken(c echo.Context) error {
+	token := c.Param("token")
+
+	download, err := redis.GetDownloadToken(token)
+	if err != nil {
+		log.Err(err).Msg("failed to get download token")
+		return c.String(500, "failed to check download token")
+	}
+
+	if download == nil {
+		return c.String(403, "invalid or expired download token")
+	}
+
+	if viper.GetBool("downloads.tokens.bind_ip") && download.IP != "" && download.IP != c.RealIP() {
+		return c.String(403, "download token was created for another client")
+	}
+
+	version := postgres.GetVersion(c.Request().Context(), download.VersionID)
+
+	if version == nil {
+		return c.String(404, "version not found, versionID:"+download.VersionID)
+	}
+
+	if version.Quarantined {
+		return c.String(403, "version is quarantined pending virus scan")
+	}
+
+	key, hash, size := version.Key, version.Hash, version.Size
+	if download.Target != "" {
+		versionTarget := postgres.GetVersionTarget(c.Request().Context(), version.ID, download.Target)
+
+		if versionTarget == nil {
+			return c.String(404, "target not found, versionID:"+version.ID+" target:"+download.Target)
+		}
+
+		key, hash, size = versionTarget.Key, &versionTarget.Hash, &versionTarget.Size
+	}
+
+	// The token stays valid while the version is restored from cold storage
+	if preparing, err := preparingDownload(c, version); preparing {
+		return err
+	}
+
+	if !redis.ConsumeDownloadToken(token) {
+		return c.String(403, "invalid or expired download token")
+	}
+
+	// Downloads are attributed to the user the token was created for
+	requester := c.RealIP()
+	if download.UserID != "" {
+		requester = "user:" + download.UserID
+	}
+
+	if redis.CanIncrement(requester, "download", "version:"+version.ID, time.Hour*4) {
+		postgres.IncrementVersionDownloads(c.Request().Context(), version, download.Target)
+	}
+
+	if download.UserID != "" {
+		postgres.RecordUserDownload(c.Request().Context(), download.UserID, version.ModID)
+	}
+
+	return sendDownload(c, version, key, hash, size)
+}
+
+// @Summary Verify a TargetName download
+// @Tags Version
+// @Tags TargetName
+// @Description Check that the SHA256 hash of a downloaded target file matches the one recorded on upload
+// @Accept  json
+// @Produce  json
+// @Param versionId path string true "Version ID"
+// @Param target path string true "TargetName"
+// @Param hash query string true "Hex encoded SHA256 hash of the downloaded file"
+// @Success 200 {object} GenericResponse{data=TargetVerification}
+// @Failure 400 {object} GenericResponse{error=ErrorResponse} "Missing hash"
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Version, target or hash not found"
+// @Router /v1/version/{versionId}/{target}/verify [get]
+func verifyModTarget(c echo.Context) (interface{}, *ErrorResponse) {
+	versionID := c.Param("versionId")
+	target := c.Param("target")
+	hash := strings.TrimSpace(c.QueryParam("hash"))
+
+	if hash == "" {
+		return nil, &ErrorVersionTargetHashMissing
+	}
+
+	version := postgres.GetVersion(c.Request().Context(), versionID)
+
+	if version == nil {
+		return nil, &ErrorVersionNotFound
+	}
+
+	versionTarget := postgres.GetVersionTarget(c.Request().Context(), versionID, target)
+
+	if versionTarget == nil {
+		return nil, &ErrorVersionTargetNotFound
+	}
+
+	if versionTarget.Hash == "" {
+		return nil, &ErrorVersionTargetHashNotFound
 	}
 
-	return c.Redirect(302, storage.GenerateDownloadLink(versionTarget.Key))
+	return &TargetVerification{
+		Matches: strings.EqualFold(versionTarget.Hash, hash),
+		Hash:    versionTarget.Hash,
+	}, nil
 }
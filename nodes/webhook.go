@@ -0,0 +1,92 @@
+package nodes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/integrations"
+)
+
+// @Summary Retrieve Current Users Webhooks
+// @Tags User
+// @Description Retrieve the webhooks of the user associated with the token
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} GenericResponse{data=[]Webhook}
+// @Failure 403 {object} GenericResponse{error=ErrorResponse} "Invalid authorization token"
+// @Router /v1/user/me/webhooks [get]
+func getMyWebhooks(user *postgres.User, c echo.Context) (interface{}, *ErrorResponse) {
+	webhooks := postgres.GetUserWebhooks(c.Request().Context(), user.ID)
+
+	converted := make([]*Webhook, len(webhooks))
+	for k, v := range webhooks {
+		converted[k] = WebhookToWebhook(&v)
+	}
+
+	return converted, nil
+}
+
+// @Summary Create a Webhook
+// @Tags User
+// @Description Register a webhook for the user associated with the token.
+// @Description Events are POSTed to the url as JSON, with an X-SMR-Signature header of sha256=<hex HMAC-SHA256 of the body, keyed by the secret>.
+// @Accept  json
+// @Produce  json
+// @Param webhook body NewWebhook true "Webhook"
+// @Success 200 {object} GenericResponse{data=Webhook}
+// @Failure 400 {object} GenericResponse{error=ErrorResponse} "Invalid webhook"
+// @Failure 403 {object} GenericResponse{error=ErrorResponse} "Invalid authorization token"
+// @Router /v1/user/me/webhooks [post]
+func createWebhook(user *postgres.User, c echo.Context) (interface{}, *ErrorResponse) {
+	var request NewWebhook
+	if err := c.Bind(&request); err != nil {
+		return nil, GenericUserError(err)
+	}
+
+	ctx := c.Request().Context()
+
+	if postgres.GetUserWebhookCount(ctx, user.ID) >= viper.GetInt64("webhooks.max_per_user") {
+		return nil, &ErrorTooManyWebhooks
+	}
+
+	webhook := &postgres.Webhook{
+		UserID: user.ID,
+		URL:    request.URL,
+		Events: request.Events,
+		ModIDs: request.ModIDs,
+	}
+
+	if err := integrations.PrepareWebhook(ctx, user, webhook); err != nil {
+		return nil, GenericUserError(err)
+	}
+
+	created, err := postgres.CreateWebhook(ctx, webhook)
+	if err != nil {
+		return nil, &ErrorFailedWebhookCreation
+	}
+
+	return WebhookToWebhook(created), nil
+}
+
+// @Summary Delete a Webhook
+// @Tags User
+// @Description Delete a webhook of the user associated with the token
+// @Accept  json
+// @Produce  json
+// @Param webhookId path string true "Webhook ID"
+// @Success 200 {object} GenericResponse
+// @Failure 403 {object} GenericResponse{error=ErrorResponse} "Invalid authorization token"
+// @Failure 404 {object} GenericResponse{error=ErrorResponse} "Webhook not found"
+// @Router /v1/user/me/webhooks/{webhookId} [delete]
+func deleteWebhook(user *postgres.User, c echo.Context) (interface{}, *ErrorResponse) {
+	webhook := postgres.GetWebhookByID(c.Request().Context(), c.Param("webhookId"))
+
+	if webhook == nil || webhook.UserID != user.ID {
+		return nil, &ErrorWebhookNotFound
+	}
+
+	postgres.Delete(c.Request().Context(), webhook)
+
+	return nil, nil
+}
@@ -0,0 +1,50 @@
+package nodes
+
+import (
+	"time"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+)
+
+type Webhook struct {
+	CreatedAt      time.Time  `json:"created_at"`
+	LastDeliveryAt *time.Time `json:"last_delivery_at"`
+	LastStatus     *int       `json:"last_status"`
+	LastError      *string    `json:"last_error"`
+	ID             string     `json:"id"`
+	URL            string     `json:"url"`
+	Secret         string     `json:"secret"`
+	Events         []string   `json:"events"`
+	ModIDs         []string   `json:"mod_ids"`
+	FailureCount   int        `json:"failure_count"`
+	Active         bool       `json:"active"`
+}
+
+func WebhookToWebhook(webhook *postgres.Webhook) *Webhook {
+	modIDs := webhook.ModIDs
+	if modIDs == nil {
+		modIDs = []string{}
+	}
+
+	return &Webhook{
+		ID:             webhook.ID,
+		URL:            webhook.URL,
+		Secret:         webhook.Secret,
+		Events:         webhook.Events,
+		ModIDs:         modIDs,
+		Active:         webhook.Active,
+		FailureCount:   webhook.FailureCount,
+		LastStatus:     webhook.LastStatus,
+		LastError:      webhook.LastError,
+		LastDeliveryAt: webhook.LastDeliveryAt,
+		CreatedAt:      webhook.CreatedAt,
+	}
+}
+
+type NewWebhook struct {
+	URL string `json:"url"`
+	// One or more of version_created, version_approved, comment_created and report_created
+	Events []string `json:"events"`
+	// Mods the events are limited to, every mod if empty
+	ModIDs []string `json:"mod_ids"`
+}
@@ -0,0 +1,127 @@
+package consumers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/integrations"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+	"github.com/satisfactorymodding/smr-api/storage"
+)
+
+const integrityAuditBatchSize = 100
+
+func init() {
+	tasks.AuditStorageIntegrityTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_audit_storage_integrity",
+		Handler: AuditStorageIntegrityConsumer,
+	})
+}
+
+// AuditStorageIntegrityConsumer re-downloads the files of the least recently audited versions,
+// and marks versions as corrupted if a file is missing or does not match its recorded hash.
+func AuditStorageIntegrityConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.AuditStorageIntegrityData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	started := time.Now()
+
+	audited := 0
+	corrupted := 0
+	for task.SampleSize == 0 || audited < task.SampleSize {
+		limit := integrityAuditBatchSize
+		if task.SampleSize > 0 && task.SampleSize-audited < limit {
+			limit = task.SampleSize - audited
+		}
+
+		versions := postgres.GetIntegrityAuditVersions(ctx, started, limit)
+		if len(versions) == 0 {
+			break
+		}
+
+		for _, version := range versions {
+			version := version
+
+			corruptedKeys := auditVersionFiles(ctx, &version)
+			postgres.SetVersionIntegrity(ctx, &version, len(corruptedKeys) > 0)
+
+			if len(corruptedKeys) > 0 {
+				corrupted++
+				log.Error().Str("version", version.ID).Strs("keys", corruptedKeys).Msg("version failed integrity audit")
+
+				// Only alert once per corruption
+				if !version.Corrupted {
+					integrations.CorruptedVersion(ctx, &version, corruptedKeys)
+				}
+			}
+
+			audited++
+		}
+	}
+
+	log.Info().Msgf("Audited %d versions, %d corrupted", audited, corrupted)
+
+	return nil
+}
+
+// auditVersionFiles returns the keys of every file of the version not matching its recorded hash
+func auditVersionFiles(ctx context.Context, version *postgres.Version) []string {
+	var corruptedKeys []string
+
+	check := func(key string, expected *string) {
+		if key == "" || expected == nil || *expected == "" {
+			return
+		}
+
+		// Blobs shared with a quarantined version are audited once it is released
+		if storage.IsBlob(key) && postgres.IsBlobQuarantined(ctx, key) {
+			return
+		}
+
+		actual, err := hashStoredFile(key)
+		if err != nil {
+			log.Err(err).Str("key", key).Msg("failed to audit file")
+			corruptedKeys = append(corruptedKeys, key)
+			return
+		}
+
+		if !strings.EqualFold(actual, *expected) {
+			corruptedKeys = append(corruptedKeys, key)
+		}
+	}
+
+	check(version.Key, version.Hash)
+	for _, target := range version.Targets {
+		target := target
+		check(target.Key, &target.Hash)
+	}
+
+	return corruptedKeys
+}
+
+func hashStoredFile(key string) (string, error) {
+	file, err := storage.Get(storage.DecodeName(key))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", errors.Wrap(err, "failed to read file")
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
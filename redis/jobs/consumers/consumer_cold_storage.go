@@ -0,0 +1,128 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+	"github.com/satisfactorymodding/smr-api/storage"
+)
+
+func init() {
+	tasks.MoveVersionsToColdStorageTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_move_versions_to_cold_storage",
+		Handler: MoveVersionsToColdStorageConsumer,
+	})
+
+	tasks.RehydrateVersionTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_rehydrate_version",
+		Handler: RehydrateVersionConsumer,
+	})
+}
+
+func versionFileKeys(version *postgres.Version) []string {
+	keys := make([]string, 0, len(version.Targets)+1)
+	if version.Key != "" {
+		keys = append(keys, version.Key)
+	}
+
+	for _, target := range version.Targets {
+		if target.Key != "" {
+			keys = append(keys, target.Key)
+		}
+	}
+
+	return keys
+}
+
+// MoveVersionsToColdStorageConsumer moves the files of versions not downloaded within storage.cold.after to cold storage.
+// Mirror copies are dropped, they are replicated again once the version is rehydrated.
+func MoveVersionsToColdStorageConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.MoveVersionsToColdStorageData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	if !storage.ColdStorageEnabled() {
+		return nil
+	}
+
+	before := time.Now().Add(-viper.GetDuration("storage.cold.after"))
+
+	moved := 0
+	for _, version := range postgres.GetColdStorageCandidates(ctx, before, task.Limit) {
+		version := version
+		l := log.With().Str("version", version.ID).Logger()
+
+		failed := false
+		for _, key := range versionFileKeys(&version) {
+			if err := storage.MoveToColdStorage(ctx, key); err != nil {
+				l.Err(err).Str("key", key).Msg("failed to move file to cold storage")
+				failed = true
+				break
+			}
+		}
+
+		if failed {
+			// Files already moved are restored, so the version stays downloadable
+			for _, key := range versionFileKeys(&version) {
+				_ = storage.RestoreFromColdStorage(ctx, key)
+			}
+			continue
+		}
+
+		for _, replica := range postgres.GetVersionFileReplicas(ctx, version.ID) {
+			if err := storage.DeleteFromMirror(replica.Mirror, replica.Key); err != nil {
+				l.Warn().Err(err).Str("key", replica.Key).Str("mirror", replica.Mirror).Msg("failed to delete mirror copy")
+			}
+		}
+		postgres.DeleteVersionFileReplicas(ctx, version.ID)
+
+		postgres.SetVersionStorageTier(ctx, &version, postgres.StorageTierCold)
+		moved++
+	}
+
+	log.Info().Msgf("Moved %d versions to cold storage", moved)
+
+	return nil
+}
+
+// RehydrateVersionConsumer moves the files of a version back from cold storage
+func RehydrateVersionConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.RehydrateVersionData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	version := postgres.GetVersion(ctx, task.VersionID)
+	if version == nil {
+		log.Error().Msgf("version %s does not exist to rehydrate", task.VersionID)
+		return nil
+	}
+
+	if version.StorageTier == postgres.StorageTierHot {
+		return nil
+	}
+
+	for _, key := range versionFileKeys(version) {
+		if err := storage.RestoreFromColdStorage(ctx, key); err != nil {
+			return errors.Wrap(err, "failed to restore "+key)
+		}
+	}
+
+	postgres.SetVersionStorageTier(ctx, version, postgres.StorageTierHot)
+
+	if len(storage.Mirrors()) > 0 {
+		jobs.SubmitJobReplicateVersionFilesTask(ctx, version.ID)
+	}
+
+	return nil
+}
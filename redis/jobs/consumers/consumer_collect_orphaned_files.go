@@ -0,0 +1,82 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+	"github.com/satisfactorymodding/smr-api/storage"
+)
+
+func init() {
+	tasks.CollectOrphanedFilesTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_collect_orphaned_files",
+		Handler: CollectOrphanedFilesConsumer,
+	})
+}
+
+// orphanPrefixes are the storage prefixes holding version files
+var orphanPrefixes = []string{"/mods/", "/quarantine/mods/", "/blobs/"}
+
+// CollectOrphanedFilesConsumer deletes version files which are not referenced by any version, target or patch.
+//
+// Files modified within storage.gc.grace_period are kept, as they may belong to an upload still being finalized.
+// In dry run mode orphans are only reported.
+func CollectOrphanedFilesConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.CollectOrphanedFilesData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	referenced := make(map[string]struct{})
+	for _, key := range postgres.GetVersionFileKeys(ctx) {
+		referenced[storage.DecodeName("/"+strings.TrimPrefix(key, "/"))] = struct{}{}
+	}
+
+	cutoff := time.Now().Add(-viper.GetDuration("storage.gc.grace_period"))
+
+	orphans := 0
+	for _, prefix := range orphanPrefixes {
+		objects, err := storage.ListFiles(prefix)
+		if err != nil {
+			return errors.Wrap(err, "failed to list "+prefix)
+		}
+
+		for _, object := range objects {
+			if object.Key == nil {
+				continue
+			}
+
+			if _, ok := referenced[*object.Key]; ok {
+				continue
+			}
+
+			if object.LastModified != nil && object.LastModified.After(cutoff) {
+				continue
+			}
+
+			orphans++
+
+			if task.DryRun {
+				log.Info().Str("key", *object.Key).Msg("found orphaned file")
+				continue
+			}
+
+			if err := storage.DeleteFile(ctx, *object.Key); err != nil {
+				log.Err(err).Str("key", *object.Key).Msg("failed to delete orphaned file")
+			}
+		}
+	}
+
+	log.Info().Bool("dry_run", task.DryRun).Msgf("Found %d orphaned files", orphans)
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package consumers
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db"
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+)
+
+func init() {
+	tasks.ComputeSiteStatsTask = taskq.RegisterTask(&taskq.TaskOptions{
+		Name:    "consumer_compute_site_stats",
+		Handler: ComputeSiteStatsConsumer,
+	})
+}
+
+// ComputeSiteStatsConsumer refreshes the stats views, and the cached aggregate statistics of the site computed from them
+func ComputeSiteStatsConsumer(ctx context.Context, _ []byte) error {
+	if err := postgres.RefreshStatsViews(ctx); err != nil {
+		return err
+	}
+
+	stats, err := db.RefreshSiteStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Int64("mods", stats.Mods).Int64("versions", stats.Versions).Msg("Site stats computed")
+
+	return nil
+}
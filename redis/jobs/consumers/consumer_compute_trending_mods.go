@@ -0,0 +1,33 @@
+package consumers
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+)
+
+func init() {
+	tasks.ComputeTrendingModsTask = taskq.RegisterTask(&taskq.TaskOptions{
+		Name:    "consumer_compute_trending_mods",
+		Handler: ComputeTrendingModsConsumer,
+	})
+}
+
+// ComputeTrendingModsConsumer recomputes the trending scores of every period from the daily stats of mods
+func ComputeTrendingModsConsumer(ctx context.Context, _ []byte) error {
+	for period := range postgres.TrendingPeriods {
+		if err := postgres.ComputeTrendingMods(ctx, period); err != nil {
+			return err
+		}
+	}
+
+	postgres.PruneModDailyStats(ctx)
+
+	log.Info().Msg("Trending mods computed")
+
+	return nil
+}
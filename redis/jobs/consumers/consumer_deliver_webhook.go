@@ -0,0 +1,42 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/integrations"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+)
+
+func init() {
+	tasks.DeliverWebhookTask = taskq.RegisterTask(&taskq.TaskOptions{
+		Name:    "consumer_deliver_webhook",
+		Handler: DeliverWebhookConsumer,
+		// Retried with an exponential backoff, for about a day in total
+		RetryLimit: 12,
+		MinBackoff: 30 * time.Second,
+		MaxBackoff: 6 * time.Hour,
+	})
+}
+
+// DeliverWebhookConsumer delivers an event to a webhook, unless it was deleted or deactivated in the meantime
+func DeliverWebhookConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.DeliverWebhookData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	webhook := postgres.GetWebhookByID(ctx, task.WebhookID)
+	if webhook == nil || !webhook.Active {
+		log.Info().Str("webhook", task.WebhookID).Str("delivery", task.DeliveryID).Msg("dropping delivery of inactive webhook")
+		return nil
+	}
+
+	return integrations.DeliverWebhook(ctx, webhook, task.DeliveryID, task.Event, task.Payload)
+}
@@ -0,0 +1,125 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/gql"
+	"github.com/satisfactorymodding/smr-api/integrations"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+	"github.com/satisfactorymodding/smr-api/storage"
+)
+
+func init() {
+	tasks.EnforceRetentionPolicyTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_enforce_retention_policy",
+		Handler: EnforceRetentionPolicyConsumer,
+	})
+}
+
+// EnforceRetentionPolicyConsumer deletes published versions which stayed unapproved, quarantined or denied
+// for more than retention.unapproved_days. Authors are warned retention.notify_days before the deletion,
+// and a version is never deleted before its authors had that long to react.
+func EnforceRetentionPolicyConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.EnforceRetentionPolicyData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	day := time.Hour * 24
+	retention := time.Duration(viper.GetInt("retention.unapproved_days")) * day
+	notice := time.Duration(viper.GetInt("retention.notify_days")) * day
+	now := time.Now()
+
+	notified := 0
+	for _, version := range postgres.GetRetentionNotifyCandidates(ctx, now.Add(notice-retention), task.Limit) {
+		version := version
+
+		deleteAt := version.CreatedAt.Add(retention)
+		if deleteAt.Before(now.Add(notice)) {
+			deleteAt = now.Add(notice)
+		}
+
+		if err := integrations.UnapprovedVersionExpiring(ctx, &version, deleteAt); err != nil {
+			continue
+		}
+
+		postgres.SetVersionRetentionNotified(ctx, &version)
+		notified++
+	}
+
+	deleted := 0
+	for _, version := range postgres.GetRetentionDeleteCandidates(ctx, now.Add(-retention), now.Add(-notice), task.Limit) {
+		version := version
+
+		if err := purgeVersion(ctx, &version); err != nil {
+			log.Err(err).Str("version", version.ID).Msg("failed to delete expired version")
+			continue
+		}
+
+		deleted++
+	}
+
+	log.Info().Msgf("Retention policy: warned authors of %d versions, deleted %d versions", notified, deleted)
+
+	return nil
+}
+
+// purgeVersion deletes every row and stored file of a version
+func purgeVersion(ctx context.Context, version *postgres.Version) error {
+	keys := versionFileKeys(version)
+	keys = append(keys, postgres.GetVersionPatchKeys(ctx, version.ID)...)
+
+	if version.TorrentKey != nil {
+		keys = append(keys, *version.TorrentKey)
+	}
+
+	if version.MetalinkKey != nil {
+		keys = append(keys, *version.MetalinkKey)
+	}
+
+	replicas := postgres.GetVersionFileReplicas(ctx, version.ID)
+	shared := postgres.GetKeysReferencedByOtherVersions(ctx, version.ID, keys)
+
+	if err := postgres.PurgeVersion(ctx, version.ID); err != nil {
+		return err
+	}
+
+	// Files left behind by a failed deletion are collected as orphans
+	for _, key := range keys {
+		if !storage.IsBlob(key) && !shared[key] {
+			storage.DeleteVersionFile(ctx, key)
+		}
+	}
+
+	released := make(map[string]bool)
+	for _, target := range version.Targets {
+		if storage.IsBlob(target.Key) {
+			released[target.Key] = gql.ReleaseTargetFile(ctx, target.Key, target.Hash)
+		}
+	}
+
+	for _, replica := range replicas {
+		// Blobs still referenced by other versions keep their mirror copies
+		if storage.IsBlob(replica.Key) && !released[replica.Key] {
+			continue
+		}
+
+		if !storage.IsBlob(replica.Key) && shared[replica.Key] {
+			continue
+		}
+
+		if err := storage.DeleteFromMirror(replica.Mirror, replica.Key); err != nil {
+			log.Warn().Err(err).Str("key", replica.Key).Str("mirror", replica.Mirror).Msg("failed to delete mirror copy")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"runtime/debug"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/gql"
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+)
+
+func init() {
+	tasks.FinalizeVersionUploadTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_finalize_version_upload",
+		Handler: FinalizeVersionUploadConsumer,
+		// The multipart upload is consumed on the first attempt, retrying would never succeed
+		RetryLimit: 1,
+	})
+}
+
+func FinalizeVersionUploadConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.FinalizeVersionUploadData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("recover", r).Str("stack", string(debug.Stack())).Msgf("recovered from version finalization")
+
+			if err := redis.StoreVersionUploadState(task.ModID, task.VersionID, generated.VersionUploadStateFailed, nil, errors.New("internal error, please try again, if it fails again, please report on discord")); err != nil {
+				log.Error().Err(err).Msg("failed to store version upload state")
+			}
+		}
+	}()
+
+	mod := postgres.GetModByID(ctx, task.ModID)
+	if mod == nil {
+		return errors.Wrap(redis.StoreVersionUploadState(task.ModID, task.VersionID, generated.VersionUploadStateFailed, nil, errors.New("mod not found")), "error storing redis state")
+	}
+
+	log.Info().Str("mod_id", mod.ID).Str("version_id", task.VersionID).Msg("calling FinalizeVersionUploadAsync")
+
+	data, err := gql.FinalizeVersionUploadAsync(ctx, mod, task.VersionID, generated.NewVersion{
+		Changelog: task.Changelog,
+		Stability: generated.VersionStabilities(task.Stability),
+		Draft:     &task.Draft,
+
+		ReplaceUnapproved: &task.ReplaceUnapproved,
+	})
+
+	state := generated.VersionUploadStateDone
+	if data != nil && !data.AutoApproved && !task.Draft {
+		state = generated.VersionUploadStateScanning
+
+		// The scan may have already finished
+		if current, _ := redis.GetVersionUploadStatus(task.VersionID); current != nil && current.State != generated.VersionUploadStateScanning {
+			state = current.State
+			if current.Err != "" && err == nil {
+				err = errors.New(current.Err)
+			}
+		}
+	}
+
+	if err2 := redis.StoreVersionUploadState(task.ModID, task.VersionID, state, data, err); err2 != nil {
+		log.Err(err2).Msg("error storing redis state")
+		return nil
+	}
+
+	if err != nil {
+		log.Err(err).Msgf("error completing version upload [%s]", task.VersionID)
+	} else {
+		log.Info().Msgf("completed version upload: %s", task.VersionID)
+	}
+
+	return nil
+}
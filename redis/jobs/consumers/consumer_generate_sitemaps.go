@@ -0,0 +1,30 @@
+package consumers
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+)
+
+func init() {
+	tasks.GenerateSitemapsTask = taskq.RegisterTask(&taskq.TaskOptions{
+		Name:    "consumer_generate_sitemaps",
+		Handler: GenerateSitemapsConsumer,
+	})
+}
+
+// GenerateSitemapsConsumer regenerates the sitemaps of the public pages
+func GenerateSitemapsConsumer(ctx context.Context, _ []byte) error {
+	sitemaps, err := db.GenerateSitemaps(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Int("sitemaps", len(sitemaps)-1).Msg("Sitemaps generated")
+
+	return nil
+}
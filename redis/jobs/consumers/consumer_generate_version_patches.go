@@ -0,0 +1,123 @@
+package consumers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+	"github.com/satisfactorymodding/smr-api/storage"
+	"github.com/satisfactorymodding/smr-api/util/delta"
+)
+
+func init() {
+	tasks.GenerateVersionPatchesTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_generate_version_patches",
+		Handler: GenerateVersionPatchesConsumer,
+	})
+}
+
+func GenerateVersionPatchesConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.GenerateVersionPatchesData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	mod := postgres.GetModByID(ctx, task.ModID)
+	version := postgres.GetVersion(ctx, task.VersionID)
+	if mod == nil || version == nil {
+		log.Error().Msgf("mod %s version %s does not exist to generate patches for", task.ModID, task.VersionID)
+		return nil
+	}
+
+	// Patches are public, they are generated once the version is released from quarantine
+	if version.Quarantined {
+		log.Info().Msgf("mod %s version %s is quarantined, skipping patches", task.ModID, task.VersionID)
+		return nil
+	}
+
+	previous := postgres.GetPreviousModVersion(ctx, mod.ID, version.ID)
+	if previous == nil {
+		log.Info().Msgf("mod %s version %s has no previous version to patch from", task.ModID, task.VersionID)
+		return nil
+	}
+
+	previousTargets := make(map[string]postgres.VersionTarget)
+	for _, target := range previous.Targets {
+		previousTargets[target.TargetName] = target
+	}
+
+	for _, target := range postgres.GetVersionTargets(ctx, version.ID) {
+		previousTarget, ok := previousTargets[target.TargetName]
+		if !ok || previousTarget.Hash == target.Hash {
+			continue
+		}
+
+		if postgres.GetVersionTargetPatch(ctx, version.ID, previous.ID, target.TargetName) != nil {
+			continue
+		}
+
+		if err := generateTargetPatch(ctx, mod, previous, version, previousTarget, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func generateTargetPatch(ctx context.Context, mod *postgres.Mod, from *postgres.Version, to *postgres.Version, fromTarget postgres.VersionTarget, toTarget postgres.VersionTarget) error {
+	base, err := readStorageFile(fromTarget.Key)
+	if err != nil {
+		return errors.Wrap(err, "failed to read previous target file")
+	}
+
+	target, err := readStorageFile(toTarget.Key)
+	if err != nil {
+		return errors.Wrap(err, "failed to read target file")
+	}
+
+	patch := delta.Diff(base, target)
+
+	if float64(len(patch)) > float64(len(target))*viper.GetFloat64("patches.max_ratio") {
+		log.Info().Msgf("patch of mod %s target %s from %s to %s is too large, skipping", mod.ID, toTarget.TargetName, from.ID, to.ID)
+		return nil
+	}
+
+	success, key := storage.UploadModTargetPatch(ctx, mod.ID, mod.Name, from.Version, to.Version, toTarget.TargetName, patch)
+	if !success {
+		return errors.New("failed to upload patch")
+	}
+
+	hash := sha256.Sum256(patch)
+
+	postgres.Save(ctx, &postgres.VersionTargetPatch{
+		VersionID:     to.ID,
+		FromVersionID: from.ID,
+		TargetName:    toTarget.TargetName,
+		Key:           key,
+		Hash:          hex.EncodeToString(hash[:]),
+		Size:          int64(len(patch)),
+	})
+
+	log.Info().Msgf("generated patch of mod %s target %s from %s to %s (%d bytes)", mod.ID, toTarget.TargetName, from.ID, to.ID, len(patch))
+
+	return nil
+}
+
+func readStorageFile(key string) ([]byte, error) {
+	file, err := storage.GetQuarantined(storage.DecodeName(key))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
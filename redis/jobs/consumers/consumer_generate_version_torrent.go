@@ -0,0 +1,117 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+	"github.com/satisfactorymodding/smr-api/storage"
+	"github.com/satisfactorymodding/smr-api/util/distribution"
+)
+
+const distributionGenerator = "Satisfactory Mod Repository"
+
+func init() {
+	tasks.GenerateVersionTorrentTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_generate_version_torrent",
+		Handler: GenerateVersionTorrentConsumer,
+	})
+}
+
+// GenerateVersionTorrentConsumer creates a torrent, web seeded from storage, and a metalink document
+// for versions of at least torrents.min_size bytes.
+func GenerateVersionTorrentConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.GenerateVersionTorrentData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	version := postgres.GetVersion(ctx, task.VersionID)
+	if version == nil {
+		log.Error().Msgf("version %s does not exist to generate a torrent for", task.VersionID)
+		return nil
+	}
+
+	if version.Key == "" || version.Quarantined || version.Size == nil || *version.Size < viper.GetInt64("torrents.min_size") {
+		return nil
+	}
+
+	mod := postgres.GetModByID(ctx, version.ModID)
+	if mod == nil {
+		return nil
+	}
+
+	link := storage.GenerateDownloadLink(version.Key)
+	if link == "" {
+		return errors.New("no download link for " + version.Key)
+	}
+
+	file, err := storage.Get(storage.DecodeName(version.Key))
+	if err != nil {
+		return errors.Wrap(err, "failed to get version file")
+	}
+	defer file.Close()
+
+	name := mod.ModReference + "-" + version.Version + ".smod"
+
+	torrent, err := distribution.NewTorrent(file, name, distribution.PieceLength(*version.Size))
+	if err != nil {
+		return errors.Wrap(err, "failed to hash version file")
+	}
+
+	torrent.Trackers = viper.GetStringSlice("torrents.trackers")
+	torrent.WebSeeds = []string{link}
+	torrent.Comment = mod.Name + " " + version.Version
+	torrent.CreatedBy = distributionGenerator
+
+	infoHash, err := torrent.InfoHash()
+	if err != nil {
+		return err
+	}
+
+	torrentData, err := torrent.Encode()
+	if err != nil {
+		return err
+	}
+
+	success, torrentKey := storage.UploadVersionDistribution(ctx, mod.ID, mod.Name, version.ID, "torrent", torrentData)
+	if !success {
+		return errors.New("failed to upload torrent")
+	}
+
+	metalinkFile := distribution.MetalinkFile{
+		Name: name,
+		Size: torrent.Length,
+		URLs: []distribution.MetalinkURL{{Priority: 1, URL: link}},
+		MetaURLs: []distribution.MetalinkMetaURL{{
+			MediaType: "torrent",
+			URL:       storage.GenerateDownloadLink(torrentKey),
+		}},
+	}
+
+	if version.Hash != nil {
+		metalinkFile.Hashes = []distribution.MetalinkHash{{Type: "sha-256", Value: *version.Hash}}
+	}
+
+	metalinkData, err := distribution.NewMetalink(distributionGenerator, metalinkFile).Encode()
+	if err != nil {
+		return err
+	}
+
+	success, metalinkKey := storage.UploadVersionDistribution(ctx, mod.ID, mod.Name, version.ID, "meta4", metalinkData)
+	if !success {
+		return errors.New("failed to upload metalink")
+	}
+
+	postgres.SetVersionTorrent(ctx, version, torrentKey, metalinkKey, infoHash)
+
+	log.Info().Str("version", version.ID).Str("info_hash", infoHash).Msg("generated version torrent")
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package consumers
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+)
+
+func init() {
+	tasks.PruneSyncChangesTask = taskq.RegisterTask(&taskq.TaskOptions{
+		Name:    "consumer_prune_sync_changes",
+		Handler: PruneSyncChangesConsumer,
+	})
+}
+
+// PruneSyncChangesConsumer deletes the sync changes older than sync.retention.
+// Clients holding a token older than that have to run a full sync again.
+func PruneSyncChangesConsumer(ctx context.Context, _ []byte) error {
+	pruned := postgres.PruneSyncChanges(ctx, time.Now().Add(-viper.GetDuration("sync.retention")))
+
+	log.Info().Int64("pruned", pruned).Msg("Sync changes pruned")
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+	"github.com/satisfactorymodding/smr-api/storage"
+)
+
+func init() {
+	tasks.PurgeDeletedTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_purge_deleted",
+		Handler: PurgeDeletedConsumer,
+	})
+	tasks.PurgeModTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_purge_mod",
+		Handler: PurgeModConsumer,
+	})
+	tasks.PurgeVersionTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_purge_version",
+		Handler: PurgeVersionConsumer,
+	})
+}
+
+// PurgeDeletedConsumer permanently deletes content deleted for more than retention.deleted_days,
+// along with its stored files. Up to the task limit of every kind of content is purged per run.
+//
+// Deleted content stays in the database until then, so that it can be restored.
+func PurgeDeletedConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.PurgeDeletedData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	deletedDays := viper.GetInt("retention.deleted_days")
+	if deletedDays <= 0 {
+		return nil
+	}
+
+	deletedBefore := time.Now().Add(-time.Duration(deletedDays) * time.Hour * 24)
+
+	versions := 0
+	for _, version := range postgres.GetDeletedVersionPurgeCandidates(ctx, deletedBefore, task.Limit) {
+		version := version
+
+		if err := purgeVersion(ctx, &version); err != nil {
+			log.Err(err).Str("version", version.ID).Msg("failed to purge deleted version")
+			continue
+		}
+
+		versions++
+	}
+
+	mods := 0
+	for _, mod := range postgres.GetDeletedModPurgeCandidates(ctx, deletedBefore, task.Limit) {
+		mod := mod
+
+		if err := purgeMod(ctx, &mod); err != nil {
+			log.Err(err).Str("mod", mod.ID).Msg("failed to purge deleted mod")
+			continue
+		}
+
+		mods++
+	}
+
+	blueprints := 0
+	for _, blueprint := range postgres.GetDeletedBlueprintPurgeCandidates(ctx, deletedBefore, task.Limit) {
+		if err := postgres.PurgeBlueprint(ctx, blueprint.ID); err != nil {
+			log.Err(err).Str("blueprint", blueprint.ID).Msg("failed to purge deleted blueprint")
+			continue
+		}
+
+		if blueprint.Key != "" {
+			storage.DeleteBlueprint(ctx, blueprint.Key)
+		}
+
+		blueprints++
+	}
+
+	comments, err := postgres.PurgeDeletedComments(ctx, deletedBefore, task.Limit)
+	if err != nil {
+		log.Err(err).Msg("failed to purge deleted comments")
+	}
+
+	rows, err := postgres.PurgeDeletedRows(ctx, deletedBefore, task.Limit)
+	if err != nil {
+		log.Err(err).Msg("failed to purge deleted rows")
+	}
+
+	log.Info().Interface("rows", rows).Msgf("Purged %d versions, %d mods, %d blueprints and %d comments", versions, mods, blueprints, comments)
+
+	return nil
+}
+
+// PurgeModConsumer permanently deletes a mod right after it was deleted, as deleted mods cannot be restored.
+// Mods still depended on by other mods are left to PurgeDeletedConsumer, which purges them once they are not anymore.
+func PurgeModConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.PurgeModData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	mod := postgres.GetDeletedModForPurge(ctx, task.ModID)
+	if mod == nil {
+		log.Info().Str("mod", task.ModID).Msg("mod is not deleted or is still depended on, skipping purge")
+		return nil
+	}
+
+	if err := purgeMod(ctx, mod); err != nil {
+		return err
+	}
+
+	log.Info().Str("mod", mod.ID).Msg("Purged deleted mod")
+
+	return nil
+}
+
+// PurgeVersionConsumer permanently deletes an unapproved version right after it was replaced by a new upload of the same version
+func PurgeVersionConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.PurgeVersionData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	version := postgres.GetDeletedVersionForPurge(ctx, task.VersionID)
+	if version == nil {
+		log.Info().Str("version", task.VersionID).Msg("version is not deleted or was approved, skipping purge")
+		return nil
+	}
+
+	if err := purgeVersion(ctx, version); err != nil {
+		return err
+	}
+
+	log.Info().Str("version", version.ID).Msg("Purged replaced version")
+
+	return nil
+}
+
+// purgeMod deletes every version, row and stored file of a mod
+func purgeMod(ctx context.Context, mod *postgres.Mod) error {
+	for _, version := range postgres.GetModVersionsForPurge(ctx, mod.ID) {
+		version := version
+
+		if err := purgeVersion(ctx, &version); err != nil {
+			return errors.Wrap(err, "failed to purge version "+version.ID)
+		}
+	}
+
+	if err := postgres.PurgeMod(ctx, mod.ID); err != nil {
+		return err
+	}
+
+	if err := storage.DeleteModFiles(ctx, mod.ID, mod.ModReference); err != nil {
+		log.Warn().Err(err).Str("mod", mod.ID).Msg("failed to delete mod files")
+	}
+
+	return nil
+}
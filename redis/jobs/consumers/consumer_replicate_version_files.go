@@ -0,0 +1,68 @@
+package consumers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+	"github.com/satisfactorymodding/smr-api/storage"
+)
+
+func init() {
+	tasks.ReplicateVersionFilesTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_replicate_version_files",
+		Handler: ReplicateVersionFilesConsumer,
+	})
+}
+
+// ReplicateVersionFilesConsumer copies the version file and every target file to each storage mirror.
+// Files already replicated are skipped, so the task can be retried after a partial failure.
+func ReplicateVersionFilesConsumer(ctx context.Context, payload []byte) error {
+	var task tasks.ReplicateVersionFilesData
+	if err := json.Unmarshal(payload, &task); err != nil {
+		return errors.Wrap(err, "failed to unmarshal task data")
+	}
+
+	version := postgres.GetVersion(ctx, task.VersionID)
+	if version == nil {
+		log.Error().Msgf("version %s does not exist to replicate", task.VersionID)
+		return nil
+	}
+
+	keys := []string{version.Key}
+	for _, target := range postgres.GetVersionTargets(ctx, version.ID) {
+		keys = append(keys, target.Key)
+	}
+
+	var failed error
+	for _, key := range keys {
+		if key == "" || storage.IsQuarantined(key) {
+			continue
+		}
+
+		for _, mirror := range storage.Mirrors() {
+			if replica := postgres.GetFileReplica(ctx, key, mirror); replica != nil && replica.State == postgres.ReplicaStateReplicated {
+				continue
+			}
+
+			postgres.SetFileReplicaState(ctx, version.ID, key, mirror, postgres.ReplicaStatePending, nil)
+
+			if err := storage.ReplicateToMirror(ctx, mirror, key); err != nil {
+				log.Err(err).Str("key", key).Str("mirror", mirror).Msg("failed to replicate file")
+				postgres.SetFileReplicaState(ctx, version.ID, key, mirror, postgres.ReplicaStateFailed, err)
+				failed = err
+				continue
+			}
+
+			postgres.SetFileReplicaState(ctx, version.ID, key, mirror, postgres.ReplicaStateReplicated, nil)
+		}
+	}
+
+	// Returning the error lets taskq retry the failed files
+	return failed
+}
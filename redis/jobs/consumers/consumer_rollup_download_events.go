@@ -0,0 +1,50 @@
+package consumers
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
+)
+
+func init() {
+	tasks.RollupDownloadEventsTask = registerDedupedTask(&taskq.TaskOptions{
+		Name:    "consumer_rollup_download_events",
+		Handler: RollupDownloadEventsConsumer,
+	})
+}
+
+// RollupDownloadEventsConsumer adds the recent download events to the download counters,
+// and maintains the monthly partitions of the events
+func RollupDownloadEventsConsumer(ctx context.Context, _ []byte) error {
+	if err := postgres.EnsureDownloadEventPartitions(ctx, viper.GetInt("downloads.partitions_ahead")); err != nil {
+		log.Err(err).Msg("failed to create download event partitions")
+	}
+
+	rolledUp, err := postgres.RollupDownloadEvents(ctx, viper.GetDuration("downloads.settle_delay"))
+	if err != nil {
+		return err
+	}
+
+	if rolledUp > 0 {
+		log.Info().Int64("events", rolledUp).Msg("Download events rolled up")
+	}
+
+	if months := viper.GetInt("downloads.retention_months"); months > 0 {
+		dropped, err := postgres.DropDownloadEventPartitions(ctx, time.Now().AddDate(0, -months, 0))
+		if err != nil {
+			log.Err(err).Msg("failed to drop expired download event partitions")
+		}
+
+		if len(dropped) > 0 {
+			log.Info().Strs("partitions", dropped).Msg("Expired download event partitions dropped")
+		}
+	}
+
+	return nil
+}
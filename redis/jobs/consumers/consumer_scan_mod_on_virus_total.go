@@ -6,7 +6,6 @@ import (
 	"context"
 	"encoding/json"
 	"io"
-	"net/http"
 	"path"
 	"time"
 
@@ -15,7 +14,10 @@ import (
 	"github.com/vmihailenco/taskq/v3"
 
 	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+	"github.com/satisfactorymodding/smr-api/gql"
 	"github.com/satisfactorymodding/smr-api/integrations"
+	"github.com/satisfactorymodding/smr-api/redis"
 	"github.com/satisfactorymodding/smr-api/redis/jobs/tasks"
 	"github.com/satisfactorymodding/smr-api/storage"
 	"github.com/satisfactorymodding/smr-api/util"
@@ -23,7 +25,7 @@ import (
 )
 
 func init() {
-	tasks.ScanModOnVirusTotalTask = taskq.RegisterTask(&taskq.TaskOptions{
+	tasks.ScanModOnVirusTotalTask = registerDedupedTask(&taskq.TaskOptions{
 		Name:    "consumer_scan_mod_on_virus_total",
 		Handler: ScanModOnVirusTotalConsumer,
 	})
@@ -44,11 +46,14 @@ func ScanModOnVirusTotalConsumer(ctx context.Context, payload []byte) error {
 		return nil
 	}
 
-	link := storage.GenerateDownloadLink(version.Key)
-
-	response, _ := http.Get(link)
+	// Download links are not generated for quarantined files, so read the file from storage directly
+	modFile, err := storage.GetQuarantined(storage.DecodeName(version.Key))
+	if err != nil {
+		return errors.Wrap(err, "failed to get mod file")
+	}
 
-	fileData, err := io.ReadAll(response.Body)
+	fileData, err := io.ReadAll(modFile)
+	modFile.Close()
 	if err != nil {
 		return errors.Wrap(err, "failed to read mod file")
 	}
@@ -79,21 +84,46 @@ func ScanModOnVirusTotalConsumer(ctx context.Context, payload []byte) error {
 
 	if !success {
 		log.Warn().Msgf("mod %s version %s failed to pass virus scan", task.ModID, task.VersionID)
+
+		updateScanUploadStage(task, generated.VersionUploadStateFailed, errors.New("version failed to pass virus scan"))
+
 		return nil
 	}
 
+	gql.ReleaseVersionFromQuarantine(ctx, version)
+
 	if task.ApproveAfter {
 		log.Info().Msgf("approving mod %s version %s after successful virus scan", task.ModID, task.VersionID)
 		version.Approved = true
-		postgres.Save(ctx, &version)
+		if err := postgres.SaveVersion(ctx, version); err != nil {
+			return err
+		}
 
 		mod := postgres.GetModByID(ctx, task.ModID)
 		now := time.Now()
 		mod.LastVersionDate = &now
-		postgres.Save(ctx, &mod)
+		if err := postgres.SaveMod(ctx, mod); err != nil {
+			return err
+		}
+
+		gql.PublishVersionApproved(ctx, version, mod)
 
 		go integrations.NewVersion(util.ReWrapCtx(ctx), version)
 	}
 
+	updateScanUploadStage(task, generated.VersionUploadStateDone, nil)
+
 	return nil
 }
+
+// updateScanUploadStage moves the upload which created the scanned version to the state, if it came from an upload.
+// Upload states are stored under the upload ID, which differs from the ID the version got in the database.
+func updateScanUploadStage(task tasks.ScanModOnVirusTotalData, state generated.VersionUploadState, reason error) {
+	if task.UploadID == "" {
+		return
+	}
+
+	if err := redis.UpdateVersionUploadStage(task.UploadID, state, reason); err != nil {
+		log.Err(err).Msg("error storing redis state")
+	}
+}
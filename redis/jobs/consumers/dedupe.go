@@ -0,0 +1,67 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/cespare/xxhash"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/vmihailenco/taskq/v3"
+
+	"github.com/satisfactorymodding/smr-api/redis"
+)
+
+var (
+	acquireJobLock = redis.AcquireJobLock
+	releaseJobLock = redis.ReleaseJobLock
+)
+
+// registerDedupedTask registers a task whose handler runs under a redis lock of the task name and payload,
+// so that duplicate submissions of the same work, such as two scans of the same version, are not run side by side
+// by different workers. The duplicate is dropped as done, without being retried.
+//
+// The lock expires after jobs.dedupe_ttl, so a worker dying mid-run does not block the work forever.
+func registerDedupedTask(options *taskq.TaskOptions) *taskq.Task {
+	var handler func(ctx context.Context, payload []byte) error
+	switch h := options.Handler.(type) {
+	case func(ctx context.Context, payload []byte) error:
+		handler = h
+	case func(payload []byte) error:
+		handler = func(_ context.Context, payload []byte) error {
+			return h(payload)
+		}
+	default:
+		panic(fmt.Sprintf("task %s handler must take a payload", options.Name))
+	}
+
+	options.Handler = dedupe(options.Name, handler)
+
+	return taskq.RegisterTask(options)
+}
+
+// dedupe wraps a handler so it is skipped while another run with the same payload holds the lock
+func dedupe(name string, handler func(ctx context.Context, payload []byte) error) func(ctx context.Context, payload []byte) error {
+	return func(ctx context.Context, payload []byte) error {
+		key := name + ":" + strconv.FormatUint(xxhash.Sum64(payload), 16)
+
+		token, err := acquireJobLock(key, viper.GetDuration("jobs.dedupe_ttl"))
+		if err != nil {
+			return err
+		}
+
+		if token == "" {
+			log.Info().Str("task", name).Msg("task already running, skipping duplicate")
+			return nil
+		}
+
+		defer func() {
+			if err := releaseJobLock(key, token); err != nil {
+				log.Err(err).Str("task", name).Msg("failed to release job lock")
+			}
+		}()
+
+		return handler(ctx, payload)
+	}
+}
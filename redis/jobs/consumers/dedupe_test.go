@@ -0,0 +1,93 @@
+package consumers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useMemoryJobLocks replaces the redis job locks with in-memory ones until the end of the test
+func useMemoryJobLocks(t *testing.T) {
+	t.Helper()
+
+	var lock sync.Mutex
+	held := make(map[string]string)
+
+	previousAcquire, previousRelease := acquireJobLock, releaseJobLock
+	acquireJobLock = func(key string, _ time.Duration) (string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if _, ok := held[key]; ok {
+			return "", nil
+		}
+
+		held[key] = key + ":token"
+		return held[key], nil
+	}
+	releaseJobLock = func(key string, token string) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if held[key] == token {
+			delete(held, key)
+		}
+		return nil
+	}
+	t.Cleanup(func() {
+		acquireJobLock, releaseJobLock = previousAcquire, previousRelease
+	})
+}
+
+func TestDedupeSkipsDuplicateRuns(t *testing.T) {
+	useMemoryJobLocks(t)
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	runs := 0
+
+	handler := dedupe("test", func(_ context.Context, payload []byte) error {
+		runs++
+		if runs == 1 {
+			close(started)
+			<-finish
+		}
+		return nil
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- handler(context.Background(), []byte("slow"))
+	}()
+	<-started
+
+	if err := handler(context.Background(), []byte("slow")); err != nil {
+		t.Fatal(err)
+	}
+
+	if runs != 1 {
+		t.Errorf("expected the duplicate to be skipped, got %d runs", runs)
+	}
+
+	if err := handler(context.Background(), []byte("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	if runs != 2 {
+		t.Errorf("expected a different payload to run, got %d runs", runs)
+	}
+
+	close(finish)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := handler(context.Background(), []byte("slow")); err != nil {
+		t.Fatal(err)
+	}
+
+	if runs != 3 {
+		t.Errorf("expected the lock to be released after the run, got %d runs", runs)
+	}
+}
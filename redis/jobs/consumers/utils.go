@@ -4,13 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"io"
-	"net/http"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 
 	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/gql"
 	"github.com/satisfactorymodding/smr-api/storage"
+	"github.com/satisfactorymodding/smr-api/util"
 	"github.com/satisfactorymodding/smr-api/validation"
 )
 
@@ -19,14 +20,22 @@ func UpdateModDataFromStorage(ctx context.Context, modID string, versionID strin
 	log.Info().Msgf("[%s] Updating DB for mod %s version with metadata: %v", versionID, modID, metadata)
 
 	version := postgres.GetVersion(ctx, versionID)
-	link := storage.GenerateDownloadLink(version.Key)
+	if version == nil {
+		return errors.New("version not found")
+	}
 
-	response, _ := http.Get(link)
+	// Read from storage directly, as download links are not generated for quarantined files
+	modFile, err := storage.GetQuarantined(storage.DecodeName(version.Key))
+	if err != nil {
+		return errors.Wrap(err, "failed to get mod file")
+	}
 
-	fileData, err := io.ReadAll(response.Body)
+	fileData, fileSize, cleanup, err := util.BufferToTempFile(modFile)
+	modFile.Close()
 	if err != nil {
-		return errors.Wrap(err, "failed to read response body")
+		return errors.Wrap(err, "failed to read mod file")
 	}
+	defer cleanup()
 
 	mod := postgres.GetModByID(ctx, modID)
 
@@ -34,33 +43,15 @@ func UpdateModDataFromStorage(ctx context.Context, modID string, versionID strin
 		return errors.New("mod not found")
 	}
 
-	info, err := validation.ExtractModInfo(ctx, fileData, metadata, false, mod.ModReference)
+	info, err := validation.ExtractModInfo(ctx, fileData, fileSize, metadata, false, mod.ModReference)
 	if err != nil {
 		log.Warn().Err(err).Msgf("[%s] Failed updating mod, likely outdated", versionID)
 		// Outdated version
 		return nil
 	}
 
-	for depModID, condition := range info.Dependencies {
-		dependency := postgres.VersionDependency{
-			VersionID: version.ID,
-			ModID:     depModID,
-			Condition: condition,
-			Optional:  false,
-		}
-
-		postgres.Save(ctx, &dependency)
-	}
-
-	for depModID, condition := range info.OptionalDependencies {
-		dependency := postgres.VersionDependency{
-			VersionID: version.ID,
-			ModID:     depModID,
-			Condition: condition,
-			Optional:  true,
-		}
-
-		postgres.Save(ctx, &dependency)
+	if err := postgres.SaveVersionDependencies(ctx, version.ID, info.Dependencies, info.OptionalDependencies); err != nil {
+		return err
 	}
 
 	if metadata {
@@ -85,7 +76,78 @@ func UpdateModDataFromStorage(ctx context.Context, modID string, versionID strin
 
 	version.ModReference = &info.ModReference
 	version.SMLVersion = info.SMLVersion
-	postgres.Save(ctx, &version)
+
+	if err := backfillVersionTargets(ctx, mod, version, info, fileData, fileSize); err != nil {
+		return err
+	}
+
+	return postgres.SaveVersion(ctx, version)
+}
+
+// backfillVersionTargets creates the targets of versions uploaded before targets were tracked,
+// and fills in the hashes of targets that were stored without one.
+func backfillVersionTargets(ctx context.Context, mod *postgres.Mod, version *postgres.Version, info *validation.ModInfo, fileData io.ReaderAt, fileSize int64) error {
+	existing := make(map[string]postgres.VersionTarget)
+	for _, target := range postgres.GetVersionTargets(ctx, version.ID) {
+		existing[target.TargetName] = target
+	}
+
+	var targets []*postgres.VersionTarget
+
+	switch info.Type {
+	case validation.MultiTargetUEPlugin:
+		for _, targetName := range info.Targets {
+			if target, ok := existing[targetName]; ok && target.Key != "" && target.Hash != "" {
+				continue
+			}
+
+			log.Info().Str("target", targetName).Str("mod", mod.Name).Str("version", version.Version).Msg("backfilling mod target")
+
+			success, key, hash, size := storage.SeparateModTarget(ctx, fileData, fileSize, mod.ID, mod.Name, version.Version, targetName)
+			if !success {
+				return errors.New("failed to separate mod target " + targetName)
+			}
+
+			if storage.IsBlob(key) {
+				postgres.AcquireBlob(ctx, hash, key, size)
+			}
+
+			targets = append(targets, &postgres.VersionTarget{
+				VersionID:  version.ID,
+				TargetName: targetName,
+				Key:        key,
+				Hash:       hash,
+				Size:       size,
+			})
+		}
+	case validation.UEPlugin:
+		for _, targetName := range info.LegacyTargets {
+			if target, ok := existing[targetName]; ok && target.Hash != "" {
+				continue
+			}
+
+			targets = append(targets, &postgres.VersionTarget{
+				VersionID:  version.ID,
+				TargetName: targetName,
+				Key:        version.Key,
+				Hash:       info.Hash,
+				Size:       info.Size,
+			})
+		}
+
+		if len(info.LegacyTargets) > 0 {
+			version.LegacyTarget = true
+		}
+	}
+
+	if err := postgres.SaveVersionTargets(ctx, targets); err != nil {
+		return err
+	}
+
+	// Separated targets are uploaded to the public location, so they have to follow the version into quarantine
+	if len(targets) > 0 && version.Quarantined {
+		gql.QuarantineVersion(ctx, version)
+	}
 
 	return nil
 }
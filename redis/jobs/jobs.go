@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 	"github.com/vmihailenco/taskq/extra/taskqotel/v3"
@@ -92,10 +93,12 @@ func SubmitJobCopyObjectToOldBucketTask(ctx context.Context, key string) {
 	}
 }
 
-func SubmitJobScanModOnVirusTotalTask(ctx context.Context, modID string, version string, approveAfter bool) {
+// SubmitJobScanModOnVirusTotalTask scans the version, uploadID is the upload whose stage is updated, if there is one
+func SubmitJobScanModOnVirusTotalTask(ctx context.Context, modID string, version string, uploadID string, approveAfter bool) {
 	task, _ := json.Marshal(tasks.ScanModOnVirusTotalData{
 		ModID:        modID,
 		VersionID:    version,
+		UploadID:     uploadID,
 		ApproveAfter: approveAfter,
 	})
 
@@ -104,3 +107,394 @@ func SubmitJobScanModOnVirusTotalTask(ctx context.Context, modID string, version
 		log.Err(err).Msg("error adding task")
 	}
 }
+
+func SubmitJobFinalizeVersionUploadTask(ctx context.Context, modID string, version string, changelog string, stability string, draft bool, replaceUnapproved bool) error {
+	task, _ := json.Marshal(tasks.FinalizeVersionUploadData{
+		ModID:             modID,
+		VersionID:         version,
+		Changelog:         changelog,
+		Stability:         stability,
+		Draft:             draft,
+		ReplaceUnapproved: replaceUnapproved,
+	})
+
+	err := queue.Add(tasks.FinalizeVersionUploadTask.WithArgs(ctx, task))
+	if err != nil {
+		log.Err(err).Msg("error adding task")
+	}
+
+	return errors.Wrap(err, "failed to submit finalize task")
+}
+
+func SubmitJobGenerateVersionPatchesTask(ctx context.Context, modID string, version string) {
+	task, _ := json.Marshal(tasks.GenerateVersionPatchesData{
+		ModID:     modID,
+		VersionID: version,
+	})
+
+	err := queue.Add(tasks.GenerateVersionPatchesTask.WithArgs(ctx, task))
+	if err != nil {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+func SubmitJobReplicateVersionFilesTask(ctx context.Context, version string) {
+	task, _ := json.Marshal(tasks.ReplicateVersionFilesData{
+		VersionID: version,
+	})
+
+	err := queue.Add(tasks.ReplicateVersionFilesTask.WithArgs(ctx, task))
+	if err != nil {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// SubmitJobCollectOrphanedFilesTask queues a storage garbage collection.
+// Submissions within the same period are deduplicated, so every API instance can schedule it.
+func SubmitJobCollectOrphanedFilesTask(ctx context.Context, dryRun bool, period time.Duration) {
+	task, _ := json.Marshal(tasks.CollectOrphanedFilesData{
+		DryRun: dryRun,
+	})
+
+	message := tasks.CollectOrphanedFilesTask.WithArgs(ctx, task)
+	message.OnceInPeriod(period, dryRun)
+
+	err := queue.Add(message)
+	if err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// RunAsyncStorageGCLoop periodically schedules storage garbage collection, if storage.gc.enabled is set
+func RunAsyncStorageGCLoop(ctx context.Context) {
+	if !viper.GetBool("storage.gc.enabled") {
+		return
+	}
+
+	go func() {
+		for {
+			interval := viper.GetDuration("storage.gc.interval")
+			SubmitJobCollectOrphanedFilesTask(ctx, viper.GetBool("storage.gc.dry_run"), interval)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// SubmitJobMoveVersionsToColdStorageTask queues moving stale versions to cold storage.
+// Submissions within the same period are deduplicated, so every API instance can schedule it.
+func SubmitJobMoveVersionsToColdStorageTask(ctx context.Context, limit int, period time.Duration) {
+	task, _ := json.Marshal(tasks.MoveVersionsToColdStorageData{
+		Limit: limit,
+	})
+
+	message := tasks.MoveVersionsToColdStorageTask.WithArgs(ctx, task)
+	message.OnceInPeriod(period)
+
+	err := queue.Add(message)
+	if err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// SubmitJobRehydrateVersionTask queues restoring a version from cold storage.
+// Repeated submissions for the same version are deduplicated while one is pending.
+func SubmitJobRehydrateVersionTask(ctx context.Context, version string) {
+	task, _ := json.Marshal(tasks.RehydrateVersionData{
+		VersionID: version,
+	})
+
+	message := tasks.RehydrateVersionTask.WithArgs(ctx, task)
+	message.OnceInPeriod(time.Hour, version)
+
+	err := queue.Add(message)
+	if err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// RunAsyncColdStorageLoop periodically schedules moving stale versions to cold storage, if storage.cold.enabled is set
+func RunAsyncColdStorageLoop(ctx context.Context) {
+	if !viper.GetBool("storage.cold.enabled") {
+		return
+	}
+
+	go func() {
+		for {
+			interval := viper.GetDuration("storage.cold.interval")
+			SubmitJobMoveVersionsToColdStorageTask(ctx, viper.GetInt("storage.cold.batch_size"), interval)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+func SubmitJobGenerateVersionTorrentTask(ctx context.Context, version string) {
+	task, _ := json.Marshal(tasks.GenerateVersionTorrentData{
+		VersionID: version,
+	})
+
+	err := queue.Add(tasks.GenerateVersionTorrentTask.WithArgs(ctx, task))
+	if err != nil {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// SubmitJobAuditStorageIntegrityTask queues an integrity audit of stored version files.
+// Submissions within the same period are deduplicated, so every API instance can schedule it.
+func SubmitJobAuditStorageIntegrityTask(ctx context.Context, sampleSize int, period time.Duration) {
+	task, _ := json.Marshal(tasks.AuditStorageIntegrityData{
+		SampleSize: sampleSize,
+	})
+
+	message := tasks.AuditStorageIntegrityTask.WithArgs(ctx, task)
+	message.OnceInPeriod(period)
+
+	err := queue.Add(message)
+	if err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// RunAsyncIntegrityAuditLoop periodically schedules integrity audits, if storage.audit.enabled is set
+func RunAsyncIntegrityAuditLoop(ctx context.Context) {
+	if !viper.GetBool("storage.audit.enabled") {
+		return
+	}
+
+	go func() {
+		for {
+			interval := viper.GetDuration("storage.audit.interval")
+			SubmitJobAuditStorageIntegrityTask(ctx, viper.GetInt("storage.audit.sample_size"), interval)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// SubmitJobComputeTrendingModsTask queues the computation of the trending mods of every period.
+// Submissions within the same period are deduplicated, so every API instance can schedule it.
+func SubmitJobComputeTrendingModsTask(ctx context.Context, period time.Duration) {
+	task, _ := json.Marshal(tasks.ComputeTrendingModsData{})
+
+	message := tasks.ComputeTrendingModsTask.WithArgs(ctx, task)
+	message.OnceInPeriod(period)
+
+	err := queue.Add(message)
+	if err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// RunAsyncTrendingLoop periodically schedules the computation of trending mods, if trending.enabled is set
+func RunAsyncTrendingLoop(ctx context.Context) {
+	if !viper.GetBool("trending.enabled") {
+		return
+	}
+
+	go func() {
+		for {
+			interval := viper.GetDuration("trending.interval")
+			SubmitJobComputeTrendingModsTask(ctx, interval)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// SubmitJobComputeSiteStatsTask queues the computation of the aggregate statistics of the site.
+// Submissions within the same period are deduplicated, so every API instance can schedule it.
+func SubmitJobComputeSiteStatsTask(ctx context.Context, period time.Duration) {
+	task, _ := json.Marshal(tasks.ComputeSiteStatsData{})
+
+	message := tasks.ComputeSiteStatsTask.WithArgs(ctx, task)
+	message.OnceInPeriod(period)
+
+	err := queue.Add(message)
+	if err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// RunAsyncSiteStatsLoop periodically schedules the computation of the site statistics
+func RunAsyncSiteStatsLoop(ctx context.Context) {
+	go func() {
+		for {
+			interval := viper.GetDuration("stats.interval")
+			SubmitJobComputeSiteStatsTask(ctx, interval)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// SubmitJobGenerateSitemapsTask queues the generation of the sitemaps.
+// Submissions within the same period are deduplicated, so every API instance can schedule it.
+func SubmitJobGenerateSitemapsTask(ctx context.Context, period time.Duration) {
+	task, _ := json.Marshal(tasks.GenerateSitemapsData{})
+
+	message := tasks.GenerateSitemapsTask.WithArgs(ctx, task)
+	message.OnceInPeriod(period)
+
+	err := queue.Add(message)
+	if err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// RunAsyncSitemapLoop periodically schedules the generation of the sitemaps
+func RunAsyncSitemapLoop(ctx context.Context) {
+	go func() {
+		for {
+			interval := viper.GetDuration("sitemap.interval")
+			SubmitJobGenerateSitemapsTask(ctx, interval)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// SubmitJobPruneSyncChangesTask queues the deletion of the sync changes older than sync.retention.
+// Submissions within the same period are deduplicated, so every API instance can schedule it.
+func SubmitJobPruneSyncChangesTask(ctx context.Context, period time.Duration) {
+	task, _ := json.Marshal(tasks.PruneSyncChangesData{})
+
+	message := tasks.PruneSyncChangesTask.WithArgs(ctx, task)
+	message.OnceInPeriod(period)
+
+	err := queue.Add(message)
+	if err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// RunAsyncSyncPruneLoop periodically schedules the pruning of the sync changes
+func RunAsyncSyncPruneLoop(ctx context.Context) {
+	go func() {
+		for {
+			interval := viper.GetDuration("sync.prune_interval")
+			SubmitJobPruneSyncChangesTask(ctx, interval)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// SubmitJobDeliverWebhookTask queues the delivery of an event to a webhook, which is retried with a backoff until it succeeds
+func SubmitJobDeliverWebhookTask(ctx context.Context, webhookID string, deliveryID string, event string, payload []byte) {
+	task, _ := json.Marshal(tasks.DeliverWebhookData{
+		WebhookID:  webhookID,
+		DeliveryID: deliveryID,
+		Event:      event,
+		Payload:    payload,
+	})
+
+	err := queue.Add(tasks.DeliverWebhookTask.WithArgs(ctx, task))
+	if err != nil {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// SubmitJobEnforceRetentionPolicyTask queues the deletion of versions which were never approved.
+// Submissions within the same period are deduplicated, so every API instance can schedule it.
+func SubmitJobEnforceRetentionPolicyTask(ctx context.Context, limit int, period time.Duration) {
+	task, _ := json.Marshal(tasks.EnforceRetentionPolicyData{
+		Limit: limit,
+	})
+
+	message := tasks.EnforceRetentionPolicyTask.WithArgs(ctx, task)
+	message.OnceInPeriod(period)
+
+	err := queue.Add(message)
+	if err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// RunAsyncRetentionLoop periodically schedules the retention policy, if retention.enabled is set
+func RunAsyncRetentionLoop(ctx context.Context) {
+	if !viper.GetBool("retention.enabled") {
+		return
+	}
+
+	go func() {
+		for {
+			interval := viper.GetDuration("retention.interval")
+			SubmitJobEnforceRetentionPolicyTask(ctx, viper.GetInt("retention.batch_size"), interval)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// SubmitJobPurgeDeletedTask queues the purge of content deleted for more than retention.deleted_days.
+// Submissions within the same period are deduplicated, so every API instance can schedule it.
+func SubmitJobPurgeDeletedTask(ctx context.Context, limit int, period time.Duration) {
+	task, _ := json.Marshal(tasks.PurgeDeletedData{
+		Limit: limit,
+	})
+
+	message := tasks.PurgeDeletedTask.WithArgs(ctx, task)
+	message.OnceInPeriod(period)
+
+	err := queue.Add(message)
+	if err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// SubmitJobPurgeModTask queues the purge of a deleted mod, along with its versions and stored files
+func SubmitJobPurgeModTask(ctx context.Context, modID string) {
+	task, _ := json.Marshal(tasks.PurgeModData{
+		ModID: modID,
+	})
+
+	err := queue.Add(tasks.PurgeModTask.WithArgs(ctx, task))
+	if err != nil {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// SubmitJobPurgeVersionTask queues the purge of a deleted version which was never approved, along with its stored files
+func SubmitJobPurgeVersionTask(ctx context.Context, versionID string) {
+	task, _ := json.Marshal(tasks.PurgeVersionData{
+		VersionID: versionID,
+	})
+
+	err := queue.Add(tasks.PurgeVersionTask.WithArgs(ctx, task))
+	if err != nil {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// RunAsyncPurgeLoop periodically schedules the purge of deleted content, if retention.deleted_days is set
+func RunAsyncPurgeLoop(ctx context.Context) {
+	if viper.GetInt("retention.deleted_days") <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			interval := viper.GetDuration("retention.interval")
+			SubmitJobPurgeDeletedTask(ctx, viper.GetInt("retention.batch_size"), interval)
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// SubmitJobRollupDownloadEventsTask queues the rollup of the download events into the download counters.
+// Submissions within the same period are deduplicated, so every API instance can schedule it.
+func SubmitJobRollupDownloadEventsTask(ctx context.Context, period time.Duration) {
+	task, _ := json.Marshal(tasks.RollupDownloadEventsData{})
+
+	message := tasks.RollupDownloadEventsTask.WithArgs(ctx, task)
+	message.OnceInPeriod(period)
+
+	err := queue.Add(message)
+	if err != nil && !errors.Is(err, taskq.ErrDuplicate) {
+		log.Err(err).Msg("error adding task")
+	}
+}
+
+// RunAsyncDownloadRollupLoop periodically schedules the rollup of the download events
+func RunAsyncDownloadRollupLoop(ctx context.Context) {
+	go func() {
+		for {
+			interval := viper.GetDuration("downloads.rollup_interval")
+			SubmitJobRollupDownloadEventsTask(ctx, interval)
+			time.Sleep(interval)
+		}
+	}()
+}
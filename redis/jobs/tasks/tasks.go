@@ -1,6 +1,10 @@
 package tasks
 
-import "github.com/vmihailenco/taskq/v3"
+import (
+	"encoding/json"
+
+	"github.com/vmihailenco/taskq/v3"
+)
 
 var (
 	UpdateDBFromModVersionFileTask     *taskq.Task
@@ -8,6 +12,24 @@ var (
 	CopyObjectFromOldBucketTask        *taskq.Task
 	CopyObjectToOldBucketTask          *taskq.Task
 	ScanModOnVirusTotalTask            *taskq.Task
+	FinalizeVersionUploadTask          *taskq.Task
+	GenerateVersionPatchesTask         *taskq.Task
+	ReplicateVersionFilesTask          *taskq.Task
+	CollectOrphanedFilesTask           *taskq.Task
+	MoveVersionsToColdStorageTask      *taskq.Task
+	RehydrateVersionTask               *taskq.Task
+	GenerateVersionTorrentTask         *taskq.Task
+	AuditStorageIntegrityTask          *taskq.Task
+	EnforceRetentionPolicyTask         *taskq.Task
+	ComputeTrendingModsTask            *taskq.Task
+	DeliverWebhookTask                 *taskq.Task
+	ComputeSiteStatsTask               *taskq.Task
+	GenerateSitemapsTask               *taskq.Task
+	PruneSyncChangesTask               *taskq.Task
+	PurgeDeletedTask                   *taskq.Task
+	PurgeModTask                       *taskq.Task
+	PurgeVersionTask                   *taskq.Task
+	RollupDownloadEventsTask           *taskq.Task
 )
 
 type UpdateDBFromModVersionFileData struct {
@@ -31,5 +53,79 @@ type CopyObjectToOldBucketData struct {
 type ScanModOnVirusTotalData struct {
 	ModID        string `json:"mod_id"`
 	VersionID    string `json:"version_id"`
+	UploadID     string `json:"upload_id"`
 	ApproveAfter bool   `json:"approve_after"`
 }
+
+type FinalizeVersionUploadData struct {
+	ModID     string `json:"mod_id"`
+	VersionID string `json:"version_id"`
+	Changelog string `json:"changelog"`
+	Stability string `json:"stability"`
+	Draft     bool   `json:"draft"`
+
+	ReplaceUnapproved bool `json:"replace_unapproved"`
+}
+
+type GenerateVersionPatchesData struct {
+	ModID     string `json:"mod_id"`
+	VersionID string `json:"version_id"`
+}
+
+type ReplicateVersionFilesData struct {
+	VersionID string `json:"version_id"`
+}
+
+type CollectOrphanedFilesData struct {
+	DryRun bool `json:"dry_run"`
+}
+
+type MoveVersionsToColdStorageData struct {
+	Limit int `json:"limit"`
+}
+
+type RehydrateVersionData struct {
+	VersionID string `json:"version_id"`
+}
+
+type GenerateVersionTorrentData struct {
+	VersionID string `json:"version_id"`
+}
+
+type AuditStorageIntegrityData struct {
+	// SampleSize is the amount of versions to audit, 0 audits every version
+	SampleSize int `json:"sample_size"`
+}
+
+type EnforceRetentionPolicyData struct {
+	Limit int `json:"limit"`
+}
+
+type ComputeTrendingModsData struct{}
+
+type DeliverWebhookData struct {
+	WebhookID  string          `json:"webhook_id"`
+	DeliveryID string          `json:"delivery_id"`
+	Event      string          `json:"event"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+type ComputeSiteStatsData struct{}
+
+type GenerateSitemapsData struct{}
+
+type PruneSyncChangesData struct{}
+
+type PurgeDeletedData struct {
+	Limit int `json:"limit"`
+}
+
+type PurgeModData struct {
+	ModID string `json:"mod_id"`
+}
+
+type PurgeVersionData struct {
+	VersionID string `json:"version_id"`
+}
+
+type RollupDownloadEventsData struct{}
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cespare/xxhash"
@@ -50,6 +53,122 @@ func CanIncrement(ip string, action string, object string, expiration time.Durat
 	return client.SetNX(key, true, expiration).Val()
 }
 
+// Ping checks that redis accepts commands
+func Ping() error {
+	return errors.Wrap(client.Ping().Err(), "failed to ping redis")
+}
+
+// RateLimitExceededError is returned when a user performed an action too often
+type RateLimitExceededError struct {
+	Action     string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitExceededError) Error() string {
+	return fmt.Sprintf("rate limit for %s exceeded, retry in %.0f seconds", e.Action, e.RetryAfter.Seconds())
+}
+
+func (e *RateLimitExceededError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":        "RATE_LIMITED",
+		"action":      e.Action,
+		"retry_after": int(e.RetryAfter.Seconds()),
+	}
+}
+
+// CheckUserRateLimit counts an action of the user and fails once it was performed
+// more than ratelimit.<action>.limit times within ratelimit.<action>.window.
+// A limit of 0 disables the rate limit.
+func CheckUserRateLimit(userID string, action string) error {
+	limit := viper.GetInt64("ratelimit." + action + ".limit")
+	if limit <= 0 {
+		return nil
+	}
+
+	window := viper.GetDuration("ratelimit." + action + ".window")
+
+	key := "ratelimit:" + action + ":" + userID
+
+	count, err := client.Incr(key).Result()
+	if err != nil {
+		return errors.Wrap(err, "failed to increment rate limit")
+	}
+
+	if count == 1 {
+		client.Expire(key, window)
+	}
+
+	if count <= limit {
+		return nil
+	}
+
+	retryAfter, err := client.TTL(key).Result()
+	if err != nil {
+		return errors.Wrap(err, "failed to get rate limit expiry")
+	}
+
+	if retryAfter < 0 {
+		// The expiry got lost, e.g. if the process died between INCR and EXPIRE
+		client.Expire(key, window)
+		retryAfter = window
+	}
+
+	return &RateLimitExceededError{
+		Action:     action,
+		RetryAfter: retryAfter,
+	}
+}
+
+// ConsumeQueryBudget spends the cost of an operation from the budget of the identity for the window.
+// The cost is only spent if the budget allows it.
+// Returns the remaining budget, the time until the budget is reset, and whether the cost could be spent.
+func ConsumeQueryBudget(identity string, cost int64, budget int64, window time.Duration) (int64, time.Duration, bool, error) {
+	return consumeBudget("query_budget:"+identity, cost, budget, window)
+}
+
+// CountRequest counts a request of the identity against the request rate limit of the action,
+// configured by ratelimit.<action>.limit and ratelimit.<action>.window.
+// Returns the limit, the remaining requests, the time until the limit is reset, and whether the request is allowed.
+// A limit of 0 disables the rate limit.
+func CountRequest(identity string, action string) (int64, int64, time.Duration, bool, error) {
+	limit := viper.GetInt64("ratelimit." + action + ".limit")
+	if limit <= 0 {
+		return 0, 0, 0, true, nil
+	}
+
+	remaining, reset, ok, err := consumeBudget("ratelimit:"+action+":"+identity, 1, limit, viper.GetDuration("ratelimit."+action+".window"))
+	return limit, remaining, reset, ok, err
+}
+
+func consumeBudget(key string, cost int64, budget int64, window time.Duration) (int64, time.Duration, bool, error) {
+	spent, err := client.IncrBy(key, cost).Result()
+	if err != nil {
+		return 0, 0, false, errors.Wrap(err, "failed to increment budget")
+	}
+
+	if spent == cost {
+		client.Expire(key, window)
+	}
+
+	reset, err := client.TTL(key).Result()
+	if err != nil {
+		return 0, 0, false, errors.Wrap(err, "failed to get budget expiry")
+	}
+
+	if reset < 0 {
+		// The expiry got lost, e.g. if the process died between INCRBY and EXPIRE
+		client.Expire(key, window)
+		reset = window
+	}
+
+	if spent > budget {
+		client.DecrBy(key, cost)
+		return budget - spent + cost, reset, false, nil
+	}
+
+	return budget - spent, reset, true, nil
+}
+
 func StoreNonce(nonce string, redirectURI string) {
 	client.Set("nonce:"+nonce, redirectURI, time.Minute*10)
 }
@@ -63,26 +182,67 @@ func GetAllKeys() []string {
 	return client.Keys("*").Val()
 }
 
+const activeMultipartUploadsKey = "s3:uploads:active"
+
+func multipartUploadTTL() time.Duration {
+	return viper.GetDuration("storage.multipart_upload_ttl")
+}
+
 func StoreMultipartCompletedPart(key string, etag string, part int) {
 	encodedKey := base64.RawStdEncoding.EncodeToString([]byte(key))
 	redisKey := "s3:uploads:part:" + encodedKey
 	client.HMSet(redisKey, map[string]interface{}{
 		strconv.Itoa(part): etag,
 	})
-	client.Expire(redisKey, time.Minute*60)
+	TouchMultipartUpload(key)
+}
+
+func StoreMultipartPartSize(key string, part int, size int64) {
+	encodedKey := base64.RawStdEncoding.EncodeToString([]byte(key))
+	redisKey := "s3:uploads:size:" + encodedKey
+	client.HSet(redisKey, strconv.Itoa(part), size)
+	client.Expire(redisKey, multipartUploadTTL())
+}
+
+// GetMultipartUploadedSize returns the total size of all uploaded parts, excluding the provided part
+func GetMultipartUploadedSize(key string, excludePart int) int64 {
+	encodedKey := base64.RawStdEncoding.EncodeToString([]byte(key))
+	sizes := client.HGetAll("s3:uploads:size:" + encodedKey).Val()
+
+	var total int64
+	for part, size := range sizes {
+		if part == strconv.Itoa(excludePart) {
+			continue
+		}
+		parsed, _ := strconv.ParseInt(size, 10, 64)
+		total += parsed
+	}
+
+	return total
+}
+
+func GetMultipartCompletedParts(key string) map[string]string {
+	encodedKey := base64.RawStdEncoding.EncodeToString([]byte(key))
+	return client.HGetAll("s3:uploads:part:" + encodedKey).Val()
 }
 
 func GetAndClearMultipartCompletedParts(key string) map[string]string {
 	encodedKey := base64.RawStdEncoding.EncodeToString([]byte(key))
 	all := client.HGetAll("s3:uploads:part:" + encodedKey)
 	client.Del("s3:uploads:part:" + encodedKey)
+	ClearMultipartUpload(key)
 	return all.Val()
 }
 
 func StoreMultipartUploadID(key string, id string) {
 	encodedKey := base64.RawStdEncoding.EncodeToString([]byte(key))
 	redisKey := "s3:uploads:part:" + encodedKey + ":id"
-	client.Set(redisKey, id, time.Minute*60)
+	client.Set(redisKey, id, multipartUploadTTL())
+	client.HSet(activeMultipartUploadsKey+":ids", key, id)
+	client.ZAdd(activeMultipartUploadsKey, redis.Z{
+		Score:  float64(time.Now().Unix()),
+		Member: key,
+	})
 }
 
 func GetMultipartUploadID(key string) string {
@@ -91,31 +251,140 @@ func GetMultipartUploadID(key string) string {
 	return client.Get(redisKey).Val()
 }
 
+// TouchMultipartUpload marks the upload as active, pushing back its expiry
+func TouchMultipartUpload(key string) {
+	encodedKey := base64.RawStdEncoding.EncodeToString([]byte(key))
+	redisKey := "s3:uploads:part:" + encodedKey
+	client.Expire(redisKey, multipartUploadTTL())
+	client.Expire(redisKey+":id", multipartUploadTTL())
+	client.Expire("s3:uploads:size:"+encodedKey, multipartUploadTTL())
+	client.ZAddXX(activeMultipartUploadsKey, redis.Z{
+		Score:  float64(time.Now().Unix()),
+		Member: key,
+	})
+}
+
+// ClearMultipartUpload removes the upload from the active upload tracking
+func ClearMultipartUpload(key string) {
+	encodedKey := base64.RawStdEncoding.EncodeToString([]byte(key))
+	client.Del("s3:uploads:part:"+encodedKey, "s3:uploads:part:"+encodedKey+":id", "s3:uploads:size:"+encodedKey)
+	client.ZRem(activeMultipartUploadsKey, key)
+	client.HDel(activeMultipartUploadsKey+":ids", key)
+}
+
+// GetExpiredMultipartUploads returns all uploads (key -> upload id) without any activity since before
+func GetExpiredMultipartUploads(before time.Time) map[string]string {
+	keys := client.ZRangeByScore(activeMultipartUploadsKey, redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(before.Unix(), 10),
+	}).Val()
+
+	result := make(map[string]string, len(keys))
+	for _, key := range keys {
+		result[key] = client.HGet(activeMultipartUploadsKey+":ids", key).Val()
+	}
+
+	return result
+}
+
 type StoredVersionUploadState struct {
-	Data *generated.CreateVersionResponse `json:"data"`
-	Err  string                           `json:"err"`
+	ModID            string                           `json:"mod_id"`
+	Data             *generated.CreateVersionResponse `json:"data"`
+	Err              string                           `json:"err"`
+	State            generated.VersionUploadState     `json:"state"`
+	DependencyErrors []*generated.DependencyError     `json:"dependency_errors,omitempty"`
+	ErrExtensions    map[string]interface{}           `json:"err_extensions,omitempty"`
 }
 
-func StoreVersionUploadState(versionID string, data *generated.CreateVersionResponse, err error) error {
-	state := StoredVersionUploadState{
-		Data: data,
+// StoreVersionUploadState stores the state of an upload of a version of the mod, and publishes it to progress subscribers
+func StoreVersionUploadState(modID string, versionID string, state generated.VersionUploadState, data *generated.CreateVersionResponse, err error) error {
+	stored := StoredVersionUploadState{
+		ModID: modID,
+		Data:  data,
+		State: state,
 	}
 
 	if err != nil {
-		state.Err = err.Error()
+		stored.Err = err.Error()
+		stored.State = generated.VersionUploadStateFailed
+
+		var dependencyErr interface {
+			DependencyErrors() []*generated.DependencyError
+		}
+		if errors.As(err, &dependencyErr) {
+			stored.DependencyErrors = dependencyErr.DependencyErrors()
+		}
+
+		var extendedErr interface {
+			Extensions() map[string]interface{}
+		}
+		if errors.As(err, &extendedErr) {
+			stored.ErrExtensions = extendedErr.Extensions()
+		}
 	}
 
-	marshaled, e := json.Marshal(state)
+	marshaled, e := json.Marshal(stored)
 
 	if e != nil {
-		return errors.Wrap(err, "failed to marshal version upload state")
+		return errors.Wrap(e, "failed to marshal version upload state")
 	}
 
 	redisKey := "version:upload:state:" + versionID
-	return errors.Wrap(client.Set(redisKey, string(marshaled), time.Minute*10).Err(), "failed to store version upload state")
+	if err := client.Set(redisKey, string(marshaled), time.Hour*24).Err(); err != nil {
+		return errors.Wrap(err, "failed to store version upload state")
+	}
+
+	progress := &generated.VersionUploadProgress{
+		State: stored.State,
+	}
+
+	if stored.Err != "" {
+		progress.Reason = &stored.Err
+	}
+
+	PublishVersionUploadProgress(versionID, progress)
+
+	return nil
+}
+
+// UpdateVersionUploadStage moves an in-progress upload to the provided state, keeping any stored result.
+// If reason is provided, the upload is marked as failed.
+// Versions without a stored upload state are ignored.
+func UpdateVersionUploadStage(versionID string, state generated.VersionUploadState, reason error) error {
+	stored, err := GetVersionUploadStatus(versionID)
+	if err != nil {
+		return err
+	}
+
+	if stored == nil {
+		return nil
+	}
+
+	if reason == nil && stored.Err != "" {
+		reason = storedUploadError{state: stored}
+	}
+
+	return StoreVersionUploadState(stored.ModID, versionID, state, stored.Data, reason)
+}
+
+// storedUploadError restores a previously stored upload error including its details
+type storedUploadError struct {
+	state *StoredVersionUploadState
+}
+
+func (e storedUploadError) Error() string {
+	return e.state.Err
+}
+
+func (e storedUploadError) DependencyErrors() []*generated.DependencyError {
+	return e.state.DependencyErrors
+}
+
+func (e storedUploadError) Extensions() map[string]interface{} {
+	return e.state.ErrExtensions
 }
 
-func GetVersionUploadState(versionID string) (*generated.CreateVersionResponse, error) {
+func GetVersionUploadStatus(versionID string) (*StoredVersionUploadState, error) {
 	redisKey := "version:upload:state:" + versionID
 	get := client.Get(redisKey)
 
@@ -133,11 +402,62 @@ func GetVersionUploadState(versionID string) (*generated.CreateVersionResponse,
 	data := &StoredVersionUploadState{}
 	_ = json.Unmarshal([]byte(get.Val()), data)
 
-	if data.Err != "" {
-		return data.Data, errors.New(data.Err)
+	return data, nil
+}
+
+func versionUploadProgressChannel(versionID string) string {
+	return "version:upload:progress:" + versionID
+}
+
+// PublishVersionUploadProgress notifies the subscribers of an upload of its progress
+func PublishVersionUploadProgress(versionID string, progress *generated.VersionUploadProgress) {
+	marshaled, err := json.Marshal(progress)
+	if err != nil {
+		log.Err(err).Msg("failed to marshal version upload progress")
+		return
 	}
 
-	return data.Data, nil
+	if err := client.Publish(versionUploadProgressChannel(versionID), string(marshaled)).Err(); err != nil {
+		log.Err(err).Str("version_id", versionID).Msg("failed to publish version upload progress")
+	}
+}
+
+// SubscribeVersionUploadProgress streams the progress of an upload until the context is done.
+// Progress is published by whichever instance is processing the upload, so it is relayed through redis.
+func SubscribeVersionUploadProgress(ctx context.Context, versionID string) <-chan *generated.VersionUploadProgress {
+	pubsub := client.Subscribe(versionUploadProgressChannel(versionID))
+	out := make(chan *generated.VersionUploadProgress)
+
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				progress := &generated.VersionUploadProgress{}
+				if err := json.Unmarshal([]byte(message.Payload), progress); err != nil {
+					log.Err(err).Msg("failed to unmarshal version upload progress")
+					continue
+				}
+
+				select {
+				case out <- progress:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out
 }
 
 func FlushRedis() {
@@ -176,3 +496,496 @@ func GetModAssetList(modReference string) []string {
 
 	return out
 }
+
+const siteStatsKey = "site_stats"
+
+// StoreSiteStats caches the aggregate statistics of the site until they are computed again
+func StoreSiteStats(stats interface{}, ttl time.Duration) error {
+	marshaled, err := json.Marshal(stats)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal site stats")
+	}
+
+	return errors.Wrap(client.Set(siteStatsKey, marshaled, ttl).Err(), "failed to store site stats")
+}
+
+// GetSiteStats reads the cached aggregate statistics of the site into target, and returns whether they were cached
+func GetSiteStats(target interface{}) bool {
+	result, err := client.Get(siteStatsKey).Bytes()
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(result, target) == nil
+}
+
+func primaryReadsKey(identity string) string {
+	return "primary_reads:" + identity
+}
+
+// StickToPrimary makes the reads of a caller which just wrote something go to the primary database for ttl
+func StickToPrimary(identity string, ttl time.Duration) {
+	if err := client.Set(primaryReadsKey(identity), 1, ttl).Err(); err != nil {
+		log.Err(err).Msg("failed to stick reads to primary")
+	}
+}
+
+// IsStuckToPrimary returns whether the reads of a caller have to go to the primary database
+func IsStuckToPrimary(identity string) bool {
+	exists, err := client.Exists(primaryReadsKey(identity)).Result()
+	return err == nil && exists > 0
+}
+
+func sitemapKey(name string) string {
+	return "sitemap:" + name
+}
+
+// StoreSitemaps stores the rendered sitemaps by name until they are generated again.
+// Sitemaps which are not generated anymore expire on their own.
+func StoreSitemaps(sitemaps map[string][]byte, ttl time.Duration) error {
+	pipe := client.TxPipeline()
+	for name, sitemap := range sitemaps {
+		pipe.Set(sitemapKey(name), sitemap, ttl)
+	}
+
+	_, err := pipe.Exec()
+	return errors.Wrap(err, "failed to store sitemaps")
+}
+
+// GetSitemap returns a rendered sitemap, and whether it exists
+func GetSitemap(name string) ([]byte, bool) {
+	result, err := client.Get(sitemapKey(name)).Bytes()
+	if err != nil {
+		return nil, false
+	}
+
+	return result, true
+}
+
+func feedKey(name string) string {
+	return "feed:" + name
+}
+
+// StoreFeed caches a rendered feed for ttl
+func StoreFeed(name string, feed []byte, ttl time.Duration) {
+	if err := client.Set(feedKey(name), feed, ttl).Err(); err != nil {
+		log.Err(err).Msg("failed to store feed")
+	}
+}
+
+// GetFeed returns a cached feed, and whether it was cached
+func GetFeed(name string) ([]byte, bool) {
+	result, err := client.Get(feedKey(name)).Bytes()
+	if err != nil {
+		return nil, false
+	}
+
+	return result, true
+}
+
+// DownloadToken authorizes a single download of a version file
+type DownloadToken struct {
+	VersionID string `json:"version_id"`
+	Target    string `json:"target,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	IP        string `json:"ip,omitempty"`
+}
+
+func downloadTokenKey(token string) string {
+	return "download:token:" + token
+}
+
+// CreateDownloadToken stores a download token valid for downloads.tokens.ttl, and returns it along with its expiry
+func CreateDownloadToken(download DownloadToken) (string, time.Time, error) {
+	random := make([]byte, 24)
+	if _, err := rand.Read(random); err != nil {
+		return "", time.Time{}, errors.Wrap(err, "failed to generate download token")
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(random)
+
+	marshaled, err := json.Marshal(download)
+	if err != nil {
+		return "", time.Time{}, errors.Wrap(err, "failed to marshal download token")
+	}
+
+	ttl := viper.GetDuration("downloads.tokens.ttl")
+	if err := client.Set(downloadTokenKey(token), string(marshaled), ttl).Err(); err != nil {
+		return "", time.Time{}, errors.Wrap(err, "failed to store download token")
+	}
+
+	return token, time.Now().Add(ttl), nil
+}
+
+// GetDownloadToken returns the download authorized by a token, or nil if it is unknown or expired
+func GetDownloadToken(token string) (*DownloadToken, error) {
+	result, err := client.Get(downloadTokenKey(token)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to get download token")
+	}
+
+	var download DownloadToken
+	if err := json.Unmarshal([]byte(result), &download); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal download token")
+	}
+
+	return &download, nil
+}
+
+// ConsumeDownloadToken invalidates a token, and returns whether it was still valid.
+// Only one of concurrent uses of the same token succeeds.
+func ConsumeDownloadToken(token string) bool {
+	return client.Del(downloadTokenKey(token)).Val() == 1
+}
+
+func jobLockKey(key string) string {
+	return "jobs:lock:" + key
+}
+
+// AcquireJobLock takes the lock of a job for at most ttl, and returns the token releasing it.
+// An empty token is returned if another worker holds the lock.
+func AcquireJobLock(key string, ttl time.Duration) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", errors.Wrap(err, "failed to generate job lock token")
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(random)
+
+	acquired, err := client.SetNX(jobLockKey(key), token, ttl).Result()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to acquire job lock")
+	}
+
+	if !acquired {
+		return "", nil
+	}
+
+	return token, nil
+}
+
+var releaseJobLockScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`)
+
+// ReleaseJobLock releases the lock of a job, unless it expired and was taken by another worker since
+func ReleaseJobLock(key string, token string) error {
+	return errors.Wrap(releaseJobLockScript.Run(client, []string{jobLockKey(key)}, token).Err(), "failed to release job lock")
+}
+
+// Lifecycle events relayed to GraphQL subscriptions
+const (
+	EventModUpdated      = "mod:updated"
+	EventVersionCreated  = "version:created"
+	EventVersionApproved = "version:approved"
+
+	EventNotificationCreated = "notification:created"
+	EventCommentCreated      = "comment:created"
+)
+
+const lifecycleEventPrefix = "events:"
+
+// lifecycleHub fans out lifecycle events received by a single redis subscription to every local subscriber
+type lifecycleHub struct {
+	subscribers map[string]map[chan string]struct{}
+	start       sync.Once
+	lock        sync.Mutex
+}
+
+var lifecycle = &lifecycleHub{
+	subscribers: make(map[string]map[chan string]struct{}),
+}
+
+func (h *lifecycleHub) run() {
+	pubsub := client.PSubscribe(lifecycleEventPrefix + "*")
+
+	go func() {
+		for message := range pubsub.Channel() {
+			event := strings.TrimPrefix(message.Channel, lifecycleEventPrefix)
+
+			h.lock.Lock()
+			for subscriber := range h.subscribers[event] {
+				select {
+				case subscriber <- message.Payload:
+				default:
+					// Slow subscribers miss events instead of blocking everyone else
+				}
+			}
+			h.lock.Unlock()
+		}
+	}()
+}
+
+func (h *lifecycleHub) subscribe(ctx context.Context, event string) <-chan string {
+	h.start.Do(h.run)
+
+	subscriber := make(chan string, 16)
+
+	h.lock.Lock()
+	if h.subscribers[event] == nil {
+		h.subscribers[event] = make(map[chan string]struct{})
+	}
+	h.subscribers[event][subscriber] = struct{}{}
+	h.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		h.lock.Lock()
+		delete(h.subscribers[event], subscriber)
+		h.lock.Unlock()
+
+		close(subscriber)
+	}()
+
+	return subscriber
+}
+
+func publishLifecycleEvent(event string, data interface{}) {
+	marshaled, err := json.Marshal(data)
+	if err != nil {
+		log.Err(err).Str("event", event).Msg("failed to marshal lifecycle event")
+		return
+	}
+
+	if err := client.Publish(lifecycleEventPrefix+event, string(marshaled)).Err(); err != nil {
+		log.Err(err).Str("event", event).Msg("failed to publish lifecycle event")
+	}
+}
+
+// PublishModEvent notifies the subscribers of every instance of a mod lifecycle event
+func PublishModEvent(event string, mod *generated.Mod) {
+	publishLifecycleEvent(event, mod)
+}
+
+// PublishVersionEvent notifies the subscribers of every instance of a version lifecycle event
+func PublishVersionEvent(event string, version *generated.Version) {
+	publishLifecycleEvent(event, version)
+}
+
+// SubscribeModEvents streams the mods of a lifecycle event until the context is done
+func SubscribeModEvents(ctx context.Context, event string) <-chan *generated.Mod {
+	payloads := lifecycle.subscribe(ctx, event)
+	out := make(chan *generated.Mod)
+
+	go func() {
+		defer close(out)
+
+		for payload := range payloads {
+			mod := &generated.Mod{}
+			if err := json.Unmarshal([]byte(payload), mod); err != nil {
+				log.Err(err).Str("event", event).Msg("failed to unmarshal lifecycle event")
+				continue
+			}
+
+			select {
+			case out <- mod:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// SubscribeVersionEvents streams the versions of a lifecycle event until the context is done
+func SubscribeVersionEvents(ctx context.Context, event string) <-chan *generated.Version {
+	payloads := lifecycle.subscribe(ctx, event)
+	out := make(chan *generated.Version)
+
+	go func() {
+		defer close(out)
+
+		for payload := range payloads {
+			version := &generated.Version{}
+			if err := json.Unmarshal([]byte(payload), version); err != nil {
+				log.Err(err).Str("event", event).Msg("failed to unmarshal lifecycle event")
+				continue
+			}
+
+			select {
+			case out <- version:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// PublishNotification delivers a notification to the subscribers of every instance
+func PublishNotification(notification *generated.Notification) {
+	publishLifecycleEvent(EventNotificationCreated, notification)
+}
+
+// SubscribeNotifications streams the notifications created for a user until the context is done
+func SubscribeNotifications(ctx context.Context, userID string) <-chan *generated.Notification {
+	payloads := lifecycle.subscribe(ctx, EventNotificationCreated)
+	out := make(chan *generated.Notification)
+
+	go func() {
+		defer close(out)
+
+		for payload := range payloads {
+			notification := &generated.Notification{}
+			if err := json.Unmarshal([]byte(payload), notification); err != nil {
+				log.Err(err).Msg("failed to unmarshal notification")
+				continue
+			}
+
+			if notification.UserID != userID {
+				continue
+			}
+
+			select {
+			case out <- notification:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// PublishComment delivers a new comment to the subscribers of every instance
+func PublishComment(comment *generated.Comment) {
+	publishLifecycleEvent(EventCommentCreated, comment)
+}
+
+// SubscribeComments streams the comments posted on a mod until the context is done
+func SubscribeComments(ctx context.Context, modID string) <-chan *generated.Comment {
+	payloads := lifecycle.subscribe(ctx, EventCommentCreated)
+	out := make(chan *generated.Comment)
+
+	go func() {
+		defer close(out)
+
+		for payload := range payloads {
+			comment := &generated.Comment{}
+			if err := json.Unmarshal([]byte(payload), comment); err != nil {
+				log.Err(err).Msg("failed to unmarshal comment")
+				continue
+			}
+
+			if comment.ModID != modID {
+				continue
+			}
+
+			select {
+			case out <- comment:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// FieldUsage is the amount of sampled operations of a client which used a schema coordinate
+type FieldUsage struct {
+	Coordinate string
+	Client     string
+	Requests   int64
+	LastSeen   time.Time // Day of the last sampled operation
+}
+
+const fieldUsagePrefix = "field_usage:"
+
+func fieldUsageKey(day time.Time) string {
+	return fieldUsagePrefix + day.Format("2006-01-02")
+}
+
+// RecordFieldUsage counts an operation of the client using the schema coordinates.
+// Counts are kept per day for graphql.usage.retention_days.
+func RecordFieldUsage(clientName string, coordinates []string) {
+	if len(coordinates) == 0 {
+		return
+	}
+
+	clientName = strings.ReplaceAll(clientName, "|", "_")
+
+	key := fieldUsageKey(time.Now().UTC())
+
+	pipe := client.Pipeline()
+	for _, coordinate := range coordinates {
+		pipe.HIncrBy(key, coordinate+"|"+clientName, 1)
+	}
+	pipe.Expire(key, time.Hour*24*time.Duration(viper.GetInt("graphql.usage.retention_days")+1))
+
+	if _, err := pipe.Exec(); err != nil {
+		log.Err(err).Msg("failed to record field usage")
+	}
+}
+
+// GetFieldUsage sums the usage of the schema coordinates by client over the last days
+func GetFieldUsage(days int) ([]FieldUsage, error) {
+	usage := make(map[string]*FieldUsage)
+
+	today := time.Now().UTC().Truncate(time.Hour * 24)
+	for i := 0; i < days; i++ {
+		day := today.AddDate(0, 0, -i)
+
+		values, err := client.HGetAll(fieldUsageKey(day)).Result()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get field usage")
+		}
+
+		for field, value := range values {
+			count, _ := strconv.ParseInt(value, 10, 64)
+
+			entry, ok := usage[field]
+			if !ok {
+				coordinate, clientName, _ := strings.Cut(field, "|")
+
+				// Days are iterated from the most recent one
+				entry = &FieldUsage{
+					Coordinate: coordinate,
+					Client:     clientName,
+					LastSeen:   day,
+				}
+				usage[field] = entry
+			}
+
+			entry.Requests += count
+		}
+	}
+
+	result := make([]FieldUsage, 0, len(usage))
+	for _, entry := range usage {
+		result = append(result, *entry)
+	}
+
+	return result, nil
+}
+
+// PersistedQueryCache stores automatic persisted queries, shared between every instance of the API.
+// Queries are kept for as long as they keep being used.
+type PersistedQueryCache struct {
+	TTL time.Duration
+}
+
+func persistedQueryKey(hash string) string {
+	return fmt.Sprintf("apq:%s", hash)
+}
+
+func (p PersistedQueryCache) Get(_ context.Context, hash string) (interface{}, bool) {
+	query, err := client.Get(persistedQueryKey(hash)).Result()
+	if err != nil {
+		return nil, false
+	}
+
+	client.Expire(persistedQueryKey(hash), p.TTL)
+
+	return query, true
+}
+
+func (p PersistedQueryCache) Add(_ context.Context, hash string, value interface{}) {
+	if query, ok := value.(string); ok {
+		client.Set(persistedQueryKey(hash), query, p.TTL)
+	}
+}
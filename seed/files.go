@@ -0,0 +1,89 @@
+package seed
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/storage"
+)
+
+// stubArchive builds a multi-target archive of a version, holding nothing but the plugin descriptor of every target
+func stubArchive(mod *postgres.Mod, version *postgres.Version) ([]byte, error) {
+	descriptor, err := json.MarshalIndent(map[string]interface{}{
+		"FileVersion":  3,
+		"Version":      *version.VersionMajor,
+		"VersionName":  version.Version,
+		"SemVersion":   version.Version,
+		"FriendlyName": mod.Name,
+		"Description":  mod.ShortDescription,
+		"CreatedBy":    "seed",
+	}, "", "\t")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode plugin descriptor")
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	for _, target := range version.Targets {
+		file, err := archive.Create(target.TargetName + "/" + mod.ModReference + ".uplugin")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to add plugin descriptor")
+		}
+
+		if _, err := file.Write(descriptor); err != nil {
+			return nil, errors.Wrap(err, "failed to write plugin descriptor")
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close archive")
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// uploadVersionFiles stores a stub archive of the version and of each of its targets, and points the version at them
+func uploadVersionFiles(ctx context.Context, mod *postgres.Mod, version *postgres.Version) error {
+	data, err := stubArchive(mod, version)
+	if err != nil {
+		return err
+	}
+
+	success, key := storage.UploadModFile(ctx, mod.ID, mod.Name, version.ID, bytes.NewReader(data))
+	if !success {
+		return errors.New("failed to upload version file of " + mod.ModReference + " " + version.Version)
+	}
+
+	hash := sha256.Sum256(data)
+	hashString := hex.EncodeToString(hash[:])
+	size := int64(len(data))
+
+	version.Key = key
+	version.Hash = &hashString
+	version.Size = &size
+
+	for i, target := range version.Targets {
+		success, key, hash, size := storage.SeparateModTarget(ctx, bytes.NewReader(data), int64(len(data)), mod.ID, mod.Name, version.Version, target.TargetName)
+		if !success {
+			return errors.New("failed to upload " + target.TargetName + " file of " + mod.ModReference + " " + version.Version)
+		}
+
+		if storage.IsBlob(key) {
+			postgres.AcquireBlob(ctx, hash, key, size)
+		}
+
+		version.Targets[i].Key = key
+		version.Targets[i].Hash = hash
+		version.Targets[i].Size = size
+	}
+
+	return nil
+}
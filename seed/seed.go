@@ -0,0 +1,464 @@
+// Package seed fills a fresh database with fake users, mods and versions,
+// so that the API can be run locally with meaningful data.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/satisfactorymodding/smr-api/auth"
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/util"
+	"github.com/satisfactorymodding/smr-api/validation"
+)
+
+type Options struct {
+	Users          int
+	Mods           int
+	VersionsPerMod int
+	// RandomSeed makes the generated content reproducible, apart from the IDs
+	RandomSeed int64
+	// WithFiles uploads stub archives of every version and target to the storage
+	WithFiles bool
+	// Force seeds a database which already contains mods
+	Force bool
+}
+
+// Result describes the seeded content
+type Result struct {
+	// AdminToken is a session token of the seeded admin, to be sent in the Authorization header
+	AdminToken string
+	Users      int
+	Mods       int
+	Versions   int
+}
+
+const smlReference = "SML"
+
+const adminEmail = "seed_admin@example.com"
+
+var smlVersions = []string{"3.4.1", "3.5.0", "3.5.1", "3.6.0", "3.6.1"}
+
+var stabilities = []string{"release", "release", "release", "beta", "alpha"}
+
+var tagNames = []string{
+	"Tweaks", "Quality of Life", "Buildings", "Logistics", "Power",
+	"Vehicles", "Cheats", "Cosmetic", "Library", "Multiplayer",
+}
+
+var firstNames = []string{
+	"Ada", "Bram", "Cleo", "Dario", "Edda", "Finn", "Greta", "Hugo", "Ines", "Jonas",
+	"Kira", "Lars", "Mila", "Nico", "Olga", "Pavel", "Quinn", "Rosa", "Sven", "Tara",
+}
+
+var handles = []string{
+	"Builder", "Pioneer", "Engineer", "Miner", "Planner", "Hauler", "Tinkerer", "Scout",
+}
+
+var modAdjectives = []string{
+	"Better", "Compact", "Smart", "Infinite", "Modular", "Advanced", "Simple", "Micro",
+	"Rapid", "Heavy", "Silent", "Remote", "Quantum", "Portable", "Stackable", "Efficient",
+}
+
+var modNouns = []string{
+	"Belts", "Storage", "Foundations", "Pipes", "Lights", "Elevators", "Signs", "Drones",
+	"Trains", "Walls", "Generators", "Splitters", "Conveyors", "Miners", "Hypertubes", "Pumps",
+}
+
+var changelogLines = []string{
+	"Fixed a crash when loading a save",
+	"Added a configuration option to disable the new recipes",
+	"Improved performance in large factories",
+	"Updated for the latest game version",
+	"Added new buildings",
+	"Fixed multiplayer desync",
+	"Rebalanced recipe costs",
+	"Translated into German and French",
+}
+
+type seeder struct {
+	rand    *rand.Rand
+	options Options
+	result  *Result
+
+	tags  []postgres.Tag
+	users []postgres.User
+	// mods are the seeded mods which others can depend on
+	mods []postgres.Mod
+}
+
+// Run seeds the database, and the storage if options.WithFiles is set.
+// It refuses to seed a database which already contains mods, unless options.Force is set.
+func Run(ctx context.Context, options Options) (*Result, error) {
+	if !options.Force {
+		var count int64
+		postgres.DBCtx(ctx).Model(postgres.Mod{}).Count(&count)
+		if count > 0 {
+			return nil, errors.New("the database already contains mods, seed it again with force")
+		}
+	}
+
+	s := &seeder{
+		rand:    rand.New(rand.NewSource(options.RandomSeed)), //nolint:gosec
+		options: options,
+		result:  &Result{},
+	}
+
+	err := postgres.Tx(ctx, func(ctx context.Context) error {
+		if err := s.seedTags(ctx); err != nil {
+			return err
+		}
+
+		if err := s.seedUsers(ctx); err != nil {
+			return err
+		}
+
+		if err := s.seedSML(ctx); err != nil {
+			return err
+		}
+
+		for i := 0; i < options.Mods; i++ {
+			if err := s.seedMod(ctx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.result, nil
+}
+
+func (s *seeder) pick(values []string) string {
+	return values[s.rand.Intn(len(values))]
+}
+
+// pastTime returns a time in the provided amount of past days
+func (s *seeder) pastTime(days int) time.Time {
+	return time.Now().Add(-time.Duration(s.rand.Int63n(int64(days) * int64(time.Hour) * 24)))
+}
+
+func (s *seeder) seedTags(ctx context.Context) error {
+	for _, name := range tagNames {
+		var tag postgres.Tag
+		postgres.DBCtx(ctx).First(&tag, "name = ?", name)
+
+		if tag.ID == "" {
+			tag = postgres.Tag{
+				SMRModel: postgres.SMRModel{
+					ID: util.GenerateUniqueID(),
+				},
+				Name:        name,
+				Description: "Mods in the " + name + " category",
+			}
+
+			if err := postgres.DBCtx(ctx).Create(&tag).Error; err != nil {
+				return errors.Wrap(err, "failed to create tag")
+			}
+		}
+
+		s.tags = append(s.tags, tag)
+	}
+
+	return nil
+}
+
+func (s *seeder) seedUsers(ctx context.Context) error {
+	// The admin of an earlier seed is reused, only its session is renewed
+	var admin postgres.User
+	postgres.DBCtx(ctx).First(&admin, "email = ?", adminEmail)
+
+	if admin.ID == "" {
+		created, err := s.createUser(ctx, "seed_admin", adminEmail)
+		if err != nil {
+			return err
+		}
+
+		err = postgres.DBCtx(ctx).Create(&postgres.UserGroup{
+			UserID:  created.ID,
+			GroupID: auth.GroupAdmin.ID,
+		}).Error
+		if err != nil {
+			return errors.Wrap(err, "failed to add admin group")
+		}
+
+		admin = *created
+	} else {
+		s.users = append(s.users, admin)
+	}
+
+	session := postgres.UserSession{
+		SMRModel: postgres.SMRModel{
+			ID: util.GenerateUniqueID(),
+		},
+		UserID:    admin.ID,
+		Token:     util.GenerateUserToken(),
+		UserAgent: "seed",
+	}
+
+	if err := postgres.DBCtx(ctx).Omit("User").Create(&session).Error; err != nil {
+		return errors.Wrap(err, "failed to create admin session")
+	}
+
+	s.result.AdminToken = session.Token
+
+	for i := 1; i < s.options.Users; i++ {
+		username := fmt.Sprintf("%s%s%d", s.pick(firstNames), s.pick(handles), i)
+		if _, err := s.createUser(ctx, username, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createUser creates a user, with an email unique to it if none is provided
+func (s *seeder) createUser(ctx context.Context, username string, email string) (*postgres.User, error) {
+	id := util.GenerateUniqueID()
+	if email == "" {
+		email = strings.ToLower(username) + "." + strings.ToLower(id) + "@example.com"
+	}
+
+	user := postgres.User{
+		SMRModel: postgres.SMRModel{
+			ID: id,
+			SMRDates: postgres.SMRDates{
+				CreatedAt: s.pastTime(730),
+			},
+		},
+		Email:      email,
+		Username:   username,
+		JoinedFrom: "seed",
+	}
+
+	if err := postgres.DBCtx(ctx).Create(&user).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to create user")
+	}
+
+	s.users = append(s.users, user)
+	s.result.Users++
+
+	return &user, nil
+}
+
+// seedSML creates the mod loader every other mod depends on, unless it exists already
+func (s *seeder) seedSML(ctx context.Context) error {
+	var existing postgres.Mod
+	postgres.DBCtx(ctx).First(&existing, "mod_reference = ?", smlReference)
+	if existing.ID != "" {
+		return nil
+	}
+
+	mod := s.newMod("Satisfactory Mod Loader", smlReference)
+	mod.ShortDescription = "Mod loading and compatibility API for Satisfactory"
+	mod.Approved = true
+
+	return s.createMod(ctx, mod, s.users[0], smlVersions, nil)
+}
+
+func (s *seeder) seedMod(ctx context.Context) error {
+	name := s.pick(modAdjectives) + " " + s.pick(modNouns)
+	reference := strings.ReplaceAll(name, " ", "")
+
+	// Names are reused once every combination is taken
+	var count int64
+	postgres.DBCtx(ctx).Model(postgres.Mod{}).Where("mod_reference LIKE ?", reference+"%").Count(&count)
+	if count > 0 {
+		name = fmt.Sprintf("%s %d", name, count+1)
+		reference = fmt.Sprintf("%s%d", reference, count+1)
+	}
+
+	mod := s.newMod(name, reference)
+	// Some mods wait for approval, to fill the moderation queue
+	mod.Approved = s.rand.Intn(10) > 0
+
+	versions := make([]string, s.options.VersionsPerMod)
+	major, minor, patch := 1, 0, 0
+	for i := range versions {
+		versions[i] = fmt.Sprintf("%d.%d.%d", major, minor, patch)
+
+		switch s.rand.Intn(10) {
+		case 0:
+			major, minor, patch = major+1, 0, 0
+		case 1, 2, 3:
+			minor, patch = minor+1, 0
+		default:
+			patch++
+		}
+	}
+
+	// Some mods depend on a mod seeded before them, besides the mod loader
+	var dependency *postgres.Mod
+	if len(s.mods) > 1 && s.rand.Intn(3) == 0 {
+		dependency = &s.mods[1+s.rand.Intn(len(s.mods)-1)]
+	}
+
+	return s.createMod(ctx, mod, s.users[s.rand.Intn(len(s.users))], versions, dependency)
+}
+
+func (s *seeder) newMod(name string, reference string) *postgres.Mod {
+	description := fmt.Sprintf("%s for your factory.", name)
+
+	return &postgres.Mod{
+		SMRModel: postgres.SMRModel{
+			ID: util.GenerateUniqueID(),
+			SMRDates: postgres.SMRDates{
+				CreatedAt: s.pastTime(730),
+			},
+		},
+		Name:             name,
+		ModReference:     reference,
+		ShortDescription: description,
+		FullDescription: fmt.Sprintf("# %s\n\n%s\n\n## Features\n\n- %s\n- %s\n",
+			name, description, s.pick(changelogLines), s.pick(changelogLines)),
+		SourceURL:  "https://github.com/example/" + reference,
+		Downloads:  uint(s.rand.Intn(100000)),
+		Popularity: uint(s.rand.Intn(1000)),
+		Hotness:    uint(s.rand.Intn(100)),
+		Views:      uint(s.rand.Intn(500000)),
+	}
+}
+
+func (s *seeder) createMod(ctx context.Context, mod *postgres.Mod, owner postgres.User, versions []string, dependency *postgres.Mod) error {
+	mod.CreatorID = owner.ID
+
+	for _, i := range s.rand.Perm(len(s.tags))[:1+s.rand.Intn(3)] {
+		mod.Tags = append(mod.Tags, s.tags[i])
+	}
+
+	if err := postgres.DBCtx(ctx).Omit("Users", "Versions").Create(mod).Error; err != nil {
+		return errors.Wrap(err, "failed to create mod "+mod.ModReference)
+	}
+
+	err := postgres.DBCtx(ctx).Create(&postgres.UserMod{
+		UserID: owner.ID,
+		ModID:  mod.ID,
+		Role:   "owner",
+	}).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to add mod owner")
+	}
+
+	// Versions are spread between the creation of the mod and now
+	interval := time.Since(mod.CreatedAt) / time.Duration(len(versions)+1)
+	createdAt := mod.CreatedAt
+
+	var lastVersionDate *time.Time
+	for _, name := range versions {
+		createdAt = createdAt.Add(interval)
+
+		version, err := s.createVersion(ctx, mod, name, createdAt, dependency)
+		if err != nil {
+			return err
+		}
+
+		if version.Approved {
+			date := version.CreatedAt
+			lastVersionDate = &date
+		}
+	}
+
+	if lastVersionDate != nil {
+		postgres.DBCtx(ctx).Model(mod).UpdateColumn("last_version_date", lastVersionDate)
+	}
+
+	s.mods = append(s.mods, *mod)
+	s.result.Mods++
+
+	return nil
+}
+
+func (s *seeder) createVersion(ctx context.Context, mod *postgres.Mod, name string, createdAt time.Time, dependency *postgres.Mod) (*postgres.Version, error) {
+	smlVersion := "^" + smlVersions[s.rand.Intn(len(smlVersions))]
+	if mod.ModReference == smlReference {
+		smlVersion = ""
+	}
+
+	targets := validation.AllowedTargets
+	// Some mods only run on clients
+	if s.rand.Intn(4) == 0 {
+		targets = targets[:1]
+	}
+
+	var major, minor, patch int
+	_, _ = fmt.Sscanf(name, "%d.%d.%d", &major, &minor, &patch)
+
+	reference := mod.ModReference
+	version := &postgres.Version{
+		SMRModel: postgres.SMRModel{
+			ID: util.GenerateUniqueID(),
+			SMRDates: postgres.SMRDates{
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+			},
+		},
+		ModID:        mod.ID,
+		ModReference: &reference,
+		Version:      name,
+		VersionMajor: &major,
+		VersionMinor: &minor,
+		VersionPatch: &patch,
+		SMLVersion:   smlVersion,
+		Changelog:    "- " + s.pick(changelogLines) + "\n- " + s.pick(changelogLines),
+		Stability:    s.pick(stabilities),
+		Downloads:    uint(s.rand.Intn(10000)),
+		Approved:     mod.Approved,
+	}
+
+	for _, target := range targets {
+		version.Targets = append(version.Targets, postgres.VersionTarget{
+			VersionID:  version.ID,
+			TargetName: target,
+		})
+	}
+
+	if s.options.WithFiles {
+		if err := uploadVersionFiles(ctx, mod, version); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := postgres.DBCtx(ctx).Create(version).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to create version "+mod.ModReference+" "+name)
+	}
+
+	var dependencies []postgres.VersionDependency
+	if smlVersion != "" {
+		dependencies = append(dependencies, postgres.VersionDependency{
+			VersionID: version.ID,
+			ModID:     smlReference,
+			Condition: smlVersion,
+		})
+	}
+
+	if dependency != nil {
+		dependencies = append(dependencies, postgres.VersionDependency{
+			VersionID: version.ID,
+			ModID:     dependency.ModReference,
+			Condition: ">=1.0.0",
+			Optional:  s.rand.Intn(2) == 0,
+		})
+	}
+
+	for _, versionDependency := range dependencies {
+		versionDependency := versionDependency
+		if err := postgres.DBCtx(ctx).Create(&versionDependency).Error; err != nil {
+			return nil, errors.Wrap(err, "failed to create version dependency")
+		}
+	}
+
+	log.Debug().Str("mod", mod.ModReference).Str("version", name).Msg("seeded version")
+
+	s.result.Versions++
+
+	return version, nil
+}
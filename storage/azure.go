@@ -0,0 +1,257 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// Azure stores objects in an Azure Blob Storage container, authenticated with the account name as the key
+// and the account key as the secret. The bucket is the container name.
+type Azure struct {
+	BaseURL   string
+	Client    *azblob.Client
+	Container *container.Client
+	Config    Config
+}
+
+const azureCopyPollInterval = time.Second
+
+func init() {
+	RegisterDriver("azure", func(ctx context.Context, config Config) Storage {
+		if azure := initializeAzure(ctx, config); azure != nil {
+			return azure
+		}
+		return nil
+	})
+}
+
+func initializeAzure(ctx context.Context, config Config) *Azure {
+	credential, err := azblob.NewSharedKeyCredential(config.Key, config.Secret)
+	if err != nil {
+		log.Err(err).Msg("failed to create Azure credentials")
+		return nil
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net/", config.Key)
+	}
+
+	client, err := azblob.NewClientWithSharedKeyCredential(endpoint, credential, nil)
+	if err != nil {
+		log.Err(err).Msg("failed to create Azure client")
+		return nil
+	}
+
+	return &Azure{
+		BaseURL:   config.BaseURL,
+		Client:    client,
+		Container: client.ServiceClient().NewContainerClient(config.Bucket),
+		Config:    config,
+	}
+}
+
+// azureBlockID names the block of a part. Every block of a blob must have an ID of the same length,
+// and the upload ID keeps blocks left over from an earlier upload of the same key out of the new one.
+func azureBlockID(uploadID string, part int64) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s-%05d", uploadID, part)))
+}
+
+func (azure *Azure) Get(key string) (io.ReadCloser, error) {
+	cleanedKey := strings.TrimPrefix(key, "/")
+
+	response, err := azure.Client.DownloadStream(context.Background(), azure.Config.Bucket, cleanedKey, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object")
+	}
+
+	return response.Body, nil
+}
+
+func (azure *Azure) GetRange(key string, offset int64, length int64) (io.ReadCloser, error) {
+	cleanedKey := strings.TrimPrefix(key, "/")
+
+	response, err := azure.Client.DownloadStream(context.Background(), azure.Config.Bucket, cleanedKey, &azblob.DownloadStreamOptions{
+		Range: azblob.HTTPRange{Offset: offset, Count: length},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object range")
+	}
+
+	return response.Body, nil
+}
+
+func (azure *Azure) Put(ctx context.Context, key string, body io.ReadSeeker) (string, error) {
+	cleanedKey := strings.TrimPrefix(key, "/")
+
+	_, err := azure.Client.UploadStream(ctx, azure.Config.Bucket, cleanedKey, body, nil)
+	if err != nil {
+		return cleanedKey, errors.Wrap(err, "failed to upload file")
+	}
+
+	return key, nil
+}
+
+func (azure *Azure) SignGet(key string) (string, error) {
+	// Public Container
+	cleanedKey := strings.TrimPrefix(key, "/")
+	return fmt.Sprintf(viper.GetString("storage.keypath"), azure.BaseURL, azure.Config.Bucket, cleanedKey), nil
+}
+
+func (azure *Azure) SignPut(key string) (string, error) {
+	// Unsupported at the moment
+	return "", errors.New("Unsupported")
+}
+
+func (azure *Azure) StartMultipartUpload(key string) error {
+	// Blocks are staged against the blob itself, so there is nothing to create until the upload is committed
+	redis.StoreMultipartUploadID(strings.TrimPrefix(key, "/"), util.GenerateUniqueID())
+
+	return nil
+}
+
+func (azure *Azure) UploadPart(key string, part int64, data io.ReadSeeker) error {
+	cleanedKey := strings.TrimPrefix(key, "/")
+	id := redis.GetMultipartUploadID(cleanedKey)
+	if id == "" {
+		return errors.New("upload not found or expired")
+	}
+
+	blockID := azureBlockID(id, part)
+
+	_, err := azure.Container.NewBlockBlobClient(cleanedKey).StageBlock(context.Background(), blockID, streaming.NopCloser(data), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to upload part")
+	}
+
+	redis.StoreMultipartCompletedPart(cleanedKey, blockID, int(part))
+
+	return nil
+}
+
+func (azure *Azure) SignUploadPart(key string, part int64, size int64, expiry time.Duration) (string, error) {
+	// A shared access signature cannot restrict the size of the block
+	return "", errors.New("Unsupported")
+}
+
+func (azure *Azure) CompleteMultipartUpload(key string) error {
+	cleanedKey := strings.TrimPrefix(key, "/")
+	parts := redis.GetAndClearMultipartCompletedParts(cleanedKey)
+
+	completedParts, err := sortCompletedParts(parts)
+	if err != nil {
+		return err
+	}
+
+	blockIDs := make([]string, len(completedParts))
+	for i, part := range completedParts {
+		blockIDs[i] = *part.ETag
+	}
+
+	_, err = azure.Container.NewBlockBlobClient(cleanedKey).CommitBlockList(context.Background(), blockIDs, nil)
+
+	return errors.Wrap(err, "failed to complete multipart upload")
+}
+
+func (azure *Azure) AbortMultipartUpload(key string, uploadID string) error {
+	// Uncommitted blocks are discarded by Azure after a week
+	return nil
+}
+
+func (azure *Azure) Rename(from string, to string) error {
+	cleanedKey := strings.TrimPrefix(to, "/")
+	// The source is provided in its encoded form, as is expected by S3 compatible backends
+	source := azure.Container.NewBlobClient(strings.TrimPrefix(DecodeName(from), "/")).URL()
+	destination := azure.Container.NewBlobClient(cleanedKey)
+
+	response, err := destination.StartCopyFromURL(context.Background(), source, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to copy object")
+	}
+
+	status := response.CopyStatus
+	for status != nil && *status == blob.CopyStatusTypePending {
+		time.Sleep(azureCopyPollInterval)
+
+		properties, err := destination.GetProperties(context.Background(), nil)
+		if err != nil {
+			return errors.Wrap(err, "failed to get copy status")
+		}
+
+		status = properties.CopyStatus
+	}
+
+	if status != nil && *status != blob.CopyStatusTypeSuccess {
+		return errors.Errorf("failed to copy object: copy %s", *status)
+	}
+
+	return nil
+}
+
+func (azure *Azure) Delete(key string) error {
+	cleanedKey := strings.TrimPrefix(key, "/")
+
+	_, err := azure.Client.DeleteBlob(context.Background(), azure.Config.Bucket, cleanedKey, &azblob.DeleteBlobOptions{
+		DeleteSnapshots: to.Ptr(azblob.DeleteSnapshotsOptionTypeInclude),
+	})
+	if err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return errors.Wrap(err, "failed to delete object")
+	}
+
+	return nil
+}
+
+func (azure *Azure) Meta(key string) (*ObjectMeta, error) {
+	cleanedKey := strings.TrimPrefix(key, "/")
+
+	properties, err := azure.Container.NewBlobClient(cleanedKey).GetProperties(context.Background(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object meta")
+	}
+
+	return &ObjectMeta{
+		ContentLength: properties.ContentLength,
+		ContentType:   properties.ContentType,
+	}, nil
+}
+
+func (azure *Azure) List(prefix string) ([]Object, error) {
+	out := make([]Object, 0)
+
+	pager := azure.Client.NewListBlobsFlatPager(azure.Config.Bucket, &azblob.ListBlobsFlatOptions{
+		Prefix: to.Ptr(prefix),
+	})
+
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list objects")
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			out = append(out, Object{
+				Key:          item.Name,
+				LastModified: item.Properties.LastModified,
+			})
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAzureBlockIDsShareLength(t *testing.T) {
+	first := azureBlockID("upload", 1)
+	last := azureBlockID("upload", 10000)
+
+	if len(first) != len(last) {
+		t.Errorf("expected block ids of the same length, got %d and %d", len(first), len(last))
+	}
+
+	if first == azureBlockID("other", 1) {
+		t.Error("expected the upload id to be part of the block id")
+	}
+}
+
+func TestAzureGetReadsBlobFromContainer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/container/mods/a/b.smod" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Length", "4")
+		_, _ = w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	driver, ok := drivers["azure"]
+	if !ok {
+		t.Fatal("expected the azure driver to be registered")
+	}
+
+	azure := driver(context.Background(), Config{
+		Type:     "azure",
+		Bucket:   "container",
+		Key:      "account",
+		Secret:   base64.StdEncoding.EncodeToString([]byte("secret")),
+		Endpoint: server.URL,
+	})
+	if azure == nil {
+		t.Fatal("expected the azure driver to initialize")
+	}
+
+	body, err := azure.Get("/mods/a/b.smod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "data" {
+		t.Errorf("expected the blob contents, got %q", data)
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -25,6 +25,15 @@ type B2 struct {
 	Config    Config
 }
 
+func init() {
+	RegisterDriver("b2", func(ctx context.Context, config Config) Storage {
+		if b2o := initializeB2(ctx, config); b2o != nil {
+			return b2o
+		}
+		return nil
+	})
+}
+
 func initializeB2(ctx context.Context, config Config) *B2 {
 	s3Config := &aws.Config{
 		Credentials:      credentials.NewStaticCredentials(config.Key, config.Secret, ""),
@@ -63,6 +72,21 @@ func (b2o *B2) Get(key string) (io.ReadCloser, error) {
 	return object.Body, nil
 }
 
+func (b2o *B2) GetRange(key string, offset int64, length int64) (io.ReadCloser, error) {
+	cleanedKey := strings.TrimPrefix(key, "/")
+
+	object, err := b2o.S3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(b2o.Config.Bucket),
+		Key:    aws.String(cleanedKey),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object range")
+	}
+
+	return object.Body, nil
+}
+
 func (b2o *B2) Put(ctx context.Context, key string, body io.ReadSeeker) (string, error) {
 	cleanedKey := strings.TrimPrefix(key, "/")
 	uploader := s3manager.NewUploader(b2o.S3Session)
@@ -108,6 +132,9 @@ func (b2o *B2) StartMultipartUpload(key string) error {
 func (b2o *B2) UploadPart(key string, part int64, data io.ReadSeeker) error {
 	cleanedKey := strings.TrimPrefix(key, "/")
 	id := redis.GetMultipartUploadID(cleanedKey)
+	if id == "" {
+		return errors.New("upload not found or expired")
+	}
 
 	response, err := b2o.S3Client.UploadPart(&s3.UploadPartInput{
 		Body:       data,
@@ -125,18 +152,46 @@ func (b2o *B2) UploadPart(key string, part int64, data io.ReadSeeker) error {
 	return nil
 }
 
+func (b2o *B2) SignUploadPart(key string, part int64, size int64, expiry time.Duration) (string, error) {
+	cleanedKey := strings.TrimPrefix(key, "/")
+	id := redis.GetMultipartUploadID(cleanedKey)
+	if id == "" {
+		return "", errors.New("upload not found or expired")
+	}
+
+	req, _ := b2o.S3Client.UploadPartRequest(&s3.UploadPartInput{
+		Bucket:     aws.String(b2o.Config.Bucket),
+		Key:        aws.String(cleanedKey),
+		PartNumber: aws.Int64(part),
+		UploadId:   aws.String(id),
+		// Signed along with the URL, so the storage rejects bodies of any other size
+		ContentLength: aws.Int64(size),
+	})
+
+	urlStr, err := req.Presign(expiry)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign url")
+	}
+
+	return urlStr, nil
+}
+
 func (b2o *B2) CompleteMultipartUpload(key string) error {
 	cleanedKey := strings.TrimPrefix(key, "/")
 	id := redis.GetMultipartUploadID(cleanedKey)
 	parts := redis.GetAndClearMultipartCompletedParts(cleanedKey)
-	completedParts := make([]*s3.CompletedPart, len(parts))
 
-	for part, etag := range parts {
-		partInt, _ := strconv.ParseInt(part, 10, 64)
-		completedParts[partInt-1] = &s3.CompletedPart{ETag: aws.String(etag), PartNumber: &partInt}
+	// Parts uploaded through pre-signed URLs are only known to the storage
+	if err := mergeStoredParts(b2o.S3Client, aws.String(b2o.Config.Bucket), cleanedKey, id, parts); err != nil {
+		return err
 	}
 
-	_, err := b2o.S3Client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+	completedParts, err := sortCompletedParts(parts)
+	if err != nil {
+		return err
+	}
+
+	_, err = b2o.S3Client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
 		Bucket:          aws.String(b2o.Config.Bucket),
 		Key:             aws.String(cleanedKey),
 		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completedParts},
@@ -146,6 +201,18 @@ func (b2o *B2) CompleteMultipartUpload(key string) error {
 	return errors.Wrap(err, "failed to complete multipart upload")
 }
 
+func (b2o *B2) AbortMultipartUpload(key string, uploadID string) error {
+	cleanedKey := strings.TrimPrefix(key, "/")
+
+	_, err := b2o.S3Client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(b2o.Config.Bucket),
+		Key:      aws.String(cleanedKey),
+		UploadId: aws.String(uploadID),
+	})
+
+	return errors.Wrap(err, "failed to abort multipart upload")
+}
+
 func (b2o *B2) Rename(from string, to string) error {
 	cleanedKey := strings.TrimPrefix(to, "/")
 
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+var coldStorage Storage
+
+// InitializeColdStorage sets up the cheaper storage stale version files are moved to, if storage.cold.enabled is set.
+// It is configured like a mirror under storage.cold.
+func InitializeColdStorage(ctx context.Context) {
+	if !viper.GetBool("storage.cold.enabled") {
+		return
+	}
+
+	var config MirrorConfig
+	if err := viper.UnmarshalKey("storage.cold", &config); err != nil {
+		log.Err(err).Msg("failed to parse cold storage")
+		return
+	}
+
+	driver, ok := drivers[config.Type]
+	if !ok {
+		log.Error().Msg("unknown cold storage type: " + config.Type)
+		return
+	}
+
+	coldStorage = driver(ctx, config.storageConfig())
+	if coldStorage == nil {
+		log.Error().Msg("failed to initialize cold storage")
+		return
+	}
+
+	log.Info().Msgf("Cold storage initialized: %s", config.Type)
+}
+
+// ColdStorageEnabled returns whether stale versions can be moved to cold storage
+func ColdStorageEnabled() bool {
+	return coldStorage != nil
+}
+
+// MoveToColdStorage moves a file from the primary storage to cold storage.
+// Keys are expected in their encoded form, as stored in the database.
+// Blobs may be shared with versions that are still downloaded, so they stay on the primary storage.
+func MoveToColdStorage(ctx context.Context, key string) error {
+	if storage == nil || coldStorage == nil {
+		return errors.New("cold storage not initialized")
+	}
+
+	if IsBlob(key) {
+		return nil
+	}
+
+	if err := copyObject(ctx, storage, coldStorage, key); err != nil {
+		return errors.Wrap(err, "failed to copy object to cold storage")
+	}
+
+	return errors.Wrap(storage.Delete(DecodeName(key)), "failed to delete object from storage")
+}
+
+// RestoreFromColdStorage moves a file from cold storage back to the primary storage
+func RestoreFromColdStorage(ctx context.Context, key string) error {
+	if storage == nil || coldStorage == nil {
+		return errors.New("cold storage not initialized")
+	}
+
+	// Already restored by a previous attempt
+	if _, err := storage.Meta(DecodeName(key)); err == nil {
+		_ = coldStorage.Delete(DecodeName(key))
+		return nil
+	}
+
+	if err := copyObject(ctx, coldStorage, storage, key); err != nil {
+		return errors.Wrap(err, "failed to copy object from cold storage")
+	}
+
+	return errors.Wrap(coldStorage.Delete(DecodeName(key)), "failed to delete object from cold storage")
+}
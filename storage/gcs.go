@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+)
+
+// Google Cloud Storage is used through its S3 compatible XML API, authenticated with HMAC keys
+const gcsEndpoint = "https://storage.googleapis.com"
+
+func init() {
+	RegisterDriver("gcs", func(ctx context.Context, config Config) Storage {
+		if config.Endpoint == "" {
+			config.Endpoint = gcsEndpoint
+		}
+
+		if config.Region == "" {
+			config.Region = "auto"
+		}
+
+		if s3o := initializeS3(ctx, config); s3o != nil {
+			return s3o
+		}
+		return nil
+	})
+}
@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/satisfactorymodding/smr-api/redis"
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// Local stores objects on the local filesystem, meant for development.
+// Objects are served by the API under the storage.base_url.
+type Local struct {
+	Root    string
+	BaseURL string
+}
+
+const localMultipartDirectory = ".multipart"
+
+func init() {
+	RegisterDriver("local", func(ctx context.Context, config Config) Storage {
+		if local := initializeLocal(ctx, config); local != nil {
+			return local
+		}
+		return nil
+	})
+}
+
+func initializeLocal(ctx context.Context, config Config) *Local {
+	root, err := filepath.Abs(config.Path)
+	if err != nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil
+	}
+
+	return &Local{
+		Root:    root,
+		BaseURL: strings.TrimSuffix(config.BaseURL, "/"),
+	}
+}
+
+// path resolves a key inside the root, keys can never point outside of it
+func (l *Local) path(key string) string {
+	return filepath.Join(l.Root, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+func (l *Local) multipartPath(key string) string {
+	return filepath.Join(l.Root, localMultipartDirectory, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+func (l *Local) Get(key string) (io.ReadCloser, error) {
+	file, err := os.Open(l.path(key))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object")
+	}
+
+	return file, nil
+}
+
+func (l *Local) GetRange(key string, offset int64, length int64) (io.ReadCloser, error) {
+	file, err := os.Open(l.path(key))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object")
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(file, offset, length), file}, nil
+}
+
+func (l *Local) Put(ctx context.Context, key string, body io.ReadSeeker) (string, error) {
+	if err := l.write(l.path(key), body); err != nil {
+		return key, errors.Wrap(err, "failed to upload file")
+	}
+
+	return key, nil
+}
+
+func (l *Local) write(target string, body io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, body); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func (l *Local) SignGet(key string) (string, error) {
+	return l.BaseURL + "/" + strings.TrimPrefix(key, "/"), nil
+}
+
+func (l *Local) SignPut(key string) (string, error) {
+	return "", errors.New("Unsupported")
+}
+
+func (l *Local) StartMultipartUpload(key string) error {
+	cleanedKey := strings.TrimPrefix(key, "/")
+
+	if err := os.MkdirAll(l.multipartPath(cleanedKey), 0o755); err != nil {
+		return errors.Wrap(err, "failed to create multipart upload")
+	}
+
+	redis.StoreMultipartUploadID(cleanedKey, util.GenerateUniqueID())
+
+	return nil
+}
+
+func (l *Local) UploadPart(key string, part int64, data io.ReadSeeker) error {
+	cleanedKey := strings.TrimPrefix(key, "/")
+	if redis.GetMultipartUploadID(cleanedKey) == "" {
+		return errors.New("upload not found or expired")
+	}
+
+	hash := md5.New() //nolint:gosec
+	if err := l.write(filepath.Join(l.multipartPath(cleanedKey), strconv.FormatInt(part, 10)), io.TeeReader(data, hash)); err != nil {
+		return errors.Wrap(err, "failed to upload part")
+	}
+
+	redis.StoreMultipartCompletedPart(cleanedKey, hex.EncodeToString(hash.Sum(nil)), int(part))
+
+	return nil
+}
+
+func (l *Local) SignUploadPart(key string, part int64, size int64, expiry time.Duration) (string, error) {
+	return "", errors.New("Unsupported")
+}
+
+func (l *Local) CompleteMultipartUpload(key string) error {
+	cleanedKey := strings.TrimPrefix(key, "/")
+	redis.GetAndClearMultipartCompletedParts(cleanedKey)
+
+	partsPath := l.multipartPath(cleanedKey)
+	entries, err := os.ReadDir(partsPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to list uploaded parts")
+	}
+
+	parts := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		part, err := strconv.ParseInt(entry.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		parts = append(parts, part)
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i] < parts[j]
+	})
+
+	if err := checkPartSequence(parts); err != nil {
+		return err
+	}
+
+	readers := make([]io.Reader, 0, len(parts))
+	for _, part := range parts {
+		file, err := os.Open(filepath.Join(partsPath, strconv.FormatInt(part, 10)))
+		if err != nil {
+			return errors.Wrap(err, "failed to open part")
+		}
+		defer file.Close()
+
+		readers = append(readers, file)
+	}
+
+	if err := l.write(l.path(cleanedKey), io.MultiReader(readers...)); err != nil {
+		return errors.Wrap(err, "failed to complete multipart upload")
+	}
+
+	return errors.Wrap(os.RemoveAll(partsPath), "failed to remove uploaded parts")
+}
+
+func (l *Local) AbortMultipartUpload(key string, uploadID string) error {
+	return errors.Wrap(os.RemoveAll(l.multipartPath(strings.TrimPrefix(key, "/"))), "failed to abort multipart upload")
+}
+
+func (l *Local) Rename(from string, to string) error {
+	// The source is provided in its encoded form, as is expected by S3 compatible backends
+	source, err := os.Open(l.path(DecodeName(from)))
+	if err != nil {
+		return errors.Wrap(err, "failed to copy object")
+	}
+	defer source.Close()
+
+	return errors.Wrap(l.write(l.path(to), source), "failed to copy object")
+}
+
+func (l *Local) Delete(key string) error {
+	err := os.Remove(l.path(key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.Wrap(err, "failed to delete object")
+	}
+
+	return nil
+}
+
+func (l *Local) Meta(key string) (*ObjectMeta, error) {
+	info, err := os.Stat(l.path(key))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object meta")
+	}
+
+	size := info.Size()
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return &ObjectMeta{
+		ContentLength: &size,
+		ContentType:   &contentType,
+	}, nil
+}
+
+func (l *Local) List(prefix string) ([]Object, error) {
+	out := make([]Object, 0)
+	cleanedPrefix := strings.TrimPrefix(prefix, "/")
+
+	err := filepath.WalkDir(l.Root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(l.Root, filePath)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(relative)
+
+		if entry.IsDir() {
+			if key == localMultipartDirectory {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasPrefix(key, cleanedPrefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		lastModified := info.ModTime()
+		out = append(out, Object{
+			Key:          &key,
+			LastModified: &lastModified,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+
+	return out, nil
+}
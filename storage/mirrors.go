@@ -0,0 +1,278 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// MirrorConfig is a secondary bucket version files are replicated to, as configured in storage.mirrors
+type MirrorConfig struct {
+	Name     string   `mapstructure:"name"`
+	Type     string   `mapstructure:"type"`
+	Bucket   string   `mapstructure:"bucket"`
+	Key      string   `mapstructure:"key"`
+	Secret   string   `mapstructure:"secret"`
+	BaseURL  string   `mapstructure:"base_url"`
+	Endpoint string   `mapstructure:"endpoint"`
+	Region   string   `mapstructure:"region"`
+	Path     string   `mapstructure:"path"`
+	Regions  []string `mapstructure:"regions"`
+}
+
+func (c MirrorConfig) storageConfig() Config {
+	return Config{
+		Type:     c.Type,
+		Bucket:   c.Bucket,
+		Key:      c.Key,
+		Secret:   c.Secret,
+		BaseURL:  c.BaseURL,
+		Endpoint: c.Endpoint,
+		Region:   c.Region,
+		Path:     c.Path,
+	}
+}
+
+type mirror struct {
+	name    string
+	regions map[string]struct{}
+	storage Storage
+	healthy atomic.Bool
+}
+
+var mirrors []*mirror
+
+// InitializeMirrors sets up every mirror in storage.mirrors.
+// Mirrors that fail to initialize are skipped, downloads are then served from the primary storage.
+func InitializeMirrors(ctx context.Context) {
+	var configs []MirrorConfig
+	if err := viper.UnmarshalKey("storage.mirrors", &configs); err != nil {
+		log.Err(err).Msg("failed to parse storage mirrors")
+		return
+	}
+
+	for _, config := range configs {
+		if config.Name == "" {
+			log.Error().Msg("storage mirror is missing a name")
+			continue
+		}
+
+		driver, ok := drivers[config.Type]
+		if !ok {
+			log.Error().Str("mirror", config.Name).Msg("unknown storage type: " + config.Type)
+			continue
+		}
+
+		mirrorStorage := driver(ctx, config.storageConfig())
+
+		if mirrorStorage == nil {
+			log.Error().Str("mirror", config.Name).Msg("failed to initialize storage mirror")
+			continue
+		}
+
+		m := &mirror{
+			name:    config.Name,
+			regions: make(map[string]struct{}, len(config.Regions)),
+			storage: mirrorStorage,
+		}
+
+		for _, region := range config.Regions {
+			m.regions[strings.ToUpper(region)] = struct{}{}
+		}
+
+		m.healthy.Store(true)
+		mirrors = append(mirrors, m)
+
+		log.Info().Msgf("Storage mirror initialized: %s (%s)", config.Name, config.Type)
+	}
+}
+
+// Mirrors returns the names of every configured mirror
+func Mirrors() []string {
+	names := make([]string, len(mirrors))
+	for i, m := range mirrors {
+		names[i] = m.name
+	}
+	return names
+}
+
+func getMirror(name string) *mirror {
+	for _, m := range mirrors {
+		if m.name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// ReplicateToMirror copies a file from the primary storage to a mirror.
+// Keys are expected in their encoded form, as stored in the database.
+func ReplicateToMirror(ctx context.Context, mirrorName string, key string) error {
+	if storage == nil {
+		return errors.New("storage not initialized")
+	}
+
+	m := getMirror(mirrorName)
+	if m == nil {
+		return errors.New("unknown storage mirror: " + mirrorName)
+	}
+
+	if IsQuarantined(key) {
+		return errors.New("quarantined files are not replicated")
+	}
+
+	if err := copyObject(ctx, storage, m.storage, key); err != nil {
+		m.healthy.Store(false)
+		return errors.Wrap(err, "failed to replicate to mirror "+mirrorName)
+	}
+
+	return nil
+}
+
+// copyObject copies a file between two storage backends, buffering it on disk
+func copyObject(ctx context.Context, from Storage, to Storage, key string) error {
+	return copyObjectAs(ctx, from, to, key, key)
+}
+
+// copyObjectAs copies an object between storages, storing it under another key
+func copyObjectAs(ctx context.Context, from Storage, to Storage, fromKey string, toKey string) error {
+	source, err := from.Get(DecodeName(fromKey))
+	if err != nil {
+		return errors.Wrap(err, "failed to get object")
+	}
+	defer source.Close()
+
+	file, _, cleanup, err := util.BufferToTempFile(source)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if _, err := file.Seek(0, 0); err != nil {
+		return errors.Wrap(err, "failed to rewind object")
+	}
+
+	if _, err := to.Put(ctx, DecodeName(toKey), file); err != nil {
+		return errors.Wrap(err, "failed to put object")
+	}
+
+	return nil
+}
+
+// DeleteFromMirror removes a replicated file from a mirror
+func DeleteFromMirror(mirrorName string, key string) error {
+	m := getMirror(mirrorName)
+	if m == nil {
+		return errors.New("unknown storage mirror: " + mirrorName)
+	}
+
+	return errors.Wrap(m.storage.Delete(DecodeName(key)), "failed to delete object from mirror "+mirrorName)
+}
+
+// GenerateMirroredDownloadLink generates a signed download link from the closest healthy mirror
+// the file has been replicated to, falling back to the primary storage.
+//
+// The region is the client's country code, matched against the regions of each mirror.
+func GenerateMirroredDownloadLink(key string, region string, replicated []string) string {
+	region = strings.ToUpper(region)
+
+	for _, name := range replicated {
+		m := getMirror(name)
+		if m == nil || !m.healthy.Load() {
+			continue
+		}
+
+		if _, ok := m.regions[region]; !ok {
+			continue
+		}
+
+		if link, ok := mirrorDownloadLink(m, key); ok {
+			return link
+		}
+	}
+
+	return GenerateSignedDownloadLink(key)
+}
+
+// GenerateDownloadLinkFromMirror generates a signed download link from the named mirror, regardless of its regions.
+// It falls back to the primary storage if the file has not been replicated to it or if it is unhealthy.
+func GenerateDownloadLinkFromMirror(key string, mirrorName string, replicated []string) string {
+	for _, name := range replicated {
+		if name != mirrorName {
+			continue
+		}
+
+		m := getMirror(name)
+		if m == nil || !m.healthy.Load() {
+			break
+		}
+
+		if link, ok := mirrorDownloadLink(m, key); ok {
+			return link
+		}
+	}
+
+	return GenerateSignedDownloadLink(key)
+}
+
+func mirrorDownloadLink(m *mirror, key string) (string, bool) {
+	if IsQuarantined(key) {
+		return "", true
+	}
+
+	link, err := m.storage.SignGet(key)
+	if err != nil {
+		return "", false
+	}
+
+	signed, err := signLink(link, time.Now().Add(viper.GetDuration("storage.cdn.ttl")))
+	if err != nil {
+		log.Err(err).Str("key", key).Str("mirror", m.name).Msg("failed to sign mirror download link")
+		return "", false
+	}
+
+	return signed, true
+}
+
+// MirrorHealth returns whether every mirror was reachable on its last health check, by name
+func MirrorHealth() map[string]bool {
+	health := make(map[string]bool, len(mirrors))
+	for _, m := range mirrors {
+		health[m.name] = m.healthy.Load()
+	}
+	return health
+}
+
+// RunAsyncMirrorHealthLoop periodically checks that every mirror is reachable,
+// so downloads are not redirected to a mirror that is down.
+func RunAsyncMirrorHealthLoop(ctx context.Context) {
+	if len(mirrors) == 0 {
+		return
+	}
+
+	go func() {
+		for {
+			for _, m := range mirrors {
+				_, err := m.storage.List(healthCheckPrefix)
+				healthy := err == nil
+
+				if m.healthy.Swap(healthy) != healthy {
+					if healthy {
+						log.Ctx(ctx).Info().Str("mirror", m.name).Msg("storage mirror recovered")
+					} else {
+						log.Ctx(ctx).Warn().Err(err).Str("mirror", m.name).Msg("storage mirror is unhealthy")
+					}
+				}
+			}
+
+			time.Sleep(viper.GetDuration("storage.mirror_health_interval"))
+		}
+	}()
+}
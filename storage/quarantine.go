@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+const quarantinePrefix = "/quarantine"
+
+var quarantineStorage Storage
+
+// InitializeQuarantineStorage sets up the private storage quarantined files are moved to, configured like a mirror under storage.quarantine.
+// Without it, quarantined files are moved to the quarantine prefix of the primary storage,
+// which keeps them out of download links but not out of a public bucket.
+func InitializeQuarantineStorage(ctx context.Context) {
+	if viper.GetString("storage.quarantine.type") == "" {
+		log.Warn().Msg("storage.quarantine is not configured, quarantined files stay in the primary storage under " + quarantinePrefix)
+		return
+	}
+
+	var config MirrorConfig
+	if err := viper.UnmarshalKey("storage.quarantine", &config); err != nil {
+		log.Err(err).Msg("failed to parse quarantine storage")
+		return
+	}
+
+	driver, ok := drivers[config.Type]
+	if !ok {
+		log.Error().Msg("unknown quarantine storage type: " + config.Type)
+		return
+	}
+
+	quarantineStorage = driver(ctx, config.storageConfig())
+	if quarantineStorage == nil {
+		log.Error().Msg("failed to initialize quarantine storage")
+		return
+	}
+
+	log.Info().Msgf("Quarantine storage initialized: %s", config.Type)
+}
+
+// IsQuarantined returns whether the key is located in the quarantine prefix
+func IsQuarantined(key string) bool {
+	return strings.HasPrefix(key, quarantinePrefix+"/")
+}
+
+// quarantine returns the storage holding quarantined files
+func quarantine() Storage {
+	if quarantineStorage != nil {
+		return quarantineStorage
+	}
+
+	return storage
+}
+
+// GetQuarantined reads a file by its decoded key, also when it is held in quarantine,
+// for the jobs which process versions pending their scan
+func GetQuarantined(key string) (io.ReadCloser, error) {
+	if storage == nil {
+		return nil, errors.New("storage not initialized")
+	}
+
+	if IsQuarantined(key) {
+		file, err := quarantine().Get(key)
+		return file, errors.Wrap(err, "failed to get quarantined object")
+	}
+
+	file, err := Get(key)
+	if err != nil && IsBlob(key) {
+		// Quarantined blobs keep their key
+		if quarantined, qErr := quarantine().Get(quarantinePrefix + key); qErr == nil {
+			return quarantined, nil
+		}
+	}
+
+	return file, err
+}
+
+// MoveToQuarantine moves an object to the quarantine prefix, which download links are never generated for.
+// Keys are expected in their encoded form, as stored in the database.
+// Blobs may be shared with other versions, they are quarantined with QuarantineBlob instead.
+func MoveToQuarantine(ctx context.Context, key string) (string, error) {
+	if IsQuarantined(key) || IsBlob(key) {
+		return key, nil
+	}
+
+	to := quarantinePrefix + key
+	if err := moveBetween(ctx, storage, quarantine(), key, to); err != nil {
+		return key, err
+	}
+
+	return to, nil
+}
+
+// ReleaseFromQuarantine moves an object out of the quarantine prefix back to its original location
+func ReleaseFromQuarantine(ctx context.Context, key string) (string, error) {
+	if !IsQuarantined(key) {
+		return key, nil
+	}
+
+	to := strings.TrimPrefix(key, quarantinePrefix)
+	if err := moveBetween(ctx, quarantine(), storage, key, to); err != nil {
+		return key, err
+	}
+
+	return to, nil
+}
+
+// QuarantineBlob moves a blob to the quarantine while keeping its key, as every target sharing it still references it.
+// The caller is responsible for refusing to serve the blob while any version referencing it is quarantined.
+func QuarantineBlob(ctx context.Context, key string) error {
+	if !IsBlob(key) {
+		return errors.New("not a blob: " + key)
+	}
+
+	return moveBetween(ctx, storage, quarantine(), key, quarantinePrefix+key)
+}
+
+// ReleaseBlobFromQuarantine moves a blob back to the primary storage, once no version referencing it is quarantined
+func ReleaseBlobFromQuarantine(ctx context.Context, key string) error {
+	if !IsBlob(key) {
+		return errors.New("not a blob: " + key)
+	}
+
+	return moveBetween(ctx, quarantine(), storage, quarantinePrefix+key, key)
+}
+
+// moveBetween moves an object to another key and storage, succeeding if a previous attempt already moved it
+func moveBetween(ctx context.Context, from Storage, to Storage, fromKey string, toKey string) error {
+	if from == nil || to == nil {
+		return errors.New("storage not initialized")
+	}
+
+	if _, err := from.Meta(DecodeName(fromKey)); err != nil {
+		if _, err := to.Meta(DecodeName(toKey)); err == nil {
+			return nil
+		}
+	}
+
+	log.Info().Msgf("Moving file from %s to %s", fromKey, toKey)
+
+	if from == to {
+		if err := from.Rename(fromKey, DecodeName(toKey)); err != nil {
+			return errors.Wrap(err, "failed to copy object")
+		}
+	} else if err := copyObjectAs(ctx, from, to, fromKey, toKey); err != nil {
+		return errors.Wrap(err, "failed to copy object")
+	}
+
+	return errors.Wrap(from.Delete(DecodeName(fromKey)), "failed to delete object")
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// useLocalStorage points the primary and quarantine storage at temporary local directories until the end of the test
+func useLocalStorage(t *testing.T) (*Local, *Local) {
+	t.Helper()
+
+	primary := initializeLocal(context.Background(), Config{Path: t.TempDir(), BaseURL: "http://localhost/storage"})
+	private := initializeLocal(context.Background(), Config{Path: t.TempDir(), BaseURL: "http://localhost/quarantine"})
+	if primary == nil || private == nil {
+		t.Fatal("failed to initialize local storage")
+	}
+
+	previousStorage, previousQuarantine := storage, quarantineStorage
+	storage, quarantineStorage = primary, private
+	t.Cleanup(func() {
+		storage, quarantineStorage = previousStorage, previousQuarantine
+	})
+
+	return primary, private
+}
+
+func TestQuarantinedKeyCannotBeFetched(t *testing.T) {
+	primary, private := useLocalStorage(t)
+
+	key := "/mods/abc/Mod-1.0.0.smod"
+	if _, err := primary.Put(context.Background(), key, bytes.NewReader([]byte("infected"))); err != nil {
+		t.Fatal(err)
+	}
+
+	quarantined, err := MoveToQuarantine(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsQuarantined(quarantined) {
+		t.Fatalf("expected a quarantined key, got %s", quarantined)
+	}
+
+	if link := GenerateDownloadLink(quarantined); link != "" {
+		t.Errorf("expected no download link, got %s", link)
+	}
+
+	if link := GenerateSignedDownloadLink(quarantined); link != "" {
+		t.Errorf("expected no signed download link, got %s", link)
+	}
+
+	if _, err := OpenFile(quarantined); err == nil {
+		t.Error("expected opening a quarantined file to fail")
+	}
+
+	if _, err := primary.Get(key); err == nil {
+		t.Error("expected the file to be gone from its public location")
+	}
+
+	if _, err := primary.Get(quarantined); err == nil {
+		t.Error("expected the file to be absent from the primary storage")
+	}
+
+	if _, err := private.Meta(quarantined); err != nil {
+		t.Errorf("expected the file in the quarantine storage: %v", err)
+	}
+
+	released, err := ReleaseFromQuarantine(context.Background(), quarantined)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if released != key {
+		t.Errorf("expected %s, got %s", key, released)
+	}
+
+	if _, err := primary.Meta(key); err != nil {
+		t.Errorf("expected the file back in the primary storage: %v", err)
+	}
+
+	// Releasing again finds the file already moved
+	if _, err := ReleaseFromQuarantine(context.Background(), quarantined); err != nil {
+		t.Errorf("expected releasing twice to succeed: %v", err)
+	}
+}
+
+func TestQuarantinedBlobKeepsItsKey(t *testing.T) {
+	primary, private := useLocalStorage(t)
+
+	key := blobKey("0123456789abcdef")
+	if _, err := primary.Put(context.Background(), key, bytes.NewReader([]byte("shared"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if moved, err := MoveToQuarantine(context.Background(), key); err != nil || moved != key {
+		t.Fatalf("expected blobs to keep their key, got %s: %v", moved, err)
+	}
+
+	if err := QuarantineBlob(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := primary.Get(key); err == nil {
+		t.Error("expected the blob to be gone from the primary storage")
+	}
+
+	if _, err := private.Meta(quarantinePrefix + key); err != nil {
+		t.Errorf("expected the blob in the quarantine storage: %v", err)
+	}
+
+	if err := ReleaseBlobFromQuarantine(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := primary.Meta(key); err != nil {
+		t.Errorf("expected the blob back in the primary storage: %v", err)
+	}
+}
+
+func TestDeleteVersionFileDeletesQuarantinedFiles(t *testing.T) {
+	primary, private := useLocalStorage(t)
+
+	blob := blobKey("fedcba9876543210")
+	if _, err := primary.Put(context.Background(), blob, bytes.NewReader([]byte("shared"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := QuarantineBlob(context.Background(), blob); err != nil {
+		t.Fatal(err)
+	}
+
+	if !DeleteVersionFile(context.Background(), blob) {
+		t.Fatal("expected the quarantined blob to be deleted")
+	}
+
+	if _, err := private.Meta(quarantinePrefix + blob); err == nil {
+		t.Error("expected the blob to be gone from the quarantine storage")
+	}
+
+	key := "/mods/abc/Mod-2.0.0.smod"
+	if _, err := primary.Put(context.Background(), key, bytes.NewReader([]byte("pending"))); err != nil {
+		t.Fatal(err)
+	}
+
+	quarantined, err := MoveToQuarantine(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !DeleteVersionFile(context.Background(), quarantined) {
+		t.Fatal("expected the quarantined file to be deleted")
+	}
+
+	if _, err := private.Meta(quarantined); err == nil {
+		t.Error("expected the file to be gone from the quarantine storage")
+	}
+}
+
+func TestGetQuarantinedReadsQuarantinedFiles(t *testing.T) {
+	primary, _ := useLocalStorage(t)
+
+	key := "/mods/abc/Mod-3.0.0.smod"
+	blob := blobKey("00112233445566778899")
+	for _, k := range []string{key, blob} {
+		if _, err := primary.Put(context.Background(), k, bytes.NewReader([]byte("pending"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	quarantined, err := MoveToQuarantine(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := QuarantineBlob(context.Background(), blob); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []string{quarantined, blob} {
+		file, err := GetQuarantined(k)
+		if err != nil {
+			t.Errorf("expected %s to be readable: %v", k, err)
+			continue
+		}
+		file.Close()
+	}
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// ObjectReader is a seekable reader over a stored object.
+// Seeking is free, the object is only requested from the current offset once it is read,
+// so serving a range does not fetch the start of the object from storage.
+type ObjectReader struct {
+	storage Storage
+	key     string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+}
+
+// OpenFile opens a stored object for ranged reads.
+// Keys are expected in their encoded form, as stored in the database.
+func OpenFile(key string) (*ObjectReader, error) {
+	if storage == nil {
+		return nil, errors.New("storage not initialized")
+	}
+
+	if IsQuarantined(key) {
+		return nil, errors.New("quarantined files cannot be downloaded")
+	}
+
+	decoded := DecodeName(key)
+
+	meta, err := storage.Meta(decoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object meta")
+	}
+
+	if meta.ContentLength == nil {
+		return nil, errors.New("object has no content length")
+	}
+
+	return &ObjectReader{
+		storage: storage,
+		key:     decoded,
+		size:    *meta.ContentLength,
+	}, nil
+}
+
+// Size returns the size of the object
+func (o *ObjectReader) Size() int64 {
+	return o.size
+}
+
+func (o *ObjectReader) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+
+	if o.body == nil {
+		body, err := o.storage.GetRange(o.key, o.offset, o.size-o.offset)
+		if err != nil {
+			return 0, err
+		}
+		o.body = body
+	}
+
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err //nolint:wrapcheck
+}
+
+func (o *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	var position int64
+	switch whence {
+	case io.SeekStart:
+		position = offset
+	case io.SeekCurrent:
+		position = o.offset + offset
+	case io.SeekEnd:
+		position = o.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if position < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	if position != o.offset && o.body != nil {
+		_ = o.body.Close()
+		o.body = nil
+	}
+
+	o.offset = position
+	return position, nil
+}
+
+func (o *ObjectReader) Close() error {
+	if o.body == nil {
+		return nil
+	}
+
+	err := o.body.Close()
+	o.body = nil
+	return errors.Wrap(err, "failed to close object")
+}
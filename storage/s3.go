@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -26,6 +28,15 @@ type S3 struct {
 	Config    Config
 }
 
+func init() {
+	RegisterDriver("s3", func(ctx context.Context, config Config) Storage {
+		if s3o := initializeS3(ctx, config); s3o != nil {
+			return s3o
+		}
+		return nil
+	})
+}
+
 func initializeS3(ctx context.Context, config Config) *S3 {
 	s3Config := &aws.Config{
 		Credentials:      credentials.NewStaticCredentials(config.Key, config.Secret, ""),
@@ -64,6 +75,21 @@ func (s3o *S3) Get(key string) (io.ReadCloser, error) {
 	return object.Body, nil
 }
 
+func (s3o *S3) GetRange(key string, offset int64, length int64) (io.ReadCloser, error) {
+	cleanedKey := strings.TrimPrefix(key, "/")
+
+	object, err := s3o.S3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s3o.Config.Bucket),
+		Key:    aws.String(cleanedKey),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object range")
+	}
+
+	return object.Body, nil
+}
+
 func (s3o *S3) Put(ctx context.Context, key string, body io.ReadSeeker) (string, error) {
 	cleanedKey := strings.TrimPrefix(key, "/")
 
@@ -71,7 +97,7 @@ func (s3o *S3) Put(ctx context.Context, key string, body io.ReadSeeker) (string,
 
 	_, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
 		Body:   body,
-		Bucket: aws.String(viper.GetString("storage.bucket")),
+		Bucket: aws.String(s3o.Config.Bucket),
 		Key:    aws.String(cleanedKey),
 	})
 	if err != nil {
@@ -84,7 +110,7 @@ func (s3o *S3) Put(ctx context.Context, key string, body io.ReadSeeker) (string,
 func (s3o *S3) SignGet(key string) (string, error) {
 	// Public Bucket
 	cleanedKey := strings.TrimPrefix(key, "/")
-	return fmt.Sprintf(viper.GetString("storage.keypath"), s3o.BaseURL, viper.GetString("storage.bucket"), cleanedKey), nil
+	return fmt.Sprintf(viper.GetString("storage.keypath"), s3o.BaseURL, s3o.Config.Bucket, cleanedKey), nil
 }
 
 func (s3o *S3) SignPut(key string) (string, error) {
@@ -95,7 +121,7 @@ func (s3o *S3) SignPut(key string) (string, error) {
 func (s3o *S3) StartMultipartUpload(key string) error {
 	cleanedKey := strings.TrimPrefix(key, "/")
 	upload, err := s3o.S3Client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
-		Bucket: aws.String(viper.GetString("storage.bucket")),
+		Bucket: aws.String(s3o.Config.Bucket),
 		Key:    aws.String(cleanedKey),
 	})
 	if err != nil {
@@ -110,10 +136,13 @@ func (s3o *S3) StartMultipartUpload(key string) error {
 func (s3o *S3) UploadPart(key string, part int64, data io.ReadSeeker) error {
 	cleanedKey := strings.TrimPrefix(key, "/")
 	id := redis.GetMultipartUploadID(cleanedKey)
+	if id == "" {
+		return errors.New("upload not found or expired")
+	}
 
 	response, err := s3o.S3Client.UploadPart(&s3.UploadPartInput{
 		Body:       data,
-		Bucket:     aws.String(viper.GetString("storage.bucket")),
+		Bucket:     aws.String(s3o.Config.Bucket),
 		Key:        aws.String(cleanedKey),
 		PartNumber: aws.Int64(part),
 		UploadId:   aws.String(id),
@@ -127,19 +156,47 @@ func (s3o *S3) UploadPart(key string, part int64, data io.ReadSeeker) error {
 	return nil
 }
 
+func (s3o *S3) SignUploadPart(key string, part int64, size int64, expiry time.Duration) (string, error) {
+	cleanedKey := strings.TrimPrefix(key, "/")
+	id := redis.GetMultipartUploadID(cleanedKey)
+	if id == "" {
+		return "", errors.New("upload not found or expired")
+	}
+
+	req, _ := s3o.S3Client.UploadPartRequest(&s3.UploadPartInput{
+		Bucket:     aws.String(s3o.Config.Bucket),
+		Key:        aws.String(cleanedKey),
+		PartNumber: aws.Int64(part),
+		UploadId:   aws.String(id),
+		// Signed along with the URL, so the storage rejects bodies of any other size
+		ContentLength: aws.Int64(size),
+	})
+
+	urlStr, err := req.Presign(expiry)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign url")
+	}
+
+	return urlStr, nil
+}
+
 func (s3o *S3) CompleteMultipartUpload(key string) error {
 	cleanedKey := strings.TrimPrefix(key, "/")
 	id := redis.GetMultipartUploadID(cleanedKey)
 	parts := redis.GetAndClearMultipartCompletedParts(cleanedKey)
-	completedParts := make([]*s3.CompletedPart, len(parts))
 
-	for part, etag := range parts {
-		partInt, _ := strconv.ParseInt(part, 10, 64)
-		completedParts[partInt-1] = &s3.CompletedPart{ETag: aws.String(etag), PartNumber: &partInt}
+	// Parts uploaded through pre-signed URLs are only known to the storage
+	if err := mergeStoredParts(s3o.S3Client, aws.String(s3o.Config.Bucket), cleanedKey, id, parts); err != nil {
+		return err
 	}
 
-	_, err := s3o.S3Client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
-		Bucket:          aws.String(viper.GetString("storage.bucket")),
+	completedParts, err := sortCompletedParts(parts)
+	if err != nil {
+		return err
+	}
+
+	_, err = s3o.S3Client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s3o.Config.Bucket),
 		Key:             aws.String(cleanedKey),
 		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completedParts},
 		UploadId:        aws.String(id),
@@ -148,12 +205,71 @@ func (s3o *S3) CompleteMultipartUpload(key string) error {
 	return errors.Wrap(err, "failed to complete multipart upload")
 }
 
+// sortCompletedParts orders the uploaded parts by part number, failing if any part number is invalid or missing
+func sortCompletedParts(parts map[string]string) ([]*s3.CompletedPart, error) {
+	completedParts := make([]*s3.CompletedPart, 0, len(parts))
+	for part, etag := range parts {
+		partInt, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid part number")
+		}
+
+		completedParts = append(completedParts, &s3.CompletedPart{ETag: aws.String(etag), PartNumber: aws.Int64(partInt)})
+	}
+
+	sort.Slice(completedParts, func(i, j int) bool {
+		return *completedParts[i].PartNumber < *completedParts[j].PartNumber
+	})
+
+	partNumbers := make([]int64, len(completedParts))
+	for i, part := range completedParts {
+		partNumbers[i] = *part.PartNumber
+	}
+
+	if err := checkPartSequence(partNumbers); err != nil {
+		return nil, err
+	}
+
+	return completedParts, nil
+}
+
+// mergeStoredParts adds the parts stored in the bucket for the upload that are missing from parts
+func mergeStoredParts(client *s3.S3, bucket *string, key string, uploadID string, parts map[string]string) error {
+	err := client.ListPartsPages(&s3.ListPartsInput{
+		Bucket:   bucket,
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	}, func(output *s3.ListPartsOutput, b bool) bool {
+		for _, part := range output.Parts {
+			partNumber := strconv.FormatInt(*part.PartNumber, 10)
+			if _, ok := parts[partNumber]; !ok {
+				parts[partNumber] = *part.ETag
+			}
+		}
+		return true
+	})
+
+	return errors.Wrap(err, "failed to list uploaded parts")
+}
+
+func (s3o *S3) AbortMultipartUpload(key string, uploadID string) error {
+	cleanedKey := strings.TrimPrefix(key, "/")
+
+	_, err := s3o.S3Client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s3o.Config.Bucket),
+		Key:      aws.String(cleanedKey),
+		UploadId: aws.String(uploadID),
+	})
+
+	return errors.Wrap(err, "failed to abort multipart upload")
+}
+
 func (s3o *S3) Rename(from string, to string) error {
 	cleanedKey := strings.TrimPrefix(to, "/")
 
 	_, err := s3o.S3Client.CopyObject(&s3.CopyObjectInput{
-		Bucket:     aws.String(viper.GetString("storage.bucket")),
-		CopySource: aws.String(viper.GetString("storage.bucket") + from),
+		Bucket:     aws.String(s3o.Config.Bucket),
+		CopySource: aws.String(s3o.Config.Bucket + from),
 		Key:        aws.String(cleanedKey),
 	})
 
@@ -165,14 +281,14 @@ func (s3o *S3) Delete(key string) error {
 
 	for i := 0; i < 10; i++ {
 		versions, err := s3o.S3Client.ListObjectVersions(&s3.ListObjectVersionsInput{
-			Bucket:    aws.String(viper.GetString("storage.bucket")),
+			Bucket:    aws.String(s3o.Config.Bucket),
 			KeyMarker: aws.String(cleanedKey),
 			Prefix:    aws.String(cleanedKey),
 		})
 		if err != nil {
 			if strings.Contains(err.Error(), "NotImplemented") {
 				_, err = s3o.S3Client.DeleteObject(&s3.DeleteObjectInput{
-					Bucket: aws.String(viper.GetString("storage.bucket")),
+					Bucket: aws.String(s3o.Config.Bucket),
 					Key:    aws.String(cleanedKey),
 				})
 
@@ -204,7 +320,7 @@ func (s3o *S3) Delete(key string) error {
 
 		if len(objects) == 0 {
 			_, err = s3o.S3Client.DeleteObject(&s3.DeleteObjectInput{
-				Bucket: aws.String(viper.GetString("storage.bucket")),
+				Bucket: aws.String(s3o.Config.Bucket),
 				Key:    aws.String(cleanedKey),
 			})
 
@@ -216,7 +332,7 @@ func (s3o *S3) Delete(key string) error {
 		}
 
 		_, err = s3o.S3Client.DeleteObjects(&s3.DeleteObjectsInput{
-			Bucket: aws.String(viper.GetString("storage.bucket")),
+			Bucket: aws.String(s3o.Config.Bucket),
 			Delete: &s3.Delete{
 				Objects: objects,
 			},
@@ -234,7 +350,7 @@ func (s3o *S3) Meta(key string) (*ObjectMeta, error) {
 	cleanedKey := strings.TrimPrefix(key, "/")
 
 	data, err := s3o.S3Client.HeadObject(&s3.HeadObjectInput{
-		Bucket: aws.String(viper.GetString("storage.bucket")),
+		Bucket: aws.String(s3o.Config.Bucket),
 		Key:    aws.String(cleanedKey),
 	})
 	if err != nil {
@@ -251,7 +367,7 @@ func (s3o *S3) List(prefix string) ([]Object, error) {
 	out := make([]Object, 0)
 
 	err := s3o.S3Client.ListObjectsPages(&s3.ListObjectsInput{
-		Bucket: aws.String(viper.GetString("storage.bucket")),
+		Bucket: aws.String(s3o.Config.Bucket),
 		Prefix: aws.String(prefix),
 	}, func(output *s3.ListObjectsOutput, b bool) bool {
 		for _, obj := range output.Contents {
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"testing"
+)
+
+func TestSortCompletedPartsOrdersParts(t *testing.T) {
+	parts, err := sortCompletedParts(map[string]string{
+		"3": "c",
+		"1": "a",
+		"2": "b",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, part := range parts {
+		if *part.PartNumber != int64(i+1) {
+			t.Errorf("expected part %d at index %d, got %d", i+1, i, *part.PartNumber)
+		}
+	}
+
+	if *parts[0].ETag != "a" || *parts[2].ETag != "c" {
+		t.Error("expected every part to keep its etag")
+	}
+}
+
+func TestSortCompletedPartsRejectsMissingParts(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts map[string]string
+	}{
+		{name: "gap", parts: map[string]string{"1": "a", "3": "c"}},
+		{name: "no first part", parts: map[string]string{"2": "b"}},
+		{name: "empty", parts: map[string]string{}},
+		{name: "zero", parts: map[string]string{"0": "a", "1": "b"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := sortCompletedParts(test.parts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/md5" //nolint:gosec
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
+	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,16 +20,21 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/redis"
 )
 
 type Storage interface {
 	Get(key string) (io.ReadCloser, error)
+	GetRange(key string, offset int64, length int64) (io.ReadCloser, error)
 	Put(ctx context.Context, key string, body io.ReadSeeker) (string, error)
 	SignGet(key string) (string, error)
 	SignPut(key string) (string, error)
 	StartMultipartUpload(key string) error
 	UploadPart(key string, part int64, data io.ReadSeeker) error
+	SignUploadPart(key string, part int64, size int64, expiry time.Duration) (string, error)
 	CompleteMultipartUpload(key string) error
+	AbortMultipartUpload(key string, uploadID string) error
 	Rename(from string, to string) error
 	Delete(key string) error
 	Meta(key string) (*ObjectMeta, error)
@@ -50,6 +59,17 @@ type Config struct {
 	BaseURL  string `json:"base_url"`
 	Endpoint string `json:"endpoint"`
 	Region   string `json:"region"`
+	Path     string `json:"path"`
+}
+
+// Driver creates a storage backend from its configuration, returning nil if it cannot be initialized
+type Driver func(ctx context.Context, config Config) Storage
+
+var drivers = make(map[string]Driver)
+
+// RegisterDriver makes a storage backend available to be selected through storage.type
+func RegisterDriver(name string, driver Driver) {
+	drivers[name] = driver
 }
 
 var storage Storage
@@ -63,6 +83,7 @@ func InitializeStorage(ctx context.Context) {
 		BaseURL:  viper.GetString("storage.base_url"),
 		Endpoint: viper.GetString("storage.endpoint"),
 		Region:   viper.GetString("storage.region"),
+		Path:     viper.GetString("storage.path"),
 	}
 
 	storage = configToStorage(ctx, baseConfig)
@@ -75,16 +96,12 @@ func InitializeStorage(ctx context.Context) {
 }
 
 func configToStorage(ctx context.Context, config Config) Storage {
-	switch config.Type {
-	case "wasabi":
-		return initializeWasabi(ctx, config)
-	case "b2":
-		return initializeB2(ctx, config)
-	case "s3":
-		return initializeS3(ctx, config)
+	driver, ok := drivers[config.Type]
+	if !ok {
+		panic("Unknown storage type: " + config.Type)
 	}
 
-	panic("Unknown storage type: " + viper.GetString("storage.type"))
+	return driver(ctx, config)
 }
 
 func StartUploadMultipartMod(ctx context.Context, modID string, name string, versionID string) (bool, string) {
@@ -105,9 +122,57 @@ func StartUploadMultipartMod(ctx context.Context, modID string, name string, ver
 	return true, fmt.Sprintf("/mods/%s/%s.smod", modID, EncodeName(filename))
 }
 
-func UploadMultipartMod(ctx context.Context, modID string, name string, versionID string, part int64, data io.ReadSeeker) (bool, string) {
+type ChecksumAlgorithm string
+
+const (
+	ChecksumMD5    ChecksumAlgorithm = "md5"
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+)
+
+// PartChecksum is the client-computed digest of a single multipart upload part
+type PartChecksum struct {
+	Algorithm ChecksumAlgorithm
+	Value     string
+}
+
+// Verify hashes data and compares it to the expected hex encoded checksum.
+// The reader is rewound to the start after hashing.
+func (c PartChecksum) Verify(data io.ReadSeeker) error {
+	var hasher hash.Hash
+	switch c.Algorithm {
+	case ChecksumMD5:
+		hasher = md5.New() //nolint:gosec
+	case ChecksumSHA256:
+		hasher = sha256.New()
+	default:
+		return errors.New("unsupported checksum algorithm: " + string(c.Algorithm))
+	}
+
+	if _, err := io.Copy(hasher, data); err != nil {
+		return errors.Wrap(err, "failed to hash part")
+	}
+
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "failed to rewind part")
+	}
+
+	actual := hex.EncodeToString(hasher.Sum(nil))
+	if !strings.EqualFold(actual, strings.TrimSpace(c.Value)) {
+		return errors.Errorf("part %s checksum mismatch: expected %s, got %s", c.Algorithm, c.Value, actual)
+	}
+
+	return nil
+}
+
+func UploadMultipartMod(ctx context.Context, modID string, name string, versionID string, part int64, data io.ReadSeeker, checksum *PartChecksum) (bool, string, error) {
 	if storage == nil {
-		return false, ""
+		return false, "", errors.New("storage not initialized")
+	}
+
+	if checksum != nil {
+		if err := checksum.Verify(data); err != nil {
+			return false, "", err
+		}
 	}
 
 	cleanName := cleanModName(name)
@@ -115,12 +180,23 @@ func UploadMultipartMod(ctx context.Context, modID string, name string, versionI
 	filename := cleanName + "-" + versionID
 	key := fmt.Sprintf("/mods/%s/%s.smod", modID, filename)
 
+	size, err := data.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, "", errors.Wrap(err, "failed to determine part size")
+	}
+
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return false, "", errors.Wrap(err, "failed to rewind part")
+	}
+
 	if err := UploadPart(key, part, data); err != nil {
 		log.Err(err).Msg("failed to upload mod")
-		return false, ""
+		return false, "", err
 	}
 
-	return true, fmt.Sprintf("/mods/%s/%s.smod", modID, EncodeName(filename))
+	redis.StoreMultipartPartSize(strings.TrimPrefix(key, "/"), int(part), size)
+
+	return true, fmt.Sprintf("/mods/%s/%s.smod", modID, EncodeName(filename)), nil
 }
 
 func CompleteUploadMultipartMod(ctx context.Context, modID string, name string, versionID string) (bool, string) {
@@ -141,6 +217,117 @@ func CompleteUploadMultipartMod(ctx context.Context, modID string, name string,
 	return true, fmt.Sprintf("/mods/%s/%s.smod", modID, EncodeName(filename))
 }
 
+// ListUploadedMultipartModParts returns the part numbers that were already
+// uploaded for the version, so an interrupted upload can be resumed.
+func ListUploadedMultipartModParts(ctx context.Context, modID string, name string, versionID string) ([]int, error) {
+	if storage == nil {
+		return nil, errors.New("storage not initialized")
+	}
+
+	cleanName := cleanModName(name)
+
+	filename := cleanName + "-" + versionID
+	key := strings.TrimPrefix(fmt.Sprintf("/mods/%s/%s.smod", modID, filename), "/")
+
+	if redis.GetMultipartUploadID(key) == "" {
+		return nil, errors.New("upload not found or expired")
+	}
+
+	parts := make([]int, 0)
+	for part := range redis.GetMultipartCompletedParts(key) {
+		partInt, err := strconv.Atoi(part)
+		if err != nil {
+			continue
+		}
+		parts = append(parts, partInt)
+	}
+
+	sort.Ints(parts)
+
+	return parts, nil
+}
+
+// checkPartSequence returns an error unless the sorted part numbers are exactly 1 to len(parts),
+// as completing an upload with a missing part would silently truncate the file
+func checkPartSequence(parts []int64) error {
+	if len(parts) == 0 {
+		return errors.New("no parts were uploaded")
+	}
+
+	for i, part := range parts {
+		if part != int64(i+1) {
+			return errors.Errorf("part %d is missing", i+1)
+		}
+	}
+
+	return nil
+}
+
+// MultipartPartSizes splits a file into parts of equal size, except for the last one holding the remainder
+func MultipartPartSizes(size int64, parts int) []int64 {
+	partSize := (size + int64(parts) - 1) / int64(parts)
+
+	sizes := make([]int64, parts)
+	remaining := size
+	for i := range sizes {
+		sizes[i] = partSize
+		if remaining < partSize {
+			sizes[i] = remaining
+		}
+		remaining -= sizes[i]
+	}
+
+	return sizes
+}
+
+// SignUploadMultipartModParts returns pre-signed URLs for parts 1 to parts of the version file,
+// so clients can PUT them directly to storage instead of going through uploadVersionPart.
+// Each URL is only valid for a body of the part size given by MultipartPartSizes, so the file cannot outgrow the declared size.
+// Completing the upload fails unless every signed part was uploaded.
+func SignUploadMultipartModParts(ctx context.Context, modID string, name string, versionID string, size int64, parts int) ([]string, time.Time, error) {
+	if storage == nil {
+		return nil, time.Time{}, errors.New("storage not initialized")
+	}
+
+	cleanName := cleanModName(name)
+
+	filename := cleanName + "-" + versionID
+	key := fmt.Sprintf("/mods/%s/%s.smod", modID, filename)
+
+	if redis.GetMultipartUploadID(strings.TrimPrefix(key, "/")) == "" {
+		return nil, time.Time{}, errors.New("upload not found or expired")
+	}
+
+	expiry := viper.GetDuration("storage.presign_ttl")
+	expiresAt := time.Now().Add(expiry)
+
+	sizes := MultipartPartSizes(size, parts)
+
+	urls := make([]string, parts)
+	for i := range urls {
+		url, err := storage.SignUploadPart(key, int64(i+1), sizes[i], expiry)
+		if err != nil {
+			return nil, time.Time{}, errors.Wrap(err, "failed to sign part upload")
+		}
+		urls[i] = url
+	}
+
+	// Parts uploaded directly are not seen by the API, so keep the upload alive for as long as the URLs are valid
+	redis.TouchMultipartUpload(strings.TrimPrefix(key, "/"))
+
+	return urls, expiresAt, nil
+}
+
+// GetMultipartModUploadedSize returns the total size of the parts uploaded so far, excluding the provided part
+func GetMultipartModUploadedSize(modID string, name string, versionID string, excludePart int64) int64 {
+	cleanName := cleanModName(name)
+
+	filename := cleanName + "-" + versionID
+	key := strings.TrimPrefix(fmt.Sprintf("/mods/%s/%s.smod", modID, filename), "/")
+
+	return redis.GetMultipartUploadedSize(key, int(excludePart))
+}
+
 func UploadModLogo(ctx context.Context, modID string, data io.ReadSeeker) (bool, string) {
 	if storage == nil {
 		return false, ""
@@ -157,6 +344,23 @@ func UploadModLogo(ctx context.Context, modID string, data io.ReadSeeker) (bool,
 	return true, key
 }
 
+// UploadModLogoVariant stores a resized encoding of a mod logo
+func UploadModLogoVariant(ctx context.Context, modID string, size int, format string, data io.ReadSeeker) (bool, string) {
+	if storage == nil {
+		return false, ""
+	}
+
+	key := fmt.Sprintf("/images/mods/%s/logo-%d.%s", modID, size, format)
+
+	key, err := storage.Put(ctx, key, data)
+	if err != nil {
+		log.Err(err).Msg("failed to upload mod logo variant")
+		return false, ""
+	}
+
+	return true, key
+}
+
 func UploadUserAvatar(ctx context.Context, userID string, data io.ReadSeeker) (bool, string) {
 	if storage == nil {
 		return false, ""
@@ -184,11 +388,45 @@ func UploadUserAvatar(ctx context.Context, userID string, data io.ReadSeeker) (b
 	return true, key
 }
 
+func UploadBlueprint(ctx context.Context, blueprintID string, name string, data io.ReadSeeker) (bool, string) {
+	if storage == nil {
+		return false, ""
+	}
+
+	key := fmt.Sprintf("/blueprints/%s/%s.zip", blueprintID, cleanModName(name))
+
+	key, err := storage.Put(ctx, key, data)
+	if err != nil {
+		log.Err(err).Msg("failed to upload blueprint")
+		return false, ""
+	}
+
+	return true, key
+}
+
+func DeleteBlueprint(ctx context.Context, key string) bool {
+	if storage == nil {
+		return false
+	}
+
+	log.Info().Str("key", key).Msg("deleting blueprint")
+	if err := storage.Delete(key); err != nil {
+		log.Err(err).Msg("failed to delete blueprint")
+		return false
+	}
+
+	return true
+}
+
 func GenerateDownloadLink(key string) string {
 	if storage == nil {
 		return ""
 	}
 
+	if IsQuarantined(key) {
+		return ""
+	}
+
 	url, err := storage.SignGet(key)
 	if err != nil {
 		return ""
@@ -221,6 +459,41 @@ func CompleteMultipartUpload(key string) error {
 	return errors.Wrap(storage.CompleteMultipartUpload(key), "failed to complete multipart upload")
 }
 
+func AbortMultipartUpload(key string, uploadID string) error {
+	if storage == nil {
+		return errors.New("storage not initialized")
+	}
+
+	return errors.Wrap(storage.AbortMultipartUpload(key, uploadID), "failed to abort multipart upload")
+}
+
+// RunAsyncMultipartCleanupLoop periodically aborts multipart uploads that
+// have not received any parts within storage.multipart_upload_ttl,
+// so abandoned uploads do not linger in the bucket.
+func RunAsyncMultipartCleanupLoop(ctx context.Context) {
+	go func() {
+		for {
+			expired := redis.GetExpiredMultipartUploads(time.Now().Add(-viper.GetDuration("storage.multipart_upload_ttl")))
+
+			for key, uploadID := range expired {
+				if uploadID != "" {
+					if err := AbortMultipartUpload(key, uploadID); err != nil {
+						log.Ctx(ctx).Warn().Err(err).Str("key", key).Msg("failed to abort expired multipart upload")
+					}
+				}
+
+				redis.ClearMultipartUpload(key)
+			}
+
+			if len(expired) > 0 {
+				log.Ctx(ctx).Info().Msgf("Aborted %d expired multipart uploads", len(expired))
+			}
+
+			time.Sleep(time.Minute)
+		}
+	}()
+}
+
 func CopyObjectFromOldBucket(key string) error {
 	// Ignored
 	return nil
@@ -253,6 +526,39 @@ func GetMod(modID string, name string, versionID string) (io.ReadCloser, error)
 	return Get(key)
 }
 
+// UploadModFile stores the whole file of a version at once, where CompleteUploadMultipartMod would have assembled it
+func UploadModFile(ctx context.Context, modID string, name string, versionID string, data io.ReadSeeker) (bool, string) {
+	if storage == nil {
+		return false, ""
+	}
+
+	filename := cleanModName(name) + "-" + versionID
+	key := fmt.Sprintf("/mods/%s/%s.smod", modID, filename)
+
+	if _, err := storage.Put(ctx, key, data); err != nil {
+		log.Err(err).Msg("failed to upload mod")
+		return false, ""
+	}
+
+	return true, fmt.Sprintf("/mods/%s/%s.smod", modID, EncodeName(filename))
+}
+
+// ReplaceMod overwrites the uploaded file of a version which has not been renamed yet
+func ReplaceMod(ctx context.Context, modID string, name string, versionID string, data io.ReadSeeker) bool {
+	if storage == nil {
+		return false
+	}
+
+	key := fmt.Sprintf("/mods/%s/%s.smod", modID, cleanModName(name)+"-"+versionID)
+
+	if _, err := storage.Put(ctx, key, data); err != nil {
+		log.Err(err).Msg("failed to replace version file")
+		return false
+	}
+
+	return true
+}
+
 func RenameVersion(ctx context.Context, modID string, name string, versionID string, version string) (bool, string) {
 	if storage == nil {
 		return false, ""
@@ -297,6 +603,79 @@ func DeleteMod(ctx context.Context, modID string, name string, versionID string)
 	return true
 }
 
+// DeleteVersionFile deletes a version file by its encoded key, as stored in the database
+func DeleteVersionFile(ctx context.Context, key string) bool {
+	if storage == nil {
+		return false
+	}
+
+	from := storage
+	if IsQuarantined(key) {
+		from = quarantine()
+	}
+
+	log.Info().Str("key", key).Msg("deleting version file")
+	if err := from.Delete(DecodeName(key)); err != nil {
+		log.Err(err).Msg("failed to delete version file")
+		return false
+	}
+
+	// Quarantined blobs keep their key, the file may be in the quarantine instead
+	if IsBlob(key) {
+		if err := quarantine().Delete(DecodeName(quarantinePrefix + key)); err != nil {
+			log.Err(err).Msg("failed to delete quarantined version file")
+			return false
+		}
+	}
+
+	return true
+}
+
+// healthCheckPrefix holds no files, so listing it is a single cheap request
+const healthCheckPrefix = "healthz/"
+
+// Ping checks that the storage can be listed, under a prefix which holds no files
+func Ping() error {
+	if storage == nil {
+		return errors.New("storage not initialized")
+	}
+
+	_, err := storage.List(healthCheckPrefix)
+	return errors.Wrap(err, "failed to list storage")
+}
+
+// ListFiles returns every object stored under the prefix.
+// Keys are returned in their decoded form, with a leading slash.
+func ListFiles(prefix string) ([]Object, error) {
+	if storage == nil {
+		return nil, errors.New("storage not initialized")
+	}
+
+	objects, err := storage.List(strings.TrimPrefix(prefix, "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list objects")
+	}
+
+	for i, object := range objects {
+		if object.Key != nil {
+			key := "/" + strings.TrimPrefix(*object.Key, "/")
+			objects[i].Key = &key
+		}
+	}
+
+	return objects, nil
+}
+
+// DeleteFile deletes an object by its decoded key, as returned by ListFiles
+func DeleteFile(ctx context.Context, key string) error {
+	if storage == nil {
+		return errors.New("storage not initialized")
+	}
+
+	log.Info().Str("key", key).Msg("deleting file")
+	return errors.Wrap(storage.Delete(key), "failed to delete file")
+}
+
 func DeleteModTarget(ctx context.Context, modID string, name string, versionID string, target string) bool {
 	if storage == nil {
 		return false
@@ -374,16 +753,41 @@ func EncodeName(name string) string {
 	return result
 }
 
-func SeparateModTarget(ctx context.Context, body []byte, modID, name, modVersion, target string) (bool, string, string, int64) {
-	zipReader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+// DecodeName reverses EncodeName
+func DecodeName(name string) string {
+	result := name
+	for k, v := range encodeMapping {
+		result = strings.ReplaceAll(result, v, k)
+	}
+	// Must be last
+	return strings.ReplaceAll(result, "%25", "%")
+}
+
+// SeparateModTarget extracts the files of a target from a multi-target archive into its own archive.
+// If storage.dedup is set, the archive is stored as a blob keyed by its hash, which the caller has to acquire.
+//
+// The source archive is read through body, usually a file spooled from storage, and the target archive
+// is streamed to a temporary file while being hashed, so neither archive is held in memory.
+func SeparateModTarget(ctx context.Context, body io.ReaderAt, size int64, modID, name, modVersion, target string) (bool, string, string, int64) {
+	zipReader, err := zip.NewReader(body, size)
 	if err != nil {
 		return false, "", "", 0
 	}
 
 	cleanName := cleanModName(name)
 
-	buf := new(bytes.Buffer)
-	zipWriter := zip.NewWriter(buf)
+	out, err := os.CreateTemp("", "smr-*")
+	if err != nil {
+		log.Err(err).Msg("failed creating " + target + " archive")
+		return false, "", "", 0
+	}
+	defer func() {
+		_ = out.Close()
+		_ = os.Remove(out.Name())
+	}()
+
+	hash := sha256.New()
+	zipWriter := zip.NewWriter(io.MultiWriter(out, hash))
 
 	for _, file := range zipReader.File {
 		if !strings.HasPrefix(file.Name, target+"/") {
@@ -402,47 +806,116 @@ func SeparateModTarget(ctx context.Context, body []byte, modID, name, modVersion
 		}
 	}
 
-	zipWriter.Close()
+	if err := zipWriter.Close(); err != nil {
+		log.Err(err).Msg("failed to finish " + target + " archive")
+		return false, "", "", 0
+	}
+
+	targetSize, err := out.Seek(0, io.SeekCurrent)
+	if err != nil {
+		log.Err(err).Msg("failed to get " + target + " archive size")
+		return false, "", "", 0
+	}
+
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		log.Err(err).Msg("failed to rewind " + target + " archive")
+		return false, "", "", 0
+	}
+
+	targetHash := hex.EncodeToString(hash.Sum(nil))
+
+	if viper.GetBool("storage.dedup") {
+		key := blobKey(targetHash)
+
+		// Identical archives are only stored once
+		if _, err := storage.Meta(key); err == nil {
+			return true, key, targetHash, targetSize
+		}
+
+		if _, err := storage.Put(ctx, key, out); err != nil {
+			log.Err(err).Msg("failed to save " + target + " archive")
+			return false, "", "", 0
+		}
+
+		return true, key, targetHash, targetSize
+	}
 
 	filename := cleanName + "-" + target + "-" + modVersion
 	key := fmt.Sprintf("/mods/%s/%s.smod", modID, filename)
 
-	_, err = storage.Put(ctx, key, bytes.NewReader(buf.Bytes()))
+	_, err = storage.Put(ctx, key, out)
 	if err != nil {
 		log.Err(err).Msg("failed to save " + target + " archive")
 		return false, "", "", 0
 	}
 
-	hash := sha256.New()
-	hash.Write(buf.Bytes())
-
 	encodedKey := fmt.Sprintf("/mods/%s/%s.smod", modID, EncodeName(filename))
-	return true, encodedKey, hex.EncodeToString(hash.Sum(nil)), int64(buf.Len())
+	return true, encodedKey, targetHash, targetSize
+}
+
+const blobPrefix = "/blobs"
+
+// blobKey returns the content addressed key of an archive, from its hex encoded SHA256 hash
+func blobKey(hash string) string {
+	return fmt.Sprintf("%s/%s/%s.smod", blobPrefix, hash[:2], hash)
+}
+
+// IsBlob returns whether the key is a content addressed file, which may be shared by several versions.
+// Blobs keep their key, and must only be deleted once no version references them.
+func IsBlob(key string) bool {
+	return strings.HasPrefix(key, blobPrefix+"/")
 }
 
+// UploadModTargetPatch stores a patch between two versions of a target, and returns its encoded key
+func UploadModTargetPatch(ctx context.Context, modID, name, fromVersion, modVersion, target string, data []byte) (bool, string) {
+	if storage == nil {
+		return false, ""
+	}
+
+	filename := cleanModName(name) + "-" + target + "-" + fromVersion + "-" + modVersion
+	key := fmt.Sprintf("/mods/%s/patches/%s.smodpatch", modID, filename)
+
+	if _, err := storage.Put(ctx, key, bytes.NewReader(data)); err != nil {
+		log.Err(err).Msg("failed to save " + target + " patch")
+		return false, ""
+	}
+
+	return true, fmt.Sprintf("/mods/%s/patches/%s.smodpatch", modID, EncodeName(filename))
+}
+
+// UploadVersionDistribution stores a torrent or metalink of a version, and returns its encoded key
+func UploadVersionDistribution(ctx context.Context, modID, name, versionID, extension string, data []byte) (bool, string) {
+	if storage == nil {
+		return false, ""
+	}
+
+	filename := cleanModName(name) + "-" + versionID
+	key := fmt.Sprintf("/mods/%s/torrents/%s.%s", modID, filename, extension)
+
+	if _, err := storage.Put(ctx, key, bytes.NewReader(data)); err != nil {
+		log.Err(err).Msg("failed to save version " + extension)
+		return false, ""
+	}
+
+	return true, fmt.Sprintf("/mods/%s/torrents/%s.%s", modID, EncodeName(filename), extension)
+}
+
+// copyModFileToArchZip copies an entry to another archive without recompressing it
 func copyModFileToArchZip(file *zip.File, zipWriter *zip.Writer, newName string) error {
 	fileHeader := file.FileHeader
 	fileHeader.Name = newName
 
-	zipFile, err := zipWriter.CreateHeader(&fileHeader)
+	zipFile, err := zipWriter.CreateRaw(&fileHeader)
 	if err != nil {
 		return errors.Wrap(err, "failed to create file")
 	}
 
-	rawFile, err := file.Open()
+	rawFile, err := file.OpenRaw()
 	if err != nil {
 		return errors.Wrap(err, "failed to open file")
 	}
-	defer rawFile.Close()
-
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(rawFile)
-
-	if err != nil {
-		return errors.Wrap(err, "failed to read file")
-	}
 
-	_, err = zipFile.Write(buf.Bytes())
+	_, err = io.Copy(zipFile, rawFile)
 
 	if err != nil {
 		return errors.Wrap(err, "failed to write file")
@@ -472,6 +945,33 @@ func DeleteOldModAssets(modReference string, before time.Time) {
 	}
 }
 
+// DeleteModFiles deletes the logos and extracted assets of a mod. Version files are deleted along with their versions.
+func DeleteModFiles(ctx context.Context, modID string, modReference string) error {
+	if storage == nil {
+		return errors.New("storage not initialized")
+	}
+
+	for _, prefix := range []string{fmt.Sprintf("images/mods/%s/", modID), fmt.Sprintf("assets/mods/%s/", modReference)} {
+		list, err := storage.List(prefix)
+		if err != nil {
+			return errors.Wrap(err, "failed to list "+prefix)
+		}
+
+		for _, object := range list {
+			if object.Key == nil {
+				continue
+			}
+
+			log.Info().Str("key", *object.Key).Msg("deleting mod file")
+			if err := storage.Delete(*object.Key); err != nil {
+				return errors.Wrap(err, "failed to delete "+*object.Key)
+			}
+		}
+	}
+
+	return nil
+}
+
 func UploadModAsset(ctx context.Context, modReference string, path string, data []byte) {
 	if storage == nil {
 		return
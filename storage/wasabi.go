@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"time"
 
@@ -18,6 +19,15 @@ type Wasabi struct {
 	Bucket   *string
 }
 
+func init() {
+	RegisterDriver("wasabi", func(ctx context.Context, config Config) Storage {
+		if wasabi := initializeWasabi(ctx, config); wasabi != nil {
+			return wasabi
+		}
+		return nil
+	})
+}
+
 func initializeWasabi(ctx context.Context, config Config) *Wasabi {
 	bucket := aws.String(config.Bucket)
 
@@ -54,6 +64,19 @@ func (wasabi *Wasabi) Get(key string) (io.ReadCloser, error) {
 	return obj.Body, nil
 }
 
+func (wasabi *Wasabi) GetRange(key string, offset int64, length int64) (io.ReadCloser, error) {
+	object, err := wasabi.S3Client.GetObject(&s3.GetObjectInput{
+		Bucket: wasabi.Bucket,
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object range")
+	}
+
+	return object.Body, nil
+}
+
 func (wasabi *Wasabi) Put(ctx context.Context, key string, body io.ReadSeeker) (string, error) {
 	_, err := wasabi.S3Client.PutObject(&s3.PutObjectInput{
 		Body:   body,
@@ -103,10 +126,18 @@ func (wasabi *Wasabi) UploadPart(key string, part int64, data io.ReadSeeker) err
 	return errors.New("Unsupported")
 }
 
+func (wasabi *Wasabi) SignUploadPart(key string, part int64, size int64, expiry time.Duration) (string, error) {
+	return "", errors.New("Unsupported")
+}
+
 func (wasabi *Wasabi) CompleteMultipartUpload(key string) error {
 	return errors.New("Unsupported")
 }
 
+func (wasabi *Wasabi) AbortMultipartUpload(key string, uploadID string) error {
+	return errors.New("Unsupported")
+}
+
 func (wasabi *Wasabi) Rename(from string, to string) error {
 	return errors.New("Unsupported")
 }
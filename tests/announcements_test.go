@@ -8,11 +8,9 @@ import (
 	"github.com/satisfactorymodding/smr-api/config"
 	"github.com/satisfactorymodding/smr-api/db/postgres"
 	"github.com/satisfactorymodding/smr-api/generated"
-	"github.com/satisfactorymodding/smr-api/migrations"
 )
 
 func init() {
-	migrations.SetMigrationDir("../migrations")
 	config.SetConfigDir("../")
 	postgres.EnableDebug()
 }
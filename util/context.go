@@ -1,8 +1,37 @@
 package util
 
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
 type (
 	ContextHeader    struct{}
 	ContextRequest   struct{}
 	ContextResponse  struct{}
 	ContextValidator struct{}
 )
+
+// RequestIP returns the IP of the client of the request a context belongs to, or an empty string outside of requests
+func RequestIP(ctx context.Context) string {
+	if header, ok := ctx.Value(ContextHeader{}).(http.Header); ok {
+		if ip := header.Get("X-Forwarded-For"); ip != "" {
+			return strings.Split(ip, ", ")[0]
+		}
+
+		if ip := header.Get("X-Real-IP"); ip != "" {
+			return ip
+		}
+	}
+
+	request, ok := ctx.Value(ContextRequest{}).(*http.Request)
+	if !ok {
+		return ""
+	}
+
+	ra, _, _ := net.SplitHostPort(request.RemoteAddr)
+
+	return ra
+}
@@ -0,0 +1,113 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/chai2010/webp"
+	"github.com/pkg/errors"
+)
+
+// ImageVariant is an encoding of an image resized to fit in a square of Size pixels
+type ImageVariant struct {
+	Format string
+	Data   []byte
+	Size   int
+}
+
+// variantEncoders are the formats variants are generated in.
+// AVIF is not listed, as no encoder is available, clients requesting it are served WebP.
+var variantEncoders = map[string]func(image.Image) ([]byte, error){
+	"webp": func(img image.Image) ([]byte, error) {
+		result := new(bytes.Buffer)
+		if err := webp.Encode(result, img, &webp.Options{Quality: 85}); err != nil {
+			return nil, errors.Wrap(err, "error encoding webp variant")
+		}
+		return result.Bytes(), nil
+	},
+}
+
+// GenerateImageVariants resizes an image to each of the sizes, in every variant format.
+// Images are never upscaled, so sizes larger than the image produce the same variant. Animated images keep their first frame.
+func GenerateImageVariants(ctx context.Context, imageAsBytes []byte, sizes []int) ([]ImageVariant, error) {
+	imageData, _, err := image.Decode(bytes.NewReader(imageAsBytes))
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding image")
+	}
+
+	variants := make([]ImageVariant, 0, len(sizes)*len(variantEncoders))
+	for _, size := range sizes {
+		if size <= 0 {
+			continue
+		}
+
+		resized := Resize(imageData, size)
+
+		for format, encode := range variantEncoders {
+			data, err := encode(resized)
+			if err != nil {
+				return nil, err
+			}
+
+			variants = append(variants, ImageVariant{
+				Format: format,
+				Data:   data,
+				Size:   size,
+			})
+		}
+	}
+
+	return variants, nil
+}
+
+// Resize scales an image down to fit in a square of size pixels, keeping its aspect ratio.
+// Each pixel is the average of the source pixels it covers.
+func Resize(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width <= size && height <= size {
+		return src
+	}
+
+	scale := float64(size) / math.Max(float64(width), float64(height))
+	targetWidth := int(math.Max(1, math.Round(float64(width)*scale)))
+	targetHeight := int(math.Max(1, math.Round(float64(height)*scale)))
+
+	dst := image.NewNRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+
+	for y := 0; y < targetHeight; y++ {
+		fromY := bounds.Min.Y + y*height/targetHeight
+		toY := bounds.Min.Y + (y+1)*height/targetHeight
+
+		for x := 0; x < targetWidth; x++ {
+			fromX := bounds.Min.X + x*width/targetWidth
+			toX := bounds.Min.X + (x+1)*width/targetWidth
+
+			var r, g, b, a, count uint64
+			for sy := fromY; sy < toY; sy++ {
+				for sx := fromX; sx < toX; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					b += uint64(pb)
+					a += uint64(pa)
+					count++
+				}
+			}
+
+			// Colors are premultiplied, so translucent pixels are weighted by their alpha
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,38 @@
+package converter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestResize(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			if x < 200 {
+				src.Set(x, y, color.NRGBA{R: 255, A: 255})
+			} else {
+				src.Set(x, y, color.NRGBA{B: 255, A: 255})
+			}
+		}
+	}
+
+	resized := Resize(src, 100)
+
+	if bounds := resized.Bounds(); bounds.Dx() != 100 || bounds.Dy() != 50 {
+		t.Fatalf("expected 100x50, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	if r, _, b, _ := resized.At(10, 10).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("expected left half to stay red")
+	}
+
+	if r, _, b, _ := resized.At(90, 10).RGBA(); r != 0 || b>>8 != 255 {
+		t.Errorf("expected right half to stay blue")
+	}
+
+	if Resize(src, 1000) != image.Image(src) {
+		t.Errorf("expected images smaller than the size to be returned as-is")
+	}
+}
@@ -0,0 +1,226 @@
+// Package delta implements a simple binary delta format used to distribute
+// patches between consecutive mod versions.
+//
+// A patch is made of copy operations, referencing ranges of the base file,
+// and insert operations, carrying literal data. Matching ranges are found
+// with an rsync-style rolling checksum over fixed size blocks of the base.
+package delta
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	blockSize = 64
+
+	// Limits how many blocks sharing a weak checksum are compared, to bound the work on repetitive data
+	maxCandidates = 8
+
+	opCopy   byte = 0
+	opInsert byte = 1
+)
+
+var magic = []byte("SMRD\x01")
+
+// Diff computes a patch which transforms base into target
+func Diff(base []byte, target []byte) []byte {
+	baseHash := sha256.Sum256(base)
+
+	out := new(bytes.Buffer)
+	out.Write(magic)
+	out.Write(baseHash[:])
+	writeUvarint(out, uint64(len(target)))
+
+	if len(base) < blockSize || len(target) < blockSize {
+		writeInsert(out, target)
+		return out.Bytes()
+	}
+
+	index := make(map[uint32][]int)
+	for offset := 0; offset+blockSize <= len(base); offset += blockSize {
+		sum := newChecksum(base[offset : offset+blockSize]).value()
+		if len(index[sum]) < maxCandidates {
+			index[sum] = append(index[sum], offset)
+		}
+	}
+
+	pending := 0
+	i := 0
+	sum := newChecksum(target[:blockSize])
+
+	for i+blockSize <= len(target) {
+		offset, found := findBlock(index[sum.value()], base, target[i:i+blockSize])
+		if !found {
+			if i+blockSize == len(target) {
+				break
+			}
+			sum.roll(target[i], target[i+blockSize])
+			i++
+			continue
+		}
+
+		start := i
+		baseStart := offset
+		for start > pending && baseStart > 0 && base[baseStart-1] == target[start-1] {
+			start--
+			baseStart--
+		}
+
+		end := i + blockSize
+		baseEnd := offset + blockSize
+		for end < len(target) && baseEnd < len(base) && base[baseEnd] == target[end] {
+			end++
+			baseEnd++
+		}
+
+		writeInsert(out, target[pending:start])
+		writeCopy(out, baseStart, end-start)
+
+		pending = end
+		i = end
+		if i+blockSize <= len(target) {
+			sum = newChecksum(target[i : i+blockSize])
+		}
+	}
+
+	writeInsert(out, target[pending:])
+
+	return out.Bytes()
+}
+
+// Patch applies a patch created by Diff to base, and returns the resulting target
+func Patch(base []byte, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, magic) {
+		return nil, errors.New("invalid patch header")
+	}
+
+	reader := bytes.NewReader(patch[len(magic):])
+
+	var baseHash [sha256.Size]byte
+	if _, err := io.ReadFull(reader, baseHash[:]); err != nil {
+		return nil, errors.Wrap(err, "failed reading base hash")
+	}
+
+	if sha256.Sum256(base) != baseHash {
+		return nil, errors.New("patch does not apply to the provided base")
+	}
+
+	targetSize, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading target size")
+	}
+
+	// The declared size is untrusted, so it only bounds the preallocation
+	capacity := targetSize
+	if limit := uint64(len(base) + len(patch)); capacity > limit {
+		capacity = limit
+	}
+
+	target := make([]byte, 0, capacity)
+
+	for reader.Len() > 0 {
+		op, _ := reader.ReadByte()
+		switch op {
+		case opCopy:
+			offset, err := binary.ReadUvarint(reader)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed reading copy offset")
+			}
+
+			length, err := binary.ReadUvarint(reader)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed reading copy length")
+			}
+
+			if offset > uint64(len(base)) || length > uint64(len(base))-offset {
+				return nil, errors.New("copy out of bounds of base")
+			}
+
+			target = append(target, base[offset:offset+length]...)
+		case opInsert:
+			length, err := binary.ReadUvarint(reader)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed reading insert length")
+			}
+
+			if length > uint64(reader.Len()) {
+				return nil, errors.New("insert out of bounds of patch")
+			}
+
+			data := make([]byte, length)
+			_, _ = reader.Read(data)
+			target = append(target, data...)
+		default:
+			return nil, errors.Errorf("unknown patch operation %d", op)
+		}
+
+		if uint64(len(target)) > targetSize {
+			return nil, errors.New("patch produces more data than declared")
+		}
+	}
+
+	if uint64(len(target)) != targetSize {
+		return nil, errors.New("patch produces less data than declared")
+	}
+
+	return target, nil
+}
+
+func findBlock(candidates []int, base []byte, block []byte) (int, bool) {
+	for _, offset := range candidates {
+		if bytes.Equal(base[offset:offset+blockSize], block) {
+			return offset, true
+		}
+	}
+	return 0, false
+}
+
+func writeUvarint(out *bytes.Buffer, value uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	out.Write(buf[:binary.PutUvarint(buf[:], value)])
+}
+
+func writeCopy(out *bytes.Buffer, offset int, length int) {
+	out.WriteByte(opCopy)
+	writeUvarint(out, uint64(offset))
+	writeUvarint(out, uint64(length))
+}
+
+func writeInsert(out *bytes.Buffer, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	out.WriteByte(opInsert)
+	writeUvarint(out, uint64(len(data)))
+	out.Write(data)
+}
+
+// checksum is the rolling weak checksum used by rsync
+type checksum struct {
+	a uint32
+	b uint32
+}
+
+func newChecksum(block []byte) checksum {
+	var sum checksum
+	for i, c := range block {
+		sum.a += uint32(c)
+		sum.b += uint32(len(block)-i) * uint32(c)
+	}
+	return sum
+}
+
+func (c *checksum) roll(out byte, in byte) {
+	c.a = c.a - uint32(out) + uint32(in)
+	c.b = c.b - blockSize*uint32(out) + c.a
+}
+
+func (c checksum) value() uint32 {
+	return (c.a & 0xffff) | (c.b << 16)
+}
@@ -0,0 +1,52 @@
+package delta
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestDiffPatch(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	base := make([]byte, 64*1024)
+	random.Read(base)
+
+	target := make([]byte, 0, len(base))
+	target = append(target, base[:20000]...)
+	target = append(target, []byte("some inserted bytes")...)
+	target = append(target, base[20100:50000]...)
+	target = append(target, base[:1000]...)
+	target = append(target, base[60000:]...)
+
+	cases := map[string][2][]byte{
+		"modified":     {base, target},
+		"identical":    {base, base},
+		"empty base":   {nil, target},
+		"empty target": {base, nil},
+		"short":        {[]byte("abc"), []byte("abcd")},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			patch := Diff(c[0], c[1])
+
+			result, err := Patch(c[0], patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(result, c[1]) {
+				t.Fatal("patched result does not match the target")
+			}
+		})
+	}
+
+	if patch := Diff(base, target); len(patch) > 1024 {
+		t.Errorf("expected a small patch, got %d bytes", len(patch))
+	}
+
+	if _, err := Patch(target, Diff(base, target)); err == nil {
+		t.Error("expected patch to be rejected for the wrong base")
+	}
+}
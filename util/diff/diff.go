@@ -0,0 +1,58 @@
+// Package diff finds the fields of an object which changed between two of its states, for the audit log.
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Change is the value of a field before and after it changed
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Snapshot captures the fields of an object as they are serialized to JSON,
+// so that the object can be modified before being compared with Changes
+func Snapshot(object interface{}) map[string]interface{} {
+	var fields map[string]interface{}
+
+	serialized, err := json.Marshal(object)
+	if err != nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(serialized, &fields); err != nil {
+		return nil
+	}
+
+	return fields
+}
+
+// Changes returns the fields whose value differs between two snapshots, except the ignored ones
+func Changes(before map[string]interface{}, after map[string]interface{}, ignored ...string) map[string]Change {
+	skip := make(map[string]bool, len(ignored))
+	for _, field := range ignored {
+		skip[field] = true
+	}
+
+	changes := make(map[string]Change)
+
+	for field, value := range after {
+		if skip[field] {
+			continue
+		}
+
+		if previous, ok := before[field]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[field] = Change{Before: before[field], After: value}
+		}
+	}
+
+	for field, value := range before {
+		if _, ok := after[field]; !ok && !skip[field] {
+			changes[field] = Change{Before: value}
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,39 @@
+package diff
+
+import (
+	"testing"
+)
+
+type object struct {
+	Name      string
+	Tags      []string
+	Hidden    bool
+	UpdatedAt int
+}
+
+func TestChanges(t *testing.T) {
+	value := object{Name: "Mod", Tags: []string{"a"}, UpdatedAt: 1}
+	before := Snapshot(value)
+
+	value.Tags = append(value.Tags, "b")
+	value.Hidden = true
+	value.UpdatedAt = 2
+
+	changes := Changes(before, Snapshot(value), "UpdatedAt")
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d: %v", len(changes), changes)
+	}
+
+	if change, ok := changes["Hidden"]; !ok || change.Before != false || change.After != true {
+		t.Errorf("unexpected change of Hidden: %v", change)
+	}
+
+	if _, ok := changes["Tags"]; !ok {
+		t.Error("expected Tags to have changed")
+	}
+
+	if len(Changes(before, before)) != 0 {
+		t.Error("expected identical snapshots to have no changes")
+	}
+}
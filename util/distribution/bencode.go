@@ -0,0 +1,70 @@
+package distribution
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// bencode writes a value in the encoding used by torrent files.
+// Supported values are strings, byte slices, integers, lists and maps with string keys.
+func bencode(out *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		out.WriteString(strconv.Itoa(len(v)))
+		out.WriteByte(':')
+		out.WriteString(v)
+	case []byte:
+		out.WriteString(strconv.Itoa(len(v)))
+		out.WriteByte(':')
+		out.Write(v)
+	case int:
+		out.WriteByte('i')
+		out.WriteString(strconv.Itoa(v))
+		out.WriteByte('e')
+	case int64:
+		out.WriteByte('i')
+		out.WriteString(strconv.FormatInt(v, 10))
+		out.WriteByte('e')
+	case []string:
+		out.WriteByte('l')
+		for _, item := range v {
+			if err := bencode(out, item); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('e')
+	case []interface{}:
+		out.WriteByte('l')
+		for _, item := range v {
+			if err := bencode(out, item); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('e')
+	case map[string]interface{}:
+		// Keys must be sorted as raw strings
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		out.WriteByte('d')
+		for _, key := range keys {
+			if err := bencode(out, key); err != nil {
+				return err
+			}
+			if err := bencode(out, v[key]); err != nil {
+				return errors.Wrap(err, "failed to encode "+key)
+			}
+		}
+		out.WriteByte('e')
+	default:
+		return errors.Errorf("unsupported bencode type %T", value)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package distribution
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Metalink is a RFC 5854 metalink document
+type Metalink struct {
+	XMLName   xml.Name       `xml:"urn:ietf:params:xml:ns:metalink metalink"`
+	Published string         `xml:"published,omitempty"`
+	Generator string         `xml:"generator,omitempty"`
+	Files     []MetalinkFile `xml:"file"`
+}
+
+type MetalinkFile struct {
+	Name     string            `xml:"name,attr"`
+	Size     int64             `xml:"size"`
+	Hashes   []MetalinkHash    `xml:"hash"`
+	URLs     []MetalinkURL     `xml:"url"`
+	MetaURLs []MetalinkMetaURL `xml:"metaurl"`
+}
+
+type MetalinkHash struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type MetalinkURL struct {
+	Priority int    `xml:"priority,attr,omitempty"`
+	Location string `xml:"location,attr,omitempty"`
+	URL      string `xml:",chardata"`
+}
+
+type MetalinkMetaURL struct {
+	MediaType string `xml:"mediatype,attr"`
+	URL       string `xml:",chardata"`
+}
+
+// NewMetalink creates a metalink document with the publication date set to now
+func NewMetalink(generator string, files ...MetalinkFile) *Metalink {
+	return &Metalink{
+		Published: time.Now().UTC().Format(time.RFC3339),
+		Generator: generator,
+		Files:     files,
+	}
+}
+
+// Encode returns the .meta4 document
+func (m *Metalink) Encode() ([]byte, error) {
+	data, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode metalink")
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,138 @@
+// Package distribution generates torrent files and metalink documents,
+// offering peer-to-peer and multi-source downloads of large version files.
+package distribution
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	minPieceLength = 256 * 1024
+	maxPieceLength = 16 * 1024 * 1024
+
+	// Piece lengths are picked to keep torrents around this many pieces
+	targetPieces = 1500
+)
+
+// Torrent is a single file torrent
+type Torrent struct {
+	Name        string
+	Length      int64
+	PieceLength int64
+	Pieces      []byte
+
+	Trackers     []string
+	WebSeeds     []string
+	Comment      string
+	CreatedBy    string
+	CreationDate time.Time
+}
+
+// PieceLength returns the power of two piece length suited to a file of the provided size
+func PieceLength(size int64) int64 {
+	length := int64(minPieceLength)
+	for length < maxPieceLength && size/length > targetPieces {
+		length *= 2
+	}
+	return length
+}
+
+// NewTorrent hashes the content of the reader into the pieces of a torrent
+func NewTorrent(reader io.Reader, name string, pieceLength int64) (*Torrent, error) {
+	if pieceLength <= 0 {
+		return nil, errors.New("invalid piece length")
+	}
+
+	torrent := &Torrent{
+		Name:         name,
+		PieceLength:  pieceLength,
+		CreationDate: time.Now(),
+	}
+
+	piece := make([]byte, pieceLength)
+	for {
+		n, err := io.ReadFull(reader, piece)
+		if n > 0 {
+			sum := sha1.Sum(piece[:n])
+			torrent.Pieces = append(torrent.Pieces, sum[:]...)
+			torrent.Length += int64(n)
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read file")
+		}
+	}
+
+	return torrent, nil
+}
+
+func (t *Torrent) info() map[string]interface{} {
+	return map[string]interface{}{
+		"name":         t.Name,
+		"length":       t.Length,
+		"piece length": t.PieceLength,
+		"pieces":       t.Pieces,
+	}
+}
+
+// InfoHash returns the hex encoded SHA1 hash identifying the torrent
+func (t *Torrent) InfoHash() (string, error) {
+	out := new(bytes.Buffer)
+	if err := bencode(out, t.info()); err != nil {
+		return "", err
+	}
+
+	sum := sha1.Sum(out.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Encode returns the .torrent file.
+// Web seeds are written as a BEP 19 url-list, trackers as a BEP 12 announce-list.
+func (t *Torrent) Encode() ([]byte, error) {
+	torrent := map[string]interface{}{
+		"info": t.info(),
+	}
+
+	if len(t.Trackers) > 0 {
+		torrent["announce"] = t.Trackers[0]
+
+		tiers := make([]interface{}, len(t.Trackers))
+		for i, tracker := range t.Trackers {
+			tiers[i] = []string{tracker}
+		}
+		torrent["announce-list"] = tiers
+	}
+
+	if len(t.WebSeeds) > 0 {
+		torrent["url-list"] = t.WebSeeds
+	}
+
+	if t.Comment != "" {
+		torrent["comment"] = t.Comment
+	}
+
+	if t.CreatedBy != "" {
+		torrent["created by"] = t.CreatedBy
+	}
+
+	if !t.CreationDate.IsZero() {
+		torrent["creation date"] = t.CreationDate.Unix()
+	}
+
+	out := new(bytes.Buffer)
+	if err := bencode(out, torrent); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,70 @@
+package distribution
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"testing"
+)
+
+func TestBencode(t *testing.T) {
+	out := new(bytes.Buffer)
+	err := bencode(out, map[string]interface{}{
+		"spam":   []string{"a", "b"},
+		"count":  int64(42),
+		"binary": []byte{0, 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "d6:binary2:\x00\x015:counti42e4:spaml1:a1:bee"
+	if out.String() != expected {
+		t.Fatalf("unexpected encoding %q, expected %q", out.String(), expected)
+	}
+}
+
+func TestNewTorrent(t *testing.T) {
+	data := bytes.Repeat([]byte("smr"), 1000)
+
+	torrent, err := NewTorrent(bytes.NewReader(data), "mod.smod", 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if torrent.Length != int64(len(data)) {
+		t.Fatalf("unexpected length %d", torrent.Length)
+	}
+
+	if len(torrent.Pieces) != 3*sha1.Size {
+		t.Fatalf("expected 3 pieces, got %d bytes of hashes", len(torrent.Pieces))
+	}
+
+	last := sha1.Sum(data[2048:])
+	if !bytes.Equal(torrent.Pieces[2*sha1.Size:], last[:]) {
+		t.Fatal("last piece hash does not match")
+	}
+
+	torrent.WebSeeds = []string{"https://cdn.example.com/mod.smod"}
+	encoded, err := torrent.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(encoded, []byte("8:url-listl32:https://cdn.example.com/mod.smode")) {
+		t.Fatal("web seed missing from torrent")
+	}
+}
+
+func TestPieceLength(t *testing.T) {
+	if PieceLength(1024) != minPieceLength {
+		t.Fatal("small files should use the minimum piece length")
+	}
+
+	if PieceLength(1<<40) != maxPieceLength {
+		t.Fatal("huge files should use the maximum piece length")
+	}
+
+	if length := PieceLength(1 << 30); (1<<30)/length > targetPieces {
+		t.Fatalf("piece length %d produces too many pieces", length)
+	}
+}
@@ -0,0 +1,107 @@
+// Package feed renders RSS 2.0 feeds
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Feed is a channel of items, newest first
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	// Self is the URL the feed itself is served from
+	Self  string
+	Items []Item
+}
+
+// Item is an entry of a feed, identified by its link
+type Item struct {
+	Title       string
+	Link        string
+	Description string
+	Published   time.Time
+}
+
+type rss struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Atom    string   `xml:"xmlns:atom,attr"`
+	Channel channel  `xml:"channel"`
+}
+
+type channel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          *atomLink `xml:"atom:link,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []item    `xml:"item"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type item struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description,omitempty"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+}
+
+// Updated returns when the newest item of the feed was published, or zero if it has none
+func (f Feed) Updated() time.Time {
+	var updated time.Time
+	for _, i := range f.Items {
+		if i.Published.After(updated) {
+			updated = i.Published
+		}
+	}
+	return updated
+}
+
+// RenderRSS renders the feed as RSS 2.0
+func (f Feed) RenderRSS() ([]byte, error) {
+	document := rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: channel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Description: f.Description,
+			Items:       make([]item, len(f.Items)),
+		},
+	}
+
+	if f.Self != "" {
+		document.Channel.Self = &atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"}
+	}
+
+	if updated := f.Updated(); !updated.IsZero() {
+		document.Channel.LastBuildDate = updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for n, i := range f.Items {
+		document.Channel.Items[n] = item{
+			Title:       i.Title,
+			Link:        i.Link,
+			Description: i.Description,
+			GUID:        i.Link,
+			PubDate:     i.Published.UTC().Format(time.RFC1123Z),
+		}
+	}
+
+	out, err := xml.Marshal(document)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render feed")
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
@@ -0,0 +1,37 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderRSS(t *testing.T) {
+	feed := Feed{
+		Title:       "New Mods",
+		Link:        "https://ficsit.app",
+		Description: "The newest mods",
+		Self:        "https://api.ficsit.app/v1/feeds/mods.rss",
+		Items: []Item{
+			{Title: "Example <Mod>", Link: "https://ficsit.app/mod/Example", Published: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+			{Title: "Older", Link: "https://ficsit.app/mod/Older", Published: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)},
+		},
+	}
+
+	out, err := feed.RenderRSS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document := string(out)
+	for _, expected := range []string{
+		`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">`,
+		`<atom:link href="https://api.ficsit.app/v1/feeds/mods.rss" rel="self" type="application/rss+xml"></atom:link>`,
+		`<lastBuildDate>Tue, 02 Jan 2024 03:04:05 +0000</lastBuildDate>`,
+		`<item><title>Example &lt;Mod&gt;</title><link>https://ficsit.app/mod/Example</link><guid>https://ficsit.app/mod/Example</guid>`,
+	} {
+		if !strings.Contains(document, expected) {
+			t.Errorf("expected feed to contain %s, got %s", expected, document)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package util
+
+import (
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ErrFileTooLarge is returned by BufferToTempFileLimited for readers longer than the limit
+var ErrFileTooLarge = errors.New("file too large")
+
+// BufferToTempFile copies the provided reader into a temporary file on disk,
+// so it can be accessed at random offsets (e.g. as a zip archive) without
+// holding the whole content in memory.
+//
+// The returned cleanup function closes and removes the file.
+func BufferToTempFile(reader io.Reader) (*os.File, int64, func(), error) {
+	file, err := os.CreateTemp("", "smr-*")
+	if err != nil {
+		return nil, 0, nil, errors.Wrap(err, "failed creating temp file")
+	}
+
+	cleanup := func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}
+
+	size, err := io.Copy(file, reader)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, errors.Wrap(err, "failed writing temp file")
+	}
+
+	return file, size, cleanup, nil
+}
+
+// BufferToTempFileLimited is BufferToTempFile, failing with ErrFileTooLarge as soon as
+// the reader yields more than limit bytes. A limit of 0 or less disables it.
+func BufferToTempFileLimited(reader io.Reader, limit int64) (*os.File, int64, func(), error) {
+	if limit <= 0 {
+		return BufferToTempFile(reader)
+	}
+
+	file, size, cleanup, err := BufferToTempFile(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	if size > limit {
+		cleanup()
+		return nil, 0, nil, ErrFileTooLarge
+	}
+
+	return file, size, cleanup, nil
+}
@@ -0,0 +1,139 @@
+// Package geoip resolves the country of client IP addresses from a CSV database of IP ranges,
+// in the "start,end,country" format of the DB-IP and IP2Location lite country databases.
+package geoip
+
+import (
+	"encoding/csv"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+type ipRange struct {
+	start   netip.Addr
+	end     netip.Addr
+	country string
+}
+
+// Database is a sorted list of non-overlapping IP ranges
+type Database struct {
+	ranges []ipRange
+}
+
+var database *Database
+
+// InitializeGeoIP loads the database configured in geoip.database, lookups return no country if it is not set
+func InitializeGeoIP() {
+	path := viper.GetString("geoip.database")
+	if path == "" {
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Err(err).Str("path", path).Msg("failed to open geoip database")
+		return
+	}
+	defer file.Close()
+
+	loaded, err := Load(file)
+	if err != nil {
+		log.Err(err).Str("path", path).Msg("failed to load geoip database")
+		return
+	}
+
+	database = loaded
+
+	log.Info().Int("ranges", len(loaded.ranges)).Msg("loaded geoip database")
+}
+
+// Load parses a CSV database of IP ranges
+func Load(reader io.Reader) (*Database, error) {
+	records := csv.NewReader(reader)
+	records.FieldsPerRecord = -1
+	records.ReuseRecord = true
+
+	db := &Database{}
+
+	for {
+		record, err := records.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read geoip database")
+		}
+
+		if len(record) < 3 {
+			continue
+		}
+
+		start, err := netip.ParseAddr(strings.TrimSpace(record[0]))
+		if err != nil {
+			continue
+		}
+		start = start.Unmap()
+
+		end, err := netip.ParseAddr(strings.TrimSpace(record[1]))
+		if err != nil {
+			continue
+		}
+		end = end.Unmap()
+
+		if start.Is4() != end.Is4() || end.Less(start) {
+			continue
+		}
+
+		db.ranges = append(db.ranges, ipRange{
+			start:   start,
+			end:     end,
+			country: strings.ToUpper(strings.TrimSpace(record[2])),
+		})
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return db.ranges[i].start.Less(db.ranges[j].start)
+	})
+
+	return db, nil
+}
+
+// Country returns the ISO country code of the IP address, or an empty string if it is unknown
+func (db *Database) Country(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	// First range starting after the address, the candidate is the one before it
+	index := sort.Search(len(db.ranges), func(i int) bool {
+		return addr.Less(db.ranges[i].start)
+	})
+
+	if index == 0 {
+		return ""
+	}
+
+	candidate := db.ranges[index-1]
+	if candidate.start.Is4() != addr.Is4() || candidate.end.Less(addr) {
+		return ""
+	}
+
+	return candidate.country
+}
+
+// Country returns the ISO country code of the IP address from the loaded database
+func Country(ip string) string {
+	if database == nil {
+		return ""
+	}
+
+	return database.Country(ip)
+}
@@ -0,0 +1,36 @@
+package geoip
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDatabase = `1.0.0.0,1.0.0.255,AU
+8.8.8.0,8.8.8.255,us
+2a00:1450::,2a00:1450:ffff:ffff:ffff:ffff:ffff:ffff,IE
+invalid,row,XX
+`
+
+func TestCountry(t *testing.T) {
+	db, err := Load(strings.NewReader(testDatabase))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"1.0.0.1":          "AU",
+		"8.8.8.8":          "US",
+		"::ffff:8.8.8.8":   "US",
+		"8.8.9.1":          "",
+		"0.0.0.1":          "",
+		"2a00:1450:4001::": "IE",
+		"2a01::":           "",
+		"not an ip":        "",
+	}
+
+	for ip, expected := range cases {
+		if country := db.Country(ip); country != expected {
+			t.Errorf("Country(%q) = %q, expected %q", ip, country, expected)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+// Package openapi converts the Swagger 2.0 document generated from the REST handler annotations to OpenAPI 3.0,
+// which most client generators expect nowadays.
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const Version = "3.0.3"
+
+// Keys of a Swagger 2.0 parameter which describe its value, and move into the schema of an OpenAPI 3.0 parameter
+var parameterSchemaKeys = []string{
+	"type", "format", "items", "collectionFormat", "default", "enum",
+	"maximum", "exclusiveMaximum", "minimum", "exclusiveMinimum",
+	"maxLength", "minLength", "pattern", "maxItems", "minItems", "uniqueItems", "multipleOf",
+}
+
+var operationMethods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// Convert converts a Swagger 2.0 JSON document to an OpenAPI 3.0 JSON document
+func Convert(swagger []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(swagger, &doc); err != nil {
+		return nil, errors.Wrap(err, "failed to parse swagger document")
+	}
+
+	if version, _ := doc["swagger"].(string); version != "2.0" {
+		return nil, errors.New("expected a swagger 2.0 document")
+	}
+
+	result := map[string]interface{}{
+		"openapi": Version,
+		"info":    doc["info"],
+		"paths":   map[string]interface{}{},
+	}
+
+	for _, key := range []string{"tags", "security", "externalDocs"} {
+		if value, ok := doc[key]; ok {
+			result[key] = value
+		}
+	}
+
+	if servers := convertServers(doc); servers != nil {
+		result["servers"] = servers
+	}
+
+	consumes := stringList(doc["consumes"], []string{"application/json"})
+	produces := stringList(doc["produces"], []string{"application/json"})
+
+	if paths, ok := doc["paths"].(map[string]interface{}); ok {
+		converted := make(map[string]interface{}, len(paths))
+		for path, item := range paths {
+			if item, ok := item.(map[string]interface{}); ok {
+				converted[path] = convertPathItem(item, consumes, produces)
+			}
+		}
+		result["paths"] = converted
+	}
+
+	components := map[string]interface{}{}
+
+	if definitions, ok := doc["definitions"].(map[string]interface{}); ok {
+		components["schemas"] = definitions
+	}
+
+	if securityDefinitions, ok := doc["securityDefinitions"].(map[string]interface{}); ok {
+		components["securitySchemes"] = convertSecuritySchemes(securityDefinitions)
+	}
+
+	if len(components) > 0 {
+		result["components"] = components
+	}
+
+	out, err := json.Marshal(rewriteRefs(result))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize openapi document")
+	}
+
+	return out, nil
+}
+
+func convertServers(doc map[string]interface{}) []interface{} {
+	host, _ := doc["host"].(string)
+	basePath, _ := doc["basePath"].(string)
+
+	if host == "" && basePath == "" {
+		return nil
+	}
+
+	if host == "" {
+		return []interface{}{map[string]interface{}{"url": basePath}}
+	}
+
+	servers := make([]interface{}, 0)
+	for _, scheme := range stringList(doc["schemes"], []string{"https"}) {
+		servers = append(servers, map[string]interface{}{"url": scheme + "://" + host + basePath})
+	}
+
+	return servers
+}
+
+func convertPathItem(item map[string]interface{}, consumes []string, produces []string) map[string]interface{} {
+	converted := make(map[string]interface{}, len(item))
+
+	for key, value := range item {
+		switch key {
+		case "parameters":
+			parameters, _ := convertParameters(value, consumes)
+			if len(parameters) > 0 {
+				converted[key] = parameters
+			}
+		default:
+			converted[key] = value
+		}
+	}
+
+	for _, method := range operationMethods {
+		if operation, ok := item[method].(map[string]interface{}); ok {
+			converted[method] = convertOperation(operation, consumes, produces)
+		}
+	}
+
+	return converted
+}
+
+func convertOperation(operation map[string]interface{}, consumes []string, produces []string) map[string]interface{} {
+	consumes = stringList(operation["consumes"], consumes)
+	produces = stringList(operation["produces"], produces)
+
+	converted := make(map[string]interface{}, len(operation))
+	for key, value := range operation {
+		switch key {
+		case "consumes", "produces", "schemes":
+			continue
+		case "parameters":
+			parameters, body := convertParameters(value, consumes)
+			if len(parameters) > 0 {
+				converted[key] = parameters
+			}
+			if body != nil {
+				converted["requestBody"] = body
+			}
+		case "responses":
+			converted[key] = convertResponses(value, produces)
+		default:
+			converted[key] = value
+		}
+	}
+
+	return converted
+}
+
+// convertParameters returns the non-body parameters, and the request body built from the body and form data parameters
+func convertParameters(value interface{}, consumes []string) ([]interface{}, map[string]interface{}) {
+	list, _ := value.([]interface{})
+
+	parameters := make([]interface{}, 0, len(list))
+	var body map[string]interface{}
+
+	formProperties := map[string]interface{}{}
+	var formRequired []interface{}
+	hasFile := false
+
+	for _, raw := range list {
+		parameter, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch parameter["in"] {
+		case "body":
+			content := map[string]interface{}{}
+			for _, mime := range consumes {
+				content[mime] = map[string]interface{}{"schema": parameter["schema"]}
+			}
+
+			body = map[string]interface{}{"content": content}
+			if description, ok := parameter["description"]; ok {
+				body["description"] = description
+			}
+			if required, ok := parameter["required"]; ok {
+				body["required"] = required
+			}
+		case "formData":
+			schema := parameterSchema(parameter)
+			if schema["type"] == "file" {
+				schema["type"] = "string"
+				schema["format"] = "binary"
+				hasFile = true
+			}
+			if description, ok := parameter["description"]; ok {
+				schema["description"] = description
+			}
+
+			name, _ := parameter["name"].(string)
+			formProperties[name] = schema
+			if required, _ := parameter["required"].(bool); required {
+				formRequired = append(formRequired, name)
+			}
+		default:
+			converted := make(map[string]interface{})
+			for key, value := range parameter {
+				if !isParameterSchemaKey(key) {
+					converted[key] = value
+				}
+			}
+
+			if _, ok := parameter["$ref"]; !ok {
+				converted["schema"] = parameterSchema(parameter)
+			}
+
+			parameters = append(parameters, converted)
+		}
+	}
+
+	if len(formProperties) > 0 {
+		mime := "application/x-www-form-urlencoded"
+		if hasFile {
+			mime = "multipart/form-data"
+		}
+
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": formProperties,
+		}
+		if formRequired != nil {
+			schema["required"] = formRequired
+		}
+
+		body = map[string]interface{}{
+			"content": map[string]interface{}{
+				mime: map[string]interface{}{"schema": schema},
+			},
+		}
+	}
+
+	return parameters, body
+}
+
+func parameterSchema(parameter map[string]interface{}) map[string]interface{} {
+	schema := make(map[string]interface{})
+
+	for _, key := range parameterSchemaKeys {
+		value, ok := parameter[key]
+		if !ok || key == "collectionFormat" {
+			continue
+		}
+
+		if key == "items" {
+			if items, ok := value.(map[string]interface{}); ok {
+				value = parameterSchema(items)
+			}
+		}
+
+		schema[key] = value
+	}
+
+	return schema
+}
+
+func isParameterSchemaKey(key string) bool {
+	for _, schemaKey := range parameterSchemaKeys {
+		if key == schemaKey {
+			return true
+		}
+	}
+	return false
+}
+
+func convertResponses(value interface{}, produces []string) map[string]interface{} {
+	responses, _ := value.(map[string]interface{})
+
+	converted := make(map[string]interface{}, len(responses))
+	for status, raw := range responses {
+		response, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		result := make(map[string]interface{}, len(response))
+		for key, value := range response {
+			switch key {
+			case "schema":
+				content := map[string]interface{}{}
+				for _, mime := range responseTypes(value, produces) {
+					content[mime] = map[string]interface{}{"schema": value}
+				}
+				result["content"] = content
+			case "headers":
+				result[key] = convertHeaders(value)
+			case "examples":
+				continue
+			default:
+				result[key] = value
+			}
+		}
+
+		// Descriptions are required by OpenAPI 3.0
+		if _, ok := result["description"]; !ok {
+			result["description"] = ""
+		}
+
+		converted[status] = result
+	}
+
+	return converted
+}
+
+// responseTypes returns the media types a response schema is served as. Plain strings are served as text.
+func responseTypes(schema interface{}, produces []string) []string {
+	if schema, ok := schema.(map[string]interface{}); ok && schema["type"] == "string" {
+		for _, mime := range produces {
+			if strings.HasPrefix(mime, "text/") {
+				return []string{mime}
+			}
+		}
+		return []string{"text/plain"}
+	}
+
+	return produces
+}
+
+func convertHeaders(value interface{}) map[string]interface{} {
+	headers, _ := value.(map[string]interface{})
+
+	converted := make(map[string]interface{}, len(headers))
+	for name, raw := range headers {
+		header, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		result := map[string]interface{}{"schema": parameterSchema(header)}
+		if description, ok := header["description"]; ok {
+			result["description"] = description
+		}
+		converted[name] = result
+	}
+
+	return converted
+}
+
+func convertSecuritySchemes(definitions map[string]interface{}) map[string]interface{} {
+	converted := make(map[string]interface{}, len(definitions))
+
+	for name, raw := range definitions {
+		definition, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch definition["type"] {
+		case "basic":
+			converted[name] = map[string]interface{}{"type": "http", "scheme": "basic"}
+		case "apiKey":
+			scheme := map[string]interface{}{"type": "apiKey", "name": definition["name"], "in": definition["in"]}
+			if description, ok := definition["description"]; ok {
+				scheme["description"] = description
+			}
+			converted[name] = scheme
+		default:
+			converted[name] = definition
+		}
+	}
+
+	return converted
+}
+
+// rewriteRefs points references to definitions at the component schemas
+func rewriteRefs(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if ref, ok := child.(string); key == "$ref" && ok {
+				v[key] = strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
+				continue
+			}
+
+			if key == "x-nullable" {
+				delete(v, key)
+				v["nullable"] = child
+				continue
+			}
+
+			v[key] = rewriteRefs(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = rewriteRefs(child)
+		}
+	}
+
+	return value
+}
+
+func stringList(value interface{}, def []string) []string {
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return def
+	}
+
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,126 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const swagger = `{
+	"swagger": "2.0",
+	"info": {"title": "API", "version": "1"},
+	"host": "api.example.com",
+	"basePath": "/v1",
+	"paths": {
+		"/mod/{modId}": {
+			"get": {
+				"produces": ["application/json"],
+				"parameters": [
+					{"type": "string", "name": "modId", "in": "path", "required": true},
+					{"type": "integer", "name": "limit", "in": "query", "minimum": 1}
+				],
+				"responses": {
+					"200": {"description": "OK", "schema": {"$ref": "#/definitions/nodes.Mod"}},
+					"404": {"description": "Not found", "schema": {"type": "string"}}
+				}
+			}
+		},
+		"/upload": {
+			"post": {
+				"parameters": [
+					{"type": "file", "name": "file", "in": "formData", "required": true},
+					{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/nodes.Mod"}}
+				],
+				"responses": {"200": {}}
+			}
+		}
+	},
+	"definitions": {
+		"nodes.Mod": {"type": "object", "properties": {"id": {"type": "string", "x-nullable": true}}}
+	}
+}`
+
+func TestConvert(t *testing.T) {
+	out, err := Convert([]byte(swagger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths map[string]map[string]struct {
+			Parameters []struct {
+				Name   string                 `json:"name"`
+				In     string                 `json:"in"`
+				Type   string                 `json:"type"`
+				Schema map[string]interface{} `json:"schema"`
+			} `json:"parameters"`
+			RequestBody *struct {
+				Content map[string]struct {
+					Schema struct {
+						Properties map[string]map[string]interface{} `json:"properties"`
+					} `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]struct {
+				Description *string `json:"description"`
+				Content     map[string]struct {
+					Schema map[string]interface{} `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.OpenAPI != Version {
+		t.Errorf("expected openapi %s, got %s", Version, doc.OpenAPI)
+	}
+
+	if len(doc.Servers) != 1 || doc.Servers[0].URL != "https://api.example.com/v1" {
+		t.Errorf("unexpected servers %+v", doc.Servers)
+	}
+
+	get := doc.Paths["/mod/{modId}"]["get"]
+	if len(get.Parameters) != 2 || get.Parameters[1].Type != "" || get.Parameters[1].Schema["type"] != "integer" || get.Parameters[1].Schema["minimum"] != float64(1) {
+		t.Errorf("expected the parameter types to move into schemas, got %+v", get.Parameters)
+	}
+
+	if ref := get.Responses["200"].Content["application/json"].Schema["$ref"]; ref != "#/components/schemas/nodes.Mod" {
+		t.Errorf("expected the reference to point at the component schemas, got %v", ref)
+	}
+
+	if _, ok := get.Responses["404"].Content["text/plain"]; !ok {
+		t.Errorf("expected string responses to be served as text, got %+v", get.Responses["404"].Content)
+	}
+
+	post := doc.Paths["/upload"]["post"]
+	if post.RequestBody == nil {
+		t.Fatal("expected a request body")
+	}
+
+	if file := post.RequestBody.Content["multipart/form-data"].Schema.Properties["file"]; file["format"] != "binary" {
+		t.Errorf("expected the file to become a binary string, got %+v", file)
+	}
+
+	if post.Responses["200"].Description == nil {
+		t.Error("expected responses to always have a description")
+	}
+
+	if nullable := doc.Components.Schemas["nodes.Mod"].Properties["id"]["nullable"]; nullable != true {
+		t.Errorf("expected x-nullable to become nullable, got %v", nullable)
+	}
+
+	if _, err := Convert([]byte(`{"openapi": "3.0.0"}`)); err == nil {
+		t.Error("expected non swagger 2.0 documents to be rejected")
+	}
+}
@@ -0,0 +1,19 @@
+package util
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SetRateLimitHeaders reports the state of a rate limit, with the reset in seconds
+func SetRateLimitHeaders(header http.Header, limit int64, remaining int64, reset time.Duration) {
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	header.Set("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
+	header.Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(reset.Seconds())), 10))
+}
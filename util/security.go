@@ -1,6 +1,7 @@
 package util
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
 	"time"
 
@@ -37,3 +38,13 @@ func GenerateUserToken() string {
 
 	return token
 }
+
+// CallerIdentity identifies the caller of a request by a hash of its authorization token, or by its address if it has none
+func CallerIdentity(authorization string, ip string) string {
+	if authorization == "" {
+		return "ip:" + ip
+	}
+
+	sum := sha256.Sum256([]byte(authorization))
+	return "token:" + hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,87 @@
+// Package sitemap renders sitemaps and sitemap indexes following the sitemaps.org protocol
+package sitemap
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// MaxURLs is the most URLs a single sitemap may contain
+const MaxURLs = 50000
+
+// URL is a page listed by a sitemap, or a sitemap listed by an index
+type URL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URLs    []URL    `xml:"url"`
+}
+
+type index struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Sitemaps []URL    `xml:"sitemap"`
+}
+
+// NewURL returns the URL of a page, last modified at lastMod unless it is zero
+func NewURL(loc string, lastMod time.Time) URL {
+	url := URL{Loc: loc}
+	if !lastMod.IsZero() {
+		url.LastMod = lastMod.UTC().Format(time.RFC3339)
+	}
+	return url
+}
+
+// Paginate splits urls into pages of at most size URLs, capped to MaxURLs
+func Paginate(urls []URL, size int) [][]URL {
+	if size <= 0 || size > MaxURLs {
+		size = MaxURLs
+	}
+
+	pages := make([][]URL, 0, (len(urls)+size-1)/size)
+	for len(urls) > size {
+		pages = append(pages, urls[:size])
+		urls = urls[size:]
+	}
+
+	if len(urls) > 0 {
+		pages = append(pages, urls)
+	}
+
+	return pages
+}
+
+// Render renders a sitemap listing the pages
+func Render(urls []URL) ([]byte, error) {
+	if len(urls) > MaxURLs {
+		return nil, errors.Errorf("sitemap of %d urls exceeds the limit of %d", len(urls), MaxURLs)
+	}
+
+	return marshal(urlSet{Xmlns: namespace, URLs: urls})
+}
+
+// RenderIndex renders a sitemap index listing the sitemaps
+func RenderIndex(sitemaps []URL) ([]byte, error) {
+	if len(sitemaps) > MaxURLs {
+		return nil, errors.Errorf("sitemap index of %d sitemaps exceeds the limit of %d", len(sitemaps), MaxURLs)
+	}
+
+	return marshal(index{Xmlns: namespace, Sitemaps: sitemaps})
+}
+
+func marshal(document interface{}) ([]byte, error) {
+	out, err := xml.Marshal(document)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render sitemap")
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
@@ -0,0 +1,54 @@
+package sitemap
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPaginate(t *testing.T) {
+	urls := make([]URL, 5)
+
+	pages := Paginate(urls, 2)
+	if len(pages) != 3 || len(pages[0]) != 2 || len(pages[2]) != 1 {
+		t.Fatalf("expected pages of 2, 2 and 1 urls, got %d pages", len(pages))
+	}
+
+	if pages := Paginate(nil, 2); len(pages) != 0 {
+		t.Fatalf("expected no pages, got %d", len(pages))
+	}
+
+	if pages := Paginate(make([]URL, MaxURLs+1), 0); len(pages) != 2 {
+		t.Fatalf("expected pages to be capped to %d urls, got %d pages", MaxURLs, len(pages))
+	}
+}
+
+func TestRender(t *testing.T) {
+	out, err := Render([]URL{
+		NewURL("https://ficsit.app/mod/Example?a=1&b=2", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+		NewURL("https://ficsit.app/guide/abc", time.Time{}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document := string(out)
+	for _, expected := range []string{
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+		`<url><loc>https://ficsit.app/mod/Example?a=1&amp;b=2</loc><lastmod>2024-01-02T03:04:05Z</lastmod></url>`,
+		`<url><loc>https://ficsit.app/guide/abc</loc></url>`,
+	} {
+		if !strings.Contains(document, expected) {
+			t.Errorf("expected sitemap to contain %s, got %s", expected, document)
+		}
+	}
+
+	out, err = RenderIndex([]URL{NewURL("https://api.ficsit.app/v1/sitemap/mods-1.xml", time.Time{})})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(out), `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc>`) {
+		t.Errorf("unexpected sitemap index %s", out)
+	}
+}
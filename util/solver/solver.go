@@ -0,0 +1,292 @@
+// Package solver resolves a set of mod constraints into a pinned set of versions.
+//
+// The solver is a backtracking search: mods are pinned one at a time to their newest
+// version satisfying every requirement collected so far, and the search backs up to
+// the previous choice whenever a dependency cannot be satisfied.
+package solver
+
+import (
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+)
+
+// Limits the amount of versions tried, to bound the work on pathological dependency graphs
+const maxAttempts = 10000
+
+// Version is a candidate version of a mod
+type Version struct {
+	ID                   string
+	Version              string
+	Dependencies         map[string]string
+	OptionalDependencies map[string]string
+	Targets              []string
+}
+
+// Source provides the candidate versions of mods
+type Source interface {
+	// Versions returns every version of the mod, or nil if the mod does not exist
+	Versions(modReference string) ([]Version, error)
+}
+
+// Requirement is a constraint put on a mod.
+// RequiredBy is empty for the constraints provided to Solve.
+type Requirement struct {
+	Constraint string
+	RequiredBy string
+}
+
+// Conflict explains why a mod could not be resolved
+type Conflict struct {
+	ModReference string
+	Requirements []Requirement
+	Message      string
+}
+
+func (c *Conflict) Error() string {
+	return c.Message
+}
+
+// Resolved is a pinned version of a mod
+type Resolved struct {
+	ModReference string
+	Version      Version
+}
+
+type candidate struct {
+	Version
+	parsed *semver.Version
+}
+
+type solver struct {
+	source       Source
+	target       string
+	versions     map[string][]candidate
+	selected     map[string]*candidate
+	requirements map[string][]Requirement
+	conflict     *Conflict
+	attempts     int
+}
+
+// Solve pins a version of every mod required by the constraints, keyed by mod reference.
+//
+// If a target is provided, only versions supporting it are considered.
+// If the constraints cannot be satisfied, the returned error is a *Conflict.
+func Solve(source Source, constraints map[string]string, target string) ([]Resolved, error) {
+	s := &solver{
+		source:       source,
+		target:       target,
+		versions:     make(map[string][]candidate),
+		selected:     make(map[string]*candidate),
+		requirements: make(map[string][]Requirement),
+	}
+
+	pending := make([]string, 0, len(constraints))
+	for modReference, constraint := range constraints {
+		if _, err := semver.NewConstraint(constraint); err != nil {
+			return nil, &Conflict{
+				ModReference: modReference,
+				Requirements: []Requirement{{Constraint: constraint}},
+				Message:      "invalid version constraint " + constraint + " for " + modReference,
+			}
+		}
+
+		s.requirements[modReference] = append(s.requirements[modReference], Requirement{Constraint: constraint})
+		pending = append(pending, modReference)
+	}
+
+	// Resolution order has to be deterministic for the result to be
+	sort.Strings(pending)
+
+	ok, err := s.solve(pending)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, s.conflict
+	}
+
+	resolved := make([]Resolved, 0, len(s.selected))
+	for modReference, selected := range s.selected {
+		resolved = append(resolved, Resolved{
+			ModReference: modReference,
+			Version:      selected.Version,
+		})
+	}
+
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].ModReference < resolved[j].ModReference
+	})
+
+	return resolved, nil
+}
+
+func (s *solver) solve(pending []string) (bool, error) {
+	if len(pending) == 0 {
+		return true, nil
+	}
+
+	modReference := pending[0]
+	rest := pending[1:]
+
+	if _, ok := s.selected[modReference]; ok {
+		return s.solve(rest)
+	}
+
+	candidates, err := s.candidates(modReference)
+	if err != nil {
+		return false, err
+	}
+
+	if len(candidates) == 0 {
+		return false, nil
+	}
+
+	for i := range candidates {
+		s.attempts++
+		if s.attempts > maxAttempts {
+			return false, errors.New("dependency resolution is too complex")
+		}
+
+		selected := &candidates[i]
+		s.selected[modReference] = selected
+
+		added, next, ok := s.require(modReference, selected)
+		if ok {
+			solved, err := s.solve(append(next, rest...))
+			if err != nil || solved {
+				return solved, err
+			}
+		}
+
+		delete(s.selected, modReference)
+		for _, dependency := range added {
+			requirements := s.requirements[dependency]
+			s.requirements[dependency] = requirements[:len(requirements)-1]
+		}
+	}
+
+	return false, nil
+}
+
+// require records the dependencies of the selected version, and returns the mods they were added to,
+// the newly required mods to resolve, and whether they are compatible with the mods already selected
+func (s *solver) require(modReference string, selected *candidate) ([]string, []string, bool) {
+	added := make([]string, 0, len(selected.Dependencies)+len(selected.OptionalDependencies))
+	next := make([]string, 0, len(selected.Dependencies))
+
+	apply := func(dependencies map[string]string, optional bool) bool {
+		for _, dependency := range sortedKeys(dependencies) {
+			requirement := Requirement{Constraint: dependencies[dependency], RequiredBy: modReference}
+
+			s.requirements[dependency] = append(s.requirements[dependency], requirement)
+			added = append(added, dependency)
+
+			if pinned, ok := s.selected[dependency]; ok {
+				if !satisfies(pinned.parsed, requirement.Constraint) {
+					s.recordConflict(dependency, "selected version "+pinned.Version.Version+" of "+dependency+" does not match the requirements")
+					return false
+				}
+			} else if !optional {
+				next = append(next, dependency)
+			}
+		}
+		return true
+	}
+
+	ok := apply(selected.Dependencies, false) && apply(selected.OptionalDependencies, true)
+
+	return added, next, ok
+}
+
+func (s *solver) candidates(modReference string) ([]candidate, error) {
+	versions, ok := s.versions[modReference]
+	if !ok {
+		raw, err := s.source.Versions(modReference)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get versions of "+modReference)
+		}
+
+		if raw == nil {
+			s.recordConflict(modReference, "mod "+modReference+" not found")
+			return nil, nil
+		}
+
+		versions = make([]candidate, 0, len(raw))
+		for _, version := range raw {
+			parsed, err := semver.NewVersion(version.Version)
+			if err != nil || (s.target != "" && version.Targets != nil && !contains(version.Targets, s.target)) {
+				continue
+			}
+
+			versions = append(versions, candidate{Version: version, parsed: parsed})
+		}
+
+		sort.SliceStable(versions, func(i, j int) bool {
+			return versions[i].parsed.GreaterThan(versions[j].parsed)
+		})
+
+		s.versions[modReference] = versions
+	}
+
+	matching := make([]candidate, 0, len(versions))
+	for _, version := range versions {
+		if s.matches(modReference, version.parsed) {
+			matching = append(matching, version)
+		}
+	}
+
+	if len(matching) == 0 {
+		s.recordConflict(modReference, "no version of "+modReference+" matches the requirements")
+	}
+
+	return matching, nil
+}
+
+func (s *solver) matches(modReference string, version *semver.Version) bool {
+	for _, requirement := range s.requirements[modReference] {
+		if !satisfies(version, requirement.Constraint) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *solver) recordConflict(modReference string, message string) {
+	requirements := make([]Requirement, len(s.requirements[modReference]))
+	copy(requirements, s.requirements[modReference])
+
+	s.conflict = &Conflict{
+		ModReference: modReference,
+		Requirements: requirements,
+		Message:      message,
+	}
+}
+
+func satisfies(version *semver.Version, constraint string) bool {
+	parsed, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false
+	}
+	return parsed.Check(version)
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
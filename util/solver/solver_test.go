@@ -0,0 +1,84 @@
+package solver
+
+import (
+	"errors"
+	"testing"
+)
+
+type testSource map[string][]Version
+
+func (t testSource) Versions(modReference string) ([]Version, error) {
+	return t[modReference], nil
+}
+
+func TestSolve(t *testing.T) {
+	source := testSource{
+		"SML": {
+			{ID: "sml-1", Version: "3.5.0"},
+			{ID: "sml-2", Version: "3.6.0"},
+		},
+		"Lib": {
+			{ID: "lib-1", Version: "1.0.0", Dependencies: map[string]string{"SML": "^3.5.0"}},
+			{ID: "lib-2", Version: "2.0.0", Dependencies: map[string]string{"SML": "^3.6.0"}},
+		},
+		"ModA": {
+			{ID: "a-1", Version: "1.0.0", Dependencies: map[string]string{"Lib": "^1.0.0"}},
+		},
+		"ModB": {
+			// The newest version conflicts with ModA, so the solver has to back up
+			{ID: "b-2", Version: "2.0.0", Dependencies: map[string]string{"Lib": "^2.0.0"}},
+			{ID: "b-1", Version: "1.0.0", Dependencies: map[string]string{"Lib": ">=1.0.0"}},
+		},
+		"ModC": {
+			{ID: "c-1", Version: "1.0.0", Dependencies: map[string]string{"SML": "^3.6.0"}, Targets: []string{"Windows"}},
+		},
+		"ModD": {
+			{ID: "d-1", Version: "1.0.0", OptionalDependencies: map[string]string{"Lib": "^2.0.0"}},
+		},
+	}
+
+	resolved, err := Solve(source, map[string]string{"ModA": "*", "ModB": ">=1.0.0"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"Lib": "lib-1", "ModA": "a-1", "ModB": "b-1", "SML": "sml-2"}
+	if len(resolved) != len(expected) {
+		t.Fatalf("expected %d resolved mods, got %d", len(expected), len(resolved))
+	}
+
+	for _, r := range resolved {
+		if expected[r.ModReference] != r.Version.ID {
+			t.Errorf("expected %s to resolve to %s, got %s", r.ModReference, expected[r.ModReference], r.Version.ID)
+		}
+	}
+
+	// Optional dependencies only constrain mods which are installed anyway
+	resolved, err = Solve(source, map[string]string{"ModD": "*"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resolved) != 1 {
+		t.Fatalf("expected only ModD to be resolved, got %d mods", len(resolved))
+	}
+
+	if _, err := Solve(source, map[string]string{"ModD": "*", "ModA": "*"}, ""); err == nil {
+		t.Fatal("expected the optional dependency of ModD to conflict with ModA")
+	}
+
+	if _, err := Solve(source, map[string]string{"ModC": "*"}, "LinuxServer"); err == nil {
+		t.Fatal("expected ModC to have no version for the target")
+	}
+
+	_, err = Solve(source, map[string]string{"ModA": "*", "Lib": "^2.0.0"}, "")
+
+	var conflict *Conflict
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+
+	if _, err := Solve(source, map[string]string{"Missing": "*"}, ""); err == nil {
+		t.Fatal("expected a missing mod to fail the resolution")
+	}
+}
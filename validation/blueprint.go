@@ -0,0 +1,90 @@
+package validation
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BlueprintInfo describes the content of a validated blueprint package
+type BlueprintInfo struct {
+	Hash       string
+	Blueprints []string
+	Size       int64
+}
+
+// ExtractBlueprintInfo validates a blueprint package.
+//
+// A blueprint package is a zip archive containing one or more .sbp blueprint files,
+// each accompanied by its .sbpcfg configuration file. Unlike mods, no .uplugin is required.
+func ExtractBlueprintInfo(body io.ReaderAt, size int64) (*BlueprintInfo, error) {
+	archive, err := zip.NewReader(body, size)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid zip archive")
+	}
+
+	blueprints := make(map[string]bool)
+	configs := make(map[string]bool)
+
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		cleanPath := path.Clean(file.Name)
+		if path.IsAbs(cleanPath) || strings.HasPrefix(cleanPath, "../") {
+			return nil, errors.New("blueprint package contains an invalid path: " + file.Name)
+		}
+
+		extension := path.Ext(cleanPath)
+		name := strings.TrimSuffix(cleanPath, extension)
+
+		switch strings.ToLower(extension) {
+		case ".sbp":
+			blueprints[name] = true
+		case ".sbpcfg":
+			configs[name] = true
+		default:
+			return nil, errors.New("blueprint package contains an unsupported file: " + file.Name)
+		}
+	}
+
+	if len(blueprints) == 0 {
+		return nil, errors.New("blueprint package does not contain any .sbp files")
+	}
+
+	info := &BlueprintInfo{
+		Size:       size,
+		Blueprints: make([]string, 0, len(blueprints)),
+	}
+
+	for name := range blueprints {
+		if !configs[name] {
+			return nil, errors.New("blueprint " + name + " is missing its .sbpcfg file")
+		}
+		info.Blueprints = append(info.Blueprints, name)
+	}
+
+	for name := range configs {
+		if !blueprints[name] {
+			return nil, errors.New("blueprint config " + name + ".sbpcfg has no matching .sbp file")
+		}
+	}
+
+	sort.Strings(info.Blueprints)
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(body, 0, size)); err != nil {
+		return nil, errors.Wrap(err, "error hashing blueprint package")
+	}
+
+	info.Hash = hex.EncodeToString(hash.Sum(nil))
+
+	return info, nil
+}
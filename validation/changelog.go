@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"archive/zip"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/satisfactorymodding/smr-api/generated"
+)
+
+// maxChangelogSize is the maximum number of bytes imported from a changelog file
+const maxChangelogSize = 64 * 1024
+
+// extractChangelog looks for a CHANGELOG.md at the root of the archive or of any target directory.
+// A changelog file takes precedence over the Changelog field of the .uplugin.
+func extractChangelog(archive *zip.Reader, modInfo *ModInfo) {
+	for _, file := range archive.File {
+		if !strings.EqualFold(path.Base(file.Name), "CHANGELOG.md") {
+			continue
+		}
+
+		dir := path.Dir(file.Name)
+		if dir != "." && path.Dir(dir) != "." {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(rc, maxChangelogSize))
+		_ = rc.Close()
+		if err != nil {
+			continue
+		}
+
+		changelog := strings.TrimSpace(string(data))
+		if changelog == "" {
+			continue
+		}
+
+		modInfo.Changelog = changelog
+		modInfo.ChangelogSource = generated.ChangelogSourceChangelogFile
+
+		return
+	}
+}
@@ -0,0 +1,240 @@
+package validation
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ContainerType is the packaging an archive was uploaded in
+type ContainerType string
+
+const (
+	// ContainerSMOD is the canonical layout, with the mod at the root of the archive
+	ContainerSMOD ContainerType = "smod"
+
+	// ContainerZip is a plain zip wrapping the mod, either in a single top-level
+	// directory or as a nested .smod/.zip file, as produced by older packaging tooling
+	ContainerZip ContainerType = "zip"
+)
+
+// NormalizedArchive is the canonical form of an uploaded archive
+type NormalizedArchive struct {
+	File      io.ReaderAt
+	Container ContainerType
+	Size      int64
+
+	// Changed is set if the archive had to be rewritten
+	Changed bool
+
+	cleanup func()
+}
+
+// Reader returns a reader over the whole canonical archive
+func (n *NormalizedArchive) Reader() io.ReadSeeker {
+	return io.NewSectionReader(n.File, 0, n.Size)
+}
+
+// Close releases the temporary file of a rewritten archive
+func (n *NormalizedArchive) Close() {
+	if n.cleanup != nil {
+		n.cleanup()
+	}
+}
+
+// NormalizeArchive unwraps archives uploaded in a zip container into the canonical layout.
+//
+// OS metadata entries (__MACOSX, .DS_Store) and directory entries are dropped while rewriting.
+// Archives already in the canonical layout are returned as-is.
+func NormalizeArchive(body io.ReaderAt, size int64, modReference string) (*NormalizedArchive, error) {
+	archive, err := zip.NewReader(body, size)
+	if err != nil {
+		return nil, errors.New("invalid zip archive")
+	}
+
+	files := make([]*zip.File, 0, len(archive.File))
+	for _, file := range archive.File {
+		if !isJunkEntry(file) {
+			files = append(files, file)
+		}
+	}
+
+	if isCanonicalLayout(files, modReference) {
+		return &NormalizedArchive{
+			File:      body,
+			Size:      size,
+			Container: ContainerSMOD,
+		}, nil
+	}
+
+	// A single nested archive, unwrapped once
+	if len(files) == 1 {
+		extension := strings.ToLower(path.Ext(files[0].Name))
+		if extension == ".smod" || extension == ".zip" {
+			return unwrapNestedArchive(files[0], modReference)
+		}
+	}
+
+	if prefix := commonDirectory(files); prefix != "" {
+		names := make([]string, len(files))
+		for i, file := range files {
+			names[i] = strings.TrimPrefix(file.Name, prefix)
+		}
+
+		if isCanonicalNames(names, modReference) {
+			return rewriteArchive(files, names)
+		}
+	}
+
+	// Unknown layout, let validation report what is missing
+	return &NormalizedArchive{
+		File:      body,
+		Size:      size,
+		Container: ContainerSMOD,
+	}, nil
+}
+
+func unwrapNestedArchive(file *zip.File, modReference string) (*NormalizedArchive, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open nested archive")
+	}
+	defer reader.Close()
+
+	// The nested archive is decompressed, so its size is checked again
+	nested, nestedSize, cleanup, err := BufferArchive(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read nested archive")
+	}
+
+	archive, err := zip.NewReader(nested, nestedSize)
+	if err != nil {
+		cleanup()
+		return nil, errors.New("invalid nested zip archive")
+	}
+
+	files := make([]*zip.File, 0, len(archive.File))
+	names := make([]string, 0, len(archive.File))
+	for _, nestedFile := range archive.File {
+		if !isJunkEntry(nestedFile) {
+			files = append(files, nestedFile)
+			names = append(names, nestedFile.Name)
+		}
+	}
+
+	if !isCanonicalNames(names, modReference) {
+		cleanup()
+		return nil, errors.New("nested archive " + file.Name + " does not contain a mod")
+	}
+
+	normalized, err := rewriteArchive(files, names)
+	cleanup()
+	return normalized, err
+}
+
+func rewriteArchive(files []*zip.File, names []string) (*NormalizedArchive, error) {
+	out, err := os.CreateTemp("", "smr-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed creating temp file")
+	}
+
+	cleanup := func() {
+		_ = out.Close()
+		_ = os.Remove(out.Name())
+	}
+
+	writer := zip.NewWriter(out)
+	for i, file := range files {
+		header := file.FileHeader
+		header.Name = names[i]
+
+		// Entries are copied without recompressing them
+		entry, err := writer.CreateRaw(&header)
+		if err != nil {
+			cleanup()
+			return nil, errors.Wrap(err, "failed to create "+names[i])
+		}
+
+		raw, err := file.OpenRaw()
+		if err != nil {
+			cleanup()
+			return nil, errors.Wrap(err, "failed to open "+file.Name)
+		}
+
+		if _, err := io.Copy(entry, raw); err != nil {
+			cleanup()
+			return nil, errors.Wrap(err, "failed to copy "+file.Name)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		cleanup()
+		return nil, errors.Wrap(err, "failed to finish archive")
+	}
+
+	size, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		cleanup()
+		return nil, errors.Wrap(err, "failed to get archive size")
+	}
+
+	return &NormalizedArchive{
+		File:      out,
+		Size:      size,
+		Container: ContainerZip,
+		Changed:   true,
+		cleanup:   cleanup,
+	}, nil
+}
+
+func isJunkEntry(file *zip.File) bool {
+	return file.FileInfo().IsDir() ||
+		strings.HasPrefix(file.Name, "__MACOSX/") ||
+		path.Base(file.Name) == ".DS_Store"
+}
+
+func isCanonicalLayout(files []*zip.File, modReference string) bool {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.Name
+	}
+	return isCanonicalNames(names, modReference)
+}
+
+func isCanonicalNames(names []string, modReference string) bool {
+	for _, name := range names {
+		if name == "data.json" || name == modReference+".uplugin" {
+			return true
+		}
+
+		for _, target := range AllowedTargets {
+			if name == target+"/"+modReference+".uplugin" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// commonDirectory returns the top-level directory containing every file, if there is one
+func commonDirectory(files []*zip.File) string {
+	prefix := ""
+	for _, file := range files {
+		index := strings.Index(file.Name, "/")
+		if index < 0 {
+			return ""
+		}
+
+		directory := file.Name[:index+1]
+		if prefix == "" {
+			prefix = directory
+		} else if prefix != directory {
+			return ""
+		}
+	}
+	return prefix
+}
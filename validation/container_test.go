@@ -0,0 +1,97 @@
+package validation
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeArchive(t *testing.T) {
+	canonical := buildZip(t, map[string][]byte{
+		"Windows/Mod.uplugin": []byte("{}"),
+		"Windows/Mod.pak":     []byte("pak"),
+	})
+
+	cases := map[string]struct {
+		archive   []byte
+		container ContainerType
+	}{
+		"canonical": {
+			archive:   canonical,
+			container: ContainerSMOD,
+		},
+		"top-level directory": {
+			archive: buildZip(t, map[string][]byte{
+				"Mod/Windows/Mod.uplugin":    []byte("{}"),
+				"Mod/Windows/Mod.pak":        []byte("pak"),
+				"__MACOSX/Mod/._Windows.pak": []byte("junk"),
+			}),
+			container: ContainerZip,
+		},
+		"nested archive": {
+			archive: buildZip(t, map[string][]byte{
+				"Mod.smod": canonical,
+			}),
+			container: ContainerZip,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			normalized, err := NormalizeArchive(bytes.NewReader(c.archive), int64(len(c.archive)), "Mod")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer normalized.Close()
+
+			if normalized.Container != c.container {
+				t.Errorf("expected container %s, got %s", c.container, normalized.Container)
+			}
+
+			reader, err := zip.NewReader(normalized.File, normalized.Size)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := make(map[string]bool)
+			for _, file := range reader.File {
+				names[file.Name] = true
+			}
+
+			if len(names) != 2 || !names["Windows/Mod.uplugin"] || !names["Windows/Mod.pak"] {
+				t.Errorf("unexpected entries: %v", names)
+			}
+		})
+	}
+}
+
+func TestNormalizeArchiveLimitsNestedArchiveSize(t *testing.T) {
+	setConfig(t, "validation.max_archive_size", 64*1024)
+
+	// Stored entries keep the nested archive large, while the outer archive compresses it away
+	buf := new(bytes.Buffer)
+	writer := zip.NewWriter(buf)
+	if _, err := writer.Create("Windows/Mod.uplugin"); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := writer.CreateHeader(&zip.FileHeader{Name: "Windows/Mod.pak", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := entry.Write(make([]byte, 1024*1024)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := buildZip(t, map[string][]byte{
+		"Mod.smod": buf.Bytes(),
+	})
+
+	_, err = NormalizeArchive(bytes.NewReader(archive), int64(len(archive)), "Mod")
+	if err == nil || !strings.Contains(err.Error(), "mod archive must be <") {
+		t.Errorf("expected an oversized nested archive to be rejected, got %v", err)
+	}
+}
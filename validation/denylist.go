@@ -0,0 +1,140 @@
+package validation
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ContentFlag is an archive entry matching the content denylist
+type ContentFlag struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+// denylistRule reports every denied entry of the archive.
+//
+// If validation.denylist.action is "flag", the upload is not failed,
+// instead the entries are recorded on ModInfo.ContentFlags for moderators to review.
+func denylistRule(archive *Archive) []string {
+	flags := ScanArchiveContent(archive.Reader, archive.ModInfo)
+
+	if viper.GetString("validation.denylist.action") == "flag" {
+		archive.ModInfo.ContentFlags = flags
+		return nil
+	}
+
+	failures := make([]string, len(flags))
+	for i, flag := range flags {
+		failures[i] = "zip archive contains denied content: " + flag.Path + " (" + flag.Reason + ")"
+	}
+
+	return failures
+}
+
+// ScanArchiveContent checks every entry of the archive against the content denylist:
+//   - files with an extension in validation.denylist.extensions
+//   - binaries (validation.denylist.binary_extensions) outside of validation.denylist.binary_directories
+//   - files whose SHA256 hash is listed in validation.denylist.hashes
+func ScanArchiveContent(archive *zip.Reader, modInfo *ModInfo) []ContentFlag {
+	deniedExtensions := lowerSet(viper.GetStringSlice("validation.denylist.extensions"))
+	binaryExtensions := lowerSet(viper.GetStringSlice("validation.denylist.binary_extensions"))
+	binaryDirectories := viper.GetStringSlice("validation.denylist.binary_directories")
+	deniedHashes := lowerSet(viper.GetStringSlice("validation.denylist.hashes"))
+
+	var flags []ContentFlag
+
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		extension := strings.ToLower(path.Ext(file.Name))
+
+		if _, ok := deniedExtensions[extension]; ok {
+			flags = append(flags, ContentFlag{
+				Path:   file.Name,
+				Reason: "denied file type",
+			})
+			continue
+		}
+
+		if _, ok := binaryExtensions[extension]; ok && !inBinaryDirectory(file.Name, modInfo, binaryDirectories) {
+			flags = append(flags, ContentFlag{
+				Path:   file.Name,
+				Reason: "binary outside of an expected directory",
+			})
+			continue
+		}
+
+		if len(deniedHashes) > 0 {
+			hash, err := hashArchiveFile(file)
+			if err != nil {
+				continue
+			}
+
+			if _, ok := deniedHashes[hash]; ok {
+				flags = append(flags, ContentFlag{
+					Path:   file.Name,
+					Reason: "matches a denied file",
+					Hash:   hash,
+				})
+			}
+		}
+	}
+
+	return flags
+}
+
+func inBinaryDirectory(name string, modInfo *ModInfo, directories []string) bool {
+	// data.json mods reference their binaries explicitly, so their layout is not enforced
+	if modInfo == nil || modInfo.Type == DataJSON {
+		return true
+	}
+
+	if modInfo.Type == MultiTargetUEPlugin {
+		for _, target := range modInfo.Targets {
+			if strings.HasPrefix(name, target+"/") {
+				name = strings.TrimPrefix(name, target+"/")
+				break
+			}
+		}
+	}
+
+	for _, directory := range directories {
+		if strings.HasPrefix(name, strings.TrimSuffix(directory, "/")+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hashArchiveFile(file *zip.File) (string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func lowerSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[strings.ToLower(value)] = struct{}{}
+	}
+	return set
+}
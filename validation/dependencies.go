@@ -0,0 +1,103 @@
+package validation
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
+)
+
+// DependencyValidationError lists every declared dependency that cannot be resolved on SMR
+type DependencyValidationError struct {
+	Errors []*generated.DependencyError
+}
+
+func (e *DependencyValidationError) Error() string {
+	entries := make([]string, len(e.Errors))
+	for i, dependencyError := range e.Errors {
+		entries[i] = dependencyError.ModReference + " " + dependencyError.Condition + ": " + dependencyError.Reason
+	}
+	return "invalid dependencies: " + strings.Join(entries, "; ")
+}
+
+func (e *DependencyValidationError) DependencyErrors() []*generated.DependencyError {
+	return e.Errors
+}
+
+func (e *DependencyValidationError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code": "INVALID_DEPENDENCIES",
+	}
+}
+
+// ValidateDependencies checks that every dependency of the mod exists and that
+// its condition is satisfied by at least one approved version.
+func ValidateDependencies(ctx context.Context, modInfo *ModInfo) error {
+	var dependencyErrors []*generated.DependencyError
+
+	check := func(dependencies map[string]string, optional bool) {
+		for modReference, condition := range dependencies {
+			if reason := checkDependency(ctx, modReference, condition); reason != "" {
+				dependencyErrors = append(dependencyErrors, &generated.DependencyError{
+					ModReference: modReference,
+					Condition:    condition,
+					Optional:     optional,
+					Reason:       reason,
+				})
+			}
+		}
+	}
+
+	check(modInfo.Dependencies, false)
+	check(modInfo.OptionalDependencies, true)
+
+	if len(dependencyErrors) == 0 {
+		return nil
+	}
+
+	sort.Slice(dependencyErrors, func(a, b int) bool {
+		return dependencyErrors[a].ModReference < dependencyErrors[b].ModReference
+	})
+
+	return &DependencyValidationError{Errors: dependencyErrors}
+}
+
+func checkDependency(ctx context.Context, modReference string, condition string) string {
+	constraint, err := semver.NewConstraint(condition)
+	if err != nil {
+		return "invalid version condition"
+	}
+
+	var versions []string
+
+	mod := postgres.GetModByReference(ctx, modReference)
+	if mod != nil {
+		for _, version := range postgres.GetAllModVersionsWithDependencies(ctx, mod.ID) {
+			versions = append(versions, version.Version)
+		}
+	} else if modReference == "SML" {
+		// SML releases predating the SML mod page are only tracked as SML versions
+		for _, version := range postgres.GetSMLVersions(ctx, nil) {
+			versions = append(versions, version.Version)
+		}
+	} else {
+		return "mod not found"
+	}
+
+	for _, version := range versions {
+		parsed, err := semver.NewVersion(version)
+		if err != nil {
+			continue
+		}
+
+		if constraint.Check(parsed) {
+			return ""
+		}
+	}
+
+	return "no approved version matches the condition"
+}
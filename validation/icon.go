@@ -0,0 +1,56 @@
+package validation
+
+import (
+	"archive/zip"
+	"io"
+	"path"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxIconSize is the maximum size of an icon extracted from a mod archive
+const maxIconSize = 5 * 1024 * 1024
+
+// extractIcon reads the icon of the plugin, either from the path declared in the .uplugin
+// or from the Unreal default of Resources/Icon128.png, relative to the .uplugin.
+func extractIcon(archive *zip.Reader, modInfo *ModInfo) {
+	var pluginDir string
+	switch modInfo.Type {
+	case UEPlugin:
+		pluginDir = "."
+	case MultiTargetUEPlugin:
+		if len(modInfo.Targets) == 0 {
+			return
+		}
+		pluginDir = modInfo.Targets[0]
+	default:
+		return
+	}
+
+	candidates := []string{path.Join(pluginDir, "Resources", "Icon128.png")}
+	if modInfo.IconPath != "" {
+		candidates = append([]string{path.Join(pluginDir, modInfo.IconPath)}, candidates...)
+	}
+
+	for _, candidate := range candidates {
+		file, err := archive.Open(candidate)
+		if err != nil {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(file, maxIconSize+1))
+		_ = file.Close()
+		if err != nil {
+			log.Err(err).Str("path", candidate).Msg("failed reading icon")
+			continue
+		}
+
+		if len(data) > maxIconSize {
+			log.Warn().Str("path", candidate).Msg("icon is too large, ignoring")
+			continue
+		}
+
+		modInfo.Icon = data
+		return
+	}
+}
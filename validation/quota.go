@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/viper"
+
+	"github.com/satisfactorymodding/smr-api/db/postgres"
+)
+
+// Quota describes the upload limits applying to a mod.
+// A limit of 0 means unlimited.
+type Quota struct {
+	Tier          string
+	MaxFileSize   int64
+	MaxModStorage int64
+}
+
+// QuotaExceededError is returned when an upload would exceed the mod's quota
+type QuotaExceededError struct {
+	Limit   string
+	Maximum int64
+	Actual  int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("upload exceeds the %s quota (%d > %d bytes)", e.Limit, e.Actual, e.Maximum)
+}
+
+func (e *QuotaExceededError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":    "QUOTA_EXCEEDED",
+		"limit":   e.Limit,
+		"maximum": e.Maximum,
+	}
+}
+
+// GetModQuota resolves the quota of a mod.
+//
+// Limits come from the quota tier of the mod's creator (quota.tiers.<tier>),
+// and can be overridden per user and then per mod in the quota_overrides table.
+func GetModQuota(ctx context.Context, mod *postgres.Mod) Quota {
+	tier := viper.GetString("quota.default_tier")
+
+	creator := postgres.GetUserByID(ctx, mod.CreatorID)
+	if creator != nil && creator.QuotaTier != nil && *creator.QuotaTier != "" {
+		tier = *creator.QuotaTier
+	}
+
+	quota := Quota{
+		Tier:          tier,
+		MaxFileSize:   viper.GetInt64("quota.tiers." + tier + ".max_file_size"),
+		MaxModStorage: viper.GetInt64("quota.tiers." + tier + ".max_mod_storage"),
+	}
+
+	if creator != nil {
+		applyQuotaOverride(&quota, postgres.GetUserQuotaOverride(ctx, creator.ID))
+	}
+
+	applyQuotaOverride(&quota, postgres.GetModQuotaOverride(ctx, mod.ID))
+
+	return quota
+}
+
+func applyQuotaOverride(quota *Quota, override *postgres.QuotaOverride) {
+	if override == nil {
+		return
+	}
+
+	if override.MaxFileSize != nil {
+		quota.MaxFileSize = *override.MaxFileSize
+	}
+
+	if override.MaxModStorage != nil {
+		quota.MaxModStorage = *override.MaxModStorage
+	}
+}
+
+// CheckModQuota verifies that a file of the provided size can be uploaded to the mod
+func CheckModQuota(ctx context.Context, mod *postgres.Mod, fileSize int64) error {
+	quota := GetModQuota(ctx, mod)
+
+	if quota.MaxFileSize > 0 && fileSize > quota.MaxFileSize {
+		return &QuotaExceededError{
+			Limit:   "file size",
+			Maximum: quota.MaxFileSize,
+			Actual:  fileSize,
+		}
+	}
+
+	if quota.MaxModStorage > 0 {
+		total := postgres.GetModStorageUsage(ctx, mod.ID) + fileSize
+		if total > quota.MaxModStorage {
+			return &QuotaExceededError{
+				Limit:   "mod storage",
+				Maximum: quota.MaxModStorage,
+				Actual:  total,
+			}
+		}
+	}
+
+	return nil
+}
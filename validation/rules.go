@@ -0,0 +1,352 @@
+package validation
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/satisfactorymodding/smr-api/util"
+)
+
+// Archive is the mod archive being validated, as seen by validation rules
+type Archive struct {
+	Reader       *zip.Reader
+	ModInfo      *ModInfo
+	ModReference string
+	Size         int64
+}
+
+// Rule checks an archive and returns a message for every problem it finds
+type Rule func(archive *Archive) []string
+
+type registeredRule struct {
+	Check Rule
+	Name  string
+}
+
+var rules []registeredRule
+
+// RegisterRule appends a rule to the validation pipeline.
+//
+// Every rule can be disabled by setting validation.rules.<name> to false.
+func RegisterRule(name string, check Rule) {
+	rules = append(rules, registeredRule{
+		Name:  name,
+		Check: check,
+	})
+}
+
+func init() {
+	RegisterRule("archive-size", archiveSizeRule)
+	RegisterRule("data-json", dataJSONRule)
+	RegisterRule("uplugin", uPluginRule)
+	RegisterRule("targets", targetsRule)
+	RegisterRule("file-blacklist", fileBlacklistRule)
+	RegisterRule("content-denylist", denylistRule)
+}
+
+// ValidationFailure is a single problem reported by a validation rule
+type ValidationFailure struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every failure reported while validating an archive
+type ValidationError struct {
+	Failures []ValidationFailure
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		messages[i] = failure.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":     "VALIDATION_FAILED",
+		"failures": e.Failures,
+	}
+}
+
+func ruleEnabled(name string) bool {
+	key := "validation.rules." + name
+	if !viper.IsSet(key) {
+		return true
+	}
+	return viper.GetBool(key)
+}
+
+// RunRules runs all enabled rules against the archive.
+// All rules are run even if one fails, so that every problem is reported at once.
+func RunRules(archive *Archive) error {
+	var failures []ValidationFailure
+
+	for _, rule := range rules {
+		if !ruleEnabled(rule.Name) {
+			continue
+		}
+
+		for _, message := range rule.Check(archive) {
+			failures = append(failures, ValidationFailure{
+				Rule:    rule.Name,
+				Message: message,
+			})
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Failures: failures}
+}
+
+// CheckArchiveSize rejects archives larger than validation.max_archive_size.
+// Uploads are checked whether or not validation is enabled.
+func CheckArchiveSize(size int64) error {
+	maxSize := viper.GetInt64("validation.max_archive_size")
+	if maxSize > 0 && size > maxSize {
+		return fmt.Errorf("mod archive must be < %d bytes", maxSize)
+	}
+	return nil
+}
+
+// MaxUploadParts is the number of parts a version file can be uploaded in
+const MaxUploadParts = 100
+
+// CheckUploadPart rejects part numbers outside of 1 to MaxUploadParts
+func CheckUploadPart(part int) error {
+	if part < 1 || part > MaxUploadParts {
+		return fmt.Errorf("part must be between 1 and %d", MaxUploadParts)
+	}
+	return nil
+}
+
+// CheckUploadPartCount rejects uploads split in fewer than 1 or more than MaxUploadParts parts
+func CheckUploadPartCount(parts int) error {
+	if parts < 1 || parts > MaxUploadParts {
+		return fmt.Errorf("files must consist of 1 to %d parts", MaxUploadParts)
+	}
+	return nil
+}
+
+// MinUploadPartSize is the smallest size object storage accepts for every part of a multipart upload but the last one
+const MinUploadPartSize = 5 << 20
+
+// CheckUploadPartSize rejects files split in so many parts that they would be smaller than MinUploadPartSize.
+// Files uploaded in a single part can be of any size.
+func CheckUploadPartSize(size int64, parts int) error {
+	if parts > 1 && size/int64(parts) < MinUploadPartSize {
+		return fmt.Errorf("every part but the last must hold at least %d bytes, upload this file in fewer parts", MinUploadPartSize)
+	}
+	return nil
+}
+
+// BufferArchive copies an uploaded archive to a temp file, giving up as soon as it exceeds validation.max_archive_size
+func BufferArchive(reader io.Reader) (*os.File, int64, func(), error) {
+	maxSize := viper.GetInt64("validation.max_archive_size")
+
+	file, size, cleanup, err := util.BufferToTempFileLimited(reader, maxSize)
+	if errors.Is(err, util.ErrFileTooLarge) {
+		return nil, 0, nil, CheckArchiveSize(maxSize + 1)
+	}
+
+	return file, size, cleanup, err
+}
+
+func archiveSizeRule(archive *Archive) []string {
+	if err := CheckArchiveSize(archive.Size); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+func dataJSONRule(archive *Archive) []string {
+	if archive.ModInfo.Type != DataJSON {
+		return nil
+	}
+
+	data, err := readArchiveFile(archive.Reader, "data.json")
+	if err != nil {
+		return []string{"invalid zip archive"}
+	}
+
+	var failures []string
+
+	result, err := gojsonschema.Validate(dataJSONSchema, gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		failures = append(failures, "data.json doesn't follow schema. please view the help page. ("+err.Error()+")")
+	} else if !result.Valid() {
+		failures = append(failures, "data.json doesn't follow schema. please view the help page. ("+fmt.Sprintf("%s", result.Errors())+")")
+	}
+
+	// Validate that all listed files are accounted for in data.json
+	for _, archiveFile := range archive.Reader.File {
+		if strings.HasSuffix(archiveFile.Name, ".dll") || strings.HasSuffix(archiveFile.Name, ".pak") || strings.HasSuffix(archiveFile.Name, ".so") {
+			found := false
+			for _, obj := range archive.ModInfo.Objects {
+				if obj.Path == archiveFile.Name {
+					found = true
+					break
+				}
+			}
+			if !found {
+				failures = append(failures, "zip archive contains unreferenced objects: "+archiveFile.Name)
+			}
+		}
+	}
+
+	// Validate that all objects refer to existing files
+	for _, obj := range archive.ModInfo.Objects {
+		found := false
+		for _, archiveFile := range archive.Reader.File {
+			if obj.Path == archiveFile.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			failures = append(failures, "data.json objects refer to non-existent path: "+obj.Path)
+		}
+	}
+
+	return failures
+}
+
+func uPluginRule(archive *Archive) []string {
+	var paths []string
+	switch archive.ModInfo.Type {
+	case UEPlugin:
+		paths = []string{archive.ModReference + ".uplugin"}
+	case MultiTargetUEPlugin:
+		for _, target := range archive.ModInfo.Targets {
+			paths = append(paths, target+"/"+archive.ModReference+".uplugin")
+		}
+	default:
+		return nil
+	}
+
+	var failures []string
+	for _, uPluginPath := range paths {
+		data, err := readArchiveFile(archive.Reader, uPluginPath)
+		if err != nil {
+			failures = append(failures, "failed to read "+uPluginPath)
+			continue
+		}
+
+		result, err := gojsonschema.Validate(uPluginJSONSchema, gojsonschema.NewBytesLoader(data))
+		if err != nil {
+			failures = append(failures, uPluginPath+" doesn't follow schema. please view the help page. ("+err.Error()+")")
+		} else if !result.Valid() {
+			failures = append(failures, uPluginPath+" doesn't follow schema. please view the help page. ("+fmt.Sprintf("%s", result.Errors())+")")
+		}
+	}
+
+	return failures
+}
+
+func targetsRule(archive *Archive) []string {
+	if archive.ModInfo.Type != MultiTargetUEPlugin {
+		return nil
+	}
+
+	var failures []string
+
+	for _, target := range archive.ModInfo.Targets {
+		found := false
+		for _, allowedTarget := range AllowedTargets {
+			if target == allowedTarget {
+				found = true
+				break
+			}
+		}
+		if !found {
+			failures = append(failures, "multi-target plugin contains invalid target: "+target)
+		}
+	}
+
+	for _, file := range archive.Reader.File {
+		found := false
+		for _, target := range archive.ModInfo.Targets {
+			if strings.HasPrefix(file.Name, target+"/") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			failures = append(failures, "multi-target plugin contains file outside of target directories: "+file.Name)
+		}
+	}
+
+	var lastData []byte
+	for _, target := range archive.ModInfo.Targets {
+		data, err := readArchiveFile(archive.Reader, target+"/"+archive.ModReference+".uplugin")
+		if err != nil {
+			failures = append(failures, "failed to read .uplugin file of target "+target)
+			continue
+		}
+
+		if lastData != nil && !bytes.Equal(lastData, data) {
+			failures = append(failures, "multi-target plugin contains different .uplugin files")
+			break
+		}
+		lastData = data
+	}
+
+	return failures
+}
+
+// fileBlacklistRule fails on files with an extension in validation.file_blacklist.
+// Extensions also listed in validation.denylist.extensions are left to the content denylist while it is enabled,
+// so they can be flagged instead of failed when validation.denylist.action is "flag".
+func fileBlacklistRule(archive *Archive) []string {
+	blacklist := viper.GetStringSlice("validation.file_blacklist")
+
+	var deniedExtensions map[string]struct{}
+	if ruleEnabled("content-denylist") {
+		deniedExtensions = lowerSet(viper.GetStringSlice("validation.denylist.extensions"))
+	}
+
+	var failures []string
+	for _, file := range archive.Reader.File {
+		extension := strings.ToLower(path.Ext(file.Name))
+		if _, ok := deniedExtensions[extension]; ok {
+			continue
+		}
+
+		for _, blacklisted := range blacklist {
+			if extension == strings.ToLower(blacklisted) {
+				failures = append(failures, "zip archive contains a forbidden file: "+file.Name)
+				break
+			}
+		}
+	}
+
+	return failures
+}
+
+func readArchiveFile(archive *zip.Reader, name string) ([]byte, error) {
+	file, err := archive.Open(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open "+name)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read "+name)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,220 @@
+package validation
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// setConfig overrides a config value until the end of the test
+func setConfig(t *testing.T, key string, value interface{}) {
+	t.Helper()
+
+	previous := viper.Get(key)
+	viper.Set(key, value)
+	t.Cleanup(func() {
+		viper.Set(key, previous)
+	})
+}
+
+func TestRunRulesReportsAllFailures(t *testing.T) {
+	setConfig(t, "validation.rules.uplugin", false)
+	setConfig(t, "validation.file_blacklist", []string{".exe"})
+
+	buf := new(bytes.Buffer)
+	writer := zip.NewWriter(buf)
+	for _, name := range []string{"Windows/Mod.uplugin", "Android/Mod.uplugin", "Windows/installer.exe", "readme.txt"} {
+		if _, err := writer.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = RunRules(&Archive{
+		Reader: reader,
+		ModInfo: &ModInfo{
+			Type:    MultiTargetUEPlugin,
+			Targets: []string{"Windows", "Android"},
+		},
+		ModReference: "Mod",
+		Size:         int64(buf.Len()),
+	})
+
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+
+	expected := map[string]int{
+		"targets":        2,
+		"file-blacklist": 1,
+	}
+
+	actual := make(map[string]int)
+	for _, failure := range validationError.Failures {
+		actual[failure.Rule]++
+	}
+
+	for rule, count := range expected {
+		if actual[rule] != count {
+			t.Errorf("expected %d failures from %s, got %d", count, rule, actual[rule])
+		}
+	}
+
+	if len(validationError.Failures) != 3 {
+		t.Errorf("expected 3 failures, got %v", validationError.Failures)
+	}
+}
+
+func TestBufferArchiveEnforcesSizeLimit(t *testing.T) {
+	setConfig(t, "validation.max_archive_size", 16)
+
+	file, size, cleanup, err := BufferArchive(bytes.NewReader(make([]byte, 16)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	if size != 16 || file == nil {
+		t.Errorf("expected 16 buffered bytes, got %d", size)
+	}
+
+	if _, _, _, err := BufferArchive(bytes.NewReader(make([]byte, 17))); err == nil {
+		t.Error("expected archive over the limit to be rejected")
+	}
+
+	if err := CheckArchiveSize(17); err == nil {
+		t.Error("expected size over the limit to be rejected")
+	}
+}
+
+func TestCheckUploadPart(t *testing.T) {
+	for _, part := range []int{1, MaxUploadParts} {
+		if err := CheckUploadPart(part); err != nil {
+			t.Errorf("expected part %d to be accepted: %v", part, err)
+		}
+	}
+
+	for _, part := range []int{-1, 0, MaxUploadParts + 1} {
+		if err := CheckUploadPart(part); err == nil {
+			t.Errorf("expected part %d to be rejected", part)
+		}
+	}
+}
+
+func TestCheckUploadPartCount(t *testing.T) {
+	for _, parts := range []int{1, MaxUploadParts} {
+		if err := CheckUploadPartCount(parts); err != nil {
+			t.Errorf("expected %d parts to be accepted: %v", parts, err)
+		}
+	}
+
+	for _, parts := range []int{0, MaxUploadParts + 1} {
+		if err := CheckUploadPartCount(parts); err == nil {
+			t.Errorf("expected %d parts to be rejected", parts)
+		}
+	}
+}
+
+func TestCheckUploadPartSize(t *testing.T) {
+	for _, upload := range []struct {
+		size  int64
+		parts int
+	}{{1, 1}, {MinUploadPartSize - 1, 1}, {2 * MinUploadPartSize, 2}, {2*MinUploadPartSize + 1, 2}} {
+		if err := CheckUploadPartSize(upload.size, upload.parts); err != nil {
+			t.Errorf("expected %d bytes in %d parts to be accepted: %v", upload.size, upload.parts, err)
+		}
+	}
+
+	for _, upload := range []struct {
+		size  int64
+		parts int
+	}{{2*MinUploadPartSize - 1, 2}, {MinUploadPartSize, MaxUploadParts}} {
+		if err := CheckUploadPartSize(upload.size, upload.parts); err == nil {
+			t.Errorf("expected %d bytes in %d parts to be rejected", upload.size, upload.parts)
+		}
+	}
+}
+
+func TestDenylistFlagsMisplacedBinaries(t *testing.T) {
+	setConfig(t, "validation.denylist.extensions", []string{".ps1"})
+	setConfig(t, "validation.denylist.binary_extensions", []string{".dll"})
+	setConfig(t, "validation.denylist.binary_directories", []string{"Binaries"})
+
+	buf := new(bytes.Buffer)
+	writer := zip.NewWriter(buf)
+	for _, name := range []string{"Windows/Binaries/Win64/Mod.dll", "Windows/Content/Mod.dll", "Windows/install.ps1"} {
+		if _, err := writer.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags := ScanArchiveContent(reader, &ModInfo{
+		Type:    MultiTargetUEPlugin,
+		Targets: []string{"Windows"},
+	})
+
+	if len(flags) != 2 || flags[0].Path != "Windows/Content/Mod.dll" || flags[1].Path != "Windows/install.ps1" {
+		t.Errorf("unexpected flags: %+v", flags)
+	}
+}
+
+func TestDenylistFlagModeFlagsBlacklistedScripts(t *testing.T) {
+	setConfig(t, "validation.rules.uplugin", false)
+	setConfig(t, "validation.file_blacklist", []string{".exe", ".bat"})
+	setConfig(t, "validation.denylist.action", "flag")
+	setConfig(t, "validation.denylist.extensions", []string{".bat"})
+
+	buf := new(bytes.Buffer)
+	writer := zip.NewWriter(buf)
+	for _, name := range []string{"Windows/Mod.uplugin", "Windows/install.bat"} {
+		if _, err := writer.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modInfo := &ModInfo{
+		Type:    MultiTargetUEPlugin,
+		Targets: []string{"Windows"},
+	}
+
+	if err := RunRules(&Archive{Reader: reader, ModInfo: modInfo, ModReference: "Mod", Size: int64(buf.Len())}); err != nil {
+		t.Fatalf("expected the script to be flagged instead of failed, got %v", err)
+	}
+
+	if len(modInfo.ContentFlags) != 1 || modInfo.ContentFlags[0].Path != "Windows/install.bat" {
+		t.Errorf("unexpected flags: %+v", modInfo.ContentFlags)
+	}
+
+	setConfig(t, "validation.rules.content-denylist", false)
+
+	if err := RunRules(&Archive{Reader: reader, ModInfo: &ModInfo{Type: MultiTargetUEPlugin, Targets: []string{"Windows"}}, ModReference: "Mod", Size: int64(buf.Len())}); err == nil {
+		t.Error("expected the blacklist to fail the script while the denylist is disabled")
+	}
+}
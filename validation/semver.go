@@ -0,0 +1,96 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
+)
+
+var conditionRegex = regexp.MustCompile(`^(=|<=|<|>=|>|\^|~)?\s*v?(\S+)$`)
+
+// NormalizeCondition strictly parses a dependency condition and returns it in canonical form.
+//
+// A condition consists of one or more ranges separated by "||",
+// each range being a space or comma separated list of comparators.
+// Each comparator is an optional operator (=, <, <=, >, >=, ^, ~) followed by a full semver version.
+// Wildcards and partial versions are rejected.
+func NormalizeCondition(condition string) (string, error) {
+	condition = strings.TrimSpace(condition)
+	if condition == "" {
+		return "", errors.New("condition is empty")
+	}
+
+	ranges := strings.Split(condition, "||")
+	normalizedRanges := make([]string, len(ranges))
+
+	for i, conditionRange := range ranges {
+		comparators := strings.FieldsFunc(conditionRange, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+
+		// Allows operators to be separated from the version by spaces (e.g. ">= 1.0.0")
+		merged := make([]string, 0, len(comparators))
+		for j := 0; j < len(comparators); j++ {
+			comparator := comparators[j]
+			if strings.Trim(comparator, "=<>^~") == "" && j+1 < len(comparators) {
+				comparator += comparators[j+1]
+				j++
+			}
+			merged = append(merged, comparator)
+		}
+
+		if len(merged) == 0 {
+			return "", errors.New("condition contains an empty range: " + condition)
+		}
+
+		normalizedComparators := make([]string, len(merged))
+		for j, comparator := range merged {
+			matches := conditionRegex.FindStringSubmatch(comparator)
+			if matches == nil {
+				return "", errors.New("invalid comparator in condition: " + comparator)
+			}
+
+			version, err := semver.StrictNewVersion(matches[2])
+			if err != nil {
+				return "", errors.New("invalid version in condition: " + comparator)
+			}
+
+			operator := matches[1]
+			if operator == "=" {
+				operator = ""
+			}
+
+			normalizedComparators[j] = operator + version.String()
+		}
+
+		normalizedRanges[i] = strings.Join(normalizedComparators, " ")
+	}
+
+	normalized := strings.Join(normalizedRanges, " || ")
+
+	if _, err := semver.NewConstraint(normalized); err != nil {
+		return "", errors.Wrap(err, "invalid condition")
+	}
+
+	return normalized, nil
+}
+
+// normalizeDependencies normalizes all conditions in place.
+// Malformed conditions are kept verbatim unless withValidation is set, in which case an error is returned.
+func normalizeDependencies(dependencies map[string]string, withValidation bool) error {
+	for modReference, condition := range dependencies {
+		normalized, err := NormalizeCondition(condition)
+		if err != nil {
+			if withValidation {
+				return errors.Wrap(err, "dependency "+modReference+" has an invalid condition")
+			}
+			continue
+		}
+
+		dependencies[modReference] = normalized
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package validation
+
+import (
+	"testing"
+)
+
+func TestNormalizeCondition(t *testing.T) {
+	valid := map[string]string{
+		"1.0.0":               "1.0.0",
+		"=1.0.0":              "1.0.0",
+		"^3.4.0":              "^3.4.0",
+		">= 1.2.3":            ">=1.2.3",
+		">=1.0.0, <2.0.0":     ">=1.0.0 <2.0.0",
+		"^1.0.0 || ^2.0.0":    "^1.0.0 || ^2.0.0",
+		"~0.1.0-beta.1":       "~0.1.0-beta.1",
+		"  >=v1.0.0   <1.5.0": ">=1.0.0 <1.5.0",
+	}
+
+	for condition, expected := range valid {
+		normalized, err := NormalizeCondition(condition)
+		if err != nil {
+			t.Errorf("expected %q to be valid: %s", condition, err)
+			continue
+		}
+
+		if normalized != expected {
+			t.Errorf("expected %q to normalize to %q, got %q", condition, expected, normalized)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"*",
+		"1.x",
+		"1.0",
+		">=",
+		"^1.0.0 ||",
+		"!1.0.0",
+		"latest",
+	}
+
+	for _, condition := range invalid {
+		if normalized, err := NormalizeCondition(condition); err == nil {
+			t.Errorf("expected %q to be invalid, got %q", condition, normalized)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"archive/zip"
+	"path"
+	"sort"
+	"strings"
+)
+
+// InferLegacyTargets infers the targets supported by a mod archive that is not in the multi-target format
+// from the binaries it contains. The Unreal build outputs are distinguished by their directory and name:
+//
+//	Binaries/Win64/FactoryGame-*.dll   -> Windows
+//	Binaries/Win64/FactoryServer-*.dll -> WindowsServer
+//	Binaries/Linux/*.so                -> LinuxServer
+//
+// Archives without any binaries (e.g. pak-only mods) are assumed to target Windows.
+func InferLegacyTargets(archive *zip.Reader) []string {
+	found := make(map[string]bool)
+
+	for _, file := range archive.File {
+		dir := path.Base(path.Dir(file.Name))
+		name := path.Base(file.Name)
+
+		switch {
+		case dir == "Win64" && strings.HasSuffix(name, ".dll"):
+			if strings.HasPrefix(name, "FactoryServer-") {
+				found["WindowsServer"] = true
+			} else {
+				found["Windows"] = true
+			}
+		case dir == "Linux" && strings.HasSuffix(name, ".so"):
+			found["LinuxServer"] = true
+		}
+	}
+
+	if len(found) == 0 {
+		return []string{"Windows"}
+	}
+
+	targets := make([]string, 0, len(found))
+	for target := range found {
+		targets = append(targets, target)
+	}
+
+	sort.Strings(targets)
+
+	return targets
+}
@@ -0,0 +1,60 @@
+package validation
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestInferLegacyTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		targets []string
+	}{
+		{
+			name:    "client module",
+			files:   []string{"ExampleMod/Binaries/Win64/FactoryGame-ExampleMod-Win64-Shipping.dll"},
+			targets: []string{"Windows"},
+		},
+		{
+			name:    "server module",
+			files:   []string{"ExampleMod/Binaries/Win64/FactoryServer-ExampleMod-Win64-Shipping.dll"},
+			targets: []string{"WindowsServer"},
+		},
+		{
+			name: "client and server modules",
+			files: []string{
+				"ExampleMod/Binaries/Win64/FactoryGame-ExampleMod-Win64-Shipping.dll",
+				"ExampleMod/Binaries/Win64/FactoryServer-ExampleMod-Win64-Shipping.dll",
+				"ExampleMod/Binaries/Linux/libFactoryServer-ExampleMod-Linux-Shipping.so",
+			},
+			targets: []string{"LinuxServer", "Windows", "WindowsServer"},
+		},
+		{
+			name:    "paks only",
+			files:   []string{"ExampleMod/Content/Paks/WindowsNoEditor/ExampleMod-WindowsNoEditor.pak"},
+			targets: []string{"Windows"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files := make(map[string][]byte, len(test.files))
+			for _, name := range test.files {
+				files[name] = []byte("binary")
+			}
+
+			data := buildZip(t, files)
+			archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if targets := InferLegacyTargets(archive); !reflect.DeepEqual(targets, test.targets) {
+				t.Errorf("expected %v, got %v", test.targets, targets)
+			}
+		})
+	}
+}
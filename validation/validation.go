@@ -7,7 +7,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"io"
 	"path"
 	"path/filepath"
@@ -25,6 +24,7 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/satisfactorymodding/smr-api/db/postgres"
+	"github.com/satisfactorymodding/smr-api/generated"
 	"github.com/satisfactorymodding/smr-api/proto/parser"
 	"github.com/satisfactorymodding/smr-api/storage"
 )
@@ -51,10 +51,16 @@ type ModInfo struct {
 	ModReference         string                                `json:"mod_reference"`
 	Version              string                                `json:"version"`
 	Hash                 string                                `json:"-"`
+	Changelog            string                                `json:"-"`
+	ChangelogSource      generated.ChangelogSource             `json:"-"`
+	IconPath             string                                `json:"-"`
+	Icon                 []byte                                `json:"-"`
 	SMLVersion           string                                `json:"sml_version"`
 	Objects              []ModObject                           `json:"objects"`
 	Metadata             []map[string]map[string][]interface{} `json:"-"`
 	Targets              []string                              `json:"-"`
+	LegacyTargets        []string                              `json:"-"`
+	ContentFlags         []ContentFlag                         `json:"-"`
 	Size                 int64                                 `json:"-"`
 	Type                 ModType                               `json:"-"`
 }
@@ -81,12 +87,11 @@ func InitializeValidator() {
 	uPluginJSONSchema = gojsonschema.NewReferenceLoader("file://" + strings.ReplaceAll(absPath, "\\", "/"))
 }
 
-func ExtractModInfo(ctx context.Context, body []byte, withMetadata bool, withValidation bool, modReference string) (*ModInfo, error) {
-	if len(body) > 1000000000 {
-		return nil, errors.New("mod archive must be < 1GB")
-	}
-
-	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+// ExtractModInfo reads and validates the mod archive available through body.
+// The archive is only accessed at the offsets zip needs, so callers can pass
+// an on-disk file instead of buffering the whole archive in memory.
+func ExtractModInfo(ctx context.Context, body io.ReaderAt, size int64, withMetadata bool, withValidation bool, modReference string) (*ModInfo, error) {
+	archive, err := zip.NewReader(body, size)
 	if err != nil {
 		return nil, errors.New("invalid zip archive")
 	}
@@ -108,14 +113,14 @@ func ExtractModInfo(ctx context.Context, body []byte, withMetadata bool, withVal
 	var modInfo *ModInfo
 
 	if dataFile != nil {
-		modInfo, err = validateDataJSON(archive, dataFile, withValidation)
+		modInfo, err = validateDataJSON(archive, dataFile)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	if uPlugin != nil {
-		modInfo, err = validateUPluginJSON(archive, uPlugin, withValidation, modReference)
+		modInfo, err = validateUPluginJSON(archive, uPlugin, modReference)
 		if err != nil {
 			return nil, err
 		}
@@ -123,7 +128,7 @@ func ExtractModInfo(ctx context.Context, body []byte, withMetadata bool, withVal
 
 	if modInfo == nil {
 		// Neither data.json nor .uplugin found, try multi-target .uplugin
-		modInfo, err = validateMultiTargetPlugin(archive, withValidation, modReference)
+		modInfo, err = validateMultiTargetPlugin(archive, modReference)
 		if err != nil {
 			return nil, err
 		}
@@ -133,6 +138,32 @@ func ExtractModInfo(ctx context.Context, body []byte, withMetadata bool, withVal
 		return nil, errors.New("missing " + modReference + ".uplugin or data.json")
 	}
 
+	if withValidation {
+		if err := RunRules(&Archive{
+			Reader:       archive,
+			ModInfo:      modInfo,
+			ModReference: modReference,
+			Size:         size,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := normalizeDependencies(modInfo.Dependencies, withValidation); err != nil {
+		return nil, err
+	}
+
+	if err := normalizeDependencies(modInfo.OptionalDependencies, withValidation); err != nil {
+		return nil, err
+	}
+
+	extractChangelog(archive, modInfo)
+	extractIcon(archive, modInfo)
+
+	if smlVersion, ok := modInfo.Dependencies["SML"]; ok {
+		modInfo.SMLVersion = smlVersion
+	}
+
 	if withMetadata {
 		// Extract all possible metadata
 		conn, err := grpc.Dial(viper.GetString("extractor_host"), grpc.WithTransportCredentials(insecure.NewCredentials()))
@@ -165,9 +196,15 @@ func ExtractModInfo(ctx context.Context, body []byte, withMetadata bool, withVal
 			}
 		}
 
+		// The parser only accepts an archive in a single message, so it only gets the entries it parses
+		zipData, err := parserPayload(archive)
+		if err != nil {
+			return nil, err
+		}
+
 		parserClient := parser.NewParserClient(conn)
 		stream, err := parserClient.Parse(ctx, &parser.ParseRequest{
-			ZipData:       body,
+			ZipData:       zipData,
 			EngineVersion: engineVersion,
 		},
 			grpc.MaxCallSendMsgSize(1024*1024*1024), // 1GB
@@ -211,10 +248,10 @@ func ExtractModInfo(ctx context.Context, body []byte, withMetadata bool, withVal
 		storage.DeleteOldModAssets(modInfo.ModReference, beforeUpload)
 	}
 
-	modInfo.Size = int64(len(body))
+	modInfo.Size = size
 
 	hash := sha256.New()
-	_, err = hash.Write(body)
+	_, err = io.Copy(hash, io.NewSectionReader(body, 0, size))
 
 	if err != nil {
 		log.Err(err).Msg("error hashing pak")
@@ -233,7 +270,7 @@ func ExtractModInfo(ctx context.Context, body []byte, withMetadata bool, withVal
 	return modInfo, nil
 }
 
-func validateDataJSON(archive *zip.Reader, dataFile *zip.File, withValidation bool) (*ModInfo, error) {
+func validateDataJSON(archive *zip.Reader, dataFile *zip.File) (*ModInfo, error) {
 	rc, err := dataFile.Open()
 	defer func(rc io.ReadCloser) {
 		_ = rc.Close()
@@ -248,17 +285,6 @@ func validateDataJSON(archive *zip.Reader, dataFile *zip.File, withValidation bo
 		return nil, errors.New("invalid zip archive")
 	}
 
-	result, err := gojsonschema.Validate(dataJSONSchema, gojsonschema.NewBytesLoader(dataJSON))
-	if err != nil {
-		return nil, errors.New("data.json doesn't follow schema. please view the help page. (" + err.Error() + ")")
-	}
-
-	if withValidation {
-		if !result.Valid() {
-			return nil, errors.New("data.json doesn't follow schema. please view the help page. (" + fmt.Sprintf("%s", result.Errors()) + ")")
-		}
-	}
-
 	var modInfo ModInfo
 	err = json.Unmarshal(dataJSON, &modInfo)
 
@@ -266,12 +292,6 @@ func validateDataJSON(archive *zip.Reader, dataFile *zip.File, withValidation bo
 		return nil, errors.New("invalid data.json")
 	}
 
-	if withValidation {
-		if len(modInfo.Dependencies) == 0 {
-			return nil, errors.New("data.json doesn't contain SML as a dependency.") //nolint:revive
-		}
-	}
-
 	for key, val := range modInfo.Dependencies {
 		if key == "SML" {
 			modInfo.SMLVersion = val
@@ -282,38 +302,6 @@ func validateDataJSON(archive *zip.Reader, dataFile *zip.File, withValidation bo
 		return nil, errors.New("data.json doesn't contain SML as a dependency.") //nolint:revive
 	}
 
-	// Validate that all listed files are accounted for in data.json
-	for _, archiveFile := range archive.File {
-		if archiveFile != nil {
-			if strings.HasSuffix(archiveFile.Name, ".dll") || strings.HasSuffix(archiveFile.Name, ".pak") || strings.HasSuffix(archiveFile.Name, ".so") {
-				found := false
-				for _, obj := range modInfo.Objects {
-					if obj.Path == archiveFile.Name {
-						found = true
-						break
-					}
-				}
-				if !found {
-					return nil, errors.New("zip archive contains unreferenced objects: " + archiveFile.Name)
-				}
-			}
-		}
-	}
-
-	// Validate that all objects refer to existing files
-	for _, obj := range modInfo.Objects {
-		found := false
-		for _, archiveFile := range archive.File {
-			if obj.Path == archiveFile.Name {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return nil, errors.New("data.json objects refer to non-existent path: " + obj.Path)
-		}
-	}
-
 	modInfo.Type = DataJSON
 
 	return &modInfo, nil
@@ -321,6 +309,8 @@ func validateDataJSON(archive *zip.Reader, dataFile *zip.File, withValidation bo
 
 type UPlugin struct {
 	SemVersion *string  `json:"SemVersion"`
+	Changelog  *string  `json:"Changelog"`
+	Icon       *string  `json:"Icon"`
 	Plugins    []Plugin `json:"Plugins"`
 	Version    int64    `json:"Version"`
 }
@@ -332,7 +322,7 @@ type Plugin struct {
 	SemVersion    string `json:"SemVersion"`
 }
 
-func validateUPluginJSON(archive *zip.Reader, uPluginFile *zip.File, withValidation bool, modReference string) (*ModInfo, error) {
+func validateUPluginJSON(archive *zip.Reader, uPluginFile *zip.File, modReference string) (*ModInfo, error) {
 	rc, err := uPluginFile.Open()
 	defer func(rc io.ReadCloser) {
 		_ = rc.Close()
@@ -347,17 +337,6 @@ func validateUPluginJSON(archive *zip.Reader, uPluginFile *zip.File, withValidat
 		return nil, errors.New("invalid zip archive")
 	}
 
-	result, err := gojsonschema.Validate(uPluginJSONSchema, gojsonschema.NewBytesLoader(uPluginJSON))
-	if err != nil {
-		return nil, errors.New(uPluginFile.Name + " doesn't follow schema. please view the help page. (" + err.Error() + ")")
-	}
-
-	if withValidation {
-		if !result.Valid() {
-			return nil, errors.New(uPluginFile.Name + " doesn't follow schema. please view the help page. (" + fmt.Sprintf("%s", result.Errors()) + ")")
-		}
-	}
-
 	var uPlugin UPlugin
 	err = json.Unmarshal(uPluginJSON, &uPlugin)
 
@@ -383,6 +362,15 @@ func validateUPluginJSON(archive *zip.Reader, uPluginFile *zip.File, withValidat
 		modInfo.Version = strconv.FormatInt(uPlugin.Version, 10) + ".0.0"
 	}
 
+	if uPlugin.Changelog != nil && strings.TrimSpace(*uPlugin.Changelog) != "" {
+		modInfo.Changelog = strings.TrimSpace(*uPlugin.Changelog)
+		modInfo.ChangelogSource = generated.ChangelogSourceUplugin
+	}
+
+	if uPlugin.Icon != nil {
+		modInfo.IconPath = *uPlugin.Icon
+	}
+
 	for _, plugin := range uPlugin.Plugins {
 		if plugin.BIsBasePlugin != nil && *plugin.BIsBasePlugin {
 			continue
@@ -413,12 +401,6 @@ func validateUPluginJSON(archive *zip.Reader, uPluginFile *zip.File, withValidat
 		}
 	}
 
-	if withValidation {
-		if len(modInfo.Dependencies) == 0 {
-			return nil, errors.New(uPluginFile.Name + " doesn't contain SML as a dependency.")
-		}
-	}
-
 	for key, val := range modInfo.Dependencies {
 		if key == "SML" {
 			modInfo.SMLVersion = val
@@ -430,11 +412,12 @@ func validateUPluginJSON(archive *zip.Reader, uPluginFile *zip.File, withValidat
 	}
 
 	modInfo.Type = UEPlugin
+	modInfo.LegacyTargets = InferLegacyTargets(archive)
 
 	return &modInfo, nil
 }
 
-func validateMultiTargetPlugin(archive *zip.Reader, withValidation bool, modReference string) (*ModInfo, error) {
+func validateMultiTargetPlugin(archive *zip.Reader, modReference string) (*ModInfo, error) {
 	var targets []string
 	var uPluginFiles []*zip.File
 	for _, file := range archive.File {
@@ -444,60 +427,12 @@ func validateMultiTargetPlugin(archive *zip.Reader, withValidation bool, modRefe
 		}
 	}
 
-	if withValidation {
-		for _, target := range targets {
-			found := false
-			for _, allowedTarget := range AllowedTargets {
-				if target == allowedTarget {
-					found = true
-					break
-				}
-			}
-			if !found {
-				return nil, errors.New("multi-target plugin contains invalid target: " + target)
-			}
-		}
-
-		for _, file := range archive.File {
-			found := false
-			for _, target := range targets {
-				if strings.HasPrefix(file.Name, target+"/") {
-					found = true
-					break
-				}
-			}
-			if !found {
-				return nil, errors.New("multi-target plugin contains file outside of target directories: " + file.Name)
-			}
-		}
-	}
-
 	if len(uPluginFiles) == 0 {
 		return nil, errors.New("multi-target plugin doesn't contain any .uplugin files")
 	}
 
-	if withValidation {
-		var lastData []byte
-		for _, uPluginFile := range uPluginFiles {
-			file, err := uPluginFile.Open()
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to open .uplugin file")
-			}
-			data, err := io.ReadAll(file)
-			file.Close()
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to read .uplugin file")
-			}
-
-			if lastData != nil && !bytes.Equal(lastData, data) {
-				return nil, errors.New("multi-target plugin contains different .uplugin files")
-			}
-			lastData = data
-		}
-	}
-
-	// All the .uplugin files should be the same at this point (assuming validation is enabled)
-	modInfo, err := validateUPluginJSON(archive, uPluginFiles[0], withValidation, modReference)
+	// All the .uplugin files should be the same, which is enforced by the targets rule
+	modInfo, err := validateUPluginJSON(archive, uPluginFiles[0], modReference)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to validate multi-target plugin")
 	}
@@ -507,3 +442,45 @@ func validateMultiTargetPlugin(archive *zip.Reader, withValidation bool, modRefe
 
 	return modInfo, nil
 }
+
+// Extensions of the archive entries read by the parser
+var parserExtensions = map[string]bool{
+	".pak":     true,
+	".utoc":    true,
+	".ucas":    true,
+	".uplugin": true,
+}
+
+// parserPayload copies the entries read by the parser into a new archive, streaming them without recompression.
+// Binaries and debug symbols, which make up most of an archive, are left behind.
+func parserPayload(archive *zip.Reader) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+
+	for _, file := range archive.File {
+		if !parserExtensions[strings.ToLower(path.Ext(file.Name))] {
+			continue
+		}
+
+		raw, err := file.OpenRaw()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed reading "+file.Name)
+		}
+
+		header := file.FileHeader
+		entry, err := writer.CreateRaw(&header)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed copying "+file.Name)
+		}
+
+		if _, err := io.Copy(entry, raw); err != nil {
+			return nil, errors.Wrap(err, "failed copying "+file.Name)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed closing parser archive")
+	}
+
+	return buffer.Bytes(), nil
+}
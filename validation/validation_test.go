@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+)
+
+func buildZip(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	writer := zip.NewWriter(buf)
+	for name, data := range files {
+		entry, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := entry.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestParserPayloadKeepsParsedEntries(t *testing.T) {
+	data := buildZip(t, map[string][]byte{
+		"Windows/Mod.uplugin":                   []byte("{}"),
+		"Windows/Content/Paks/Windows/Mod.pak":  []byte("pak"),
+		"Windows/Content/Paks/Windows/Mod.utoc": []byte("utoc"),
+		"Windows/Binaries/Win64/Mod.dll":        []byte("dll"),
+		"Windows/Binaries/Win64/Mod.pdb":        []byte("pdb"),
+	})
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := parserPayload(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(payload), int64(len(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := make(map[string]string)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[file.Name] = string(content)
+	}
+
+	expected := map[string]string{
+		"Windows/Mod.uplugin":                   "{}",
+		"Windows/Content/Paks/Windows/Mod.pak":  "pak",
+		"Windows/Content/Paks/Windows/Mod.utoc": "utoc",
+	}
+
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d entries, got %v", len(expected), contents)
+	}
+
+	for name, content := range expected {
+		if contents[name] != content {
+			t.Errorf("expected %s to contain %q, got %q", name, content, contents[name])
+		}
+	}
+}
@@ -22,6 +22,15 @@ func InitializeVirusTotal() {
 	}
 }
 
+// PingVirusTotal checks that the virustotal key is accepted, by looking up the user it belongs to
+func PingVirusTotal() error {
+	if _, err := client.GetObject(vt.URL("users/%s", viper.GetString("virustotal.key"))); err != nil {
+		return errors.Wrap(err, "failed to reach virustotal")
+	}
+
+	return nil
+}
+
 type AnalysisResults struct {
 	Attributes struct {
 		Stats *struct {